@@ -3,27 +3,90 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/go-clix/cli"
 	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grafana"
 	"github.com/grafana/grizzly/pkg/grizzly"
 	"github.com/spf13/viper"
 )
 
 // LoggingOpts contains logging options (used in all commands)
 type LoggingOpts struct {
-	LogLevel string
+	LogLevel   string
+	NoColor    bool
+	Quiet      bool
+	Porcelain  bool
+	LogHTTP    bool
+	HTTPRecord string
+	HTTPReplay string
+	Env        string // only declared for help purposes: the profile is selected before parsing flags
 }
 
 // Opts contains options for most Grizzly commands
 type Opts struct {
 	LoggingOpts
-	Directory    bool // Deprecated: now is gathered with os.Stat(<resource-path>)
-	JsonnetPaths []string
-	Targets      []string
-	OutputFormat string
-	IsDir        bool // used internally to denote that the resource path argument pointed at a directory
+	Directory     bool // Deprecated: now is gathered with os.Stat(<resource-path>)
+	JsonnetPaths  []string
+	Targets       []string
+	OutputFormat  string
+	FolderMapPath string
+	Ignore        []string
+	// Poll detects changes by polling files every PollInterval, rather than
+	// with file system events
+	Poll         bool
+	PollInterval time.Duration
+	// ExtVars are the external variables given to Jsonnet with --ext-str
+	// and --ext-code
+	ExtVars map[string]grizzly.ExtVar
+	// TLAs are the top-level arguments given to Jsonnet with --tla-str and
+	// --tla-code
+	TLAs map[string]grizzly.ExtVar
+	// NoJsonnetCache evaluates Jsonnet files every time, instead of reusing
+	// the cached output of unchanged files
+	NoJsonnetCache bool
+	// UpdateImportsLock records the checksums of the remote Jsonnet imports
+	// in the lock file, instead of checking them
+	UpdateImportsLock bool
+	// HelmValues are the values files overlaid when rendering Helm charts
+	HelmValues []string
+	// Values is the values file YAML files are executed with, as Go
+	// templates
+	Values string
+	// JsonnetEnv are the environment variables readable by Jsonnet files,
+	// from the current context
+	JsonnetEnv []string
+	// ParseWorkers is the number of files parsed concurrently
+	ParseWorkers int
+	// LargeFileSize is the size in bytes above which the specs of the
+	// resources of a file are only loaded when needed
+	LargeFileSize int64
+	// ContinueOnError reports all the errors at the end instead of stopping
+	// at the first one
+	ContinueOnError bool
+	IsDir           bool // used internally to denote that the resource path argument pointed at a directory
+
+	// Used for reporting warnings, turned into failures when strict
+	Strict       bool
+	WarningsFile string
+
+	// Used for reporting the outcome of each resource to CI systems
+	JUnitFile string
+
+	// ExecServer is the URL of the remote execution server diffing and
+	// applying resources on behalf of the client
+	ExecServer string
+
+	// Workspaces are the workspaces of the project configuration the
+	// command runs for, instead of a resource path
+	Workspaces []string
+
+	// Used for bounding the time spent reaching remote endpoints
+	Timeout         time.Duration
+	ResourceTimeout time.Duration
+	RequestTimeout  time.Duration
 
 	// Used for supporting resources without envelopes
 	OnlySpec     bool
@@ -31,6 +94,21 @@ type Opts struct {
 	FolderUID    string
 	ResourceKind string
 
+	// Used for marking the dashboards managed by Grizzly with a tag, and only
+	// listing or pulling these
+	ManagedTag       string
+	OnlyManaged      bool
+	IncludeUnmanaged bool
+
+	// Used for caching the remote state of resources
+	Offline  bool
+	CacheDir string
+
+	// Used for detecting remote changes since resources were last applied or
+	// pulled
+	VersionLockPath string
+	Force           bool
+
 	// Used for supporting the proxy server
 	OpenBrowser bool
 	ProxyPort   int
@@ -238,6 +316,10 @@ func checkCmd(registry grizzly.Registry) *cli.Command {
 			}
 			fmt.Printf("Online: %s\n", onlineMsg)
 
+			if grafanaProvider, ok := provider.(*grafana.Provider); ok && status.Online {
+				printCapabilities(grafanaProvider.Capabilities())
+			}
+
 			if i != len(registry.Providers)-1 {
 				fmt.Printf("\n")
 			}
@@ -247,3 +329,22 @@ func checkCmd(registry grizzly.Registry) *cli.Command {
 	}
 	return initialiseLogging(cmd, &opts)
 }
+
+func printCapabilities(capabilities *grafana.Capabilities) {
+	yellow := color.New(color.FgYellow).SprintfFunc()
+	green := color.New(color.FgGreen).SprintfFunc()
+
+	if !capabilities.Detected() {
+		fmt.Printf("Version: %s\n", yellow("unknown, features are not checked"))
+		return
+	}
+
+	fmt.Printf("Version: %s\n", green(capabilities.Version))
+	for _, feature := range grafana.Features {
+		supported := green("true")
+		if !capabilities.Supports(feature) {
+			supported = yellow("false")
+		}
+		fmt.Printf("Supports %s: %s\n", feature.Name, supported)
+	}
+}
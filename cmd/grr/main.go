@@ -3,12 +3,15 @@ package main
 import (
 	"errors"
 	"os"
+	"strings"
 
 	"github.com/go-clix/cli"
 	"github.com/grafana/grizzly/pkg/config"
 	"github.com/grafana/grizzly/pkg/grafana"
 	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/loki"
 	"github.com/grafana/grizzly/pkg/mimir"
+	"github.com/grafana/grizzly/pkg/oncall"
 	"github.com/grafana/grizzly/pkg/syntheticmonitoring"
 	log "github.com/sirupsen/logrus"
 )
@@ -48,6 +51,12 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	// the profile needs to be known before the flags are parsed, as it
+	// determines the context used to create the registry
+	if err := config.LoadProject(".", profileFromArgs(os.Args[1:])); err != nil {
+		log.Fatalln(err)
+	}
+
 	context, err := config.CurrentContext()
 	if err != nil {
 		log.Fatalln(err)
@@ -58,16 +67,32 @@ func main() {
 	rootCmd.AddCommand(
 		getCmd(registry),
 		listCmd(registry),
+		statsCmd(registry),
+		searchCmd(registry),
+		duplicatesCmd(registry),
+		convertCmd(registry),
+		housekeepingCmd(registry),
 		pullCmd(registry),
+		instantiateCmd(registry),
+		vendorCmd(),
+		fmtCmd(),
 		showCmd(registry),
 		diffCmd(registry),
 		applyCmd(registry),
 		watchCmd(registry),
 		exportCmd(registry),
+		backupCmd(registry),
+		restoreCmd(registry),
+		testCmd(registry),
+		lintCmd(registry),
+		routeCmd(registry),
 		snapshotCmd(registry),
+		previewCmd(registry),
 		providersCmd(registry),
+		functionsCmd(),
 		configCmd(registry),
 		serveCmd(registry),
+		serverCmd(registry),
 		selfUpdateCmd(),
 	)
 
@@ -86,8 +111,28 @@ func createRegistry(context *config.Context) grizzly.Registry {
 	providers := []grizzly.Provider{
 		grafana.NewProvider(&context.Grafana),
 		mimir.NewProvider(&context.Mimir),
+		loki.NewProvider(&context.Loki),
 		syntheticmonitoring.NewProvider(&context.SyntheticMonitoring),
+		oncall.NewProvider(&context.OnCall),
 	}
 
 	return grizzly.NewRegistry(providers)
 }
+
+// profileFromArgs returns the profile selected with the `--env` flag, or the
+// GRIZZLY_ENV environment variable.
+func profileFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--env="); ok {
+			return value
+		}
+		if arg == "--env" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+
+	return os.Getenv("GRIZZLY_ENV")
+}
@@ -8,6 +8,9 @@ import (
 
 	"github.com/go-clix/cli"
 	"github.com/grafana/grizzly/internal/grizzly"
+	"github.com/grafana/grizzly/pkg/config"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/mod/semver"
 )
 
 func selfUpdateCmd() *cli.Command {
@@ -17,9 +20,29 @@ func selfUpdateCmd() *cli.Command {
 		Args:  cli.ArgsExact(0),
 	}
 	var opts LoggingOpts
+	version := cmd.Flags().String("version", "", "version to install (e.g. v0.4.1), instead of the latest one")
+	skipChecksum := cmd.Flags().Bool("skip-checksum", false, "don't verify the checksum of the downloaded binary")
 
 	cmd.Run = func(cmd *cli.Command, args []string) error {
 		updater := grizzly.NewSelfUpdater(http.DefaultClient)
+		updater.VerifyChecksums(!*skipChecksum)
+
+		if *version != "" {
+			err := updater.UpdateTo(context.Background(), Version, *version)
+			if errors.Is(err, grizzly.ErrAlreadyAtVersion) {
+				fmt.Printf("Current version is already %s\n", Version)
+				return nil
+			}
+			if errors.Is(err, grizzly.ErrChecksumNotFound) {
+				return fmt.Errorf("%w. Use --skip-checksum to update anyway", err)
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Successfully updated to version %s\n", *version)
+			return nil
+		}
 
 		newVersion, err := updater.UpdateSelf(context.Background(), Version)
 		if errors.Is(err, grizzly.ErrNextVersionIsMajorBump) {
@@ -29,6 +52,9 @@ func selfUpdateCmd() *cli.Command {
 			fmt.Printf("Current version is the latest: %s\n", Version)
 			return nil
 		}
+		if errors.Is(err, grizzly.ErrChecksumNotFound) {
+			return fmt.Errorf("%w. Use --skip-checksum to update anyway", err)
+		}
 		if err != nil {
 			return err
 		}
@@ -40,3 +66,19 @@ func selfUpdateCmd() *cli.Command {
 
 	return initialiseLogging(cmd, &opts)
 }
+
+func checkRequiredVersion(project *config.Project) error {
+	if project.RequiredVersion == "" {
+		return nil
+	}
+	if !semver.IsValid(Version) {
+		log.Debugf("Skipping required version check for development version %s", Version)
+		return nil
+	}
+
+	if err := grizzly.CheckRequiredVersion(Version, project.RequiredVersion); err != nil {
+		return fmt.Errorf("%w (from %s). Use `grr self-update --version <version>` to install a matching version", err, project.Path)
+	}
+
+	return nil
+}
@@ -1,13 +1,24 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/go-clix/cli"
 	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grafana"
 	"github.com/grafana/grizzly/pkg/grizzly"
 	"github.com/grafana/grizzly/pkg/grizzly/notifier"
 	"github.com/hashicorp/go-multierror"
@@ -31,10 +42,15 @@ func getCmd(registry grizzly.Registry) *cli.Command {
 		if err != nil {
 			return err
 		}
-		return grizzly.Get(registry, uid, onlySpec, format)
+		cachedRegistry, err := withRemoteCache(registry, opts)
+		if err != nil {
+			return err
+		}
+		return grizzly.Get(cachedRegistry, uid, onlySpec, format)
 	}
 	cmd = initialiseOnlySpec(cmd, &opts)
-	return initialiseCmd(cmd, &opts)
+	cmd = initialiseRemoteCache(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
 }
 
 func listCmd(registry grizzly.Registry) *cli.Command {
@@ -62,7 +78,16 @@ func listCmd(registry grizzly.Registry) *cli.Command {
 				return nil
 			}
 
-			return grizzly.ListRemote(registry, targets, format)
+			cachedRegistry, err := withRemoteCache(registry, opts)
+			if err != nil {
+				return err
+			}
+			managedRegistry, err := withManaged(cachedRegistry, opts)
+			if err != nil {
+				return err
+			}
+
+			return grizzly.ListRemote(managedRegistry, targets, format)
 		}
 		if len(args) == 0 {
 			notifier.Error(nil, "resource-path required when listing local resources")
@@ -74,218 +99,379 @@ func listCmd(registry grizzly.Registry) *cli.Command {
 			return err
 		}
 
-		resources, err := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths).Parse(args[0], grizzly.ParserOptions{
+		resources, parseErr := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...).Parse(args[0], grizzly.ParserOptions{
 			DefaultResourceKind: resourceKind,
 			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
 		})
-		if err != nil {
+		if err := reportParseErrors(opts, parseErr); err != nil {
+			return err
+		}
+
+		if err := grizzly.List(registry, resources, format); err != nil {
 			return err
 		}
 
-		return grizzly.List(registry, resources, format)
+		// parse errors are already displayed
+		if parseErr != nil {
+			return silentError{Err: parseErr}
+		}
+		return nil
 	}
-	return initialiseCmd(cmd, &opts)
+	cmd = initialiseContinueOnError(cmd, &opts)
+	cmd = initialiseRemoteCache(cmd, &opts)
+	cmd = initialiseManaged(cmd, &opts)
+	cmd = initialiseLargeFiles(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
 }
 
-func pullCmd(registry grizzly.Registry) *cli.Command {
+func statsCmd(registry grizzly.Registry) *cli.Command {
 	cmd := &cli.Command{
-		Use:   "pull <resource-path>",
-		Short: "Pulls remote resources and writes them to local sources",
+		Use:   "stats [-r] <resource-path>",
+		Short: "report counts and sizes of resources, per kind and folder",
 		Args:  cli.ArgsExact(1),
 	}
 	var opts Opts
-	var continueOnError bool
-
-	cmd.Flags().BoolVarP(&continueOnError, "continue-on-error", "e", false, "don't stop pulling on error")
-
-	eventsRecorder := grizzly.NewWriterRecorder(os.Stdout, getEventFormatter())
+	var isRemote bool
+	var format string
+	var top int
+	cmd.Flags().BoolVarP(&isRemote, "remote", "r", false, "compare with remote resources, to tell managed resources from unmanaged ones")
+	cmd.Flags().StringVarP(&format, "format", "f", "default", "format of the report, one of default, json, yaml")
+	cmd.Flags().IntVar(&top, "top", 10, "number of largest resources to report")
 
 	cmd.Run = func(cmd *cli.Command, args []string) error {
-		format, onlySpec, err := getOutputFormat(opts)
+		currentContext, err := config.CurrentContext()
 		if err != nil {
 			return err
 		}
+		targets := currentContext.GetTargets(opts.Targets)
 
-		currentContext, err := config.CurrentContext()
+		resourceKind, folderUID, err := getOnlySpec(opts)
 		if err != nil {
 			return err
 		}
 
-		targets := currentContext.GetTargets(opts.Targets)
-
-		err = grizzly.Pull(registry, args[0], onlySpec, format, targets, continueOnError, eventsRecorder)
+		resources, parseErr := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...).Parse(args[0], grizzly.ParserOptions{
+			DefaultResourceKind: resourceKind,
+			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
+		})
+		if err := reportParseErrors(opts, parseErr); err != nil {
+			return err
+		}
 
-		notifier.Info(nil, eventsRecorder.Summary().AsString("resource"))
+		cachedRegistry, err := withRemoteCache(registry, opts)
+		if err != nil {
+			return err
+		}
 
-		// errors are already displayed by the `eventsRecorder`, so we return a
-		// "silent" one to ensure that the exit code will be non-zero
+		stats, err := grizzly.ComputeStats(cachedRegistry, resources, isRemote, targets, top)
+		if err != nil {
+			return err
+		}
+		output, err := stats.Format(format)
 		if err != nil {
-			return silentError{Err: err}
+			return err
 		}
+		fmt.Println(string(output))
 
+		// parse errors are already displayed
+		if parseErr != nil {
+			return silentError{Err: parseErr}
+		}
 		return nil
 	}
-
-	cmd = initialiseOnlySpec(cmd, &opts)
-	return initialiseCmd(cmd, &opts)
+	cmd = initialiseContinueOnError(cmd, &opts)
+	cmd = initialiseRemoteCache(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
 }
 
-func showCmd(registry grizzly.Registry) *cli.Command {
+func duplicatesCmd(registry grizzly.Registry) *cli.Command {
 	cmd := &cli.Command{
-		Use:   "show <resource-path>",
-		Short: "show list of resource types and UIDs",
+		Use:   "duplicates <resource-path>",
+		Short: "find panels repeated across dashboards, and extract them as library panels",
 		Args:  cli.ArgsExact(1),
 	}
 	var opts Opts
+	var format string
+	var minDashboards int
+	var extractDir string
+	var libraryFolder string
+	cmd.Flags().StringVarP(&format, "format", "f", "default", "format of the report, one of default, json, yaml")
+	cmd.Flags().IntVar(&minDashboards, "min-dashboards", 2, "minimum number of dashboards a panel must be repeated on")
+	cmd.Flags().StringVar(&extractDir, "extract", "", "directory to write the library panels and the dashboards using them to")
+	cmd.Flags().StringVar(&libraryFolder, "library-folder", "", "UID of the folder of the extracted library panels")
 
 	cmd.Run = func(cmd *cli.Command, args []string) error {
-		resourceKind, folderUID, err := getOnlySpec(opts)
+		currentContext, err := config.CurrentContext()
 		if err != nil {
 			return err
 		}
+		targets := currentContext.GetTargets(opts.Targets)
 
-		currentContext, err := config.CurrentContext()
+		resourceKind, folderUID, err := getOnlySpec(opts)
 		if err != nil {
 			return err
 		}
-		targets := currentContext.GetTargets(opts.Targets)
 
-		resources, err := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths).Parse(args[0], grizzly.ParserOptions{
+		resources, parseErr := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...).Parse(args[0], grizzly.ParserOptions{
 			DefaultResourceKind: resourceKind,
 			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
 		})
-		if err != nil {
+		if err := reportParseErrors(opts, parseErr); err != nil {
 			return err
 		}
 
-		format, _, err := getOutputFormat(opts)
+		duplicates, err := grafana.FindDuplicatePanels(resources, minDashboards)
+		if err != nil {
+			return err
+		}
+		output, err := duplicates.Format(format)
 		if err != nil {
 			return err
 		}
-		return grizzly.Show(registry, resources, format)
+		fmt.Println(string(output))
+
+		if extractDir != "" && len(duplicates) > 0 {
+			extracted, err := grafana.ExtractLibraryPanels(resources, duplicates, libraryFolder)
+			if err != nil {
+				return err
+			}
+			outputFormat, _, err := getOutputFormat(opts)
+			if err != nil {
+				return err
+			}
+			if err := grizzly.Export(registry, extractDir, extracted, false, outputFormat); err != nil {
+				return err
+			}
+			notifier.Info(nil, fmt.Sprintf("Extracted %s to %s", grizzly.Pluraliser(len(duplicates), "library panel"), extractDir))
+		}
+
+		// parse errors are already displayed
+		if parseErr != nil {
+			return silentError{Err: parseErr}
+		}
+		return nil
 	}
-	cmd = initialiseOnlySpec(cmd, &opts)
-	return initialiseCmd(cmd, &opts)
+	cmd = initialiseContinueOnError(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
 }
 
-func diffCmd(registry grizzly.Registry) *cli.Command {
+func convertCmd(registry grizzly.Registry) *cli.Command {
 	cmd := &cli.Command{
-		Use:   "diff <resource-path>",
-		Short: "compare local and remote resources",
-		Args:  cli.ArgsExact(1),
+		Use:   "convert <sub-command>",
+		Short: "scaffold resources of a kind from resources of another",
+		Args:  cli.ArgsExact(0),
+	}
+	cmd.AddCommand(convertAlertRulesCmd(registry))
+	return cmd
+}
+
+func convertAlertRulesCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "alert-rules <resource-path> <output-dir>",
+		Short: "scaffold alert rules from the thresholds of dashboard panels",
+		Args:  cli.ArgsExact(2),
 	}
 	var opts Opts
+	var options grafana.PanelAlertOptions
+	cmd.Flags().StringVar(&options.Folder, "rules-folder", "", "UID of the folder of the alert rules, the folder of each dashboard by default")
+	cmd.Flags().Int64Var(&options.Interval, "interval", 60, "evaluation interval of the rule groups, in seconds")
+	cmd.Flags().StringVar(&options.For, "for", "5m", "time a threshold must be crossed for before rules fire")
 
 	cmd.Run = func(cmd *cli.Command, args []string) error {
-		resourceKind, folderUID, err := getOnlySpec(opts)
+		currentContext, err := config.CurrentContext()
 		if err != nil {
 			return err
 		}
+		targets := currentContext.GetTargets(opts.Targets)
 
-		currentContext, err := config.CurrentContext()
+		resourceKind, folderUID, err := getOnlySpec(opts)
 		if err != nil {
 			return err
 		}
 
-		targets := currentContext.GetTargets(opts.Targets)
-
-		resources, err := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths).Parse(args[0], grizzly.ParserOptions{
+		resources, parseErr := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...).Parse(args[0], grizzly.ParserOptions{
 			DefaultResourceKind: resourceKind,
 			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
 		})
-		if err != nil {
+		if err := reportParseErrors(opts, parseErr); err != nil {
 			return err
 		}
 
-		format, onlySpec, err := getOutputFormat(opts)
+		groups, warnings := grafana.PanelAlertRules(resources, options)
+		for _, warning := range warnings {
+			grizzly.RecordWarning(registry.Context(), warning)
+		}
+		outputFormat, _, err := getOutputFormat(opts)
 		if err != nil {
 			return err
 		}
+		if err := grizzly.Export(registry, args[1], groups, false, outputFormat); err != nil {
+			return err
+		}
+		notifier.Info(nil, fmt.Sprintf("Scaffolded %s to %s", grizzly.Pluraliser(groups.Len(), "alert rule group"), args[1]))
 
-		return grizzly.Diff(registry, resources, onlySpec, format)
+		// parse errors are already displayed
+		if parseErr != nil {
+			return silentError{Err: parseErr}
+		}
+		return nil
 	}
-	return initialiseCmd(cmd, &opts)
+	cmd = initialiseContinueOnError(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
 }
 
-func applyCmd(registry grizzly.Registry) *cli.Command {
+func housekeepingCmd(registry grizzly.Registry) *cli.Command {
 	cmd := &cli.Command{
-		Use:     "apply <resource-path>",
-		Aliases: []string{"push"},
-		Short:   "apply local resources to remote endpoints",
-		Args:    cli.ArgsExact(1),
+		Use:   "housekeeping",
+		Short: "report dashboards not used for a while, and folders without activity",
+		Args:  cli.ArgsExact(0),
 	}
-	var opts Opts
-	var continueOnError bool
+	var opts LoggingOpts
+	var format string
+	var days int
+	cmd.Flags().StringVarP(&format, "format", "f", "default", "format of the report, one of default, json, yaml, targets")
+	cmd.Flags().IntVar(&days, "days", 90, "number of days without activity after which dashboards are flagged")
 
-	cmd.Flags().BoolVarP(&continueOnError, "continue-on-error", "e", false, "don't stop apply on first error")
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		if days <= 0 {
+			return fmt.Errorf("--days must be positive")
+		}
 
-	eventsRecorder := grizzly.NewWriterRecorder(os.Stdout, getEventFormatter())
+		var grafanaProvider *grafana.Provider
+		for _, provider := range registry.Providers {
+			if p, ok := provider.(*grafana.Provider); ok {
+				grafanaProvider = p
+			}
+		}
+		if grafanaProvider == nil {
+			return fmt.Errorf("housekeeping requires the Grafana provider")
+		}
 
-	cmd.Run = func(cmd *cli.Command, args []string) error {
-		resourceKind, folderUID, err := getOnlySpec(opts)
+		activity, err := grafanaProvider.DashboardsActivity()
 		if err != nil {
 			return err
 		}
+		if !activity.ViewsKnown {
+			log.Warn("Grafana doesn't provide usage insights: dashboards are flagged by their last update instead of their last view")
+		}
 
-		currentContext, err := config.CurrentContext()
+		report := grafana.NewHousekeepingReport(activity, time.Now().AddDate(0, 0, -days))
+		output, err := report.Format(format)
 		if err != nil {
 			return err
 		}
+		fmt.Println(string(output))
+		return nil
+	}
+	return initialiseLogging(cmd, &opts)
+}
+
+func searchCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "search [-r] <text> [<resource-path>]",
+		Short: "search local (and remote) resources for values containing some text",
+		Args:  cli.ArgsRange(1, 2),
+	}
+	var opts Opts
+	var isRemote bool
+	var format string
+	var query grizzly.SearchQuery
+	cmd.Flags().BoolVarP(&isRemote, "remote", "r", false, "search remote resources too")
+	cmd.Flags().StringVar(&query.Path, "path", "", "JSONPath expression restricting the values searched, e.g. '$..targets[*].expr'")
+	cmd.Flags().BoolVarP(&query.IgnoreCase, "ignore-case", "i", false, "ignore case when searching")
+	cmd.Flags().StringVarP(&format, "format", "f", "default", "format of the matches, one of default, json, yaml")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		query.Text = args[0]
+		if len(args) == 1 && !isRemote {
+			return fmt.Errorf("a resource-path is required, unless searching remote resources with -r")
+		}
 
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
 		targets := currentContext.GetTargets(opts.Targets)
-		parser := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, grizzly.ParserContinueOnError(continueOnError))
 
-		resources, parseErr := parser.Parse(args[0], grizzly.ParserOptions{
-			DefaultResourceKind: resourceKind,
-			DefaultFolderUID:    folderUID,
-		})
+		var matches []grizzly.SearchMatch
+		var parseErr error
+		if len(args) == 2 {
+			resourceKind, folderUID, err := getOnlySpec(opts)
+			if err != nil {
+				return err
+			}
 
-		if parseErr != nil {
-			var parseErrors []error
-			if merr, ok := parseErr.(*multierror.Error); ok {
-				parseErrors = merr.Errors
-			} else {
-				parseErrors = []error{parseErr}
+			var resources grizzly.Resources
+			resources, parseErr = grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...).Parse(args[1], grizzly.ParserOptions{
+				DefaultResourceKind: resourceKind,
+				DefaultFolderUID:    folderUID,
+				ExtVars:             opts.ExtVars,
+				TLAs:                opts.TLAs,
+			})
+			if err := reportParseErrors(opts, parseErr); err != nil {
+				return err
 			}
 
-			for _, e := range parseErrors {
-				notifier.Error(nil, e.Error())
+			matches, err = grizzly.Search(resources, func(resource grizzly.Resource) string { return resource.Source.Path }, query)
+			if err != nil {
+				return err
 			}
 		}
 
-		if parseErr != nil && !continueOnError {
-			return silentError{Err: parseErr}
+		if isRemote {
+			cachedRegistry, err := withRemoteCache(registry, opts)
+			if err != nil {
+				return err
+			}
+			remoteMatches, err := grizzly.SearchRemote(cachedRegistry, targets, query)
+			if err != nil {
+				return err
+			}
+			matches = append(matches, remoteMatches...)
 		}
 
-		notifier.Info(nil, fmt.Sprintf("Applying %s", grizzly.Pluraliser(resources.Len(), "resource")))
-
-		applyErr := grizzly.Apply(registry, resources, continueOnError, eventsRecorder)
-
-		notifier.Info(nil, eventsRecorder.Summary().AsString("resource"))
-
-		// errors are already displayed by the `eventsRecorder`, so we return a
-		// "silent" one to ensure that the exit code will be non-zero
-		if parseErr != nil || applyErr != nil {
-			return silentError{Err: errors.Join(parseErr, applyErr)}
+		output, err := grizzly.FormatSearchMatches(matches, format)
+		if err != nil {
+			return err
 		}
+		fmt.Println(string(output))
 
+		// parse errors are already displayed
+		if parseErr != nil {
+			return silentError{Err: parseErr}
+		}
 		return nil
 	}
-
-	cmd = initialiseOnlySpec(cmd, &opts)
-	return initialiseCmd(cmd, &opts)
+	cmd = initialiseContinueOnError(cmd, &opts)
+	cmd = initialiseRemoteCache(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
 }
 
-func watchCmd(registry grizzly.Registry) *cli.Command {
+func pullCmd(registry grizzly.Registry) *cli.Command {
 	cmd := &cli.Command{
-		Use:   "watch <dir-to-watch> <resource-path>",
-		Short: "watch dir recursively for file changes and apply selected resource path",
-		Args:  cli.ArgsExact(2),
+		Use:   "pull <resource-path>",
+		Short: "Pulls remote resources and writes them to local sources",
+		Args:  cli.ArgsExact(1),
 	}
 	var opts Opts
+	var continueOnError bool
+	var nameTemplate string
+
+	cmd.Flags().BoolVarP(&continueOnError, "continue-on-error", "e", false, "don't stop pulling on error")
+	cmd.Flags().StringVar(&nameTemplate, "name-template", "", "template naming the files of pulled resources, e.g. '{{ .kind }}/{{ .folder }}/{{ .uid }}.{{ .extension }}'")
 
 	cmd.Run = func(cmd *cli.Command, args []string) error {
-		resourceKind, folderUID, err := getOnlySpec(opts)
+		eventsRecorder := grizzly.NewWriterRecorder(os.Stdout, getEventFormatter(opts.LoggingOpts))
+
+		format, onlySpec, err := getOutputFormat(opts)
 		if err != nil {
 			return err
 		}
@@ -294,236 +480,2040 @@ func watchCmd(registry grizzly.Registry) *cli.Command {
 		if err != nil {
 			return err
 		}
-		targets := currentContext.GetTargets(opts.Targets)
-
-		watchDir, resourcePath := args[0], args[1]
 
-		trailRecorder := grizzly.NewWriterRecorder(os.Stdout, grizzly.EventToPlainText)
+		targets := currentContext.GetTargets(opts.Targets)
 
-		parser := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, grizzly.ParserContinueOnError(true))
-		parserOpts := grizzly.ParserOptions{
-			DefaultResourceKind: resourceKind,
-			DefaultFolderUID:    folderUID,
+		var filenameTemplate *grizzly.FilenameTemplate
+		if nameTemplate != "" {
+			filenameTemplate, err = grizzly.ParseFilenameTemplate(nameTemplate)
+			if err != nil {
+				return err
+			}
 		}
-		return grizzly.Watch(registry, watchDir, resourcePath, parser, parserOpts, trailRecorder)
-	}
-	cmd = initialiseOnlySpec(cmd, &opts)
-	return initialiseCmd(cmd, &opts)
-}
-
-func snapshotCmd(registry grizzly.Registry) *cli.Command {
-	cmd := &cli.Command{
-		Use:   "snapshot <resource-path>",
-		Short: "upload a snapshot to preview resources",
-		Args:  cli.ArgsExact(1),
-	}
-	var opts Opts
-	expires := cmd.Flags().IntP("expires", "e", 0, "when the preview should expire. Default 0 (never)")
 
-	cmd.Run = func(cmd *cli.Command, args []string) error {
-		resourceKind, folderUID, err := getOnlySpec(opts)
+		cachedRegistry, err := withRemoteCache(registry, opts)
+		if err != nil {
+			return err
+		}
+		managedRegistry, err := withManaged(cachedRegistry, opts)
 		if err != nil {
 			return err
 		}
 
-		currentContext, err := config.CurrentContext()
+		lockedRegistry, saveVersions, err := withVersionLock(managedRegistry, opts)
 		if err != nil {
 			return err
 		}
-		targets := currentContext.GetTargets(opts.Targets)
-		parser := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, grizzly.ParserContinueOnError(false))
 
-		resources, parseErr := parser.Parse(args[0], grizzly.ParserOptions{
-			DefaultResourceKind: resourceKind,
-			DefaultFolderUID:    folderUID,
-		})
+		err = grizzly.Pull(lockedRegistry, args[0], onlySpec, format, filenameTemplate, targets, continueOnError, eventsRecorder)
+		saveErr := saveVersions()
 
-		if parseErr != nil {
-			var parseErrors []error
-			if merr, ok := parseErr.(*multierror.Error); ok {
-				parseErrors = merr.Errors
-			} else {
-				parseErrors = []error{parseErr}
-			}
+		notifier.Info(nil, eventsRecorder.Summary().AsString("resource"))
 
-			for _, e := range parseErrors {
-				notifier.Error(nil, e.Error())
-			}
-			return silentError{Err: parseErr}
-		}
-		return grizzly.Snapshot(registry, resources, *expires)
+		return commandError(err, saveErr)
 	}
-	return initialiseCmd(cmd, &opts)
+
+	cmd = initialiseOnlySpec(cmd, &opts)
+	cmd = initialiseFormat(cmd, &opts)
+	cmd = initialiseRemoteCache(cmd, &opts)
+	cmd = initialiseManaged(cmd, &opts)
+	cmd = initialiseVersionLock(cmd, &opts)
+	cmd = initialiseTimeouts(cmd, &opts, &registry)
+	return initialiseCmd(cmd, &opts, &registry)
 }
 
-func serveCmd(registry grizzly.Registry) *cli.Command {
+func instantiateCmd(registry grizzly.Registry) *cli.Command {
 	cmd := &cli.Command{
-		Use:   "serve <resources>",
-		Short: "Run Grizzly server",
-		Args:  cli.ArgsAny(),
+		Use:   "instantiate <module> [<resource-path>]",
+		Short: "render the resources of a module, for the given inputs, to local sources",
+		Args:  cli.ArgsRange(1, 2),
 	}
 	var opts Opts
+	values := cmd.Flags().StringArray("set", nil, "value of an input of the module, as <input>=<value>. Can be repeated")
 
 	cmd.Run = func(cmd *cli.Command, args []string) error {
-		resourceKind, folderUID, err := getOnlySpec(opts)
+		eventsRecorder := grizzly.NewWriterRecorder(os.Stdout, getEventFormatter(opts.LoggingOpts))
+
+		format, onlySpec, err := getOutputFormat(opts)
 		if err != nil {
 			return err
 		}
 
-		currentContext, err := config.CurrentContext()
+		outputDir := "."
+		if len(args) > 1 {
+			outputDir = args[1]
+		}
+
+		inputs := map[string]string{}
+		for _, value := range *values {
+			name, value, found := strings.Cut(value, "=")
+			if !found || name == "" {
+				return fmt.Errorf("invalid input %q: expected <input>=<value>", value)
+			}
+			inputs[name] = value
+		}
+
+		dir, cleanup, err := grizzly.FetchModule(args[0])
 		if err != nil {
 			return err
 		}
+		defer cleanup()
 
-		resourcesPath := ""
-		watchPaths := args
+		module, err := grizzly.LoadModule(dir)
+		if err != nil {
+			return err
+		}
+		resolved, err := module.ResolveInputs(inputs)
+		if err != nil {
+			return err
+		}
 
-		if len(args) > 0 {
-			resourcesPath = args[0]
+		err = grizzly.InstantiateModule(registry, module, resolved, outputDir, format, onlySpec, eventsRecorder)
+		if err != nil {
+			return err
+		}
+
+		notifier.Info(nil, eventsRecorder.Summary().AsString("resource"))
+		return nil
+	}
+
+	cmd = initialiseOnlySpec(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func vendorCmd() *cli.Command {
+	cmd := &cli.Command{
+		Use:   "vendor [<dir>]",
+		Short: "install the Jsonnet dependencies declared in jsonnetfile.json into the vendor directory",
+		Args:  cli.ArgsRange(0, 1),
+	}
+	var opts LoggingOpts
+	update := cmd.Flags().Bool("update", false, "install the latest versions of the dependencies, ignoring jsonnetfile.lock.json")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		dependencies, err := grizzly.Vendor(dir, *update)
+		for _, dependency := range dependencies {
+			if dependency.Source.Local != nil {
+				notifier.Info(notifier.SimpleString(dependency.Path()), "linked to "+dependency.Source.Local.Directory)
+				continue
+			}
+			notifier.Info(notifier.SimpleString(dependency.Path()), "vendored at "+dependency.Version)
+		}
+
+		return err
+	}
+
+	return initialiseLogging(cmd, &opts)
+}
+
+func fmtCmd() *cli.Command {
+	cmd := &cli.Command{
+		Use:   "fmt [<path>]",
+		Short: "format Jsonnet and YAML source files in a canonical style",
+		Args:  cli.ArgsRange(0, 1),
+	}
+	var opts LoggingOpts
+	check := cmd.Flags().Bool("check", false, "only list the files which aren't formatted, failing if any")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		changed, err := grizzly.FormatSources(path, *check)
+		for _, file := range changed {
+			fmt.Println(file)
+		}
+		if err != nil {
+			return err
+		}
+		if *check && len(changed) > 0 {
+			return silentError{Err: fmt.Errorf("%s not formatted", grizzly.Pluraliser(len(changed), "file"))}
+		}
+
+		return nil
+	}
+
+	return initialiseLogging(cmd, &opts)
+}
+
+func backupCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "backup <dir>",
+		Short: "write all remote resources to a directory, with a manifest, to be restored with restore",
+		Args:  cli.ArgsExact(1),
+	}
+	var opts Opts
+	var settings backupSettings
+
+	cmd.Flags().BoolVarP(&opts.ContinueOnError, "continue-on-error", "e", false, "don't stop backup on first error")
+	cmd.Flags().DurationVar(&settings.every, "every", 0, "keep running, and back up at this interval (e.g. 24h), in timestamped subdirectories of <dir>")
+	cmd.Flags().IntVar(&settings.retention.Daily, "keep-daily", 0, "number of days for which the latest backup is kept, older ones are deleted")
+	cmd.Flags().IntVar(&settings.retention.Weekly, "keep-weekly", 0, "number of weeks for which the latest backup is kept, older ones are deleted")
+	cmd.Flags().BoolVar(&settings.compress, "compress", false, "compress backups as .tar.gz archives")
+	cmd.Flags().StringVar(&settings.upload, "upload", "", "upload compressed backups to an S3-compatible bucket, e.g. s3://bucket/prefix or gs://bucket/prefix")
+	cmd.Flags().StringVar(&settings.endpoint, "upload-endpoint", "", "URL of the S3-compatible object storage, e.g. to use MinIO")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		if settings.upload != "" && !settings.compress {
+			return fmt.Errorf("--upload requires --compress")
+		}
+		if settings.every > 0 && opts.Timeout > 0 {
+			return fmt.Errorf("--timeout can't be used with --every: use --resource-timeout or --request-timeout instead")
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+		targets := currentContext.GetTargets(opts.Targets)
+
+		if settings.upload != "" {
+			settings.store, err = grizzly.NewObjectStorageBackupStore(settings.upload, settings.endpoint)
+			if err != nil {
+				return err
+			}
+		}
+
+		backup := func() error {
+			return runBackup(registry, args[0], currentContext.Name, targets, opts, settings)
+		}
+		if settings.every <= 0 {
+			return backup()
+		}
+
+		// scheduled backups keep running when one of them fails
+		log.Infof("Backing up every %s to %s", settings.every, args[0])
+		for {
+			if err := backup(); err != nil {
+				notifier.Error(nil, fmt.Sprintf("backup failed: %s", err))
+			}
+			time.Sleep(settings.every)
+		}
+	}
+
+	cmd = initialiseTimeouts(cmd, &opts, &registry)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+// backupSettings describes how backups are scheduled, rotated and stored
+type backupSettings struct {
+	every     time.Duration
+	retention grizzly.RetentionPolicy
+	compress  bool
+	upload    string
+	endpoint  string
+	store     *grizzly.ObjectStorageBackupStore
+}
+
+// rotated tells whether backups are written to timestamped subdirectories
+// of the backup directory
+func (settings backupSettings) rotated() bool {
+	return settings.every > 0 || settings.compress || settings.retention.Enabled()
+}
+
+func runBackup(registry grizzly.Registry, dir string, context string, targets []string, opts Opts, settings backupSettings) error {
+	eventsRecorder := grizzly.NewWriterRecorder(os.Stdout, getEventFormatter(opts.LoggingOpts))
+
+	manifest := grizzly.BackupManifest{
+		CreatedAt:      time.Now().UTC(),
+		GrizzlyVersion: Version,
+		Context:        context,
+	}
+	path := dir
+	if settings.rotated() {
+		path = filepath.Join(dir, grizzly.BackupName(manifest.CreatedAt))
+	}
+
+	manifest, backupErr := grizzly.Backup(registry, path, targets, manifest, opts.ContinueOnError, eventsRecorder)
+	if backupErr != nil {
+		// errors are already displayed by the `eventsRecorder`, so we return a
+		// "silent" one to ensure that the exit code will be non-zero
+		return silentError{Err: backupErr}
+	}
+
+	if settings.compress {
+		archive, err := grizzly.ArchiveBackup(path)
+		if err != nil {
+			return err
+		}
+		path = archive
+	}
+	notifier.Info(nil, fmt.Sprintf("Backed up %s to %s", grizzly.Pluraliser(len(manifest.Resources), "resource"), path))
+
+	if settings.store != nil {
+		if err := settings.store.Upload(path); err != nil {
+			return fmt.Errorf("uploading backup: %w", err)
+		}
+		notifier.Info(nil, fmt.Sprintf("Uploaded %s to %s", filepath.Base(path), settings.upload))
+	}
+
+	stores := []grizzly.BackupStore{grizzly.LocalBackupStore{Dir: dir}}
+	if settings.store != nil {
+		stores = append(stores, settings.store)
+	}
+	for _, store := range stores {
+		expired, err := grizzly.ApplyRetention(store, settings.retention)
+		for _, name := range expired {
+			notifier.Info(nil, fmt.Sprintf("Deleted expired backup %s", name))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func restoreCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "restore <dir|archive>",
+		Short: "apply the resources of a backup made with backup",
+		Args:  cli.ArgsExact(1),
+	}
+	var opts Opts
+
+	cmd.Flags().BoolVarP(&opts.ContinueOnError, "continue-on-error", "e", false, "don't stop restore on first error")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		eventsRecorder := grizzly.NewWriterRecorder(os.Stdout, getEventFormatter(opts.LoggingOpts))
+
+		dir := args[0]
+		if strings.HasSuffix(dir, grizzly.BackupArchiveExtension) {
+			extracted, err := os.MkdirTemp("", "grizzly-restore-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(extracted)
+
+			if err := grizzly.ExtractBackup(dir, extracted); err != nil {
+				return err
+			}
+			dir = extracted
+		}
+
+		manifest, err := grizzly.ReadBackupManifest(dir)
+		if err != nil {
+			return err
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+		targets := currentContext.GetTargets(opts.Targets)
+
+		parser := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts, grizzly.ParserIgnore(append([]string{config.ProjectConfigFile, grizzly.BackupManifestFile}, opts.Ignore...)))...)
+		resources, parseErr := parser.Parse(dir, grizzly.ParserOptions{})
+		if err := reportParseErrors(opts, parseErr); err != nil {
+			return err
+		}
+
+		notifier.Info(nil, fmt.Sprintf("Restoring %s, backed up on %s", grizzly.Pluraliser(resources.Len(), "resource"), manifest.CreatedAt.Format(time.RFC3339)))
+
+		restoreErr := grizzly.Restore(registry, manifest, resources, opts.ContinueOnError, eventsRecorder)
+
+		notifier.Info(nil, eventsRecorder.Summary().AsString("resource"))
+
+		// errors are already displayed by the `eventsRecorder`, so we return a
+		// "silent" one to ensure that the exit code will be non-zero
+		if parseErr != nil || restoreErr != nil {
+			return silentError{Err: errors.Join(parseErr, restoreErr)}
+		}
+		return nil
+	}
+
+	cmd = initialiseTimeouts(cmd, &opts, &registry)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func showCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "show <resource-path>",
+		Short: "show list of resource types and UIDs",
+		Args:  cli.ArgsExact(1),
+	}
+	var opts Opts
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		resourceKind, folderUID, err := getOnlySpec(opts)
+		if err != nil {
+			return err
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+		targets := currentContext.GetTargets(opts.Targets)
+
+		resources, parseErr := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...).Parse(args[0], grizzly.ParserOptions{
+			DefaultResourceKind: resourceKind,
+			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
+		})
+		if err := reportParseErrors(opts, parseErr); err != nil {
+			return err
+		}
+
+		format, _, err := getOutputFormat(opts)
+		if err != nil {
+			return err
+		}
+		if err := grizzly.Show(registry, resources, format); err != nil {
+			return err
+		}
+
+		// parse errors are already displayed
+		if parseErr != nil {
+			return silentError{Err: parseErr}
+		}
+		return nil
+	}
+	cmd = initialiseOnlySpec(cmd, &opts)
+	cmd = initialiseContinueOnError(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func diffCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "diff [<resource-path>]",
+		Short: "compare local and remote resources",
+		Args:  cli.ArgsRange(0, 1),
+	}
+	var opts Opts
+	theme := cmd.Flags().String("theme", notifier.DefaultDiffTheme, "color theme used to render differences, one of default, high-contrast")
+	summaryOnly := cmd.Flags().Bool("summary-only", false, "only print the summary of the changes, per kind")
+	againstRef := cmd.Flags().String("against-ref", "", "compare the resources rendered from the sources at a git revision, e.g. a release tag, with the working tree")
+	againstRemote := cmd.Flags().BoolP("remote", "r", false, "with --against-ref, compare the resources at the revision with the remote ones instead")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		if err := notifier.SetDiffTheme(*theme); err != nil {
+			return err
+		}
+		if *summaryOnly && opts.Porcelain {
+			return fmt.Errorf("--summary-only can't be used with --porcelain")
+		}
+		if *againstRemote && *againstRef == "" {
+			return fmt.Errorf("--remote requires --against-ref")
+		}
+		if *againstRef != "" && args[0] == grizzly.StdinPath {
+			return fmt.Errorf("--against-ref can't be used with resources read from stdin")
+		}
+		if *againstRef != "" && opts.ExecServer != "" {
+			return fmt.Errorf("--against-ref can't be used with --exec-server")
+		}
+
+		resourceKind, folderUID, err := getOnlySpec(opts)
+		if err != nil {
+			return err
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+
+		targets := currentContext.GetTargets(opts.Targets)
+		parse := func(path string) (grizzly.Resources, error) {
+			return grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...).Parse(path, grizzly.ParserOptions{
+				DefaultResourceKind: resourceKind,
+				DefaultFolderUID:    folderUID,
+				ExtVars:             opts.ExtVars,
+				TLAs:                opts.TLAs,
+			})
+		}
+
+		var resources grizzly.Resources
+		var parseErr error
+		if !*againstRemote {
+			resources, parseErr = parse(args[0])
+			if err := reportParseErrors(opts, parseErr); err != nil {
+				return err
+			}
+		}
+
+		format, onlySpec, err := getOutputFormat(opts)
+		if err != nil {
+			return err
+		}
+
+		if *againstRef != "" {
+			path, cleanup, err := grizzly.RevisionSources(args[0], *againstRef)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			base, baseErr := parse(path)
+			if err := reportParseErrors(opts, baseErr); err != nil {
+				return err
+			}
+			if *againstRemote {
+				resources, parseErr = base, baseErr
+			} else {
+				return diffRevision(registry, base, resources, *againstRef, onlySpec, format, *summaryOnly, opts, errors.Join(parseErr, baseErr))
+			}
+		}
+
+		cachedRegistry, err := withRemoteCache(registry, opts)
+		if err != nil {
+			return err
+		}
+
+		// the remote execution server holds the credentials of the instances
+		if err := checkGrafanaVersion(registry, resources, !opts.Offline && opts.ExecServer == ""); err != nil {
+			return err
+		}
+
+		// resources that would be added or updated by an apply are failures
+		report := grizzly.NewJUnitReport("grr diff", grizzly.ResourceChanged, grizzly.ResourceNotFound)
+		summary := grizzly.NewDiffSummary()
+		if *summaryOnly {
+			notifier.SetOutputMode(notifier.QuietOutput)
+		}
+		var diffErr error
+		if opts.ExecServer != "" {
+			client, err := execClient(registry, opts)
+			if err != nil {
+				return err
+			}
+			diffErr = client.Diff(resources, onlySpec, format, eventRecorders{report, summary})
+		} else {
+			diffErr = grizzly.Diff(cachedRegistry, resources, onlySpec, format, eventRecorders{report, summary})
+		}
+		switch {
+		case summary.String() == "":
+		case *summaryOnly:
+			fmt.Println(summary)
+		case !opts.Quiet && !opts.Porcelain:
+			fmt.Printf("\nSummary:\n%s\n", summary)
+		}
+		if err := writeJUnitReport(opts, report); err != nil {
+			return err
+		}
+		if diffErr != nil {
+			return diffErr
+		}
+
+		// parse errors are already displayed
+		if parseErr != nil {
+			return silentError{Err: parseErr}
+		}
+		return nil
+	}
+	cmd = initialiseWorkspaces(cmd, &opts, &registry)
+	cmd = initialiseRemoteCache(cmd, &opts)
+	cmd = initialiseContinueOnError(cmd, &opts)
+	cmd = initialiseJUnit(cmd, &opts)
+	cmd = initialiseTimeouts(cmd, &opts, &registry)
+	cmd = initialiseLargeFiles(cmd, &opts)
+	cmd = initialiseExecServer(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func applyCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:     "apply [<resource-path>]",
+		Aliases: []string{"push"},
+		Short:   "apply local resources to remote endpoints",
+		Args:    cli.ArgsRange(0, 1),
+	}
+	var opts Opts
+
+	cmd.Flags().BoolVarP(&opts.ContinueOnError, "continue-on-error", "e", false, "don't stop apply on first error")
+	stamp := cmd.Flags().Bool("stamp", false, "record the commit, pipeline URL and time of the apply along with resources, where supported (e.g. in the version history of dashboards)")
+	stampCommit := cmd.Flags().String("stamp-commit", "", "commit recorded by --stamp, detected from the CI environment or the Git repository by default")
+	stampPipelineURL := cmd.Flags().String("stamp-pipeline-url", "", "pipeline URL recorded by --stamp, detected from the CI environment by default")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "overwrite remote resources modified since they were last applied or pulled")
+	skipUnchanged := cmd.Flags().Bool("skip-unchanged", false, "skip the resources unchanged since they were last applied, without reaching remote endpoints (changes made remotely since are not detected)")
+	checksumsFile := cmd.Flags().String("checksums-file", "", "file recording the checksums of applied resources for --skip-unchanged (defaults to a per-context user cache directory)")
+	resume := cmd.Flags().Bool("resume", false, "resume the last failed or interrupted apply, skipping the resources it applied since unchanged")
+	circuitBreaker := cmd.Flags().Int("circuit-breaker", 0, "number of resources of a kind failing in a row after which the remaining ones are skipped, while other kinds proceed (0 disables it)")
+	canarySelector := cmd.Flags().String("canary", "", "apply the resources selected first, as folder=<uid> or a target (e.g. Dashboard/canary-*), then the others once these soaked")
+	canarySoak := cmd.Flags().Duration("canary-soak", 5*time.Minute, "time to wait after applying the canary, before checking its gates")
+	canaryAlerts := cmd.Flags().StringSlice("canary-alert", nil, "UID of a Grafana alert rule that must not be firing once the canary soaked, for the apply to proceed")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		eventsRecorder := grizzly.NewWriterRecorder(os.Stdout, getEventFormatter(opts.LoggingOpts))
+
+		resourceKind, folderUID, err := getOnlySpec(opts)
+		if err != nil {
+			return err
+		}
+
+		if *stamp {
+			applyStamp := grizzly.DetectApplyStamp(time.Now())
+			if *stampCommit != "" {
+				applyStamp.Commit = *stampCommit
+			}
+			if *stampPipelineURL != "" {
+				applyStamp.PipelineURL = *stampPipelineURL
+			}
+			registry = registry.WithContext(grizzly.WithApplyStamp(registry.Context(), applyStamp))
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+
+		targets := currentContext.GetTargets(opts.Targets)
+		folderMap, err := grizzly.LoadFolderMap(opts.FolderMapPath)
+		if err != nil {
+			return err
+		}
+		parser := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts, grizzly.ParserRecordFolders(folderMap))...)
+
+		resources, parseErr := parser.Parse(args[0], grizzly.ParserOptions{
+			DefaultResourceKind: resourceKind,
+			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
+		})
+		if err := reportParseErrors(opts, parseErr); err != nil {
+			return err
+		}
+
+		if opts.ExecServer != "" {
+			if *stamp || *skipUnchanged || *resume || *canarySelector != "" {
+				return fmt.Errorf("--exec-server can't be used with --stamp, --skip-unchanged, --resume or --canary")
+			}
+			if err := checkGrafanaVersion(registry, resources, false); err != nil {
+				return err
+			}
+			client, err := execClient(registry, opts)
+			if err != nil {
+				return err
+			}
+
+			notifier.Info(nil, fmt.Sprintf("Applying %s with %s", grizzly.Pluraliser(resources.Len(), "resource"), opts.ExecServer))
+			applyErr := client.Apply(resources, opts.ContinueOnError, eventsRecorder)
+			saveErr := saveFolderMap(folderMap, applyErr)
+			notifier.Info(nil, eventsRecorder.Summary().AsString("resource"))
+			return commandError(errors.Join(parseErr, applyErr), saveErr)
+		}
+
+		if err := checkGrafanaVersion(registry, resources, true); err != nil {
+			return err
+		}
+
+		lockedRegistry, saveVersions, err := withVersionLock(registry, opts)
+		if err != nil {
+			return err
+		}
+
+		var applyOpts []grizzly.ApplyOpt
+		saveChecksums := func() error { return nil }
+		if *skipUnchanged {
+			path := *checksumsFile
+			if path == "" {
+				dir, err := grizzly.DefaultRemoteCacheDir(currentContext.Name)
+				if err != nil {
+					return err
+				}
+				path = filepath.Join(dir, grizzly.AppliedChecksumsFile)
+			}
+			checksums, err := grizzly.LoadAppliedChecksums(path)
+			if err != nil {
+				return err
+			}
+			applyOpts = append(applyOpts, grizzly.ApplySkipUnchanged(checksums))
+			saveChecksums = checksums.Save
+		}
+
+		cacheDir, err := grizzly.DefaultRemoteCacheDir(currentContext.Name)
+		if err != nil {
+			return err
+		}
+		progress, err := grizzly.OpenApplyProgress(filepath.Join(cacheDir, grizzly.ApplyProgressFile(args[0])), *resume)
+		if err != nil {
+			return err
+		}
+		applyOpts = append(applyOpts, grizzly.ApplyRecordProgress(progress))
+		if *resume {
+			notifier.Info(nil, fmt.Sprintf("Resuming the last apply: %s already applied", grizzly.Pluraliser(progress.Resumed(), "resource")))
+		}
+
+		if *circuitBreaker > 0 {
+			applyOpts = append(applyOpts, grizzly.ApplyCircuitBreaker(*circuitBreaker))
+		}
+		if project := config.CurrentProject(); project != nil && len(project.Apply.Timeouts) > 0 {
+			timeouts, err := kindTimeouts(registry, project.Apply.Timeouts)
+			if err != nil {
+				return fmt.Errorf("invalid timeouts in %s: %w", project.Path, err)
+			}
+			applyOpts = append(applyOpts, grizzly.ApplyKindTimeouts(timeouts))
+		}
+
+		notifier.Info(nil, fmt.Sprintf("Applying %s", grizzly.Pluraliser(resources.Len(), "resource")))
+
+		var applyErr error
+		if *canarySelector != "" {
+			canary, err := newCanary(registry, *canarySelector, *canarySoak, *canaryAlerts)
+			if err != nil {
+				return err
+			}
+			applyErr = grizzly.ApplyCanary(lockedRegistry, resources, canary, opts.ContinueOnError, eventsRecorder, applyOpts...)
+		} else {
+			applyErr = grizzly.Apply(lockedRegistry, resources, opts.ContinueOnError, eventsRecorder, applyOpts...)
+		}
+		saveErr := errors.Join(saveVersions(), saveChecksums(), saveFolderMap(folderMap, applyErr), progress.Close(parseErr != nil || applyErr != nil))
+
+		notifier.Info(nil, eventsRecorder.Summary().AsString("resource"))
+
+		return commandError(errors.Join(parseErr, applyErr), saveErr)
+	}
+
+	cmd = initialiseWorkspaces(cmd, &opts, &registry)
+	cmd = initialiseOnlySpec(cmd, &opts)
+	cmd = initialiseVersionLock(cmd, &opts)
+	cmd = initialiseTimeouts(cmd, &opts, &registry)
+	cmd = initialiseExecServer(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func watchCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "watch <dir-to-watch> <resource-path>",
+		Short: "watch dir recursively for file changes and apply selected resource path",
+		Args:  cli.ArgsExact(2),
+	}
+	var opts Opts
+	execs := cmd.Flags().StringArray("exec", nil, "command to run on changes, before applying resources. Can be repeated")
+	apply := cmd.Flags().Bool("apply", true, "apply resources on changes. With --apply=false, only the --exec commands are run")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		resourceKind, folderUID, err := getOnlySpec(opts)
+		if err != nil {
+			return err
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+		targets := currentContext.GetTargets(opts.Targets)
+
+		watchDir, resourcePath := args[0], args[1]
+		if resourcePath == grizzly.StdinPath {
+			return fmt.Errorf("watch can't read resources from the standard input")
+		}
+		folderMap, err := grizzly.LoadFolderMap(opts.FolderMapPath)
+		if err != nil {
+			return err
+		}
+
+		trailRecorder := grizzly.NewWriterRecorder(os.Stdout, grizzly.EventToPlainText)
+
+		parser := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts, grizzly.ParserContinueOnError(true), grizzly.ParserRecordFolders(folderMap))...)
+		parserOpts := grizzly.ParserOptions{
+			DefaultResourceKind: resourceKind,
+			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
+		}
+		hooks := grizzly.WatchHooks{Exec: *execs, SkipApply: !*apply, Applied: folderMap.Save}
+		if project := config.CurrentProject(); project != nil && !cmd.Flags().Changed("exec") {
+			hooks.Exec = project.Watch.Exec
+		}
+		if hooks.SkipApply && len(hooks.Exec) == 0 {
+			return fmt.Errorf("nothing to do on changes: --apply=false requires commands to run with --exec")
+		}
+
+		return grizzly.Watch(registry, watchDir, resourcePath, parser, parserOpts, hooks, trailRecorder, watcherOpts(opts)...)
+	}
+	cmd = initialiseOnlySpec(cmd, &opts)
+	cmd = initialisePolling(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func snapshotCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "snapshot <resource-path>",
+		Short: "upload a snapshot to preview resources",
+		Args:  cli.ArgsExact(1),
+	}
+	var opts Opts
+	expires := cmd.Flags().IntP("expires", "e", 0, "when the preview should expire. Default 0 (never)")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		resourceKind, folderUID, err := getOnlySpec(opts)
+		if err != nil {
+			return err
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+		targets := currentContext.GetTargets(opts.Targets)
+		parser := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts, grizzly.ParserContinueOnError(false))...)
+
+		resources, parseErr := parser.Parse(args[0], grizzly.ParserOptions{
+			DefaultResourceKind: resourceKind,
+			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
+		})
+
+		if parseErr != nil {
+			var parseErrors []error
+			if merr, ok := parseErr.(*multierror.Error); ok {
+				parseErrors = merr.Errors
+			} else {
+				parseErrors = []error{parseErr}
+			}
+
+			for _, e := range parseErrors {
+				notifier.Error(nil, e.Error())
+			}
+			return silentError{Err: parseErr}
+		}
+		return grizzly.Snapshot(registry, resources, *expires)
+	}
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func previewCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "preview <resource-path>",
+		Short: "render resources as images, e.g. to review dashboards in CI",
+		Args:  cli.ArgsExact(1),
+	}
+	var opts Opts
+	var previewOpts grizzly.PreviewOptions
+
+	cmd.Flags().BoolVarP(&opts.ContinueOnError, "continue-on-error", "e", false, "don't stop rendering on first error")
+	cmd.Flags().StringVar(&previewOpts.Format, "format", "png", "format of the images. Only png is supported")
+	outDir := cmd.Flags().String("out", "previews", "directory the images are written to")
+	cmd.Flags().IntVar(&previewOpts.Width, "width", 1000, "width of the images, in pixels")
+	cmd.Flags().IntVar(&previewOpts.Height, "height", 500, "height of the images, in pixels")
+	cmd.Flags().StringVar(&previewOpts.Theme, "theme", "light", "theme of the images: light or dark")
+	var baseline grizzly.Baseline
+	cmd.Flags().StringVar(&baseline.Dir, "baseline", "", "directory of the baseline images to compare the rendered images with")
+	cmd.Flags().Float64Var(&baseline.Threshold, "threshold", 0, "ratio of pixels (between 0 and 1) that may differ from the baseline")
+	cmd.Flags().BoolVarP(&baseline.Update, "update-baseline", "u", false, "write the rendered images as baseline instead of comparing them")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		if previewOpts.Format != "png" {
+			return fmt.Errorf("unsupported format %q: only png is supported", previewOpts.Format)
+		}
+		if baseline.Threshold < 0 || baseline.Threshold > 1 {
+			return fmt.Errorf("--threshold must be between 0 and 1")
+		}
+		var compareWith *grizzly.Baseline
+		if baseline.Dir != "" {
+			compareWith = &baseline
+		} else if baseline.Update {
+			return fmt.Errorf("--update-baseline requires --baseline")
+		}
+
+		eventsRecorder := grizzly.NewWriterRecorder(os.Stdout, getEventFormatter(opts.LoggingOpts))
+
+		resourceKind, folderUID, err := getOnlySpec(opts)
+		if err != nil {
+			return err
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+
+		targets := currentContext.GetTargets(opts.Targets)
+		parser := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...)
+
+		resources, parseErr := parser.Parse(args[0], grizzly.ParserOptions{
+			DefaultResourceKind: resourceKind,
+			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
+		})
+		if err := reportParseErrors(opts, parseErr); err != nil {
+			return err
+		}
+
+		if err := checkGrafanaVersion(registry, resources, true); err != nil {
+			return err
+		}
+
+		previewErr := grizzly.Preview(registry, resources, previewOpts, *outDir, compareWith, opts.ContinueOnError, eventsRecorder)
+
+		notifier.Info(nil, eventsRecorder.Summary().AsString("resource"))
+
+		// errors are already displayed by the `eventsRecorder`, so we return a
+		// "silent" one to ensure that the exit code will be non-zero
+		if parseErr != nil || previewErr != nil {
+			return silentError{Err: errors.Join(parseErr, previewErr)}
+		}
+
+		return nil
+	}
+
+	cmd = initialiseOnlySpec(cmd, &opts)
+	cmd = initialiseTimeouts(cmd, &opts, &registry)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func serveCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "serve <resources>",
+		Short: "Run Grizzly server",
+		Args:  cli.ArgsAny(),
+	}
+	var opts Opts
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		resourceKind, folderUID, err := getOnlySpec(opts)
+		if err != nil {
+			return err
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+
+		resourcesPath := ""
+		watchPaths := args
+
+		if len(args) > 0 {
+			resourcesPath = args[0]
+		}
+		if resourcesPath == grizzly.StdinPath {
+			return fmt.Errorf("serve can't read resources from the standard input")
 		}
 
 		if opts.WatchScript != "" {
 			resourcesPath = ""
 		}
-		if len(args) > 1 {
-			watchPaths = args[1:]
+		if len(args) > 1 {
+			watchPaths = args[1:]
+		}
+
+		targets := currentContext.GetTargets(opts.Targets)
+		parser := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts, grizzly.ParserContinueOnError(true))...)
+		parserOpts := grizzly.ParserOptions{
+			DefaultResourceKind: resourceKind,
+			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
+		}
+
+		format, onlySpec, err := getOutputFormat(opts)
+		if err != nil {
+			return err
+		}
+
+		server, err := grizzly.NewGrizzlyServer(registry, resourcesPath, opts.ProxyPort)
+		if err != nil {
+			return err
+		}
+		server.SetParser(parser, parserOpts)
+		server.SetContext(currentContext.Name)
+		server.SetFormatting(onlySpec, format)
+		if opts.Watch {
+			server.Watch(watchPaths)
+			if opts.WatchScript != "" {
+				server.WatchScript(opts.WatchScript)
+			}
+		}
+		server.SetWatcherOpts(watcherOpts(opts)...)
+		if opts.OpenBrowser {
+			server.OpenBrowser()
+		}
+		return server.Start()
+	}
+	cmd.Flags().BoolVarP(&opts.Watch, "watch", "w", false, "Watch filesystem for changes")
+	cmd.Flags().BoolVarP(&opts.OpenBrowser, "open-browser", "b", false, "Open Grizzly in default browser")
+	cmd.Flags().IntVarP(&opts.ProxyPort, "port", "p", 8080, "Port on which the server will listen")
+	cmd.Flags().StringVarP(&opts.WatchScript, "script", "S", "", "Script to execute on filesystem change")
+	cmd = initialiseOnlySpec(cmd, &opts)
+	cmd = initialisePolling(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func serverCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "server",
+		Short: "run a remote execution server, diffing and applying the resources submitted by clients with the credentials of the current context",
+		Args:  cli.ArgsExact(0),
+	}
+	var opts Opts
+	listen := cmd.Flags().String("listen", "127.0.0.1:8443", "address the server listens on")
+	tokensFile := cmd.Flags().String("tokens-file", "", "file listing the tokens clients authenticate with, one per line")
+	tlsCert := cmd.Flags().String("tls-cert", "", "certificate the server is served with over TLS")
+	tlsKey := cmd.Flags().String("tls-key", "", "key of the TLS certificate")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		if *tokensFile == "" {
+			return fmt.Errorf("--tokens-file is required: clients must authenticate")
+		}
+		if (*tlsCert == "") != (*tlsKey == "") {
+			return fmt.Errorf("--tls-cert and --tls-key must be given together")
+		}
+		tokens, err := grizzly.ReadExecTokens(*tokensFile)
+		if err != nil {
+			return err
+		}
+		server, err := grizzly.NewExecServer(registry, tokens)
+		if err != nil {
+			return err
+		}
+
+		httpServer := &http.Server{
+			Addr:              *listen,
+			Handler:           server.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		if *tlsCert != "" {
+			notifier.Info(nil, fmt.Sprintf("Remote execution server listening on https://%s", *listen))
+			return httpServer.ListenAndServeTLS(*tlsCert, *tlsKey)
+		}
+		notifier.Warn(nil, "Serving without TLS: tokens and resources are sent in clear text")
+		notifier.Info(nil, fmt.Sprintf("Remote execution server listening on http://%s", *listen))
+		return httpServer.ListenAndServe()
+	}
+
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func exportCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "export <resource-path> <dashboard-dir>",
+		Short: "render resources and save to a directory",
+		Args:  cli.ArgsExact(2),
+	}
+	var opts Opts
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		resourcePath := args[0]
+		dashboardDir := args[1]
+		resourceKind, folderUID, err := getOnlySpec(opts)
+		if err != nil {
+			return err
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+
+		targets := currentContext.GetTargets(opts.Targets)
+
+		resources, parseErr := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...).Parse(resourcePath, grizzly.ParserOptions{
+			DefaultResourceKind: resourceKind,
+			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
+		})
+		if err := reportParseErrors(opts, parseErr); err != nil {
+			return err
+		}
+
+		format, onlySpec, err := getOutputFormat(opts)
+		if err != nil {
+			return err
+		}
+
+		if err := checkGrafanaVersion(registry, resources, false); err != nil {
+			return err
+		}
+
+		if err := grizzly.Export(registry, dashboardDir, resources, onlySpec, format); err != nil {
+			return err
+		}
+
+		// parse errors are already displayed
+		if parseErr != nil {
+			return silentError{Err: parseErr}
+		}
+		return nil
+	}
+	cmd = initialiseOnlySpec(cmd, &opts)
+	cmd = initialiseFormat(cmd, &opts)
+	cmd = initialiseContinueOnError(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func testCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "test <resource-path>",
+		Short: "compare rendered resources with golden files",
+		Args:  cli.ArgsExact(1),
+	}
+	var opts Opts
+	var goldenDir string
+	var update bool
+
+	cmd.Flags().StringVar(&goldenDir, "golden-dir", "golden", "directory containing the golden files")
+	cmd.Flags().BoolVarP(&update, "update", "u", false, "write the golden files instead of comparing against them")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		eventsRecorder := grizzly.NewWriterRecorder(os.Stdout, getEventFormatter(opts.LoggingOpts))
+
+		resourceKind, folderUID, err := getOnlySpec(opts)
+		if err != nil {
+			return err
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+
+		targets := currentContext.GetTargets(opts.Targets)
+
+		resources, parseErr := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...).Parse(args[0], grizzly.ParserOptions{
+			DefaultResourceKind: resourceKind,
+			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
+		})
+		if err := reportParseErrors(opts, parseErr); err != nil {
+			return err
+		}
+
+		if err := checkGrafanaVersion(registry, resources, false); err != nil {
+			return err
+		}
+
+		results, err := grizzly.CheckGolden(registry, goldenDir, resources, update)
+		if err != nil {
+			return err
+		}
+
+		report := grizzly.NewJUnitReport("grr test")
+		failed := 0
+		for _, result := range results {
+			event := grizzly.Event{
+				Type:        result.Event,
+				ResourceRef: result.ResourceRef,
+				Details:     result.Details,
+			}
+			eventsRecorder.Record(event)
+			if result.Diff != "" {
+				event.Details += "\n" + result.Diff
+			}
+			report.Record(event)
+			if result.Event == grizzly.ResourceFailure {
+				failed++
+				if result.Diff != "" && !opts.Quiet && !opts.Porcelain {
+					fmt.Println(notifier.ColorizeDiff(result.Diff))
+				}
+			}
+		}
+
+		notifier.Info(nil, eventsRecorder.Summary().AsString("golden file"))
+		if err := writeJUnitReport(opts, report); err != nil {
+			return err
+		}
+
+		// failures are already displayed by the `eventsRecorder`
+		if failed > 0 {
+			return silentError{Err: fmt.Errorf("%w: %d failures, run with --update to accept the changes", grizzly.ErrGoldenMismatch, failed)}
+		}
+		if parseErr != nil {
+			return silentError{Err: parseErr}
+		}
+
+		return nil
+	}
+	cmd = initialiseOnlySpec(cmd, &opts)
+	cmd = initialiseContinueOnError(cmd, &opts)
+	cmd = initialiseJUnit(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func lintCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "lint <resource-path>",
+		Short: "check resources for common mistakes, without contacting remote endpoints",
+		Args:  cli.ArgsExact(1),
+	}
+	var opts Opts
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		resourceKind, folderUID, err := getOnlySpec(opts)
+		if err != nil {
+			return err
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+
+		targets := currentContext.GetTargets(opts.Targets)
+
+		resources, parseErr := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...).Parse(args[0], grizzly.ParserOptions{
+			DefaultResourceKind: resourceKind,
+			DefaultFolderUID:    folderUID,
+			ExtVars:             opts.ExtVars,
+			TLAs:                opts.TLAs,
+		})
+		if err := reportParseErrors(opts, parseErr); err != nil {
+			return err
+		}
+
+		if err := checkGrafanaVersion(registry, resources, false); err != nil {
+			return err
+		}
+		for _, warning := range grafana.CheckTemplateVariables(resources) {
+			grizzly.RecordWarning(registry.Context(), warning)
+		}
+		if err := writeJUnitReport(opts, lintReport(resources, grizzly.Warnings(registry.Context()))); err != nil {
+			return err
+		}
+
+		// problems are displayed along with warnings
+		if problems := len(grizzly.Warnings(registry.Context())); problems > 0 {
+			return silentError{Err: fmt.Errorf("%s found", grizzly.Pluraliser(problems, "problem"))}
+		}
+		if parseErr != nil {
+			return silentError{Err: parseErr}
+		}
+
+		notifier.Info(nil, fmt.Sprintf("No problems found in %s", grizzly.Pluraliser(resources.Len(), "resource")))
+		return nil
+	}
+	cmd = initialiseOnlySpec(cmd, &opts)
+	cmd = initialiseContinueOnError(cmd, &opts)
+	cmd = initialiseJUnit(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+// lintReport reports the problems found by lint, with one test case per
+// dashboard, failing with its problems, and one per other resource with
+// problems. Problems about no resource in particular are reported together.
+func lintReport(resources grizzly.Resources, warnings []grizzly.Warning) *grizzly.JUnitReport {
+	problems := map[string][]string{}
+	for _, warning := range warnings {
+		ref := warning.ResourceRef
+		if ref == "" {
+			ref = "grr lint"
+		}
+		problems[ref] = append(problems[ref], warning.Err.Error())
+	}
+
+	report := grizzly.NewJUnitReport("grr lint")
+	record := func(ref string) {
+		event := grizzly.Event{Type: grizzly.ResourceNotChanged, ResourceRef: ref}
+		if len(problems[ref]) > 0 {
+			event.Type = grizzly.ResourceFailure
+			event.Details = strings.Join(problems[ref], "\n")
+		}
+		report.Record(event)
+		delete(problems, ref)
+	}
+	for _, resource := range resources.AsList() {
+		if resource.Kind() == "Dashboard" {
+			record(resource.Ref().String())
+		}
+	}
+	refs := make([]string, 0, len(problems))
+	for ref := range problems {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		record(ref)
+	}
+
+	return report
+}
+
+func routeCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "route <resource-path> [<label>=<value>...]",
+		Short: "show where local notification policies route an alert with the given labels",
+		Args:  cli.ArgsMin(1),
+	}
+	var opts Opts
+	var format string
+	var expected []string
+	cmd.Flags().StringVarP(&format, "format", "f", "default", "format of the routes, one of default, json, yaml")
+	cmd.Flags().StringSliceVar(&expected, "expect", nil, "contact points the alert must be routed to, failing otherwise")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		labels := map[string]string{}
+		for _, arg := range args[1:] {
+			name, value, ok := strings.Cut(arg, "=")
+			if !ok || name == "" {
+				return fmt.Errorf("invalid label %s, expected <label>=<value>", arg)
+			}
+			labels[name] = value
+		}
+
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+		targets := currentContext.GetTargets(opts.Targets)
+
+		resources, parseErr := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, parserOpts(opts)...).Parse(args[0], grizzly.ParserOptions{
+			ExtVars: opts.ExtVars,
+			TLAs:    opts.TLAs,
+		})
+		if err := reportParseErrors(opts, parseErr); err != nil {
+			return err
+		}
+
+		simulation, warnings, err := grafana.SimulateRouting(resources, labels)
+		if err != nil {
+			return err
+		}
+		for _, warning := range warnings {
+			grizzly.RecordWarning(registry.Context(), warning)
+		}
+		output, err := simulation.Format(format)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+
+		if expected != nil {
+			sort.Strings(expected)
+			if receivers := simulation.Receivers(); !slices.Equal(expected, receivers) {
+				return fmt.Errorf("alert routed to %s, expected %s", strings.Join(receivers, ", "), strings.Join(expected, ", "))
+			}
+		}
+
+		// parse errors are already displayed
+		if parseErr != nil {
+			return silentError{Err: parseErr}
+		}
+		return nil
+	}
+	cmd = initialiseContinueOnError(cmd, &opts)
+	return initialiseCmd(cmd, &opts, &registry)
+}
+
+func providersCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "providers",
+		Short: "Lists the kinds of resources supported by Grizzly, the operations they support and the providers they are bound to",
+		Args:  cli.ArgsExact(0),
+	}
+	var opts LoggingOpts
+	var format string
+	cmd.Flags().StringVarP(&format, "format", "f", "default", "format of the list, one of default, json, yaml")
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		currentContext, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+
+		output, err := registry.Capabilities(currentContext.Name, currentContext.GetTargets(nil)).Format(format)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	return initialiseLogging(cmd, &opts)
+}
+
+func functionsCmd() *cli.Command {
+	cmd := &cli.Command{
+		Use:   "functions",
+		Short: "Lists the functions available to Jsonnet files and filename templates",
+		Args:  cli.ArgsExact(0),
+	}
+	var opts LoggingOpts
+
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		f := "%s\t%s\n"
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+
+		fmt.Fprintf(w, f, "JSONNET NATIVE FUNCTION", "DESCRIPTION")
+		for _, function := range grizzly.NativeFunctionDocs() {
+			fmt.Fprintf(w, f, function.Signature(), function.Description)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, f, "NAME TEMPLATE FUNCTION", "DESCRIPTION")
+		for _, function := range grizzly.FilenameTemplateFunctionDocs() {
+			fmt.Fprintf(w, f, function.Signature(), function.Description)
+		}
+		return w.Flush()
+	}
+
+	return initialiseLogging(cmd, &opts)
+}
+
+func configCmd(registry grizzly.Registry) *cli.Command {
+	cmd := &cli.Command{
+		Use:   "config <sub-command>",
+		Short: "Show, select or configure configuration",
+		Args:  cli.ArgsExact(0),
+	}
+	cmd.AddCommand(configPathCmd())
+	cmd.AddCommand(currentContextCmd())
+	cmd.AddCommand(useContextCmd())
+	cmd.AddCommand(getContextsCmd())
+	cmd.AddCommand(configImportCmd())
+	cmd.AddCommand(getConfigCmd())
+	cmd.AddCommand(setCmd())
+	cmd.AddCommand(unsetCmd())
+	cmd.AddCommand(createContextCmd())
+	cmd.AddCommand(checkCmd(registry))
+	return cmd
+}
+
+// initialiseCmd sets up the flags common to workflow commands, and runs them
+// with a registry whose context collects the warnings and permission
+// problems of the run, reported at the end of it
+func initialiseCmd(cmd *cli.Command, opts *Opts, registry *grizzly.Registry) *cli.Command {
+	// Keep the old flags for backwards compatibility
+	cmd.Flags().BoolVarP(&opts.Directory, "directory", "d", false, "treat resource path as a directory")
+	if err := cmd.Flags().MarkDeprecated("directory", "now it is inferred from the operating system"); err != nil {
+		log.Fatal(err)
+	}
+
+	cmd.Flags().StringSliceVarP(&opts.Targets, "target", "t", nil, "resources to target")
+	cmd.Flags().StringSliceVarP(&opts.JsonnetPaths, "jpath", "J", getDefaultJsonnetFolders(), "Specify an additional library search dir (right-most wins)")
+	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", "", "Output format, one of "+strings.Join(grizzly.EncoderFormats(), ", "))
+	cmd.Flags().StringVar(&opts.FolderMapPath, "folder-map", grizzly.DefaultFolderMapFile, "File recording the UIDs of folders created from folderName metadata")
+	cmd.Flags().StringSliceVar(&opts.Ignore, "ignore", nil, "glob patterns of files and directories to skip when parsing directories")
+	cmd.Flags().BoolVar(&opts.Strict, "strict", false, "fail when warnings are raised")
+	cmd.Flags().StringVar(&opts.WarningsFile, "warnings-file", "", "write the warnings raised to the given file, as JSON")
+	cmd.Flags().StringVar(&opts.ManagedTag, "managed-tag", "", "tag added to dashboards to mark them as managed by Grizzly, e.g. managed:grizzly")
+	var extStrs, extCodes []string
+	cmd.Flags().StringArrayVar(&extStrs, "ext-str", nil, "set a Jsonnet external variable to a string, as name=value, or name to read it from the environment")
+	cmd.Flags().StringArrayVar(&extCodes, "ext-code", nil, "set a Jsonnet external variable to Jsonnet code, as name=code, or name to read it from the environment")
+	var tlaStrs, tlaCodes []string
+	cmd.Flags().StringArrayVar(&tlaStrs, "tla-str", nil, "set a Jsonnet top-level argument to a string, as name=value, or name to read it from the environment")
+	cmd.Flags().StringArrayVar(&tlaCodes, "tla-code", nil, "set a Jsonnet top-level argument to Jsonnet code, as name=code, or name to read it from the environment")
+	cmd.Flags().IntVar(&opts.ParseWorkers, "parse-workers", 0, "number of files parsed concurrently when parsing directories (defaults to the number of CPUs)")
+	cmd.Flags().StringArrayVar(&opts.HelmValues, "helm-values", nil, "values file overlaid when rendering Helm charts. Can be repeated")
+	cmd.Flags().StringVar(&opts.Values, "values", "", "values file to execute YAML files with, as Go templates")
+	cmd.Flags().BoolVar(&opts.NoJsonnetCache, "no-jsonnet-cache", false, "evaluate Jsonnet files every time, instead of reusing the cached output of unchanged files")
+	cmd.Flags().BoolVar(&opts.UpdateImportsLock, "update-imports-lock", false, "record the checksums of the remote Jsonnet imports in "+grizzly.JsonnetImportsLockFile+", instead of checking them")
+
+	cmdRun := cmd.Run
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		extVars, err := parseExtVars("ext", extStrs, extCodes)
+		if err != nil {
+			return err
+		}
+		opts.ExtVars = extVars
+		tlas, err := parseExtVars("tla", tlaStrs, tlaCodes)
+		if err != nil {
+			return err
+		}
+		opts.TLAs = tlas
+
+		context, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("jpath") && len(context.JsonnetPaths) > 0 {
+			opts.JsonnetPaths = context.JsonnetPaths
+		}
+		opts.JsonnetEnv = context.JsonnetEnv
+
+		defaultRegistry := *registry
+		defer func() {
+			*registry = defaultRegistry
+		}()
+		ctx := grizzly.WithPermissionProblems(grizzly.WithWarnings(registry.Context()))
+		*registry = registry.WithContext(ctx)
+
+		return reportWarnings(ctx, *opts, reportPermissionProblems(ctx, cmdRun(cmd, args)))
+	}
+
+	return initialiseProject(initialiseLogging(cmd, &opts.LoggingOpts))
+}
+
+// parseExtVars reads the external variables, or top-level arguments, given
+// with the `--<prefix>-str` and `--<prefix>-code` flags as `name=value`, or
+// as `name` to read them from the environment, like the jsonnet command does
+func parseExtVars(prefix string, extStrs []string, extCodes []string) (map[string]grizzly.ExtVar, error) {
+	extVars := map[string]grizzly.ExtVar{}
+
+	parse := func(flag string, values []string, code bool) error {
+		for _, value := range values {
+			name, content, found := strings.Cut(value, "=")
+			if !found {
+				content, found = os.LookupEnv(name)
+			}
+			if name == "" || !found {
+				return fmt.Errorf("invalid --%s %q: expected name=value, or the name of an environment variable", flag, value)
+			}
+			extVars[name] = grizzly.ExtVar{Value: content, Code: code}
+		}
+		return nil
+	}
+
+	if err := parse(prefix+"-str", extStrs, false); err != nil {
+		return nil, err
+	}
+	if err := parse(prefix+"-code", extCodes, true); err != nil {
+		return nil, err
+	}
+
+	return extVars, nil
+}
+
+// initialiseProject uses the project configuration, if any, as default
+// values for the flags that were not explicitly set
+func initialiseProject(cmd *cli.Command) *cli.Command {
+	cmdRun := cmd.Run
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		project := config.CurrentProject()
+		if project == nil {
+			return cmdRun(cmd, args)
+		}
+
+		log.Debugf("Using project configuration %s", project.Path)
+
+		if err := checkRequiredVersion(project); err != nil {
+			return err
+		}
+		if project.Profile != "" {
+			log.Debugf("Using profile %s", project.Profile)
+		}
+
+		defaults := map[string]string{
+			"jpath":             strings.Join(project.JsonnetPaths, ","),
+			"target":            strings.Join(project.Targets, ","),
+			"ignore":            strings.Join(project.Ignore, ","),
+			"output":            project.Output.Format,
+			"log-level":         project.Output.LogLevel,
+			"folder-map":        project.Parser.FolderMap,
+			"kind":              project.Parser.ResourceKind,
+			"folder":            project.Parser.FolderUID,
+			"no-color":          formatOptionalBool(project.Output.NoColor),
+			"only-spec":         formatOptionalBool(project.Parser.OnlySpec),
+			"continue-on-error": formatOptionalBool(project.Parser.ContinueOnError),
+			"strict":            formatOptionalBool(project.Strict),
+			"apply":             formatOptionalBool(project.Watch.Apply),
+			"poll":              formatOptionalBool(project.Watch.Poll),
+			"poll-interval":     project.Watch.PollInterval,
+			"name-template":     project.Pull.NameTemplate,
+			"managed-tag":       project.Managed.Tag,
+			"only-managed":      formatOptionalBool(project.Managed.OnlyManaged),
+			"circuit-breaker":   formatOptionalInt(project.Apply.CircuitBreaker),
+			"values":            project.Values,
+		}
+		for name, value := range defaults {
+			flag := cmd.Flags().Lookup(name)
+			if value == "" || flag == nil || flag.Changed {
+				continue
+			}
+			// workspaces have targets of their own
+			if workspace := cmd.Flags().Lookup("workspace"); name == "target" && workspace != nil && workspace.Changed {
+				continue
+			}
+			if err := cmd.Flags().Set(name, value); err != nil {
+				return fmt.Errorf("invalid value for %s in %s: %w", name, project.Path, err)
+			}
+		}
+		// each value of a repeatable flag is set on its own, as values may
+		// contain commas
+		if flag := cmd.Flags().Lookup("helm-values"); flag != nil && !flag.Changed {
+			for _, values := range project.HelmValues {
+				if err := cmd.Flags().Set("helm-values", values); err != nil {
+					return fmt.Errorf("invalid value for helm-values in %s: %w", project.Path, err)
+				}
+			}
+		}
+
+		return cmdRun(cmd, args)
+	}
+
+	return cmd
+}
+
+func formatOptionalBool(value *bool) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*value)
+}
+
+func formatOptionalInt(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+// kindTimeouts parses the timeouts of kinds of resources, e.g. `30s`
+func kindTimeouts(registry grizzly.Registry, timeouts map[string]string) (map[string]time.Duration, error) {
+	parsed := make(map[string]time.Duration, len(timeouts))
+	for kind, timeout := range timeouts {
+		if _, err := registry.GetHandler(kind); err != nil {
+			return nil, err
+		}
+		duration, err := time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", kind, err)
+		}
+		parsed[kind] = duration
+	}
+	return parsed, nil
+}
+
+func parserOpts(opts Opts, extra ...grizzly.ParserOpt) []grizzly.ParserOpt {
+	options := []grizzly.ParserOpt{
+		grizzly.ParserContinueOnError(opts.ContinueOnError),
+		grizzly.ParserFolderMap(opts.FolderMapPath),
+		// remote endpoints can't be reached offline
+		grizzly.ParserRemoteFolders(!opts.Offline),
+		grizzly.ParserIgnore(append([]string{config.ProjectConfigFile}, opts.Ignore...)),
+		grizzly.ParserWorkers(opts.ParseWorkers),
+		grizzly.ParserLargeFileSize(opts.LargeFileSize),
+	}
+	if project := config.CurrentProject(); project != nil && len(project.DatasourceDefaults) > 0 {
+		options = append(options, grizzly.ParserTransform(grafana.DatasourceDefaults(project.DatasourceDefaults)))
+	}
+	if project := config.CurrentProject(); project != nil && len(project.Placement) > 0 {
+		options = append(options, placementOpt(project))
+	}
+	if project := config.CurrentProject(); project != nil && !project.DashboardBudgets.IsZero() {
+		options = append(options, grizzly.ParserTransform(grafana.DashboardBudgets(project.DashboardBudgets)))
+	}
+	if opts.ManagedTag != "" {
+		options = append(options, grizzly.ParserTransform(grafana.ManagedTag(opts.ManagedTag)))
+	}
+	if project := config.CurrentProject(); project != nil && len(project.JsonnetAliases) > 0 {
+		options = append(options, grizzly.ParserJsonnetAliases(project.JsonnetAliases))
+	}
+	if len(opts.HelmValues) > 0 {
+		options = append(options, grizzly.ParserHelmValues(opts.HelmValues))
+	}
+	if opts.Values != "" {
+		options = append(options, grizzly.ParserTemplateValues(opts.Values))
+	}
+	if len(opts.JsonnetEnv) > 0 {
+		options = append(options, grizzly.ParserJsonnetEnv(opts.JsonnetEnv))
+	}
+	// the lock of remote imports sits next to the project configuration, if
+	// any, or in the working directory
+	lockFile := grizzly.JsonnetImportsLockFile
+	if project := config.CurrentProject(); project != nil {
+		lockFile = filepath.Join(filepath.Dir(project.Path), grizzly.JsonnetImportsLockFile)
+	}
+	options = append(options, grizzly.ParserJsonnetLock(lockFile, opts.UpdateImportsLock))
+	if !opts.NoJsonnetCache {
+		if dir, err := grizzly.DefaultJsonnetEvalCacheDir(); err == nil {
+			options = append(options, grizzly.ParserJsonnetCache(dir))
+		} else {
+			log.Debugf("not caching Jsonnet evaluations: %v", err)
+		}
+	}
+
+	return append(options, extra...)
+}
+
+// placementOpt places resources according to the placement rules of the
+// project, for the organization of the current context
+func placementOpt(project *config.Project) grizzly.ParserOpt {
+	rules := make([]grizzly.PlacementRule, 0, len(project.Placement))
+	for _, rule := range project.Placement {
+		rules = append(rules, grizzly.PlacementRule{
+			Path:       rule.Path,
+			Folder:     rule.Folder,
+			FolderName: rule.FolderName,
+			Org:        rule.Org,
+		})
+	}
+
+	var orgID int64
+	if context, err := config.CurrentContext(); err == nil {
+		orgID = context.Grafana.OrgID
+	}
+
+	return grizzly.ParserPlacement(filepath.Dir(project.Path), rules, orgID)
+}
+
+// reportParseErrors displays every parse error. Unless continuing on error,
+// they interrupt the command.
+func reportParseErrors(opts Opts, parseErr error) error {
+	if parseErr == nil {
+		return nil
+	}
+
+	var parseErrors []error
+	if merr, ok := parseErr.(*multierror.Error); ok {
+		parseErrors = merr.Errors
+	} else {
+		parseErrors = []error{parseErr}
+	}
+
+	for _, e := range parseErrors {
+		notifier.Error(nil, e.Error())
+	}
+	if len(parseErrors) > 1 {
+		notifier.Error(nil, fmt.Sprintf("%s found", grizzly.Pluraliser(len(parseErrors), "parse error")))
+	}
+
+	if !opts.ContinueOnError {
+		return silentError{Err: parseErr}
+	}
+
+	return nil
+}
+
+// reportPermissionProblems sums up the requests denied by remote endpoints,
+// by kind, along with the permissions they require
+func reportPermissionProblems(ctx context.Context, err error) error {
+	problems := grizzly.PermissionProblems(ctx)
+	if len(problems) == 0 {
+		return err
+	}
+
+	denied := 0
+	for _, problem := range problems {
+		notifier.Error(nil, "permissions: "+problem.String())
+		denied += problem.Unauthorized + problem.Forbidden
+	}
+	notifier.Error(nil, fmt.Sprintf("%s denied", grizzly.Pluraliser(denied, "request")))
+
+	return err
+}
+
+// reportWarnings displays the warnings raised while running a command, and
+// writes them to the warnings file if any. With --strict, they make the
+// command fail.
+func reportWarnings(ctx context.Context, opts Opts, err error) error {
+	warnings := grizzly.Warnings(ctx)
+
+	if opts.WarningsFile != "" {
+		content, jsonErr := json.MarshalIndent(warnings, "", "  ")
+		if jsonErr != nil {
+			return errors.Join(err, jsonErr)
+		}
+		if writeErr := os.WriteFile(opts.WarningsFile, append(content, '\n'), 0644); writeErr != nil {
+			return errors.Join(err, writeErr)
 		}
+	}
 
-		targets := currentContext.GetTargets(opts.Targets)
-		parser := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths, grizzly.ParserContinueOnError(true))
-		parserOpts := grizzly.ParserOptions{
-			DefaultResourceKind: resourceKind,
-			DefaultFolderUID:    folderUID,
+	if len(warnings) == 0 {
+		return err
+	}
+
+	announce := notifier.Warn
+	if opts.Strict {
+		announce = notifier.Error
+	}
+	for _, warning := range warnings {
+		announce(nil, "warning: "+warning.Error())
+	}
+	announce(nil, fmt.Sprintf("%s raised", grizzly.Pluraliser(len(warnings), "warning")))
+
+	if opts.Strict && err == nil {
+		return silentError{Err: fmt.Errorf("%s raised in strict mode", grizzly.Pluraliser(len(warnings), "warning"))}
+	}
+
+	return err
+}
+
+// newCanary sets up a canary rollout, gated by alert rules of the Grafana
+// provider
+func newCanary(registry grizzly.Registry, selector string, soak time.Duration, alertRules []string) (grizzly.Canary, error) {
+	canary := grizzly.Canary{Selector: selector, Soak: soak}
+	if len(alertRules) == 0 {
+		return canary, nil
+	}
+
+	var grafanaProvider *grafana.Provider
+	for _, provider := range registry.Providers {
+		if p, ok := provider.(*grafana.Provider); ok {
+			grafanaProvider = p
 		}
+	}
+	if grafanaProvider == nil {
+		return canary, fmt.Errorf("--canary-alert requires the Grafana provider")
+	}
+	for _, uid := range alertRules {
+		canary.Gates = append(canary.Gates, grafana.AlertRuleGate(grafanaProvider, uid))
+	}
+	return canary, nil
+}
 
-		format, onlySpec, err := getOutputFormat(opts)
-		if err != nil {
-			return err
+// checkGrafanaVersion warns about the resources relying on features newer
+// than the version of Grafana targeted by the project, and when remote, about
+// a Grafana instance older than that version
+func checkGrafanaVersion(registry grizzly.Registry, resources grizzly.Resources, remote bool) error {
+	project := config.CurrentProject()
+	if project == nil || project.GrafanaVersion == "" {
+		return nil
+	}
+	if err := grafana.ValidateTargetVersion(project.GrafanaVersion); err != nil {
+		return fmt.Errorf("%w in %s", err, project.Path)
+	}
+
+	for _, warning := range grafana.CheckTargetVersion(resources, project.GrafanaVersion) {
+		grizzly.RecordWarning(registry.Context(), warning)
+	}
+	if !remote {
+		return nil
+	}
+
+	for _, provider := range registry.Providers {
+		grafanaProvider, ok := provider.(*grafana.Provider)
+		if !ok {
+			continue
 		}
 
-		server, err := grizzly.NewGrizzlyServer(registry, resourcesPath, opts.ProxyPort)
-		if err != nil {
-			return err
+		usesGrafana := false
+		for _, resource := range resources.AsList() {
+			usesGrafana = usesGrafana || resource.APIVersion() == grafanaProvider.APIVersion()
 		}
-		server.SetParser(parser, parserOpts)
-		server.SetContext(currentContext.Name)
-		server.SetFormatting(onlySpec, format)
-		if opts.Watch {
-			server.Watch(watchPaths)
-			if opts.WatchScript != "" {
-				server.WatchScript(opts.WatchScript)
-			}
+		if !usesGrafana {
+			continue
 		}
-		if opts.OpenBrowser {
-			server.OpenBrowser()
+
+		if err := grafanaProvider.Capabilities().CheckInstanceVersion(project.GrafanaVersion); err != nil {
+			grizzly.RecordWarning(registry.Context(), grizzly.NewWarning(err))
 		}
-		return server.Start()
 	}
-	cmd.Flags().BoolVarP(&opts.Watch, "watch", "w", false, "Watch filesystem for changes")
-	cmd.Flags().BoolVarP(&opts.OpenBrowser, "open-browser", "b", false, "Open Grizzly in default browser")
-	cmd.Flags().IntVarP(&opts.ProxyPort, "port", "p", 8080, "Port on which the server will listen")
-	cmd.Flags().StringVarP(&opts.WatchScript, "script", "S", "", "Script to execute on filesystem change")
-	cmd = initialiseOnlySpec(cmd, &opts)
-	return initialiseCmd(cmd, &opts)
+
+	return nil
 }
 
-func exportCmd(registry grizzly.Registry) *cli.Command {
-	cmd := &cli.Command{
-		Use:   "export <resource-path> <dashboard-dir>",
-		Short: "render resources and save to a directory",
-		Args:  cli.ArgsExact(2),
+func initialisePolling(cmd *cli.Command, opts *Opts) *cli.Command {
+	cmd.Flags().BoolVar(&opts.Poll, "poll", false, "detect changes by polling files, where file system events aren't available (e.g. network mounts). Used automatically when file system events can't be set up")
+	cmd.Flags().DurationVar(&opts.PollInterval, "poll-interval", grizzly.DefaultPollInterval, "interval at which files are polled")
+	return cmd
+}
+
+func watcherOpts(opts Opts) []grizzly.WatcherOpt {
+	return []grizzly.WatcherOpt{
+		grizzly.WatcherPolling(opts.Poll),
+		grizzly.WatcherPollInterval(opts.PollInterval),
 	}
-	var opts Opts
+}
+
+func initialiseContinueOnError(cmd *cli.Command, opts *Opts) *cli.Command {
+	cmd.Flags().BoolVarP(&opts.ContinueOnError, "continue-on-error", "e", false, "report all parse errors at the end instead of stopping at the first one")
+	return cmd
+}
+
+func initialiseTimeouts(cmd *cli.Command, opts *Opts, registry *grizzly.Registry) *cli.Command {
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 0, "maximum duration of the whole run, after which remaining resources are skipped (e.g. 10m)")
+	cmd.Flags().DurationVar(&opts.ResourceTimeout, "resource-timeout", 0, "maximum duration of the processing of each resource (e.g. 1m)")
+	cmd.Flags().DurationVar(&opts.RequestTimeout, "request-timeout", 0, "maximum duration of each HTTP request (e.g. 30s)")
 
+	cmdRun := cmd.Run
 	cmd.Run = func(cmd *cli.Command, args []string) error {
-		resourcePath := args[0]
-		dashboardDir := args[1]
-		resourceKind, folderUID, err := getOnlySpec(opts)
-		if err != nil {
-			return err
-		}
+		ctx, cancel := grizzly.WithTimeouts(registry.Context(), opts.RequestTimeout, opts.ResourceTimeout, opts.Timeout)
+		defer cancel()
 
-		currentContext, err := config.CurrentContext()
-		if err != nil {
-			return err
-		}
+		defaultRegistry := *registry
+		defer func() {
+			*registry = defaultRegistry
+		}()
+		*registry = registry.WithContext(ctx)
 
-		targets := currentContext.GetTargets(opts.Targets)
+		return cmdRun(cmd, args)
+	}
 
-		resources, err := grizzly.DefaultParser(registry, targets, opts.JsonnetPaths).Parse(resourcePath, grizzly.ParserOptions{
-			DefaultResourceKind: resourceKind,
-			DefaultFolderUID:    folderUID,
-		})
-		if err != nil {
-			return err
-		}
+	return cmd
+}
 
-		format, onlySpec, err := getOutputFormat(opts)
-		if err != nil {
-			return err
+func initialiseLargeFiles(cmd *cli.Command, opts *Opts) *cli.Command {
+	size := cmd.Flags().String("large-file-size", "1MB", "size above which the specs of the resources of a file are only loaded when needed, keeping memory flat on large repositories (e.g. 500KB). 0 loads them all upfront")
+
+	cmdRun := cmd.Run
+	cmd.Run = func(cmd *cli.Command, args []string) error {
+		opts.LargeFileSize = 0
+		if *size != "0" {
+			bytes, err := grizzly.ParseSize(*size)
+			if err != nil {
+				return fmt.Errorf("invalid --large-file-size: %w", err)
+			}
+			opts.LargeFileSize = int64(bytes)
 		}
 
-		return grizzly.Export(registry, dashboardDir, resources, onlySpec, format)
+		return cmdRun(cmd, args)
 	}
-	cmd = initialiseOnlySpec(cmd, &opts)
-	return initialiseCmd(cmd, &opts)
+
+	return cmd
 }
 
-func providersCmd(registry grizzly.Registry) *cli.Command {
-	cmd := &cli.Command{
-		Use:   "providers",
-		Short: "Lists all providers registered with Grizzly",
-		Args:  cli.ArgsExact(0),
-	}
-	var opts LoggingOpts
+// initialiseWorkspaces runs the command for each of the workspaces of the
+// project selected with `--workspace`, in turn, with the context and
+// targets of each workspace, and the registry of its context
+func initialiseWorkspaces(cmd *cli.Command, opts *Opts, registry *grizzly.Registry) *cli.Command {
+	cmd.Flags().StringArrayVarP(&opts.Workspaces, "workspace", "w", nil, "workspace of the project configuration to run for, instead of a resource path. Can be repeated")
 
+	cmdRun := cmd.Run
 	cmd.Run = func(cmd *cli.Command, args []string) error {
-		f := "%s\t%s\n"
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+		if len(opts.Workspaces) == 0 {
+			if len(args) != 1 {
+				return fmt.Errorf("a resource path or a --workspace is required")
+			}
+			return cmdRun(cmd, args)
+		}
+		if len(args) > 0 {
+			return fmt.Errorf("a resource path can't be given along with --workspace")
+		}
 
-		fmt.Fprintf(w, f, "API VERSION", "KIND")
-		for _, provider := range registry.Providers {
-			for _, handler := range provider.GetHandlers() {
-				fmt.Fprintf(w, f, provider.APIVersion(), handler.Kind())
+		project := config.CurrentProject()
+		if project == nil {
+			return fmt.Errorf("--workspace requires a %s file", config.ProjectConfigFile)
+		}
+		workspaces := make([]config.Workspace, len(opts.Workspaces))
+		for i, name := range opts.Workspaces {
+			workspace, err := project.Workspace(name)
+			if err != nil {
+				return err
 			}
+			workspaces[i] = workspace
 		}
-		return w.Flush()
+		if opts.JUnitFile != "" && (len(workspaces) > 1 || len(workspaces[0].Sources) > 1) {
+			return fmt.Errorf("--junit can't be used with several workspaces or sources, as each run would overwrite the report")
+		}
+
+		defaultRegistry, defaultTargets := *registry, opts.Targets
+		defer func() {
+			*registry, opts.Targets = defaultRegistry, defaultTargets
+		}()
+
+		var errs []error
+		for i, workspace := range workspaces {
+			err := runWorkspace(cmd, opts, registry, opts.Workspaces[i], workspace, cmdRun)
+			if err == nil {
+				continue
+			}
+			// errors of resources are already displayed
+			if !errors.Is(err, silentError{}) {
+				notifier.Error(nil, fmt.Sprintf("workspace %s: %s", opts.Workspaces[i], err))
+			}
+			errs = append(errs, err)
+			if !opts.ContinueOnError {
+				break
+			}
+		}
+		if len(errs) > 0 {
+			return silentError{Err: errors.Join(errs...)}
+		}
+		return nil
 	}
 
-	return initialiseLogging(cmd, &opts)
+	return cmd
 }
 
-func configCmd(registry grizzly.Registry) *cli.Command {
-	cmd := &cli.Command{
-		Use:   "config <sub-command>",
-		Short: "Show, select or configure configuration",
-		Args:  cli.ArgsExact(0),
+// runWorkspace runs a command for each of the sources of a workspace
+func runWorkspace(cmd *cli.Command, opts *Opts, registry *grizzly.Registry, name string, workspace config.Workspace, cmdRun func(*cli.Command, []string) error) error {
+	if workspace.Context != "" {
+		restore, err := config.OverrideContext(workspace.Context)
+		if err != nil {
+			return err
+		}
+		defer restore()
 	}
-	cmd.AddCommand(configPathCmd())
-	cmd.AddCommand(currentContextCmd())
-	cmd.AddCommand(useContextCmd())
-	cmd.AddCommand(getContextsCmd())
-	cmd.AddCommand(configImportCmd())
-	cmd.AddCommand(getConfigCmd())
-	cmd.AddCommand(setCmd())
-	cmd.AddCommand(unsetCmd())
-	cmd.AddCommand(createContextCmd())
-	cmd.AddCommand(checkCmd(registry))
+	context, err := config.CurrentContext()
+	if err != nil {
+		return err
+	}
+	// the workspace is run within the context of the command
+	workspaceRegistry := createRegistry(context)
+	*registry = workspaceRegistry.WithContext(registry.Context())
+
+	switch {
+	case cmd.Flags().Changed("target"):
+	case len(workspace.Targets) > 0:
+		opts.Targets = workspace.Targets
+	default:
+		opts.Targets = config.CurrentProject().Targets
+	}
+	if !cmd.Flags().Changed("jpath") && len(context.JsonnetPaths) > 0 {
+		opts.JsonnetPaths = context.JsonnetPaths
+	}
+	opts.JsonnetEnv = context.JsonnetEnv
+
+	notifier.Info(nil, fmt.Sprintf("Workspace %s, with context %s", name, context.Name))
+	for _, source := range workspace.Sources {
+		if err := cmdRun(cmd, []string{source}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func initialiseExecServer(cmd *cli.Command, opts *Opts) *cli.Command {
+	cmd.Flags().StringVar(&opts.ExecServer, "exec-server", "", "URL of a remote execution server (see grr server) to submit the rendered resources to, authenticating with the token in $"+execTokenEnvVar)
 	return cmd
 }
 
-func initialiseCmd(cmd *cli.Command, opts *Opts) *cli.Command {
-	// Keep the old flags for backwards compatibility
-	cmd.Flags().BoolVarP(&opts.Directory, "directory", "d", false, "treat resource path as a directory")
-	if err := cmd.Flags().MarkDeprecated("directory", "now it is inferred from the operating system"); err != nil {
-		log.Fatal(err)
+// execTokenEnvVar is the environment variable holding the token of the
+// remote execution server, kept out of command lines
+const execTokenEnvVar = "GRIZZLY_EXEC_TOKEN"
+
+// execClient returns a client of the remote execution server given with
+// --exec-server, recording the warnings of the server in the context of the
+// registry
+func execClient(registry grizzly.Registry, opts Opts) (*grizzly.ExecClient, error) {
+	token := os.Getenv(execTokenEnvVar)
+	if token == "" {
+		return nil, fmt.Errorf("--exec-server requires a token, set in $%s", execTokenEnvVar)
 	}
+	return grizzly.NewExecClient(opts.ExecServer, token).WithContext(registry.Context()), nil
+}
 
-	cmd.Flags().StringSliceVarP(&opts.Targets, "target", "t", nil, "resources to target")
-	cmd.Flags().StringSliceVarP(&opts.JsonnetPaths, "jpath", "J", getDefaultJsonnetFolders(), "Specify an additional library search dir (right-most wins)")
-	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", "", "Output format")
+func initialiseJUnit(cmd *cli.Command, opts *Opts) *cli.Command {
+	cmd.Flags().StringVar(&opts.JUnitFile, "junit", "", "write a JUnit XML report, with one test case per resource, to the given file")
+	return cmd
+}
+
+// writeJUnitReport writes the report to the file requested with --junit, if any
+func writeJUnitReport(opts Opts, report *grizzly.JUnitReport) error {
+	if opts.JUnitFile == "" {
+		return nil
+	}
+
+	return report.WriteFile(opts.JUnitFile)
+}
+
+// diffRevision reports the changes of resources since a git revision, like
+// diff reports them against the remote
+func diffRevision(registry grizzly.Registry, base grizzly.Resources, resources grizzly.Resources, ref string, onlySpec bool, format string, summaryOnly bool, opts Opts, parseErr error) error {
+	report := grizzly.NewJUnitReport("grr diff", grizzly.ResourceChanged, grizzly.ResourceNotFound, grizzly.ResourceRemoved)
+	summary := grizzly.NewDiffSummary()
+	if summaryOnly {
+		notifier.SetOutputMode(notifier.QuietOutput)
+	}
+	diffErr := grizzly.DiffRevision(registry, base, resources, ref, onlySpec, format, eventRecorders{report, summary})
+	switch {
+	case summary.String() == "":
+	case summaryOnly:
+		fmt.Println(summary)
+	case !opts.Quiet && !opts.Porcelain:
+		fmt.Printf("\nSummary:\n%s\n", summary)
+	}
+	if err := writeJUnitReport(opts, report); err != nil {
+		return err
+	}
+	if diffErr != nil {
+		return diffErr
+	}
 
-	return initialiseLogging(cmd, &opts.LoggingOpts)
+	// parse errors are already displayed
+	if parseErr != nil {
+		return silentError{Err: parseErr}
+	}
+	return nil
+}
+
+// initialiseFormat adds --format to the commands writing resources, as an
+// alias of --output naming the encoders
+func initialiseFormat(cmd *cli.Command, opts *Opts) *cli.Command {
+	cmd.Flags().StringVar(&opts.OutputFormat, "format", "", "format of the files written, one of "+strings.Join(grizzly.EncoderFormats(), ", ")+". Alias of --output")
+	return cmd
 }
 
 func initialiseOnlySpec(cmd *cli.Command, opts *Opts) *cli.Command {
@@ -542,19 +2532,163 @@ func initialiseOnlySpec(cmd *cli.Command, opts *Opts) *cli.Command {
 
 func initialiseLogging(cmd *cli.Command, loggingOpts *LoggingOpts) *cli.Command {
 	cmd.Flags().StringVarP(&loggingOpts.LogLevel, "log-level", "l", log.InfoLevel.String(), "info, debug, warning, error")
+	cmd.Flags().BoolVar(&loggingOpts.NoColor, "no-color", false, "disable colored output (also disabled by setting NO_COLOR)")
+	cmd.Flags().BoolVarP(&loggingOpts.Quiet, "quiet", "q", false, "only output errors")
+	cmd.Flags().BoolVar(&loggingOpts.Porcelain, "porcelain", false, "output the status of each resource as stable, tab-separated lines")
+	cmd.Flags().BoolVar(&loggingOpts.LogHTTP, "log-http", false, "log HTTP requests and responses, with secrets redacted")
+	cmd.Flags().StringVar(&loggingOpts.HTTPRecord, "http-record", "", "record the HTTP interactions with remote endpoints to the given fixtures file")
+	cmd.Flags().StringVar(&loggingOpts.HTTPReplay, "http-replay", "", "replay the HTTP interactions recorded in the given fixtures file, instead of reaching remote endpoints")
+	cmd.Flags().StringVar(&loggingOpts.Env, "env", "", "profile of the project configuration to use (also set with GRIZZLY_ENV)")
 	cmdRun := cmd.Run
 	cmd.Run = func(cmd *cli.Command, args []string) error {
 		logLevel, err := log.ParseLevel(loggingOpts.LogLevel)
 		if err != nil {
 			return err
 		}
+		if loggingOpts.Quiet && loggingOpts.Porcelain {
+			return fmt.Errorf("--quiet and --porcelain are mutually exclusive")
+		}
+		switch {
+		case loggingOpts.Quiet:
+			notifier.SetOutputMode(notifier.QuietOutput)
+			logLevel = log.ErrorLevel
+		case loggingOpts.Porcelain:
+			notifier.SetOutputMode(notifier.PorcelainOutput)
+			color.NoColor = true
+			if !cmd.Flags().Changed("log-level") {
+				logLevel = log.ErrorLevel
+			}
+		}
 		log.SetLevel(logLevel)
+		if err := initialiseHTTPFixtures(*loggingOpts); err != nil {
+			return err
+		}
+		if loggingOpts.LogHTTP {
+			grizzly.AddHTTPTransportDecorator(grizzly.NewHTTPLoggingTransport)
+		}
+		if loggingOpts.NoColor {
+			color.NoColor = true
+		}
 		return cmdRun(cmd, args)
 	}
 
 	return cmd
 }
 
+func initialiseRemoteCache(cmd *cli.Command, opts *Opts) *cli.Command {
+	cmd.Flags().BoolVar(&opts.Offline, "offline", false, "use the last known state of remote resources, without reaching remote endpoints")
+	cmd.Flags().StringVar(&opts.CacheDir, "cache-dir", "", "directory in which the state of remote resources is cached (defaults to a per-context user cache directory)")
+
+	return cmd
+}
+
+// withRemoteCache returns a registry caching the state of remote resources,
+// or relying on that cache only when offline
+func withRemoteCache(registry grizzly.Registry, opts Opts) (grizzly.Registry, error) {
+	dir := opts.CacheDir
+	if dir == "" {
+		context, err := config.CurrentContext()
+		if err != nil {
+			return registry, err
+		}
+
+		dir, err = grizzly.DefaultRemoteCacheDir(context.Name)
+		if err != nil {
+			return registry, err
+		}
+	}
+
+	return registry.WithRemoteCache(grizzly.NewRemoteCache(dir), opts.Offline), nil
+}
+
+func initialiseManaged(cmd *cli.Command, opts *Opts) *cli.Command {
+	cmd.Flags().BoolVar(&opts.OnlyManaged, "only-managed", false, "only consider the remote dashboards with the managed tag")
+	cmd.Flags().BoolVar(&opts.IncludeUnmanaged, "include-unmanaged", false, "consider all remote dashboards, even when only managed ones are by default")
+
+	return cmd
+}
+
+// withManaged returns a registry only listing the remote resources managed
+// by Grizzly, when asked to
+func withManaged(registry grizzly.Registry, opts Opts) (grizzly.Registry, error) {
+	if !opts.OnlyManaged || opts.IncludeUnmanaged {
+		return registry, nil
+	}
+	if opts.ManagedTag == "" {
+		return registry, fmt.Errorf("--only-managed requires a managed tag, set with --managed-tag")
+	}
+
+	return registry.OnlyManaged(opts.ManagedTag), nil
+}
+
+func initialiseVersionLock(cmd *cli.Command, opts *Opts) *cli.Command {
+	cmd.Flags().StringVar(&opts.VersionLockPath, "version-lock", "", "file recording the versions of the remote resources last applied or pulled, to detect remote changes, e.g. .grizzly-versions.yaml")
+	return cmd
+}
+
+// commandError returns the error of a command whose resources were processed
+// despite failures. Their errors are already displayed by the events
+// recorder, so they only make the exit code non-zero, unless the state of
+// the command (e.g. the version lock) couldn't be saved either: the failures
+// to save it are reported along with them.
+func commandError(resourcesErr error, saveErr error) error {
+	if saveErr != nil {
+		return errors.Join(resourcesErr, saveErr)
+	}
+	if resourcesErr != nil {
+		return silentError{Err: resourcesErr}
+	}
+	return nil
+}
+
+// saveFolderMap saves the UIDs of the folders created from `folderName`
+// metadata, unless resources failed to be applied: some folders may not have
+// been created, and are looked up by title again next time.
+func saveFolderMap(folderMap *grizzly.FolderMap, applyErr error) error {
+	if applyErr != nil {
+		return nil
+	}
+	return folderMap.Save()
+}
+
+// withVersionLock returns a registry refusing to overwrite remote changes
+// made since resources were last applied or pulled, unless forced. The
+// returned function saves the versions recorded meanwhile.
+func withVersionLock(registry grizzly.Registry, opts Opts) (grizzly.Registry, func() error, error) {
+	// versions can't be checked offline
+	if opts.VersionLockPath == "" || opts.Offline {
+		return registry, func() error { return nil }, nil
+	}
+
+	context, err := config.CurrentContext()
+	if err != nil {
+		return registry, nil, err
+	}
+	lock, err := grizzly.LoadVersionLock(opts.VersionLockPath, context.Name)
+	if err != nil {
+		return registry, nil, err
+	}
+
+	return registry.WithVersionLock(lock, opts.Force), lock.Save, nil
+}
+
+func initialiseHTTPFixtures(opts LoggingOpts) error {
+	switch {
+	case opts.HTTPRecord != "" && opts.HTTPReplay != "":
+		return fmt.Errorf("--http-record and --http-replay are mutually exclusive")
+	case opts.HTTPRecord != "":
+		grizzly.AddHTTPTransportDecorator(grizzly.NewHTTPFixtures(opts.HTTPRecord).Recorder)
+	case opts.HTTPReplay != "":
+		fixtures, err := grizzly.LoadHTTPFixtures(opts.HTTPReplay)
+		if err != nil {
+			return err
+		}
+		grizzly.AddHTTPTransportDecorator(fixtures.Replayer)
+	}
+
+	return nil
+}
+
 func getDefaultJsonnetFolders() []string {
 	return []string{"vendor", "lib", "."}
 }
@@ -593,10 +2727,27 @@ func getOnlySpec(opts Opts) (string, string, error) {
 	return kind, folderUID, nil
 }
 
-func getEventFormatter() grizzly.EventFormatter {
+// eventRecorders records events with several recorders
+type eventRecorders []interface{ Record(grizzly.Event) }
+
+func (recorders eventRecorders) Record(event grizzly.Event) {
+	for _, recorder := range recorders {
+		recorder.Record(event)
+	}
+}
+
+func getEventFormatter(opts LoggingOpts) grizzly.EventFormatter {
+	if opts.Porcelain {
+		return grizzly.EventToPorcelain
+	}
+
+	formatter := grizzly.EventToPlainText
 	if terminal.IsTerminal(int(os.Stdout.Fd())) {
-		return grizzly.EventToColoredText
+		formatter = grizzly.EventToColoredText
+	}
+	if opts.Quiet {
+		return grizzly.OnlyErrors(formatter)
 	}
 
-	return grizzly.EventToPlainText
+	return formatter
 }
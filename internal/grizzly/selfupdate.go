@@ -1,7 +1,10 @@
 package grizzly
 
 import (
+	"bufio"
 	"context"
+	"crypto"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,6 +19,16 @@ import (
 var ErrCurrentVersionIsLatest = fmt.Errorf("current version is the latest")
 var ErrNextVersionIsMajorBump = fmt.Errorf("next version is a major bump")
 var ErrInvalidSemver = fmt.Errorf("invalid semver version")
+var ErrAlreadyAtVersion = fmt.Errorf("current version is the requested one")
+var ErrChecksumNotFound = fmt.Errorf("checksum not found")
+
+const (
+	releasesURL = "https://api.github.com/repos/grafana/grizzly/releases"
+
+	// checksumsAsset is the release asset listing the SHA-256 checksums of
+	// the binaries, as produced by `sha256sum`
+	checksumsAsset = "checksums.txt"
+)
 
 type ghRelease struct {
 	Draft      bool             `json:"draft"`
@@ -24,7 +37,7 @@ type ghRelease struct {
 	Assets     []ghReleaseAsset `json:"assets"`
 }
 
-func (r ghRelease) assetFor(goos string, goarch string) (string, bool) {
+func (r ghRelease) assetFor(goos string, goarch string) (ghReleaseAsset, bool) {
 	expectedAssetNameSuffix := fmt.Sprintf("%s-%s", goos, goarch)
 
 	for _, asset := range r.Assets {
@@ -32,10 +45,20 @@ func (r ghRelease) assetFor(goos string, goarch string) (string, bool) {
 			continue
 		}
 
-		return asset.DownloadURL, true
+		return asset, true
+	}
+
+	return ghReleaseAsset{}, false
+}
+
+func (r ghRelease) asset(name string) (ghReleaseAsset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
 	}
 
-	return "", false
+	return ghReleaseAsset{}, false
 }
 
 type ghReleaseAsset struct {
@@ -44,15 +67,26 @@ type ghReleaseAsset struct {
 }
 
 type SelfUpdater struct {
-	http *http.Client
+	http            *http.Client
+	verifyChecksums bool
 }
 
 func NewSelfUpdater(client *http.Client) *SelfUpdater {
 	return &SelfUpdater{
-		http: client,
+		http:            client,
+		verifyChecksums: true,
 	}
 }
 
+// VerifyChecksums toggles the verification of the downloaded binary against
+// the checksums published with the release. Checksums only detect corrupted
+// downloads: they are served along with the binaries, and aren't signed, so
+// they don't prove where a binary comes from. Verifying signatures is out of
+// scope.
+func (updater *SelfUpdater) VerifyChecksums(verify bool) {
+	updater.verifyChecksums = verify
+}
+
 func (updater *SelfUpdater) UpdateSelf(ctx context.Context, currentVersion string) (string, error) {
 	if !semver.IsValid(currentVersion) {
 		return "", fmt.Errorf("invalid current version '%s': %w", currentVersion, ErrInvalidSemver)
@@ -76,20 +110,50 @@ func (updater *SelfUpdater) UpdateSelf(ctx context.Context, currentVersion strin
 		return latestRelease.TagName, ErrNextVersionIsMajorBump
 	}
 
-	assetURL, found := latestRelease.assetFor(runtime.GOOS, runtime.GOARCH)
-	if !found {
-		return "", fmt.Errorf("could not find binary for version=%s, GOOS=%s, GOARCH=%s", latestRelease.TagName, runtime.GOOS, runtime.GOARCH)
-	}
-
-	if err := updater.doUpdate(ctx, assetURL); err != nil {
+	if err := updater.doUpdate(ctx, latestRelease); err != nil {
 		return "", err
 	}
 
 	return latestRelease.TagName, nil
 }
 
-func (updater *SelfUpdater) doUpdate(ctx context.Context, assetURL string) error {
-	response, err := updater.httpGet(ctx, assetURL)
+// UpdateTo replaces the current binary with the one of the given version.
+// Unlike UpdateSelf, major bumps and downgrades are allowed.
+func (updater *SelfUpdater) UpdateTo(ctx context.Context, currentVersion string, version string) error {
+	if !semver.IsValid(version) {
+		return fmt.Errorf("invalid version '%s': %w", version, ErrInvalidSemver)
+	}
+
+	if semver.IsValid(currentVersion) && semver.Compare(currentVersion, version) == 0 {
+		return ErrAlreadyAtVersion
+	}
+
+	release, err := updater.fetchRelease(ctx, releasesURL+"/tags/"+version)
+	if err != nil {
+		return err
+	}
+
+	return updater.doUpdate(ctx, release)
+}
+
+func (updater *SelfUpdater) doUpdate(ctx context.Context, release *ghRelease) error {
+	asset, found := release.assetFor(runtime.GOOS, runtime.GOARCH)
+	if !found {
+		return fmt.Errorf("could not find binary for version=%s, GOOS=%s, GOARCH=%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+
+	options := selfupdate.Options{}
+	if updater.verifyChecksums {
+		checksum, err := updater.checksumFor(ctx, release, asset.Name)
+		if err != nil {
+			return err
+		}
+
+		options.Checksum = checksum
+		options.Hash = crypto.SHA256
+	}
+
+	response, err := updater.httpGet(ctx, asset.DownloadURL)
 	defer func() {
 		if response != nil && response.Body != nil {
 			_ = response.Body.Close()
@@ -99,11 +163,101 @@ func (updater *SelfUpdater) doUpdate(ctx context.Context, assetURL string) error
 		return err
 	}
 
-	return selfupdate.Apply(response.Body, selfupdate.Options{})
+	return selfupdate.Apply(response.Body, options)
+}
+
+// checksumFor returns the checksum of an asset of a release. Releases that
+// don't publish checksums, such as those published before checksums were,
+// can't be verified: that is an error, like an asset missing from the
+// published checksums.
+func (updater *SelfUpdater) checksumFor(ctx context.Context, release *ghRelease, assetName string) ([]byte, error) {
+	checksums, found := release.asset(checksumsAsset)
+	if !found {
+		return nil, fmt.Errorf("release %s does not publish checksums: %w", release.TagName, ErrChecksumNotFound)
+	}
+
+	response, err := updater.httpGet(ctx, checksums.DownloadURL)
+	defer func() {
+		if response != nil && response.Body != nil {
+			_ = response.Body.Close()
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseChecksum(response.Body, assetName)
+}
+
+func parseChecksum(checksums io.Reader, assetName string) ([]byte, error) {
+	scanner := bufio.NewScanner(checksums)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != assetName {
+			continue
+		}
+
+		checksum, err := hex.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid checksum for %s: %w", assetName, err)
+		}
+
+		return checksum, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("no checksum for %s: %w", assetName, ErrChecksumNotFound)
+}
+
+// CheckRequiredVersion verifies that the given version satisfies a
+// constraint such as `v0.4.1`, `>= v0.4.0` or `>= v0.4.0, < v1.0.0`.
+func CheckRequiredVersion(version string, constraint string) error {
+	if !semver.IsValid(version) {
+		return fmt.Errorf("invalid version '%s': %w", version, ErrInvalidSemver)
+	}
+
+	for _, part := range strings.Split(constraint, ",") {
+		part = strings.TrimSpace(part)
+
+		operator := "="
+		for _, op := range []string{">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(part, op) {
+				operator = op
+				part = strings.TrimSpace(strings.TrimPrefix(part, op))
+				break
+			}
+		}
+
+		if !semver.IsValid(part) {
+			return fmt.Errorf("invalid version constraint '%s': %w", constraint, ErrInvalidSemver)
+		}
+
+		comparison := semver.Compare(version, part)
+		satisfied := map[string]bool{
+			"=":  comparison == 0,
+			"!=": comparison != 0,
+			">":  comparison > 0,
+			">=": comparison >= 0,
+			"<":  comparison < 0,
+			"<=": comparison <= 0,
+		}[operator]
+
+		if !satisfied {
+			return fmt.Errorf("version %s does not satisfy the required version %s", version, constraint)
+		}
+	}
+
+	return nil
 }
 
 func (updater *SelfUpdater) latestStableRelease(ctx context.Context) (*ghRelease, error) {
-	response, err := updater.httpGet(ctx, "https://api.github.com/repos/grafana/grizzly/releases/latest")
+	return updater.fetchRelease(ctx, releasesURL+"/latest")
+}
+
+func (updater *SelfUpdater) fetchRelease(ctx context.Context, url string) (*ghRelease, error) {
+	response, err := updater.httpGet(ctx, url)
 	defer func() {
 		if response != nil && response.Body != nil {
 			_ = response.Body.Close()
@@ -0,0 +1,111 @@
+package grizzly
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChecksum(t *testing.T) {
+	const checksums = `9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  grr-linux-amd64
+60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752 *grr-darwin-arm64
+not-hex  grr-windows-amd64.exe
+`
+
+	tests := []struct {
+		asset    string
+		expected string
+		err      error
+		errMsg   string
+	}{
+		{asset: "grr-linux-amd64", expected: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+		{asset: "grr-darwin-arm64", expected: "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"},
+		{asset: "grr-linux", err: ErrChecksumNotFound},
+		{asset: "grr-freebsd-amd64", err: ErrChecksumNotFound},
+		{asset: "grr-windows-amd64.exe", errMsg: "invalid checksum for grr-windows-amd64.exe"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.asset, func(t *testing.T) {
+			checksum, err := parseChecksum(strings.NewReader(checksums), test.asset)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			if test.errMsg != "" {
+				require.ErrorContains(t, err, test.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, hex.EncodeToString(checksum))
+		})
+	}
+}
+
+func TestChecksumForReleasesWithoutChecksums(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  grr-linux-amd64\n")
+	}))
+	defer server.Close()
+	updater := NewSelfUpdater(server.Client())
+
+	_, err := updater.checksumFor(context.Background(), &ghRelease{TagName: "v0.4.0"}, "grr-linux-amd64")
+	require.ErrorIs(t, err, ErrChecksumNotFound, "releases without checksums can't be verified")
+
+	release := &ghRelease{
+		TagName: "v0.5.0",
+		Assets:  []ghReleaseAsset{{Name: checksumsAsset, DownloadURL: server.URL + "/checksums.txt"}},
+	}
+	checksum, err := updater.checksumFor(context.Background(), release, "grr-linux-amd64")
+	require.NoError(t, err)
+	require.Len(t, checksum, 32)
+
+	_, err = updater.checksumFor(context.Background(), release, "grr-darwin-amd64")
+	require.ErrorIs(t, err, ErrChecksumNotFound)
+}
+
+func TestCheckRequiredVersion(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		satisfied  bool
+		err        error
+	}{
+		{version: "v0.4.1", constraint: "v0.4.1", satisfied: true},
+		{version: "v0.4.1", constraint: "= v0.4.1", satisfied: true},
+		{version: "v0.4.2", constraint: "v0.4.1"},
+		{version: "v0.4.1", constraint: ">= v0.4.0", satisfied: true},
+		{version: "v0.4.0", constraint: ">= v0.4.0", satisfied: true},
+		{version: "v0.3.9", constraint: ">= v0.4.0"},
+		{version: "v0.4.1", constraint: "> v0.4.1"},
+		{version: "v0.4.1", constraint: "<= v0.4.1", satisfied: true},
+		{version: "v0.4.1", constraint: "< v0.4.1"},
+		{version: "v0.4.1", constraint: "!= v0.4.1"},
+		{version: "v0.9.0", constraint: ">= v0.4.0, < v1.0.0", satisfied: true},
+		{version: "v1.0.0", constraint: ">= v0.4.0, < v1.0.0"},
+		{version: "v0.4.1", constraint: ">=v0.4.0,<v1.0.0", satisfied: true},
+		{version: "v0.4.1", constraint: ">= 0.4.0", err: ErrInvalidSemver},
+		{version: "v0.4.1", constraint: "latest", err: ErrInvalidSemver},
+		{version: "dev", constraint: ">= v0.4.0", err: ErrInvalidSemver},
+	}
+
+	for _, test := range tests {
+		t.Run(test.version+" "+test.constraint, func(t *testing.T) {
+			err := CheckRequiredVersion(test.version, test.constraint)
+			switch {
+			case test.err != nil:
+				require.ErrorIs(t, err, test.err)
+			case test.satisfied:
+				require.NoError(t, err)
+			default:
+				require.ErrorContains(t, err, "does not satisfy the required version")
+			}
+		})
+	}
+}
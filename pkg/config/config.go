@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -19,6 +20,17 @@ const (
 	CurrentContextSetting = "current-context"
 )
 
+// contextOverride, when set, is used instead of the current context stored
+// in the configuration
+var contextOverride string
+
+func currentContextName() string {
+	if contextOverride != "" {
+		return contextOverride
+	}
+	return viper.GetString(CurrentContextSetting)
+}
+
 func Initialise() {
 	viper.SetConfigName("settings")
 	viper.SetConfigType("yaml")
@@ -29,9 +41,10 @@ func Initialise() {
 
 func override(v *viper.Viper) {
 	bindings := map[string]string{
-		"grafana.url":   "GRAFANA_URL",
-		"grafana.user":  "GRAFANA_USER",
-		"grafana.token": "GRAFANA_TOKEN",
+		"grafana.url":    "GRAFANA_URL",
+		"grafana.user":   "GRAFANA_USER",
+		"grafana.token":  "GRAFANA_TOKEN",
+		"grafana.org-id": "GRAFANA_ORG_ID",
 
 		"synthetic-monitoring.access-token": "GRAFANA_SM_ACCESS_TOKEN",
 		"synthetic-monitoring.token":        "GRAFANA_SM_TOKEN",
@@ -40,9 +53,16 @@ func override(v *viper.Viper) {
 		"synthetic-monitoring.metrics-id":   "GRAFANA_SM_METRICS_ID",
 		"synthetic-monitoring.url":          "GRAFANA_SM_URL",
 
+		"oncall.url":   "GRAFANA_ONCALL_URL",
+		"oncall.token": "GRAFANA_ONCALL_TOKEN",
+
 		"mimir.address":   "MIMIR_ADDRESS",
 		"mimir.tenant-id": "MIMIR_TENANT_ID",
 		"mimir.api-key":   "MIMIR_API_KEY",
+
+		"loki.address":   "LOKI_ADDRESS",
+		"loki.tenant-id": "LOKI_TENANT_ID",
+		"loki.api-key":   "LOKI_API_KEY",
 	}
 
 	// To keep retro compatibility
@@ -90,7 +110,7 @@ func Mock(values map[string]interface{}) {
 }
 
 func Import() error {
-	name := viper.GetString(CurrentContextSetting)
+	name := currentContextName()
 	if name == "" {
 		NewConfig()
 		return Import()
@@ -141,8 +161,25 @@ func UseContext(context string) error {
 	return fmt.Errorf("context %s not found", context)
 }
 
+// OverrideContext uses the context of the given name instead of the current
+// one, until the returned function restores the previous one, e.g. for the
+// context of a workspace
+func OverrideContext(name string) (func(), error) {
+	contexts, err := GetContexts()
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(contexts, name) {
+		return nil, fmt.Errorf("context %s not found", name)
+	}
+
+	previous := contextOverride
+	contextOverride = name
+	return func() { contextOverride = previous }, nil
+}
+
 func CurrentContext() (*Context, error) {
-	name := viper.GetString(CurrentContextSetting)
+	name := currentContextName()
 	if name == "" {
 		NewConfig()
 		return CurrentContext()
@@ -167,22 +204,36 @@ var acceptableKeys = map[string]string{
 	"grafana.user":                      "string",
 	"grafana.insecure-skip-verify":      "bool",
 	"grafana.tls-host":                  "string",
+	"grafana.ca-path":                   "string",
+	"grafana.org-id":                    "int",
 	"mimir.address":                     "string",
 	"mimir.tenant-id":                   "string",
+	"mimir.tenants":                     "[]string",
 	"mimir.api-key":                     "string",
+	"mimir.tls.ca-path":                 "string",
+	"mimir.tls.insecure-skip-verify":    "bool",
+	"loki.address":                      "string",
+	"loki.tenant-id":                    "string",
+	"loki.api-key":                      "string",
+	"loki.tls.ca-path":                  "string",
+	"loki.tls.insecure-skip-verify":     "bool",
 	"synthetic-monitoring.access-token": "string",
 	"synthetic-monitoring.token":        "string",
 	"synthetic-monitoring.stack-id":     "int",
 	"synthetic-monitoring.metrics-id":   "int",
 	"synthetic-monitoring.logs-id":      "int",
 	"synthetic-monitoring.url":          "string",
+	"oncall.url":                        "string",
+	"oncall.token":                      "string",
 	"targets":                           "[]string",
 	"output-format":                     "string",
 	"only-spec":                         "bool",
+	"jsonnet-paths":                     "[]string",
+	"jsonnet-env":                       "[]string",
 }
 
 func Get(path, outputFormat string) (string, error) {
-	ctx := viper.GetString(CurrentContextSetting)
+	ctx := currentContextName()
 
 	vCtx := viper.Sub(fmt.Sprintf("contexts.%s", ctx))
 	if vCtx == nil {
@@ -227,49 +278,62 @@ func Get(path, outputFormat string) (string, error) {
 	return "", fmt.Errorf("unknown output format: %s", outputFormat)
 }
 
-func Set(path string, value string) error {
-	for key, typ := range acceptableKeys {
-		if path == key {
-			ctx := viper.GetString(CurrentContextSetting)
-			fullPath := fmt.Sprintf("contexts.%s.%s", ctx, path)
-			var val any
-			switch typ {
-			case "string":
-				val = value
-			case "[]string":
-				val = strings.Split(value, ",")
-			case "bool":
-				val = strings.ToLower(value) == "true"
-			case "int":
-				intValue, err := strconv.Atoi(value)
-				if err != nil {
-					return fmt.Errorf("key %s should be an integer: %s", key, err)
-				}
-				val = intValue
-			default:
-				return fmt.Errorf("unknown config key type %s for key %s", typ, key)
-			}
-			viper.Set(fullPath, val)
-			return Write()
+// acceptablePrefixes are the keys whose children can be set, e.g.
+// `grafana.headers.X-Scope-OrgID`
+var acceptablePrefixes = map[string]string{
+	"grafana.headers.": "string",
+	"mimir.headers.":   "string",
+	"loki.headers.":    "string",
+}
+
+// keyType returns the type of the values of an acceptable key
+func keyType(path string) (string, bool) {
+	if typ, ok := acceptableKeys[path]; ok {
+		return typ, true
+	}
+	for prefix, typ := range acceptablePrefixes {
+		if strings.HasPrefix(path, prefix) && len(path) > len(prefix) && !strings.Contains(path[len(prefix):], ".") {
+			return typ, true
 		}
 	}
-	return fmt.Errorf("key not recognised: %s", path)
+	return "", false
 }
 
-func Unset(path string) error {
-	exists := false
-	for k := range acceptableKeys {
-		if path == k {
-			exists = true
-			break
+func Set(path string, value string) error {
+	typ, ok := keyType(path)
+	if !ok {
+		return fmt.Errorf("key not recognised: %s", path)
+	}
+
+	ctx := currentContextName()
+	fullPath := fmt.Sprintf("contexts.%s.%s", ctx, path)
+	var val any
+	switch typ {
+	case "string":
+		val = value
+	case "[]string":
+		val = strings.Split(value, ",")
+	case "bool":
+		val = strings.ToLower(value) == "true"
+	case "int":
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("key %s should be an integer: %s", path, err)
 		}
+		val = intValue
+	default:
+		return fmt.Errorf("unknown config key type %s for key %s", typ, path)
 	}
+	viper.Set(fullPath, val)
+	return Write()
+}
 
-	if !exists {
+func Unset(path string) error {
+	if _, exists := keyType(path); !exists {
 		return fmt.Errorf("%s is not a valid path", path)
 	}
 
-	ctx := viper.GetString(CurrentContextSetting)
+	ctx := currentContextName()
 	fullPath := fmt.Sprintf("contexts.%s.%s", ctx, path)
 
 	if !viper.InConfig(fullPath) {
@@ -278,7 +342,7 @@ func Unset(path string) error {
 
 	parts := strings.Split(path, ".")
 	allConfig := viper.AllSettings()
-	deleteValue(allConfig, parts[1], "contexts", ctx, parts[0])
+	deleteValue(allConfig, parts[len(parts)-1], append([]string{"contexts", ctx}, parts[:len(parts)-1]...)...)
 
 	encodedConfig, err := json.MarshalIndent(allConfig, "", "	")
 	if err != nil {
@@ -6,19 +6,56 @@ type GrafanaConfig struct {
 	Token              string `yaml:"token" mapstructure:"token"`
 	InsecureSkipVerify bool   `yaml:"insecure-skip-verify" mapstructure:"insecure-skip-verify"`
 	TLSHost            string `yaml:"tls-host" mapstructure:"tls-host"`
+	// CAPath is the path of a PEM bundle of certificate authorities trusted
+	// on top of the system ones
+	CAPath string `yaml:"ca-path" mapstructure:"ca-path"`
+	// OrgID selects the organization of Grafana to use. Service account
+	// tokens are bound to their organization, so this requires basic auth.
+	OrgID int64 `yaml:"org-id" mapstructure:"org-id"`
+	// Headers are sent with every request to Grafana, e.g. the
+	// authentication headers of a gateway in front of it
+	Headers map[string]string `yaml:"headers,omitempty" mapstructure:"headers"`
 }
 
 type MimirConfig struct {
-	Address  string         `yaml:"address" mapstructure:"address"`
-	TenantID string         `yaml:"tenant-id" mapstructure:"tenant-id"`
-	APIKey   string         `yaml:"api-key" mapstructure:"api-key"`
-	TLS      MimirTLSConfig `yaml:"tls" mapstructure:"tls"`
+	Address  string `yaml:"address" mapstructure:"address"`
+	TenantID string `yaml:"tenant-id" mapstructure:"tenant-id"`
+	// Tenants are the tenants managed besides TenantID, whose rule groups
+	// are listed and pulled along with the ones of TenantID
+	Tenants []string       `yaml:"tenants,omitempty" mapstructure:"tenants"`
+	APIKey  string         `yaml:"api-key" mapstructure:"api-key"`
+	TLS     MimirTLSConfig `yaml:"tls" mapstructure:"tls"`
+	// Headers are sent with every request to Mimir, e.g. to reach
+	// Cortex-compatible backends
+	Headers map[string]string `yaml:"headers,omitempty" mapstructure:"headers"`
 }
 
 type MimirTLSConfig struct {
 	ClientCertPath string `yaml:"client-cert-path,omitempty" mapstructure:"client-cert-path"`
 	ClientKeyPath  string `yaml:"client-key-path,omitempty" mapstructure:"client-key-path"`
 	CAPath         string `yaml:"ca-path" mapstructure:"ca-path"`
+	// InsecureSkipVerify disables the verification of the certificates of
+	// Mimir
+	InsecureSkipVerify bool `yaml:"insecure-skip-verify,omitempty" mapstructure:"insecure-skip-verify"`
+}
+
+// LokiConfig configures the ruler of Loki, whose rule groups are managed
+// like the ones of Mimir
+type LokiConfig struct {
+	Address  string        `yaml:"address" mapstructure:"address"`
+	TenantID string        `yaml:"tenant-id" mapstructure:"tenant-id"`
+	APIKey   string        `yaml:"api-key" mapstructure:"api-key"`
+	TLS      LokiTLSConfig `yaml:"tls" mapstructure:"tls"`
+	// Headers are sent with every request to Loki
+	Headers map[string]string `yaml:"headers,omitempty" mapstructure:"headers"`
+}
+
+// LokiTLSConfig configures how the certificates of Loki are verified
+type LokiTLSConfig struct {
+	CAPath string `yaml:"ca-path,omitempty" mapstructure:"ca-path"`
+	// InsecureSkipVerify disables the verification of the certificates of
+	// Loki
+	InsecureSkipVerify bool `yaml:"insecure-skip-verify,omitempty" mapstructure:"insecure-skip-verify"`
 }
 
 type SyntheticMonitoringConfig struct {
@@ -31,14 +68,27 @@ type SyntheticMonitoringConfig struct {
 	AccessToken string `yaml:"access-token" mapstructure:"access-token"`
 }
 
+// OnCallConfig configures the API of Grafana OnCall, whose URL is shown in
+// the settings of the OnCall plugin
+type OnCallConfig struct {
+	URL   string `yaml:"url" mapstructure:"url"`
+	Token string `yaml:"token" mapstructure:"token"`
+}
+
 type Context struct {
 	Name                string                    `yaml:"name" mapstructure:"name"`
 	Grafana             GrafanaConfig             `yaml:"grafana" mapstructure:"grafana"`
 	Mimir               MimirConfig               `yaml:"mimir" mapstructure:"mimir"`
+	Loki                LokiConfig                `yaml:"loki" mapstructure:"loki"`
 	SyntheticMonitoring SyntheticMonitoringConfig `yaml:"synthetic-monitoring" mapstructure:"synthetic-monitoring"`
+	OnCall              OnCallConfig              `yaml:"oncall" mapstructure:"oncall"`
 	Targets             []string                  `yaml:"targets" mapstructure:"targets"`
 	OutputFormat        string                    `yaml:"output-format" mapstructure:"output-format"`
 	OnlySpec            bool                      `yaml:"only-spec" mapstructure:"only-spec"`
 	ResourceKind        string                    `yaml:"resource-kind" mapstructure:"resource-kind"`
 	FolderUID           string                    `yaml:"folder-uid" mapstructure:"folder-uid"`
+	JsonnetPaths        []string                  `yaml:"jsonnet-paths" mapstructure:"jsonnet-paths"`
+	// JsonnetEnv are the environment variables Jsonnet files can read with
+	// the getEnv native function
+	JsonnetEnv []string `yaml:"jsonnet-env" mapstructure:"jsonnet-env"`
 }
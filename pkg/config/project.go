@@ -0,0 +1,424 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigFile is the name of the per-repository configuration file
+const ProjectConfigFile = ".grizzly.yaml"
+
+// Project holds per-repository settings, used as defaults for CLI flags
+type Project struct {
+	ProjectSettings `yaml:",inline"`
+
+	// RequiredVersion constrains the versions of grizzly allowed to be used
+	// with the project, e.g. `>= v0.4.0, < v1.0.0`
+	RequiredVersion string `yaml:"required-version"`
+
+	// Profiles are named sets of settings (e.g. one per environment),
+	// overriding the project-wide ones when selected
+	Profiles map[string]ProjectSettings `yaml:"profiles"`
+
+	// Workspaces are named sets of resources of the project, each with its
+	// own sources, context and targets, selected with `--workspace`
+	Workspaces map[string]Workspace `yaml:"workspaces"`
+
+	// Path is the location of the file the project was loaded from
+	Path string `yaml:"-"`
+	// Profile is the name of the selected profile, if any
+	Profile string `yaml:"-"`
+}
+
+// Workspace is a set of resources of the project, applied to their own
+// context
+type Workspace struct {
+	// Sources are the resource paths of the workspace
+	Sources []string `yaml:"sources"`
+	// Context is the context of the workspace, the one of the project by
+	// default
+	Context string `yaml:"context"`
+	// Targets restrict the resources of the workspace, the targets of the
+	// project by default
+	Targets []string `yaml:"targets"`
+}
+
+type ProjectSettings struct {
+	Context      string        `yaml:"context"`
+	JsonnetPaths []string      `yaml:"jsonnet-paths"`
+	Targets      []string      `yaml:"targets"`
+	Ignore       []string      `yaml:"ignore"`
+	CacheDir     string        `yaml:"cache-dir"`
+	Parser       ProjectParser `yaml:"parser"`
+	Output       ProjectOutput `yaml:"output"`
+	// JsonnetAliases map prefixes of Jsonnet import paths to other
+	// locations, e.g. `grafonnet: vendor/github.com/grafana/grafonnet@v10`,
+	// so that upgrading a library only takes changing its alias
+	JsonnetAliases map[string]string `yaml:"jsonnet-aliases"`
+	// Strict turns warnings into failures
+	Strict *bool `yaml:"strict"`
+	// GrafanaVersion is the oldest version of Grafana the resources of the
+	// project must be compatible with, e.g. `10.4.0`
+	GrafanaVersion string       `yaml:"grafana-version"`
+	Watch          ProjectWatch `yaml:"watch"`
+	Pull           ProjectPull  `yaml:"pull"`
+	Apply          ProjectApply `yaml:"apply"`
+	// Managed marks the dashboards managed by Grizzly with a tag
+	Managed ProjectManaged `yaml:"managed"`
+	// DatasourceDefaults inject datasources into the panels of dashboards
+	// lacking one. The first matching rule applies.
+	DatasourceDefaults []DatasourceDefault `yaml:"datasource-defaults"`
+	// Placement maps directories of the project to folders and
+	// organizations. The first matching rule applies.
+	Placement []PlacementRule `yaml:"placement"`
+	// DashboardBudgets bound the size of dashboards, to keep them within
+	// what Grafana renders smoothly
+	DashboardBudgets DashboardBudgets `yaml:"dashboard-budgets"`
+	// Values is the values file YAML files are executed with, as Go
+	// templates
+	Values string `yaml:"values"`
+	// HelmValues are the values files overlaid when rendering Helm charts
+	HelmValues []string `yaml:"helm-values"`
+}
+
+// DashboardBudgets are the limits dashboards must stay within. Zero values
+// are not enforced.
+type DashboardBudgets struct {
+	// MaxSize is the size of the JSON model of a dashboard, in bytes or
+	// with a unit, e.g. `500KB` or `1MB`
+	MaxSize string `yaml:"max-size"`
+	// MaxPanels is the number of panels, including the ones nested in rows
+	// but not the rows themselves
+	MaxPanels int `yaml:"max-panels"`
+	// MaxQueries is the number of queries of all the panels
+	MaxQueries int `yaml:"max-queries"`
+}
+
+// IsZero tells whether no budget is set
+func (budgets DashboardBudgets) IsZero() bool {
+	return budgets == DashboardBudgets{}
+}
+
+// PlacementRule places the resources parsed from the files matching Path,
+// relative to the project root, e.g. `teams/<team>/**`, into a folder and
+// an organization. Names captured with `<name>` can be used in Folder and
+// FolderName.
+type PlacementRule struct {
+	Path string `yaml:"path"`
+	// Folder is the UID of the folder of dashboards
+	Folder string `yaml:"folder"`
+	// FolderName is the path of the folder of dashboards, created as
+	// needed, as with the `folderName` metadata
+	FolderName string `yaml:"folder-name"`
+	// Org is the ID of the organization of the resources, skipped when
+	// targeting other organizations
+	Org int64 `yaml:"org"`
+}
+
+// DatasourceDefault is the datasource injected into the panels and targets
+// of the dashboards in a folder, or with a tag, that don't set any. A rule
+// without folder nor tag applies to every dashboard.
+type DatasourceDefault struct {
+	// Folder is the UID of the folder of the dashboards
+	Folder string `yaml:"folder"`
+	// Tag is a tag of the dashboards
+	Tag        string        `yaml:"tag"`
+	Datasource DatasourceRef `yaml:"datasource"`
+}
+
+type DatasourceRef struct {
+	Type string `yaml:"type"`
+	UID  string `yaml:"uid"`
+}
+
+type ProjectParser struct {
+	ContinueOnError *bool  `yaml:"continue-on-error"`
+	FolderMap       string `yaml:"folder-map"`
+	ResourceKind    string `yaml:"resource-kind"`
+	FolderUID       string `yaml:"folder-uid"`
+	OnlySpec        *bool  `yaml:"only-spec"`
+}
+
+type ProjectWatch struct {
+	// Exec lists the commands run by `watch` on changes
+	Exec []string `yaml:"exec"`
+	// Apply tells whether `watch` applies resources on changes
+	Apply *bool `yaml:"apply"`
+	// Poll detects changes by polling files rather than with file system
+	// events, every PollInterval (e.g. `2s`)
+	Poll         *bool  `yaml:"poll"`
+	PollInterval string `yaml:"poll-interval"`
+}
+
+type ProjectPull struct {
+	// NameTemplate names the files of pulled resources, e.g.
+	// `{{ .kind }}/{{ .folder }}/{{ .uid }}.yaml`
+	NameTemplate string `yaml:"name-template"`
+}
+
+type ProjectApply struct {
+	// CircuitBreaker is the number of resources of a kind failing in a row
+	// after which the remaining ones are skipped
+	CircuitBreaker int `yaml:"circuit-breaker"`
+	// Timeouts bound the processing of each resource of a kind, e.g.
+	// `SyntheticMonitoringCheck: 30s`
+	Timeouts map[string]string `yaml:"timeouts"`
+}
+
+type ProjectManaged struct {
+	// Tag is added to the dashboards applied, e.g. `managed:grizzly`
+	Tag string `yaml:"tag"`
+	// OnlyManaged only lists and pulls the remote dashboards with the tag
+	OnlyManaged *bool `yaml:"only-managed"`
+}
+
+type ProjectOutput struct {
+	Format   string `yaml:"format"`
+	LogLevel string `yaml:"log-level"`
+	NoColor  *bool  `yaml:"no-color"`
+}
+
+var currentProject *Project
+
+// CurrentProject returns the project configuration loaded by LoadProject, if any
+func CurrentProject() *Project {
+	return currentProject
+}
+
+// LoadProject looks for a project configuration file in the given directory
+// and its parents. The closest one is loaded, with the settings of the given
+// profile (if any) applied, and its context (if any) is used instead of the
+// current one.
+func LoadProject(dir string, profile string) error {
+	path, err := findProjectConfig(dir)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		if profile != "" {
+			return fmt.Errorf("profile %s requested, but no %s file found", profile, ProjectConfigFile)
+		}
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	project := &Project{}
+	if err := yaml.Unmarshal(content, project); err != nil {
+		return fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	project.Path = path
+
+	if profile != "" {
+		settings, ok := project.Profiles[profile]
+		if !ok {
+			return fmt.Errorf("profile %s not found in %s", profile, path)
+		}
+		project.Profile = profile
+		project.ProjectSettings = project.ProjectSettings.merge(settings)
+	}
+
+	// paths are relative to the project configuration file
+	root := filepath.Dir(path)
+	for i, jpath := range project.JsonnetPaths {
+		project.JsonnetPaths[i] = project.resolve(root, jpath)
+	}
+	for alias, target := range project.JsonnetAliases {
+		if !strings.HasPrefix(target, "https://") {
+			project.JsonnetAliases[alias] = project.resolve(root, target)
+		}
+	}
+	for i, pattern := range project.Ignore {
+		// patterns without a separator match file names anywhere
+		if strings.Contains(pattern, "/") {
+			project.Ignore[i] = project.resolve(root, pattern)
+		}
+	}
+	if project.CacheDir != "" {
+		project.CacheDir = project.resolve(root, project.CacheDir)
+	}
+	if project.Parser.FolderMap != "" {
+		project.Parser.FolderMap = project.resolve(root, project.Parser.FolderMap)
+	}
+	if project.Values != "" {
+		project.Values = project.resolve(root, project.Values)
+	}
+	for i, values := range project.HelmValues {
+		project.HelmValues[i] = project.resolve(root, values)
+	}
+	for name, workspace := range project.Workspaces {
+		if len(workspace.Sources) == 0 {
+			return fmt.Errorf("workspace %s has no sources in %s", name, path)
+		}
+		for i, source := range workspace.Sources {
+			workspace.Sources[i] = project.resolve(root, source)
+		}
+	}
+
+	if project.Context != "" {
+		contextOverride = project.Context
+	}
+	currentProject = project
+
+	return nil
+}
+
+// Workspace returns a workspace of the project by name
+func (project *Project) Workspace(name string) (Workspace, error) {
+	workspace, ok := project.Workspaces[name]
+	if !ok {
+		return Workspace{}, fmt.Errorf("workspace %s not found in %s", name, project.Path)
+	}
+	return workspace, nil
+}
+
+// merge returns the settings, overridden by the ones set in `other`
+func (settings ProjectSettings) merge(other ProjectSettings) ProjectSettings {
+	merged := settings
+
+	if other.Context != "" {
+		merged.Context = other.Context
+	}
+	if len(other.JsonnetPaths) > 0 {
+		merged.JsonnetPaths = other.JsonnetPaths
+	}
+	if len(other.JsonnetAliases) > 0 {
+		merged.JsonnetAliases = other.JsonnetAliases
+	}
+	if len(other.Targets) > 0 {
+		merged.Targets = other.Targets
+	}
+	if len(other.Ignore) > 0 {
+		merged.Ignore = other.Ignore
+	}
+	if other.CacheDir != "" {
+		merged.CacheDir = other.CacheDir
+	}
+
+	if other.Strict != nil {
+		merged.Strict = other.Strict
+	}
+	if other.GrafanaVersion != "" {
+		merged.GrafanaVersion = other.GrafanaVersion
+	}
+	if len(other.DatasourceDefaults) > 0 {
+		merged.DatasourceDefaults = other.DatasourceDefaults
+	}
+	if len(other.Placement) > 0 {
+		merged.Placement = other.Placement
+	}
+	if other.DashboardBudgets.MaxSize != "" {
+		merged.DashboardBudgets.MaxSize = other.DashboardBudgets.MaxSize
+	}
+	if other.DashboardBudgets.MaxPanels != 0 {
+		merged.DashboardBudgets.MaxPanels = other.DashboardBudgets.MaxPanels
+	}
+	if other.DashboardBudgets.MaxQueries != 0 {
+		merged.DashboardBudgets.MaxQueries = other.DashboardBudgets.MaxQueries
+	}
+	if other.Values != "" {
+		merged.Values = other.Values
+	}
+	if len(other.HelmValues) > 0 {
+		merged.HelmValues = other.HelmValues
+	}
+
+	if other.Parser.ContinueOnError != nil {
+		merged.Parser.ContinueOnError = other.Parser.ContinueOnError
+	}
+	if other.Parser.FolderMap != "" {
+		merged.Parser.FolderMap = other.Parser.FolderMap
+	}
+	if other.Parser.ResourceKind != "" {
+		merged.Parser.ResourceKind = other.Parser.ResourceKind
+	}
+	if other.Parser.FolderUID != "" {
+		merged.Parser.FolderUID = other.Parser.FolderUID
+	}
+	if other.Parser.OnlySpec != nil {
+		merged.Parser.OnlySpec = other.Parser.OnlySpec
+	}
+
+	if len(other.Watch.Exec) > 0 {
+		merged.Watch.Exec = other.Watch.Exec
+	}
+	if other.Watch.Apply != nil {
+		merged.Watch.Apply = other.Watch.Apply
+	}
+	if other.Watch.Poll != nil {
+		merged.Watch.Poll = other.Watch.Poll
+	}
+	if other.Watch.PollInterval != "" {
+		merged.Watch.PollInterval = other.Watch.PollInterval
+	}
+
+	if other.Pull.NameTemplate != "" {
+		merged.Pull.NameTemplate = other.Pull.NameTemplate
+	}
+
+	if other.Apply.CircuitBreaker != 0 {
+		merged.Apply.CircuitBreaker = other.Apply.CircuitBreaker
+	}
+	if len(other.Apply.Timeouts) > 0 {
+		merged.Apply.Timeouts = other.Apply.Timeouts
+	}
+
+	if other.Managed.Tag != "" {
+		merged.Managed.Tag = other.Managed.Tag
+	}
+	if other.Managed.OnlyManaged != nil {
+		merged.Managed.OnlyManaged = other.Managed.OnlyManaged
+	}
+
+	if other.Output.Format != "" {
+		merged.Output.Format = other.Output.Format
+	}
+	if other.Output.LogLevel != "" {
+		merged.Output.LogLevel = other.Output.LogLevel
+	}
+	if other.Output.NoColor != nil {
+		merged.Output.NoColor = other.Output.NoColor
+	}
+
+	return merged
+}
+
+func (project *Project) resolve(root string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(root, path)
+}
+
+func findProjectConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectConfigFile)
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
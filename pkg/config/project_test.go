@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectSettingsMerge(t *testing.T) {
+	enabled, disabled := true, false
+	base := ProjectSettings{
+		Context:    "staging",
+		Targets:    []string{"Dashboard"},
+		Strict:     &enabled,
+		Values:     "values.yaml",
+		HelmValues: []string{"values-staging.yaml"},
+		Parser:     ProjectParser{FolderUID: "staging", OnlySpec: &enabled},
+		DashboardBudgets: DashboardBudgets{
+			MaxSize:   "1MB",
+			MaxPanels: 50,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		other    ProjectSettings
+		expected ProjectSettings
+	}{
+		{
+			name:     "unset settings are kept",
+			other:    ProjectSettings{},
+			expected: base,
+		},
+		{
+			name:  "set settings override",
+			other: ProjectSettings{Context: "production", Values: "values-prod.yaml", HelmValues: []string{"values-prod.yaml"}},
+			expected: func() ProjectSettings {
+				expected := base
+				expected.Context = "production"
+				expected.Values = "values-prod.yaml"
+				expected.HelmValues = []string{"values-prod.yaml"}
+				return expected
+			}(),
+		},
+		{
+			name:  "booleans are overridden when set, even to false",
+			other: ProjectSettings{Strict: &disabled, Parser: ProjectParser{OnlySpec: &disabled}},
+			expected: func() ProjectSettings {
+				expected := base
+				expected.Strict = &disabled
+				expected.Parser.OnlySpec = &disabled
+				return expected
+			}(),
+		},
+		{
+			name:  "nested settings are merged field by field",
+			other: ProjectSettings{Parser: ProjectParser{ResourceKind: "Dashboard"}, DashboardBudgets: DashboardBudgets{MaxQueries: 20}},
+			expected: func() ProjectSettings {
+				expected := base
+				expected.Parser.ResourceKind = "Dashboard"
+				expected.DashboardBudgets.MaxQueries = 20
+				return expected
+			}(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, base.merge(test.other))
+		})
+	}
+}
+
+func TestLoadProject(t *testing.T) {
+	t.Cleanup(func() {
+		currentProject = nil
+		contextOverride = ""
+	})
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "teams", "platform")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ProjectConfigFile), []byte(`
+context: staging
+targets: [Dashboard]
+values: values.yaml
+helm-values: [charts/values.yaml]
+profiles:
+  prod:
+    context: production
+    values: values-prod.yaml
+    helm-values: [charts/values.yaml, /etc/grizzly/values-prod.yaml]
+`), 0644))
+
+	t.Run("settings are read from the closest project, with paths relative to it", func(t *testing.T) {
+		require.NoError(t, LoadProject(dir, ""))
+		project := CurrentProject()
+		require.Equal(t, filepath.Join(root, ProjectConfigFile), project.Path)
+		require.Equal(t, "staging", project.Context)
+		require.Equal(t, filepath.Join(root, "values.yaml"), project.Values)
+		require.Equal(t, []string{filepath.Join(root, "charts", "values.yaml")}, project.HelmValues)
+	})
+
+	t.Run("profiles override the project-wide settings", func(t *testing.T) {
+		require.NoError(t, LoadProject(dir, "prod"))
+		project := CurrentProject()
+		require.Equal(t, "prod", project.Profile)
+		require.Equal(t, "production", project.Context)
+		require.Equal(t, []string{"Dashboard"}, project.Targets)
+		require.Equal(t, filepath.Join(root, "values-prod.yaml"), project.Values)
+		require.Equal(t, []string{filepath.Join(root, "charts", "values.yaml"), "/etc/grizzly/values-prod.yaml"}, project.HelmValues)
+	})
+
+	t.Run("unknown profiles are errors", func(t *testing.T) {
+		require.ErrorContains(t, LoadProject(dir, "dev"), "profile dev not found")
+		require.ErrorContains(t, LoadProject(t.TempDir(), "prod"), "no .grizzly.yaml file found")
+	})
+}
@@ -23,6 +23,19 @@ func NewAlertRuleGroupHandler(provider grizzly.Provider) *AlertRuleGroupHandler
 	}
 }
 
+// alertingProvisioningPermissions are the permissions required by the
+// alerting provisioning API, granted to administrators by default
+var alertingProvisioningPermissions = grizzly.Permissions{
+	Read:  grizzly.Access{Role: "Admin", Actions: []string{"alert.provisioning:read"}},
+	Write: grizzly.Access{Role: "Admin", Actions: []string{"alert.provisioning:read", "alert.provisioning:write"}},
+}
+
+// Permissions returns the permissions required to manage alert rule groups,
+// through the alerting provisioning API
+func (h *AlertRuleGroupHandler) Permissions() grizzly.Permissions {
+	return alertingProvisioningPermissions
+}
+
 const (
 	alertRuleGroupPattern = "alert-rules/alertRuleGroup-%s.%s"
 )
@@ -32,6 +45,19 @@ func (h *AlertRuleGroupHandler) ResourceFilePath(resource grizzly.Resource, file
 	return fmt.Sprintf(alertRuleGroupPattern, resource.Name(), filetype)
 }
 
+// DiffEngine matches the rules of groups by UID, so that reordering rules
+// isn't reported as a difference of all of them
+func (h *AlertRuleGroupHandler) DiffEngine() grizzly.DiffEngine {
+	return grizzly.KeyedListDiff{Field: "rules", Key: "uid"}
+}
+
+// Normalizers canonicalize the pending periods of rules, e.g. `300s` as `5m`
+func (h *AlertRuleGroupHandler) Normalizers() []grizzly.Normalizer {
+	return []grizzly.Normalizer{
+		grizzly.CanonicalDurations{Paths: [][]string{{"rules", "*", "for"}}},
+	}
+}
+
 // Validate checks that the uid format is valid
 func (h *AlertRuleGroupHandler) Validate(resource grizzly.Resource) error {
 	data, err := json.Marshal(resource.Spec())
@@ -60,26 +86,46 @@ func (h *AlertRuleGroupHandler) GetSpecUID(resource grizzly.Resource) (string, e
 
 // GetByUID retrieves JSON for a resource from an endpoint, by UID
 func (h *AlertRuleGroupHandler) GetByUID(uid string) (*grizzly.Resource, error) {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return nil, err
+	}
+
 	return h.getRemoteAlertRuleGroup(uid)
 }
 
 // GetRemote retrieves a alertRuleGroup as a Resource
 func (h *AlertRuleGroupHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return nil, err
+	}
+
 	return h.getRemoteAlertRuleGroup(resource.Name())
 }
 
 // ListRemote retrieves as list of UIDs of all remote resources
 func (h *AlertRuleGroupHandler) ListRemote() ([]string, error) {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return nil, err
+	}
+
 	return h.getRemoteAlertRuleGroupList()
 }
 
 // Add pushes a alertRuleGroup to Grafana via the API
 func (h *AlertRuleGroupHandler) Add(resource grizzly.Resource) error {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return err
+	}
+
 	return h.createAlertRuleGroup(resource)
 }
 
 // Update pushes a alertRuleGroup to Grafana via the API
 func (h *AlertRuleGroupHandler) Update(existing, resource grizzly.Resource) error {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return err
+	}
+
 	return h.putAlertRuleGroup(existing, resource)
 }
 
@@ -161,17 +207,20 @@ func (h *AlertRuleGroupHandler) createAlertRuleGroup(resource grizzly.Resource)
 		return err
 	}
 
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+	if err := prepareAlertRules(client, &group); err != nil {
+		return err
+	}
+
 	for _, r := range group.Rules {
 		if err := h.createAlertRule(r); err != nil {
 			return fmt.Errorf("creating rule for group %s: %w", resource.Name(), err)
 		}
 	}
 
-	client, err := h.Provider.(ClientProvider).Client()
-	if err != nil {
-		return err
-	}
-
 	params := provisioning.NewPutAlertRuleGroupParams().
 		WithBody(&group).
 		WithGroup(group.Title).
@@ -255,16 +304,20 @@ func (h *AlertRuleGroupHandler) putAlertRuleGroup(existing, resource grizzly.Res
 	if err != nil {
 		return err
 	}
-	for _, r := range group.Rules {
-		if err := h.updateAlertRule(r); err != nil {
-			return err
-		}
-	}
 
 	client, err := h.Provider.(ClientProvider).Client()
 	if err != nil {
 		return err
 	}
+	if err := prepareAlertRules(client, group); err != nil {
+		return err
+	}
+
+	for _, r := range group.Rules {
+		if err := h.updateAlertRule(r); err != nil {
+			return err
+		}
+	}
 
 	params := provisioning.NewPutAlertRuleGroupParams().
 		WithBody(group).
@@ -0,0 +1,168 @@
+package grafana
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	gclient "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/client/provisioning"
+	"github.com/grafana/grafana-openapi-client-go/models"
+)
+
+// alertRuleUIDLength is the length of derived alert rule UIDs, Grafana
+// limiting UIDs to 40 characters
+const alertRuleUIDLength = 24
+
+// expressionDatasourceUIDs are the datasources of server-side expressions,
+// which don't exist as datasources
+var expressionDatasourceUIDs = map[string]bool{
+	"__expr__": true,
+	"-100":     true,
+}
+
+// stableAlertRuleUID derives the UID of an alert rule from its folder, group
+// and title, so that rules keep the same UID in every environment
+func stableAlertRuleUID(folderUID, group, title string) string {
+	sum := sha256.Sum256([]byte(folderUID + "\x00" + group + "\x00" + title))
+	return hex.EncodeToString(sum[:])[:alertRuleUIDLength]
+}
+
+// alertReferences resolves the references of alert rules to datasources,
+// contact points and mute timings, listing each kind of resource once
+type alertReferences struct {
+	client *gclient.GrafanaHTTPAPI
+
+	// datasources maps the names and UIDs of datasources to their UID
+	datasources map[string]string
+	// contactPoints maps the names and UIDs of contact points to their name
+	contactPoints map[string]string
+	muteTimings   map[string]bool
+}
+
+// prepareAlertRules fills in what the rules of a group can leave out: their
+// folder and group, their UID, derived from their title, and references by
+// name where Grafana expects environment-specific UIDs
+func prepareAlertRules(client *gclient.GrafanaHTTPAPI, group *models.AlertRuleGroup) error {
+	references := &alertReferences{client: client}
+
+	for _, rule := range group.Rules {
+		if rule.FolderUID == nil || *rule.FolderUID == "" {
+			rule.FolderUID = &group.FolderUID
+		}
+		if rule.RuleGroup == nil || *rule.RuleGroup == "" {
+			rule.RuleGroup = &group.Title
+		}
+		if rule.Title == nil {
+			return fmt.Errorf("alert rule without a title in group %s", group.Title)
+		}
+		if rule.UID == "" {
+			rule.UID = stableAlertRuleUID(group.FolderUID, group.Title, *rule.Title)
+		}
+
+		if err := references.resolve(rule); err != nil {
+			return fmt.Errorf("alert rule %s: %w", *rule.Title, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *alertReferences) resolve(rule *models.ProvisionedAlertRule) error {
+	for _, query := range rule.Data {
+		if query == nil || query.DatasourceUID == "" || expressionDatasourceUIDs[query.DatasourceUID] {
+			continue
+		}
+		uid, err := r.datasource(query.DatasourceUID)
+		if err != nil {
+			return err
+		}
+		query.DatasourceUID = uid
+	}
+
+	settings := rule.NotificationSettings
+	if settings == nil {
+		return nil
+	}
+	if settings.Receiver != nil {
+		name, err := r.contactPoint(*settings.Receiver)
+		if err != nil {
+			return err
+		}
+		settings.Receiver = &name
+	}
+	for _, name := range settings.MuteTimeIntervals {
+		if err := r.muteTiming(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// datasource returns the UID of a datasource referenced by name or UID
+func (r *alertReferences) datasource(ref string) (string, error) {
+	if r.datasources == nil {
+		datasources, err := r.client.Datasources.GetDataSources()
+		if err != nil {
+			return "", fmt.Errorf("listing datasources: %w", err)
+		}
+		r.datasources = map[string]string{}
+		for _, datasource := range datasources.GetPayload() {
+			r.datasources[datasource.Name] = datasource.UID
+		}
+		// UIDs take precedence over names
+		for _, datasource := range datasources.GetPayload() {
+			r.datasources[datasource.UID] = datasource.UID
+		}
+	}
+
+	uid, ok := r.datasources[ref]
+	if !ok {
+		return "", fmt.Errorf("unknown datasource %s", ref)
+	}
+	return uid, nil
+}
+
+// contactPoint returns the name of a contact point referenced by name or UID
+func (r *alertReferences) contactPoint(ref string) (string, error) {
+	if r.contactPoints == nil {
+		contactPoints, err := r.client.Provisioning.GetContactpoints(provisioning.NewGetContactpointsParams())
+		if err != nil {
+			return "", fmt.Errorf("listing contact points: %w", err)
+		}
+		r.contactPoints = map[string]string{}
+		for _, contactPoint := range contactPoints.GetPayload() {
+			r.contactPoints[contactPoint.UID] = contactPoint.Name
+		}
+		// names take precedence over UIDs
+		for _, contactPoint := range contactPoints.GetPayload() {
+			r.contactPoints[contactPoint.Name] = contactPoint.Name
+		}
+	}
+
+	name, ok := r.contactPoints[ref]
+	if !ok {
+		return "", fmt.Errorf("unknown contact point %s", ref)
+	}
+	return name, nil
+}
+
+// muteTiming checks that a mute timing referenced by name exists
+func (r *alertReferences) muteTiming(name string) error {
+	if r.muteTimings == nil {
+		muteTimings, err := r.client.Provisioning.GetMuteTimings()
+		if err != nil {
+			return fmt.Errorf("listing mute timings: %w", err)
+		}
+		r.muteTimings = map[string]bool{}
+		for _, muteTiming := range muteTimings.GetPayload() {
+			r.muteTimings[muteTiming.Name] = true
+		}
+	}
+
+	if !r.muteTimings[name] {
+		return fmt.Errorf("unknown mute timing %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package grafana_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertRuleReferences(t *testing.T) {
+	group := func(t *testing.T, receiver string, muteTimings ...any) grizzly.Resource {
+		return grizzlytest.NewResource(t, "AlertRuleGroup", "alerts.nodes", map[string]any{
+			"folderUid": "alerts",
+			"title":     "nodes",
+			"interval":  60,
+			"rules": []any{map[string]any{
+				"title":     "Node down",
+				"condition": "A",
+				"for":       "5m",
+				"data": []any{
+					map[string]any{"refId": "A", "datasourceUid": "Prometheus", "model": map[string]any{"expr": "up == 0"}},
+					map[string]any{"refId": "B", "datasourceUid": "__expr__"},
+				},
+				"notification_settings": map[string]any{
+					"receiver":            receiver,
+					"mute_time_intervals": muteTimings,
+				},
+			}},
+		})
+	}
+	// apply applies the datasource, contact point and group to a new
+	// instance, where the datasource has an instance-specific UID
+	apply := func(t *testing.T, datasourceUID string, group grizzly.Resource) (*grizzlytest.Server, grizzly.Registry, error) {
+		t.Helper()
+		server := grizzlytest.NewServer(t)
+		server.AddMuteTiming("weekends")
+		registry := server.GrafanaRegistry()
+
+		resources := grizzly.NewResources(
+			grizzlytest.NewResource(t, "Datasource", datasourceUID, map[string]any{"uid": datasourceUID, "name": "Prometheus", "type": "prometheus", "access": "proxy"}),
+			grizzlytest.NewResource(t, "AlertContactPoint", "oncall", map[string]any{"uid": "oncall", "name": "On-call", "type": "email", "settings": map[string]any{"addresses": "oncall@example.com"}}),
+			group,
+		)
+		err := grizzly.Apply(registry, registry.Sort(resources), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText))
+		return server, registry, err
+	}
+	rule := func(t *testing.T, registry grizzly.Registry) map[string]any {
+		t.Helper()
+		handler, err := registry.GetHandler("AlertRuleGroup")
+		require.NoError(t, err)
+		remote, err := handler.GetByUID("alerts.nodes")
+		require.NoError(t, err)
+		rules := remote.GetSpecValue("rules").([]any)
+		require.Len(t, rules, 1)
+		return rules[0].(map[string]any)
+	}
+
+	_, production, err := apply(t, "prometheus-production", group(t, "oncall", "weekends"))
+	require.NoError(t, err)
+	productionRule := rule(t, production)
+	require.Equal(t, "prometheus-production", productionRule["data"].([]any)[0].(map[string]any)["datasourceUid"])
+	require.Equal(t, "__expr__", productionRule["data"].([]any)[1].(map[string]any)["datasourceUid"])
+	require.Equal(t, "On-call", productionRule["notification_settings"].(map[string]any)["receiver"])
+
+	_, staging, err := apply(t, "prometheus-staging", group(t, "On-call"))
+	require.NoError(t, err)
+	stagingRule := rule(t, staging)
+	require.Equal(t, "prometheus-staging", stagingRule["data"].([]any)[0].(map[string]any)["datasourceUid"])
+	require.NotEmpty(t, productionRule["uid"])
+	require.Equal(t, productionRule["uid"], stagingRule["uid"])
+
+	_, _, err = apply(t, "prometheus", group(t, "pagerduty"))
+	require.ErrorContains(t, err, "alert rule Node down: unknown contact point pagerduty")
+
+	_, _, err = apply(t, "prometheus", group(t, "oncall", "holidays"))
+	require.ErrorContains(t, err, "unknown mute timing holidays")
+}
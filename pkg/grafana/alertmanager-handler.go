@@ -0,0 +1,274 @@
+package grafana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+const AlertmanagerConfigKind = "AlertmanagerConfig"
+
+const (
+	// GrafanaAlertmanagerName is the name of the configuration of the
+	// Alertmanager built into Grafana. The configurations of external
+	// Alertmanagers are named after the UID of their datasource.
+	GrafanaAlertmanagerName = "grafana"
+
+	alertmanagerConfigEndpoint = "/api/alertmanager/%s/config/api/v1/alerts"
+	alertmanagerConfigPattern  = "alertmanager/config-%s.%s"
+	alertmanagerDatasourceType = "alertmanager"
+)
+
+// AlertmanagerConfigHandler is a Grizzly Handler for the full configuration
+// of an Alertmanager: its routes, receivers, inhibition rules and
+// templates. It manages the Alertmanager of Grafana, and the Mimir or Cortex
+// Alertmanagers of datasources, through Grafana.
+type AlertmanagerConfigHandler struct {
+	grizzly.BaseHandler
+}
+
+var _ grizzly.Handler = &AlertmanagerConfigHandler{}
+var _ grizzly.ExclusiveHandler = &AlertmanagerConfigHandler{}
+
+// NewAlertmanagerConfigHandler returns a new Grizzly Handler for
+// Alertmanager configurations
+func NewAlertmanagerConfigHandler(provider grizzly.Provider) *AlertmanagerConfigHandler {
+	return &AlertmanagerConfigHandler{
+		BaseHandler: grizzly.NewBaseHandler(provider, AlertmanagerConfigKind, false),
+	}
+}
+
+// Permissions returns the permissions required to manage the configurations
+// of the Alertmanager of Grafana and of external Alertmanagers
+func (h *AlertmanagerConfigHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Role: "Viewer", Actions: []string{"alert.notifications:read", "alert.notifications.external:read"}},
+		Write: grizzly.Access{Role: "Editor", Actions: []string{"alert.notifications:read", "alert.notifications:write", "alert.notifications.external:read", "alert.notifications.external:write"}},
+	}
+}
+
+// ResourceFilePath returns the location on disk where a resource should be updated
+func (h *AlertmanagerConfigHandler) ResourceFilePath(resource grizzly.Resource, filetype string) string {
+	return fmt.Sprintf(alertmanagerConfigPattern, resource.Name(), filetype)
+}
+
+// Unprepare removes the fields maintained by Grafana from a remote
+// configuration: the provenance of its objects, and the UIDs and set secure
+// settings of the receivers of the Alertmanager of Grafana, which differ
+// between stacks
+func (h *AlertmanagerConfigHandler) Unprepare(resource grizzly.Resource) *grizzly.Resource {
+	resource.DeleteSpecKey("template_file_provenances")
+	config, _ := resource.GetSpecValue("alertmanager_config").(map[string]any)
+	delete(config, "muteTimeProvenances")
+	receivers, _ := config["receivers"].([]any)
+	for _, receiver := range receivers {
+		receiver, _ := receiver.(map[string]any)
+		configs, _ := receiver["grafana_managed_receiver_configs"].([]any)
+		for _, config := range configs {
+			if config, ok := config.(map[string]any); ok {
+				delete(config, "uid")
+				delete(config, "secureFields")
+			}
+		}
+	}
+	return &resource
+}
+
+// Prepare gives the receivers of the Alertmanager of Grafana the UIDs of
+// their existing counterparts, matched by receiver name and type, as
+// Grafana keeps the secure settings of receivers by UID. Pulled
+// configurations don't hold secure settings, so they would be wiped.
+func (h *AlertmanagerConfigHandler) Prepare(existing *grizzly.Resource, resource grizzly.Resource) *grizzly.Resource {
+	if existing == nil || resource.Name() != GrafanaAlertmanagerName {
+		return &resource
+	}
+
+	existingConfigs := grafanaReceiverConfigs(*existing)
+	for name, configs := range grafanaReceiverConfigs(resource) {
+		used := map[int]bool{}
+		for _, config := range configs {
+			if uid, _ := config["uid"].(string); uid != "" {
+				continue
+			}
+			for i, existingConfig := range existingConfigs[name] {
+				if used[i] || existingConfig["type"] != config["type"] {
+					continue
+				}
+				if uid, _ := existingConfig["uid"].(string); uid != "" {
+					config["uid"] = uid
+					used[i] = true
+					break
+				}
+			}
+		}
+	}
+	return &resource
+}
+
+// grafanaReceiverConfigs returns the integrations of the receivers of the
+// Alertmanager of Grafana, by receiver name
+func grafanaReceiverConfigs(resource grizzly.Resource) map[string][]map[string]any {
+	receiverConfigs := map[string][]map[string]any{}
+	config, _ := resource.GetSpecValue("alertmanager_config").(map[string]any)
+	receivers, _ := config["receivers"].([]any)
+	for _, receiver := range receivers {
+		receiver, _ := receiver.(map[string]any)
+		name, _ := receiver["name"].(string)
+		configs, _ := receiver["grafana_managed_receiver_configs"].([]any)
+		for _, config := range configs {
+			if config, ok := config.(map[string]any); ok {
+				receiverConfigs[name] = append(receiverConfigs[name], config)
+			}
+		}
+	}
+	return receiverConfigs
+}
+
+// ConflictingKinds returns the contact points and notification policy for
+// the Alertmanager of Grafana, whose configuration embeds them
+func (h *AlertmanagerConfigHandler) ConflictingKinds(resource grizzly.Resource) []string {
+	if resource.Name() != GrafanaAlertmanagerName {
+		return nil
+	}
+	return []string{"AlertContactPoint", "AlertNotificationPolicy"}
+}
+
+// Validate checks that the configuration has a route, and that its routes
+// send alerts to receivers it defines
+func (h *AlertmanagerConfigHandler) Validate(resource grizzly.Resource) error {
+	if strings.Contains(resource.Name(), "/") {
+		return fmt.Errorf("'%s' is not a valid Alertmanager name: expected '%s' or the UID of an Alertmanager datasource", resource.Name(), GrafanaAlertmanagerName)
+	}
+	config, ok := resource.GetSpecValue("alertmanager_config").(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s %s requires an alertmanager_config", h.Kind(), resource.Name())
+	}
+	route, ok := config["route"].(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s %s requires a route", h.Kind(), resource.Name())
+	}
+
+	receivers := map[string]bool{}
+	defined, _ := config["receivers"].([]any)
+	for _, receiver := range defined {
+		receiver, _ := receiver.(map[string]any)
+		if name, _ := receiver["name"].(string); name != "" {
+			receivers[name] = true
+		}
+	}
+	if name, _ := route["receiver"].(string); name == "" {
+		return fmt.Errorf("%s %s: the root route requires a receiver", h.Kind(), resource.Name())
+	}
+	return validateRouteReceivers(route, receivers)
+}
+
+// validateRouteReceivers checks that a route and its nested routes send
+// alerts to known receivers. Nested routes inherit the receiver of their
+// parent when they don't set one.
+func validateRouteReceivers(route map[string]any, receivers map[string]bool) error {
+	if name, _ := route["receiver"].(string); name != "" && !receivers[name] {
+		return fmt.Errorf("route refers to unknown receiver '%s'", name)
+	}
+	routes, _ := route["routes"].([]any)
+	for _, nested := range routes {
+		if nested, ok := nested.(map[string]any); ok {
+			if err := validateRouteReceivers(nested, receivers); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (h *AlertmanagerConfigHandler) GetSpecUID(resource grizzly.Resource) (string, error) {
+	return "", fmt.Errorf("GetSpecUID not implemented for Alertmanager configurations")
+}
+
+// GetByUID retrieves the configuration of an Alertmanager, by name
+func (h *AlertmanagerConfigHandler) GetByUID(name string) (*grizzly.Resource, error) {
+	return h.getRemoteConfig(name)
+}
+
+// GetRemote retrieves the configuration of an Alertmanager as a Resource
+func (h *AlertmanagerConfigHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	return h.getRemoteConfig(resource.Name())
+}
+
+// ListRemote lists the Alertmanager of Grafana, and the Alertmanager
+// datasources whose configuration can be managed. The configuration of
+// Prometheus Alertmanagers is read from files, and can't be.
+func (h *AlertmanagerConfigHandler) ListRemote() ([]string, error) {
+	resp, err := h.Provider.(*Provider).get("/api/datasources")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var datasources []map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&datasources); err != nil {
+		return nil, err
+	}
+
+	names := []string{GrafanaAlertmanagerName}
+	for _, datasource := range datasources {
+		if datasource["type"] != alertmanagerDatasourceType {
+			continue
+		}
+		jsonData, _ := datasource["jsonData"].(map[string]any)
+		if jsonData["implementation"] == "prometheus" {
+			continue
+		}
+		if uid, _ := datasource["uid"].(string); uid != "" {
+			names = append(names, uid)
+		}
+	}
+	return names, nil
+}
+
+// Add pushes the configuration of an Alertmanager. Alertmanagers always
+// have a configuration, so this replaces it as Update does.
+func (h *AlertmanagerConfigHandler) Add(resource grizzly.Resource) error {
+	return h.postConfig(resource)
+}
+
+// Update replaces the configuration of an Alertmanager
+func (h *AlertmanagerConfigHandler) Update(existing, resource grizzly.Resource) error {
+	return h.postConfig(resource)
+}
+
+// getRemoteConfig retrieves the configuration of an Alertmanager. Grafana
+// answers 404 for unknown datasources.
+func (h *AlertmanagerConfigHandler) getRemoteConfig(name string) (*grizzly.Resource, error) {
+	resp, err := h.Provider.(*Provider).get(fmt.Sprintf(alertmanagerConfigEndpoint, url.PathEscape(name)))
+	var status grizzly.HTTPStatusError
+	if errors.As(err, &status) && status.StatusCode == http.StatusNotFound {
+		return nil, grizzly.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	spec := map[string]any{}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		return nil, err
+	}
+	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), name, spec)
+	if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+func (h *AlertmanagerConfigHandler) postConfig(resource grizzly.Resource) error {
+	resp, err := h.Provider.(*Provider).send(http.MethodPost, fmt.Sprintf(alertmanagerConfigEndpoint, url.PathEscape(resource.Name())), resource.Spec())
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,143 @@
+package grafana_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertmanagerConfig(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+	handler, err := registry.GetHandler(grafana.AlertmanagerConfigKind)
+	require.NoError(t, err)
+
+	apply := func(t *testing.T, resources ...grizzly.Resource) error {
+		t.Helper()
+		return grizzly.Apply(registry, grizzly.NewResources(resources...), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText))
+	}
+	config := func(t *testing.T, name string, receivers []any, routes []any) grizzly.Resource {
+		t.Helper()
+		return grizzlytest.NewResource(t, grafana.AlertmanagerConfigKind, name, map[string]any{
+			"template_files": map[string]any{},
+			"alertmanager_config": map[string]any{
+				"route": map[string]any{
+					"receiver":        "platform",
+					"group_by":        []any{"alertname"},
+					"routes":          routes,
+					"repeat_interval": "4h",
+				},
+				"receivers": receivers,
+				"inhibit_rules": []any{
+					map[string]any{
+						"source_matchers": []any{"severity=critical"},
+						"target_matchers": []any{"severity=warning"},
+						"equal":           []any{"alertname"},
+					},
+				},
+			},
+		})
+	}
+	grafanaReceiver := func(name string, address string) any {
+		return map[string]any{
+			"name": name,
+			"grafana_managed_receiver_configs": []any{
+				map[string]any{"name": name, "type": "email", "settings": map[string]any{"addresses": address}},
+			},
+		}
+	}
+
+	t.Run("the configuration of Grafana is applied and compared", func(t *testing.T) {
+		receivers := []any{grafanaReceiver("platform", "platform@example.com"), grafanaReceiver("databases", "dba@example.com")}
+		routes := []any{map[string]any{"receiver": "databases", "object_matchers": []any{[]any{"team", "=", "databases"}}}}
+		require.NoError(t, apply(t, config(t, grafana.GrafanaAlertmanagerName, receivers, routes)))
+
+		stored, found := server.AlertmanagerConfig(grafana.GrafanaAlertmanagerName)
+		require.True(t, found)
+		require.Len(t, stored["alertmanager_config"].(map[string]any)["inhibit_rules"], 1)
+
+		summary := grizzly.NewDiffSummary()
+		require.NoError(t, grizzly.Diff(registry, grizzly.NewResources(config(t, grafana.GrafanaAlertmanagerName, receivers, routes)), false, "yaml", summary))
+		require.Equal(t, 1, summary.Count(grafana.AlertmanagerConfigKind, grizzly.ResourceNotChanged), "receiver UIDs and secure fields are ignored")
+
+		receivers[1] = grafanaReceiver("databases", "oncall@example.com")
+		summary = grizzly.NewDiffSummary()
+		require.NoError(t, grizzly.Diff(registry, grizzly.NewResources(config(t, grafana.GrafanaAlertmanagerName, receivers, routes)), false, "yaml", summary))
+		require.Equal(t, 1, summary.Count(grafana.AlertmanagerConfigKind, grizzly.ResourceChanged))
+	})
+
+	t.Run("secure settings survive updates of pulled configurations", func(t *testing.T) {
+		slack := map[string]any{
+			"name": "slack",
+			"grafana_managed_receiver_configs": []any{
+				map[string]any{
+					"name":           "slack",
+					"type":           "slack",
+					"settings":       map[string]any{"recipient": "#platform"},
+					"secureSettings": map[string]any{"url": "https://hooks.slack.com/services/secret"},
+				},
+			},
+		}
+		receivers := []any{grafanaReceiver("platform", "platform@example.com"), slack}
+		require.NoError(t, apply(t, config(t, grafana.GrafanaAlertmanagerName, receivers, nil)))
+
+		// pulled configurations have neither the UIDs nor the secure
+		// settings of receivers
+		pulled, err := handler.GetByUID(grafana.GrafanaAlertmanagerName)
+		require.NoError(t, err)
+		pulled = handler.Unprepare(*pulled)
+		alertmanagerConfig := pulled.GetSpecValue("alertmanager_config").(map[string]any)
+		slackConfig := alertmanagerConfig["receivers"].([]any)[1].(map[string]any)["grafana_managed_receiver_configs"].([]any)[0].(map[string]any)
+		require.Nil(t, slackConfig["uid"])
+		require.Nil(t, slackConfig["secureSettings"])
+
+		slackConfig["settings"] = map[string]any{"recipient": "#platform-alerts"}
+		require.NoError(t, apply(t, *pulled))
+		require.Equal(t, []map[string]any{{"url": "https://hooks.slack.com/services/secret"}}, server.ReceiverSecureSettings("slack"))
+		stored, _ := server.AlertmanagerConfig(grafana.GrafanaAlertmanagerName)
+		require.Contains(t, fmt.Sprint(stored["alertmanager_config"]), "#platform-alerts")
+	})
+
+	t.Run("the configuration of Grafana isn't applied along with contact points and policies", func(t *testing.T) {
+		policy := grizzlytest.NewResource(t, "AlertNotificationPolicy", grafana.GlobalAlertNotificationPolicyName, map[string]any{"receiver": "platform"})
+		receivers := []any{grafanaReceiver("platform", "platform@example.com")}
+		err := apply(t, config(t, grafana.GrafanaAlertmanagerName, receivers, nil), policy)
+		require.ErrorContains(t, err, "overwrites the AlertNotificationPolicy resources applied along with it")
+	})
+
+	t.Run("external Alertmanagers are configured through their datasource", func(t *testing.T) {
+		require.NoError(t, apply(t,
+			grizzlytest.NewResource(t, "Datasource", "mimir-alertmanager", map[string]any{"name": "Mimir Alertmanager", "type": "alertmanager", "access": "proxy", "jsonData": map[string]any{"implementation": "mimir"}}),
+			grizzlytest.NewResource(t, "Datasource", "prometheus-alertmanager", map[string]any{"name": "Prometheus Alertmanager", "type": "alertmanager", "access": "proxy", "jsonData": map[string]any{"implementation": "prometheus"}}),
+			grizzlytest.NewResource(t, "Datasource", "prometheus", map[string]any{"name": "Prometheus", "type": "prometheus", "access": "proxy"}),
+		))
+
+		names, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.Equal(t, []string{grafana.GrafanaAlertmanagerName, "mimir-alertmanager"}, names)
+
+		receivers := []any{map[string]any{"name": "platform", "slack_configs": []any{map[string]any{"channel": "#platform"}}}}
+		require.NoError(t, apply(t, config(t, "mimir-alertmanager", receivers, nil)))
+		stored, found := server.AlertmanagerConfig("mimir-alertmanager")
+		require.True(t, found)
+		require.Equal(t, "platform", stored["alertmanager_config"].(map[string]any)["route"].(map[string]any)["receiver"])
+
+		_, err = handler.GetByUID("unknown")
+		require.ErrorIs(t, err, grizzly.ErrNotFound)
+	})
+
+	t.Run("routes must send alerts to known receivers", func(t *testing.T) {
+		receivers := []any{grafanaReceiver("platform", "platform@example.com")}
+		require.NoError(t, handler.Validate(config(t, grafana.GrafanaAlertmanagerName, receivers, nil)))
+
+		routes := []any{map[string]any{"receiver": "frontend"}}
+		require.ErrorContains(t, handler.Validate(config(t, grafana.GrafanaAlertmanagerName, receivers, routes)), "unknown receiver 'frontend'")
+		require.ErrorContains(t, handler.Validate(config(t, grafana.GrafanaAlertmanagerName, nil, nil)), "unknown receiver 'platform'")
+		require.ErrorContains(t, handler.Validate(grizzlytest.NewResource(t, grafana.AlertmanagerConfigKind, "grafana", map[string]any{})), "requires an alertmanager_config")
+	})
+}
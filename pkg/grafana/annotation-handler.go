@@ -0,0 +1,417 @@
+package grafana
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/grafana/grafana-openapi-client-go/client/annotations"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+const AnnotationKind = "Annotation"
+
+// AnnotationHandler is a Grizzly Handler for Grafana annotations, e.g.
+// maintenance windows or release markers. Annotations have no UID: a resource
+// matches the existing annotation with the same tags and time range.
+type AnnotationHandler struct {
+	grizzly.BaseHandler
+}
+
+var _ grizzly.Handler = &AnnotationHandler{}
+var _ grizzly.DeleteHandler = &AnnotationHandler{}
+
+// NewAnnotationHandler returns a new Grizzly Handler for Grafana annotations
+func NewAnnotationHandler(provider grizzly.Provider) *AnnotationHandler {
+	return &AnnotationHandler{
+		BaseHandler: grizzly.NewBaseHandler(provider, AnnotationKind, false),
+	}
+}
+
+// Permissions returns the permissions required to manage annotations
+func (h *AnnotationHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Role: "Viewer", Actions: []string{"annotations:read"}},
+		Write: grizzly.Access{Role: "Editor", Actions: []string{"annotations:read", "annotations:create", "annotations:write", "annotations:delete"}},
+	}
+}
+
+const (
+	annotationPattern = "annotations/annotation-%s.%s"
+)
+
+// ResourceFilePath returns the location on disk where a resource should be updated
+func (h *AnnotationHandler) ResourceFilePath(resource grizzly.Resource, filetype string) string {
+	return fmt.Sprintf(annotationPattern, resource.Name(), filetype)
+}
+
+// Unprepare removes unnecessary elements from a remote resource ready for presentation/comparison
+func (h *AnnotationHandler) Unprepare(resource grizzly.Resource) *grizzly.Resource {
+	resource.DeleteSpecKey("id")
+	return &resource
+}
+
+// Prepare gets a resource ready for dispatch to the remote endpoint
+func (h *AnnotationHandler) Prepare(existing *grizzly.Resource, resource grizzly.Resource) *grizzly.Resource {
+	return &resource
+}
+
+// Normalizers write times in UTC and sort tags, as Grafana doesn't keep their
+// order
+func (h *AnnotationHandler) Normalizers() []grizzly.Normalizer {
+	return []grizzly.Normalizer{grizzly.NormalizerFunc(normalizeAnnotation)}
+}
+
+// Validate checks that annotations have a text, tags to match them by, and a
+// valid time range
+func (h *AnnotationHandler) Validate(resource grizzly.Resource) error {
+	if text, _ := resource.GetSpecString("text"); text == "" {
+		return fmt.Errorf("text of annotation is required")
+	}
+	tags, err := annotationTags(resource)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("annotations are matched by their tags, at least one tag is required")
+	}
+	_, _, err = annotationTimeRange(resource)
+	return err
+}
+
+// GetSpecUID is unsupported: annotations are identified by their tags and
+// time range, so resources need a name
+func (h *AnnotationHandler) GetSpecUID(resource grizzly.Resource) (string, error) {
+	return "", fmt.Errorf("annotations have no UID, they need a name")
+}
+
+// GetByUID retrieves JSON for a resource from an endpoint, by ID
+func (h *AnnotationHandler) GetByUID(id string) (*grizzly.Resource, error) {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+
+	annotationOk, err := client.Annotations.GetAnnotationByID(id)
+	if err != nil {
+		// OpenAPI definition does not define 404 for GetAnnotationByID, so falls though to runtime.APIError.
+		var gErr *runtime.APIError
+		if errors.As(err, &gErr) && gErr.IsCode(http.StatusNotFound) {
+			return nil, grizzly.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return h.annotationResource(id, annotationOk.GetPayload())
+}
+
+// GetRemote retrieves the annotation with the same tags and time range as a
+// resource
+func (h *AnnotationHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	annotation, err := h.findAnnotation(resource)
+	if err != nil {
+		return nil, err
+	}
+	return h.annotationResource(resource.Name(), annotation)
+}
+
+// ListRemote retrieves as list of IDs of the annotations created by users,
+// leaving out the ones of alerts
+func (h *AnnotationHandler) ListRemote() ([]string, error) {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		limit          int64 = 1000
+		annotationType       = "annotation"
+	)
+	params := annotations.NewGetAnnotationsParams().WithType(&annotationType).WithLimit(&limit)
+	annotationsOk, err := client.Annotations.GetAnnotations(params)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, annotation := range annotationsOk.GetPayload() {
+		ids = append(ids, strconv.FormatInt(annotation.ID, 10))
+	}
+	return ids, nil
+}
+
+// Add creates an annotation
+func (h *AnnotationHandler) Add(resource grizzly.Resource) error {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	command, err := annotationCommand(resource)
+	if err != nil {
+		return err
+	}
+	_, err = client.Annotations.PostAnnotation(command)
+	return err
+}
+
+// Update changes the text of the matching annotation. Annotations can't be
+// moved to another dashboard or panel, so these are replaced instead.
+func (h *AnnotationHandler) Update(existing, resource grizzly.Resource) error {
+	annotation, err := h.findAnnotation(resource)
+	if err != nil {
+		return err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	command, err := annotationCommand(resource)
+	if err != nil {
+		return err
+	}
+	id := strconv.FormatInt(annotation.ID, 10)
+	if command.DashboardUID != annotation.DashboardUID || command.PanelID != annotation.PanelID {
+		if _, err := client.Annotations.DeleteAnnotationByID(id); err != nil {
+			return err
+		}
+		_, err = client.Annotations.PostAnnotation(command)
+		return err
+	}
+
+	_, err = client.Annotations.UpdateAnnotation(id, &models.UpdateAnnotationsCmd{
+		Text:    *command.Text,
+		Tags:    command.Tags,
+		Time:    command.Time,
+		TimeEnd: command.TimeEnd,
+	})
+	return err
+}
+
+// Delete deletes the annotation matching a resource
+func (h *AnnotationHandler) Delete(resource grizzly.Resource) error {
+	annotation, err := h.findAnnotation(resource)
+	if err != nil {
+		return err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Annotations.DeleteAnnotationByID(strconv.FormatInt(annotation.ID, 10))
+	return err
+}
+
+// findAnnotation returns the annotation with exactly the tags and time range
+// of a resource, the oldest one if several do
+func (h *AnnotationHandler) findAnnotation(resource grizzly.Resource) (*models.Annotation, error) {
+	tags, err := annotationTags(resource)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := annotationTimeRange(resource)
+	if err != nil {
+		return nil, err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		limit          int64 = 1000
+		annotationType       = "annotation"
+		matchAny             = false
+	)
+	params := annotations.NewGetAnnotationsParams().
+		WithType(&annotationType).
+		WithTags(tags).
+		WithMatchAny(&matchAny).
+		WithFrom(&from).
+		WithTo(&to).
+		WithLimit(&limit)
+	annotationsOk, err := client.Annotations.GetAnnotations(params)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(tags)
+	var found *models.Annotation
+	for _, annotation := range annotationsOk.GetPayload() {
+		timeEnd := annotation.TimeEnd
+		if timeEnd == 0 {
+			timeEnd = annotation.Time
+		}
+		if annotation.Time != from || timeEnd != to {
+			continue
+		}
+		remoteTags := slices.Clone(annotation.Tags)
+		sort.Strings(remoteTags)
+		if !slices.Equal(tags, remoteTags) {
+			continue
+		}
+		if found == nil || annotation.ID < found.ID {
+			found = annotation
+		}
+	}
+	if found == nil {
+		return nil, grizzly.ErrNotFound
+	}
+	return found, nil
+}
+
+// annotationResource turns an annotation into a resource, writing times in UTC
+func (h *AnnotationHandler) annotationResource(name string, annotation *models.Annotation) (*grizzly.Resource, error) {
+	tags := []any{}
+	for _, tag := range annotation.Tags {
+		tags = append(tags, tag)
+	}
+	spec := map[string]any{
+		"id":   annotation.ID,
+		"text": annotation.Text,
+		"tags": tags,
+		"time": formatAnnotationTime(annotation.Time),
+	}
+	if annotation.TimeEnd != 0 && annotation.TimeEnd != annotation.Time {
+		spec["timeEnd"] = formatAnnotationTime(annotation.TimeEnd)
+	}
+	if annotation.DashboardUID != "" {
+		spec["dashboardUID"] = annotation.DashboardUID
+	}
+	if annotation.PanelID != 0 {
+		spec["panelId"] = annotation.PanelID
+	}
+	normalizeAnnotation(spec)
+
+	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), name, spec)
+	if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// annotationCommand returns the command creating the annotation of a
+// resource
+func annotationCommand(resource grizzly.Resource) (*models.PostAnnotationsCmd, error) {
+	tags, err := annotationTags(resource)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := annotationTimeRange(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	text, _ := resource.GetSpecString("text")
+	dashboardUID, _ := resource.GetSpecString("dashboardUID")
+	command := &models.PostAnnotationsCmd{
+		Text:         &text,
+		Tags:         tags,
+		Time:         from,
+		TimeEnd:      to,
+		DashboardUID: dashboardUID,
+	}
+	switch panelID := resource.GetSpecValue("panelId").(type) {
+	case float64:
+		command.PanelID = int64(panelID)
+	case int:
+		command.PanelID = int64(panelID)
+	}
+	return command, nil
+}
+
+// annotationTags returns the tags of an annotation resource
+func annotationTags(resource grizzly.Resource) ([]string, error) {
+	list, ok := resource.GetSpecValue("tags").([]any)
+	if !ok && resource.GetSpecValue("tags") != nil {
+		return nil, fmt.Errorf("tags must be a list of strings")
+	}
+
+	tags := make([]string, 0, len(list))
+	for _, tag := range list {
+		value, ok := tag.(string)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("tags must be a list of strings, got %v", tag)
+		}
+		tags = append(tags, value)
+	}
+	return tags, nil
+}
+
+// annotationTimeRange returns the time range of an annotation resource, in
+// milliseconds since the epoch. Without timeEnd, annotations mark a point in
+// time.
+func annotationTimeRange(resource grizzly.Resource) (int64, int64, error) {
+	from, err := parseAnnotationTime(resource.GetSpecValue("time"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time of annotation: %w", err)
+	}
+	if resource.GetSpecValue("timeEnd") == nil {
+		return from, from, nil
+	}
+
+	to, err := parseAnnotationTime(resource.GetSpecValue("timeEnd"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid timeEnd of annotation: %w", err)
+	}
+	if to < from {
+		return 0, 0, fmt.Errorf("timeEnd of annotation is before its time")
+	}
+	return from, to, nil
+}
+
+// parseAnnotationTime parses a time given in RFC 3339 format, e.g.
+// `2024-05-01T22:00:00Z`, or in milliseconds since the epoch
+func parseAnnotationTime(value any) (int64, error) {
+	switch value := value.(type) {
+	case string:
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a time, expected e.g. 2024-05-01T22:00:00Z", value)
+		}
+		return parsed.UnixMilli(), nil
+	case time.Time:
+		return value.UnixMilli(), nil
+	case float64:
+		return int64(value), nil
+	case int:
+		return int64(value), nil
+	case int64:
+		return value, nil
+	case nil:
+		return 0, fmt.Errorf("time is required")
+	}
+
+	return 0, fmt.Errorf("%v is not a time, expected e.g. 2024-05-01T22:00:00Z", value)
+}
+
+func formatAnnotationTime(milliseconds int64) string {
+	return time.UnixMilli(milliseconds).UTC().Format(time.RFC3339)
+}
+
+// normalizeAnnotation writes the times of an annotation spec in UTC, leaves out
+// an end equal to the start and sorts tags
+func normalizeAnnotation(spec map[string]any) {
+	for _, key := range []string{"time", "timeEnd"} {
+		if milliseconds, err := parseAnnotationTime(spec[key]); err == nil {
+			spec[key] = formatAnnotationTime(milliseconds)
+		}
+	}
+	if spec["timeEnd"] != nil && spec["timeEnd"] == spec["time"] {
+		delete(spec, "timeEnd")
+	}
+
+	if tags, ok := spec["tags"].([]any); ok {
+		sort.Slice(tags, func(i, j int) bool {
+			return fmt.Sprint(tags[i]) < fmt.Sprint(tags[j])
+		})
+	}
+}
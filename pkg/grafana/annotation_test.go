@@ -0,0 +1,93 @@
+package grafana_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotations(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+
+	maintenance := func(t *testing.T, text string, timeEnd string) grizzly.Resource {
+		t.Helper()
+		return grizzlytest.NewResource(t, "Annotation", "maintenance", map[string]any{
+			"text":    text,
+			"tags":    []any{"maintenance", "db"},
+			"time":    "2024-05-02T00:00:00+02:00",
+			"timeEnd": timeEnd,
+		})
+	}
+	apply := func(t *testing.T, resource grizzly.Resource) error {
+		t.Helper()
+		return grizzly.Apply(registry, grizzly.NewResources(resource), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText))
+	}
+	diff := func(t *testing.T, resource grizzly.Resource) string {
+		t.Helper()
+		output := &bytes.Buffer{}
+		require.NoError(t, grizzly.Diff(registry, grizzly.NewResources(resource), false, "", grizzly.NewWriterRecorder(output, grizzly.EventToPlainText)))
+		return output.String()
+	}
+
+	t.Run("annotations are created", func(t *testing.T) {
+		require.NoError(t, apply(t, maintenance(t, "Database upgrade", "2024-05-01T23:00:00Z")))
+
+		annotations := server.Annotations()
+		require.Len(t, annotations, 1)
+		require.Equal(t, "Database upgrade", annotations[0]["text"])
+		require.EqualValues(t, 1714600800000, annotations[0]["time"])
+		require.EqualValues(t, 1714604400000, annotations[0]["timeEnd"])
+
+		require.Contains(t, diff(t, maintenance(t, "Database upgrade", "2024-05-01T23:00:00Z")), "Annotation.maintenance unchanged")
+	})
+
+	t.Run("annotations with the same tags and time range are updated", func(t *testing.T) {
+		require.NoError(t, apply(t, maintenance(t, "Database upgrade to v16", "2024-05-01T23:00:00Z")))
+
+		annotations := server.Annotations()
+		require.Len(t, annotations, 1)
+		require.Equal(t, "Database upgrade to v16", annotations[0]["text"])
+	})
+
+	t.Run("annotations with another time range are added", func(t *testing.T) {
+		require.NoError(t, apply(t, maintenance(t, "Database upgrade to v16", "2024-05-02T01:00:00Z")))
+		require.Len(t, server.Annotations(), 2)
+	})
+
+	t.Run("remote annotations are listed and pulled by ID", func(t *testing.T) {
+		handler, err := registry.GetHandler("Annotation")
+		require.NoError(t, err)
+		ids, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.Len(t, ids, 2)
+
+		remote, err := handler.GetByUID(ids[0])
+		require.NoError(t, err)
+		require.Equal(t, []any{"db", "maintenance"}, remote.GetSpecValue("tags"))
+		require.Equal(t, "2024-05-01T22:00:00Z", remote.GetSpecValue("time"))
+
+		_, err = handler.GetByUID("999")
+		require.ErrorIs(t, err, grizzly.ErrNotFound)
+	})
+
+	t.Run("annotations are checked", func(t *testing.T) {
+		handler, err := registry.GetHandler("Annotation")
+		require.NoError(t, err)
+		require.ErrorContains(t, handler.Validate(maintenance(t, "Database upgrade", "tomorrow")), `invalid timeEnd of annotation: "tomorrow" is not a time`)
+		require.ErrorContains(t, handler.Validate(maintenance(t, "Database upgrade", "2024-05-01T21:00:00Z")), "timeEnd of annotation is before its time")
+	})
+
+	t.Run("annotations are deleted", func(t *testing.T) {
+		handler, err := registry.GetHandler("Annotation")
+		require.NoError(t, err)
+		require.NoError(t, handler.(grizzly.DeleteHandler).Delete(maintenance(t, "Database upgrade", "2024-05-01T23:00:00Z")))
+
+		annotations := server.Annotations()
+		require.Len(t, annotations, 1)
+		require.EqualValues(t, 1714611600000, annotations[0]["timeEnd"])
+	})
+}
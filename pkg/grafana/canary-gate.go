@@ -0,0 +1,52 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// FiringAlerts returns the number of alerts an alert rule currently fires,
+// leaving out silenced and inhibited ones
+func (p *Provider) FiringAlerts(ruleUID string) (int, error) {
+	query := url.Values{}
+	query.Set("filter", fmt.Sprintf("__alert_rule_uid__=%q", ruleUID))
+	query.Set("active", "true")
+	query.Set("silenced", "false")
+	query.Set("inhibited", "false")
+
+	resp, err := p.get("/api/alertmanager/grafana/api/v2/alerts?" + query.Encode())
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var alerts []struct {
+		Labels map[string]string `json:"labels"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&alerts); err != nil {
+		return 0, fmt.Errorf("listing the alerts of rule %s: %w", ruleUID, err)
+	}
+	return len(alerts), nil
+}
+
+// AlertRuleGate returns a canary gate failing while an alert rule fires, e.g.
+// a rule on the error rate of a service, or on the Synthetic Monitoring check
+// of a canary
+func AlertRuleGate(provider *Provider, ruleUID string) grizzly.CanaryGate {
+	return grizzly.CanaryGate{
+		Name: "alert rule " + ruleUID,
+		Check: func() error {
+			firing, err := provider.FiringAlerts(ruleUID)
+			if err != nil {
+				return err
+			}
+			if firing > 0 {
+				return fmt.Errorf("alert rule %s is firing %s", ruleUID, grizzly.Pluraliser(firing, "alert"))
+			}
+			return nil
+		},
+	}
+}
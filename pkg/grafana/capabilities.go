@@ -0,0 +1,197 @@
+package grafana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/mod/semver"
+)
+
+// ErrNotSupported is returned when a resource relies on a feature that the
+// target Grafana instance doesn't support
+var ErrNotSupported = errors.New("not supported by the target Grafana instance")
+
+// Feature is a Grafana feature that resources may rely on
+type Feature struct {
+	Name string
+	// MinVersion is the first version of Grafana providing the feature
+	MinVersion string
+	// Toggle is the feature toggle enabling the feature, if any
+	Toggle string
+	// DefaultSince is the first version of Grafana enabling the toggle by
+	// default
+	DefaultSince string
+	// Setting is a boolean frontend setting enabling the feature, if any
+	Setting string
+}
+
+var (
+	FeatureNestedFolders = Feature{
+		Name:         "nested folders",
+		MinVersion:   "10.0.0",
+		Toggle:       "nestedFolders",
+		DefaultSince: "11.0.0",
+	}
+	FeatureAlertingProvisioning = Feature{
+		Name:       "alerting provisioning",
+		MinVersion: "9.1.0",
+		Setting:    "unifiedAlertingEnabled",
+	}
+	FeaturePublicDashboards = Feature{
+		Name:         "public dashboards",
+		MinVersion:   "9.1.0",
+		Toggle:       "publicDashboards",
+		DefaultSince: "10.2.0",
+	}
+
+	// Features lists the features detected on the target Grafana instance
+	Features = []Feature{FeatureNestedFolders, FeatureAlertingProvisioning, FeaturePublicDashboards}
+)
+
+// Capabilities describes the version and the enabled features of the target
+// Grafana instance. When they couldn't be detected, every feature is
+// considered supported, leaving it to Grafana to reject requests.
+type Capabilities struct {
+	Version  string
+	Toggles  map[string]bool
+	Settings map[string]any
+}
+
+// Detected tells whether the capabilities of the instance could be detected
+func (c *Capabilities) Detected() bool {
+	return c.Version != ""
+}
+
+// Supports tells whether the instance supports a feature
+func (c *Capabilities) Supports(feature Feature) bool {
+	if !c.Detected() {
+		return true
+	}
+	if c.OlderThan(feature.MinVersion) {
+		return false
+	}
+	if feature.Setting != "" {
+		if enabled, ok := c.Settings[feature.Setting].(bool); ok && !enabled {
+			return false
+		}
+	}
+	if feature.Toggle != "" && !c.Toggles[feature.Toggle] {
+		return feature.DefaultSince != "" && !c.OlderThan(feature.DefaultSince)
+	}
+
+	return true
+}
+
+// Require returns an error explaining why a feature isn't supported, if it
+// isn't
+func (c *Capabilities) Require(feature Feature) error {
+	if c.Supports(feature) {
+		return nil
+	}
+
+	requirement := feature.requirement()
+	if feature.Setting != "" && !c.OlderThan(feature.MinVersion) {
+		requirement = fmt.Sprintf("%s to be enabled", feature.Setting)
+	}
+
+	return fmt.Errorf("%s %w: Grafana %s (requires %s)", feature.Name, ErrNotSupported, c.Version, requirement)
+}
+
+// requirement describes the versions of Grafana providing a feature
+func (feature Feature) requirement() string {
+	if feature.Toggle != "" {
+		return fmt.Sprintf("%s with the %s feature toggle, or %s", feature.MinVersion, feature.Toggle, feature.DefaultSince)
+	}
+
+	return feature.MinVersion
+}
+
+// OlderThan tells whether the detected version of the instance is older than
+// the given one
+func (c *Capabilities) OlderThan(version string) bool {
+	if !c.Detected() {
+		return false
+	}
+
+	current := releaseVersion(c.Version)
+	if !semver.IsValid(current) {
+		return false
+	}
+
+	return semver.Compare(current, releaseVersion(version)) < 0
+}
+
+// releaseVersion turns a Grafana version into a semantic version, without
+// pre-release, as pre-releases of a version provide its features
+func releaseVersion(version string) string {
+	version = "v" + strings.TrimPrefix(version, "v")
+	if prerelease := semver.Prerelease(version); prerelease != "" {
+		version = strings.TrimSuffix(semver.Canonical(version), prerelease)
+	}
+
+	return version
+}
+
+// Capabilities detects the version and the enabled features of the target
+// Grafana instance, once for the provider and its copies
+func (p *Provider) Capabilities() *Capabilities {
+	shared := p.shared()
+	shared.capabilitiesLock.Lock()
+	defer shared.capabilitiesLock.Unlock()
+
+	if shared.capabilities != nil {
+		return shared.capabilities
+	}
+
+	capabilities, err := p.detectCapabilities()
+	if err != nil {
+		log.Debugf("Could not detect the capabilities of Grafana: %s", err)
+		capabilities = &Capabilities{}
+	}
+	shared.capabilities = capabilities
+
+	return capabilities
+}
+
+func (p *Provider) detectCapabilities() (*Capabilities, error) {
+	resp, err := p.get("/api/frontend/settings")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var settings map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&settings); err != nil {
+		return nil, err
+	}
+
+	capabilities := &Capabilities{
+		Toggles:  map[string]bool{},
+		Settings: settings,
+	}
+	if buildInfo, ok := settings["buildInfo"].(map[string]any); ok {
+		capabilities.Version, _ = buildInfo["version"].(string)
+	}
+	if toggles, ok := settings["featureToggles"].(map[string]any); ok {
+		for name, enabled := range toggles {
+			capabilities.Toggles[name], _ = enabled.(bool)
+		}
+	}
+
+	return capabilities, nil
+}
+
+// requireFeature fails when the target Grafana instance of a provider
+// doesn't support a feature
+func requireFeature(provider grizzly.Provider, feature Feature) error {
+	capable, ok := provider.(interface{ Capabilities() *Capabilities })
+	if !ok {
+		return nil
+	}
+
+	return capable.Capabilities().Require(feature)
+}
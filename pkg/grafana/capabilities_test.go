@@ -0,0 +1,129 @@
+package grafana_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilitiesSupports(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities grafana.Capabilities
+		feature      grafana.Feature
+		expected     bool
+	}{
+		{
+			name:         "undetected capabilities support everything",
+			capabilities: grafana.Capabilities{},
+			feature:      grafana.FeatureNestedFolders,
+			expected:     true,
+		},
+		{
+			name:         "older versions don't support features",
+			capabilities: grafana.Capabilities{Version: "9.5.2"},
+			feature:      grafana.FeatureNestedFolders,
+			expected:     false,
+		},
+		{
+			name:         "toggles must be enabled",
+			capabilities: grafana.Capabilities{Version: "10.4.1"},
+			feature:      grafana.FeatureNestedFolders,
+			expected:     false,
+		},
+		{
+			name:         "enabled toggles",
+			capabilities: grafana.Capabilities{Version: "10.4.1", Toggles: map[string]bool{"nestedFolders": true}},
+			feature:      grafana.FeatureNestedFolders,
+			expected:     true,
+		},
+		{
+			name:         "toggles enabled by default",
+			capabilities: grafana.Capabilities{Version: "11.0.0"},
+			feature:      grafana.FeatureNestedFolders,
+			expected:     true,
+		},
+		{
+			name:         "pre-releases provide the features of their version",
+			capabilities: grafana.Capabilities{Version: "11.0.0-pre"},
+			feature:      grafana.FeatureNestedFolders,
+			expected:     true,
+		},
+		{
+			name:         "disabled settings",
+			capabilities: grafana.Capabilities{Version: "11.0.0", Settings: map[string]any{"unifiedAlertingEnabled": false}},
+			feature:      grafana.FeatureAlertingProvisioning,
+			expected:     false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, test.capabilities.Supports(test.feature))
+		})
+	}
+}
+
+func TestFeatureGating(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	server.SetGrafanaVersion("9.5.2")
+	server.SetUnifiedAlerting(false)
+
+	provider := grafana.NewProvider(&server.Context().Grafana)
+	registry := grizzly.NewRegistry([]grizzly.Provider{provider})
+
+	apply := func(resources ...grizzly.Resource) error {
+		return grizzly.Apply(registry, grizzly.NewResources(resources...), false, grizzly.NewJUnitReport("apply"))
+	}
+
+	require.Equal(t, "9.5.2", provider.Capabilities().Version)
+
+	t.Run("folders without parents are supported", func(t *testing.T) {
+		require.NoError(t, apply(grizzlytest.NewFolder(t, "parent", "Parent")))
+	})
+
+	t.Run("nested folders are not supported", func(t *testing.T) {
+		err := apply(grizzlytest.NewResource(t, "DashboardFolder", "child", map[string]any{"uid": "child", "title": "Child", "parentUid": "parent"}))
+		require.ErrorIs(t, err, grafana.ErrNotSupported)
+		require.ErrorContains(t, err, "nested folders not supported by the target Grafana instance: Grafana 9.5.2 (requires 10.0.0 with the nestedFolders feature toggle, or 11.0.0)")
+	})
+
+	t.Run("alerting is not supported", func(t *testing.T) {
+		err := apply(grizzlytest.NewResource(t, "AlertContactPoint", "email", map[string]any{"uid": "email", "name": "email", "type": "email"}))
+		require.ErrorIs(t, err, grafana.ErrNotSupported)
+	})
+}
+
+func TestCheckTargetVersion(t *testing.T) {
+	resources := grizzly.NewResources(
+		grizzlytest.NewResource(t, "DashboardFolder", "parent", map[string]any{"uid": "parent"}),
+		grizzlytest.NewResource(t, "DashboardFolder", "child", map[string]any{"uid": "child", "parentUid": "parent"}),
+		grizzlytest.NewResource(t, "AlertContactPoint", "email", map[string]any{"uid": "email"}),
+	)
+	warnings := func(version string) []string {
+		messages := []string{}
+		for _, warning := range grafana.CheckTargetVersion(resources, version) {
+			messages = append(messages, warning.Error())
+		}
+		return messages
+	}
+
+	require.Equal(t, []string{
+		"DashboardFolder.child: relies on nested folders, newer than the targeted Grafana 9.0.0 (requires 10.0.0 with the nestedFolders feature toggle, or 11.0.0)",
+		"AlertContactPoint.email: relies on alerting provisioning, newer than the targeted Grafana 9.0.0 (requires 9.1.0)",
+	}, warnings("9.0.0"))
+	require.Equal(t, []string{
+		"DashboardFolder.child: relies on nested folders, newer than the targeted Grafana 10.4.0 (requires 10.0.0 with the nestedFolders feature toggle, or 11.0.0)",
+	}, warnings("10.4.0"))
+	require.Empty(t, warnings("v11.0.0"))
+}
+
+func TestCheckInstanceVersion(t *testing.T) {
+	require.NoError(t, (&grafana.Capabilities{}).CheckInstanceVersion("10.4.0"))
+	require.NoError(t, (&grafana.Capabilities{Version: "10.4.0"}).CheckInstanceVersion("10.4.0"))
+	require.NoError(t, (&grafana.Capabilities{Version: "11.0.0-pre"}).CheckInstanceVersion("11.0.0"))
+	require.EqualError(t, (&grafana.Capabilities{Version: "10.2.3"}).CheckInstanceVersion("10.4.0"), "Grafana 10.2.3 is older than the version targeted by the project (10.4.0)")
+}
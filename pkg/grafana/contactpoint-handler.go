@@ -21,6 +21,12 @@ func NewAlertContactPointHandler(provider grizzly.Provider) *AlertContactPointHa
 	}
 }
 
+// Permissions returns the permissions required to manage contact points,
+// through the alerting provisioning API
+func (h *AlertContactPointHandler) Permissions() grizzly.Permissions {
+	return alertingProvisioningPermissions
+}
+
 const (
 	contactPointPattern = "alert-contact-points/contactPoint-%s.%s"
 )
@@ -58,26 +64,46 @@ func (h *AlertContactPointHandler) GetSpecUID(resource grizzly.Resource) (string
 
 // GetByUID retrieves JSON for a resource from an endpoint, by UID
 func (h *AlertContactPointHandler) GetByUID(uid string) (*grizzly.Resource, error) {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return nil, err
+	}
+
 	return h.getRemoteContactPoint(uid)
 }
 
 // GetRemote retrieves a contactPoint as a Resource
 func (h *AlertContactPointHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return nil, err
+	}
+
 	return h.getRemoteContactPoint(resource.Name())
 }
 
 // ListRemote retrieves as list of UIDs of all remote resources
 func (h *AlertContactPointHandler) ListRemote() ([]string, error) {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return nil, err
+	}
+
 	return h.getRemoteContactPointList()
 }
 
 // Add pushes a contactPoint to Grafana via the API
 func (h *AlertContactPointHandler) Add(resource grizzly.Resource) error {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return err
+	}
+
 	return h.postContactPoint(resource)
 }
 
 // Update pushes a contactPoint to Grafana via the API
 func (h *AlertContactPointHandler) Update(existing, resource grizzly.Resource) error {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return err
+	}
+
 	return h.putContactPoint(resource)
 }
 
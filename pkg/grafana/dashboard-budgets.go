@@ -0,0 +1,93 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// sizeUnits are the units of the sizes of dashboards, longest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  int
+}{
+	{"MB", 1024 * 1024},
+	{"KB", 1024},
+	{"B", 1},
+}
+
+// DashboardBudgets returns a transformer rejecting the dashboards exceeding
+// any of the budgets, listing every budget exceeded
+func DashboardBudgets(budgets config.DashboardBudgets) grizzly.ResourceTransformer {
+	return func(resource grizzly.Resource) (grizzly.Resource, error) {
+		if resource.Kind() != "Dashboard" {
+			return resource, nil
+		}
+
+		var exceeded []string
+		if budgets.MaxSize != "" {
+			maxSize, err := grizzly.ParseSize(budgets.MaxSize)
+			if err != nil {
+				return resource, fmt.Errorf("invalid max-size of dashboard budgets: %w", err)
+			}
+			model, err := json.Marshal(resource.Spec())
+			if err != nil {
+				return resource, err
+			}
+			if len(model) > maxSize {
+				exceeded = append(exceeded, fmt.Sprintf("%s of JSON (max %s)", formatSize(len(model)), budgets.MaxSize))
+			}
+		}
+
+		panels, queries := countPanels(resource.GetSpecValue("panels"))
+		if budgets.MaxPanels > 0 && panels > budgets.MaxPanels {
+			exceeded = append(exceeded, fmt.Sprintf("%d panels (max %d)", panels, budgets.MaxPanels))
+		}
+		if budgets.MaxQueries > 0 && queries > budgets.MaxQueries {
+			exceeded = append(exceeded, fmt.Sprintf("%d queries (max %d)", queries, budgets.MaxQueries))
+		}
+
+		if len(exceeded) > 0 {
+			return resource, fmt.Errorf("exceeds dashboard budgets: %s", strings.Join(exceeded, ", "))
+		}
+		return resource, nil
+	}
+}
+
+// countPanels counts the panels, including the ones nested in collapsed rows
+// but not the rows themselves, and the queries of these panels
+func countPanels(value any) (panels int, queries int) {
+	list, _ := value.([]any)
+	for _, item := range list {
+		panel, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if panel["type"] == "row" {
+			nestedPanels, nestedQueries := countPanels(panel["panels"])
+			panels += nestedPanels
+			queries += nestedQueries
+			continue
+		}
+
+		panels++
+		if targets, ok := panel["targets"].([]any); ok {
+			queries += len(targets)
+		}
+	}
+
+	return panels, queries
+}
+
+func formatSize(bytes int) string {
+	for _, unit := range sizeUnits {
+		if bytes >= unit.bytes && unit.bytes > 1 {
+			return strconv.FormatFloat(float64(bytes)/float64(unit.bytes), 'f', 1, 64) + unit.suffix
+		}
+	}
+	return strconv.Itoa(bytes) + "B"
+}
@@ -0,0 +1,78 @@
+package grafana_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardBudgets(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	dashboards := `apiVersion: grizzly.grafana.com/v1alpha1
+kind: Dashboard
+metadata:
+  name: small
+spec:
+  panels:
+    - title: CPU
+      targets: [{expr: cpu}]
+---
+apiVersion: grizzly.grafana.com/v1alpha1
+kind: Dashboard
+metadata:
+  name: large
+spec:
+  panels:
+    - title: CPU
+      targets: [{expr: cpu}, {expr: up}]
+    - title: Notes
+      type: text
+    - type: row
+      collapsed: true
+      panels:
+        - title: Memory
+          targets: [{expr: mem}, {expr: swap}]
+---
+apiVersion: grizzly.grafana.com/v1alpha1
+kind: DashboardFolder
+metadata:
+  name: general
+spec:
+  title: General
+`
+
+	parse := func(budgets config.DashboardBudgets, continueOnError bool) (grizzly.Resources, error) {
+		parser := grizzly.DefaultParser(registry, nil, nil,
+			grizzly.ParserStdin(strings.NewReader(dashboards)),
+			grizzly.ParserContinueOnError(continueOnError),
+			grizzly.ParserTransform(grafana.DashboardBudgets(budgets)),
+		)
+		return parser.Parse(grizzly.StdinPath, grizzly.ParserOptions{})
+	}
+
+	t.Run("dashboards within budgets are kept", func(t *testing.T) {
+		resources, err := parse(config.DashboardBudgets{MaxSize: "1KB", MaxPanels: 3, MaxQueries: 4}, false)
+		require.NoError(t, err)
+		require.Equal(t, 3, resources.Len())
+	})
+
+	t.Run("every budget exceeded is reported, by dashboard", func(t *testing.T) {
+		resources, err := parse(config.DashboardBudgets{MaxSize: "200B", MaxPanels: 2, MaxQueries: 3}, true)
+		require.ErrorContains(t, err, "Dashboard.large: exceeds dashboard budgets: 206B of JSON (max 200B), 3 panels (max 2), 4 queries (max 3)")
+		require.NotContains(t, err.Error(), "Dashboard.small")
+
+		_, found := resources.Find(grizzly.NewResourceRef("Dashboard", "large"))
+		require.False(t, found)
+		_, found = resources.Find(grizzly.NewResourceRef("Dashboard", "small"))
+		require.True(t, found)
+	})
+
+	t.Run("sizes are checked", func(t *testing.T) {
+		_, err := parse(config.DashboardBudgets{MaxSize: "lots"}, false)
+		require.ErrorContains(t, err, `invalid max-size of dashboard budgets: "lots" is not a size, expected e.g. 500KB or 1MB`)
+	})
+}
@@ -0,0 +1,92 @@
+package grafana
+
+import (
+	"fmt"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// DatasourceDefaults returns a transformer injecting, into the panels and
+// targets of dashboards that don't set any, the datasource of the first rule
+// matching the dashboard. Panels without targets, such as rows or text
+// panels, and library panels are left as is.
+func DatasourceDefaults(rules []config.DatasourceDefault) grizzly.ResourceTransformer {
+	return func(resource grizzly.Resource) (grizzly.Resource, error) {
+		if resource.Kind() != "Dashboard" {
+			return resource, nil
+		}
+
+		for i, rule := range rules {
+			if rule.Datasource.UID == "" {
+				return resource, fmt.Errorf("datasource default %d has no datasource uid", i)
+			}
+			if !datasourceDefaultMatches(rule, resource) {
+				continue
+			}
+
+			ref := map[string]any{"uid": rule.Datasource.UID}
+			if rule.Datasource.Type != "" {
+				ref["type"] = rule.Datasource.Type
+			}
+			if panels, ok := resource.GetSpecValue("panels").([]any); ok {
+				injectDatasource(panels, ref)
+			}
+			break
+		}
+
+		return resource, nil
+	}
+}
+
+func datasourceDefaultMatches(rule config.DatasourceDefault, resource grizzly.Resource) bool {
+	if rule.Folder != "" && rule.Folder != resource.GetMetadata("folder") {
+		return false
+	}
+	if rule.Tag == "" {
+		return true
+	}
+
+	tags, _ := resource.GetSpecValue("tags").([]any)
+	for _, tag := range tags {
+		if tag == rule.Tag {
+			return true
+		}
+	}
+	return false
+}
+
+// injectDatasource sets the datasource of panels, including the ones nested
+// in collapsed rows, and of their targets
+func injectDatasource(panels []any, ref map[string]any) {
+	for _, item := range panels {
+		panel, ok := item.(map[string]any)
+		if !ok || panel["libraryPanel"] != nil {
+			continue
+		}
+		if nested, ok := panel["panels"].([]any); ok {
+			injectDatasource(nested, ref)
+		}
+
+		targets, ok := panel["targets"].([]any)
+		if !ok || len(targets) == 0 {
+			continue
+		}
+		if panel["datasource"] == nil {
+			panel["datasource"] = copyDatasourceRef(ref)
+		}
+		for _, target := range targets {
+			if target, ok := target.(map[string]any); ok && target["datasource"] == nil {
+				target["datasource"] = copyDatasourceRef(ref)
+			}
+		}
+	}
+}
+
+func copyDatasourceRef(ref map[string]any) map[string]any {
+	copied := make(map[string]any, len(ref))
+	for key, value := range ref {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,120 @@
+package grafana_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatasourceDefaults(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	rules := []config.DatasourceDefault{
+		{Folder: "team-a", Datasource: config.DatasourceRef{Type: "prometheus", UID: "mimir-a"}},
+		{Tag: "logs", Datasource: config.DatasourceRef{Type: "loki", UID: "loki"}},
+	}
+
+	parse := func(rules []config.DatasourceDefault, stdin string) (grizzly.Resources, error) {
+		parser := grizzly.DefaultParser(registry, nil, nil,
+			grizzly.ParserStdin(strings.NewReader(stdin)),
+			grizzly.ParserTransform(grafana.DatasourceDefaults(rules)),
+		)
+		return parser.Parse(grizzly.StdinPath, grizzly.ParserOptions{})
+	}
+	panels := func(t *testing.T, resources grizzly.Resources, name string) []any {
+		resource, found := resources.Find(grizzly.NewResourceRef("Dashboard", name))
+		require.True(t, found)
+		return resource.GetSpecValue("panels").([]any)
+	}
+
+	resources, err := parse(rules, `apiVersion: grizzly.grafana.com/v1alpha1
+kind: Dashboard
+metadata:
+  name: cpu
+  folder: team-a
+spec:
+  tags: [logs]
+  panels:
+    - title: CPU
+      targets:
+        - expr: rate(cpu[5m])
+        - expr: up
+          datasource: {type: prometheus, uid: other}
+    - title: Disk
+      datasource: {type: prometheus, uid: other}
+      targets:
+        - expr: disk
+    - title: Notes
+      type: text
+    - type: row
+      panels:
+        - title: Memory
+          targets:
+            - expr: mem
+    - libraryPanel: {uid: shared}
+---
+apiVersion: grizzly.grafana.com/v1alpha1
+kind: Dashboard
+metadata:
+  name: errors
+  folder: team-b
+spec:
+  tags: [logs]
+  panels:
+    - title: Errors
+      targets:
+        - expr: '{level="error"}'
+---
+apiVersion: grizzly.grafana.com/v1alpha1
+kind: Dashboard
+metadata:
+  name: latency
+  folder: team-b
+spec:
+  panels:
+    - title: Latency
+      targets:
+        - expr: latency
+`)
+	require.NoError(t, err)
+
+	mimir := map[string]any{"type": "prometheus", "uid": "mimir-a"}
+	other := map[string]any{"type": "prometheus", "uid": "other"}
+	require.Equal(t, []any{
+		map[string]any{
+			"title":      "CPU",
+			"datasource": mimir,
+			"targets": []any{
+				map[string]any{"expr": "rate(cpu[5m])", "datasource": mimir},
+				map[string]any{"expr": "up", "datasource": other},
+			},
+		},
+		map[string]any{
+			"title":      "Disk",
+			"datasource": other,
+			"targets":    []any{map[string]any{"expr": "disk", "datasource": mimir}},
+		},
+		map[string]any{"title": "Notes", "type": "text"},
+		map[string]any{
+			"type": "row",
+			"panels": []any{map[string]any{
+				"title":      "Memory",
+				"datasource": mimir,
+				"targets":    []any{map[string]any{"expr": "mem", "datasource": mimir}},
+			}},
+		},
+		map[string]any{"libraryPanel": map[string]any{"uid": "shared"}},
+	}, panels(t, resources, "cpu"))
+
+	errors := panels(t, resources, "errors")[0].(map[string]any)
+	require.Equal(t, map[string]any{"type": "loki", "uid": "loki"}, errors["datasource"])
+
+	latency := panels(t, resources, "latency")[0].(map[string]any)
+	require.NotContains(t, latency, "datasource")
+
+	_, err = parse([]config.DatasourceDefault{{Tag: "logs"}}, "apiVersion: grizzly.grafana.com/v1alpha1\nkind: Dashboard\nmetadata: {name: cpu}\nspec: {title: CPU}\n")
+	require.ErrorContains(t, err, "Dashboard.cpu: datasource default 0 has no datasource uid")
+}
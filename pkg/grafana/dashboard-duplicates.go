@@ -0,0 +1,265 @@
+package grafana
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"gopkg.in/yaml.v3"
+)
+
+// panelLayoutFields are the fields of panels describing where they are on
+// their dashboard, rather than what they show
+var panelLayoutFields = []string{"id", "gridPos", "pluginVersion"}
+
+// PanelOccurrence locates a panel on a dashboard
+type PanelOccurrence struct {
+	Dashboard string `yaml:"dashboard" json:"dashboard"`
+	PanelID   int    `yaml:"panelId" json:"panelId"`
+}
+
+// DuplicatePanel is a panel repeated on several dashboards, a candidate for
+// a library panel
+type DuplicatePanel struct {
+	Title string `yaml:"title" json:"title"`
+	Type  string `yaml:"type" json:"type"`
+	// Fingerprint identifies the content of the panel, its layout aside
+	Fingerprint string            `yaml:"fingerprint" json:"fingerprint"`
+	Occurrences []PanelOccurrence `yaml:"occurrences" json:"occurrences"`
+
+	model map[string]any
+}
+
+// LibraryPanelUID is the UID of the library panel extracted from the panel
+func (duplicate DuplicatePanel) LibraryPanelUID() string {
+	return "panel-" + duplicate.Fingerprint[:12]
+}
+
+// DuplicatePanels is the report of the panels repeated across dashboards
+type DuplicatePanels []DuplicatePanel
+
+// FindDuplicatePanels finds the panels structurally identical, their layout
+// aside, on at least minDashboards dashboards. Panels already in libraries
+// and rows aren't considered. Duplicates are sorted by decreasing number of
+// occurrences.
+func FindDuplicatePanels(resources grizzly.Resources, minDashboards int) (DuplicatePanels, error) {
+	byFingerprint := map[string]*DuplicatePanel{}
+	dashboards := map[string]map[string]bool{}
+
+	for _, resource := range resources.AsList() {
+		if resource.Kind() != "Dashboard" {
+			continue
+		}
+		panels, _ := resource.GetSpecValue("panels").([]any)
+		err := forEachPanel(panels, func(panel map[string]any) error {
+			if panel["libraryPanel"] != nil || panel["type"] == "row" {
+				return nil
+			}
+			model := panelModel(panel)
+			content, err := json.Marshal(model)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(content)
+			fingerprint := hex.EncodeToString(sum[:])
+
+			duplicate, found := byFingerprint[fingerprint]
+			if !found {
+				title, _ := panel["title"].(string)
+				panelType, _ := panel["type"].(string)
+				duplicate = &DuplicatePanel{Title: title, Type: panelType, Fingerprint: fingerprint, model: model}
+				byFingerprint[fingerprint] = duplicate
+				dashboards[fingerprint] = map[string]bool{}
+			}
+			id, _ := panel["id"].(float64)
+			duplicate.Occurrences = append(duplicate.Occurrences, PanelOccurrence{Dashboard: resource.Name(), PanelID: int(id)})
+			dashboards[fingerprint][resource.Name()] = true
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", resource.Ref(), err)
+		}
+	}
+
+	duplicates := DuplicatePanels{}
+	for fingerprint, duplicate := range byFingerprint {
+		if len(dashboards[fingerprint]) >= minDashboards {
+			duplicates = append(duplicates, *duplicate)
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if len(duplicates[i].Occurrences) != len(duplicates[j].Occurrences) {
+			return len(duplicates[i].Occurrences) > len(duplicates[j].Occurrences)
+		}
+		return duplicates[i].Fingerprint < duplicates[j].Fingerprint
+	})
+
+	return duplicates, nil
+}
+
+// ExtractLibraryPanels turns duplicate panels into library panels, created
+// in the given folder, and rewrites the dashboards of resources to use them.
+// It returns the library panels and the rewritten dashboards.
+func ExtractLibraryPanels(resources grizzly.Resources, duplicates DuplicatePanels, folderUID string) (grizzly.Resources, error) {
+	extracted := grizzly.NewResources()
+	byFingerprint := map[string]DuplicatePanel{}
+	names := map[string]int{}
+
+	for _, duplicate := range duplicates {
+		byFingerprint[duplicate.Fingerprint] = duplicate
+
+		// library panels are named after their title, which must be unique
+		// within their folder
+		name := duplicate.Title
+		if name == "" {
+			name = duplicate.Type
+		}
+		names[name]++
+		if names[name] > 1 {
+			name = fmt.Sprintf("%s (%d)", name, names[name])
+		}
+
+		spec := map[string]any{
+			"uid":   duplicate.LibraryPanelUID(),
+			"name":  name,
+			"kind":  1,
+			"type":  duplicate.Type,
+			"model": duplicate.model,
+		}
+		if folderUID != "" && folderUID != generalFolderUID {
+			spec["folderUid"] = folderUID
+		}
+		element, err := grizzly.NewResource("grizzly.grafana.com/v1alpha1", LibraryElementKind, duplicate.LibraryPanelUID(), spec)
+		if err != nil {
+			return extracted, err
+		}
+		extracted.Add(element)
+	}
+
+	for _, resource := range resources.AsList() {
+		if resource.Kind() != "Dashboard" {
+			continue
+		}
+		panels, _ := resource.GetSpecValue("panels").([]any)
+		rewritten := false
+		err := forEachPanel(panels, func(panel map[string]any) error {
+			if panel["libraryPanel"] != nil || panel["type"] == "row" {
+				return nil
+			}
+			content, err := json.Marshal(panelModel(panel))
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(content)
+			duplicate, found := byFingerprint[hex.EncodeToString(sum[:])]
+			if !found {
+				return nil
+			}
+			element, _ := extracted.Find(grizzly.NewResourceRef(LibraryElementKind, duplicate.LibraryPanelUID()))
+			name, _ := element.GetSpecString("name")
+
+			for key := range panel {
+				if !isPanelLayoutField(key) {
+					delete(panel, key)
+				}
+			}
+			panel["title"] = duplicate.Title
+			panel["libraryPanel"] = map[string]any{
+				"uid":  duplicate.LibraryPanelUID(),
+				"name": name,
+			}
+			rewritten = true
+			return nil
+		})
+		if err != nil {
+			return extracted, fmt.Errorf("%s: %w", resource.Ref(), err)
+		}
+		if rewritten {
+			extracted.Add(resource)
+		}
+	}
+
+	return extracted, nil
+}
+
+// Format formats the report as a table, JSON or YAML
+func (duplicates DuplicatePanels) Format(format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.MarshalIndent(duplicates, "", "  ")
+	case "yaml":
+		return yaml.Marshal(duplicates)
+	case "default", "":
+	default:
+		return nil, fmt.Errorf("unknown format %s", format)
+	}
+
+	buffer := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buffer, 4, 4, 4, ' ', 0)
+	fmt.Fprintf(w, "TITLE\tTYPE\tOCCURRENCES\tLIBRARY PANEL UID\tDASHBOARDS\n")
+	for _, duplicate := range duplicates {
+		dashboards := map[string]bool{}
+		list := ""
+		for _, occurrence := range duplicate.Occurrences {
+			if dashboards[occurrence.Dashboard] {
+				continue
+			}
+			dashboards[occurrence.Dashboard] = true
+			if list != "" {
+				list += ","
+			}
+			list += occurrence.Dashboard
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", duplicate.Title, duplicate.Type, len(duplicate.Occurrences), duplicate.LibraryPanelUID(), list)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+// forEachPanel calls a function on the panels of a dashboard, including the
+// ones nested in collapsed rows
+func forEachPanel(panels []any, f func(panel map[string]any) error) error {
+	for _, item := range panels {
+		panel, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if err := f(panel); err != nil {
+			return err
+		}
+		if nested, ok := panel["panels"].([]any); ok {
+			if err := forEachPanel(nested, f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// panelModel returns what a panel shows, without its layout
+func panelModel(panel map[string]any) map[string]any {
+	model := make(map[string]any, len(panel))
+	for key, value := range panel {
+		if !isPanelLayoutField(key) {
+			model[key] = value
+		}
+	}
+	return model
+}
+
+func isPanelLayoutField(key string) bool {
+	for _, field := range panelLayoutFields {
+		if key == field {
+			return true
+		}
+	}
+	return false
+}
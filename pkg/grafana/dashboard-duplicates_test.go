@@ -0,0 +1,94 @@
+package grafana_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicatePanels(t *testing.T) {
+	cpuPanel := func(id float64, y float64) map[string]any {
+		return map[string]any{
+			"id":      id,
+			"gridPos": map[string]any{"x": 0, "y": y, "w": 12, "h": 8},
+			"title":   "CPU",
+			"type":    "timeseries",
+			"targets": []any{map[string]any{"expr": "rate(cpu[5m])"}},
+		}
+	}
+	dashboards := func() grizzly.Resources {
+		resources := grizzly.NewResources()
+		for name, panels := range map[string][]any{
+			"nodes": {
+				cpuPanel(1, 0),
+				map[string]any{"id": float64(2), "title": "Memory", "type": "timeseries"},
+			},
+			"pods": {
+				map[string]any{"id": float64(1), "type": "row", "collapsed": true, "panels": []any{cpuPanel(4, 3)}},
+				map[string]any{"id": float64(2), "title": "Memory", "type": "stat"},
+			},
+			"library": {
+				map[string]any{"id": float64(1), "title": "CPU", "libraryPanel": map[string]any{"uid": "cpu", "name": "CPU"}},
+			},
+		} {
+			resource := grizzlytest.NewResource(t, "Dashboard", name, map[string]any{"uid": name, "panels": panels})
+			resources.Add(resource)
+		}
+		return resources
+	}
+
+	t.Run("panels identical but for their layout are duplicates", func(t *testing.T) {
+		duplicates, err := grafana.FindDuplicatePanels(dashboards(), 2)
+		require.NoError(t, err)
+
+		require.Len(t, duplicates, 1)
+		require.Equal(t, "CPU", duplicates[0].Title)
+		require.ElementsMatch(t, []grafana.PanelOccurrence{
+			{Dashboard: "nodes", PanelID: 1},
+			{Dashboard: "pods", PanelID: 4},
+		}, duplicates[0].Occurrences)
+	})
+
+	t.Run("panels must be repeated on enough dashboards", func(t *testing.T) {
+		duplicates, err := grafana.FindDuplicatePanels(dashboards(), 3)
+		require.NoError(t, err)
+		require.Empty(t, duplicates)
+	})
+
+	t.Run("duplicates are extracted as library panels", func(t *testing.T) {
+		resources := dashboards()
+		duplicates, err := grafana.FindDuplicatePanels(resources, 2)
+		require.NoError(t, err)
+
+		extracted, err := grafana.ExtractLibraryPanels(resources, duplicates, "shared")
+		require.NoError(t, err)
+
+		byKind := extracted.GroupByKind()
+		require.Equal(t, 1, byKind["LibraryElement"].Len())
+		require.Equal(t, 2, byKind["Dashboard"].Len())
+
+		element := byKind["LibraryElement"].First()
+		require.Equal(t, duplicates[0].LibraryPanelUID(), element.Name())
+		require.Equal(t, "shared", element.GetSpecValue("folderUid"))
+		require.Equal(t, "CPU", element.GetSpecValue("name"))
+		model := element.GetSpecValue("model").(map[string]any)
+		require.NotContains(t, model, "gridPos")
+		require.Equal(t, "timeseries", model["type"])
+
+		pods, found := extracted.Find(grizzly.NewResourceRef("Dashboard", "pods"))
+		require.True(t, found)
+		row := pods.GetSpecValue("panels").([]any)[0].(map[string]any)
+		require.Equal(t, map[string]any{
+			"id":      float64(4),
+			"gridPos": map[string]any{"x": 0, "y": float64(3), "w": 12, "h": 8},
+			"title":   "CPU",
+			"libraryPanel": map[string]any{
+				"uid":  duplicates[0].LibraryPanelUID(),
+				"name": "CPU",
+			},
+		}, row["panels"].([]any)[0])
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/grafana/grafana-openapi-client-go/client/dashboards"
@@ -33,6 +34,14 @@ func NewDashboardHandler(provider grizzly.Provider) *DashboardHandler {
 	}
 }
 
+// Permissions returns the permissions required to manage dashboards
+func (h *DashboardHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Role: "Viewer", Actions: []string{"dashboards:read", "folders:read"}},
+		Write: grizzly.Access{Role: "Editor", Actions: []string{"dashboards:read", "dashboards:create", "dashboards:write", "folders:read"}},
+	}
+}
+
 const (
 	dashboardPattern = "dashboards/%s/dashboard-%s.%s"
 )
@@ -49,6 +58,21 @@ func (h *DashboardHandler) Unprepare(resource grizzly.Resource) *grizzly.Resourc
 	return &resource
 }
 
+// Normalizers fill in the defaults set by Grafana when saving dashboards, and
+// ignore the order of tags
+func (h *DashboardHandler) Normalizers() []grizzly.Normalizer {
+	return []grizzly.Normalizer{
+		grizzly.Defaults{
+			"editable":     true,
+			"graphTooltip": 0,
+			"links":        []any{},
+			"tags":         []any{},
+		},
+		grizzly.SortList{Path: []string{"tags"}},
+		grizzly.CanonicalDurations{Paths: [][]string{{"refresh"}}},
+	}
+}
+
 // Prepare gets a resource ready for dispatch to the remote endpoint
 func (h *DashboardHandler) Prepare(existing *grizzly.Resource, resource grizzly.Resource) *grizzly.Resource {
 	if !resource.HasSpecString("uid") {
@@ -100,6 +124,11 @@ func (h *DashboardHandler) ListRemote() ([]string, error) {
 	return h.getRemoteDashboardList()
 }
 
+// ListRemoteTagged lists the UIDs of the dashboards with the given tag
+func (h *DashboardHandler) ListRemoteTagged(tag string) ([]string, error) {
+	return h.getRemoteDashboardList(tag)
+}
+
 // Add pushes a new dashboard to Grafana via the API
 func (h *DashboardHandler) Add(resource grizzly.Resource) error {
 	resource = *h.Unprepare(resource)
@@ -112,6 +141,72 @@ func (h *DashboardHandler) Update(existing, resource grizzly.Resource) error {
 	return h.postDashboard(resource)
 }
 
+// Delete deletes a dashboard from Grafana
+func (h *DashboardHandler) Delete(resource grizzly.Resource) error {
+	return h.deleteDashboard(resource.Name())
+}
+
+// RemoteVersion returns the current version of a dashboard, and who last
+// modified it
+func (h *DashboardHandler) RemoteVersion(uid string) (grizzly.ResourceVersion, error) {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return grizzly.ResourceVersion{}, err
+	}
+	dashboardOk, err := client.Dashboards.GetDashboardByUID(uid)
+	if err != nil {
+		var gErr *dashboards.GetDashboardByUIDNotFound
+		if errors.As(err, &gErr) {
+			return grizzly.ResourceVersion{}, grizzly.ErrNotFound
+		}
+		return grizzly.ResourceVersion{}, err
+	}
+
+	meta := dashboardOk.GetPayload().Meta
+	if meta == nil {
+		return grizzly.ResourceVersion{}, fmt.Errorf("dashboard %s has no metadata", uid)
+	}
+
+	return grizzly.ResourceVersion{
+		Version:   meta.Version,
+		UpdatedBy: meta.UpdatedBy,
+		UpdatedAt: time.Time(meta.Updated),
+	}, nil
+}
+
+// Version returns the version of a dashboard retrieved from Grafana
+func (h *DashboardHandler) Version(resource grizzly.Resource) (int64, error) {
+	version, ok := resource.GetSpecValue("version").(float64)
+	if !ok {
+		return 0, fmt.Errorf("dashboard %s has no version", resource.Name())
+	}
+	return int64(version), nil
+}
+
+// AddVersion pushes a new dashboard to Grafana via the API, and returns its
+// version
+func (h *DashboardHandler) AddVersion(resource grizzly.Resource) (int64, error) {
+	resource = *h.Unprepare(resource)
+	return h.saveDashboard(resource, true)
+}
+
+// UpdateVersion pushes a dashboard to Grafana via the API, provided that it
+// is still at the given version, and returns its new version
+func (h *DashboardHandler) UpdateVersion(existing, resource grizzly.Resource, version int64) (int64, error) {
+	resource = *h.Unprepare(resource)
+	if version == grizzly.AnyVersion {
+		return h.saveDashboard(resource, true)
+	}
+	resource.SetSpecValue("version", version)
+
+	saved, err := h.saveDashboard(resource, false)
+	var gErr *dashboards.PostDashboardPreconditionFailed
+	if errors.As(err, &gErr) {
+		return 0, grizzly.ErrConflict
+	}
+	return saved, err
+}
+
 // Snapshot pushes dashboards as snapshots
 func (h *DashboardHandler) Snapshot(resource grizzly.Resource, expiresSeconds int) error {
 	s, err := h.postSnapshot(resource, expiresSeconds)
@@ -158,7 +253,7 @@ func (h *DashboardHandler) getRemoteDashboard(uid string) (*grizzly.Resource, er
 	return &resource, nil
 }
 
-func (h *DashboardHandler) getRemoteDashboardList() ([]string, error) {
+func (h *DashboardHandler) getRemoteDashboardList(tags ...string) ([]string, error) {
 	var (
 		limit            = int64(1000)
 		searchType       = "dash-db"
@@ -171,7 +266,7 @@ func (h *DashboardHandler) getRemoteDashboardList() ([]string, error) {
 		return nil, err
 	}
 
-	params := search.NewSearchParams().WithLimit(&limit).WithType(&searchType)
+	params := search.NewSearchParams().WithLimit(&limit).WithType(&searchType).WithTag(tags)
 	for {
 		page++
 		params.SetPage(&page)
@@ -191,6 +286,14 @@ func (h *DashboardHandler) getRemoteDashboardList() ([]string, error) {
 }
 
 func (h *DashboardHandler) postDashboard(resource grizzly.Resource) error {
+	_, err := h.saveDashboard(resource, true)
+	return err
+}
+
+// saveDashboard pushes a dashboard to Grafana, and returns the version it
+// was saved at. Without overwrite, Grafana refuses to save it unless its
+// version is the current one.
+func (h *DashboardHandler) saveDashboard(resource grizzly.Resource, overwrite bool) (int64, error) {
 	folderUID := resource.GetMetadata("folder")
 	var folderID int64
 	if !(folderUID == DefaultFolder || folderUID == strings.ToLower(DefaultFolder)) {
@@ -198,9 +301,9 @@ func (h *DashboardHandler) postDashboard(resource grizzly.Resource) error {
 		folder, err := folderHandler.getRemoteFolder(folderUID)
 		if err != nil {
 			if errors.Is(err, grizzly.ErrNotFound) {
-				return fmt.Errorf("cannot upload dashboard %s as folder %s not found", resource.Name(), folderUID)
+				return 0, fmt.Errorf("cannot upload dashboard %s as folder %s not found", resource.Name(), folderUID)
 			} else {
-				return fmt.Errorf("cannot upload dashboard %s: %w", resource.Name(), err)
+				return 0, fmt.Errorf("cannot upload dashboard %s: %w", resource.Name(), err)
 			}
 		}
 		folderID = int64(folder.GetSpecValue("id").(float64))
@@ -211,15 +314,25 @@ func (h *DashboardHandler) postDashboard(resource grizzly.Resource) error {
 	body := models.SaveDashboardCommand{
 		Dashboard: resource.Spec(),
 		FolderID:  folderID,
-		Overwrite: true,
+		Overwrite: overwrite,
+	}
+	// stamps are visible in the version history of the dashboard
+	if stamp, ok := grizzly.ApplyStampFromContext(contextOf(h.Provider)); ok {
+		body.Message = stamp.Message()
 	}
 	client, err := h.Provider.(ClientProvider).Client()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	_, err = client.Dashboards.PostDashboard(&body)
-	return err
+	dashboardOk, err := client.Dashboards.PostDashboard(&body)
+	if err != nil {
+		return 0, err
+	}
+	if version := dashboardOk.GetPayload().Version; version != nil {
+		return *version, nil
+	}
+	return 0, nil
 }
 
 func (h *DashboardHandler) postSnapshot(resource grizzly.Resource, expiresSeconds int) (*models.CreateDashboardSnapshotOKBody, error) {
@@ -302,7 +415,7 @@ func (h *DashboardHandler) RootDashboardPageHandler(s grizzly.Server) http.Handl
 			grizzly.SendError(w, "Error: No Grafana URL configured", fmt.Errorf("no Grafana URL configured"), 400)
 			return
 		}
-		req, err := http.NewRequest("GET", config.URL+r.URL.Path, nil)
+		req, err := http.NewRequest("GET", strings.TrimSuffix(config.URL, "/")+r.URL.Path, nil)
 		if err != nil {
 			grizzly.SendError(w, http.StatusText(500), err, 500)
 			return
@@ -316,7 +429,11 @@ func (h *DashboardHandler) RootDashboardPageHandler(s grizzly.Server) http.Handl
 
 		req.Header.Set("User-Agent", s.UserAgent)
 
-		client := &http.Client{}
+		client, err := h.Provider.(*Provider).rawClient()
+		if err != nil {
+			grizzly.SendError(w, http.StatusText(500), err, 500)
+			return
+		}
 		resp, err := client.Do(req)
 
 		if err == nil {
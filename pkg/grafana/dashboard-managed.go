@@ -0,0 +1,25 @@
+package grafana
+
+import (
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// ManagedTag returns a transformer adding the given tag to dashboards, to
+// mark them as managed by Grizzly
+func ManagedTag(tag string) grizzly.ResourceTransformer {
+	return func(resource grizzly.Resource) (grizzly.Resource, error) {
+		if resource.Kind() != "Dashboard" {
+			return resource, nil
+		}
+
+		tags, _ := resource.GetSpecValue("tags").([]any)
+		for _, existing := range tags {
+			if existing == tag {
+				return resource, nil
+			}
+		}
+		resource.SetSpecValue("tags", append(tags, tag))
+
+		return resource, nil
+	}
+}
@@ -0,0 +1,173 @@
+package grafana
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strconv"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// previewFolderUID is the UID of the folder receiving the scratch copies of
+// the dashboards being rendered. It is kept once previews are rendered, as
+// other previews may be rendered meanwhile.
+const previewFolderUID = "grizzly-previews"
+
+const (
+	defaultPreviewWidth  = 1000
+	defaultPreviewHeight = 500
+)
+
+// Preview renders each panel of a dashboard with the image renderer of
+// Grafana. The dashboard is rendered from a scratch copy, pushed to a
+// dedicated folder and removed afterwards, so that the dashboard itself is
+// left untouched. Failures to remove the copy are warnings.
+func (h *DashboardHandler) Preview(resource grizzly.Resource, opts grizzly.PreviewOptions) ([]grizzly.PreviewImage, error) {
+	if opts.Format != "" && opts.Format != "png" {
+		return nil, fmt.Errorf("unsupported preview format %q: only png is supported", opts.Format)
+	}
+	if opts.Width <= 0 {
+		opts.Width = defaultPreviewWidth
+	}
+	if opts.Height <= 0 {
+		opts.Height = defaultPreviewHeight
+	}
+
+	if err := h.ensurePreviewFolder(); err != nil {
+		return nil, err
+	}
+
+	scratch, err := previewCopy(resource)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.postDashboard(scratch); err != nil {
+		return nil, fmt.Errorf("cannot push the scratch copy of dashboard %s: %w", resource.Name(), err)
+	}
+	defer func() {
+		if err := h.deleteDashboard(scratch.Name()); err != nil {
+			grizzly.RecordWarning(contextOf(h.Provider), grizzly.NewResourceWarning(resource.Ref(), fmt.Errorf("could not remove its scratch copy %s from the %s folder: %w", scratch.Name(), previewFolderUID, err)))
+		}
+	}()
+
+	var images []grizzly.PreviewImage
+	for _, id := range previewPanelIDs(resource.Spec()) {
+		content, err := h.renderPanel(scratch.Name(), id, opts)
+		if err != nil {
+			return nil, fmt.Errorf("cannot render panel %d of dashboard %s: %w", id, resource.Name(), err)
+		}
+		images = append(images, grizzly.PreviewImage{
+			Name:    "panel-" + strconv.Itoa(id),
+			Content: content,
+		})
+	}
+
+	return images, nil
+}
+
+func (h *DashboardHandler) ensurePreviewFolder() error {
+	folderHandler := NewFolderHandler(h.Provider)
+	_, err := folderHandler.getRemoteFolder(previewFolderUID)
+	if !errors.Is(err, grizzly.ErrNotFound) {
+		return err
+	}
+
+	folder, err := grizzly.NewResource(folderHandler.APIVersion(), folderHandler.Kind(), previewFolderUID, map[string]any{
+		"uid":   previewFolderUID,
+		"title": "Grizzly previews",
+	})
+	if err != nil {
+		return err
+	}
+
+	return folderHandler.postFolder(folder)
+}
+
+// previewCopy returns a copy of a dashboard, with a UID of its own, living
+// in the previews folder
+func previewCopy(resource grizzly.Resource) (grizzly.Resource, error) {
+	sum := sha1.Sum([]byte(resource.Name()))
+	uid := "grr-preview-" + hex.EncodeToString(sum[:])[:16]
+
+	spec := make(map[string]any, len(resource.Spec()))
+	for key, value := range resource.Spec() {
+		spec[key] = value
+	}
+	delete(spec, "id")
+	delete(spec, "version")
+	spec["uid"] = uid
+
+	scratch, err := grizzly.NewResource(resource.APIVersion(), resource.Kind(), uid, spec)
+	if err != nil {
+		return scratch, err
+	}
+	scratch.SetMetadata("folder", previewFolderUID)
+
+	return scratch, nil
+}
+
+// previewPanelIDs returns the IDs of the panels of a dashboard, including
+// the ones of collapsed rows, rows themselves excluded
+func previewPanelIDs(spec map[string]any) []int {
+	var ids []int
+
+	panels, _ := spec["panels"].([]any)
+	for _, item := range panels {
+		panel, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if panel["type"] == "row" {
+			ids = append(ids, previewPanelIDs(panel)...)
+			continue
+		}
+		if id, ok := panel["id"].(float64); ok {
+			ids = append(ids, int(id))
+		}
+	}
+
+	return ids
+}
+
+func (h *DashboardHandler) renderPanel(uid string, panelID int, opts grizzly.PreviewOptions) ([]byte, error) {
+	query := url.Values{}
+	query.Set("panelId", strconv.Itoa(panelID))
+	query.Set("width", strconv.Itoa(opts.Width))
+	query.Set("height", strconv.Itoa(opts.Height))
+	if opts.Theme != "" {
+		query.Set("theme", opts.Theme)
+	}
+
+	provider, ok := h.Provider.(*Provider)
+	if !ok {
+		return nil, fmt.Errorf("previews require a Grafana provider: %w", ErrNotSupported)
+	}
+	resp, err := provider.get("/render/d-solo/" + url.PathEscape(uid) + "/_?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Grafana answers with an HTML page when the image renderer isn't installed
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "image/png" {
+		return nil, fmt.Errorf("unexpected content type %q: is the image renderer installed?", mediaType)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func (h *DashboardHandler) deleteDashboard(uid string) error {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Dashboards.DeleteDashboardByUID(uid)
+	return err
+}
@@ -0,0 +1,90 @@
+package grafana_test
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardPreview(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	handler := grafana.NewDashboardHandler(grafana.NewProvider(&server.Context().Grafana))
+
+	dashboard, err := grizzly.NewResource(handler.APIVersion(), handler.Kind(), "overview", map[string]any{
+		"uid":   "overview",
+		"title": "Overview",
+		"panels": []any{
+			map[string]any{"id": float64(1), "type": "timeseries", "title": "Requests"},
+			map[string]any{"id": float64(2), "type": "row", "collapsed": true, "panels": []any{
+				map[string]any{"id": float64(3), "type": "stat", "title": "Errors"},
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	images, err := handler.Preview(dashboard, grizzly.PreviewOptions{Format: "png", Width: 40, Height: 20})
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, image := range images {
+		names = append(names, image.Name)
+
+		decoded, err := png.Decode(bytes.NewReader(image.Content))
+		require.NoError(t, err)
+		require.Equal(t, 40, decoded.Bounds().Dx())
+		require.Equal(t, 20, decoded.Bounds().Dy())
+	}
+	require.Equal(t, []string{"panel-1", "panel-3"}, names)
+	require.NotEqual(t, images[0].Content, images[1].Content)
+
+	t.Run("dashboards are left untouched", func(t *testing.T) {
+		_, _, found := server.Dashboard("overview")
+		require.False(t, found)
+
+		_, found = server.Folder("grizzly-previews")
+		require.True(t, found)
+	})
+
+	t.Run("scratch copies are removed", func(t *testing.T) {
+		require.Contains(t, server.Requests(), "DELETE /api/dashboards/uid/grr-preview-fed758b7a842befd")
+	})
+
+	t.Run("failures to remove scratch copies are warnings", func(t *testing.T) {
+		target, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		grafanaProxy := httputil.NewSingleHostReverseProxy(target)
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodDelete {
+				http.Error(w, "deletion failed", http.StatusInternalServerError)
+				return
+			}
+			grafanaProxy.ServeHTTP(w, r)
+		}))
+		defer proxy.Close()
+		grafanaConfig := server.Context().Grafana
+		grafanaConfig.URL = proxy.URL
+		ctx := grizzly.WithWarnings(context.Background())
+		handler := grafana.NewDashboardHandler(grafana.NewProvider(&grafanaConfig).WithContext(ctx))
+
+		_, err = handler.Preview(dashboard, grizzly.PreviewOptions{Format: "png", Width: 40, Height: 20})
+		require.NoError(t, err)
+		warnings := grizzly.Warnings(ctx)
+		require.Len(t, warnings, 1)
+		require.ErrorContains(t, warnings[0], "Dashboard.overview: could not remove its scratch copy grr-preview-fed758b7a842befd")
+	})
+
+	t.Run("only png is supported", func(t *testing.T) {
+		_, err := handler.Preview(dashboard, grizzly.PreviewOptions{Format: "jpeg"})
+		require.ErrorContains(t, err, "only png is supported")
+	})
+}
@@ -0,0 +1,281 @@
+package grafana
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/grafana/grafana-openapi-client-go/client/dashboards"
+	"github.com/grafana/grafana-openapi-client-go/client/search"
+	"gopkg.in/yaml.v3"
+)
+
+// sortLastViewed is the search sort option ordering dashboards by the time
+// they were last viewed, provided by the usage insights of Grafana Enterprise
+// and Grafana Cloud. Its sort meta is the time of the last view, in
+// milliseconds since the epoch.
+const sortLastViewed = "viewed-recently-desc"
+
+// DashboardActivity tells when a dashboard was last used
+type DashboardActivity struct {
+	UID    string
+	Title  string
+	Folder string
+	// LastViewed is the time the dashboard was last viewed, zero when it
+	// never was or when views aren't known
+	LastViewed time.Time
+	// Updated is the time the dashboard was last saved, only fetched when
+	// views aren't known
+	Updated time.Time
+}
+
+// lastActivity returns the time of the last activity on a dashboard, and
+// what it was
+func (activity DashboardActivity) lastActivity(viewsKnown bool) (time.Time, string) {
+	if !viewsKnown {
+		return activity.Updated, "updated"
+	}
+	if activity.LastViewed.IsZero() {
+		return time.Time{}, "never viewed"
+	}
+	return activity.LastViewed, "viewed"
+}
+
+// Activity is the activity of the dashboards of an instance
+type Activity struct {
+	Dashboards []DashboardActivity
+	// Folders are the UIDs of every folder, including the ones without
+	// dashboards
+	Folders []string
+	// ViewsKnown tells whether the instance provides usage insights,
+	// otherwise the last update of dashboards is their last activity
+	ViewsKnown bool
+}
+
+// DashboardsActivity lists the folders and dashboards of the instance, along
+// with the last activity of dashboards
+func (p *Provider) DashboardsActivity() (Activity, error) {
+	activity := Activity{}
+	viewsKnown, err := p.hasSortOption(sortLastViewed)
+	if err != nil {
+		return activity, err
+	}
+	activity.ViewsKnown = viewsKnown
+
+	client, err := p.Client()
+	if err != nil {
+		return activity, err
+	}
+
+	var (
+		limit = int64(1000)
+		page  = int64(0)
+	)
+	params := search.NewSearchParams().WithLimit(&limit)
+	if viewsKnown {
+		sortOption := sortLastViewed
+		params.SetSort(&sortOption)
+	}
+	for {
+		page++
+		params.SetPage(&page)
+
+		searchOk, err := client.Search.Search(params, nil)
+		if err != nil {
+			return activity, err
+		}
+		for _, hit := range searchOk.GetPayload() {
+			if hit.Type == "dash-folder" {
+				activity.Folders = append(activity.Folders, hit.UID)
+				continue
+			}
+			dashboard := DashboardActivity{
+				UID:    hit.UID,
+				Title:  hit.Title,
+				Folder: hit.FolderUID,
+			}
+			if dashboard.Folder == "" {
+				dashboard.Folder = generalFolderUID
+			}
+			if viewsKnown && hit.SortMeta > 0 {
+				dashboard.LastViewed = time.UnixMilli(hit.SortMeta).UTC()
+			}
+			activity.Dashboards = append(activity.Dashboards, dashboard)
+		}
+		if int64(len(searchOk.GetPayload())) < limit {
+			break
+		}
+	}
+
+	if viewsKnown {
+		return activity, nil
+	}
+
+	for i, dashboard := range activity.Dashboards {
+		dashboardOk, err := client.Dashboards.GetDashboardByUID(dashboard.UID)
+		if err != nil {
+			var gErr *dashboards.GetDashboardByUIDNotFound
+			if errors.As(err, &gErr) {
+				continue
+			}
+			return activity, fmt.Errorf("dashboard %s: %w", dashboard.UID, err)
+		}
+		if meta := dashboardOk.GetPayload().Meta; meta != nil {
+			activity.Dashboards[i].Updated = time.Time(meta.Updated).UTC()
+		}
+	}
+
+	return activity, nil
+}
+
+// hasSortOption tells whether the instance provides a search sort option
+func (p *Provider) hasSortOption(name string) (bool, error) {
+	resp, err := p.get("/api/search/sorting")
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var options struct {
+		SortOptions []struct {
+			Name string `json:"name"`
+		} `json:"sortOptions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&options); err != nil {
+		return false, fmt.Errorf("listing search sort options: %w", err)
+	}
+	for _, option := range options.SortOptions {
+		if option.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// StaleDashboard is a dashboard without activity for a while
+type StaleDashboard struct {
+	UID    string `yaml:"uid" json:"uid"`
+	Title  string `yaml:"title" json:"title"`
+	Folder string `yaml:"folder" json:"folder"`
+	// Activity is the last activity on the dashboard: viewed, updated or
+	// never viewed
+	Activity     string     `yaml:"activity" json:"activity"`
+	LastActivity *time.Time `yaml:"lastActivity,omitempty" json:"lastActivity,omitempty"`
+}
+
+// InactiveFolder is a folder whose dashboards are all stale, or without any
+// dashboard
+type InactiveFolder struct {
+	UID        string `yaml:"uid" json:"uid"`
+	Dashboards int    `yaml:"dashboards" json:"dashboards"`
+}
+
+// HousekeepingReport lists the dashboards and folders without activity
+type HousekeepingReport struct {
+	// ViewsKnown tells whether the activity of dashboards is based on their
+	// views, or on their updates
+	ViewsKnown bool             `yaml:"viewsKnown" json:"viewsKnown"`
+	Since      time.Time        `yaml:"since" json:"since"`
+	Dashboards []StaleDashboard `yaml:"dashboards" json:"dashboards"`
+	Folders    []InactiveFolder `yaml:"folders" json:"folders"`
+}
+
+// NewHousekeepingReport flags the dashboards without activity since a given
+// time, and the folders holding only such dashboards, or none. The General
+// folder is never flagged. Folders are flagged regardless of their
+// subfolders.
+func NewHousekeepingReport(activity Activity, since time.Time) HousekeepingReport {
+	report := HousekeepingReport{
+		ViewsKnown: activity.ViewsKnown,
+		Since:      since,
+		Dashboards: []StaleDashboard{},
+		Folders:    []InactiveFolder{},
+	}
+
+	activeFolders := map[string]bool{}
+	staleDashboards := map[string]int{}
+	for _, dashboard := range activity.Dashboards {
+		last, kind := dashboard.lastActivity(activity.ViewsKnown)
+		if !last.IsZero() && !last.Before(since) {
+			activeFolders[dashboard.Folder] = true
+			continue
+		}
+
+		stale := StaleDashboard{
+			UID:      dashboard.UID,
+			Title:    dashboard.Title,
+			Folder:   dashboard.Folder,
+			Activity: kind,
+		}
+		if !last.IsZero() {
+			stale.LastActivity = &last
+		}
+		report.Dashboards = append(report.Dashboards, stale)
+		staleDashboards[dashboard.Folder]++
+	}
+
+	for _, folder := range activity.Folders {
+		if !activeFolders[folder] && folder != generalFolderUID {
+			report.Folders = append(report.Folders, InactiveFolder{UID: folder, Dashboards: staleDashboards[folder]})
+		}
+	}
+
+	sort.Slice(report.Dashboards, func(i, j int) bool {
+		return report.Dashboards[i].UID < report.Dashboards[j].UID
+	})
+	sort.Slice(report.Folders, func(i, j int) bool {
+		return report.Folders[i].UID < report.Folders[j].UID
+	})
+
+	return report
+}
+
+// Format formats the report as a table, JSON, YAML, or as targets: one
+// resource per line, in the form accepted by `-t`
+func (report HousekeepingReport) Format(format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.MarshalIndent(report, "", "  ")
+	case "yaml":
+		return yaml.Marshal(report)
+	case "targets":
+		targets := []string{}
+		for _, dashboard := range report.Dashboards {
+			targets = append(targets, "Dashboard."+dashboard.UID)
+		}
+		for _, folder := range report.Folders {
+			targets = append(targets, "DashboardFolder."+folder.UID)
+		}
+		return []byte(strings.Join(targets, "\n")), nil
+	case "default", "":
+	default:
+		return nil, fmt.Errorf("unknown format %s", format)
+	}
+
+	buffer := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buffer, 4, 4, 4, ' ', 0)
+	fmt.Fprintf(w, "DASHBOARD\tFOLDER\tACTIVITY\tLAST ACTIVITY\n")
+	for _, dashboard := range report.Dashboards {
+		last := "-"
+		if dashboard.LastActivity != nil {
+			last = dashboard.LastActivity.Format(time.DateOnly)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", dashboard.UID, dashboard.Folder, dashboard.Activity, last)
+	}
+	if len(report.Folders) > 0 {
+		fmt.Fprintf(w, "\nFOLDER\tDASHBOARDS\n")
+		for _, folder := range report.Folders {
+			fmt.Fprintf(w, "%s\t%d\n", folder.UID, folder.Dashboards)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,85 @@
+package grafana_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHousekeepingReport(t *testing.T) {
+	now := time.Now()
+	setup := func(t *testing.T) (*grizzlytest.Server, *grafana.Provider) {
+		t.Helper()
+		server := grizzlytest.NewServer(t)
+		provider := grafana.NewProvider(&server.Context().Grafana)
+		registry := grizzly.NewRegistry([]grizzly.Provider{provider})
+
+		newResource := func(kind, name string, spec map[string]any, folder string) grizzly.Resource {
+			resource := grizzlytest.NewResource(t, kind, name, spec)
+			if folder != "" {
+				resource.SetMetadata("folder", folder)
+			}
+			return resource
+		}
+		resources := grizzly.NewResources(
+			newResource("DashboardFolder", "teams", map[string]any{"uid": "teams", "title": "Teams"}, ""),
+			newResource("DashboardFolder", "legacy", map[string]any{"uid": "legacy", "title": "Legacy"}, ""),
+			newResource("DashboardFolder", "empty", map[string]any{"uid": "empty", "title": "Empty"}, ""),
+			newResource("Dashboard", "nodes", map[string]any{"uid": "nodes", "title": "Nodes"}, "teams"),
+			newResource("Dashboard", "pods", map[string]any{"uid": "pods", "title": "Pods"}, "teams"),
+			newResource("Dashboard", "old", map[string]any{"uid": "old", "title": "Old"}, "legacy"),
+		)
+		require.NoError(t, grizzly.Apply(registry, registry.Sort(resources), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText)))
+
+		return server, provider
+	}
+	uids := func(report grafana.HousekeepingReport) ([]string, []string) {
+		dashboards, folders := []string{}, []string{}
+		for _, dashboard := range report.Dashboards {
+			dashboards = append(dashboards, dashboard.UID+":"+dashboard.Activity)
+		}
+		for _, folder := range report.Folders {
+			folders = append(folders, folder.UID)
+		}
+		return dashboards, folders
+	}
+
+	t.Run("dashboards are flagged by their last view", func(t *testing.T) {
+		server, provider := setup(t)
+		server.ViewDashboard("nodes", now.AddDate(0, 0, -2))
+		server.ViewDashboard("old", now.AddDate(0, -6, 0))
+
+		activity, err := provider.DashboardsActivity()
+		require.NoError(t, err)
+		require.True(t, activity.ViewsKnown)
+
+		report := grafana.NewHousekeepingReport(activity, now.AddDate(0, 0, -30))
+		dashboards, folders := uids(report)
+		require.Equal(t, []string{"old:viewed", "pods:never viewed"}, dashboards)
+		require.Equal(t, []string{"empty", "legacy"}, folders)
+
+		targets, err := report.Format("targets")
+		require.NoError(t, err)
+		require.Equal(t, "Dashboard.old\nDashboard.pods\nDashboardFolder.empty\nDashboardFolder.legacy", string(targets))
+	})
+
+	t.Run("dashboards are flagged by their last update without usage insights", func(t *testing.T) {
+		server, provider := setup(t)
+		server.SetDashboardUpdated("old", now.AddDate(-1, 0, 0))
+
+		activity, err := provider.DashboardsActivity()
+		require.NoError(t, err)
+		require.False(t, activity.ViewsKnown)
+
+		report := grafana.NewHousekeepingReport(activity, now.AddDate(0, 0, -30))
+		dashboards, folders := uids(report)
+		require.Equal(t, []string{"old:updated"}, dashboards)
+		require.Equal(t, []string{"empty", "legacy"}, folders)
+		require.Equal(t, 1, report.Folders[1].Dashboards)
+	})
+}
@@ -0,0 +1,178 @@
+package grafana
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// variableReferenceRegex matches the syntaxes referencing template variables:
+// `$var`, `${var}`, `${var:format}`, `${var.field}` and the deprecated
+// `[[var]]`
+var variableReferenceRegex = regexp.MustCompile(`\$\{([A-Za-z_]\w*)(?:[.:][^}]*)?\}|\[\[([A-Za-z_]\w*)(?::[^\]]*)?\]\]|\$([A-Za-z_]\w*)`)
+
+// builtinVariables are provided by Grafana itself, besides the ones starting
+// with `__` such as `$__interval`
+var builtinVariables = map[string]bool{
+	"interval":    true,
+	"interval_ms": true,
+	"timeFilter":  true,
+	"timeFrom":    true,
+	"timeTo":      true,
+}
+
+// variableDefinitionFields are the fields of a variable that don't reference
+// other variables, e.g. its current value
+var variableDefinitionFields = map[string]bool{
+	"name":        true,
+	"label":       true,
+	"description": true,
+	"current":     true,
+	"options":     true,
+}
+
+// CheckTemplateVariables warns about the dashboards referencing template
+// variables they don't define, and defining variables nothing references.
+// Ad hoc filters apply to queries without being referenced, and dashboards
+// with library panels may rely on variables in these panels: neither is
+// reported as unused.
+func CheckTemplateVariables(resources grizzly.Resources) []grizzly.Warning {
+	var warnings []grizzly.Warning
+
+	for _, resource := range resources.AsList() {
+		if resource.Kind() != "Dashboard" {
+			continue
+		}
+
+		undefined, unused := checkDashboardVariables(resource.Spec())
+		for _, name := range undefined {
+			warnings = append(warnings, grizzly.NewResourceWarning(resource.Ref(), fmt.Errorf("references undefined template variable $%s", name)))
+		}
+		for _, name := range unused {
+			warnings = append(warnings, grizzly.NewResourceWarning(resource.Ref(), fmt.Errorf("defines template variable $%s that is never referenced", name)))
+		}
+	}
+
+	return warnings
+}
+
+func checkDashboardVariables(spec map[string]any) ([]string, []string) {
+	defined := map[string]bool{}
+	ignoreUnused := map[string]bool{}
+	referenced := map[string]bool{}
+	// referencedBy records the variables referenced by other variables, so
+	// that a variable referencing itself doesn't count as used
+	referencedBy := map[string]map[string]bool{}
+
+	templating, _ := spec["templating"].(map[string]any)
+	variables, _ := templating["list"].([]any)
+	for _, item := range variables {
+		variable, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := variable["name"].(string)
+		if name == "" {
+			continue
+		}
+		defined[name] = true
+		if variable["type"] == "adhoc" {
+			ignoreUnused[name] = true
+		}
+
+		referencedBy[name] = map[string]bool{}
+		for key, value := range variable {
+			if !variableDefinitionFields[key] {
+				collectVariableReferences(value, referencedBy[name])
+			}
+		}
+	}
+
+	hasLibraryPanels := false
+	for key, value := range spec {
+		if key != "templating" {
+			collectVariableReferences(value, referenced)
+			hasLibraryPanels = hasLibraryPanels || hasField(value, "libraryPanel")
+		}
+	}
+
+	undefinedSet := map[string]bool{}
+	for name := range referenced {
+		undefinedSet[name] = !defined[name]
+	}
+	for owner, names := range referencedBy {
+		for name := range names {
+			undefinedSet[name] = undefinedSet[name] || !defined[name]
+			if name != owner {
+				referenced[name] = true
+			}
+		}
+	}
+
+	var undefined, unused []string
+	for name, isUndefined := range undefinedSet {
+		if isUndefined {
+			undefined = append(undefined, name)
+		}
+	}
+	if !hasLibraryPanels {
+		for name := range defined {
+			if !referenced[name] && !ignoreUnused[name] {
+				unused = append(unused, name)
+			}
+		}
+	}
+	sort.Strings(undefined)
+	sort.Strings(unused)
+
+	return undefined, unused
+}
+
+// collectVariableReferences records the variables referenced in the strings
+// of a value, at any depth. Panels repeated over a variable name it without
+// `$` in their `repeat` field.
+func collectVariableReferences(value any, references map[string]bool) {
+	switch value := value.(type) {
+	case string:
+		for _, match := range variableReferenceRegex.FindAllStringSubmatch(value, -1) {
+			name := match[1] + match[2] + match[3]
+			if strings.HasPrefix(name, "__") || builtinVariables[name] {
+				continue
+			}
+			references[name] = true
+		}
+	case map[string]any:
+		for key, item := range value {
+			if repeat, ok := item.(string); key == "repeat" && ok && repeat != "" {
+				references[repeat] = true
+				continue
+			}
+			collectVariableReferences(item, references)
+		}
+	case []any:
+		for _, item := range value {
+			collectVariableReferences(item, references)
+		}
+	}
+}
+
+func hasField(value any, field string) bool {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if key == field || hasField(item, field) {
+				return true
+			}
+		}
+	case []any:
+		for _, item := range value {
+			if hasField(item, field) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package grafana_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTemplateVariables(t *testing.T) {
+	dashboard := func(spec map[string]any) grizzly.Resources {
+		resource := grizzlytest.NewResource(t, "Dashboard", "cpu", spec)
+		return grizzly.NewResources(resource)
+	}
+	variables := func(variables ...map[string]any) map[string]any {
+		list := []any{}
+		for _, variable := range variables {
+			list = append(list, variable)
+		}
+		return map[string]any{"list": list}
+	}
+	messages := func(warnings []grizzly.Warning) []string {
+		result := []string{}
+		for _, warning := range warnings {
+			result = append(result, warning.Error())
+		}
+		return result
+	}
+
+	t.Run("references must be defined", func(t *testing.T) {
+		warnings := grafana.CheckTemplateVariables(dashboard(map[string]any{
+			"templating": variables(
+				map[string]any{"name": "cluster", "type": "query", "query": "label_values(up, cluster)"},
+				map[string]any{"name": "node", "type": "query", "query": "label_values(up{cluster=\"$cluster\", env=\"$env\"}, node)"},
+			),
+			"panels": []any{
+				map[string]any{
+					"title":   "CPU of ${node:csv}",
+					"targets": []any{map[string]any{"expr": "rate(cpu{node=~\"$node\", job=\"[[job]]\"}[$__rate_interval])"}},
+				},
+				map[string]any{"title": "Memory", "targets": []any{map[string]any{"expr": "label_replace(mem, \"host\", \"$1\", \"instance\", \"(.*)\")"}}},
+			},
+		}))
+
+		require.Equal(t, []string{
+			"Dashboard.cpu: references undefined template variable $env",
+			"Dashboard.cpu: references undefined template variable $job",
+		}, messages(warnings))
+	})
+
+	t.Run("definitions must be referenced", func(t *testing.T) {
+		warnings := grafana.CheckTemplateVariables(dashboard(map[string]any{
+			"templating": variables(
+				map[string]any{"name": "region", "type": "custom", "query": "eu,us", "current": map[string]any{"text": "$region"}},
+				map[string]any{"name": "server", "type": "query", "query": "label_values(up{region=\"$region\"}, server)"},
+				map[string]any{"name": "self", "type": "query", "query": "label_values(up{self=\"$self\"}, self)"},
+				map[string]any{"name": "filters", "type": "adhoc"},
+			),
+			"panels": []any{
+				map[string]any{"title": "Load", "repeat": "server"},
+			},
+		}))
+
+		require.Equal(t, []string{
+			"Dashboard.cpu: defines template variable $self that is never referenced",
+		}, messages(warnings))
+	})
+
+	t.Run("library panels may use any variable", func(t *testing.T) {
+		warnings := grafana.CheckTemplateVariables(dashboard(map[string]any{
+			"templating": variables(map[string]any{"name": "region", "type": "custom"}),
+			"panels": []any{
+				map[string]any{"libraryPanel": map[string]any{"uid": "load"}},
+			},
+		}))
+
+		require.Empty(t, warnings)
+	})
+}
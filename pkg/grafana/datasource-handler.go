@@ -25,6 +25,15 @@ func NewDatasourceHandler(provider grizzly.Provider) *DatasourceHandler {
 	}
 }
 
+// Permissions returns the permissions required to manage datasources, whose
+// settings only administrators can read by default
+func (h *DatasourceHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Role: "Admin", Actions: []string{"datasources:read"}},
+		Write: grizzly.Access{Role: "Admin", Actions: []string{"datasources:read", "datasources:create", "datasources:write"}},
+	}
+}
+
 const (
 	datasourcePattern = "datasources/datasource-%s.%s"
 )
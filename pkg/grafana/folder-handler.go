@@ -27,6 +27,14 @@ func NewFolderHandler(provider grizzly.Provider) *FolderHandler {
 	}
 }
 
+// Permissions returns the permissions required to manage folders
+func (h *FolderHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Role: "Viewer", Actions: []string{"folders:read"}},
+		Write: grizzly.Access{Role: "Editor", Actions: []string{"folders:read", "folders:create", "folders:write"}},
+	}
+}
+
 const (
 	folderPattern = "folders/folder-%s.%s"
 )
@@ -46,13 +54,10 @@ func (h *FolderHandler) Prepare(existing *grizzly.Resource, resource grizzly.Res
 
 // Unprepare removes unnecessary elements from a remote resource ready for presentation/comparison
 func (h *FolderHandler) Unprepare(resource grizzly.Resource) *grizzly.Resource {
-	for _, key := range []string{"id", "version", "canAdmin", "canDelete", "canEdit", "canSave", "created", "createdBy", "updated", "updatedBy", "url"} {
+	// `parents` is derived from `parentUid` by Grafana
+	for _, key := range []string{"id", "version", "canAdmin", "canDelete", "canEdit", "canSave", "created", "createdBy", "updated", "updatedBy", "url", "parents"} {
 		resource.DeleteSpecKey(key)
 	}
-	value := resource.GetSpecValue("parents")
-	if value == nil {
-		resource.DeleteSpecKey("parents")
-	}
 	return &resource
 }
 
@@ -134,19 +139,77 @@ func (h *FolderHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource,
 
 // ListRemote retrieves as list of UIDs of all remote resources
 func (h *FolderHandler) ListRemote() ([]string, error) {
-	return h.getRemoteFolderList()
+	hits, err := h.searchFolders()
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		uids = append(uids, hit.UID)
+	}
+	return uids, nil
+}
+
+// ListRemoteFolders lists the folders of Grafana with their titles. Nothing
+// is listed when Grafana isn't configured.
+func (h *FolderHandler) ListRemoteFolders() ([]grizzly.RemoteFolder, error) {
+	if err := h.Provider.Validate(); err != nil {
+		return nil, nil
+	}
+
+	hits, err := h.searchFolders()
+	if err != nil {
+		return nil, err
+	}
+
+	folders := make([]grizzly.RemoteFolder, 0, len(hits))
+	for _, hit := range hits {
+		folders = append(folders, grizzly.RemoteFolder{
+			UID:       hit.UID,
+			Title:     hit.Title,
+			ParentUID: hit.FolderUID,
+		})
+	}
+	return folders, nil
 }
 
 // Add pushes a new folder to Grafana via the API
 func (h *FolderHandler) Add(resource grizzly.Resource) error {
+	if err := h.requireNestedFolders(resource); err != nil {
+		return err
+	}
+
 	return h.postFolder(resource)
 }
 
 // Update pushes a folder to Grafana via the API
 func (h *FolderHandler) Update(existing, resource grizzly.Resource) error {
+	if err := h.requireNestedFolders(resource); err != nil {
+		return err
+	}
+
+	existingParentUID, _ := existing.GetSpecString("parentUid")
+	parentUID, _ := resource.GetSpecString("parentUid")
+	if existingParentUID != parentUID {
+		if err := h.moveFolder(resource.Name(), parentUID); err != nil {
+			return err
+		}
+	}
+
 	return h.putFolder(resource)
 }
 
+// requireNestedFolders fails for folders with a parent when the target
+// Grafana instance doesn't support nested folders
+func (h *FolderHandler) requireNestedFolders(resource grizzly.Resource) error {
+	if parentUID, _ := resource.GetSpecString("parentUid"); parentUID == "" {
+		return nil
+	}
+
+	return requireFeature(h.Provider, FeatureNestedFolders)
+}
+
 // getRemoteFolder retrieves a folder object from Grafana
 func (h *FolderHandler) getRemoteFolder(uid string) (*grizzly.Resource, error) {
 	if uid == "" {
@@ -191,11 +254,13 @@ func (h *FolderHandler) getRemoteFolder(uid string) (*grizzly.Resource, error) {
 	return &resource, nil
 }
 
-func (h *FolderHandler) getRemoteFolderList() ([]string, error) {
+// searchFolders lists the folders of Grafana. Nested folders refer to their
+// parent with their folder UID.
+func (h *FolderHandler) searchFolders() ([]*models.Hit, error) {
 	var (
 		limit            = int64(1000)
 		page       int64 = 0
-		uids       []string
+		hits       []*models.Hit
 		folderType = "dash-folder"
 	)
 
@@ -215,11 +280,9 @@ func (h *FolderHandler) getRemoteFolderList() ([]string, error) {
 			return nil, err
 		}
 
-		for _, folder := range searchOk.GetPayload() {
-			uids = append(uids, folder.UID)
-		}
+		hits = append(hits, searchOk.GetPayload()...)
 		if int64(len(searchOk.GetPayload())) < *params.Limit {
-			return uids, nil
+			return hits, nil
 		}
 	}
 }
@@ -288,6 +351,21 @@ func (h *FolderHandler) putFolder(resource grizzly.Resource) error {
 	return err
 }
 
+// moveFolder changes the parent of a folder. An empty parent UID moves the
+// folder to the root level.
+func (h *FolderHandler) moveFolder(uid string, parentUID string) error {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Folders.MoveFolder(uid, &models.MoveFolderCommand{ParentUID: parentUID})
+	if err != nil {
+		return fmt.Errorf("moving folder %s under '%s': %w", uid, parentUID, err)
+	}
+	return nil
+}
+
 var getFolderByID = func(client *gclient.GrafanaHTTPAPI, folderId int64) (*models.Folder, error) {
 	folderOk, err := client.Folders.GetFolderByID(folderId)
 	if err != nil {
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/go-chi/chi"
 	library "github.com/grafana/grafana-openapi-client-go/client/library_elements"
@@ -21,6 +24,8 @@ type LibraryElementHandler struct {
 }
 
 var _ grizzly.Handler = &LibraryElementHandler{}
+var _ grizzly.DeleteHandler = &LibraryElementHandler{}
+var _ grizzly.ReadOnlyFieldsHandler = &LibraryElementHandler{}
 
 // NewLibraryElementHandler returns configuration defining a new Grafana Library Element Handler
 func NewLibraryElementHandler(provider grizzly.Provider) *LibraryElementHandler {
@@ -29,6 +34,14 @@ func NewLibraryElementHandler(provider grizzly.Provider) *LibraryElementHandler
 	}
 }
 
+// Permissions returns the permissions required to manage library elements
+func (h *LibraryElementHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Role: "Viewer", Actions: []string{"library.panels:read", "folders:read"}},
+		Write: grizzly.Access{Role: "Editor", Actions: []string{"library.panels:read", "library.panels:create", "library.panels:write", "library.panels:delete", "folders:read"}},
+	}
+}
+
 const (
 	libraryElementPattern = "library-elements/%s-%s.%s"
 )
@@ -69,6 +82,7 @@ func (h *LibraryElementHandler) Prepare(existing *grizzly.Resource, resource gri
 		resource.SetSpecValue("version", val)
 	}
 	resource.DeleteSpecKey("meta")
+	resource.DeleteSpecKey("connections")
 
 	uid, _ := resource.GetSpecString("uid")
 	if uid == "" {
@@ -127,6 +141,34 @@ func (h *LibraryElementHandler) Update(existing, resource grizzly.Resource) erro
 	return h.updateElement(existing, resource)
 }
 
+// Delete deletes an element, unless dashboards still use it
+func (h *LibraryElementHandler) Delete(resource grizzly.Resource) error {
+	connections, err := h.getConnections(resource.Name())
+	if err != nil {
+		return err
+	}
+	if len(connections) > 0 {
+		return fmt.Errorf("library element %s is still used by the dashboards %s", resource.Name(), strings.Join(connections, ", "))
+	}
+
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+	_, err = client.LibraryElements.DeleteLibraryElementByUID(resource.Name())
+	var gErr *library.DeleteLibraryElementByUIDNotFound
+	if errors.As(err, &gErr) {
+		return grizzly.ErrNotFound
+	}
+	return err
+}
+
+// ReadOnlyFields returns the fields maintained by Grafana: the connections of
+// elements, i.e. the UIDs of the dashboards using them
+func (h *LibraryElementHandler) ReadOnlyFields() []string {
+	return []string{"connections"}
+}
+
 func (h *LibraryElementHandler) GetProxyEndpoints(s grizzly.Server) []grizzly.HTTPEndpoint {
 	return []grizzly.HTTPEndpoint{
 		{
@@ -239,6 +281,15 @@ func (h *LibraryElementHandler) getRemoteLibraryElement(uid string) (*grizzly.Re
 	if err != nil {
 		return nil, err
 	}
+	connections, err := h.getConnections(uid)
+	if err != nil {
+		return nil, err
+	}
+	dashboards := make([]any, 0, len(connections))
+	for _, connection := range connections {
+		dashboards = append(dashboards, connection)
+	}
+	spec["connections"] = dashboards
 
 	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), uid, spec)
 	if err != nil {
@@ -246,3 +297,28 @@ func (h *LibraryElementHandler) getRemoteLibraryElement(uid string) (*grizzly.Re
 	}
 	return &resource, nil
 }
+
+// getConnections returns the UIDs of the dashboards using an element, sorted
+func (h *LibraryElementHandler) getConnections(uid string) ([]string, error) {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+	connectionsOk, err := client.LibraryElements.GetLibraryElementConnections(uid)
+	if err != nil {
+		var gErr *library.GetLibraryElementConnectionsNotFound
+		if errors.As(err, &gErr) {
+			return nil, grizzly.ErrNotFound
+		}
+		return nil, err
+	}
+
+	connections := []string{}
+	for _, connection := range connectionsOk.GetPayload().Result {
+		if !slices.Contains(connections, connection.ConnectionUID) {
+			connections = append(connections, connection.ConnectionUID)
+		}
+	}
+	sort.Strings(connections)
+	return connections, nil
+}
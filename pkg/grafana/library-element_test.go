@@ -0,0 +1,80 @@
+package grafana_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryElementConnections(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+	handler, err := registry.GetHandler("LibraryElement")
+	require.NoError(t, err)
+
+	element := func(t *testing.T, connections ...any) grizzly.Resource {
+		t.Helper()
+		spec := map[string]any{
+			"uid":   "cpu",
+			"name":  "CPU",
+			"kind":  1,
+			"model": map[string]any{"type": "timeseries", "title": "CPU"},
+		}
+		if connections != nil {
+			spec["connections"] = connections
+		}
+		return grizzlytest.NewResource(t, "LibraryElement", "cpu", spec)
+	}
+	dashboard := grizzlytest.NewResource(t, "Dashboard", "nodes", map[string]any{
+		"uid":    "nodes",
+		"title":  "Nodes",
+		"panels": []any{map[string]any{"id": 1, "libraryPanel": map[string]any{"uid": "cpu", "name": "CPU"}}},
+	})
+
+	apply := func(t *testing.T, resources ...grizzly.Resource) string {
+		t.Helper()
+		output := &bytes.Buffer{}
+		require.NoError(t, grizzly.Apply(registry, grizzly.NewResources(resources...), false, grizzly.NewWriterRecorder(output, grizzly.EventToPlainText)))
+		return output.String()
+	}
+	diff := func(t *testing.T, resource grizzly.Resource) string {
+		t.Helper()
+		output := &bytes.Buffer{}
+		require.NoError(t, grizzly.Diff(registry, grizzly.NewResources(resource), false, "", grizzly.NewWriterRecorder(output, grizzly.EventToPlainText)))
+		return output.String()
+	}
+
+	apply(t, element(t), dashboard)
+
+	t.Run("connections are pulled", func(t *testing.T) {
+		remote, err := handler.GetByUID("cpu")
+		require.NoError(t, err)
+		require.Equal(t, []any{"nodes"}, remote.GetSpecValue("connections"))
+	})
+
+	t.Run("connections are compared when recorded locally", func(t *testing.T) {
+		require.Contains(t, diff(t, element(t)), "LibraryElement.cpu unchanged")
+		require.Contains(t, diff(t, element(t, "nodes")), "LibraryElement.cpu unchanged")
+		require.Contains(t, diff(t, element(t, "nodes", "hosts")), "+        - hosts")
+	})
+
+	t.Run("connections are never applied", func(t *testing.T) {
+		require.Contains(t, apply(t, element(t, "hosts")), "LibraryElement.cpu unchanged")
+	})
+
+	t.Run("connected elements aren't deleted", func(t *testing.T) {
+		err := handler.(grizzly.DeleteHandler).Delete(element(t))
+		require.ErrorContains(t, err, "library element cpu is still used by the dashboards nodes")
+
+		dashboards, err := registry.GetHandler("Dashboard")
+		require.NoError(t, err)
+		require.NoError(t, dashboards.(grizzly.DeleteHandler).Delete(dashboard))
+
+		require.NoError(t, handler.(grizzly.DeleteHandler).Delete(element(t)))
+		_, err = handler.GetByUID("cpu")
+		require.ErrorIs(t, err, grizzly.ErrNotFound)
+	})
+}
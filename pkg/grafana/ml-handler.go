@@ -0,0 +1,268 @@
+package grafana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	MLJobKind             = "MLJob"
+	MLOutlierDetectorKind = "MLOutlierDetector"
+)
+
+// ErrMLNotSupported is returned by instances without the Machine Learning
+// plugin
+var ErrMLNotSupported = fmt.Errorf("machine learning jobs are %w: they require the Grafana Machine Learning plugin of Grafana Cloud", ErrNotSupported)
+
+const (
+	mlJobsEndpoint     = "/api/plugins/grafana-ml-app/resources/manage/api/v1/jobs"
+	mlOutliersEndpoint = "/api/plugins/grafana-ml-app/resources/manage/api/v1/outliers"
+
+	mlJobPattern             = "ml/job-%s.%s"
+	mlOutlierDetectorPattern = "ml/outlier-detector-%s.%s"
+)
+
+// mlMetricName matches the names of the metrics written by machine learning
+// jobs, which are Prometheus metric names
+var mlMetricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// MLHandler is a Grizzly Handler for the forecast jobs or the outlier
+// detectors of Grafana Machine Learning, identified by the name of the
+// metric they write, which dashboards query
+type MLHandler struct {
+	grizzly.BaseHandler
+
+	endpoint string
+	pattern  string
+	// required are the fields of the spec the ML API requires, besides the
+	// metric and name
+	required []string
+}
+
+var _ grizzly.Handler = &MLHandler{}
+var _ grizzly.DeleteHandler = &MLHandler{}
+
+// NewMLJobHandler returns a new Grizzly Handler for the forecast jobs of
+// Grafana Machine Learning
+func NewMLJobHandler(provider grizzly.Provider) *MLHandler {
+	return &MLHandler{
+		BaseHandler: grizzly.NewBaseHandler(provider, MLJobKind, false),
+		endpoint:    mlJobsEndpoint,
+		pattern:     mlJobPattern,
+		required:    []string{"datasourceUid", "queryParams"},
+	}
+}
+
+// NewMLOutlierDetectorHandler returns a new Grizzly Handler for the outlier
+// detectors of Grafana Machine Learning
+func NewMLOutlierDetectorHandler(provider grizzly.Provider) *MLHandler {
+	return &MLHandler{
+		BaseHandler: grizzly.NewBaseHandler(provider, MLOutlierDetectorKind, false),
+		endpoint:    mlOutliersEndpoint,
+		pattern:     mlOutlierDetectorPattern,
+		required:    []string{"datasourceUid", "queryParams", "algorithm"},
+	}
+}
+
+// Permissions returns the permissions required to manage machine learning
+// jobs
+func (h *MLHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Role: "Viewer", Actions: []string{"grafana-ml-app.jobs:read"}},
+		Write: grizzly.Access{Role: "Editor", Actions: []string{"grafana-ml-app.jobs:read", "grafana-ml-app.jobs:write"}},
+	}
+}
+
+// ResourceFilePath returns the location on disk where a resource should be updated
+func (h *MLHandler) ResourceFilePath(resource grizzly.Resource, filetype string) string {
+	return fmt.Sprintf(h.pattern, resource.Name(), filetype)
+}
+
+// Unprepare removes unnecessary elements from a remote resource ready for presentation/comparison
+func (h *MLHandler) Unprepare(resource grizzly.Resource) *grizzly.Resource {
+	resource.DeleteSpecKey("id")
+	return &resource
+}
+
+// Prepare gets a resource ready for dispatch to the remote endpoint
+func (h *MLHandler) Prepare(existing *grizzly.Resource, resource grizzly.Resource) *grizzly.Resource {
+	if !resource.HasSpecString("metric") {
+		resource.SetSpecString("metric", resource.Name())
+	}
+	return &resource
+}
+
+// Validate checks that the metric of the job matches the name of the
+// resource, and that the fields required by the ML API are set
+func (h *MLHandler) Validate(resource grizzly.Resource) error {
+	metric, exist := resource.GetSpecString("metric")
+	if exist && metric != resource.Name() {
+		return fmt.Errorf("metric '%s' and name '%s', don't match", metric, resource.Name())
+	}
+	if !mlMetricName.MatchString(resource.Name()) {
+		return fmt.Errorf("'%s' is not a valid metric name", resource.Name())
+	}
+	for _, field := range append([]string{"name"}, h.required...) {
+		if value := resource.GetSpecValue(field); value == nil || value == "" {
+			return fmt.Errorf("%s is required", field)
+		}
+	}
+	return nil
+}
+
+func (h *MLHandler) GetSpecUID(resource grizzly.Resource) (string, error) {
+	metric, ok := resource.GetSpecString("metric")
+	if !ok {
+		return "", fmt.Errorf("metric not specified")
+	}
+	return metric, nil
+}
+
+// GetByUID retrieves JSON for a resource from an endpoint, by metric
+func (h *MLHandler) GetByUID(metric string) (*grizzly.Resource, error) {
+	return h.getRemote(metric)
+}
+
+// GetRemote retrieves a job as a Resource
+func (h *MLHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	return h.getRemote(resource.Name())
+}
+
+// ListRemote retrieves as list of metrics of all remote jobs. Instances
+// without the Machine Learning plugin have none, so that pulling doesn't
+// fail on them.
+func (h *MLHandler) ListRemote() ([]string, error) {
+	jobs, err := h.list()
+	if errors.Is(err, ErrMLNotSupported) {
+		log.Debugf("Not listing %s: %s", h.Kind(), err)
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		if metric, _ := job["metric"].(string); metric != "" {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics, nil
+}
+
+// Add creates a job
+func (h *MLHandler) Add(resource grizzly.Resource) error {
+	return h.request(http.MethodPost, h.endpoint, mlObject(resource), nil)
+}
+
+// Update replaces a job. The ML API updates jobs with POST requests.
+func (h *MLHandler) Update(existing, resource grizzly.Resource) error {
+	remote, err := h.find(resource.Name())
+	if err != nil {
+		return err
+	}
+
+	return h.request(http.MethodPost, h.endpoint+"/"+url.PathEscape(mlID(remote)), mlObject(resource), nil)
+}
+
+// Delete deletes a job
+func (h *MLHandler) Delete(resource grizzly.Resource) error {
+	remote, err := h.find(resource.Name())
+	if err != nil {
+		return err
+	}
+
+	return h.request(http.MethodDelete, h.endpoint+"/"+url.PathEscape(mlID(remote)), nil, nil)
+}
+
+// request sends a request to the ML API, decoding the data of its response
+// into target unless nil. Instances without the plugin don't serve the API.
+func (h *MLHandler) request(method string, path string, body any, target any) error {
+	resp, err := h.Provider.(*Provider).send(method, path, body)
+	var status grizzly.HTTPStatusError
+	if errors.As(err, &status) && status.StatusCode == http.StatusNotFound {
+		// the collection of jobs is missing along with the plugin
+		if path == h.endpoint {
+			return ErrMLNotSupported
+		}
+		return grizzly.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if target == nil {
+		return nil
+	}
+
+	// responses are wrapped, with their status
+	var wrapped struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&wrapped); err != nil {
+		return err
+	}
+	return json.Unmarshal(wrapped.Data, target)
+}
+
+// list lists the jobs of the kind of the handler
+func (h *MLHandler) list() ([]map[string]any, error) {
+	jobs := []map[string]any{}
+	if err := h.request(http.MethodGet, h.endpoint, nil, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// find returns a job by metric
+func (h *MLHandler) find(metric string) (map[string]any, error) {
+	jobs, err := h.list()
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job["metric"] == metric {
+			return job, nil
+		}
+	}
+
+	return nil, grizzly.ErrNotFound
+}
+
+// getRemote retrieves a job as a resource
+func (h *MLHandler) getRemote(metric string) (*grizzly.Resource, error) {
+	job, err := h.find(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), metric, job)
+	if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// mlObject returns the job described by a resource, without its ID, which
+// differs between stacks
+func mlObject(resource grizzly.Resource) map[string]any {
+	object := make(map[string]any, len(resource.Spec()))
+	for key, value := range resource.Spec() {
+		object[key] = value
+	}
+	object["metric"] = resource.Name()
+	delete(object, "id")
+	return object
+}
+
+func mlID(job map[string]any) string {
+	id, _ := job["id"].(string)
+	return id
+}
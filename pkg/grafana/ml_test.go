@@ -0,0 +1,96 @@
+package grafana_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMLJobs(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+	apply := func(t *testing.T, resource grizzly.Resource) error {
+		t.Helper()
+		return grizzly.Apply(registry, grizzly.NewResources(resource), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText))
+	}
+	job := func(t *testing.T, kind, metric string, spec map[string]any) grizzly.Resource {
+		t.Helper()
+		spec["metric"] = metric
+		spec["datasourceUid"] = "prometheus"
+		spec["datasourceType"] = "prometheus"
+		spec["queryParams"] = map[string]any{"expr": "sum(rate(checkout_requests_total[5m]))"}
+		return grizzlytest.NewResource(t, kind, metric, spec)
+	}
+
+	t.Run("forecast jobs are identified by their metric", func(t *testing.T) {
+		forecast := job(t, "MLJob", "checkout_requests_forecast", map[string]any{
+			"name":           "Checkout requests",
+			"interval":       300,
+			"trainingWindow": 7776000,
+			"hyperParams":    map[string]any{"changepoint_prior_scale": 0.05},
+		})
+		require.NoError(t, apply(t, forecast))
+		stored, found := server.MLJob("jobs", "checkout_requests_forecast")
+		require.True(t, found)
+		require.Equal(t, "Checkout requests", stored["name"])
+
+		handler, err := registry.GetHandler("MLJob")
+		require.NoError(t, err)
+		remote, err := handler.GetByUID("checkout_requests_forecast")
+		require.NoError(t, err)
+		require.Nil(t, handler.Unprepare(*remote).GetSpecValue("id"), "IDs differ between stacks")
+
+		forecast.SetSpecValue("interval", 600)
+		require.NoError(t, apply(t, forecast))
+		updated, _ := server.MLJob("jobs", "checkout_requests_forecast")
+		require.Equal(t, stored["id"], updated["id"])
+		require.EqualValues(t, 600, updated["interval"])
+
+		metrics, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.Equal(t, []string{"checkout_requests_forecast"}, metrics)
+	})
+
+	t.Run("outlier detectors are deleted", func(t *testing.T) {
+		detector := job(t, "MLOutlierDetector", "checkout_latency_outliers", map[string]any{
+			"name":      "Checkout latency per pod",
+			"interval":  300,
+			"algorithm": map[string]any{"name": "dbscan", "sensitivity": 0.5, "config": map[string]any{"epsilon": 1.0}},
+		})
+		require.NoError(t, apply(t, detector))
+		_, found := server.MLJob("outliers", "checkout_latency_outliers")
+		require.True(t, found)
+
+		handler, err := registry.GetHandler("MLOutlierDetector")
+		require.NoError(t, err)
+		require.NoError(t, handler.(grizzly.DeleteHandler).Delete(detector))
+		_, found = server.MLJob("outliers", "checkout_latency_outliers")
+		require.False(t, found)
+		require.ErrorIs(t, handler.(grizzly.DeleteHandler).Delete(detector), grizzly.ErrNotFound)
+	})
+
+	t.Run("invalid jobs are reported", func(t *testing.T) {
+		handler, err := registry.GetHandler("MLOutlierDetector")
+		require.NoError(t, err)
+
+		require.ErrorContains(t, handler.Validate(job(t, "MLOutlierDetector", "latency_outliers", map[string]any{"name": "Latency"})), "algorithm is required")
+		require.ErrorContains(t, handler.Validate(job(t, "MLOutlierDetector", "latency-outliers", map[string]any{"name": "Latency"})), "'latency-outliers' is not a valid metric name")
+	})
+
+	t.Run("instances without the Machine Learning plugin have no job", func(t *testing.T) {
+		server.SetML(false)
+		defer server.SetML(true)
+
+		handler, err := registry.GetHandler("MLJob")
+		require.NoError(t, err)
+		metrics, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.Empty(t, metrics)
+
+		require.ErrorIs(t, apply(t, job(t, "MLJob", "checkout_requests_forecast", map[string]any{"name": "Checkout requests"})), grafana.ErrMLNotSupported)
+	})
+}
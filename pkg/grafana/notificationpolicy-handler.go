@@ -25,6 +25,12 @@ func NewAlertNotificationPolicyHandler(provider grizzly.Provider) *AlertNotifica
 	}
 }
 
+// Permissions returns the permissions required to manage the notification
+// policy, through the alerting provisioning API
+func (h *AlertNotificationPolicyHandler) Permissions() grizzly.Permissions {
+	return alertingProvisioningPermissions
+}
+
 const (
 	alertNotificationPolicyFile = "alertNotificationPolicy.yaml"
 )
@@ -48,26 +54,46 @@ func (h *AlertNotificationPolicyHandler) GetSpecUID(resource grizzly.Resource) (
 
 // GetByUID retrieves JSON for a resource from an endpoint, by UID
 func (h *AlertNotificationPolicyHandler) GetByUID(uid string) (*grizzly.Resource, error) {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return nil, err
+	}
+
 	return h.getRemoteAlertNotificationPolicy()
 }
 
 // GetRemote retrieves a alertNotificationPolicy as a Resource
 func (h *AlertNotificationPolicyHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return nil, err
+	}
+
 	return h.getRemoteAlertNotificationPolicy()
 }
 
 // ListRemote retrieves as list of UIDs of all remote resources
 func (h *AlertNotificationPolicyHandler) ListRemote() ([]string, error) {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return nil, err
+	}
+
 	return h.getRemoteAlertNotificationPolicyList()
 }
 
 // Add pushes a alertNotificationPolicy to Grafana via the API
 func (h *AlertNotificationPolicyHandler) Add(resource grizzly.Resource) error {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return err
+	}
+
 	return h.putAlertNotificationPolicy(resource)
 }
 
 // Update pushes a alertNotificationPolicy to Grafana via the API
 func (h *AlertNotificationPolicyHandler) Update(existing, resource grizzly.Resource) error {
+	if err := requireFeature(h.Provider, FeatureAlertingProvisioning); err != nil {
+		return err
+	}
+
 	return h.putAlertNotificationPolicy(resource)
 }
 
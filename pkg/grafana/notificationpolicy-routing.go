@@ -0,0 +1,338 @@
+package grafana
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultPolicyTimings are the timings of notifications used by Grafana when
+// the default policy doesn't set them
+var defaultPolicyTimings = policyRoute{
+	GroupWait:      "30s",
+	GroupInterval:  "5m",
+	RepeatInterval: "4h",
+}
+
+// policyRoute is a notification policy, as described by the spec of the
+// notification policy resource
+type policyRoute struct {
+	Receiver            string            `json:"receiver"`
+	GroupBy             []string          `json:"group_by"`
+	GroupWait           string            `json:"group_wait"`
+	GroupInterval       string            `json:"group_interval"`
+	RepeatInterval      string            `json:"repeat_interval"`
+	ObjectMatchers      [][]string        `json:"object_matchers"`
+	Match               map[string]string `json:"match"`
+	MatchRe             map[string]string `json:"match_re"`
+	Matchers            any               `json:"matchers"`
+	Continue            bool              `json:"continue"`
+	MuteTimeIntervals   []string          `json:"mute_time_intervals"`
+	ActiveTimeIntervals []string          `json:"active_time_intervals"`
+	Routes              []*policyRoute    `json:"routes"`
+}
+
+// labelMatcher matches the value of a label
+type labelMatcher struct {
+	label    string
+	operator string
+	value    string
+	regexp   *regexp.Regexp
+}
+
+func (m labelMatcher) matches(labels map[string]string) bool {
+	value := labels[m.label]
+	switch m.operator {
+	case "=":
+		return value == m.value
+	case "!=":
+		return value != m.value
+	case "=~":
+		return m.regexp.MatchString(value)
+	default:
+		return !m.regexp.MatchString(value)
+	}
+}
+
+func (m labelMatcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.label, m.operator, m.value)
+}
+
+// RoutedAlert is a contact point an alert is routed to by a notification
+// policy, along with how its notifications are grouped and sent
+type RoutedAlert struct {
+	Receiver string `yaml:"receiver" json:"receiver"`
+	// ContactPoints are the UIDs of the contact points named after the
+	// receiver, empty when none is known
+	ContactPoints []string `yaml:"contactPoints" json:"contactPoints"`
+	// Policy locates the policy routing the alert in the policy tree, e.g.
+	// `routes[0].routes[1]`, empty for the default policy
+	Policy string `yaml:"policy" json:"policy"`
+	// Matchers are the matchers of the policies leading to the alert
+	Matchers            []string `yaml:"matchers" json:"matchers"`
+	GroupBy             []string `yaml:"groupBy" json:"groupBy"`
+	GroupWait           string   `yaml:"groupWait" json:"groupWait"`
+	GroupInterval       string   `yaml:"groupInterval" json:"groupInterval"`
+	RepeatInterval      string   `yaml:"repeatInterval" json:"repeatInterval"`
+	MuteTimeIntervals   []string `yaml:"muteTimeIntervals,omitempty" json:"muteTimeIntervals,omitempty"`
+	ActiveTimeIntervals []string `yaml:"activeTimeIntervals,omitempty" json:"activeTimeIntervals,omitempty"`
+}
+
+// RoutingSimulation lists where an alert is routed by a notification policy
+type RoutingSimulation struct {
+	Labels map[string]string `yaml:"labels" json:"labels"`
+	Routes []RoutedAlert     `yaml:"routes" json:"routes"`
+}
+
+// SimulateRouting routes an alert with the given labels through the local
+// notification policy, as Alertmanager does: an alert goes down the first
+// policy it matches at each level, and on to the next ones when a policy
+// continues, stopping at the most specific policies. Timings and grouping
+// are inherited from parent policies, mute and active timings aren't.
+// Receivers are resolved to the local contact points, warning about unknown
+// ones when there are contact points.
+func SimulateRouting(resources grizzly.Resources, labels map[string]string) (RoutingSimulation, []grizzly.Warning, error) {
+	simulation := RoutingSimulation{Labels: labels, Routes: []RoutedAlert{}}
+
+	var policy *grizzly.Resource
+	contactPoints := map[string][]string{}
+	hasContactPoints := false
+	for _, resource := range resources.AsList() {
+		switch resource.Kind() {
+		case "AlertNotificationPolicy":
+			policy = &resource
+		case "AlertContactPoint":
+			name, _ := resource.GetSpecString("name")
+			contactPoints[name] = append(contactPoints[name], resource.Name())
+			hasContactPoints = true
+		}
+	}
+	if policy == nil {
+		return simulation, nil, fmt.Errorf("no notification policy found")
+	}
+
+	content, err := json.Marshal(policy.Spec())
+	if err != nil {
+		return simulation, nil, err
+	}
+	root := &policyRoute{}
+	if err := json.Unmarshal(content, root); err != nil {
+		return simulation, nil, fmt.Errorf("invalid notification policy: %w", err)
+	}
+	if root.Receiver == "" {
+		return simulation, nil, fmt.Errorf("the default notification policy has no contact point")
+	}
+	inherit(root, &defaultPolicyTimings)
+
+	routes, err := routeAlert(root, "", nil, labels)
+	if err != nil {
+		return simulation, nil, err
+	}
+
+	var warnings []grizzly.Warning
+	for _, route := range routes {
+		route.ContactPoints = contactPoints[route.Receiver]
+		sort.Strings(route.ContactPoints)
+		if route.ContactPoints == nil {
+			route.ContactPoints = []string{}
+			if hasContactPoints {
+				warnings = append(warnings, grizzly.NewResourceWarning(policy.Ref(), fmt.Errorf("alerts are routed to the unknown contact point %s", route.Receiver)))
+			}
+		}
+		simulation.Routes = append(simulation.Routes, route)
+	}
+
+	return simulation, warnings, nil
+}
+
+// routeAlert returns where the policies under a policy matching an alert
+// route it, or the policy itself when none of them does
+func routeAlert(route *policyRoute, path string, matchers []string, labels map[string]string) ([]RoutedAlert, error) {
+	routed := []RoutedAlert{}
+	for i, child := range route.Routes {
+		if child == nil {
+			continue
+		}
+		childPath := fmt.Sprintf("%sroutes[%d]", path, i)
+		childMatchers, err := policyMatchers(child)
+		if err != nil {
+			return nil, fmt.Errorf("notification policy %s: %w", childPath, err)
+		}
+		if !matchAll(childMatchers, labels) {
+			continue
+		}
+
+		inherit(child, route)
+		descriptions := append([]string{}, matchers...)
+		for _, matcher := range childMatchers {
+			descriptions = append(descriptions, matcher.String())
+		}
+		routes, err := routeAlert(child, childPath+".", descriptions, labels)
+		if err != nil {
+			return nil, err
+		}
+		routed = append(routed, routes...)
+		if !child.Continue {
+			break
+		}
+	}
+	if len(routed) > 0 {
+		return routed, nil
+	}
+
+	return []RoutedAlert{{
+		Receiver:            route.Receiver,
+		Policy:              strings.TrimSuffix(path, "."),
+		Matchers:            append([]string{}, matchers...),
+		GroupBy:             append([]string{}, route.GroupBy...),
+		GroupWait:           route.GroupWait,
+		GroupInterval:       route.GroupInterval,
+		RepeatInterval:      route.RepeatInterval,
+		MuteTimeIntervals:   route.MuteTimeIntervals,
+		ActiveTimeIntervals: route.ActiveTimeIntervals,
+	}}, nil
+}
+
+// inherit fills in the receiver, grouping and timings a policy leaves out
+// from its parent
+func inherit(route *policyRoute, parent *policyRoute) {
+	if route.Receiver == "" {
+		route.Receiver = parent.Receiver
+	}
+	if route.GroupBy == nil {
+		route.GroupBy = parent.GroupBy
+	}
+	if route.GroupWait == "" {
+		route.GroupWait = parent.GroupWait
+	}
+	if route.GroupInterval == "" {
+		route.GroupInterval = parent.GroupInterval
+	}
+	if route.RepeatInterval == "" {
+		route.RepeatInterval = parent.RepeatInterval
+	}
+}
+
+// policyMatchers returns the matchers of a policy, given as object matchers,
+// or with the legacy match and match_re fields
+func policyMatchers(route *policyRoute) ([]labelMatcher, error) {
+	if route.Matchers != nil {
+		return nil, fmt.Errorf("matchers aren't supported, use object_matchers")
+	}
+
+	matchers := []labelMatcher{}
+	add := func(label, operator, value string) error {
+		matcher := labelMatcher{label: label, operator: operator, value: value}
+		switch operator {
+		case "=", "!=":
+		case "=~", "!~":
+			// regular expressions match whole values, as in Alertmanager
+			re, err := regexp.Compile("^(?:" + value + ")$")
+			if err != nil {
+				return fmt.Errorf("invalid regular expression for %s: %w", label, err)
+			}
+			matcher.regexp = re
+		default:
+			return fmt.Errorf("unknown matcher operator %s for %s", operator, label)
+		}
+		matchers = append(matchers, matcher)
+		return nil
+	}
+
+	for _, matcher := range route.ObjectMatchers {
+		if len(matcher) != 3 {
+			return nil, fmt.Errorf("object matchers must be [label, operator, value], got %v", matcher)
+		}
+		if err := add(matcher[0], matcher[1], matcher[2]); err != nil {
+			return nil, err
+		}
+	}
+	for _, label := range sortedKeys(route.Match) {
+		if err := add(label, "=", route.Match[label]); err != nil {
+			return nil, err
+		}
+	}
+	for _, label := range sortedKeys(route.MatchRe) {
+		if err := add(label, "=~", route.MatchRe[label]); err != nil {
+			return nil, err
+		}
+	}
+
+	return matchers, nil
+}
+
+func matchAll(matchers []labelMatcher, labels map[string]string) bool {
+	for _, matcher := range matchers {
+		if !matcher.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Receivers returns the receivers the alert is routed to, sorted
+func (simulation RoutingSimulation) Receivers() []string {
+	receivers := []string{}
+	for _, route := range simulation.Routes {
+		receivers = append(receivers, route.Receiver)
+	}
+	sort.Strings(receivers)
+	return receivers
+}
+
+// Format formats the simulation as a table, JSON or YAML
+func (simulation RoutingSimulation) Format(format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.MarshalIndent(simulation, "", "  ")
+	case "yaml":
+		return yaml.Marshal(simulation)
+	case "default", "":
+	default:
+		return nil, fmt.Errorf("unknown format %s", format)
+	}
+
+	orNone := func(values []string) string {
+		if len(values) == 0 {
+			return "-"
+		}
+		return strings.Join(values, ", ")
+	}
+
+	buffer := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buffer, 4, 4, 4, ' ', 0)
+	fmt.Fprintf(w, "RECEIVER\tCONTACT POINTS\tPOLICY\tGROUP BY\tTIMINGS\tMUTED\n")
+	for _, route := range simulation.Routes {
+		policy := "default policy"
+		if route.Policy != "" {
+			policy = fmt.Sprintf("%s {%s}", route.Policy, strings.Join(route.Matchers, ", "))
+		}
+		timings := fmt.Sprintf("%s/%s/%s", route.GroupWait, route.GroupInterval, route.RepeatInterval)
+		muted := orNone(route.MuteTimeIntervals)
+		if len(route.ActiveTimeIntervals) > 0 {
+			muted += fmt.Sprintf(" (active during %s)", strings.Join(route.ActiveTimeIntervals, ", "))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", route.Receiver, orNone(route.ContactPoints), policy, orNone(route.GroupBy), timings, muted)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,113 @@
+package grafana_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateRouting(t *testing.T) {
+	policy := grizzlytest.NewResource(t, "AlertNotificationPolicy", "global", map[string]any{
+		"receiver":        "default",
+		"group_by":        []any{"alertname"},
+		"repeat_interval": "12h",
+		"routes": []any{
+			map[string]any{
+				"receiver":        "platform",
+				"object_matchers": []any{[]any{"team", "=", "platform"}},
+				"continue":        true,
+				"routes": []any{
+					map[string]any{
+						"receiver":            "platform-pager",
+						"object_matchers":     []any{[]any{"severity", "=~", "critical|page"}},
+						"group_wait":          "10s",
+						"mute_time_intervals": []any{"maintenance"},
+					},
+				},
+			},
+			map[string]any{
+				"receiver": "audit",
+				"match_re": map[string]any{"team": "platform|databases"},
+			},
+			map[string]any{
+				"receiver":        "unrouted",
+				"object_matchers": []any{[]any{"team", "!=", ""}},
+			},
+		},
+	})
+	contactPoints := []grizzly.Resource{
+		grizzlytest.NewResource(t, "AlertContactPoint", "default-email", map[string]any{"uid": "default-email", "name": "default", "type": "email"}),
+		grizzlytest.NewResource(t, "AlertContactPoint", "platform-slack", map[string]any{"uid": "platform-slack", "name": "platform", "type": "slack"}),
+		grizzlytest.NewResource(t, "AlertContactPoint", "platform-pagerduty", map[string]any{"uid": "platform-pagerduty", "name": "platform-pager", "type": "pagerduty"}),
+		grizzlytest.NewResource(t, "AlertContactPoint", "platform-email", map[string]any{"uid": "platform-email", "name": "platform-pager", "type": "email"}),
+	}
+	simulate := func(t *testing.T, labels map[string]string) (grafana.RoutingSimulation, []grizzly.Warning) {
+		t.Helper()
+		simulation, warnings, err := grafana.SimulateRouting(grizzly.NewResources(append(contactPoints, policy)...), labels)
+		require.NoError(t, err)
+		return simulation, warnings
+	}
+
+	t.Run("alerts matching no policy go to the default policy", func(t *testing.T) {
+		simulation, warnings := simulate(t, map[string]string{"alertname": "Disk"})
+		require.Empty(t, warnings)
+		require.Equal(t, []grafana.RoutedAlert{{
+			Receiver:       "default",
+			ContactPoints:  []string{"default-email"},
+			Policy:         "",
+			Matchers:       []string{},
+			GroupBy:        []string{"alertname"},
+			GroupWait:      "30s",
+			GroupInterval:  "5m",
+			RepeatInterval: "12h",
+		}}, simulation.Routes)
+	})
+
+	t.Run("alerts go down the most specific policies, and on when policies continue", func(t *testing.T) {
+		simulation, warnings := simulate(t, map[string]string{"team": "platform", "severity": "critical"})
+		require.Equal(t, []string{"audit", "platform-pager"}, simulation.Receivers())
+
+		pager := simulation.Routes[0]
+		require.Equal(t, "routes[0].routes[0]", pager.Policy)
+		require.Equal(t, []string{`team="platform"`, `severity=~"critical|page"`}, pager.Matchers)
+		require.Equal(t, []string{"platform-email", "platform-pagerduty"}, pager.ContactPoints)
+		require.Equal(t, "10s", pager.GroupWait)
+		require.Equal(t, "12h", pager.RepeatInterval, "timings are inherited")
+		require.Equal(t, []string{"maintenance"}, pager.MuteTimeIntervals)
+
+		require.Len(t, warnings, 1)
+		require.ErrorContains(t, warnings[0], "alerts are routed to the unknown contact point audit")
+	})
+
+	t.Run("regular expressions match whole values", func(t *testing.T) {
+		simulation, _ := simulate(t, map[string]string{"team": "platform", "severity": "critical-ish"})
+		require.Equal(t, []string{"audit", "platform"}, simulation.Receivers())
+	})
+
+	t.Run("missing labels are empty", func(t *testing.T) {
+		simulation, _ := simulate(t, map[string]string{"team": "frontend"})
+		require.Equal(t, []string{"unrouted"}, simulation.Receivers())
+	})
+
+	t.Run("routes are formatted as a table", func(t *testing.T) {
+		simulation, _ := simulate(t, map[string]string{"team": "platform", "severity": "page"})
+		output, err := simulation.Format("default")
+		require.NoError(t, err)
+		require.Contains(t, string(output), "platform-pager    platform-email, platform-pagerduty    routes[0].routes[0] {team=\"platform\", severity=~\"critical|page\"}")
+	})
+
+	t.Run("invalid policies are reported", func(t *testing.T) {
+		invalid := grizzlytest.NewResource(t, "AlertNotificationPolicy", "global", map[string]any{
+			"receiver": "default",
+			"routes":   []any{map[string]any{"object_matchers": []any{[]any{"team", "=~", "("}}}},
+		})
+		_, _, err := grafana.SimulateRouting(grizzly.NewResources(invalid), map[string]string{})
+		require.ErrorContains(t, err, "notification policy routes[0]: invalid regular expression for team")
+
+		_, _, err = grafana.SimulateRouting(grizzly.NewResources(contactPoints...), map[string]string{})
+		require.ErrorContains(t, err, "no notification policy found")
+	})
+}
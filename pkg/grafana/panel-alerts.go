@@ -0,0 +1,297 @@
+package grafana
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// PanelAlertOptions configure the alert rules scaffolded from panels
+type PanelAlertOptions struct {
+	// Folder is the UID of the folder of the rule groups, the folder of each
+	// dashboard by default
+	Folder string
+	// Interval is the evaluation interval of the rule groups, in seconds
+	Interval int64
+	// For is the pending period of the rules, e.g. `5m`
+	For string
+}
+
+// dashboardVariable matches the references to dashboard variables, which
+// rules can't resolve. The `$__` variables are supported by alerting.
+var dashboardVariable = regexp.MustCompile(`\$(\{)?[a-zA-Z]`)
+
+// PanelAlertRules scaffolds an alert rule group per dashboard, with a rule
+// per query of the panels having thresholds: the rule fires when the last
+// value of a series crosses the first red threshold, or the last one. Panels
+// that can't be converted, e.g. whose queries use dashboard variables, are
+// reported as warnings. Panels with the default thresholds are left out.
+func PanelAlertRules(resources grizzly.Resources, options PanelAlertOptions) (grizzly.Resources, []grizzly.Warning) {
+	groups := grizzly.NewResources()
+	var warnings []grizzly.Warning
+
+	for _, resource := range resources.AsList() {
+		if resource.Kind() != "Dashboard" {
+			continue
+		}
+		warn := func(panel map[string]any, reason string) {
+			id, _ := numberValue(panel["id"])
+			title, _ := panel["title"].(string)
+			warnings = append(warnings, grizzly.NewResourceWarning(resource.Ref(), fmt.Errorf("panel %d (%s) not converted to an alert rule: %s", int(id), title, reason)))
+		}
+
+		folder := options.Folder
+		if folder == "" {
+			folder = resource.GetMetadata("folder")
+		}
+		dashboardTitle, _ := resource.GetSpecString("title")
+		if dashboardTitle == "" {
+			dashboardTitle = resource.Name()
+		}
+
+		rules := []any{}
+		// titles of rules must be unique within their folder
+		titles := map[string]int{}
+		panels, _ := resource.GetSpecValue("panels").([]any)
+		_ = forEachPanel(panels, func(panel map[string]any) error {
+			if panel["type"] == "row" || panel["libraryPanel"] != nil {
+				return nil
+			}
+			evaluator, err := panelThreshold(panel)
+			if err != nil {
+				warn(panel, err.Error())
+				return nil
+			}
+			if evaluator == nil {
+				return nil
+			}
+
+			targets := panelTargets(panel)
+			if len(targets) == 0 {
+				warn(panel, "no queries")
+				return nil
+			}
+			for _, target := range targets {
+				rule, err := panelTargetRule(resource, panel, target, evaluator, options)
+				if err != nil {
+					warn(panel, err.Error())
+					continue
+				}
+				if len(targets) > 1 {
+					rule["title"] = fmt.Sprintf("%s (%s)", rule["title"], target["refId"])
+				}
+				title := rule["title"].(string)
+				titles[title]++
+				if titles[title] > 1 {
+					rule["title"] = fmt.Sprintf("%s (%d)", title, titles[title])
+				}
+				rules = append(rules, rule)
+			}
+			return nil
+		})
+		if len(rules) == 0 {
+			continue
+		}
+		if folder == "" || folder == generalFolderUID {
+			warnings = append(warnings, grizzly.NewResourceWarning(resource.Ref(), fmt.Errorf("alert rules can't be in the General folder, give the folder of the rules")))
+			continue
+		}
+
+		interval := options.Interval
+		if interval <= 0 {
+			interval = 60
+		}
+		group, err := grizzly.NewResource(resource.APIVersion(), "AlertRuleGroup", folder+"."+dashboardTitle, map[string]any{
+			"title":     dashboardTitle,
+			"folderUid": folder,
+			"interval":  interval,
+			"rules":     rules,
+		})
+		if err != nil {
+			warnings = append(warnings, grizzly.NewResourceWarning(resource.Ref(), err))
+			continue
+		}
+		groups.Add(group)
+	}
+
+	return groups, warnings
+}
+
+// thresholdEvaluator is the condition of a rule derived from the thresholds
+// of a panel
+type thresholdEvaluator struct {
+	Type  string // gt or lt
+	Value float64
+}
+
+// panelThreshold returns the condition worth alerting on from the absolute
+// thresholds of a panel, if any: above the first red threshold, or the last
+// one, or below the first threshold when lower values are the red ones
+func panelThreshold(panel map[string]any) (*thresholdEvaluator, error) {
+	fieldConfig, _ := panel["fieldConfig"].(map[string]any)
+	defaults, _ := fieldConfig["defaults"].(map[string]any)
+	thresholds, _ := defaults["thresholds"].(map[string]any)
+	steps, _ := thresholds["steps"].([]any)
+	if len(steps) < 2 {
+		return nil, nil
+	}
+	if mode, _ := thresholds["mode"].(string); mode != "" && mode != "absolute" {
+		return nil, fmt.Errorf("%s thresholds aren't supported", mode)
+	}
+
+	base, _ := steps[0].(map[string]any)
+	var values []float64
+	var red []float64
+	for _, item := range steps[1:] {
+		step, _ := item.(map[string]any)
+		value, ok := numberValue(step["value"])
+		if !ok {
+			continue
+		}
+		values = append(values, value)
+		if isRed(step["color"]) {
+			red = append(red, value)
+		}
+	}
+	switch {
+	case len(values) == 0, isDefaultThresholds(base, steps[1:]):
+		return nil, nil
+	case isRed(base["color"]):
+		return &thresholdEvaluator{Type: "lt", Value: values[0]}, nil
+	case len(red) > 0:
+		return &thresholdEvaluator{Type: "gt", Value: red[0]}, nil
+	}
+	return &thresholdEvaluator{Type: "gt", Value: values[len(values)-1]}, nil
+}
+
+// isDefaultThresholds tells whether thresholds are the ones of new panels,
+// which don't tell anything about what is worth alerting on
+func isDefaultThresholds(base map[string]any, steps []any) bool {
+	if len(steps) != 1 || base["color"] != "green" {
+		return false
+	}
+	step, _ := steps[0].(map[string]any)
+	value, _ := numberValue(step["value"])
+	return step["color"] == "red" && value == 80
+}
+
+// numberValue returns a number decoded from JSON or YAML
+func numberValue(value any) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	}
+	return 0, false
+}
+
+// isRed tells whether a color of a threshold is a shade of red
+func isRed(color any) bool {
+	name, _ := color.(string)
+	return strings.HasSuffix(name, "red") || strings.EqualFold(name, "#F2495C")
+}
+
+// panelTargets returns the queries of a panel that aren't hidden
+func panelTargets(panel map[string]any) []map[string]any {
+	items, _ := panel["targets"].([]any)
+	targets := []map[string]any{}
+	for _, item := range items {
+		target, ok := item.(map[string]any)
+		if !ok || target["hide"] == true {
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// panelTargetRule scaffolds the rule of a query of a panel: the query, the
+// last value of its series, and the threshold
+func panelTargetRule(dashboard grizzly.Resource, panel map[string]any, target map[string]any, evaluator *thresholdEvaluator, options PanelAlertOptions) (map[string]any, error) {
+	datasource, _ := target["datasource"].(map[string]any)
+	if datasource == nil {
+		datasource, _ = panel["datasource"].(map[string]any)
+	}
+	uid, _ := datasource["uid"].(string)
+	switch {
+	case uid == "":
+		return nil, fmt.Errorf("query %s has no datasource UID", target["refId"])
+	case strings.HasPrefix(uid, "$") || strings.HasPrefix(uid, "-- "):
+		return nil, fmt.Errorf("datasource %s of query %s can't be used by alert rules", uid, target["refId"])
+	}
+	for key, value := range target {
+		if text, ok := value.(string); ok && key != "datasource" && dashboardVariable.MatchString(text) {
+			return nil, fmt.Errorf("query %s uses dashboard variables", target["refId"])
+		}
+	}
+
+	model := make(map[string]any, len(target))
+	for key, value := range target {
+		model[key] = value
+	}
+	model["refId"] = "A"
+	model["datasource"] = datasource
+	expression := map[string]any{"type": "__expr__", "uid": "__expr__"}
+
+	panelTitle, _ := panel["title"].(string)
+	dashboardTitle, _ := dashboard.GetSpecString("title")
+	id, _ := numberValue(panel["id"])
+	direction := "above"
+	if evaluator.Type == "lt" {
+		direction = "below"
+	}
+	pendingPeriod := options.For
+	if pendingPeriod == "" {
+		pendingPeriod = "5m"
+	}
+
+	return map[string]any{
+		"title":     strings.TrimPrefix(dashboardTitle+": "+panelTitle, ": "),
+		"condition": "C",
+		"data": []any{
+			map[string]any{
+				"refId":             "A",
+				"datasourceUid":     uid,
+				"relativeTimeRange": map[string]any{"from": 600, "to": 0},
+				"model":             model,
+			},
+			map[string]any{
+				"refId":         "B",
+				"datasourceUid": "__expr__",
+				"model": map[string]any{
+					"refId":      "B",
+					"type":       "reduce",
+					"expression": "A",
+					"reducer":    "last",
+					"datasource": expression,
+				},
+			},
+			map[string]any{
+				"refId":         "C",
+				"datasourceUid": "__expr__",
+				"model": map[string]any{
+					"refId":      "C",
+					"type":       "threshold",
+					"expression": "B",
+					"conditions": []any{
+						map[string]any{"evaluator": map[string]any{"type": evaluator.Type, "params": []any{evaluator.Value}}},
+					},
+					"datasource": expression,
+				},
+			},
+		},
+		"noDataState":  "NoData",
+		"execErrState": "Error",
+		"for":          pendingPeriod,
+		"annotations": map[string]any{
+			"__dashboardUid__": dashboard.Name(),
+			"__panelId__":      fmt.Sprint(int(id)),
+			"summary":          fmt.Sprintf("%s is %s %v", panelTitle, direction, evaluator.Value),
+		},
+	}, nil
+}
@@ -0,0 +1,111 @@
+package grafana_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPanelAlertRules(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	dashboards := `apiVersion: grizzly.grafana.com/v1alpha1
+kind: Dashboard
+metadata:
+  name: api
+  folder: services
+spec:
+  title: API
+  panels:
+    - id: 1
+      title: Error rate
+      datasource: {type: prometheus, uid: prom}
+      fieldConfig:
+        defaults:
+          thresholds:
+            mode: absolute
+            steps:
+              - {color: green, value: null}
+              - {color: orange, value: 0.01}
+              - {color: red, value: 0.05}
+      targets:
+        - refId: A
+          expr: sum(rate(errors_total[$__rate_interval]))
+    - id: 2
+      title: Free disk
+      datasource: {type: prometheus, uid: prom}
+      fieldConfig:
+        defaults:
+          thresholds:
+            steps:
+              - {color: red, value: null}
+              - {color: green, value: 10}
+      targets:
+        - {refId: A, expr: disk_free_percent}
+    - id: 3
+      title: Latency
+      datasource: {type: prometheus, uid: prom}
+      fieldConfig:
+        defaults:
+          thresholds:
+            steps:
+              - {color: green, value: null}
+              - {color: red, value: 80}
+      targets:
+        - {refId: A, expr: latency_seconds}
+    - type: row
+      collapsed: true
+      panels:
+        - id: 4
+          title: Saturation
+          datasource: {type: prometheus, uid: prom}
+          fieldConfig:
+            defaults:
+              thresholds:
+                steps:
+                  - {color: green, value: null}
+                  - {color: yellow, value: 0.9}
+          targets:
+            - {refId: A, expr: 'saturation{instance="$instance"}'}
+`
+
+	resources, err := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserStdin(strings.NewReader(dashboards))).
+		Parse(grizzly.StdinPath, grizzly.ParserOptions{})
+	require.NoError(t, err)
+
+	groups, warnings := grafana.PanelAlertRules(resources, grafana.PanelAlertOptions{})
+	require.Equal(t, 1, groups.Len())
+	group := groups.First()
+	require.Equal(t, "services.API", group.Name())
+	require.Equal(t, "services", group.GetSpecValue("folderUid"))
+
+	rules := group.GetSpecValue("rules").([]any)
+	require.Len(t, rules, 2, "panels with default thresholds are left out")
+
+	errorRate := rules[0].(map[string]any)
+	require.Equal(t, "API: Error rate", errorRate["title"])
+	require.Equal(t, "C", errorRate["condition"])
+	require.Equal(t, "5m", errorRate["for"])
+	data := errorRate["data"].([]any)
+	require.Equal(t, "prom", data[0].(map[string]any)["datasourceUid"])
+	require.Equal(t, "sum(rate(errors_total[$__rate_interval]))", data[0].(map[string]any)["model"].(map[string]any)["expr"])
+	threshold := data[2].(map[string]any)["model"].(map[string]any)["conditions"].([]any)[0].(map[string]any)["evaluator"]
+	require.Equal(t, map[string]any{"type": "gt", "params": []any{0.05}}, threshold)
+	require.Equal(t, map[string]any{"__dashboardUid__": "api", "__panelId__": "1", "summary": "Error rate is above 0.05"}, errorRate["annotations"])
+
+	freeDisk := rules[1].(map[string]any)
+	threshold = freeDisk["data"].([]any)[2].(map[string]any)["model"].(map[string]any)["conditions"].([]any)[0].(map[string]any)["evaluator"]
+	require.Equal(t, map[string]any{"type": "lt", "params": []any{float64(10)}}, threshold)
+
+	require.Len(t, warnings, 1)
+	require.Equal(t, "Dashboard.api", warnings[0].ResourceRef)
+	require.ErrorContains(t, warnings[0].Err, "panel 4 (Saturation) not converted to an alert rule: query A uses dashboard variables")
+
+	t.Run("rules can't be in the General folder", func(t *testing.T) {
+		groups, warnings := grafana.PanelAlertRules(resources, grafana.PanelAlertOptions{Folder: "general"})
+		require.Equal(t, 0, groups.Len())
+		require.ErrorContains(t, warnings[len(warnings)-1].Err, "alert rules can't be in the General folder")
+	})
+}
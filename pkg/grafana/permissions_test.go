@@ -0,0 +1,62 @@
+package grafana_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionProblems(t *testing.T) {
+	// the token is valid, but only allows reading dashboards
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Header.Get("Authorization") != "Bearer token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/dashboards/uid/nodes":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+		_, _ = w.Write([]byte(`{"message": "denied"}`))
+	}))
+	defer server.Close()
+
+	newRegistry := func(token string) grizzly.Registry {
+		registry := grizzly.NewRegistry([]grizzly.Provider{grafana.NewProvider(&config.GrafanaConfig{URL: server.URL, Token: token})})
+		return registry.WithContext(grizzly.WithPermissionProblems(context.Background()))
+	}
+	dashboard := grizzlytest.NewDashboard(t, "nodes", "Nodes")
+	datasource := grizzlytest.NewResource(t, "Datasource", "prometheus", map[string]any{"uid": "prometheus", "name": "prometheus", "type": "prometheus"})
+
+	t.Run("denied requests are summed up by kind, with the permissions they require", func(t *testing.T) {
+		registry := newRegistry("token")
+		recorder := grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText)
+		require.Error(t, grizzly.Apply(registry, grizzly.NewResources(dashboard), true, recorder))
+		require.Error(t, grizzly.Diff(registry, grizzly.NewResources(datasource), false, "", recorder))
+
+		problems := grizzly.PermissionProblems(registry.Context())
+		require.Len(t, problems, 2)
+		require.Equal(t, "Dashboard (write): 1 forbidden; requires role Editor, or RBAC actions dashboards:read, dashboards:create, dashboards:write, folders:read", problems[0].String())
+		require.Equal(t, "Datasource (read): 1 forbidden; requires role Admin, or RBAC actions datasources:read", problems[1].String())
+	})
+
+	t.Run("rejected credentials are told apart", func(t *testing.T) {
+		registry := newRegistry("expired")
+		recorder := grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText)
+		require.Error(t, grizzly.Diff(registry, grizzly.NewResources(datasource), false, "", recorder))
+
+		problems := grizzly.PermissionProblems(registry.Context())
+		require.Len(t, problems, 1)
+		require.Equal(t, 1, problems[0].Unauthorized)
+		require.Equal(t, "Datasource (read): 1 unauthorized; check the credentials of the context", problems[0].String())
+	})
+}
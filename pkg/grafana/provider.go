@@ -1,24 +1,56 @@
 package grafana
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/go-openapi/runtime"
+	httptransport "github.com/go-openapi/runtime/client"
 	gclient "github.com/grafana/grafana-openapi-client-go/client"
 	"github.com/grafana/grafana-openapi-client-go/client/dashboards"
+	"github.com/grafana/grafana-openapi-client-go/pkg/transport"
 	"github.com/grafana/grizzly/pkg/config"
 	"github.com/grafana/grizzly/pkg/grizzly"
 )
 
-// Provider is a grizzly.Provider implementation for Grafana.
+// Provider is a grizzly.Provider implementation for Grafana. It is safe for
+// concurrent use: the client and the capabilities of the instance are
+// created once, and shared by all handlers. Copies bound to the context of a
+// call (see WithContext) create their own client, but share the connections
+// and the capabilities of the provider they are copied from.
 type Provider struct {
 	config *config.GrafanaConfig
-	client *gclient.GrafanaHTTPAPI
+
+	// root is the provider this one was copied from, with WithContext
+	root *Provider
+	ctx  context.Context
+
+	clientLock sync.Mutex
+	client     *gclient.GrafanaHTTPAPI
+
+	capabilitiesLock sync.Mutex
+	capabilities     *Capabilities
+
+	tlsOnce   sync.Once
+	tlsConfig *tls.Config
+	tlsErr    error
+
+	transportOnce sync.Once
+	transport     *http.Transport
+	transportErr  error
 }
 
 type ClientProvider interface {
@@ -33,6 +65,40 @@ func NewProvider(config *config.GrafanaConfig) *Provider {
 	}
 }
 
+// WithContext returns a copy of the provider sending its requests with the
+// given context
+func (p *Provider) WithContext(ctx context.Context) grizzly.Provider {
+	return &Provider{
+		config: p.config,
+		root:   p.shared(),
+		ctx:    ctx,
+	}
+}
+
+// shared returns the provider holding the state shared by its copies
+func (p *Provider) shared() *Provider {
+	if p.root != nil {
+		return p.root
+	}
+	return p
+}
+
+// context returns the context the provider is bound to
+func (p *Provider) context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
+// contextOf returns the context a handler's provider is bound to
+func contextOf(provider grizzly.Provider) context.Context {
+	if p, ok := provider.(*Provider); ok {
+		return p.context()
+	}
+	return context.Background()
+}
+
 func (p *Provider) Validate() error {
 	if p.config.URL == "" {
 		return fmt.Errorf("grafana URL is not set")
@@ -81,9 +147,22 @@ func (p *Provider) Version() string {
 }
 
 func (p *Provider) Client() (*gclient.GrafanaHTTPAPI, error) {
+	p.clientLock.Lock()
+	defer p.clientLock.Unlock()
+
 	if p.client != nil {
 		return p.client, nil
 	}
+	// copies bind the client of their root to their context, as creating
+	// clients alters the default transport of the process
+	if p.root != nil {
+		client, err := p.root.Client()
+		if err != nil {
+			return nil, err
+		}
+		p.client = p.bind(client)
+		return p.client, nil
+	}
 
 	parsedURL, err := url.Parse(p.config.URL)
 	if err != nil {
@@ -95,11 +174,9 @@ func (p *Provider) Client() (*gclient.GrafanaHTTPAPI, error) {
 		WithSchemes([]string{parsedURL.Scheme}).
 		WithBasePath(filepath.Join(parsedURL.Path, "api"))
 
-	if parsedURL.Scheme == "https" && p.config.InsecureSkipVerify {
-		transportConfig.TLSConfig = &tls.Config{
-			InsecureSkipVerify: true,
-			ServerName:         p.config.TLSHost,
-		}
+	baseTransport, err := p.baseTransport()
+	if err != nil {
+		return nil, err
 	}
 
 	if p.config.Token != "" {
@@ -109,11 +186,96 @@ func (p *Provider) Client() (*gclient.GrafanaHTTPAPI, error) {
 			transportConfig.APIKey = p.config.Token
 		}
 	}
+	transportConfig.OrgID = p.config.OrgID
 	grafanaClient := gclient.NewHTTPClientWithConfig(nil, transportConfig)
+	if runtime, ok := grafanaClient.Transport.(*httptransport.Runtime); ok {
+		if retryable, ok := runtime.Transport.(*transport.RetryableTransport); ok {
+			retryable.Transport = baseTransport
+		}
+		runtime.Transport = grizzly.DecorateHTTPTransport(grizzly.WithHTTPHeaders(runtime.Transport, p.config.Headers))
+	}
 	p.client = grafanaClient
 	return grafanaClient, nil
 }
 
+// bind returns a copy of a client sending its requests with the context of
+// the provider
+func (p *Provider) bind(client *gclient.GrafanaHTTPAPI) *gclient.GrafanaHTTPAPI {
+	bound := client.Clone()
+	bound.SetTransport(contextTransport{ClientTransport: client.Transport, ctx: p.context()})
+	return bound
+}
+
+// contextTransport submits the operations of the API client with a context,
+// unless they have their own
+type contextTransport struct {
+	runtime.ClientTransport
+	ctx context.Context
+}
+
+func (transport contextTransport) Submit(operation *runtime.ClientOperation) (any, error) {
+	if operation.Context == nil {
+		operation.Context = transport.ctx
+	}
+	return transport.ClientTransport.Submit(operation)
+}
+
+// tls returns the TLS configuration of the connections to Grafana, created
+// once so that insecure settings are only reported once
+func (p *Provider) tls() (*tls.Config, error) {
+	p = p.shared()
+	p.tlsOnce.Do(func() {
+		if !strings.HasPrefix(p.config.URL, "https://") {
+			return
+		}
+		p.tlsConfig, p.tlsErr = grizzly.NewTLSConfig("Grafana at "+p.config.URL, grizzly.TLSOptions{
+			CAPath:             p.config.CAPath,
+			InsecureSkipVerify: p.config.InsecureSkipVerify,
+			ServerName:         p.config.TLSHost,
+		})
+	})
+	return p.tlsConfig, p.tlsErr
+}
+
+// baseTransport returns the transport of every connection to Grafana,
+// verifying certificates with the TLS options of the context. It is created
+// once, so that connections are reused. The API client is given this
+// transport, as it would otherwise set its TLS configuration on
+// http.DefaultTransport, shared by the whole process.
+func (p *Provider) baseTransport() (*http.Transport, error) {
+	p = p.shared()
+	p.transportOnce.Do(func() {
+		tlsConfig, err := p.tls()
+		if err != nil {
+			p.transportErr = err
+			return
+		}
+		p.transport = http.DefaultTransport.(*http.Transport).Clone()
+		p.transport.TLSClientConfig = tlsConfig
+	})
+	return p.transport, p.transportErr
+}
+
+// httpTransport returns the transport of the requests to Grafana that the
+// API client doesn't send: raw requests, and those proxied by grr serve
+func (p *Provider) httpTransport() (http.RoundTripper, error) {
+	base, err := p.baseTransport()
+	if err != nil {
+		return nil, err
+	}
+	return grizzly.DecorateHTTPTransport(grizzly.WithHTTPHeaders(base, p.config.Headers)), nil
+}
+
+// rawClient returns the HTTP client of the requests the API client can't
+// express
+func (p *Provider) rawClient() (*http.Client, error) {
+	transport, err := p.httpTransport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
 func (p *Provider) Config() *config.GrafanaConfig {
 	return p.config
 }
@@ -128,11 +290,21 @@ func (p *Provider) GetHandlers() []grizzly.Handler {
 	return []grizzly.Handler{
 		NewDatasourceHandler(p),
 		NewFolderHandler(p),
+		NewTeamHandler(p),
+		NewServiceAccountHandler(p),
+		NewServiceAccountTokenHandler(p),
 		NewLibraryElementHandler(p),
 		NewDashboardHandler(p),
+		NewAnnotationHandler(p),
+		NewReportHandler(p),
+		NewSLOHandler(p),
+		NewMLJobHandler(p),
+		NewMLOutlierDetectorHandler(p),
+		// contact points go first, as rules and policies refer to them
+		NewAlertContactPointHandler(p),
 		NewAlertRuleGroupHandler(p),
 		NewAlertNotificationPolicyHandler(p),
-		NewAlertContactPointHandler(p),
+		NewAlertmanagerConfigHandler(p),
 	}
 }
 
@@ -153,7 +325,12 @@ func (p *Provider) SetupProxy() (*httputil.ReverseProxy, error) {
 	if err != nil {
 		return nil, err
 	}
+	transport, err := p.httpTransport()
+	if err != nil {
+		return nil, err
+	}
 	proxy := &httputil.ReverseProxy{
+		Transport: transport,
 		Rewrite: func(r *httputil.ProxyRequest) {
 			r.SetURL(u)
 
@@ -165,9 +342,64 @@ func (p *Provider) SetupProxy() (*httputil.ReverseProxy, error) {
 				r.Out.Header.Set("Authorization", "Bearer "+p.config.Token)
 			}
 
+			if p.config.OrgID != 0 {
+				r.Out.Header.Set(gclient.OrgIDHeader, strconv.FormatInt(p.config.OrgID, 10))
+			}
+
 			r.Out.Header.Del("Origin")
 			r.Out.Header.Set("User-Agent", "Grizzly Proxy Server")
 		},
 	}
 	return proxy, nil
 }
+
+// get sends an authenticated request to an endpoint of Grafana that isn't
+// covered by the API client. Responses with an unexpected status are errors.
+func (p *Provider) get(path string) (*http.Response, error) {
+	return p.send(http.MethodGet, path, nil)
+}
+
+// send sends an authenticated request to Grafana, with a JSON body unless
+// nil, for the requests the API client can't express. Responses with an
+// unexpected status are errors.
+func (p *Provider) send(method string, path string, body any) (*http.Response, error) {
+	var content io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		content = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(p.context(), method, strings.TrimSuffix(p.config.URL, "/")+path, content)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p.config.User != "" {
+		req.SetBasicAuth(p.config.User, p.config.Token)
+	} else if p.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.Token)
+	}
+	if p.config.OrgID != 0 {
+		req.Header.Set(gclient.OrgIDHeader, strconv.FormatInt(p.config.OrgID, 10))
+	}
+
+	client, err := p.rawClient()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: unexpected status %w: %s", method, path, grizzly.HTTPStatusError{StatusCode: resp.StatusCode}, strings.TrimSpace(string(body)))
+	}
+
+	return resp, nil
+}
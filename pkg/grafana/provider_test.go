@@ -0,0 +1,68 @@
+package grafana_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderSubpathAndHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Gateway-Key") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/grafana/api/folders/infra" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"uid": "infra", "title": "Infrastructure"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	provider := grafana.NewProvider(&config.GrafanaConfig{
+		URL:     server.URL + "/grafana/",
+		Headers: map[string]string{"X-Gateway-Key": "secret"},
+	})
+	client, err := provider.Client()
+	require.NoError(t, err)
+
+	folder, err := client.Folders.GetFolderByUID("infra")
+	require.NoError(t, err)
+	require.Equal(t, "Infrastructure", folder.GetPayload().Title)
+}
+
+func TestProviderCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"meta": {}, "dashboard": {"title": "Home"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+	provider := grafana.NewProvider(&config.GrafanaConfig{URL: server.URL, CAPath: caPath})
+
+	// the proxy of grr serve trusts the same certificates as the API client
+	proxy, err := provider.SetupProxy()
+	require.NoError(t, err)
+	proxyServer := httptest.NewServer(proxy)
+	t.Cleanup(proxyServer.Close)
+
+	resp, err := http.Get(proxyServer.URL + "/api/dashboards/home")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the CA bundle of the context isn't trusted by the rest of the process
+	_, err = http.Get(server.URL)
+	require.ErrorContains(t, err, "certificate")
+}
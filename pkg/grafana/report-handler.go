@@ -0,0 +1,301 @@
+package grafana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	log "github.com/sirupsen/logrus"
+)
+
+const ReportKind = "Report"
+
+// ErrReportsNotSupported is returned by instances without the reporting of
+// Grafana Enterprise
+var ErrReportsNotSupported = fmt.Errorf("reporting %w: it requires Grafana Enterprise or Grafana Cloud", ErrNotSupported)
+
+// ReportHandler is a Grizzly Handler for the scheduled reports of Grafana
+// Enterprise, identified by their name
+type ReportHandler struct {
+	grizzly.BaseHandler
+}
+
+var _ grizzly.Handler = &ReportHandler{}
+var _ grizzly.DeleteHandler = &ReportHandler{}
+
+// NewReportHandler returns a new Grizzly Handler for Grafana reports
+func NewReportHandler(provider grizzly.Provider) *ReportHandler {
+	return &ReportHandler{
+		BaseHandler: grizzly.NewBaseHandler(provider, ReportKind, false),
+	}
+}
+
+// Permissions returns the permissions required to manage reports
+func (h *ReportHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Role: "Admin", Actions: []string{"reports:read"}},
+		Write: grizzly.Access{Role: "Admin", Actions: []string{"reports:read", "reports:create", "reports:write", "reports:delete"}},
+	}
+}
+
+const (
+	reportPattern = "reports/report-%s.%s"
+)
+
+// ResourceFilePath returns the location on disk where a resource should be updated
+func (h *ReportHandler) ResourceFilePath(resource grizzly.Resource, filetype string) string {
+	return fmt.Sprintf(reportPattern, resource.Name(), filetype)
+}
+
+// Unprepare removes unnecessary elements from a remote resource ready for presentation/comparison
+func (h *ReportHandler) Unprepare(resource grizzly.Resource) *grizzly.Resource {
+	resource.DeleteSpecKey("id")
+	return &resource
+}
+
+// Prepare gets a resource ready for dispatch to the remote endpoint
+func (h *ReportHandler) Prepare(existing *grizzly.Resource, resource grizzly.Resource) *grizzly.Resource {
+	if !resource.HasSpecString("name") {
+		resource.SetSpecString("name", resource.Name())
+	}
+	return &resource
+}
+
+// Validate checks that the name of the report matches the name of the
+// resource, and that the report can be sent
+func (h *ReportHandler) Validate(resource grizzly.Resource) error {
+	name, exist := resource.GetSpecString("name")
+	if exist && name != resource.Name() {
+		return fmt.Errorf("name '%s' and resource name '%s', don't match", name, resource.Name())
+	}
+	_, err := reportConfig(resource)
+	return err
+}
+
+func (h *ReportHandler) GetSpecUID(resource grizzly.Resource) (string, error) {
+	name, ok := resource.GetSpecString("name")
+	if !ok {
+		return "", fmt.Errorf("name not specified")
+	}
+	return name, nil
+}
+
+// GetByUID retrieves JSON for a resource from an endpoint, by name
+func (h *ReportHandler) GetByUID(name string) (*grizzly.Resource, error) {
+	return h.getRemoteReport(name)
+}
+
+// GetRemote retrieves a report as a Resource
+func (h *ReportHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	return h.getRemoteReport(resource.Name())
+}
+
+// ListRemote retrieves as list of names of all remote reports. Instances
+// without reporting have none, so that pulling doesn't fail on them.
+func (h *ReportHandler) ListRemote() ([]string, error) {
+	reports, err := h.listReports()
+	if errors.Is(err, ErrReportsNotSupported) {
+		log.Debugf("Not listing reports: %s", err)
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(reports))
+	for _, report := range reports {
+		names = append(names, report.Name)
+	}
+	return names, nil
+}
+
+// Add creates a report
+func (h *ReportHandler) Add(resource grizzly.Resource) error {
+	config, err := reportConfig(resource)
+	if err != nil {
+		return err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Reports.CreateReport(config)
+	return err
+}
+
+// Update replaces the configuration of a report
+func (h *ReportHandler) Update(existing, resource grizzly.Resource) error {
+	report, err := h.getReport(resource.Name())
+	if err != nil {
+		return err
+	}
+	config, err := reportConfig(resource)
+	if err != nil {
+		return err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Reports.UpdateReport(report.ID, config)
+	return err
+}
+
+// Delete deletes a report
+func (h *ReportHandler) Delete(resource grizzly.Resource) error {
+	report, err := h.getReport(resource.Name())
+	if err != nil {
+		return err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Reports.DeleteReport(report.ID)
+	return err
+}
+
+// listReports lists the reports of the organization. Instances without
+// reporting don't serve its API.
+func (h *ReportHandler) listReports() ([]*models.Report, error) {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+
+	reportsOk, err := client.Reports.GetReports()
+	if err != nil {
+		// OpenAPI definition does not define 404 for GetReports, so falls though to runtime.APIError.
+		var gErr *runtime.APIError
+		if errors.As(err, &gErr) && gErr.IsCode(http.StatusNotFound) {
+			return nil, ErrReportsNotSupported
+		}
+		return nil, err
+	}
+	return reportsOk.GetPayload(), nil
+}
+
+// getReport returns a report by name
+func (h *ReportHandler) getReport(name string) (*models.Report, error) {
+	reports, err := h.listReports()
+	if err != nil {
+		return nil, err
+	}
+	for _, report := range reports {
+		if report.Name == name {
+			return report, nil
+		}
+	}
+
+	return nil, grizzly.ErrNotFound
+}
+
+// getRemoteReport retrieves a report as a resource, with its recipients as a
+// list and its dashboards by UID
+func (h *ReportHandler) getRemoteReport(name string) (*grizzly.Resource, error) {
+	report, err := h.getReport(name)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := structToMap(report)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"uid", "orgId", "userId", "created", "updated"} {
+		delete(spec, key)
+	}
+
+	recipients := []any{}
+	for _, recipient := range strings.Split(report.Recipients, ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+	spec["recipients"] = recipients
+
+	dashboards := []any{}
+	for _, dashboard := range report.Dashboards {
+		item := map[string]any{}
+		if dashboard.Dashboard != nil {
+			item["uid"] = dashboard.Dashboard.UID
+		}
+		if dashboard.TimeRange != nil && (dashboard.TimeRange.From != "" || dashboard.TimeRange.To != "") {
+			item["timeRange"] = map[string]any{"from": dashboard.TimeRange.From, "to": dashboard.TimeRange.To}
+		}
+		if dashboard.ReportVariables != nil {
+			item["reportVariables"] = dashboard.ReportVariables
+		}
+		dashboards = append(dashboards, item)
+	}
+	spec["dashboards"] = dashboards
+
+	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), report.Name, spec)
+	if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// reportConfig returns the configuration of a report resource, whose
+// recipients are a list and dashboards are given by UID
+func reportConfig(resource grizzly.Resource) (*models.CreateOrUpdateReportConfig, error) {
+	spec := make(map[string]any, len(resource.Spec()))
+	for key, value := range resource.Spec() {
+		spec[key] = value
+	}
+	spec["name"] = resource.Name()
+
+	if value, ok := spec["recipients"]; ok && value != nil {
+		list, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("recipients must be a list of emails")
+		}
+		recipients := make([]string, 0, len(list))
+		for _, item := range list {
+			recipient, ok := item.(string)
+			if !ok || recipient == "" {
+				return nil, fmt.Errorf("recipients must be a list of emails, got %v", item)
+			}
+			recipients = append(recipients, recipient)
+		}
+		spec["recipients"] = strings.Join(recipients, ",")
+	}
+
+	list, _ := spec["dashboards"].([]any)
+	if len(list) == 0 {
+		return nil, fmt.Errorf("reports need at least one dashboard")
+	}
+	dashboards := make([]any, 0, len(list))
+	for _, item := range list {
+		dashboard, _ := item.(map[string]any)
+		uid, _ := dashboard["uid"].(string)
+		if uid == "" {
+			return nil, fmt.Errorf("dashboards of reports must be given by uid")
+		}
+		dashboards = append(dashboards, map[string]any{
+			"dashboard":       map[string]any{"uid": uid},
+			"timeRange":       dashboard["timeRange"],
+			"reportVariables": dashboard["reportVariables"],
+		})
+	}
+	spec["dashboards"] = dashboards
+
+	content, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	var config models.CreateOrUpdateReportConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("invalid report: %w", err)
+	}
+	return &config, nil
+}
@@ -0,0 +1,95 @@
+package grafana_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReports(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+	handler, err := registry.GetHandler("Report")
+	require.NoError(t, err)
+
+	report := func(t *testing.T, frequency string, recipients ...any) grizzly.Resource {
+		t.Helper()
+		return grizzlytest.NewResource(t, "Report", "weekly-slo", map[string]any{
+			"name":       "weekly-slo",
+			"recipients": recipients,
+			"formats":    []any{"pdf"},
+			"schedule":   map[string]any{"frequency": frequency, "timeZone": "Europe/Paris"},
+			"dashboards": []any{
+				map[string]any{"uid": "slo", "timeRange": map[string]any{"from": "now-7d", "to": "now"}},
+			},
+		})
+	}
+	apply := func(t *testing.T, resource grizzly.Resource) error {
+		t.Helper()
+		return grizzly.Apply(registry, grizzly.NewResources(resource), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText))
+	}
+	diff := func(t *testing.T, resource grizzly.Resource) string {
+		t.Helper()
+		output := &bytes.Buffer{}
+		require.NoError(t, grizzly.Diff(registry, grizzly.NewResources(resource), false, "", grizzly.NewWriterRecorder(output, grizzly.EventToPlainText)))
+		return output.String()
+	}
+
+	t.Run("reports are created with their recipients and dashboards", func(t *testing.T) {
+		require.NoError(t, apply(t, report(t, "weekly", "sre@example.com", "cto@example.com")))
+
+		remote, found := server.Report("weekly-slo")
+		require.True(t, found)
+		require.Equal(t, "sre@example.com,cto@example.com", remote["recipients"])
+		dashboard := remote["dashboards"].([]any)[0].(map[string]any)
+		require.Equal(t, "slo", dashboard["dashboard"].(map[string]any)["uid"])
+
+		names, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.Equal(t, []string{"weekly-slo"}, names)
+
+		require.Contains(t, diff(t, report(t, "weekly", "sre@example.com", "cto@example.com")), "Report.weekly-slo unchanged")
+	})
+
+	t.Run("reports are updated", func(t *testing.T) {
+		require.Contains(t, diff(t, report(t, "monthly", "sre@example.com")), "+        frequency: monthly")
+		require.NoError(t, apply(t, report(t, "monthly", "sre@example.com")))
+
+		remote, found := server.Report("weekly-slo")
+		require.True(t, found)
+		require.Equal(t, "sre@example.com", remote["recipients"])
+		require.Equal(t, "monthly", remote["schedule"].(map[string]any)["frequency"])
+	})
+
+	t.Run("reports need dashboards given by uid", func(t *testing.T) {
+		resource := grizzlytest.NewResource(t, "Report", "empty", map[string]any{
+			"dashboards": []any{map[string]any{"title": "SLO"}},
+		})
+		require.ErrorContains(t, handler.Validate(resource), "dashboards of reports must be given by uid")
+	})
+
+	t.Run("reports are deleted", func(t *testing.T) {
+		require.NoError(t, handler.(grizzly.DeleteHandler).Delete(report(t, "monthly")))
+
+		_, found := server.Report("weekly-slo")
+		require.False(t, found)
+		_, err := handler.GetByUID("weekly-slo")
+		require.ErrorIs(t, err, grizzly.ErrNotFound)
+	})
+
+	t.Run("reporting requires Grafana Enterprise", func(t *testing.T) {
+		server.SetReporting(false)
+		defer server.SetReporting(true)
+
+		names, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.Empty(t, names, "pulling doesn't fail on open source Grafana")
+
+		_, err = handler.GetByUID("weekly-slo")
+		require.ErrorIs(t, err, grafana.ErrReportsNotSupported)
+	})
+}
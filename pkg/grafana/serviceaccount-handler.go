@@ -0,0 +1,450 @@
+package grafana
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana-openapi-client-go/client/service_accounts"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzly/notifier"
+)
+
+const (
+	ServiceAccountKind      = "ServiceAccount"
+	ServiceAccountTokenKind = "ServiceAccountToken"
+)
+
+// serviceAccountsPermissions grant managing service accounts and their tokens
+var serviceAccountsPermissions = grizzly.Permissions{
+	Read:  grizzly.Access{Role: "Admin", Actions: []string{"serviceaccounts:read"}},
+	Write: grizzly.Access{Role: "Admin", Actions: []string{"serviceaccounts:read", "serviceaccounts:create", "serviceaccounts:write", "serviceaccounts:delete"}},
+}
+
+// ServiceAccountHandler is a Grizzly Handler for Grafana service accounts,
+// identified by their name
+type ServiceAccountHandler struct {
+	grizzly.BaseHandler
+}
+
+var _ grizzly.Handler = &ServiceAccountHandler{}
+var _ grizzly.DeleteHandler = &ServiceAccountHandler{}
+
+// NewServiceAccountHandler returns a new Grizzly Handler for Grafana service
+// accounts
+func NewServiceAccountHandler(provider grizzly.Provider) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		BaseHandler: grizzly.NewBaseHandler(provider, ServiceAccountKind, false),
+	}
+}
+
+// Permissions returns the permissions required to manage service accounts
+func (h *ServiceAccountHandler) Permissions() grizzly.Permissions {
+	return serviceAccountsPermissions
+}
+
+const (
+	serviceAccountPattern = "service-accounts/service-account-%s.%s"
+)
+
+// ResourceFilePath returns the location on disk where a resource should be updated
+func (h *ServiceAccountHandler) ResourceFilePath(resource grizzly.Resource, filetype string) string {
+	return fmt.Sprintf(serviceAccountPattern, resource.Name(), filetype)
+}
+
+// Unprepare removes unnecessary elements from a remote resource ready for presentation/comparison
+func (h *ServiceAccountHandler) Unprepare(resource grizzly.Resource) *grizzly.Resource {
+	resource.DeleteSpecKey("id")
+	if disabled, _ := resource.GetSpecValue("isDisabled").(bool); !disabled {
+		resource.DeleteSpecKey("isDisabled")
+	}
+	return &resource
+}
+
+// Prepare gets a resource ready for dispatch to the remote endpoint
+func (h *ServiceAccountHandler) Prepare(existing *grizzly.Resource, resource grizzly.Resource) *grizzly.Resource {
+	if !resource.HasSpecString("name") {
+		resource.SetSpecString("name", resource.Name())
+	}
+	return &resource
+}
+
+// Validate checks that the name of the service account matches the name of
+// the resource
+func (h *ServiceAccountHandler) Validate(resource grizzly.Resource) error {
+	name, exist := resource.GetSpecString("name")
+	if exist && name != resource.Name() {
+		return fmt.Errorf("name '%s' and resource name '%s', don't match", name, resource.Name())
+	}
+	return nil
+}
+
+func (h *ServiceAccountHandler) GetSpecUID(resource grizzly.Resource) (string, error) {
+	name, ok := resource.GetSpecString("name")
+	if !ok {
+		return "", fmt.Errorf("name not specified")
+	}
+	return name, nil
+}
+
+// GetByUID retrieves JSON for a resource from an endpoint, by name
+func (h *ServiceAccountHandler) GetByUID(name string) (*grizzly.Resource, error) {
+	return h.getRemoteServiceAccount(name)
+}
+
+// GetRemote retrieves a service account as a Resource
+func (h *ServiceAccountHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	return h.getRemoteServiceAccount(resource.Name())
+}
+
+// ListRemote retrieves as list of names of all remote service accounts
+func (h *ServiceAccountHandler) ListRemote() ([]string, error) {
+	accounts, err := searchServiceAccounts(h.Provider, "")
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(accounts))
+	for i, account := range accounts {
+		names[i] = account.Name
+	}
+	return names, nil
+}
+
+// Add creates a service account
+func (h *ServiceAccountHandler) Add(resource grizzly.Resource) error {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	role, _ := resource.GetSpecString("role")
+	disabled, _ := resource.GetSpecValue("isDisabled").(bool)
+	params := service_accounts.NewCreateServiceAccountParams().WithBody(&models.CreateServiceAccountForm{
+		Name:       resource.Name(),
+		Role:       role,
+		IsDisabled: disabled,
+	})
+	_, err = client.ServiceAccounts.CreateServiceAccount(params)
+	return err
+}
+
+// Update changes the role of a service account, or disables it. The API
+// client omits `isDisabled` when false, which Grafana reads as unchanged:
+// the service account is updated directly so that it can be enabled again.
+func (h *ServiceAccountHandler) Update(existing, resource grizzly.Resource) error {
+	account, err := getServiceAccount(h.Provider, resource.Name())
+	if err != nil {
+		return err
+	}
+
+	role, _ := resource.GetSpecString("role")
+	disabled, _ := resource.GetSpecValue("isDisabled").(bool)
+	update := map[string]any{"name": account.Name, "isDisabled": disabled}
+	if role != "" {
+		update["role"] = role
+	}
+	resp, err := h.Provider.(*Provider).send(http.MethodPatch, fmt.Sprintf("/api/serviceaccounts/%d", account.ID), update)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Delete deletes a service account, along with its tokens
+func (h *ServiceAccountHandler) Delete(resource grizzly.Resource) error {
+	account, err := getServiceAccount(h.Provider, resource.Name())
+	if err != nil {
+		return err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.ServiceAccounts.DeleteServiceAccount(account.ID)
+	return err
+}
+
+func (h *ServiceAccountHandler) getRemoteServiceAccount(name string) (*grizzly.Resource, error) {
+	account, err := getServiceAccount(h.Provider, name)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := map[string]any{
+		"id":         account.ID,
+		"name":       account.Name,
+		"role":       account.Role,
+		"isDisabled": account.IsDisabled,
+	}
+	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), account.Name, spec)
+	if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// ServiceAccountTokenHandler is a Grizzly Handler for the tokens of Grafana
+// service accounts, named `<service account>.<token>`. As token values can
+// only be read when tokens are created, they are announced then, and tokens
+// are left as is once created: they are rotated by renaming them.
+type ServiceAccountTokenHandler struct {
+	grizzly.BaseHandler
+}
+
+var _ grizzly.Handler = &ServiceAccountTokenHandler{}
+var _ grizzly.DeleteHandler = &ServiceAccountTokenHandler{}
+var _ grizzly.ApplyStrategyHandler = &ServiceAccountTokenHandler{}
+
+// NewServiceAccountTokenHandler returns a new Grizzly Handler for the tokens
+// of Grafana service accounts
+func NewServiceAccountTokenHandler(provider grizzly.Provider) *ServiceAccountTokenHandler {
+	return &ServiceAccountTokenHandler{
+		BaseHandler: grizzly.NewBaseHandler(provider, ServiceAccountTokenKind, false),
+	}
+}
+
+// Permissions returns the permissions required to manage tokens, the ones of
+// their service accounts
+func (h *ServiceAccountTokenHandler) Permissions() grizzly.Permissions {
+	return serviceAccountsPermissions
+}
+
+// ApplyStrategy leaves existing tokens as is, as they can't be updated
+func (h *ServiceAccountTokenHandler) ApplyStrategy() grizzly.ApplyStrategy {
+	return grizzly.ApplyCreateOnly
+}
+
+const (
+	serviceAccountTokenPattern = "service-accounts/token-%s.%s"
+)
+
+// ResourceFilePath returns the location on disk where a resource should be updated
+func (h *ServiceAccountTokenHandler) ResourceFilePath(resource grizzly.Resource, filetype string) string {
+	return fmt.Sprintf(serviceAccountTokenPattern, resource.Name(), filetype)
+}
+
+// Unprepare removes unnecessary elements from a remote resource ready for
+// presentation/comparison. The lifetime of tokens isn't compared, as Grafana
+// only returns their expiration date.
+func (h *ServiceAccountTokenHandler) Unprepare(resource grizzly.Resource) *grizzly.Resource {
+	resource.DeleteSpecKey("id")
+	resource.DeleteSpecKey("secondsToLive")
+	return &resource
+}
+
+// Prepare gets a resource ready for dispatch to the remote endpoint
+func (h *ServiceAccountTokenHandler) Prepare(existing *grizzly.Resource, resource grizzly.Resource) *grizzly.Resource {
+	return &resource
+}
+
+// Validate checks that the name of the resource is made of the names of the
+// service account and of the token
+func (h *ServiceAccountTokenHandler) Validate(resource grizzly.Resource) error {
+	account, _ := resource.GetSpecString("serviceAccount")
+	name, _ := resource.GetSpecString("name")
+	if account == "" || name == "" {
+		return fmt.Errorf("serviceAccount and name must be set")
+	}
+	if uid := account + "." + name; uid != resource.Name() {
+		return fmt.Errorf("serviceAccount/name combination '%s' and name '%s', don't match", uid, resource.Name())
+	}
+	return nil
+}
+
+func (h *ServiceAccountTokenHandler) GetSpecUID(resource grizzly.Resource) (string, error) {
+	account, _ := resource.GetSpecString("serviceAccount")
+	name, _ := resource.GetSpecString("name")
+	if account == "" || name == "" {
+		return "", fmt.Errorf("serviceAccount and name not specified")
+	}
+	return account + "." + name, nil
+}
+
+// GetByUID retrieves JSON for a resource from an endpoint, by
+// `<service account>.<token>`
+func (h *ServiceAccountTokenHandler) GetByUID(uid string) (*grizzly.Resource, error) {
+	account, name, ok := strings.Cut(uid, ".")
+	if !ok {
+		return nil, fmt.Errorf("invalid token name %s: expected <service account>.<token>", uid)
+	}
+
+	_, token, err := h.getToken(account, name)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := map[string]any{
+		"id":             token.ID,
+		"serviceAccount": account,
+		"name":           token.Name,
+	}
+	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), uid, spec)
+	if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// GetRemote retrieves a token as a Resource
+func (h *ServiceAccountTokenHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	return h.GetByUID(resource.Name())
+}
+
+// ListRemote retrieves as list of the tokens of all remote service accounts
+func (h *ServiceAccountTokenHandler) ListRemote() ([]string, error) {
+	accounts, err := searchServiceAccounts(h.Provider, "")
+	if err != nil {
+		return nil, err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []string
+	for _, account := range accounts {
+		if account.Tokens == 0 {
+			continue
+		}
+		tokensOk, err := client.ServiceAccounts.ListTokens(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range tokensOk.GetPayload() {
+			uids = append(uids, account.Name+"."+token.Name)
+		}
+	}
+	return uids, nil
+}
+
+// Add creates a token, and announces its value
+func (h *ServiceAccountTokenHandler) Add(resource grizzly.Resource) error {
+	accountName, _ := resource.GetSpecString("serviceAccount")
+	account, err := getServiceAccount(h.Provider, accountName)
+	if errors.Is(err, grizzly.ErrNotFound) {
+		return fmt.Errorf("service account %s not found", accountName)
+	}
+	if err != nil {
+		return err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	name, _ := resource.GetSpecString("name")
+	command := &models.AddServiceAccountTokenCommand{Name: name}
+	switch seconds := resource.GetSpecValue("secondsToLive").(type) {
+	case float64:
+		command.SecondsToLive = int64(seconds)
+	case int:
+		command.SecondsToLive = int64(seconds)
+	}
+	params := service_accounts.NewCreateTokenParams().WithServiceAccountID(account.ID).WithBody(command)
+	createOk, err := client.ServiceAccounts.CreateToken(params)
+	if err != nil {
+		return err
+	}
+
+	notifier.Secret(resource.Ref(), "token", createOk.GetPayload().Key)
+	return nil
+}
+
+// Update fails, as tokens can't be updated: they have to be replaced
+func (h *ServiceAccountTokenHandler) Update(existing, resource grizzly.Resource) error {
+	return fmt.Errorf("tokens can't be updated: rename the token to replace it")
+}
+
+// Delete revokes a token
+func (h *ServiceAccountTokenHandler) Delete(resource grizzly.Resource) error {
+	account, name, ok := strings.Cut(resource.Name(), ".")
+	if !ok {
+		return fmt.Errorf("invalid token name %s: expected <service account>.<token>", resource.Name())
+	}
+	accountID, token, err := h.getToken(account, name)
+	if err != nil {
+		return err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.ServiceAccounts.DeleteToken(token.ID, accountID)
+	return err
+}
+
+// getToken returns a token, and the ID of its service account
+func (h *ServiceAccountTokenHandler) getToken(accountName string, name string) (int64, *models.TokenDTO, error) {
+	account, err := getServiceAccount(h.Provider, accountName)
+	if err != nil {
+		return 0, nil, err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	tokensOk, err := client.ServiceAccounts.ListTokens(account.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, token := range tokensOk.GetPayload() {
+		if token.Name == name {
+			return account.ID, token, nil
+		}
+	}
+	return 0, nil, grizzly.ErrNotFound
+}
+
+// getServiceAccount returns a service account by name
+func getServiceAccount(provider grizzly.Provider, name string) (*models.ServiceAccountDTO, error) {
+	accounts, err := searchServiceAccounts(provider, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account.Name == name {
+			return account, nil
+		}
+	}
+	return nil, grizzly.ErrNotFound
+}
+
+// searchServiceAccounts returns the service accounts whose name contains the
+// query, every one when empty
+func searchServiceAccounts(provider grizzly.Provider, query string) ([]*models.ServiceAccountDTO, error) {
+	client, err := provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		perPage  int64 = 1000
+		page     int64 = 0
+		accounts []*models.ServiceAccountDTO
+	)
+
+	params := service_accounts.NewSearchOrgServiceAccountsWithPagingParams().WithPerpage(&perPage)
+	if query != "" {
+		params.SetQuery(&query)
+	}
+	for {
+		page++
+		params.SetPage(&page)
+
+		searchOk, err := client.ServiceAccounts.SearchOrgServiceAccountsWithPaging(params)
+		if err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, searchOk.GetPayload().ServiceAccounts...)
+		if int64(len(searchOk.GetPayload().ServiceAccounts)) < perPage {
+			return accounts, nil
+		}
+	}
+}
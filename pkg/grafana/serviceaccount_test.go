@@ -0,0 +1,75 @@
+package grafana_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccounts(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+
+	account := func(t *testing.T, role string, disabled bool) grizzly.Resource {
+		return grizzlytest.NewResource(t, "ServiceAccount", "ci", map[string]any{"name": "ci", "role": role, "isDisabled": disabled})
+	}
+	token := grizzlytest.NewResource(t, "ServiceAccountToken", "ci.deploy", map[string]any{"serviceAccount": "ci", "name": "deploy", "secondsToLive": 3600})
+	apply := func(t *testing.T, resources ...grizzly.Resource) error {
+		t.Helper()
+		return grizzly.Apply(registry, registry.Sort(grizzly.NewResources(resources...)), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText))
+	}
+	diff := func(t *testing.T, resource grizzly.Resource) string {
+		t.Helper()
+		output := &bytes.Buffer{}
+		require.NoError(t, grizzly.Diff(registry, grizzly.NewResources(resource), false, "", grizzly.NewWriterRecorder(output, grizzly.EventToPlainText)))
+		return output.String()
+	}
+
+	t.Run("service accounts are created along with their tokens", func(t *testing.T) {
+		require.NoError(t, apply(t, token, account(t, "Editor", false)))
+
+		remote, tokens, found := server.ServiceAccount("ci")
+		require.True(t, found)
+		require.Equal(t, "Editor", remote["role"])
+		require.Equal(t, []string{"deploy"}, tokens)
+
+		require.Contains(t, diff(t, account(t, "Editor", false)), "ServiceAccount.ci unchanged")
+		require.Contains(t, diff(t, token), "ServiceAccountToken.ci.deploy unchanged")
+	})
+
+	t.Run("roles are updated, and service accounts disabled and enabled again", func(t *testing.T) {
+		require.NoError(t, apply(t, account(t, "Admin", true)))
+		remote, _, _ := server.ServiceAccount("ci")
+		require.Equal(t, "Admin", remote["role"])
+		require.Equal(t, true, remote["isDisabled"])
+
+		require.NoError(t, apply(t, account(t, "Admin", false)))
+		remote, _, _ = server.ServiceAccount("ci")
+		require.Equal(t, false, remote["isDisabled"])
+	})
+
+	t.Run("existing tokens are left as is", func(t *testing.T) {
+		before := server.Requests()
+		require.NoError(t, apply(t, token))
+		_, tokens, _ := server.ServiceAccount("ci")
+		require.Equal(t, []string{"deploy"}, tokens)
+		require.NotContains(t, server.Requests()[len(before):], "POST /api/serviceaccounts/1/tokens")
+	})
+
+	t.Run("tokens of unknown service accounts are reported", func(t *testing.T) {
+		orphan := grizzlytest.NewResource(t, "ServiceAccountToken", "bot.deploy", map[string]any{"serviceAccount": "bot", "name": "deploy"})
+		require.ErrorContains(t, apply(t, orphan), "service account bot not found")
+	})
+
+	t.Run("service accounts are deleted with their tokens", func(t *testing.T) {
+		handler, err := registry.GetHandler("ServiceAccount")
+		require.NoError(t, err)
+		require.NoError(t, handler.(grizzly.DeleteHandler).Delete(account(t, "Admin", false)))
+
+		_, _, found := server.ServiceAccount("ci")
+		require.False(t, found)
+	})
+}
@@ -0,0 +1,284 @@
+package grafana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	log "github.com/sirupsen/logrus"
+)
+
+const SLOKind = "SLO"
+
+// ErrSLONotSupported is returned by instances without the SLO plugin
+var ErrSLONotSupported = fmt.Errorf("SLOs are %w: they require the Grafana SLO plugin of Grafana Cloud", ErrNotSupported)
+
+const (
+	sloEndpoint = "/api/plugins/grafana-slo-app/resources/v1/slo"
+	// sloUUIDLabel is the label of the alert rules generated for an SLO
+	// identifying it
+	sloUUIDLabel = "grafana_slo_uuid"
+)
+
+// SLOHandler is a Grizzly Handler for the SLOs of Grafana Cloud,
+// identified by their UUID. The alert rules Grafana generates for SLOs are
+// pulled along with them, so that they can be compared between stacks.
+type SLOHandler struct {
+	grizzly.BaseHandler
+}
+
+var _ grizzly.Handler = &SLOHandler{}
+var _ grizzly.DeleteHandler = &SLOHandler{}
+var _ grizzly.ReadOnlyFieldsHandler = &SLOHandler{}
+
+// NewSLOHandler returns a new Grizzly Handler for Grafana SLOs
+func NewSLOHandler(provider grizzly.Provider) *SLOHandler {
+	return &SLOHandler{
+		BaseHandler: grizzly.NewBaseHandler(provider, SLOKind, false),
+	}
+}
+
+// Permissions returns the permissions required to manage SLOs, and to read
+// the alert rules generated for them
+func (h *SLOHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Role: "Editor", Actions: []string{"grafana-slo-app.slo:read", "alert.provisioning:read"}},
+		Write: grizzly.Access{Role: "Editor", Actions: []string{"grafana-slo-app.slo:read", "grafana-slo-app.slo:create", "grafana-slo-app.slo:write", "grafana-slo-app.slo:delete", "alert.provisioning:read"}},
+	}
+}
+
+// ReadOnlyFields returns the alert rules generated for SLOs, which are
+// compared when pulled, but never applied
+func (h *SLOHandler) ReadOnlyFields() []string {
+	return []string{"alertRules"}
+}
+
+const (
+	sloPattern = "slos/slo-%s.%s"
+)
+
+// ResourceFilePath returns the location on disk where a resource should be updated
+func (h *SLOHandler) ResourceFilePath(resource grizzly.Resource, filetype string) string {
+	return fmt.Sprintf(sloPattern, resource.Name(), filetype)
+}
+
+// Unprepare removes unnecessary elements from a remote resource ready for presentation/comparison
+func (h *SLOHandler) Unprepare(resource grizzly.Resource) *grizzly.Resource {
+	resource.DeleteSpecKey("readOnly")
+	return &resource
+}
+
+// Prepare gets a resource ready for dispatch to the remote endpoint
+func (h *SLOHandler) Prepare(existing *grizzly.Resource, resource grizzly.Resource) *grizzly.Resource {
+	if !resource.HasSpecString("uuid") {
+		resource.SetSpecString("uuid", resource.Name())
+	}
+	return &resource
+}
+
+// Validate checks that the UUID of the SLO matches the name of the resource,
+// and that it has a name and objectives
+func (h *SLOHandler) Validate(resource grizzly.Resource) error {
+	uuid, exist := resource.GetSpecString("uuid")
+	if exist && uuid != resource.Name() {
+		return fmt.Errorf("uuid '%s' and name '%s', don't match", uuid, resource.Name())
+	}
+	if name, _ := resource.GetSpecString("name"); name == "" {
+		return fmt.Errorf("SLOs need a name")
+	}
+	if objectives, _ := resource.GetSpecValue("objectives").([]any); len(objectives) == 0 {
+		return fmt.Errorf("SLOs need at least one objective")
+	}
+	return nil
+}
+
+func (h *SLOHandler) GetSpecUID(resource grizzly.Resource) (string, error) {
+	uuid, ok := resource.GetSpecString("uuid")
+	if !ok {
+		return "", fmt.Errorf("uuid not specified")
+	}
+	return uuid, nil
+}
+
+// GetByUID retrieves JSON for a resource from an endpoint, by UUID
+func (h *SLOHandler) GetByUID(uuid string) (*grizzly.Resource, error) {
+	return h.getRemoteSLO(uuid)
+}
+
+// GetRemote retrieves an SLO as a Resource
+func (h *SLOHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	return h.getRemoteSLO(resource.Name())
+}
+
+// ListRemote retrieves as list of UUIDs of all remote SLOs. Instances
+// without the SLO plugin have none, so that pulling doesn't fail on them.
+func (h *SLOHandler) ListRemote() ([]string, error) {
+	slos, err := h.listSLOs()
+	if errors.Is(err, ErrSLONotSupported) {
+		log.Debugf("Not listing SLOs: %s", err)
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	uuids := make([]string, 0, len(slos))
+	for _, slo := range slos {
+		if uuid, _ := slo["uuid"].(string); uuid != "" {
+			uuids = append(uuids, uuid)
+		}
+	}
+	return uuids, nil
+}
+
+// Add creates an SLO, with the UUID of the resource
+func (h *SLOHandler) Add(resource grizzly.Resource) error {
+	resp, err := h.Provider.(*Provider).send(http.MethodPost, sloEndpoint, sloObject(resource))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Update replaces an SLO
+func (h *SLOHandler) Update(existing, resource grizzly.Resource) error {
+	resp, err := h.Provider.(*Provider).send(http.MethodPut, sloEndpoint+"/"+url.PathEscape(resource.Name()), sloObject(resource))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Delete deletes an SLO, along with the alert rules generated for it
+func (h *SLOHandler) Delete(resource grizzly.Resource) error {
+	resp, err := h.Provider.(*Provider).send(http.MethodDelete, sloEndpoint+"/"+url.PathEscape(resource.Name()), nil)
+	var status grizzly.HTTPStatusError
+	if errors.As(err, &status) && status.StatusCode == http.StatusNotFound {
+		return grizzly.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// listSLOs lists the SLOs of the stack. Instances without the SLO plugin
+// don't serve its API.
+func (h *SLOHandler) listSLOs() ([]map[string]any, error) {
+	resp, err := h.Provider.(*Provider).get(sloEndpoint)
+	var status grizzly.HTTPStatusError
+	if errors.As(err, &status) && status.StatusCode == http.StatusNotFound {
+		return nil, ErrSLONotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var list struct {
+		SLOs []map[string]any `json:"slos"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return list.SLOs, nil
+}
+
+// getRemoteSLO retrieves an SLO as a resource, along with the alert rules
+// generated for it
+func (h *SLOHandler) getRemoteSLO(uuid string) (*grizzly.Resource, error) {
+	slos, err := h.listSLOs()
+	if err != nil {
+		return nil, err
+	}
+	for _, slo := range slos {
+		if slo["uuid"] != uuid {
+			continue
+		}
+
+		rules, err := h.generatedAlertRules(uuid)
+		if err != nil {
+			return nil, err
+		}
+		slo["alertRules"] = rules
+
+		resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), uuid, slo)
+		if err != nil {
+			return nil, err
+		}
+		return &resource, nil
+	}
+
+	return nil, grizzly.ErrNotFound
+}
+
+// generatedAlertRules returns the alert rules Grafana generated for an SLO,
+// sorted by title, without what differs between stacks: their UID, folder
+// and datasources
+func (h *SLOHandler) generatedAlertRules(uuid string) ([]any, error) {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+	rulesOk, err := client.Provisioning.GetAlertRules()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []any{}
+	for _, rule := range rulesOk.GetPayload() {
+		if rule == nil || rule.Labels[sloUUIDLabel] != uuid {
+			continue
+		}
+
+		generated := map[string]any{
+			"labels":      stringMap(rule.Labels),
+			"annotations": stringMap(rule.Annotations),
+		}
+		if rule.Title != nil {
+			generated["title"] = *rule.Title
+		}
+		if rule.For != nil {
+			generated["for"] = rule.For.String()
+		}
+		queries := []any{}
+		for _, query := range rule.Data {
+			model, _ := query.Model.(map[string]any)
+			if expr, ok := model["expr"].(string); ok {
+				queries = append(queries, expr)
+			}
+		}
+		generated["queries"] = queries
+		rules = append(rules, generated)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return fmt.Sprint(rules[i].(map[string]any)["title"]) < fmt.Sprint(rules[j].(map[string]any)["title"])
+	})
+
+	return rules, nil
+}
+
+// sloObject returns the SLO described by a resource, without the fields
+// maintained by Grafana
+func sloObject(resource grizzly.Resource) map[string]any {
+	object := make(map[string]any, len(resource.Spec()))
+	for key, value := range resource.Spec() {
+		object[key] = value
+	}
+	object["uuid"] = resource.Name()
+	delete(object, "readOnly")
+	delete(object, "alertRules")
+	return object
+}
+
+func stringMap(values map[string]string) map[string]any {
+	converted := make(map[string]any, len(values))
+	for key, value := range values {
+		converted[key] = value
+	}
+	return converted
+}
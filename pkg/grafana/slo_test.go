@@ -0,0 +1,114 @@
+package grafana_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSLOs(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+	handler, err := registry.GetHandler("SLO")
+	require.NoError(t, err)
+
+	slo := func(t *testing.T, objective float64) grizzly.Resource {
+		t.Helper()
+		return grizzlytest.NewResource(t, "SLO", "checkout-availability", map[string]any{
+			"uuid":        "checkout-availability",
+			"name":        "Checkout availability",
+			"description": "Checkouts succeed",
+			"query": map[string]any{
+				"type": "ratio",
+				"ratio": map[string]any{
+					"successMetric": map[string]any{"prometheusMetric": `checkout_requests_total{code!~"5.."}`},
+					"totalMetric":   map[string]any{"prometheusMetric": "checkout_requests_total"},
+				},
+			},
+			"objectives": []any{map[string]any{"value": objective, "window": "28d"}},
+			"labels":     []any{map[string]any{"key": "team", "value": "payments"}},
+			"alerting": map[string]any{
+				"labels":   []any{map[string]any{"key": "service", "value": "checkout"}},
+				"fastBurn": map[string]any{},
+				"slowBurn": map[string]any{},
+			},
+		})
+	}
+	apply := func(t *testing.T, resource grizzly.Resource) error {
+		t.Helper()
+		return grizzly.Apply(registry, grizzly.NewResources(resource), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText))
+	}
+	diff := func(t *testing.T, resource grizzly.Resource) string {
+		t.Helper()
+		output := &bytes.Buffer{}
+		require.NoError(t, grizzly.Diff(registry, grizzly.NewResources(resource), false, "", grizzly.NewWriterRecorder(output, grizzly.EventToPlainText)))
+		return output.String()
+	}
+
+	t.Run("SLOs are created with the UUID of the resource", func(t *testing.T) {
+		require.NoError(t, apply(t, slo(t, 0.995)))
+		stored, found := server.SLO("checkout-availability")
+		require.True(t, found)
+		require.Equal(t, "Checkout availability", stored["name"])
+		require.Nil(t, stored["alertRules"])
+
+		require.Contains(t, diff(t, slo(t, 0.995)), "SLO.checkout-availability unchanged")
+	})
+
+	t.Run("generated alert rules are pulled", func(t *testing.T) {
+		remote, err := handler.GetByUID("checkout-availability")
+		require.NoError(t, err)
+		remote = handler.Unprepare(*remote)
+		require.Nil(t, remote.GetSpecValue("readOnly"))
+
+		rules, _ := remote.GetSpecValue("alertRules").([]any)
+		require.Len(t, rules, 2)
+		critical := rules[0].(map[string]any)
+		require.Equal(t, "Checkout availability critical burn rate", critical["title"])
+		require.Equal(t, "2m0s", critical["for"])
+		require.Equal(t, map[string]any{"grafana_slo_uuid": "checkout-availability", "grafana_slo_severity": "critical", "service": "checkout"}, critical["labels"])
+		require.Equal(t, []any{`grafana_slo_burn_rate{grafana_slo_uuid="checkout-availability"} > 0.072`}, critical["queries"])
+	})
+
+	t.Run("generated alert rules are compared when pulled", func(t *testing.T) {
+		remote, err := handler.GetByUID("checkout-availability")
+		require.NoError(t, err)
+		pulled := *handler.Unprepare(*remote)
+
+		// the generated rules of another stack differ
+		require.NoError(t, apply(t, slo(t, 0.99)))
+		require.Contains(t, diff(t, slo(t, 0.99)), "SLO.checkout-availability unchanged")
+		pulled.SetSpecValue("objectives", []any{map[string]any{"value": 0.99, "window": "28d"}})
+		require.Contains(t, diff(t, pulled), `-            - grafana_slo_burn_rate{grafana_slo_uuid="checkout-availability"} > 0.144`)
+		require.Contains(t, diff(t, pulled), `+            - grafana_slo_burn_rate{grafana_slo_uuid="checkout-availability"} > 0.072`)
+	})
+
+	t.Run("SLOs are deleted along with their alert rules", func(t *testing.T) {
+		require.NoError(t, handler.(grizzly.DeleteHandler).Delete(slo(t, 0.99)))
+		_, err := handler.GetByUID("checkout-availability")
+		require.ErrorIs(t, err, grizzly.ErrNotFound)
+		_, found := server.AlertRule("slo-checkout-availability-critical")
+		require.False(t, found)
+	})
+
+	t.Run("invalid SLOs are reported", func(t *testing.T) {
+		invalid := slo(t, 0.99)
+		invalid.SetSpecValue("objectives", []any{})
+		require.ErrorContains(t, handler.Validate(invalid), "SLOs need at least one objective")
+	})
+
+	t.Run("instances without the SLO plugin have no SLO", func(t *testing.T) {
+		server.SetSLO(false)
+		defer server.SetSLO(true)
+
+		uuids, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.Empty(t, uuids)
+
+		require.ErrorIs(t, apply(t, slo(t, 0.99)), grafana.ErrSLONotSupported)
+	})
+}
@@ -0,0 +1,345 @@
+package grafana
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/grafana-openapi-client-go/client/teams"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+const TeamKind = "Team"
+
+// TeamHandler is a Grizzly Handler for Grafana teams, identified by their
+// name, and their members
+type TeamHandler struct {
+	grizzly.BaseHandler
+}
+
+var _ grizzly.Handler = &TeamHandler{}
+var _ grizzly.DeleteHandler = &TeamHandler{}
+
+// NewTeamHandler returns a new Grizzly Handler for Grafana teams
+func NewTeamHandler(provider grizzly.Provider) *TeamHandler {
+	return &TeamHandler{
+		BaseHandler: grizzly.NewBaseHandler(provider, TeamKind, false),
+	}
+}
+
+// Permissions returns the permissions required to manage teams: managing
+// their members requires looking up the users of the organization
+func (h *TeamHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Role: "Admin", Actions: []string{"teams:read"}},
+		Write: grizzly.Access{Role: "Admin", Actions: []string{"teams:read", "teams:create", "teams:write", "teams:delete", "teams.permissions:write", "org.users:read"}},
+	}
+}
+
+const (
+	teamPattern = "teams/team-%s.%s"
+)
+
+// ResourceFilePath returns the location on disk where a resource should be updated
+func (h *TeamHandler) ResourceFilePath(resource grizzly.Resource, filetype string) string {
+	return fmt.Sprintf(teamPattern, resource.Name(), filetype)
+}
+
+// Unprepare removes unnecessary elements from a remote resource ready for presentation/comparison
+func (h *TeamHandler) Unprepare(resource grizzly.Resource) *grizzly.Resource {
+	resource.DeleteSpecKey("id")
+	return &resource
+}
+
+// Prepare gets a resource ready for dispatch to the remote endpoint
+func (h *TeamHandler) Prepare(existing *grizzly.Resource, resource grizzly.Resource) *grizzly.Resource {
+	if !resource.HasSpecString("name") {
+		resource.SetSpecString("name", resource.Name())
+	}
+	return &resource
+}
+
+// Validate checks that the name of the team matches the name of the resource
+func (h *TeamHandler) Validate(resource grizzly.Resource) error {
+	name, exist := resource.GetSpecString("name")
+	if exist && name != resource.Name() {
+		return fmt.Errorf("name '%s' and resource name '%s', don't match", name, resource.Name())
+	}
+	if _, err := teamMembers(resource); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (h *TeamHandler) GetSpecUID(resource grizzly.Resource) (string, error) {
+	name, ok := resource.GetSpecString("name")
+	if !ok {
+		return "", fmt.Errorf("name not specified")
+	}
+	return name, nil
+}
+
+// GetByUID retrieves JSON for a resource from an endpoint, by name
+func (h *TeamHandler) GetByUID(name string) (*grizzly.Resource, error) {
+	return h.getRemoteTeam(name)
+}
+
+// GetRemote retrieves a team as a Resource
+func (h *TeamHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	return h.getRemoteTeam(resource.Name())
+}
+
+// ListRemote retrieves as list of names of all remote teams
+func (h *TeamHandler) ListRemote() ([]string, error) {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		perPage int64 = 1000
+		page    int64 = 0
+		names   []string
+	)
+
+	params := teams.NewSearchTeamsParams().WithPerpage(&perPage)
+	for {
+		page++
+		params.SetPage(&page)
+
+		searchOk, err := client.Teams.SearchTeams(params)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, team := range searchOk.GetPayload().Teams {
+			names = append(names, team.Name)
+		}
+		if int64(len(searchOk.GetPayload().Teams)) < perPage {
+			return names, nil
+		}
+	}
+}
+
+// Add creates a team, then adds its members
+func (h *TeamHandler) Add(resource grizzly.Resource) error {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	email, _ := resource.GetSpecString("email")
+	createOk, err := client.Teams.CreateTeam(&models.CreateTeamCommand{
+		Name:  resource.Name(),
+		Email: email,
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.syncMembers(createOk.GetPayload().TeamID, nil, resource)
+}
+
+// Update changes the email of a team, and adds and removes members so as to
+// match the resource
+func (h *TeamHandler) Update(existing, resource grizzly.Resource) error {
+	team, err := h.getTeam(resource.Name())
+	if err != nil {
+		return err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	email, _ := resource.GetSpecString("email")
+	if email != team.Email {
+		_, err = client.Teams.UpdateTeam(teamID(team.ID), &models.UpdateTeamCommand{
+			Name:  team.Name,
+			Email: email,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	membersOk, err := client.Teams.GetTeamMembers(teamID(team.ID))
+	if err != nil {
+		return err
+	}
+	return h.syncMembers(team.ID, membersOk.GetPayload(), resource)
+}
+
+// Delete deletes a team, along with its memberships
+func (h *TeamHandler) Delete(resource grizzly.Resource) error {
+	team, err := h.getTeam(resource.Name())
+	if err != nil {
+		return err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Teams.DeleteTeamByID(teamID(team.ID))
+	return err
+}
+
+// getTeam returns a team by name
+func (h *TeamHandler) getTeam(name string) (*models.TeamDTO, error) {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+
+	searchOk, err := client.Teams.SearchTeams(teams.NewSearchTeamsParams().WithName(&name))
+	if err != nil {
+		return nil, err
+	}
+	for _, team := range searchOk.GetPayload().Teams {
+		if team.Name == name {
+			return team, nil
+		}
+	}
+
+	return nil, grizzly.ErrNotFound
+}
+
+// getRemoteTeam retrieves a team and the logins of its members as a
+// resource
+func (h *TeamHandler) getRemoteTeam(name string) (*grizzly.Resource, error) {
+	team, err := h.getTeam(name)
+	if err != nil {
+		return nil, err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+
+	membersOk, err := client.Teams.GetTeamMembers(teamID(team.ID))
+	if err != nil {
+		var gErr *teams.GetTeamMembersNotFound
+		if errors.As(err, &gErr) {
+			return nil, grizzly.ErrNotFound
+		}
+		return nil, err
+	}
+	members := []any{}
+	for _, member := range membersOk.GetPayload() {
+		members = append(members, memberLogin(member))
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].(string) < members[j].(string)
+	})
+
+	spec := map[string]any{
+		"id":      team.ID,
+		"name":    team.Name,
+		"members": members,
+	}
+	if team.Email != "" {
+		spec["email"] = team.Email
+	}
+
+	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), team.Name, spec)
+	if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// syncMembers adds and removes members of a team so as to match the members
+// of a resource, given by login or email
+func (h *TeamHandler) syncMembers(id int64, current []*models.TeamMemberDTO, resource grizzly.Resource) error {
+	wanted, err := teamMembers(resource)
+	if err != nil {
+		return err
+	}
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+
+	for _, member := range current {
+		if slices.ContainsFunc(wanted, func(user string) bool { return isUser(user, member.Login, member.Email) }) {
+			continue
+		}
+		if _, err := client.Teams.RemoveTeamMember(member.UserID, teamID(id)); err != nil {
+			return fmt.Errorf("removing %s from the team: %w", memberLogin(member), err)
+		}
+	}
+
+	var missing []string
+	for _, user := range wanted {
+		isCurrent := func(member *models.TeamMemberDTO) bool { return isUser(user, member.Login, member.Email) }
+		if !slices.ContainsFunc(current, isCurrent) {
+			missing = append(missing, user)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	usersOk, err := client.Org.GetOrgUsersForCurrentOrg()
+	if err != nil {
+		return fmt.Errorf("looking up the users of the organization: %w", err)
+	}
+	for _, user := range missing {
+		index := slices.IndexFunc(usersOk.GetPayload(), func(orgUser *models.OrgUserDTO) bool {
+			return isUser(user, orgUser.Login, orgUser.Email)
+		})
+		if index < 0 {
+			return fmt.Errorf("adding %s to the team: no user of the organization has this login or email", user)
+		}
+		command := &models.AddTeamMemberCommand{UserID: usersOk.GetPayload()[index].UserID}
+		if _, err := client.Teams.AddTeamMember(teamID(id), command); err != nil {
+			return fmt.Errorf("adding %s to the team: %w", user, err)
+		}
+	}
+
+	return nil
+}
+
+// teamMembers returns the logins or emails of the members of a team resource
+func teamMembers(resource grizzly.Resource) ([]string, error) {
+	value := resource.GetSpecValue("members")
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("members must be a list of user logins or emails")
+	}
+
+	members := make([]string, 0, len(list))
+	for _, member := range list {
+		login, ok := member.(string)
+		if !ok || login == "" {
+			return nil, fmt.Errorf("members must be a list of user logins or emails, got %v", member)
+		}
+		members = append(members, login)
+	}
+	return members, nil
+}
+
+// isUser tells whether a login or email refers to a user
+func isUser(user, login, email string) bool {
+	return user == login || (email != "" && user == email)
+}
+
+// memberLogin identifies a member by login, or by email for users without
+// login
+func memberLogin(member *models.TeamMemberDTO) string {
+	if member.Login != "" {
+		return member.Login
+	}
+	return member.Email
+}
+
+func teamID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
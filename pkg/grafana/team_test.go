@@ -0,0 +1,80 @@
+package grafana_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeams(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	server.AddUser("alice", "alice@example.com")
+	server.AddUser("bob", "bob@example.com")
+	server.AddUser("carol", "carol@example.com")
+	registry := server.GrafanaRegistry()
+
+	team := func(t *testing.T, email string, members ...any) grizzly.Resource {
+		t.Helper()
+		return grizzlytest.NewResource(t, "Team", "sre", map[string]any{
+			"name":    "sre",
+			"email":   email,
+			"members": members,
+		})
+	}
+	apply := func(t *testing.T, resource grizzly.Resource) error {
+		t.Helper()
+		return grizzly.Apply(registry, grizzly.NewResources(resource), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText))
+	}
+	diff := func(t *testing.T, resource grizzly.Resource) string {
+		t.Helper()
+		output := &bytes.Buffer{}
+		require.NoError(t, grizzly.Diff(registry, grizzly.NewResources(resource), false, "", grizzly.NewWriterRecorder(output, grizzly.EventToPlainText)))
+		return output.String()
+	}
+
+	t.Run("teams are created with their members", func(t *testing.T) {
+		require.NoError(t, apply(t, team(t, "sre@example.com", "alice", "bob@example.com")))
+
+		remote, members, found := server.Team("sre")
+		require.True(t, found)
+		require.Equal(t, "sre@example.com", remote["email"])
+		require.Equal(t, []string{"alice", "bob"}, members)
+
+		handler, err := registry.GetHandler("Team")
+		require.NoError(t, err)
+		names, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.Equal(t, []string{"sre"}, names)
+
+		require.Contains(t, diff(t, team(t, "sre@example.com", "alice", "bob")), "Team.sre unchanged")
+	})
+
+	t.Run("members are added and removed", func(t *testing.T) {
+		require.Contains(t, diff(t, team(t, "oncall@example.com", "alice", "carol")), "+        - carol")
+		require.NoError(t, apply(t, team(t, "oncall@example.com", "alice", "carol")))
+
+		remote, members, found := server.Team("sre")
+		require.True(t, found)
+		require.Equal(t, "oncall@example.com", remote["email"])
+		require.Equal(t, []string{"alice", "carol"}, members)
+	})
+
+	t.Run("unknown users are reported", func(t *testing.T) {
+		err := apply(t, team(t, "oncall@example.com", "alice", "dave"))
+		require.ErrorContains(t, err, "adding dave to the team: no user of the organization has this login or email")
+	})
+
+	t.Run("teams are deleted", func(t *testing.T) {
+		handler, err := registry.GetHandler("Team")
+		require.NoError(t, err)
+		require.NoError(t, handler.(grizzly.DeleteHandler).Delete(team(t, "oncall@example.com")))
+
+		_, _, found := server.Team("sre")
+		require.False(t, found)
+		_, err = handler.GetByUID("sre")
+		require.ErrorIs(t, err, grizzly.ErrNotFound)
+	})
+}
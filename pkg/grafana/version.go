@@ -0,0 +1,68 @@
+package grafana
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"golang.org/x/mod/semver"
+)
+
+// ValidateTargetVersion checks the version of Grafana targeted by a project,
+// e.g. `10.4.0`
+func ValidateTargetVersion(version string) error {
+	if !semver.IsValid(releaseVersion(version)) {
+		return fmt.Errorf("invalid Grafana version '%s'", version)
+	}
+
+	return nil
+}
+
+// RequiredFeatures lists the features a resource relies on
+func RequiredFeatures(resource grizzly.Resource) []Feature {
+	switch resource.Kind() {
+	case "DashboardFolder":
+		if parentUID, _ := resource.GetSpecString("parentUid"); parentUID != "" {
+			return []Feature{FeatureNestedFolders}
+		}
+	case "AlertRuleGroup", "AlertContactPoint", "AlertNotificationPolicy":
+		return []Feature{FeatureAlertingProvisioning}
+	}
+
+	return nil
+}
+
+// CheckTargetVersion warns about the resources relying on features that the
+// targeted version of Grafana doesn't provide. Features depending on a toggle
+// are expected to be enabled by default in the targeted version.
+func CheckTargetVersion(resources grizzly.Resources, version string) []grizzly.Warning {
+	target := &Capabilities{Version: strings.TrimPrefix(version, "v")}
+
+	var warnings []grizzly.Warning
+	for _, resource := range resources.AsList() {
+		resource, err := resource.Hydrate()
+		if err != nil {
+			warnings = append(warnings, grizzly.NewResourceWarning(resource.Ref(), err))
+			continue
+		}
+		for _, feature := range RequiredFeatures(resource) {
+			if target.Supports(feature) {
+				continue
+			}
+
+			warnings = append(warnings, grizzly.NewResourceWarning(resource.Ref(), fmt.Errorf("relies on %s, newer than the targeted Grafana %s (requires %s)", feature.Name, version, feature.requirement())))
+		}
+	}
+
+	return warnings
+}
+
+// CheckInstanceVersion fails when the target Grafana instance is older than
+// the version targeted by the project
+func (c *Capabilities) CheckInstanceVersion(version string) error {
+	if c.OlderThan(version) {
+		return fmt.Errorf("Grafana %s is older than the version targeted by the project (%s)", c.Version, version)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package grizzly
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ApplyProgressFile returns the file of a remote cache directory recording
+// the resources applied by the current, or last failed, apply of a resource
+// path. Applies of other paths to the same context, e.g. from other
+// projects, don't share it.
+func ApplyProgressFile(resourcePath string) string {
+	if absPath, err := filepath.Abs(resourcePath); err == nil && resourcePath != StdinPath {
+		resourcePath = absPath
+	}
+	sum := sha256.Sum256([]byte(resourcePath))
+
+	return fmt.Sprintf("apply-progress-%s.jsonl", hex.EncodeToString(sum[:])[:12])
+}
+
+type appliedResource struct {
+	Resource string `json:"resource"`
+	Checksum string `json:"checksum"`
+}
+
+// ApplyProgress records every resource applied as soon as it is applied, so
+// that a failed or interrupted apply can be resumed instead of applying
+// every resource again. Resources are only considered completed while their
+// content is unchanged.
+type ApplyProgress struct {
+	lock      sync.Mutex
+	file      *os.File
+	completed map[string]string
+}
+
+// OpenApplyProgress opens the progress recorded in a file. When resuming,
+// the resources completed by the previous apply are kept, otherwise the
+// recording starts over.
+func OpenApplyProgress(path string, resume bool) (*ApplyProgress, error) {
+	progress := &ApplyProgress{completed: map[string]string{}}
+
+	if resume {
+		existing, err := os.Open(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if err == nil {
+			scanner := bufio.NewScanner(existing)
+			for scanner.Scan() {
+				entry := appliedResource{}
+				// the last entry is truncated when the apply was
+				// interrupted while recording it
+				if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+					continue
+				}
+				progress.completed[entry.Resource] = entry.Checksum
+			}
+			existing.Close()
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !resume {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return nil, err
+	}
+	progress.file = file
+
+	return progress, nil
+}
+
+// Resumed returns the number of resources completed by the previous apply
+func (p *ApplyProgress) Resumed() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return len(p.completed)
+}
+
+// Completed returns whether a resource was applied by the previous apply,
+// with the same content
+func (p *ApplyProgress) Completed(resource Resource) bool {
+	checksum, err := ResourceChecksum(resource)
+	if err != nil {
+		return false
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	recorded, ok := p.completed[resource.Ref().String()]
+	return ok && recorded == checksum
+}
+
+// Record records an applied resource
+func (p *ApplyProgress) Record(resource Resource) error {
+	checksum, err := ResourceChecksum(resource)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(appliedResource{
+		Resource: resource.Ref().String(),
+		Checksum: checksum,
+	})
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	p.completed[resource.Ref().String()] = checksum
+
+	return nil
+}
+
+// Close closes the progress, keeping it to resume from when the apply
+// failed, and removing it otherwise
+func (p *ApplyProgress) Close(failed bool) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := p.file.Close(); err != nil {
+		return err
+	}
+	if failed {
+		return nil
+	}
+
+	err := os.Remove(p.file.Name())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyResume(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+	path := filepath.Join(t.TempDir(), grizzly.ApplyProgressFile("resources"))
+
+	apply := func(t *testing.T, resume bool, resources ...grizzly.Resource) (string, error) {
+		t.Helper()
+		progress, err := grizzly.OpenApplyProgress(path, resume)
+		require.NoError(t, err)
+
+		out := &bytes.Buffer{}
+		applyErr := grizzly.Apply(registry, grizzly.NewResources(resources...), false, grizzly.NewWriterRecorder(out, grizzly.EventToPlainText), grizzly.ApplyRecordProgress(progress))
+		require.NoError(t, progress.Close(applyErr != nil))
+		return out.String(), applyErr
+	}
+
+	// the apply stops at the unknown kind
+	_, err := apply(t, false, grizzlytest.NewDashboard(t, "cpu", "CPU"), grizzlytest.NewResource(t, "Widget", "broken", map[string]any{"uid": "broken", "title": "Broken"}), grizzlytest.NewDashboard(t, "memory", "Memory"))
+	require.Error(t, err)
+	require.FileExists(t, path)
+
+	out, err := apply(t, true, grizzlytest.NewDashboard(t, "cpu", "CPU"), grizzlytest.NewDashboard(t, "memory", "Memory v2"))
+	require.NoError(t, err)
+	require.Contains(t, out, "Dashboard.cpu unchanged: applied by the resumed apply")
+	require.Contains(t, out, "Dashboard.memory added")
+	require.NoFileExists(t, path)
+
+	// complete applies aren't resumed
+	out, err = apply(t, true, grizzlytest.NewDashboard(t, "cpu", "CPU"))
+	require.NoError(t, err)
+	require.Contains(t, out, "Dashboard.cpu unchanged\n")
+}
+
+func TestApplyProgressFile(t *testing.T) {
+	absPath, err := filepath.Abs("resources")
+	require.NoError(t, err)
+
+	require.Equal(t, grizzly.ApplyProgressFile("resources"), grizzly.ApplyProgressFile(absPath))
+	require.Equal(t, grizzly.ApplyProgressFile("resources"), grizzly.ApplyProgressFile("./resources/"))
+	require.NotEqual(t, grizzly.ApplyProgressFile("resources"), grizzly.ApplyProgressFile("../other-project/resources"))
+}
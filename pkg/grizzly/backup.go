@@ -0,0 +1,205 @@
+package grizzly
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/grizzly/notifier"
+	"github.com/hashicorp/go-multierror"
+	"gopkg.in/yaml.v3"
+)
+
+// BackupManifestFile is the name of the file describing the content of a
+// backup, at its root
+const BackupManifestFile = "grizzly-backup.yaml"
+
+// ErrCorruptedBackup is returned when the files of a backup don't match its
+// manifest
+var ErrCorruptedBackup = errors.New("backup doesn't match its manifest")
+
+// BackupManifest describes the content of a backup
+type BackupManifest struct {
+	CreatedAt      time.Time `yaml:"created-at"`
+	GrizzlyVersion string    `yaml:"grizzly-version,omitempty"`
+	Context        string    `yaml:"context,omitempty"`
+	// Skipped lists the providers that weren't configured, hence not backed up
+	Skipped   []string      `yaml:"skipped,omitempty"`
+	Resources []BackupEntry `yaml:"resources"`
+}
+
+// BackupEntry is a resource of a backup
+type BackupEntry struct {
+	Kind string `yaml:"kind"`
+	Name string `yaml:"name"`
+	// Path is the location of the resource, relative to the backup directory
+	Path   string `yaml:"path"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// Backup writes all the remote resources of the targeted kinds to dir, along
+// with a manifest listing them. Providers that aren't configured are skipped.
+func Backup(registry Registry, dir string, targets []string, manifest BackupManifest, continueOnError bool, eventsRecorder eventsRecorder) (BackupManifest, error) {
+	var finalErr error
+
+	for _, provider := range registry.Providers {
+		if err := provider.Validate(); err != nil {
+			notifier.Warn(notifier.SimpleString(provider.Name()), fmt.Sprintf("skipped: %s", err))
+			manifest.Skipped = append(manifest.Skipped, provider.Name())
+			continue
+		}
+
+		for _, providerHandler := range provider.GetHandlers() {
+			handler, err := registry.GetHandler(providerHandler.Kind())
+			if err != nil {
+				return manifest, err
+			}
+			if !registry.HandlerMatchesTarget(handler, targets) {
+				continue
+			}
+
+			entries, err := backupHandler(registry, handler, dir, targets, continueOnError, eventsRecorder)
+			manifest.Resources = append(manifest.Resources, entries...)
+			if err != nil {
+				finalErr = multierror.Append(finalErr, err)
+				if !continueOnError {
+					return manifest, finalErr
+				}
+			}
+		}
+	}
+
+	content, err := yaml.Marshal(manifest)
+	if err != nil {
+		return manifest, err
+	}
+	if err := WriteFile(filepath.Join(dir, BackupManifestFile), content); err != nil {
+		return manifest, err
+	}
+
+	return manifest, finalErr
+}
+
+func backupHandler(registry Registry, handler Handler, dir string, targets []string, continueOnError bool, eventsRecorder eventsRecorder) ([]BackupEntry, error) {
+	var entries []BackupEntry
+	var finalErr error
+
+	uids, err := handler.ListRemote()
+	if err != nil {
+		eventsRecorder.Record(Event{
+			Type:        ResourceFailure,
+			ResourceRef: handler.Kind(),
+			Details:     fmt.Sprintf("failed listing remote values: %s", err),
+		})
+		return nil, err
+	}
+
+	for _, uid := range uids {
+		if !registry.ResourceMatchesTarget(handler.Kind(), uid, targets) {
+			continue
+		}
+
+		entry, err := backupResource(registry, handler, dir, uid)
+		if err != nil {
+			finalErr = multierror.Append(finalErr, err)
+			eventsRecorder.Record(Event{
+				Type:        ResourceFailure,
+				ResourceRef: NewResourceRef(handler.Kind(), uid).String(),
+				Details:     err.Error(),
+			})
+			if !continueOnError {
+				return entries, finalErr
+			}
+			continue
+		}
+
+		entries = append(entries, entry)
+		eventsRecorder.Record(Event{Type: ResourcePulled, ResourceRef: entry.Ref().String()})
+	}
+
+	return entries, finalErr
+}
+
+func backupResource(registry Registry, handler Handler, dir string, uid string) (BackupEntry, error) {
+	resource, err := handler.GetByUID(uid)
+	if err != nil {
+		return BackupEntry{}, fmt.Errorf("failed pulling resource: %w", err)
+	}
+	resource = handler.Unprepare(*resource)
+
+	content, filename, _, err := Format(registry, dir, resource, formatYAML, false)
+	if err != nil {
+		return BackupEntry{}, fmt.Errorf("failed formatting resource: %w", err)
+	}
+	if err := WriteFile(filename, content); err != nil {
+		return BackupEntry{}, err
+	}
+
+	path, err := filepath.Rel(dir, filename)
+	if err != nil {
+		return BackupEntry{}, err
+	}
+	sum := sha256.Sum256(content)
+
+	return BackupEntry{
+		Kind:   resource.Kind(),
+		Name:   resource.Name(),
+		Path:   filepath.ToSlash(path),
+		SHA256: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+func (entry BackupEntry) Ref() ResourceRef {
+	return NewResourceRef(entry.Kind, entry.Name)
+}
+
+// ReadBackupManifest reads the manifest of the backup stored in dir, and
+// checks that the files of the backup match it
+func ReadBackupManifest(dir string) (BackupManifest, error) {
+	var manifest BackupManifest
+
+	content, err := os.ReadFile(filepath.Join(dir, BackupManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, fmt.Errorf("%s is not a backup: %s not found", dir, BackupManifestFile)
+	}
+	if err != nil {
+		return manifest, err
+	}
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return manifest, ParseError{File: filepath.Join(dir, BackupManifestFile), Err: err}
+	}
+
+	for _, entry := range manifest.Resources {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(entry.Path)))
+		if errors.Is(err, os.ErrNotExist) {
+			return manifest, fmt.Errorf("%w: %s of %s is missing", ErrCorruptedBackup, entry.Path, entry.Ref())
+		}
+		if err != nil {
+			return manifest, err
+		}
+
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != entry.SHA256 {
+			return manifest, fmt.Errorf("%w: %s of %s was modified", ErrCorruptedBackup, entry.Path, entry.Ref())
+		}
+	}
+
+	return manifest, nil
+}
+
+// Restore applies the resources of a backup, in dependency order (e.g.
+// folders before the dashboards they contain). Every resource listed in the
+// manifest must be part of the given resources.
+func Restore(registry Registry, manifest BackupManifest, resources Resources, continueOnError bool, eventsRecorder eventsRecorder) error {
+	for _, entry := range manifest.Resources {
+		if _, found := resources.Find(entry.Ref()); !found {
+			return fmt.Errorf("%w: %s not found in %s", ErrCorruptedBackup, entry.Ref(), entry.Path)
+		}
+	}
+
+	return Apply(registry, registry.Sort(resources), continueOnError, eventsRecorder)
+}
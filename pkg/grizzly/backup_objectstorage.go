@@ -0,0 +1,280 @@
+package grizzly
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ObjectStorageBackupStore keeps backups in a bucket of an S3-compatible
+// object storage: Amazon S3, Google Cloud Storage (with HMAC keys), MinIO,
+// etc. Requests are signed with AWS Signature Version 4.
+type ObjectStorageBackupStore struct {
+	// Endpoint is the URL of the object storage, e.g.
+	// `https://s3.eu-west-1.amazonaws.com`
+	Endpoint        string
+	Bucket          string
+	Prefix          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewObjectStorageBackupStore returns a store for the given location, e.g.
+// `s3://bucket/prefix` or `gs://bucket/prefix`. Credentials and region are
+// read from the usual AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN and AWS_REGION environment variables. The endpoint is
+// derived from the scheme of the location, unless given.
+func NewObjectStorageBackupStore(location string, endpoint string) (*ObjectStorageBackupStore, error) {
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup location %s: %w", location, err)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("invalid backup location %s: missing bucket", location)
+	}
+
+	store := &ObjectStorageBackupStore{
+		Endpoint:        strings.TrimSuffix(endpoint, "/"),
+		Bucket:          parsed.Host,
+		Prefix:          strings.Trim(parsed.Path, "/"),
+		Region:          os.Getenv("AWS_REGION"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		client:          &http.Client{Transport: DecorateHTTPTransport(http.DefaultTransport)},
+		now:             time.Now,
+	}
+	if store.Region == "" {
+		store.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	switch parsed.Scheme {
+	case "s3":
+		if store.Region == "" {
+			store.Region = "us-east-1"
+		}
+		if store.Endpoint == "" {
+			store.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", store.Region)
+		}
+	case "gs":
+		if store.Region == "" {
+			store.Region = "auto"
+		}
+		if store.Endpoint == "" {
+			store.Endpoint = "https://storage.googleapis.com"
+		}
+	default:
+		return nil, fmt.Errorf("invalid backup location %s: only s3:// and gs:// are supported", location)
+	}
+
+	if store.AccessKeyID == "" || store.SecretAccessKey == "" {
+		return nil, fmt.Errorf("uploading backups requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY to be set")
+	}
+
+	return store, nil
+}
+
+func (store *ObjectStorageBackupStore) key(name string) string {
+	if store.Prefix == "" {
+		return name
+	}
+	return store.Prefix + "/" + name
+}
+
+// Upload uploads a file, named after its base name
+func (store *ObjectStorageBackupStore) Upload(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.do(http.MethodPut, store.key(filepath.Base(path)), nil, content)
+	return err
+}
+
+func (store *ObjectStorageBackupStore) List() ([]string, error) {
+	var names []string
+
+	query := url.Values{}
+	query.Set("list-type", "2")
+	query.Set("delimiter", "/")
+	if store.Prefix != "" {
+		query.Set("prefix", store.Prefix+"/")
+	}
+
+	for {
+		body, err := store.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("listing backups: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			name := strings.TrimPrefix(object.Key, query.Get("prefix"))
+			if _, ok := backupTime(name); ok {
+				names = append(names, name)
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return names, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+func (store *ObjectStorageBackupStore) Delete(name string) error {
+	_, err := store.do(http.MethodDelete, store.key(name), nil, nil)
+	return err
+}
+
+func (store *ObjectStorageBackupStore) do(method string, key string, query url.Values, body []byte) ([]byte, error) {
+	path := "/" + awsEscape(store.Bucket, false)
+	if key != "" {
+		path += "/" + awsEscape(key, true)
+	}
+
+	req, err := http.NewRequest(method, store.Endpoint+path+"?"+awsQuery(query), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = awsQuery(query)
+	store.sign(req, body, store.now())
+
+	resp, err := store.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s: unexpected status %s: %s", method, path, resp.Status, strings.TrimSpace(string(content)))
+	}
+
+	return content, nil
+}
+
+// sign signs a request with AWS Signature Version 4, covering the host and
+// all the headers of the request
+func (store *ObjectStorageBackupStore) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256.Sum256(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	if store.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", store.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + store.Region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + store.SecretAccessKey)
+	for _, part := range []string{date, store.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", store.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsEscape escapes a value the way AWS signatures expect it: everything but
+// unreserved characters (and slashes, in paths) is percent-encoded
+func awsEscape(value string, path bool) string {
+	var escaped strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			escaped.WriteByte(b)
+		case b == '/' && path:
+			escaped.WriteByte(b)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
+
+// awsQuery encodes a query string canonically: sorted, and escaped with
+// awsEscape
+func awsQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, awsEscape(key, false)+"="+awsEscape(value, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
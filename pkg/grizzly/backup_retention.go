@@ -0,0 +1,263 @@
+package grizzly
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupNameLayout is the layout of the names of scheduled backups, i.e.
+// their creation time
+const BackupNameLayout = "20060102T150405Z"
+
+// BackupArchiveExtension is the extension of compressed backups
+const BackupArchiveExtension = ".tar.gz"
+
+// BackupName returns the name of a backup created at the given time
+func BackupName(createdAt time.Time) string {
+	return createdAt.UTC().Format(BackupNameLayout)
+}
+
+// backupTime returns the creation time of a backup, given its name
+func backupTime(name string) (time.Time, bool) {
+	createdAt, err := time.Parse(BackupNameLayout, strings.TrimSuffix(name, BackupArchiveExtension))
+	return createdAt, err == nil
+}
+
+// RetentionPolicy tells which backups to keep. The latest backup is always
+// kept.
+type RetentionPolicy struct {
+	// Daily is the number of days for which the latest backup is kept
+	Daily int
+	// Weekly is the number of weeks for which the latest backup is kept
+	Weekly int
+}
+
+// Enabled tells whether backups expire
+func (policy RetentionPolicy) Enabled() bool {
+	return policy.Daily > 0 || policy.Weekly > 0
+}
+
+// Expired returns the backups, given their names, that the policy doesn't
+// keep. Names that aren't backup names are ignored.
+func (policy RetentionPolicy) Expired(names []string) []string {
+	type backup struct {
+		name      string
+		createdAt time.Time
+	}
+	var backups []backup
+	for _, name := range names {
+		if createdAt, ok := backupTime(name); ok {
+			backups = append(backups, backup{name: name, createdAt: createdAt})
+		}
+	}
+	// latest first
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].createdAt.After(backups[j].createdAt)
+	})
+
+	kept := map[string]bool{}
+	keep := func(count int, period func(time.Time) string) {
+		periods := map[string]bool{}
+		for _, backup := range backups {
+			key := period(backup.createdAt)
+			if periods[key] {
+				continue
+			}
+			if len(periods) == count {
+				return
+			}
+			periods[key] = true
+			kept[backup.name] = true
+		}
+	}
+	keep(1, func(time.Time) string { return "" })
+	keep(policy.Daily, func(t time.Time) string { return t.Format("2006-01-02") })
+	keep(policy.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%d", year, week)
+	})
+
+	var expired []string
+	for _, backup := range backups {
+		if !kept[backup.name] {
+			expired = append(expired, backup.name)
+		}
+	}
+	sort.Strings(expired)
+
+	return expired
+}
+
+// BackupStore is where scheduled backups are kept
+type BackupStore interface {
+	// List returns the names of the backups in the store
+	List() ([]string, error)
+	Delete(name string) error
+}
+
+// LocalBackupStore keeps backups in a directory
+type LocalBackupStore struct {
+	Dir string
+}
+
+func (store LocalBackupStore) List() ([]string, error) {
+	entries, err := os.ReadDir(store.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if _, ok := backupTime(entry.Name()); ok {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+func (store LocalBackupStore) Delete(name string) error {
+	return os.RemoveAll(filepath.Join(store.Dir, name))
+}
+
+// ApplyRetention deletes the backups of a store that the policy doesn't keep,
+// and returns their names
+func ApplyRetention(store BackupStore, policy RetentionPolicy) ([]string, error) {
+	if !policy.Enabled() {
+		return nil, nil
+	}
+
+	names, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	expired := policy.Expired(names)
+	for i, name := range expired {
+		if err := store.Delete(name); err != nil {
+			return expired[:i], fmt.Errorf("deleting backup %s: %w", name, err)
+		}
+	}
+
+	return expired, nil
+}
+
+// ArchiveBackup compresses the backup stored in dir as a `.tar.gz` file next
+// to it, removes dir, and returns the path of the archive
+func ArchiveBackup(dir string) (string, error) {
+	dir = filepath.Clean(dir)
+	path := dir + BackupArchiveExtension
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	compressed := gzip.NewWriter(file)
+	archive := tar.NewWriter(compressed)
+
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relative)
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+
+		content, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+		_, err = io.Copy(archive, content)
+		return err
+	})
+	if err == nil {
+		err = archive.Close()
+	}
+	if err == nil {
+		err = compressed.Close()
+	}
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("archiving backup %s: %w", dir, err)
+	}
+
+	return path, os.RemoveAll(dir)
+}
+
+// ExtractBackup extracts a backup compressed by ArchiveBackup to dir
+func ExtractBackup(path string, dir string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	compressed, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("extracting backup %s: %w", path, err)
+	}
+	archive := tar.NewReader(compressed)
+
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("extracting backup %s: %w", path, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("extracting backup %s: invalid file name %s", path, header.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		content, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(content, archive)
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
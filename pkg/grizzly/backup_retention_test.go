@@ -0,0 +1,73 @@
+package grizzly_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	// every 12 hours, from Monday 2024-04-01 to Sunday 2024-04-14
+	var names []string
+	start := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 28; i++ {
+		names = append(names, grizzly.BackupName(start.Add(time.Duration(i)*12*time.Hour)))
+	}
+	names = append(names, "not-a-backup")
+
+	t.Run("latest backup is always kept", func(t *testing.T) {
+		expired := grizzly.RetentionPolicy{}.Expired(names)
+		require.Len(t, expired, 27)
+		require.NotContains(t, expired, "20240414T120000Z")
+		require.NotContains(t, expired, "not-a-backup")
+	})
+
+	t.Run("latest backup of the last days", func(t *testing.T) {
+		expired := grizzly.RetentionPolicy{Daily: 3}.Expired(names)
+		require.Len(t, expired, 25)
+		require.NotContains(t, expired, "20240414T120000Z")
+		require.NotContains(t, expired, "20240413T120000Z")
+		require.NotContains(t, expired, "20240412T120000Z")
+	})
+
+	t.Run("latest backup of the last weeks", func(t *testing.T) {
+		expired := grizzly.RetentionPolicy{Daily: 1, Weekly: 2}.Expired(names)
+		require.Len(t, expired, 26)
+		require.NotContains(t, expired, "20240414T120000Z")
+		require.NotContains(t, expired, "20240407T120000Z")
+	})
+}
+
+func TestArchiveBackup(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, grizzly.BackupName(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, grizzly.WriteFile(filepath.Join(dir, "dashboards", "infra", "nodes.yaml"), []byte("kind: Dashboard\n")))
+	require.NoError(t, grizzly.WriteFile(filepath.Join(dir, grizzly.BackupManifestFile), []byte("resources: []\n")))
+
+	archive, err := grizzly.ArchiveBackup(dir)
+	require.NoError(t, err)
+	require.Equal(t, dir+grizzly.BackupArchiveExtension, archive)
+	require.NoDirExists(t, dir)
+
+	extracted := t.TempDir()
+	require.NoError(t, grizzly.ExtractBackup(archive, extracted))
+	content, err := os.ReadFile(filepath.Join(extracted, "dashboards", "infra", "nodes.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "kind: Dashboard\n", string(content))
+	require.FileExists(t, filepath.Join(extracted, grizzly.BackupManifestFile))
+
+	t.Run("retention applies to archives", func(t *testing.T) {
+		newer := filepath.Join(root, grizzly.BackupName(time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)))
+		require.NoError(t, os.Mkdir(newer, 0755))
+
+		expired, err := grizzly.ApplyRetention(grizzly.LocalBackupStore{Dir: root}, grizzly.RetentionPolicy{Daily: 1})
+		require.NoError(t, err)
+		require.Equal(t, []string{filepath.Base(archive)}, expired)
+		require.NoFileExists(t, archive)
+		require.DirExists(t, newer)
+	})
+}
@@ -0,0 +1,65 @@
+package grizzly_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRestore(t *testing.T) {
+	source := grizzlytest.NewServer(t)
+	sourceRegistry := source.GrafanaRegistry()
+
+	folder := grizzlytest.NewFolder(t, "infra", "Infra")
+	dashboard := grizzlytest.NewResource(t, "Dashboard", "nodes", map[string]any{
+		"uid":   "nodes",
+		"title": "Nodes",
+	})
+	dashboard.SetMetadata("folder", "infra")
+	require.NoError(t, grizzly.Apply(sourceRegistry, grizzly.NewResources(folder, dashboard), false, grizzly.NewJUnitReport("apply")))
+
+	dir := t.TempDir()
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	targets := []string{"Dashboard", "DashboardFolder"}
+	manifest, err := grizzly.Backup(sourceRegistry, dir, targets, grizzly.BackupManifest{CreatedAt: createdAt}, false, grizzly.NewJUnitReport("backup"))
+	require.NoError(t, err)
+	require.Len(t, manifest.Resources, 2)
+
+	read, err := grizzly.ReadBackupManifest(dir)
+	require.NoError(t, err)
+	require.Equal(t, manifest, read)
+
+	parser := grizzly.DefaultParser(sourceRegistry, nil, nil, grizzly.ParserIgnore([]string{grizzly.BackupManifestFile}))
+	resources, err := parser.Parse(dir, grizzly.ParserOptions{})
+	require.NoError(t, err)
+
+	t.Run("restore in dependency order", func(t *testing.T) {
+		target := grizzlytest.NewServer(t)
+		targetRegistry := target.GrafanaRegistry()
+
+		// dashboards/ is parsed before folders/: folders must be restored first
+		require.NoError(t, grizzly.Restore(targetRegistry, read, resources, false, grizzly.NewJUnitReport("restore")))
+
+		_, folderUID, found := target.Dashboard("nodes")
+		require.True(t, found)
+		require.Equal(t, "infra", folderUID)
+	})
+
+	t.Run("modified backups are rejected", func(t *testing.T) {
+		path := filepath.Join(dir, filepath.FromSlash(manifest.Resources[0].Path))
+		require.NoError(t, os.WriteFile(path, []byte("tampered: true\n"), 0644))
+
+		_, err := grizzly.ReadBackupManifest(dir)
+		require.ErrorIs(t, err, grizzly.ErrCorruptedBackup)
+	})
+
+	t.Run("directories without manifest are rejected", func(t *testing.T) {
+		_, err := grizzly.ReadBackupManifest(t.TempDir())
+		require.ErrorContains(t, err, "is not a backup")
+	})
+}
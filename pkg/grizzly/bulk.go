@@ -0,0 +1,128 @@
+package grizzly
+
+import (
+	"fmt"
+)
+
+// BulkApplyHandler is implemented by handlers whose backends can retrieve
+// and write several resources at once, e.g. all the rule groups of a ruler.
+// Consecutive resources of their kind are then applied as a batch, instead
+// of one at a time. Decorated handlers, e.g. checking versions, and
+// resources recreated on change are still applied one at a time.
+type BulkApplyHandler interface {
+	Handler
+
+	// BulkGetRemote retrieves the remote counterparts of resources, in the
+	// same order: nil for the resources that don't exist remotely
+	BulkGetRemote(resources []Resource) ([]*Resource, error)
+
+	// BulkApply writes changes, creating the resources that have no
+	// existing counterpart and updating the others. It returns the error of
+	// each change, in the same order, nil when applied.
+	BulkApply(changes []BulkChange) []error
+}
+
+// BulkChange is a resource to write, prepared for its remote endpoint
+type BulkChange struct {
+	// Existing is the remote counterpart of the resource, nil when it is to
+	// be created
+	Existing *Resource
+	Resource Resource
+}
+
+// bulkBatch returns the resources at the start of the list that can be
+// applied as a batch by their handler, if it supports it
+func bulkBatch(registry Registry, list []Resource) (BulkApplyHandler, []Resource) {
+	handler, err := registry.GetHandler(list[0].Kind())
+	if err != nil {
+		return nil, nil
+	}
+	bulkHandler, ok := handler.(BulkApplyHandler)
+	if !ok {
+		return nil, nil
+	}
+
+	var batch []Resource
+	for _, resource := range list {
+		if resource.Kind() != bulkHandler.Kind() {
+			break
+		}
+		strategy, err := GetApplyStrategy(bulkHandler, resource)
+		if err != nil || strategy == ApplyRecreate {
+			break
+		}
+		batch = append(batch, resource)
+	}
+
+	return bulkHandler, batch
+}
+
+// applyBatch applies resources with a single retrieval of their remote
+// counterparts, and a single write of those that changed. It returns the
+// error of each resource, in the same order.
+func applyBatch(handler BulkApplyHandler, batch []Resource, trailRecorder eventsRecorder) []error {
+	errs := make([]error, len(batch))
+	failAll := func(err error) []error {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	existing, err := handler.BulkGetRemote(batch)
+	if err != nil {
+		return failAll(err)
+	}
+	if len(existing) != len(batch) {
+		return failAll(fmt.Errorf("%s: %d remote resources retrieved for %d resources", handler.Kind(), len(existing), len(batch)))
+	}
+
+	var changes []BulkChange
+	var changed []int
+	for i, resource := range batch {
+		strategy, err := GetApplyStrategy(handler, resource)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		change, event, err := planApply(handler, resource, existing[i], strategy)
+		switch {
+		case err != nil:
+			errs[i] = err
+		case change == nil:
+			trailRecorder.Record(*event)
+		default:
+			changes = append(changes, *change)
+			changed = append(changed, i)
+		}
+	}
+	if len(changes) == 0 {
+		return errs
+	}
+
+	changeErrs := handler.BulkApply(changes)
+	if len(changeErrs) != len(changes) {
+		err := fmt.Errorf("%s: %d results returned for %d changes", handler.Kind(), len(changeErrs), len(changes))
+		changeErrs = make([]error, len(changes))
+		for i := range changeErrs {
+			changeErrs[i] = err
+		}
+	}
+	for j, i := range changed {
+		if errs[i] = changeErrs[j]; errs[i] != nil {
+			continue
+		}
+
+		eventType := ResourceUpdated
+		if changes[j].Existing == nil {
+			eventType = ResourceAdded
+		}
+		trailRecorder.Record(Event{
+			Type:        eventType,
+			ResourceRef: batch[i].Ref().String(),
+		})
+	}
+
+	return errs
+}
@@ -0,0 +1,198 @@
+package grizzly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RemoteCache stores the last known remote state of resources, so that it
+// can be used without reaching remote endpoints.
+type RemoteCache struct {
+	dir string
+}
+
+func NewRemoteCache(dir string) *RemoteCache {
+	return &RemoteCache{dir: dir}
+}
+
+func (cache *RemoteCache) path(kind string, uid string) string {
+	return filepath.Join(cache.dir, kind, url.PathEscape(uid)+".yaml")
+}
+
+// Put records the remote state of a resource
+func (cache *RemoteCache) Put(resource Resource) error {
+	content, err := yaml.Marshal(resource.Body)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(cache.path(resource.Kind(), resource.Name()), content, 0600)
+}
+
+// Delete forgets the remote state of a resource
+func (cache *RemoteCache) Delete(kind string, uid string) error {
+	err := os.Remove(cache.path(kind, uid))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Get returns the recorded remote state of a resource
+func (cache *RemoteCache) Get(kind string, uid string) (*Resource, error) {
+	content, err := os.ReadFile(cache.path(kind, uid))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]any{}
+	if err := yaml.Unmarshal(content, &body); err != nil {
+		return nil, ParseError{File: cache.path(kind, uid), Err: err}
+	}
+
+	return ResourceFromMap(body)
+}
+
+// List returns the UIDs of the resources of the given kind known to the cache
+func (cache *RemoteCache) List(kind string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(cache.dir, kind))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name, found := strings.CutSuffix(entry.Name(), ".yaml")
+		if entry.IsDir() || !found {
+			continue
+		}
+
+		uid, err := url.PathUnescape(name)
+		if err != nil {
+			continue
+		}
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+
+	return uids, nil
+}
+
+// ErrOffline is returned when trying to modify remote resources while offline
+var ErrOffline = errors.New("remote resources can not be modified while offline")
+
+// cachingHandler records the remote resources it retrieves in a cache, or
+// only relies on that cache when offline.
+type cachingHandler struct {
+	Handler
+	cache   *RemoteCache
+	offline bool
+	// ctx collects the warnings about the cache
+	ctx context.Context
+}
+
+func (h *cachingHandler) GetByUID(uid string) (*Resource, error) {
+	if h.offline {
+		return h.cache.Get(h.Kind(), uid)
+	}
+
+	return h.record(uid, func() (*Resource, error) {
+		return h.Handler.GetByUID(uid)
+	})
+}
+
+func (h *cachingHandler) GetRemote(resource Resource) (*Resource, error) {
+	if h.offline {
+		return h.cache.Get(h.Kind(), resource.Name())
+	}
+
+	return h.record(resource.Name(), func() (*Resource, error) {
+		return h.Handler.GetRemote(resource)
+	})
+}
+
+func (h *cachingHandler) ListRemote() ([]string, error) {
+	if h.offline {
+		return h.cache.List(h.Kind())
+	}
+
+	return h.Handler.ListRemote()
+}
+
+func (h *cachingHandler) Add(resource Resource) error {
+	if h.offline {
+		return ErrOffline
+	}
+
+	return h.Handler.Add(resource)
+}
+
+func (h *cachingHandler) Update(existing, resource Resource) error {
+	if h.offline {
+		return ErrOffline
+	}
+
+	return h.Handler.Update(existing, resource)
+}
+
+func (h *cachingHandler) record(uid string, get func() (*Resource, error)) (*Resource, error) {
+	resource, err := get()
+
+	var cacheErr error
+	switch {
+	case errors.Is(err, ErrNotFound):
+		cacheErr = h.cache.Delete(h.Kind(), uid)
+	case err == nil:
+		cacheErr = h.cache.Put(*resource)
+	}
+	if cacheErr != nil {
+		RecordWarning(h.ctx, NewResourceWarning(NewResourceRef(h.Kind(), uid), fmt.Errorf("could not update the cached state: %w", cacheErr)))
+	}
+
+	return resource, err
+}
+
+// WithRemoteCache returns a registry whose handlers record the remote
+// resources they retrieve in the given cache. When offline, remote resources
+// are read from the cache only.
+func (r *Registry) WithRemoteCache(cache *RemoteCache, offline bool) Registry {
+	registry := Registry{
+		Providers:    r.Providers,
+		Handlers:     make(map[string]Handler, len(r.Handlers)),
+		HandlerOrder: make([]Handler, 0, len(r.HandlerOrder)),
+		ctx:          r.ctx,
+	}
+
+	for _, handler := range r.HandlerOrder {
+		cached := &cachingHandler{Handler: handler, cache: cache, offline: offline, ctx: r.Context()}
+		registry.Handlers[handler.Kind()] = cached
+		registry.HandlerOrder = append(registry.HandlerOrder, cached)
+	}
+
+	return registry
+}
+
+// DefaultRemoteCacheDir returns the directory caching the remote state of
+// the resources of the given context.
+func DefaultRemoteCacheDir(context string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not locate the cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, "grizzly", "remote", context), nil
+}
@@ -0,0 +1,47 @@
+package grizzly_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteCache(t *testing.T) {
+	cache := grizzly.NewRemoteCache(t.TempDir())
+
+	folder := grizzlytest.NewFolder(t, "infra", "Infrastructure")
+	require.NoError(t, cache.Put(folder))
+
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	offline := registry.WithRemoteCache(cache, true)
+	handler, err := offline.GetHandler("DashboardFolder")
+	require.NoError(t, err)
+
+	t.Run("remote resources are read from the cache", func(t *testing.T) {
+		remote, err := handler.GetRemote(folder)
+		require.NoError(t, err)
+		require.Equal(t, "Infrastructure", remote.Spec()["title"])
+
+		uids, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.Equal(t, []string{"infra"}, uids)
+	})
+
+	t.Run("unknown resources are not found", func(t *testing.T) {
+		_, err := handler.GetByUID("unknown")
+		require.ErrorIs(t, err, grizzly.ErrNotFound)
+	})
+
+	t.Run("remote resources can not be modified", func(t *testing.T) {
+		require.ErrorIs(t, handler.Add(folder), grizzly.ErrOffline)
+	})
+
+	t.Run("forgotten resources are not found", func(t *testing.T) {
+		require.NoError(t, cache.Delete("DashboardFolder", "infra"))
+		_, err := handler.GetRemote(folder)
+		require.ErrorIs(t, err, grizzly.ErrNotFound)
+	})
+}
@@ -0,0 +1,104 @@
+package grizzly
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/grizzly/notifier"
+)
+
+// CanaryGate checks, once the canary resources soaked, that the rollout can
+// proceed with the remaining resources
+type CanaryGate struct {
+	// Name describes what is checked, e.g. `alert rule high-latency`
+	Name  string
+	Check func() error
+}
+
+// Canary describes a rollout applying some resources first, then the others
+// once these soaked and the gates passed
+type Canary struct {
+	// Selector selects the canary resources: either the resources of a folder,
+	// as `folder=<uid>`, or the resources matching a target, e.g.
+	// `Dashboard/canary-*`
+	Selector string
+	Soak     time.Duration
+	Gates    []CanaryGate
+}
+
+// SplitCanary splits resources into the ones selected by a canary selector
+// and the others. Selecting none of the resources is an error, as the rollout
+// wouldn't be guarded.
+func SplitCanary(registry Registry, resources Resources, selector string) (Resources, Resources, error) {
+	var selected func(resource Resource) bool
+	if folder, ok := strings.CutPrefix(selector, "folder="); ok {
+		if folder == "" {
+			return Resources{}, Resources{}, fmt.Errorf("invalid canary selector %q: expected folder=<uid>", selector)
+		}
+		selected = func(resource Resource) bool {
+			if resource.Kind() == FolderKind {
+				return resource.Name() == folder
+			}
+			return resource.GetMetadata("folder") == folder
+		}
+	} else {
+		selected = func(resource Resource) bool {
+			return registry.ResourceMatchesTarget(resource.Kind(), resource.Name(), []string{selector})
+		}
+	}
+
+	canary := resources.Filter(selected)
+	rest := resources.Filter(func(resource Resource) bool { return !selected(resource) })
+	if canary.Len() == 0 {
+		return Resources{}, Resources{}, fmt.Errorf("canary selector %q matches none of the %s", selector, Pluraliser(resources.Len(), "resource"))
+	}
+
+	return canary, rest, nil
+}
+
+// ApplyCanary applies the canary resources, waits for them to soak, then
+// applies the remaining resources unless the canary failed to apply or one of
+// the gates failed. The resources left out are reported as skipped.
+func ApplyCanary(registry Registry, resources Resources, canary Canary, continueOnError bool, eventsRecorder eventsRecorder, opts ...ApplyOpt) error {
+	canaryResources, rest, err := SplitCanary(registry, resources, canary.Selector)
+	if err != nil {
+		return err
+	}
+
+	notifier.Info(nil, fmt.Sprintf("Applying %s to the canary first", Pluraliser(canaryResources.Len(), "resource")))
+	if err := Apply(registry, canaryResources, continueOnError, eventsRecorder, opts...); err != nil {
+		return abortRollout(rest, eventsRecorder, "the canary failed to apply", err)
+	}
+
+	if rest.Len() == 0 {
+		return nil
+	}
+	if canary.Soak > 0 {
+		notifier.Info(nil, fmt.Sprintf("Soaking the canary for %s", canary.Soak))
+		time.Sleep(canary.Soak)
+	}
+	for _, gate := range canary.Gates {
+		if err := gate.Check(); err != nil {
+			return abortRollout(rest, eventsRecorder, fmt.Sprintf("canary gate on %s failed", gate.Name), err)
+		}
+		notifier.Info(nil, fmt.Sprintf("Canary gate on %s passed", gate.Name))
+	}
+
+	notifier.Info(nil, fmt.Sprintf("Applying the remaining %s", Pluraliser(rest.Len(), "resource")))
+	return Apply(registry, rest, continueOnError, eventsRecorder, opts...)
+}
+
+// abortRollout reports the resources left out of an aborted rollout as
+// skipped
+func abortRollout(rest Resources, eventsRecorder eventsRecorder, reason string, err error) error {
+	for _, resource := range rest.AsList() {
+		eventsRecorder.Record(Event{
+			Type:        ResourceSkipped,
+			ResourceRef: resource.Ref().String(),
+			Details:     reason,
+		})
+	}
+
+	return fmt.Errorf("%s, %s not applied: %w", reason, Pluraliser(rest.Len(), "resource"), err)
+}
@@ -0,0 +1,78 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCanary(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	provider := grafana.NewProvider(&server.Context().Grafana)
+	registry := grizzly.NewRegistry([]grizzly.Provider{provider})
+
+	resources := grizzly.NewResources()
+	for _, folder := range []string{"canary", "prod"} {
+		resource := grizzlytest.NewResource(t, "DashboardFolder", folder, map[string]any{"title": folder})
+		resources.Add(resource)
+
+		dashboard := grizzlytest.NewResource(t, "Dashboard", folder+"-cpu", map[string]any{"uid": folder + "-cpu", "title": "CPU"})
+		dashboard.SetMetadata("folder", folder)
+		resources.Add(dashboard)
+	}
+	resources = registry.Sort(resources)
+
+	apply := func(canary grizzly.Canary) (string, error) {
+		out := &bytes.Buffer{}
+		err := grizzly.ApplyCanary(registry, resources, canary, false, grizzly.NewWriterRecorder(out, grizzly.EventToPlainText))
+		return out.String(), err
+	}
+
+	t.Run("resources are selected by folder or target", func(t *testing.T) {
+		canary, rest, err := grizzly.SplitCanary(registry, resources, "folder=canary")
+		require.NoError(t, err)
+		require.Equal(t, 2, canary.Len())
+		require.Equal(t, 2, rest.Len())
+
+		canary, _, err = grizzly.SplitCanary(registry, resources, "Dashboard/*-cpu")
+		require.NoError(t, err)
+		require.Equal(t, 2, canary.Len())
+
+		_, _, err = grizzly.SplitCanary(registry, resources, "folder=staging")
+		require.ErrorContains(t, err, `canary selector "folder=staging" matches none of the 4 resources`)
+	})
+
+	t.Run("the rollout stops when a gate fails", func(t *testing.T) {
+		server.SetFiringAlerts("error-rate", 2)
+
+		out, err := apply(grizzly.Canary{
+			Selector: "folder=canary",
+			Gates:    []grizzly.CanaryGate{grafana.AlertRuleGate(provider, "error-rate")},
+		})
+		require.ErrorContains(t, err, "canary gate on alert rule error-rate failed, 2 resources not applied: alert rule error-rate is firing 2 alerts")
+		require.Contains(t, out, "Dashboard.prod-cpu skipped: canary gate on alert rule error-rate failed")
+
+		_, _, found := server.Dashboard("canary-cpu")
+		require.True(t, found)
+		_, _, found = server.Dashboard("prod-cpu")
+		require.False(t, found)
+	})
+
+	t.Run("the rollout proceeds once gates pass", func(t *testing.T) {
+		server.SetFiringAlerts("error-rate", 0)
+
+		_, err := apply(grizzly.Canary{
+			Selector: "folder=canary",
+			Gates:    []grizzly.CanaryGate{grafana.AlertRuleGate(provider, "error-rate")},
+		})
+		require.NoError(t, err)
+
+		_, folder, found := server.Dashboard("prod-cpu")
+		require.True(t, found)
+		require.Equal(t, "prod", folder)
+	})
+}
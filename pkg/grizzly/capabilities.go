@@ -0,0 +1,147 @@
+package grizzly
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Operation is an operation on remote resources, which handlers may or may
+// not support
+type Operation string
+
+const (
+	// OperationGet retrieves remote resources, e.g. with `grr get`
+	OperationGet Operation = "get"
+	// OperationList lists remote resources, e.g. with `grr list -r`
+	OperationList Operation = "list"
+	// OperationApply creates and updates remote resources
+	OperationApply Operation = "apply"
+	// OperationDelete deletes remote resources, e.g. to recreate them with
+	// the recreate apply strategy
+	OperationDelete Operation = "delete"
+	// OperationHistory tracks the versions of remote resources, so that
+	// remote changes aren't overwritten
+	OperationHistory Operation = "history"
+	// OperationPreview renders resources as images, with `grr preview`
+	OperationPreview Operation = "preview"
+	// OperationSnapshot pushes resources as snapshots, with `grr snapshot`
+	OperationSnapshot Operation = "snapshot"
+)
+
+// Capabilities describes what Grizzly can do with a kind of resources, and
+// the provider the kind is bound to
+type Capabilities struct {
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string `json:"kind" yaml:"kind"`
+	Provider   string `json:"provider" yaml:"provider"`
+	// Configured tells whether the provider is configured in the context of
+	// the registry. Reason explains why it isn't.
+	Configured bool   `json:"configured" yaml:"configured"`
+	Reason     string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	// Targeted tells whether the kind matches the targets, if any
+	Targeted   bool        `json:"targeted" yaml:"targeted"`
+	Operations []Operation `json:"operations" yaml:"operations"`
+}
+
+// CapabilitiesReport lists the capabilities of the kinds of resources of a
+// registry, bound to a context
+type CapabilitiesReport struct {
+	Context string         `json:"context" yaml:"context"`
+	Kinds   []Capabilities `json:"kinds" yaml:"kinds"`
+}
+
+// Capabilities returns the capabilities of the kinds of resources of the
+// registry, in the order of their providers, without reaching them
+func (r *Registry) Capabilities(context string, targets []string) CapabilitiesReport {
+	report := CapabilitiesReport{Context: context, Kinds: []Capabilities{}}
+	for _, provider := range r.Providers {
+		configured, reason := true, ""
+		if err := provider.Validate(); err != nil {
+			configured, reason = false, err.Error()
+		}
+
+		for _, handler := range provider.GetHandlers() {
+			report.Kinds = append(report.Kinds, Capabilities{
+				APIVersion: provider.APIVersion(),
+				Kind:       handler.Kind(),
+				Provider:   provider.Name(),
+				Configured: configured,
+				Reason:     reason,
+				Targeted:   r.HandlerMatchesTarget(handler, targets),
+				Operations: HandlerOperations(handler),
+			})
+		}
+	}
+	return report
+}
+
+// Format renders the report as a table, or as JSON or YAML
+func (report CapabilitiesReport) Format(format string) ([]byte, error) {
+	switch format {
+	case formatJSON:
+		return json.MarshalIndent(report, "", "  ")
+	case formatYAML:
+		return yaml.Marshal(report)
+	case formatDefault:
+		return report.table()
+	}
+
+	return nil, fmt.Errorf("unknown format %s", format)
+}
+
+func (report CapabilitiesReport) table() ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "Context: %s\n\n", report.Context)
+
+	w := tabwriter.NewWriter(&out, 0, 0, 4, ' ', 0)
+	fmt.Fprintf(w, "API VERSION\tKIND\tPROVIDER\tOPERATIONS\tSTATUS\n")
+	for _, kind := range report.Kinds {
+		operations := make([]string, len(kind.Operations))
+		for i, operation := range kind.Operations {
+			operations[i] = string(operation)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", kind.APIVersion, kind.Kind, kind.Provider, strings.Join(operations, ","), kind.status())
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n")), nil
+}
+
+// status sums up whether the kind can be managed in the context
+func (capabilities Capabilities) status() string {
+	switch {
+	case !capabilities.Configured:
+		return "not configured: " + capabilities.Reason
+	case !capabilities.Targeted:
+		return "not targeted"
+	}
+	return "ready"
+}
+
+// HandlerOperations returns the operations supported by a handler. Every
+// handler retrieves, lists and applies resources, while the other
+// operations depend on the interfaces it implements.
+func HandlerOperations(handler Handler) []Operation {
+	handler = unwrapHandler(handler)
+	operations := []Operation{OperationGet, OperationList, OperationApply}
+	if _, ok := handler.(DeleteHandler); ok {
+		operations = append(operations, OperationDelete)
+	}
+	if _, ok := handler.(VersionedHandler); ok {
+		operations = append(operations, OperationHistory)
+	}
+	if _, ok := handler.(PreviewHandler); ok {
+		operations = append(operations, OperationPreview)
+	}
+	if _, ok := handler.(SnapshotHandler); ok {
+		operations = append(operations, OperationSnapshot)
+	}
+	return operations
+}
@@ -0,0 +1,52 @@
+package grizzly_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/grafana/grizzly/pkg/mimir"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := grizzly.NewRegistry([]grizzly.Provider{
+		grafana.NewProvider(&server.Context().Grafana),
+		mimir.NewProvider(&config.MimirConfig{}),
+	})
+	// capabilities are the same through decorated registries
+	cached := registry.WithRemoteCache(grizzly.NewRemoteCache(t.TempDir()), false)
+
+	report := cached.Capabilities("production", []string{"Dashboard", "PrometheusRuleGroup/*"})
+	require.Equal(t, "production", report.Context)
+	kinds := map[string]grizzly.Capabilities{}
+	for _, kind := range report.Kinds {
+		kinds[kind.Kind] = kind
+	}
+
+	dashboard := kinds["Dashboard"]
+	require.Equal(t, "Grafana", dashboard.Provider)
+	require.True(t, dashboard.Configured)
+	require.True(t, dashboard.Targeted)
+	require.Equal(t, []grizzly.Operation{"get", "list", "apply", "delete", "history", "preview", "snapshot"}, dashboard.Operations)
+
+	require.False(t, kinds["DashboardFolder"].Targeted)
+	require.Equal(t, []grizzly.Operation{"get", "list", "apply"}, kinds["DashboardFolder"].Operations)
+
+	rules := kinds["PrometheusRuleGroup"]
+	require.False(t, rules.Configured)
+	require.Equal(t, "mimir address is not set", rules.Reason)
+
+	table, err := report.Format("default")
+	require.NoError(t, err)
+	require.Contains(t, string(table), "Context: production\n")
+	require.Regexp(t, `Dashboard\s+Grafana\s+get,list,apply,delete,history,preview,snapshot\s+ready\n`, string(table))
+	require.Regexp(t, `DashboardFolder\s+Grafana\s+get,list,apply\s+not targeted\n`, string(table))
+	require.Regexp(t, `PrometheusRuleGroup\s+Mimir\s+get,list,apply\s+not configured: mimir address is not set`, string(table))
+
+	_, err = report.Format("xml")
+	require.ErrorContains(t, err, "unknown format xml")
+}
@@ -0,0 +1,112 @@
+package grizzly
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// AppliedChecksumsFile is the file of a remote cache directory recording
+// the checksums of the resources applied
+const AppliedChecksumsFile = "applied-checksums.json"
+
+// AppliedChecksums records the checksum of each resource last applied, so
+// that applying unchanged resources again can be skipped without reaching
+// remote endpoints. Changes made remotely since are not detected.
+type AppliedChecksums struct {
+	lock      sync.Mutex
+	path      string
+	checksums map[string]string
+	dirty     bool
+}
+
+// LoadAppliedChecksums reads the checksums recorded in a file. A missing
+// file results in no checksums.
+func LoadAppliedChecksums(path string) (*AppliedChecksums, error) {
+	checksums := &AppliedChecksums{
+		path:      path,
+		checksums: map[string]string{},
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checksums, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &checksums.checksums); err != nil {
+		return nil, ParseError{File: path, Err: err}
+	}
+	if checksums.checksums == nil {
+		checksums.checksums = map[string]string{}
+	}
+
+	return checksums, nil
+}
+
+// ResourceChecksum returns the checksum of the normalized content of a
+// resource, its envelope included as it tells where the resource goes
+func ResourceChecksum(resource Resource) (string, error) {
+	content, err := resource.YAML()
+	if err != nil {
+		return "", err
+	}
+
+	return checksumOf([]byte(content)), nil
+}
+
+// Unchanged returns whether a resource was last applied with the same
+// content
+func (c *AppliedChecksums) Unchanged(resource Resource) bool {
+	checksum, err := ResourceChecksum(resource)
+	if err != nil {
+		return false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	recorded, ok := c.checksums[resource.Ref().String()]
+	return ok && recorded == checksum
+}
+
+// Record records the checksum of an applied resource
+func (c *AppliedChecksums) Record(resource Resource) {
+	checksum, err := ResourceChecksum(resource)
+	if err != nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.checksums[resource.Ref().String()] == checksum {
+		return
+	}
+	c.checksums[resource.Ref().String()] = checksum
+	c.dirty = true
+}
+
+// Save writes the checksums back to disk, if they were modified
+func (c *AppliedChecksums) Save() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(c.checksums, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(c.path, content, 0600); err != nil {
+		return err
+	}
+	c.dirty = false
+
+	return nil
+}
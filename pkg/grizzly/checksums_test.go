@@ -0,0 +1,44 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySkipUnchanged(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+	path := filepath.Join(t.TempDir(), grizzly.AppliedChecksumsFile)
+
+	apply := func(t *testing.T, resource grizzly.Resource) string {
+		t.Helper()
+		checksums, err := grizzly.LoadAppliedChecksums(path)
+		require.NoError(t, err)
+
+		out := &bytes.Buffer{}
+		require.NoError(t, grizzly.Apply(registry, grizzly.NewResources(resource), false, grizzly.NewWriterRecorder(out, grizzly.EventToPlainText), grizzly.ApplySkipUnchanged(checksums)))
+		require.NoError(t, checksums.Save())
+		return out.String()
+	}
+	remoteTitle := func(t *testing.T) any {
+		t.Helper()
+		remote, _, found := server.Dashboard("nodes")
+		require.True(t, found)
+		return remote["title"]
+	}
+
+	require.Contains(t, apply(t, grizzlytest.NewDashboard(t, "nodes", "Nodes")), "Dashboard.nodes added")
+
+	// remote changes are left as is, as Grafana isn't reached
+	server.EditDashboard("nodes", "admin", map[string]any{"title": "Edited"})
+	require.Contains(t, apply(t, grizzlytest.NewDashboard(t, "nodes", "Nodes")), "Dashboard.nodes unchanged: same content as last applied")
+	require.Equal(t, "Edited", remoteTitle(t))
+
+	require.Contains(t, apply(t, grizzlytest.NewDashboard(t, "nodes", "Nodes v2")), "Dashboard.nodes updated")
+	require.Equal(t, "Nodes v2", remoteTitle(t))
+}
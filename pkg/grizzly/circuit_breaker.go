@@ -0,0 +1,95 @@
+package grizzly
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrCircuitOpen signals resources skipped because the endpoint of their
+// kind failed repeatedly
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// circuitBreakers stop reaching the endpoint of a kind of resources after a
+// number of consecutive failures, so that an endpoint being down doesn't
+// stall the whole run: the remaining resources of the kind are skipped, while
+// the other kinds proceed. Only failures of the endpoint count, not the ones
+// specific to a resource, such as validation errors.
+type circuitBreakers struct {
+	threshold int
+	failures  map[string]int
+	skipped   map[string]int
+}
+
+func newCircuitBreakers(threshold int) *circuitBreakers {
+	return &circuitBreakers{
+		threshold: threshold,
+		failures:  map[string]int{},
+		skipped:   map[string]int{},
+	}
+}
+
+func (b *circuitBreakers) enabled() bool {
+	return b.threshold > 0
+}
+
+// open tells whether the resources of a kind are skipped
+func (b *circuitBreakers) open(kind string) bool {
+	return b.enabled() && b.failures[kind] >= b.threshold
+}
+
+// governs tells whether a failure counts towards opening the circuit
+// breaker of its kind, instead of stopping the run
+func (b *circuitBreakers) governs(err error) bool {
+	if !b.enabled() || err == nil {
+		return false
+	}
+	switch ClassifyError(err) {
+	case ErrorClassValidation, ErrorClassConflict, ErrorClassNotFound:
+		return false
+	}
+	return !errors.Is(err, ErrRunTimeout)
+}
+
+// record records the outcome of processing a resource of a kind
+func (b *circuitBreakers) record(kind string, err error) {
+	if !b.governs(err) {
+		if err == nil {
+			b.failures[kind] = 0
+		}
+		return
+	}
+
+	b.failures[kind]++
+	if b.failures[kind] == b.threshold {
+		log.Warnf("%s of %s failed in a row: skipping the remaining ones", Pluraliser(b.threshold, "resource"), kind)
+	}
+}
+
+// skip reports a resource skipped as its circuit breaker is open
+func (b *circuitBreakers) skip(resource Resource, eventsRecorder eventsRecorder) {
+	b.skipped[resource.Kind()]++
+	eventsRecorder.Record(Event{
+		Type:        ResourceSkipped,
+		ResourceRef: resource.Ref().String(),
+		Details:     fmt.Sprintf("%s after %d consecutive failures of %s", ErrCircuitOpen, b.threshold, resource.Kind()),
+	})
+}
+
+// err summarizes the resources skipped, per kind
+func (b *circuitBreakers) err() error {
+	if len(b.skipped) == 0 {
+		return nil
+	}
+
+	kinds := make([]string, 0, len(b.skipped))
+	for kind, count := range b.skipped {
+		kinds = append(kinds, fmt.Sprintf("%s of %s", Pluraliser(count, "resource"), kind))
+	}
+	sort.Strings(kinds)
+
+	return fmt.Errorf("%w: %s skipped", ErrCircuitOpen, strings.Join(kinds, ", "))
+}
@@ -0,0 +1,69 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/grafana/grizzly/pkg/syntheticmonitoring"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCircuitBreaker(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	server.AddProbe("Paris")
+	context := server.Context()
+	// Grafana is down
+	down := httptest.NewServer(nil)
+	down.Close()
+	context.Grafana.URL = down.URL
+
+	registry := grizzly.NewRegistry([]grizzly.Provider{
+		grafana.NewProvider(&context.Grafana),
+		syntheticmonitoring.NewProvider(&context.SyntheticMonitoring),
+	})
+
+	resources := grizzly.NewResources()
+	for _, uid := range []string{"cpu", "disk", "memory"} {
+		dashboard := grizzlytest.NewDashboard(t, uid, uid)
+		resources.Add(dashboard)
+	}
+	check := grizzlytest.NewResource(t, "SyntheticMonitoringCheck", "http.web", map[string]any{
+		"job":       "web",
+		"target":    "https://web.example.com",
+		"frequency": 60000,
+		"timeout":   3000,
+		"probes":    []any{"Paris"},
+		"settings":  map[string]any{"http": map[string]any{}},
+	})
+	check.SetMetadata("type", "http")
+	resources.Add(check)
+
+	apply := func(opts ...grizzly.ApplyOpt) (string, error) {
+		out := &bytes.Buffer{}
+		err := grizzly.Apply(registry, registry.Sort(resources), false, grizzly.NewWriterRecorder(out, grizzly.EventToPlainText), opts...)
+		return out.String(), err
+	}
+
+	t.Run("the kind is skipped while others proceed", func(t *testing.T) {
+		out, err := apply(grizzly.ApplyCircuitBreaker(2))
+		require.ErrorIs(t, err, grizzly.ErrCircuitOpen)
+		require.ErrorContains(t, err, "1 resource of Dashboard skipped")
+		require.Contains(t, out, "Dashboard.cpu failed")
+		require.Contains(t, out, "Dashboard.disk failed")
+		require.Contains(t, out, "Dashboard.memory skipped: circuit breaker open after 2 consecutive failures of Dashboard")
+
+		_, found := server.Check("web")
+		require.True(t, found)
+	})
+
+	t.Run("without circuit breaker the apply stops", func(t *testing.T) {
+		out, err := apply()
+		require.Error(t, err)
+		require.NotErrorIs(t, err, grizzly.ErrCircuitOpen)
+		require.NotContains(t, out, "Dashboard.disk")
+	})
+}
@@ -0,0 +1,72 @@
+package grizzly
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	cueerrors "cuelang.org/go/cue/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const formatCUE = "cue"
+
+// CueParser parses resources authored in CUE. Files are evaluated on their
+// own, and must be concrete: the constraints they declare, e.g. by unifying
+// resources with a schema definition, are validated while evaluating them.
+// The resulting value is parsed like the output of Jsonnet files.
+type CueParser struct {
+	registry Registry
+	logger   *log.Entry
+}
+
+func NewCueParser(registry Registry) *CueParser {
+	return &CueParser{
+		registry: registry,
+		logger:   log.WithField("parser", "cue"),
+	}
+}
+
+func (parser *CueParser) Accept(file string) bool {
+	return filepath.Ext(file) == ".cue"
+}
+
+// Parse evaluates a CUE file and parses it into resources
+func (parser *CueParser) Parse(file string, options ParserOptions) (Resources, error) {
+	parser.logger.WithField("file", file).Debug("Parsing file")
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return Resources{}, err
+	}
+
+	value := cuecontext.New().CompileBytes(content, cue.Filename(file))
+	if err := value.Validate(cue.Concrete(true)); err != nil {
+		return Resources{}, cueError(err)
+	}
+
+	output, err := value.MarshalJSON()
+	if err != nil {
+		return Resources{}, cueError(err)
+	}
+	var data any
+	if err := json.Unmarshal(output, &data); err != nil {
+		return Resources{}, err
+	}
+
+	source := Source{
+		Format:     formatCUE,
+		Path:       file,
+		Rewritable: false,
+	}
+
+	return parseAny(parser.registry, data, options.DefaultResourceKind, options.DefaultFolderUID, source)
+}
+
+// cueError reports every error of an evaluation, along with their position
+func cueError(err error) error {
+	return fmt.Errorf("cue: %s", cueerrors.Details(err, nil))
+}
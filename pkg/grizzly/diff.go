@@ -0,0 +1,192 @@
+package grizzly
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// DiffEngine compares a local resource with its remote counterpart, both
+// unprepared
+type DiffEngine interface {
+	// Diff returns the differences between the remote and the local
+	// resources, as displayed to users. It is empty when they're equivalent.
+	Diff(registry Registry, local Resource, remote Resource, outputFormat string, onlySpec bool) (string, error)
+}
+
+// DiffHandler describes a handler providing its own diff engine, e.g. to
+// compare resources semantically rather than textually
+type DiffHandler interface {
+	DiffEngine() DiffEngine
+}
+
+var (
+	diffEnginesLock sync.Mutex
+	diffEngines     = map[string]DiffEngine{}
+)
+
+// RegisterDiffEngine sets the diff engine used to compare the resources of a
+// kind, taking precedence over the one provided by its handler, if any
+func RegisterDiffEngine(kind string, engine DiffEngine) {
+	diffEnginesLock.Lock()
+	defer diffEnginesLock.Unlock()
+
+	diffEngines[kind] = engine
+}
+
+// GetDiffEngine returns the diff engine of a kind: the registered one, else
+// the one provided by the handler, else a TextDiff
+func GetDiffEngine(handler Handler) DiffEngine {
+	diffEnginesLock.Lock()
+	engine, found := diffEngines[handler.Kind()]
+	diffEnginesLock.Unlock()
+
+	if found {
+		return engine
+	}
+	if diffHandler, ok := unwrapHandler(handler).(DiffHandler); ok {
+		return diffHandler.DiffEngine()
+	}
+
+	return TextDiff{}
+}
+
+// TextDiff compares resources line by line, as formatted for output
+type TextDiff struct{}
+
+func (TextDiff) Diff(registry Registry, local Resource, remote Resource, outputFormat string, onlySpec bool) (string, error) {
+	return unifiedDiff(registry, remote, local, "Remote", "Local", outputFormat, onlySpec)
+}
+
+// unifiedDiff returns the differences between two versions of a resource,
+// line by line, empty when there are none
+func unifiedDiff(registry Registry, from Resource, to Resource, fromName string, toName string, outputFormat string, onlySpec bool) (string, error) {
+	toRepresentation, _, _, err := Format(registry, "", &to, outputFormat, onlySpec)
+	if err != nil {
+		return "", err
+	}
+	fromRepresentation, _, _, err := Format(registry, "", &from, outputFormat, onlySpec)
+	if err != nil {
+		return "", err
+	}
+
+	if string(toRepresentation) == string(fromRepresentation) {
+		return "", nil
+	}
+
+	diff := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(fromRepresentation)),
+		B:        difflib.SplitLines(string(toRepresentation)),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	}
+
+	return difflib.GetUnifiedDiffString(diff)
+}
+
+// KeyedListDiff compares resources whose spec holds a list of objects
+// identified by a key, e.g. the rules of an alert rule group identified by
+// their UID. Items are matched by key rather than by index, so that
+// reordering them isn't a difference.
+type KeyedListDiff struct {
+	// Field is the field of the spec holding the list
+	Field string
+	// Key is the field identifying items
+	Key string
+}
+
+func (engine KeyedListDiff) Diff(registry Registry, local Resource, remote Resource, outputFormat string, onlySpec bool) (string, error) {
+	localItems, localOK := local.Spec()[engine.Field].([]any)
+	remoteItems, remoteOK := remote.Spec()[engine.Field].([]any)
+	if localOK && remoteOK {
+		remote = withSpecValue(remote, engine.Field, engine.matchOrder(localItems, remoteItems))
+	}
+
+	return TextDiff{}.Diff(registry, local, remote, outputFormat, onlySpec)
+}
+
+// matchOrder sorts remote items in the order of the local items with the
+// same key. Remote items without local counterpart come last, in their
+// original order.
+func (engine KeyedListDiff) matchOrder(localItems []any, remoteItems []any) []any {
+	remoteByKey := map[string]any{}
+	for _, item := range remoteItems {
+		if key, ok := engine.key(item); ok {
+			remoteByKey[key] = item
+		}
+	}
+
+	ordered := make([]any, 0, len(remoteItems))
+	matched := map[string]bool{}
+	for _, item := range localItems {
+		key, ok := engine.key(item)
+		if !ok || matched[key] {
+			continue
+		}
+		if remoteItem, found := remoteByKey[key]; found {
+			ordered = append(ordered, remoteItem)
+			matched[key] = true
+		}
+	}
+	for _, item := range remoteItems {
+		if key, ok := engine.key(item); ok && matched[key] {
+			continue
+		}
+		ordered = append(ordered, item)
+	}
+
+	return ordered
+}
+
+func (engine KeyedListDiff) key(item any) (string, bool) {
+	object, ok := item.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	value, ok := object[engine.Key]
+	if !ok || value == nil || value == "" {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+// withSpecValue returns a copy of a resource with a field of its spec set,
+// leaving the original resource untouched
+func withSpecValue(resource Resource, field string, value any) Resource {
+	body := make(map[string]any, len(resource.Body))
+	for key, bodyValue := range resource.Body {
+		body[key] = bodyValue
+	}
+	spec := make(map[string]any, len(resource.Spec()))
+	for key, specValue := range resource.Spec() {
+		spec[key] = specValue
+	}
+	spec[field] = value
+	body["spec"] = spec
+
+	return Resource{Body: body, Source: resource.Source}
+}
+
+// withoutSpecKeys returns a copy of a resource without some fields of its
+// spec, leaving the resource as is
+func withoutSpecKeys(resource Resource, fields ...string) Resource {
+	if len(fields) == 0 {
+		return resource
+	}
+	body := make(map[string]any, len(resource.Body))
+	for key, bodyValue := range resource.Body {
+		body[key] = bodyValue
+	}
+	spec := make(map[string]any, len(resource.Spec()))
+	for key, specValue := range resource.Spec() {
+		spec[key] = specValue
+	}
+	for _, field := range fields {
+		delete(spec, field)
+	}
+	body["spec"] = spec
+
+	return Resource{Body: body, Source: resource.Source}
+}
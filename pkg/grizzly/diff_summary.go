@@ -0,0 +1,70 @@
+package grizzly
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffSummaryLabels are the change types listed by a DiffSummary, in order
+var diffSummaryLabels = []struct {
+	eventType EventType
+	label     string
+}{
+	{ResourceChanged, "changed"},
+	{ResourceNotFound, "new"},
+	{ResourceRemoved, "removed"},
+	{ResourceFailure, "failed"},
+	{ResourceSkipped, "skipped"},
+}
+
+// DiffSummary records the events of a diff, and summarises them per kind and
+// change type, e.g. `Dashboard: 3 changed, 1 new`
+type DiffSummary struct {
+	counts map[string]map[EventType]int
+}
+
+func NewDiffSummary() *DiffSummary {
+	return &DiffSummary{counts: map[string]map[EventType]int{}}
+}
+
+func (summary *DiffSummary) Record(event Event) {
+	kind, _, _ := strings.Cut(event.ResourceRef, ".")
+
+	if summary.counts[kind] == nil {
+		summary.counts[kind] = map[EventType]int{}
+	}
+	summary.counts[kind][event.Type]++
+}
+
+// Count returns the number of resources of a kind with the given change type
+func (summary *DiffSummary) Count(kind string, eventType EventType) int {
+	return summary.counts[kind][eventType]
+}
+
+// String returns one line per kind, sorted by kind. Kinds without changes are
+// reported as unchanged.
+func (summary *DiffSummary) String() string {
+	kinds := make([]string, 0, len(summary.counts))
+	for kind := range summary.counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var lines []string
+	for _, kind := range kinds {
+		var parts []string
+		for _, change := range diffSummaryLabels {
+			if count := summary.counts[kind][change.eventType]; count > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", count, change.label))
+			}
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "unchanged")
+		}
+
+		lines = append(lines, fmt.Sprintf("%s: %s", kind, strings.Join(parts, ", ")))
+	}
+
+	return strings.Join(lines, "\n")
+}
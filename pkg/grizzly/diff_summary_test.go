@@ -0,0 +1,28 @@
+package grizzly_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSummary(t *testing.T) {
+	summary := grizzly.NewDiffSummary()
+	require.Equal(t, "", summary.String())
+
+	for _, event := range []grizzly.Event{
+		{Type: grizzly.ResourceChanged, ResourceRef: "Dashboard.nodes"},
+		{Type: grizzly.ResourceChanged, ResourceRef: "Dashboard.pods"},
+		{Type: grizzly.ResourceNotChanged, ResourceRef: "Dashboard.api"},
+		{Type: grizzly.ResourceNotFound, ResourceRef: "Dashboard.my.dotted.name"},
+		{Type: grizzly.ResourceNotChanged, ResourceRef: "AlertRuleGroup.infra.cpu"},
+		{Type: grizzly.ResourceFailure, ResourceRef: "Datasource.loki"},
+	} {
+		summary.Record(event)
+	}
+
+	require.Equal(t, 2, summary.Count("Dashboard", grizzly.ResourceChanged))
+	require.Equal(t, 1, summary.Count("Dashboard", grizzly.ResourceNotFound))
+	require.Equal(t, "AlertRuleGroup: unchanged\nDashboard: 2 changed, 1 new\nDatasource: 1 failed", summary.String())
+}
@@ -0,0 +1,59 @@
+package grizzly_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffEngines(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+
+	group := func(rules ...map[string]any) grizzly.Resource {
+		items := []any{}
+		for _, rule := range rules {
+			items = append(items, rule)
+		}
+		return grizzlytest.NewResource(t, "AlertRuleGroup", "infra.cpu", map[string]any{
+			"title": "cpu",
+			"rules": items,
+		})
+	}
+	high := map[string]any{"uid": "high", "title": "CPU high"}
+	low := map[string]any{"uid": "low", "title": "CPU low"}
+
+	handler, err := registry.GetHandler("AlertRuleGroup")
+	require.NoError(t, err)
+	engine := grizzly.GetDiffEngine(handler)
+	require.Equal(t, grizzly.KeyedListDiff{Field: "rules", Key: "uid"}, engine)
+
+	t.Run("rules are matched by UID", func(t *testing.T) {
+		remote := group(high, low)
+		diff, err := engine.Diff(registry, group(low, high), remote, "yaml", false)
+		require.NoError(t, err)
+		require.Empty(t, diff)
+
+		// the remote resource is left untouched
+		require.Equal(t, "high", remote.Spec()["rules"].([]any)[0].(map[string]any)["uid"])
+
+		diff, err = grizzly.TextDiff{}.Diff(registry, group(low, high), group(high, low), "yaml", false)
+		require.NoError(t, err)
+		require.NotEmpty(t, diff)
+	})
+
+	t.Run("changed rules are reported", func(t *testing.T) {
+		diff, err := engine.Diff(registry, group(low, map[string]any{"uid": "high", "title": "CPU very high"}), group(high, low), "yaml", false)
+		require.NoError(t, err)
+		require.Contains(t, diff, "-        - title: CPU high\n+        - title: CPU very high\n")
+	})
+
+	t.Run("registered engines take precedence", func(t *testing.T) {
+		grizzly.RegisterDiffEngine("AlertRuleGroup", grizzly.TextDiff{})
+		defer grizzly.RegisterDiffEngine("AlertRuleGroup", engine)
+
+		require.Equal(t, grizzly.TextDiff{}, grizzly.GetDiffEngine(handler))
+	})
+}
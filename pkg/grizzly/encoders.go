@@ -0,0 +1,113 @@
+package grizzly
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/format"
+	"github.com/google/go-jsonnet/formatter"
+	"gopkg.in/yaml.v3"
+)
+
+const formatJsonnet = "jsonnet"
+
+// Encoder writes resources in the format of a toolchain, when pulling or
+// exporting them
+type Encoder interface {
+	// Extension returns the extension of the files the encoder writes
+	Extension() string
+	// Encode returns the encoding of a resource, or of its spec
+	Encode(value map[string]any) ([]byte, error)
+}
+
+var encoders = map[string]Encoder{
+	formatYAML:    yamlEncoder{},
+	formatJSON:    jsonEncoder{},
+	formatJsonnet: jsonnetEncoder{},
+	formatCUE:     cueEncoder{},
+}
+
+// RegisterEncoder makes an encoder selectable as an output format. It isn't
+// safe to call concurrently with the encoding of resources.
+func RegisterEncoder(format string, encoder Encoder) {
+	encoders[format] = encoder
+}
+
+// GetEncoder returns the encoder of an output format, YAML when none is given
+func GetEncoder(format string) (Encoder, error) {
+	if format == "" {
+		format = formatYAML
+	}
+	encoder, ok := encoders[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %s, expected one of %s", format, strings.Join(EncoderFormats(), ", "))
+	}
+	return encoder, nil
+}
+
+// EncoderFormats returns the output formats encoders are registered for
+func EncoderFormats() []string {
+	formats := make([]string, 0, len(encoders))
+	for format := range encoders {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+type yamlEncoder struct{}
+
+func (yamlEncoder) Extension() string { return formatYAML }
+
+func (yamlEncoder) Encode(value map[string]any) ([]byte, error) {
+	return yaml.Marshal(value)
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) Extension() string { return formatJSON }
+
+func (jsonEncoder) Encode(value map[string]any) ([]byte, error) {
+	return json.MarshalIndent(value, "", "  ")
+}
+
+// jsonnetEncoder writes resources as jsonnetfmt formats them, so that they
+// can be edited and imported like any Jsonnet source
+type jsonnetEncoder struct{}
+
+func (jsonnetEncoder) Extension() string { return formatJsonnet }
+
+func (jsonnetEncoder) Encode(value map[string]any) ([]byte, error) {
+	// jsonnetfmt keeps objects and arrays on a single line when they are
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	output, err := formatter.Format("resource.jsonnet", string(content), formatter.DefaultOptions())
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+// cueEncoder writes resources as CUE files whose top-level fields are the
+// fields of the resource, as cue fmt formats them
+type cueEncoder struct{}
+
+func (cueEncoder) Extension() string { return formatCUE }
+
+func (cueEncoder) Encode(value map[string]any) ([]byte, error) {
+	encoded := cuecontext.New().Encode(value)
+	if err := encoded.Err(); err != nil {
+		return nil, cueError(err)
+	}
+	node := encoded.Syntax()
+	if object, ok := node.(*ast.StructLit); ok {
+		node = &ast.File{Decls: object.Elts}
+	}
+	return format.Node(node)
+}
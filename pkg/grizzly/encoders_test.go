@@ -0,0 +1,63 @@
+package grizzly_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoders(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	dashboard := grizzlytest.NewResource(t, "Dashboard", "nodes", map[string]any{
+		"uid":    "nodes",
+		"title":  "Nodes",
+		"tags":   []any{"infra"},
+		"panels": []any{map[string]any{"id": 1, "type": "timeseries", "fieldConfig": map[string]any{"defaults": map[string]any{}}}},
+	})
+	dashboard.SetMetadata("folder", "infra")
+
+	t.Run("resources are written in the native format of each toolchain", func(t *testing.T) {
+		content, filename, extension, err := grizzly.Format(registry, "out", &dashboard, "jsonnet", true)
+		require.NoError(t, err)
+		require.Equal(t, "jsonnet", extension)
+		require.Equal(t, filepath.Join("out", "dashboards", "infra", "dashboard-nodes.jsonnet"), filename)
+		require.Contains(t, string(content), "  title: 'Nodes',\n")
+
+		content, _, extension, err = grizzly.Format(registry, "", &dashboard, "cue", true)
+		require.NoError(t, err)
+		require.Equal(t, "cue", extension)
+		require.Contains(t, string(content), "title: \"Nodes\"\n")
+		require.Contains(t, string(content), "tags: [\"infra\"]\n")
+	})
+
+	t.Run("exported resources are parsed back", func(t *testing.T) {
+		for _, format := range grizzly.EncoderFormats() {
+			t.Run(format, func(t *testing.T) {
+				dir := t.TempDir()
+				require.NoError(t, grizzly.Export(registry, dir, grizzly.NewResources(dashboard), false, format))
+
+				file := filepath.Join(dir, "Dashboard", "nodes."+format)
+				_, err := os.Stat(file)
+				require.NoError(t, err)
+
+				resources, err := grizzly.DefaultParser(registry, nil, nil).Parse(file, grizzly.ParserOptions{})
+				require.NoError(t, err)
+				require.Equal(t, 1, resources.Len())
+				parsed := resources.First()
+				require.Equal(t, "Nodes", parsed.GetSpecValue("title"))
+				require.Equal(t, []any{"infra"}, parsed.GetSpecValue("tags"))
+				require.Equal(t, "infra", parsed.GetMetadata("folder"))
+			})
+		}
+	})
+
+	t.Run("unknown formats are reported", func(t *testing.T) {
+		_, _, _, err := grizzly.Format(registry, "", &dashboard, "toml", false)
+		require.ErrorContains(t, err, "unknown output format toml, expected one of cue, json, jsonnet, yaml")
+	})
+}
@@ -3,6 +3,8 @@ package grizzly
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
 )
 
 var (
@@ -40,19 +42,117 @@ func NewUnrecognisedFormatError(file string) UnrecognisedFormatError {
 	}
 }
 
-type Warning struct {
-	Err error
+// ErrorClass classifies errors, so that callers can decide how to react to
+// them, e.g. retrying rate-limited requests, or paging on auth failures.
+type ErrorClass string
+
+const (
+	ErrorClassUnknown    ErrorClass = "unknown"
+	ErrorClassAuth       ErrorClass = "auth"
+	ErrorClassNotFound   ErrorClass = "not-found"
+	ErrorClassConflict   ErrorClass = "conflict"
+	ErrorClassRateLimit  ErrorClass = "rate-limit"
+	ErrorClassValidation ErrorClass = "validation"
+	ErrorClassNetwork    ErrorClass = "network"
+)
+
+// Retryable tells whether errors of this class are transient, and the
+// operation may succeed if attempted again
+func (class ErrorClass) Retryable() bool {
+	return class == ErrorClassRateLimit || class == ErrorClassNetwork
 }
 
-func NewWarning(err error) Warning {
-	return Warning{err}
+// statusClasses classifies the HTTP status codes returned by remote endpoints
+var statusClasses = map[int]ErrorClass{
+	http.StatusBadRequest:          ErrorClassValidation,
+	http.StatusUnauthorized:        ErrorClassAuth,
+	http.StatusForbidden:           ErrorClassAuth,
+	http.StatusNotFound:            ErrorClassNotFound,
+	http.StatusConflict:            ErrorClassConflict,
+	http.StatusPreconditionFailed:  ErrorClassConflict,
+	http.StatusUnprocessableEntity: ErrorClassValidation,
+	http.StatusTooManyRequests:     ErrorClassRateLimit,
+	http.StatusBadGateway:          ErrorClassNetwork,
+	http.StatusServiceUnavailable:  ErrorClassNetwork,
+	http.StatusGatewayTimeout:      ErrorClassNetwork,
+}
+
+// ClassifyError returns the class of an error. HTTP errors are classified
+// by status code, whether they come from OpenAPI clients (implementing
+// `Code() int` or `IsCode(int) bool`) or are an HTTPStatusError.
+func ClassifyError(err error) ErrorClass {
+	var classified ClassifiedError
+	if errors.As(err, &classified) {
+		return classified.Class
+	}
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotFound):
+		return ErrorClassNotFound
+	case errors.Is(err, ErrResourceTimeout):
+		return ErrorClassNetwork
+	case errors.As(err, &ParseError{}), errors.As(err, &UnrecognisedFormatError{}):
+		return ErrorClassValidation
+	}
+
+	var coder interface{ Code() int }
+	if errors.As(err, &coder) {
+		if class, ok := statusClasses[coder.Code()]; ok {
+			return class
+		}
+	}
+	var isCoder interface{ IsCode(code int) bool }
+	if errors.As(err, &isCoder) {
+		for status, class := range statusClasses {
+			if isCoder.IsCode(status) {
+				return class
+			}
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorClassNetwork
+	}
+
+	return ErrorClassUnknown
+}
+
+// HTTPStatusError reports an unexpected status returned by a remote endpoint
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (e HTTPStatusError) Error() string {
+	return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e HTTPStatusError) Code() int {
+	return e.StatusCode
+}
+
+// ClassifiedError is the error of a resource that failed to be applied or
+// compared, along with its class
+type ClassifiedError struct {
+	Ref   ResourceRef
+	Class ErrorClass
+	Err   error
+}
+
+func NewClassifiedError(resource Resource, err error) ClassifiedError {
+	return ClassifiedError{
+		Ref:   resource.Ref(),
+		Class: ClassifyError(err),
+		Err:   err,
+	}
 }
 
-func (w Warning) Error() string {
-	return w.Err.Error()
+func (e ClassifiedError) Error() string {
+	return e.Err.Error()
 }
 
-func IsWarning(err any) bool {
-	_, ok := err.(Warning)
-	return ok
+func (e ClassifiedError) Unwrap() error {
+	return e.Err
 }
@@ -0,0 +1,75 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected grizzly.ErrorClass
+	}{
+		{err: grizzly.ErrNotFound, expected: grizzly.ErrorClassNotFound},
+		{err: fmt.Errorf("loading: %w", grizzly.HTTPStatusError{StatusCode: http.StatusTooManyRequests}), expected: grizzly.ErrorClassRateLimit},
+		{err: grizzly.HTTPStatusError{StatusCode: http.StatusForbidden}, expected: grizzly.ErrorClassAuth},
+		{err: grizzly.HTTPStatusError{StatusCode: http.StatusConflict}, expected: grizzly.ErrorClassConflict},
+		{err: grizzly.HTTPStatusError{StatusCode: http.StatusUnprocessableEntity}, expected: grizzly.ErrorClassValidation},
+		{err: grizzly.HTTPStatusError{StatusCode: http.StatusInternalServerError}, expected: grizzly.ErrorClassUnknown},
+		{err: grizzly.ParseError{File: "dashboard.json", Err: errors.New("invalid")}, expected: grizzly.ErrorClassValidation},
+		{err: fmt.Errorf("applying: %w", grizzly.ErrResourceTimeout), expected: grizzly.ErrorClassNetwork},
+		{err: errors.New("boom"), expected: grizzly.ErrorClassUnknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			require.Equal(t, test.expected, grizzly.ClassifyError(test.err))
+		})
+	}
+	require.True(t, grizzly.ErrorClassRateLimit.Retryable())
+	require.False(t, grizzly.ErrorClassAuth.Retryable())
+}
+
+func TestApplyErrorClasses(t *testing.T) {
+	status := http.StatusTooManyRequests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"message": "nope"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	registry := grizzly.NewRegistry([]grizzly.Provider{grafana.NewProvider(&config.GrafanaConfig{URL: server.URL})})
+	folder := grizzlytest.NewFolder(t, "infra", "Infrastructure")
+
+	apply := func(t *testing.T) (string, grizzly.ClassifiedError) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		err := grizzly.Apply(registry, grizzly.NewResources(folder), true, grizzly.NewWriterRecorder(out, grizzly.EventToPlainText))
+		classified := grizzly.ClassifiedError{}
+		require.ErrorAs(t, err, &classified)
+		require.Equal(t, grizzly.NewResourceRef("DashboardFolder", "infra"), classified.Ref)
+		return out.String(), classified
+	}
+
+	out, classified := apply(t)
+	require.Equal(t, grizzly.ErrorClassRateLimit, classified.Class)
+	require.True(t, classified.Class.Retryable())
+	require.Contains(t, out, "DashboardFolder.infra failed (rate-limit): ")
+
+	status = http.StatusUnauthorized
+	out, classified = apply(t)
+	require.Equal(t, grizzly.ErrorClassAuth, classified.Class)
+	require.False(t, classified.Class.Retryable())
+	require.Contains(t, out, "DashboardFolder.infra failed (auth): ")
+}
@@ -29,22 +29,42 @@ var (
 	ResourceUpdated    = EventType{ID: "resource-updated", Severity: Notice, HumanReadable: "updated"}
 	ResourcePulled     = EventType{ID: "resource-pulled", Severity: Notice, HumanReadable: "pulled"}
 	ResourceFailure    = EventType{ID: "resource-failure", Severity: Error, HumanReadable: "failed"}
+	ResourceSkipped    = EventType{ID: "resource-skipped", Severity: Error, HumanReadable: "skipped"}
+	ResourceRendered   = EventType{ID: "resource-rendered", Severity: Notice, HumanReadable: "rendered"}
+	// ResourceChanged signals a difference between a local resource and its
+	// remote counterpart
+	ResourceChanged = EventType{ID: "resource-changed", Severity: Notice, HumanReadable: "changed"}
+	// ResourceRemoved signals a resource removed from the sources since a
+	// revision
+	ResourceRemoved = EventType{ID: "resource-removed", Severity: Notice, HumanReadable: "removed"}
 )
 
 type Event struct {
 	Type        EventType
 	ResourceRef string
 	Details     string
+	// ErrorClass classifies the error of failures, when known
+	ErrorClass ErrorClass
+}
+
+// humanReadable describes the type of an event, along with the class of its
+// error, if any
+func (event Event) humanReadable() string {
+	if event.ErrorClass == "" || event.ErrorClass == ErrorClassUnknown {
+		return event.Type.HumanReadable
+	}
+
+	return fmt.Sprintf("%s (%s)", event.Type.HumanReadable, event.ErrorClass)
 }
 
 type EventFormatter func(event Event) string
 
 func EventToPlainText(event Event) string {
 	if event.Details == "" {
-		return fmt.Sprintf("%s %s\n", event.ResourceRef, event.Type.HumanReadable)
+		return fmt.Sprintf("%s %s\n", event.ResourceRef, event.humanReadable())
 	}
 
-	return fmt.Sprintf("%s %s: %s\n", event.ResourceRef, event.Type.HumanReadable, event.Details)
+	return fmt.Sprintf("%s %s: %s\n", event.ResourceRef, event.humanReadable(), event.Details)
 }
 
 func EventToColoredText(event Event) string {
@@ -59,7 +79,7 @@ func EventToColoredText(event Event) string {
 		colorFunc = color.New(color.FgRed).SprintFunc()
 	}
 
-	eventType := event.Type.HumanReadable
+	eventType := event.humanReadable()
 	if colorFunc != nil {
 		eventType = colorFunc(eventType)
 	}
@@ -71,6 +91,29 @@ func EventToColoredText(event Event) string {
 	return fmt.Sprintf("%s %s: %s\n", event.ResourceRef, eventType, event.Details)
 }
 
+// EventToPorcelain formats events as stable `<resource>\t<event-id>` lines,
+// suitable for scripts. Details, if any, are appended as a third field.
+func EventToPorcelain(event Event) string {
+	if event.Details == "" {
+		return fmt.Sprintf("%s\t%s\n", event.ResourceRef, event.Type.ID)
+	}
+
+	details := strings.Join(strings.Fields(event.Details), " ")
+
+	return fmt.Sprintf("%s\t%s\t%s\n", event.ResourceRef, event.Type.ID, details)
+}
+
+// OnlyErrors decorates a formatter to discard any event that isn't an error.
+func OnlyErrors(formatter EventFormatter) EventFormatter {
+	return func(event Event) string {
+		if event.Type.Severity != Error {
+			return ""
+		}
+
+		return formatter(event)
+	}
+}
+
 type Summary struct {
 	EventCounts map[EventType]int
 }
@@ -0,0 +1,38 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventFormatters(t *testing.T) {
+	events := []grizzly.Event{
+		{Type: grizzly.ResourceAdded, ResourceRef: "Dashboard.added"},
+		{Type: grizzly.ResourceNotChanged, ResourceRef: "Dashboard.unchanged"},
+		{Type: grizzly.ResourceFailure, ResourceRef: "Dashboard.failed", Details: "something\nwent wrong"},
+	}
+
+	t.Run("porcelain", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		recorder := grizzly.NewWriterRecorder(out, grizzly.EventToPorcelain)
+		for _, event := range events {
+			recorder.Record(event)
+		}
+
+		require.Equal(t, "Dashboard.added\tresource-added\nDashboard.unchanged\tresource-not-changed\nDashboard.failed\tresource-failure\tsomething went wrong\n", out.String())
+	})
+
+	t.Run("only errors", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		recorder := grizzly.NewWriterRecorder(out, grizzly.OnlyErrors(grizzly.EventToPlainText))
+		for _, event := range events {
+			recorder.Record(event)
+		}
+
+		require.Equal(t, "Dashboard.failed failed: something\nwent wrong\n", out.String())
+		require.Equal(t, 1, recorder.Summary().EventCounts[grizzly.ResourceAdded])
+	})
+}
@@ -0,0 +1,382 @@
+package grizzly
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-multierror"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FolderKind is the kind of the resources representing dashboard folders
+	FolderKind = "DashboardFolder"
+
+	// DefaultFolderMapFile is the file in which the UIDs of implicitly created
+	// folders are recorded
+	DefaultFolderMapFile = ".grizzly-folders.yaml"
+)
+
+// RemoteFolder describes a remote folder, as listed by a
+// FolderListingHandler
+type RemoteFolder struct {
+	UID       string
+	Title     string
+	ParentUID string
+}
+
+// FolderListingHandler describes a handler listing all the remote folders at
+// once, with their titles, so that folders can be looked up by title
+type FolderListingHandler interface {
+	ListRemoteFolders() ([]RemoteFolder, error)
+}
+
+// FolderMap records the UIDs assigned to folders created implicitly from a
+// resource's `folderName` metadata, so that the same UIDs are reused by
+// subsequent runs and across environments.
+type FolderMap struct {
+	path  string
+	lock  sync.Mutex
+	uids  map[string]string
+	dirty bool
+}
+
+// LoadFolderMap reads a folder map from disk. A missing file results in an
+// empty map.
+func LoadFolderMap(path string) (*FolderMap, error) {
+	folderMap := &FolderMap{
+		path: path,
+		uids: map[string]string{},
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return folderMap, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(content, &folderMap.uids); err != nil {
+		return nil, ParseError{File: path, Err: err}
+	}
+	if folderMap.uids == nil {
+		folderMap.uids = map[string]string{}
+	}
+
+	return folderMap, nil
+}
+
+// UID returns the UID recorded for the given folder name, generating (and
+// recording) a new one if the folder is not known yet.
+func (m *FolderMap) UID(name string) string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if uid, ok := m.uids[name]; ok {
+		return uid
+	}
+
+	uid := uuid.New().String()
+	m.uids[name] = uid
+	m.dirty = true
+
+	return uid
+}
+
+// Has reports whether a UID is recorded for the given folder name.
+func (m *FolderMap) Has(name string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	_, ok := m.uids[name]
+	return ok
+}
+
+// Record records the UID of an existing folder, unless the folder name is
+// already known.
+func (m *FolderMap) Record(name string, uid string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.uids[name]; ok {
+		return
+	}
+	m.uids[name] = uid
+	m.dirty = true
+}
+
+// Save writes the folder map back to disk, if it was modified.
+func (m *FolderMap) Save() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.dirty {
+		return nil
+	}
+
+	content, err := yaml.Marshal(m.uids)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(m.path, content, 0644); err != nil {
+		return err
+	}
+	m.dirty = false
+
+	return nil
+}
+
+// FolderNameParser resolves the `folderName` metadata of parsed resources into
+// folder UIDs, and adds the corresponding folders to the parsed resources.
+// It never writes the folder map: the UIDs of new folders are recorded in
+// the map it is given, if any, for the caller to save once resources are
+// applied.
+//
+// Folders missing from the map can be looked up among the remote folders by
+// title first, so that existing folders are reused rather than duplicated.
+type FolderNameParser struct {
+	registry      Registry
+	decorated     Parser
+	folderMapPath string
+	folderMap     *FolderMap
+	remoteFolders bool
+	logger        *log.Entry
+}
+
+func NewFolderNameParser(registry Registry, decorated Parser, folderMapPath string, folderMap *FolderMap, remoteFolders bool) *FolderNameParser {
+	return &FolderNameParser{
+		registry:      registry,
+		decorated:     decorated,
+		folderMapPath: folderMapPath,
+		folderMap:     folderMap,
+		remoteFolders: remoteFolders,
+		logger:        log.WithField("parser", "folder-name"),
+	}
+}
+
+func (parser *FolderNameParser) Accept(file string) bool {
+	return parser.decorated.Accept(file)
+}
+
+func (parser *FolderNameParser) Parse(resourcePath string, options ParserOptions) (Resources, error) {
+	// resources parsed despite errors get their folders too, as parsers can
+	// be configured to continue on error
+	resources, parseErr := parser.decorated.Parse(resourcePath, options)
+
+	resources, err := parser.resolveFolders(resources)
+	if parseErr != nil && err != nil {
+		return resources, multierror.Append(parseErr, err)
+	}
+	if parseErr != nil {
+		return resources, parseErr
+	}
+
+	return resources, err
+}
+
+// resolveFolders sets the folder of resources with a `folderName`, and
+// declares the folders they need
+func (parser *FolderNameParser) resolveFolders(resources Resources) (Resources, error) {
+	named := resources.Filter(func(resource Resource) bool {
+		return resource.GetMetadata("folderName") != "" && resource.GetMetadata("folder") == ""
+	})
+	if named.Len() == 0 {
+		return resources, nil
+	}
+
+	folderHandler, err := parser.registry.GetHandler(FolderKind)
+	if err != nil {
+		return resources, err
+	}
+
+	folderMap := parser.folderMap
+	if folderMap == nil {
+		folderMap, err = LoadFolderMap(parser.folderMapPath)
+		if err != nil {
+			return resources, err
+		}
+	}
+
+	nestedPaths := map[string]bool{}
+	for _, resource := range named.AsList() {
+		// nested folders are declared as `parent/child`: every folder
+		// along the path needs to exist
+		parts := strings.Split(cleanFolderPath(resource.GetMetadata("folderName")), "/")
+		for i := range parts {
+			nestedPaths[strings.Join(parts[:i+1], "/")] = true
+		}
+	}
+
+	paths := make([]string, 0, len(nestedPaths))
+	for path := range nestedPaths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if parser.remoteFolders {
+		parser.lookupFolders(folderHandler, folderMap, paths)
+	}
+
+	for _, resource := range named.AsList() {
+		path := cleanFolderPath(resource.GetMetadata("folderName"))
+		uid := folderMap.UID(path)
+
+		resource.SetMetadata("folder", uid)
+		resources.Add(resource)
+
+		parser.logger.WithField("resource", resource.Ref().String()).Debugf("Using folder %s for %q", uid, path)
+	}
+
+	for _, path := range paths {
+		uid := folderMap.UID(path)
+		if _, found := resources.Find(NewResourceRef(FolderKind, uid)); found {
+			continue
+		}
+
+		spec := map[string]any{
+			"uid":   uid,
+			"title": path,
+		}
+		if i := strings.LastIndex(path, "/"); i != -1 {
+			spec["title"] = path[i+1:]
+			spec["parentUid"] = folderMap.UID(path[:i])
+		}
+
+		folder, err := NewResource(folderHandler.APIVersion(), FolderKind, uid, spec)
+		if err != nil {
+			return resources, err
+		}
+		resources.Add(folder)
+	}
+
+	return parser.registry.Sort(resources), nil
+}
+
+// lookupFolders records the UIDs of the remote folders whose path of titles
+// matches one of the given paths missing from the folder map
+func (parser *FolderNameParser) lookupFolders(folderHandler Handler, folderMap *FolderMap, paths []string) {
+	missing := false
+	for _, path := range paths {
+		missing = missing || !folderMap.Has(path)
+	}
+	lister, ok := unwrapHandler(folderHandler).(FolderListingHandler)
+	if !missing || !ok {
+		return
+	}
+
+	folders, err := lister.ListRemoteFolders()
+	if err != nil {
+		parser.logger.Warnf("Could not look up the remote folders, folders missing from the folder map get new UIDs: %s", err)
+		return
+	}
+
+	remotePaths := remoteFolderPaths(folders)
+	for _, path := range paths {
+		if uid, ok := remotePaths[path]; ok {
+			parser.logger.Debugf("Using the remote folder %s for %q", uid, path)
+			folderMap.Record(path, uid)
+		}
+	}
+}
+
+// remoteFolderPaths returns the UID of remote folders by path of titles.
+// Grafana refuses folders with the same title and parent, but the first
+// folder by UID is used if any.
+func remoteFolderPaths(folders []RemoteFolder) map[string]string {
+	byUID := make(map[string]RemoteFolder, len(folders))
+	for _, folder := range folders {
+		byUID[folder.UID] = folder
+	}
+
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].UID < folders[j].UID
+	})
+
+	paths := make(map[string]string, len(folders))
+	for _, folder := range folders {
+		path := folder.Title
+		seen := map[string]bool{folder.UID: true}
+		for parent, ok := byUID[folder.ParentUID]; ok && !seen[parent.UID]; parent, ok = byUID[parent.ParentUID] {
+			seen[parent.UID] = true
+			path = parent.Title + "/" + path
+		}
+
+		path = cleanFolderPath(path)
+		if _, found := paths[path]; !found {
+			paths[path] = folder.UID
+		}
+	}
+
+	return paths
+}
+
+func cleanFolderPath(path string) string {
+	parts := []string{}
+	for _, part := range strings.Split(path, "/") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// folderPaths returns the path of each folder found in the given resources.
+// A path is made of the UIDs of the folder's ancestors, followed by its own.
+func folderPaths(resources Resources) map[string]string {
+	parents := map[string]string{}
+	_ = resources.ForEach(func(resource Resource) error {
+		if resource.Kind() != FolderKind {
+			return nil
+		}
+		parentUID, _ := resource.GetSpecValue("parentUid").(string)
+		parents[resource.Name()] = parentUID
+		return nil
+	})
+
+	paths := make(map[string]string, len(parents))
+	for uid := range parents {
+		path := uid
+		seen := map[string]bool{uid: true}
+		for parent := parents[uid]; parent != "" && !seen[parent]; parent = parents[parent] {
+			seen[parent] = true
+			path = parent + "/" + path
+		}
+		paths[uid] = path
+	}
+
+	return paths
+}
+
+// nestedPath returns the path of a resource within the folder hierarchy.
+// Resources living outside folders are addressed by their name only.
+func nestedPath(registry Registry, resource Resource, paths map[string]string) string {
+	if resource.Kind() == FolderKind {
+		if path, ok := paths[resource.Name()]; ok {
+			return path
+		}
+		return resource.Name()
+	}
+
+	handler, err := registry.GetHandler(resource.Kind())
+	if err != nil || !handler.UsesFolders() {
+		return resource.Name()
+	}
+
+	folder := resource.GetMetadata("folder")
+	if folder == "" {
+		return resource.Name()
+	}
+	if path, ok := paths[folder]; ok {
+		folder = path
+	}
+
+	return folder + "/" + resource.Name()
+}
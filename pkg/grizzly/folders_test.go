@@ -0,0 +1,151 @@
+package grizzly_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFolderNameParser(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	parseOpts := grizzly.ParserOptions{}
+
+	t.Run("folders are created and their UIDs recorded", func(t *testing.T) {
+		folderMapPath := filepath.Join(t.TempDir(), "folders.yaml")
+		recorded, err := grizzly.LoadFolderMap(folderMapPath)
+		require.NoError(t, err)
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserFolderMap(folderMapPath), grizzly.ParserRecordFolders(recorded))
+
+		resources, err := parser.Parse("testdata/folders/dashboards-with-folder-name.yaml", parseOpts)
+		require.NoError(t, err)
+		require.Equal(t, 3, resources.Len())
+
+		folder := resources.First()
+		require.Equal(t, grizzly.FolderKind, folder.Kind())
+		require.Equal(t, "Infrastructure", folder.Spec()["title"])
+
+		for _, resource := range resources.AsList()[1:] {
+			require.Equal(t, folder.Name(), resource.GetMetadata("folder"))
+		}
+
+		// the parser never writes the folder map: the caller does
+		require.NoFileExists(t, folderMapPath)
+		require.NoError(t, recorded.Save())
+		folderMap, err := grizzly.LoadFolderMap(folderMapPath)
+		require.NoError(t, err)
+		require.Equal(t, folder.Name(), folderMap.UID("Infrastructure"))
+	})
+
+	t.Run("the folder map is only read without a map to record folders in", func(t *testing.T) {
+		folderMapPath := filepath.Join(t.TempDir(), "folders.yaml")
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserFolderMap(folderMapPath))
+
+		resources, err := parser.Parse("testdata/folders/dashboards-with-folder-name.yaml", parseOpts)
+		require.NoError(t, err)
+		folder := resources.First()
+		require.Equal(t, grizzly.FolderKind, folder.Kind())
+		require.NoFileExists(t, folderMapPath)
+	})
+
+	t.Run("recorded UIDs are reused", func(t *testing.T) {
+		folderMapPath := filepath.Join(t.TempDir(), "folders.yaml")
+		require.NoError(t, os.WriteFile(folderMapPath, []byte("Infrastructure: infra-uid\n"), 0644))
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserFolderMap(folderMapPath))
+
+		resources, err := parser.Parse("testdata/folders/dashboards-with-folder-name.yaml", parseOpts)
+		require.NoError(t, err)
+
+		_, found := resources.Find(grizzly.NewResourceRef(grizzly.FolderKind, "infra-uid"))
+		require.True(t, found)
+		dashboard, found := resources.Find(grizzly.NewResourceRef("Dashboard", "first-dashboard"))
+		require.True(t, found)
+		require.Equal(t, "infra-uid", dashboard.GetMetadata("folder"))
+	})
+
+	t.Run("nested folders are created along the path", func(t *testing.T) {
+		folderMapPath := filepath.Join(t.TempDir(), "folders.yaml")
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserFolderMap(folderMapPath))
+
+		resources, err := parser.Parse("testdata/folders/dashboard-with-nested-folder-name.yaml", parseOpts)
+		require.NoError(t, err)
+		require.Equal(t, 3, resources.Len())
+
+		list := resources.AsList()
+		parent, child, dashboard := list[0], list[1], list[2]
+		require.Equal(t, "Infrastructure", parent.Spec()["title"])
+		require.Nil(t, parent.GetSpecValue("parentUid"))
+		require.Equal(t, "Network", child.Spec()["title"])
+		require.Equal(t, parent.Name(), child.GetSpecValue("parentUid"))
+		require.Equal(t, child.Name(), dashboard.GetMetadata("folder"))
+	})
+
+	t.Run("remote folders are looked up by title", func(t *testing.T) {
+		server := grizzlytest.NewServer(t)
+		registry := server.GrafanaRegistry()
+		network := grizzlytest.NewFolder(t, "network", "Network")
+		network.SetSpecValue("parentUid", "infra")
+		existing := grizzly.NewResources(grizzlytest.NewFolder(t, "infra", "Infrastructure"), network)
+		require.NoError(t, grizzly.Apply(registry, existing, false, grizzly.NewJUnitReport("apply")))
+
+		folderMapPath := filepath.Join(t.TempDir(), "folders.yaml")
+		recorded, err := grizzly.LoadFolderMap(folderMapPath)
+		require.NoError(t, err)
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserFolderMap(folderMapPath), grizzly.ParserRecordFolders(recorded), grizzly.ParserRemoteFolders(true))
+
+		resources, err := parser.Parse("testdata/folders/dashboard-with-nested-folder-name.yaml", parseOpts)
+		require.NoError(t, err)
+		dashboard, found := resources.Find(grizzly.NewResourceRef("Dashboard", "routers"))
+		require.True(t, found)
+		require.Equal(t, "network", dashboard.GetMetadata("folder"))
+		_, found = resources.Find(grizzly.NewResourceRef(grizzly.FolderKind, "infra"))
+		require.True(t, found)
+
+		require.NoError(t, recorded.Save())
+		folderMap, err := grizzly.LoadFolderMap(folderMapPath)
+		require.NoError(t, err)
+		require.True(t, folderMap.Has("Infrastructure/Network"))
+		require.Equal(t, "infra", folderMap.UID("Infrastructure"))
+	})
+}
+
+func TestFolderPathTargets(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+
+	tests := []struct {
+		target   string
+		expected []string
+	}{
+		{
+			target:   "DashboardFolder/infra/**",
+			expected: []string{"DashboardFolder.network", "DashboardFolder.storage"},
+		},
+		{
+			target:   "Dashboard/infra/network/*",
+			expected: []string{"Dashboard.routers"},
+		},
+		{
+			target:   "Dashboard/disks",
+			expected: []string{"Dashboard.disks"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			parser := grizzly.DefaultParser(registry, []string{test.target}, nil)
+
+			resources, err := parser.Parse("testdata/folders/nested-folders.yaml", grizzly.ParserOptions{})
+			require.NoError(t, err)
+
+			refs := []string{}
+			for _, resource := range resources.AsList() {
+				refs = append(refs, resource.Ref().String())
+			}
+			require.Equal(t, test.expected, refs)
+		})
+	}
+}
@@ -1,11 +1,8 @@
 package grizzly
 
 import (
-	"encoding/json"
 	"os"
 	"path/filepath"
-
-	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -15,33 +12,25 @@ const (
 	formatDefault = "default"
 )
 
+// Format encodes a resource, or its spec, with the encoder of an output format
+// and returns the file it belongs to
 func Format(registry Registry, resourcePath string, resource *Resource, format string, onlySpec bool) ([]byte, string, string, error) {
-	var content []byte
-	var filename string
-	var extension string
-	var err error
+	encoder, err := GetEncoder(format)
+	if err != nil {
+		return nil, "", "", err
+	}
 
 	spec := resource.Body
 	if onlySpec {
 		spec = resource.Spec()
 	}
 
-	if format == formatJSON {
-		extension = formatJSON
-		j, err := json.MarshalIndent(spec, "", "  ")
-		if err != nil {
-			return nil, "", "", err
-		}
-		content = j
-	} else {
-		extension = formatYAML
-		y, err := yaml.Marshal(spec)
-		if err != nil {
-			return nil, "", "", err
-		}
-		content = y
+	content, err := encoder.Encode(spec)
+	if err != nil {
+		return nil, "", "", err
 	}
-	filename, err = getFilename(registry, resourcePath, resource, extension)
+	extension := encoder.Extension()
+	filename, err := getFilename(registry, resourcePath, resource, extension)
 	if err != nil {
 		return nil, "", "", err
 	}
@@ -0,0 +1,106 @@
+package grizzly
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-jsonnet"
+)
+
+// FunctionDoc documents a function available to resources
+type FunctionDoc struct {
+	Name        string
+	Params      []string
+	Description string
+}
+
+// Signature returns how the function is called, e.g. `regexMatch(regex, string)`
+func (doc FunctionDoc) Signature() string {
+	return fmt.Sprintf("%s(%s)", doc.Name, strings.Join(doc.Params, ", "))
+}
+
+type nativeFunction struct {
+	function    *jsonnet.NativeFunction
+	description string
+}
+
+var registeredNativeFunctions = struct {
+	lock      sync.Mutex
+	functions []nativeFunction
+}{}
+
+// RegisterNativeFunction makes a function callable from Jsonnet with
+// `std.native(name)`, in the files evaluated from now on. Programs embedding
+// Grizzly use it to extend the functions available to their users. Names
+// must be unique: built-in functions can't be replaced.
+func RegisterNativeFunction(function *jsonnet.NativeFunction, description string) error {
+	if function == nil || function.Name == "" || function.Func == nil {
+		return fmt.Errorf("native functions need a name and an implementation")
+	}
+
+	registeredNativeFunctions.lock.Lock()
+	defer registeredNativeFunctions.lock.Unlock()
+
+	for _, existing := range append(builtinNativeFunctions(nil), registeredNativeFunctions.functions...) {
+		if existing.function.Name == function.Name {
+			return fmt.Errorf("native function %s is already registered", function.Name)
+		}
+	}
+	registeredNativeFunctions.functions = append(registeredNativeFunctions.functions, nativeFunction{
+		function:    function,
+		description: description,
+	})
+
+	return nil
+}
+
+// builtinNativeFunctions returns the native functions of Grizzly, env being
+// the environment variables readable with getEnv
+func builtinNativeFunctions(env map[string]string) []nativeFunction {
+	return []nativeFunction{
+		{escapeStringRegexNativeFunc(), "escapes all regular expression metacharacters of a string"},
+		{regexMatchNativeFunc(), "returns whether a string is matched by a re2 regular expression"},
+		{regexSubstNativeFunc(), "replaces all the matches of a re2 regular expression with another string"},
+		{hashNativeFunc("sha256", sha256.New), "returns the hex-encoded SHA-256 digest of a string"},
+		{hashNativeFunc("sha1", sha1.New), "returns the hex-encoded SHA-1 digest of a string"},
+		{hashNativeFunc("crc32", func() hash.Hash { return crc32.NewIEEE() }), "returns the hex-encoded CRC-32 checksum of a string"},
+		{parseYamlNativeFunc(), "parses a YAML string into an array of the documents it holds"},
+		{manifestYamlDocNativeFunc(), "manifests a value as a YAML document"},
+		{getEnvNativeFunc(env), "returns the value of an environment variable allowed by the context, or a default when it is empty"},
+	}
+}
+
+// nativeFunctions returns the built-in and registered native functions
+func nativeFunctions(env map[string]string) []nativeFunction {
+	registeredNativeFunctions.lock.Lock()
+	defer registeredNativeFunctions.lock.Unlock()
+
+	return append(builtinNativeFunctions(env), registeredNativeFunctions.functions...)
+}
+
+// NativeFunctionDocs documents the native functions available to Jsonnet
+// files, sorted by name
+func NativeFunctionDocs() []FunctionDoc {
+	functions := nativeFunctions(nil)
+	docs := make([]FunctionDoc, 0, len(functions))
+	for _, function := range functions {
+		params := make([]string, 0, len(function.function.Params))
+		for _, param := range function.function.Params {
+			params = append(params, string(param))
+		}
+		docs = append(docs, FunctionDoc{
+			Name:        function.function.Name,
+			Params:      params,
+			Description: function.description,
+		})
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Name < docs[j].Name })
+
+	return docs
+}
@@ -0,0 +1,45 @@
+package grizzly_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterNativeFunction(t *testing.T) {
+	shout := &jsonnet.NativeFunction{
+		Name:   "testShout",
+		Params: ast.Identifiers{"str"},
+		Func: func(args []any) (any, error) {
+			return args[0].(string) + "!", nil
+		},
+	}
+	require.NoError(t, grizzly.RegisterNativeFunction(shout, "appends an exclamation mark"))
+	require.ErrorContains(t, grizzly.RegisterNativeFunction(shout, "again"), "native function testShout is already registered")
+	require.ErrorContains(t, grizzly.RegisterNativeFunction(&jsonnet.NativeFunction{Name: "sha256", Func: shout.Func}, "replaced"), "native function sha256 is already registered")
+
+	require.Contains(t, grizzly.NativeFunctionDocs(), grizzly.FunctionDoc{
+		Name:        "testShout",
+		Params:      []string{"str"},
+		Description: "appends an exclamation mark",
+	})
+
+	file := filepath.Join(t.TempDir(), "dashboard.jsonnet")
+	require.NoError(t, os.WriteFile(file, []byte(`{
+  apiVersion: 'grizzly.grafana.com/v1alpha1',
+  kind: 'Dashboard',
+  metadata: { name: 'shout', folder: 'general' },
+  spec: { title: std.native('testShout')('Nodes') },
+}`), 0644))
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	resources, err := grizzly.DefaultParser(registry, nil, nil).Parse(file, grizzly.ParserOptions{})
+	require.NoError(t, err)
+	resource := resources.First()
+	require.Equal(t, "Nodes!", resource.GetSpecValue("title"))
+}
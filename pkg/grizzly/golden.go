@@ -0,0 +1,176 @@
+package grizzly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// ErrGoldenMismatch is returned when rendered resources don't match their
+// golden files
+var ErrGoldenMismatch = errors.New("rendered resources don't match their golden files")
+
+// GoldenFile is the outcome of the comparison of a rendered resource with
+// its golden file.
+type GoldenFile struct {
+	ResourceRef string
+	Path        string
+	// Event is ResourceNotChanged when the golden file matches, ResourceAdded
+	// or ResourceUpdated when it was written, and ResourceFailure otherwise
+	Event EventType
+	// Details explains failures
+	Details string
+	// Diff is the unified diff between the existing golden file and the
+	// rendered resource
+	Diff string
+}
+
+// GoldenFilePath returns the location of the golden file of a resource
+func GoldenFilePath(goldenDir string, resource Resource) string {
+	return filepath.Join(goldenDir, resource.Kind(), url.PathEscape(resource.Name())+".json")
+}
+
+// RenderGolden renders a resource the way it is stored in golden files
+func RenderGolden(registry Registry, resource Resource) ([]byte, error) {
+	handler, err := registry.GetHandler(resource.Kind())
+	if err != nil {
+		return nil, err
+	}
+	resource = *handler.Unprepare(resource)
+
+	content, err := json.MarshalIndent(resource.Body, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(content, '\n'), nil
+}
+
+// CheckGolden compares rendered resources with the golden files stored in
+// goldenDir. With update, golden files are written instead, and the ones of
+// resources that don't exist anymore are removed.
+func CheckGolden(registry Registry, goldenDir string, resources Resources, update bool) ([]GoldenFile, error) {
+	var results []GoldenFile
+	expected := map[string]bool{}
+
+	for _, resource := range resources.AsList() {
+		path := GoldenFilePath(goldenDir, resource)
+		expected[path] = true
+
+		rendered, err := RenderGolden(registry, resource)
+		if err != nil {
+			return nil, fmt.Errorf("rendering %s: %w", resource.Ref(), err)
+		}
+
+		result, err := checkGoldenFile(resource.Ref().String(), path, rendered, update)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	stale, err := staleGoldenFiles(goldenDir, expected, update)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(results, stale...), nil
+}
+
+func checkGoldenFile(ref string, path string, rendered []byte, update bool) (GoldenFile, error) {
+	result := GoldenFile{ResourceRef: ref, Path: path}
+
+	golden, err := os.ReadFile(path)
+	missing := errors.Is(err, os.ErrNotExist)
+	if err != nil && !missing {
+		return result, err
+	}
+
+	if !missing && string(golden) == string(rendered) {
+		result.Event = ResourceNotChanged
+		return result, nil
+	}
+
+	if !missing {
+		result.Diff, _ = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(golden)),
+			B:        difflib.SplitLines(string(rendered)),
+			FromFile: "Golden",
+			ToFile:   "Rendered",
+			Context:  3,
+		})
+	}
+
+	switch {
+	case update && missing:
+		result.Event = ResourceAdded
+	case update:
+		result.Event = ResourceUpdated
+	case missing:
+		result.Event = ResourceFailure
+		result.Details = fmt.Sprintf("missing golden file %s", path)
+		return result, nil
+	default:
+		result.Event = ResourceFailure
+		result.Details = fmt.Sprintf("rendering differs from golden file %s", path)
+		return result, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return result, err
+	}
+
+	return result, os.WriteFile(path, rendered, 0644)
+}
+
+// staleGoldenFiles reports the golden files whose resource doesn't exist
+// anymore, and removes them when updating
+func staleGoldenFiles(goldenDir string, expected map[string]bool, update bool) ([]GoldenFile, error) {
+	var stale []GoldenFile
+
+	err := filepath.WalkDir(goldenDir, func(path string, entry os.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) && path == goldenDir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".json" || expected[path] {
+			return nil
+		}
+
+		name, _ := url.PathUnescape(strings.TrimSuffix(entry.Name(), ".json"))
+		result := GoldenFile{
+			ResourceRef: NewResourceRef(filepath.Base(filepath.Dir(path)), name).String(),
+			Path:        path,
+			Event:       ResourceFailure,
+			Details:     fmt.Sprintf("golden file %s doesn't match any resource", path),
+		}
+		if update {
+			result.Event = ResourceUpdated
+			result.Details = fmt.Sprintf("removed golden file %s", path)
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+		stale = append(stale, result)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].Path < stale[j].Path
+	})
+
+	return stale, nil
+}
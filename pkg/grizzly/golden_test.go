@@ -0,0 +1,71 @@
+package grizzly_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckGolden(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	goldenDir := t.TempDir()
+
+	events := func(results []grizzly.GoldenFile) map[string]string {
+		events := map[string]string{}
+		for _, result := range results {
+			events[result.ResourceRef] = result.Event.ID
+		}
+		return events
+	}
+
+	t.Run("missing golden files are failures", func(t *testing.T) {
+		results, err := grizzly.CheckGolden(registry, goldenDir, grizzly.NewResources(grizzlytest.NewFolder(t, "infra", "Infrastructure")), false)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"DashboardFolder.infra": "resource-failure"}, events(results))
+	})
+
+	t.Run("golden files are written when updating", func(t *testing.T) {
+		results, err := grizzly.CheckGolden(registry, goldenDir, grizzly.NewResources(grizzlytest.NewFolder(t, "infra", "Infrastructure")), true)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"DashboardFolder.infra": "resource-added"}, events(results))
+		require.FileExists(t, filepath.Join(goldenDir, "DashboardFolder", "infra.json"))
+	})
+
+	t.Run("matching golden files are unchanged", func(t *testing.T) {
+		results, err := grizzly.CheckGolden(registry, goldenDir, grizzly.NewResources(grizzlytest.NewFolder(t, "infra", "Infrastructure")), false)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"DashboardFolder.infra": "resource-not-changed"}, events(results))
+	})
+
+	t.Run("rendering changes are reported with a diff", func(t *testing.T) {
+		results, err := grizzly.CheckGolden(registry, goldenDir, grizzly.NewResources(grizzlytest.NewFolder(t, "infra", "Infra")), false)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, grizzly.ResourceFailure, results[0].Event)
+		require.Contains(t, results[0].Diff, `-    "title": "Infrastructure"`)
+		require.Contains(t, results[0].Diff, `+    "title": "Infra"`)
+	})
+
+	t.Run("golden files of removed resources are reported, and removed when updating", func(t *testing.T) {
+		results, err := grizzly.CheckGolden(registry, goldenDir, grizzly.NewResources(), false)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"DashboardFolder.infra": "resource-failure"}, events(results))
+
+		results, err = grizzly.CheckGolden(registry, goldenDir, grizzly.NewResources(), true)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"DashboardFolder.infra": "resource-updated"}, events(results))
+		_, err = os.Stat(filepath.Join(goldenDir, "DashboardFolder", "infra.json"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("a missing golden directory is empty", func(t *testing.T) {
+		results, err := grizzly.CheckGolden(registry, filepath.Join(goldenDir, "missing"), grizzly.NewResources(), false)
+		require.NoError(t, err)
+		require.Empty(t, results)
+	})
+}
@@ -1,6 +1,7 @@
 package grizzly
 
 import (
+	"fmt"
 	"net/http"
 )
 
@@ -50,7 +51,11 @@ func (h *BaseHandler) Detect(map[string]any) bool {
 	return false
 }
 
-// Handler describes a handler for a single API resource handled by a single provider
+// Handler describes a handler for a single API resource handled by a single provider.
+// Handlers can be called from several goroutines: state shared across calls, such
+// as clients, has to be guarded. They read the settings of a call, e.g.
+// ApplyStampFromContext, from the context their provider is bound to (see
+// ContextProvider) rather than from their arguments.
 type Handler interface {
 	APIVersion() string
 	Kind() string
@@ -105,6 +110,80 @@ type SnapshotHandler interface {
 	Snapshot(resource Resource, expiresSeconds int) error
 }
 
+// ReadOnlyFieldsHandler describes a handler whose resources have spec fields
+// maintained by the remote, e.g. usage information. They are pulled, and
+// compared by diff when present locally, but never applied: differences in
+// them don't make apply update resources.
+type ReadOnlyFieldsHandler interface {
+	ReadOnlyFields() []string
+}
+
+// readOnlyFields returns the read-only spec fields of the resources of a
+// handler, if any
+func readOnlyFields(handler Handler) []string {
+	if readOnlyHandler, ok := unwrapHandler(handler).(ReadOnlyFieldsHandler); ok {
+		return readOnlyHandler.ReadOnlyFields()
+	}
+	return nil
+}
+
+// ExclusiveHandler describes a handler whose resources overwrite resources
+// of other kinds, e.g. a configuration embedding them. Apply refuses to
+// apply them along with resources of the kinds they overwrite.
+type ExclusiveHandler interface {
+	ConflictingKinds(resource Resource) []string
+}
+
+// checkConflicts returns an error when resources overwrite other resources
+// applied along with them
+func checkConflicts(registry Registry, resources []Resource) error {
+	kinds := map[string]bool{}
+	for _, resource := range resources {
+		kinds[resource.Kind()] = true
+	}
+
+	for _, resource := range resources {
+		handler, err := registry.GetHandler(resource.Kind())
+		if err != nil {
+			continue
+		}
+		exclusiveHandler, ok := unwrapHandler(handler).(ExclusiveHandler)
+		if !ok {
+			continue
+		}
+		for _, kind := range exclusiveHandler.ConflictingKinds(resource) {
+			if kinds[kind] {
+				return fmt.Errorf("%s overwrites the %s resources applied along with it: apply either of them", resource.Ref(), kind)
+			}
+		}
+	}
+	return nil
+}
+
+// PreviewOptions describes how resources are rendered as images
+type PreviewOptions struct {
+	// Format is the format of the images, e.g. `png`
+	Format string
+	Width  int
+	Height int
+	// Theme is the theme used to render images, e.g. `light` or `dark`
+	Theme string
+}
+
+// PreviewImage is an image rendered from (a part of) a resource
+type PreviewImage struct {
+	// Name identifies the image among the ones of the resource
+	Name    string
+	Content []byte
+}
+
+// PreviewHandler describes a handler that has the ability to render a
+// resource as images
+type PreviewHandler interface {
+	// Preview renders a resource, without modifying its remote counterpart
+	Preview(resource Resource, opts PreviewOptions) ([]PreviewImage, error)
+}
+
 // ListenHandler describes a handler that has the ability to watch a single
 // resource for changes, and write changes to that resource to a local file
 type ListenHandler interface {
@@ -0,0 +1,233 @@
+package grizzly
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const formatHCL = "hcl"
+
+// hclKindAliases maps the block types named after the resources of the
+// Terraform Grafana provider to kinds, when they differ
+var hclKindAliases = map[string]string{
+	"folder":              "DashboardFolder",
+	"library_panel":       "LibraryElement",
+	"rule_group":          "AlertRuleGroup",
+	"contact_point":       "AlertContactPoint",
+	"notification_policy": "AlertNotificationPolicy",
+}
+
+// HCLParser parses resources declared as HCL blocks, e.g.
+// `dashboard "my-uid" { ... }`, to ease migrating from the Terraform Grafana
+// provider. The type of a block is its kind, in snake case and optionally
+// prefixed with `grafana_`, and its label is the name of the resource. The
+// body of a block is the spec of the resource, except for its `metadata`
+// block or attribute. As with Terraform, a `config_json` attribute holds the
+// JSON of the spec, e.g. `config_json = file("dashboard.json")`.
+type HCLParser struct {
+	registry Registry
+	logger   *log.Entry
+}
+
+func NewHCLParser(registry Registry) *HCLParser {
+	return &HCLParser{
+		registry: registry,
+		logger:   log.WithField("parser", "hcl"),
+	}
+}
+
+func (parser *HCLParser) Accept(file string) bool {
+	return filepath.Ext(file) == ".hcl"
+}
+
+// Parse decodes a HCL file and parses its blocks into resources
+func (parser *HCLParser) Parse(file string, options ParserOptions) (Resources, error) {
+	parser.logger.WithField("file", file).Debug("Parsing file")
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return Resources{}, err
+	}
+
+	config, diags := hclsyntax.ParseConfig(content, file, hcl.InitialPos)
+	if diags.HasErrors() {
+		return Resources{}, diags
+	}
+	body := config.Body.(*hclsyntax.Body)
+	if attributes := body.Attributes; len(attributes) > 0 {
+		var first *hclsyntax.Attribute
+		for _, attribute := range attributes {
+			if first == nil || attribute.SrcRange.Start.Byte < first.SrcRange.Start.Byte {
+				first = attribute
+			}
+		}
+		return Resources{}, hclRangeError(first.SrcRange, fmt.Errorf("unexpected attribute %s: resources are declared as blocks", first.Name))
+	}
+
+	evalContext := &hcl.EvalContext{Functions: hclFunctions(filepath.Dir(file))}
+	source := Source{
+		Format:     formatHCL,
+		Path:       file,
+		Rewritable: false,
+	}
+	resources := NewResources()
+	for _, block := range body.Blocks {
+		kind, ok := parser.kind(block.Type)
+		if !ok {
+			return resources, hclRangeError(block.TypeRange, fmt.Errorf("unknown resource type %s", block.Type))
+		}
+		if len(block.Labels) != 1 {
+			return resources, hclRangeError(block.TypeRange, fmt.Errorf("%s blocks have a single label, the name of the resource", block.Type))
+		}
+		handler, err := parser.registry.GetHandler(kind)
+		if err != nil {
+			return resources, err
+		}
+
+		spec, err := hclBodyValue(block.Body, evalContext)
+		if err != nil {
+			return resources, err
+		}
+		metadata := map[string]any{}
+		switch value := spec["metadata"].(type) {
+		case map[string]any:
+			metadata = value
+		case []any:
+			if len(value) != 1 {
+				return resources, hclRangeError(block.TypeRange, fmt.Errorf("%s %s has several metadata blocks", block.Type, block.Labels[0]))
+			}
+			metadata = value[0].(map[string]any)
+		}
+		delete(spec, "metadata")
+		metadata["name"] = block.Labels[0]
+		if configJSON, ok := spec["config_json"].(string); ok {
+			delete(spec, "config_json")
+			if err := json.Unmarshal([]byte(configJSON), &spec); err != nil {
+				return resources, hclRangeError(block.TypeRange, fmt.Errorf("invalid config_json: %w", err))
+			}
+		}
+
+		parsed, err := parseAny(parser.registry, map[string]any{
+			"apiVersion": handler.APIVersion(),
+			"kind":       kind,
+			"metadata":   metadata,
+			"spec":       spec,
+		}, options.DefaultResourceKind, options.DefaultFolderUID, source)
+		if err != nil {
+			return resources, hclRangeError(block.TypeRange, err)
+		}
+		resources.Merge(parsed)
+	}
+
+	return resources, nil
+}
+
+// kind returns the kind of resources declared by a type of blocks
+func (parser *HCLParser) kind(blockType string) (string, bool) {
+	blockType = strings.TrimPrefix(blockType, "grafana_")
+	if kind, ok := hclKindAliases[blockType]; ok {
+		return kind, true
+	}
+
+	for _, handler := range parser.registry.HandlerOrder {
+		if strings.EqualFold(strings.ReplaceAll(blockType, "_", ""), handler.Kind()) {
+			return handler.Kind(), true
+		}
+	}
+	return "", false
+}
+
+// hclBodyValue evaluates the attributes of a body, and its nested blocks as
+// lists of objects, like the nested blocks of Terraform
+func hclBodyValue(body *hclsyntax.Body, evalContext *hcl.EvalContext) (map[string]any, error) {
+	result := make(map[string]any, len(body.Attributes)+len(body.Blocks))
+	for name, attribute := range body.Attributes {
+		value, diags := attribute.Expr.Value(evalContext)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		converted, err := ctyToAny(value)
+		if err != nil {
+			return nil, hclRangeError(attribute.SrcRange, err)
+		}
+		result[name] = converted
+	}
+
+	for _, block := range body.Blocks {
+		if len(block.Labels) > 0 {
+			return nil, hclRangeError(block.TypeRange, fmt.Errorf("nested %s blocks can't have labels", block.Type))
+		}
+		value, err := hclBodyValue(block.Body, evalContext)
+		if err != nil {
+			return nil, err
+		}
+		list, _ := result[block.Type].([]any)
+		result[block.Type] = append(list, value)
+	}
+
+	return result, nil
+}
+
+func ctyToAny(value cty.Value) (any, error) {
+	if !value.IsWhollyKnown() {
+		return nil, fmt.Errorf("value is not known")
+	}
+	content, err := ctyjson.Marshal(value, value.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var result any
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// hclFunctions are the functions available to HCL files, resolving paths
+// relative to the directory of the file
+func hclFunctions(dir string) map[string]function.Function {
+	return map[string]function.Function{
+		"file": function.New(&function.Spec{
+			Params: []function.Parameter{{Name: "path", Type: cty.String}},
+			Type:   function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+				path := args[0].AsString()
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(dir, path)
+				}
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return cty.NilVal, err
+				}
+				return cty.StringVal(string(content)), nil
+			},
+		}),
+		"jsondecode": stdlib.JSONDecodeFunc,
+		"jsonencode": stdlib.JSONEncodeFunc,
+		"lower":      stdlib.LowerFunc,
+		"upper":      stdlib.UpperFunc,
+		"format":     stdlib.FormatFunc,
+		"merge":      stdlib.MergeFunc,
+		"concat":     stdlib.ConcatFunc,
+	}
+}
+
+func hclRangeError(subject hcl.Range, err error) error {
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  err.Error(),
+		Subject:  &subject,
+	}}
+}
@@ -0,0 +1,174 @@
+package grizzly
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatHelm = "helm"
+
+	// HelmChartFile is the file identifying the directory of a Helm chart
+	HelmChartFile = "Chart.yaml"
+
+	// helmDashboardLabel marks the ConfigMaps holding dashboards, as
+	// discovered by the sidecar of the Grafana Helm chart
+	helmDashboardLabel = "grafana_dashboard"
+	// helmFolderAnnotation sets the folder of the dashboards of a ConfigMap
+	helmFolderAnnotation = "grafana_folder"
+	// helmDefaultFolderUID is the folder of dashboards without any, the
+	// General folder of Grafana
+	helmDefaultFolderUID = "general"
+)
+
+// HelmParser renders Helm charts with `helm template`, and extracts the
+// dashboards shipped in their ConfigMaps: the ones labelled
+// `grafana_dashboard`, as discovered by the sidecar of the Grafana chart.
+// Each `.json` entry of such ConfigMaps is a dashboard, placed in the folder
+// named by the `grafana_folder` annotation, if any. Charts are identified by
+// their Chart.yaml file.
+type HelmParser struct {
+	registry Registry
+	logger   *log.Entry
+	// values are the values files overlaid when rendering charts
+	values []string
+}
+
+func NewHelmParser(registry Registry, values []string) *HelmParser {
+	return &HelmParser{
+		registry: registry,
+		logger:   log.WithField("parser", "helm"),
+		values:   values,
+	}
+}
+
+func (parser *HelmParser) Accept(file string) bool {
+	return filepath.Base(file) == HelmChartFile
+}
+
+// Parse renders the chart of a Chart.yaml file and parses the dashboards of
+// its ConfigMaps into resources
+func (parser *HelmParser) Parse(file string, options ParserOptions) (Resources, error) {
+	parser.logger.WithField("file", file).Debug("Parsing file")
+
+	args := []string{"template", "grizzly", filepath.Dir(file)}
+	for _, values := range parser.values {
+		args = append(args, "--values", values)
+	}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command("helm", args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return Resources{}, fmt.Errorf("rendering Helm charts requires the helm command: %w", err)
+		}
+		return Resources{}, fmt.Errorf("rendering Helm chart %s: %w: %s", filepath.Dir(file), err, strings.TrimSpace(stderr.String()))
+	}
+
+	source := Source{
+		Format:     formatHelm,
+		Path:       file,
+		Rewritable: false,
+	}
+	resources := NewResources()
+	decoder := yaml.NewDecoder(stdout)
+	for {
+		var manifest helmManifest
+		err := decoder.Decode(&manifest)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return resources, fmt.Errorf("rendering Helm chart %s: %w", filepath.Dir(file), err)
+		}
+
+		dashboards, err := manifest.dashboards(parser.registry, options.DefaultFolderUID, source)
+		if err != nil {
+			return resources, err
+		}
+		resources.Merge(dashboards)
+	}
+
+	return resources, nil
+}
+
+type helmManifest struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name        string            `yaml:"name"`
+		Labels      map[string]string `yaml:"labels"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+	Data map[string]string `yaml:"data"`
+}
+
+// dashboards returns the dashboards of a ConfigMap
+func (manifest helmManifest) dashboards(registry Registry, defaultFolderUID string, source Source) (Resources, error) {
+	resources := NewResources()
+	if manifest.Kind != "ConfigMap" {
+		return resources, nil
+	}
+	if _, ok := manifest.Metadata.Labels[helmDashboardLabel]; !ok {
+		return resources, nil
+	}
+	handler, err := registry.GetHandler("Dashboard")
+	if err != nil {
+		return resources, err
+	}
+
+	keys := make([]string, 0, len(manifest.Data))
+	for key := range manifest.Data {
+		if strings.HasSuffix(key, ".json") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		spec := map[string]any{}
+		if err := json.Unmarshal([]byte(manifest.Data[key]), &spec); err != nil {
+			return resources, fmt.Errorf("ConfigMap %s: %s: %w", manifest.Metadata.Name, key, err)
+		}
+		uid, _ := spec["uid"].(string)
+		if uid == "" {
+			uid = strings.TrimSuffix(key, ".json")
+			spec["uid"] = uid
+		}
+
+		resource, err := NewResource(handler.APIVersion(), handler.Kind(), uid, spec)
+		if err != nil {
+			return resources, err
+		}
+		switch {
+		case manifest.Metadata.Annotations[helmFolderAnnotation] != "":
+			resource.SetMetadata("folderName", manifest.Metadata.Annotations[helmFolderAnnotation])
+		case defaultFolderUID != "":
+			resource.SetMetadata("folder", defaultFolderUID)
+		default:
+			resource.SetMetadata("folder", helmDefaultFolderUID)
+		}
+		resource.SetSource(source)
+		resources.Add(resource)
+	}
+
+	return resources, nil
+}
+
+// isHelmChart returns whether a directory holds a Helm chart
+func isHelmChart(dir string) bool {
+	stat, err := os.Stat(filepath.Join(dir, HelmChartFile))
+	return err == nil && !stat.IsDir()
+}
@@ -0,0 +1,304 @@
+package grizzly
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HTTPTransportDecorator wraps the transport used by providers to reach their
+// remote endpoints.
+type HTTPTransportDecorator func(transport http.RoundTripper) http.RoundTripper
+
+var httpTransportDecorators = struct {
+	lock       sync.Mutex
+	decorators []HTTPTransportDecorator
+}{}
+
+// AddHTTPTransportDecorator registers a decorator applied to the transports
+// of the HTTP clients created by providers from now on.
+func AddHTTPTransportDecorator(decorator HTTPTransportDecorator) {
+	httpTransportDecorators.lock.Lock()
+	defer httpTransportDecorators.lock.Unlock()
+
+	httpTransportDecorators.decorators = append(httpTransportDecorators.decorators, decorator)
+}
+
+// DecorateHTTPTransport applies the registered decorators to the given
+// transport. Providers are expected to call it when creating their clients.
+func DecorateHTTPTransport(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	httpTransportDecorators.lock.Lock()
+	decorators := httpTransportDecorators.decorators
+	httpTransportDecorators.lock.Unlock()
+
+	for _, decorator := range decorators {
+		transport = decorator(transport)
+	}
+
+	return transport
+}
+
+// WithHTTPHeaders returns a transport setting the given headers on every
+// request sent through the given one, e.g. headers required by a gateway.
+// These headers often are credentials, so the transport is expected to be
+// decorated with DecorateHTTPTransport, rather than the other way around:
+// HTTP logs and fixtures then never hold them.
+func WithHTTPHeaders(transport http.RoundTripper, headers map[string]string) http.RoundTripper {
+	if len(headers) == 0 {
+		return transport
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		return transport.RoundTrip(req)
+	})
+}
+
+// TLSOptions configures how the certificates of a remote endpoint are
+// verified
+type TLSOptions struct {
+	// CAPath is the path of a PEM bundle of certificate authorities trusted
+	// on top of the system ones, e.g. the private CA of a lab instance
+	CAPath string
+	// InsecureSkipVerify disables the verification of certificates
+	InsecureSkipVerify bool
+	// ServerName overrides the host name certificates are verified against
+	ServerName string
+}
+
+// NewTLSConfig returns the TLS configuration reaching the named endpoint
+// with the given options, or nil when the defaults apply. Skipping the
+// verification of certificates is reported loudly, as it exposes credentials
+// to anyone able to intercept the connection.
+func NewTLSConfig(endpoint string, options TLSOptions) (*tls.Config, error) {
+	if options == (TLSOptions{}) {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		ServerName: options.ServerName,
+	}
+	if options.CAPath != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, err
+		}
+		bundle, err := os.ReadFile(options.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("could not append ca-bundle at path %s to existing certificates", options.CAPath)
+		}
+		config.RootCAs = pool
+	}
+	if options.InsecureSkipVerify {
+		log.Warnf("TLS certificate verification is DISABLED for %s: connections can be intercepted, including their credentials. Configure a CA bundle instead of insecure-skip-verify where possible.", endpoint)
+		config.InsecureSkipVerify = true
+	}
+
+	return config, nil
+}
+
+const (
+	redacted = "<redacted>"
+
+	// httpLogBodyLimit is the maximum number of bytes of a body to log
+	httpLogBodyLimit = 1024
+)
+
+// sensitiveNames identifies headers and fields whose value should never be
+// logged, by (case-insensitive) substring.
+var sensitiveNames = []string{"authorization", "cookie", "token", "password", "secret", "api-key", "api_key", "apikey", "securejsondata", "securesettings"}
+
+// sensitiveExactNames identifies fields whose value should never be logged,
+// by (case-insensitive) name, as a substring would match too many fields:
+// e.g. `key` holds the service account tokens created by Grafana.
+var sensitiveExactNames = []string{"key"}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	if slices.Contains(sensitiveExactNames, name) {
+		return true
+	}
+	for _, sensitive := range sensitiveNames {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HTTPLoggingTransport logs the requests it sends and the responses it
+// receives, with secrets redacted.
+type HTTPLoggingTransport struct {
+	next   http.RoundTripper
+	logger *log.Entry
+}
+
+// NewHTTPLoggingTransport is an HTTPTransportDecorator logging requests and responses
+func NewHTTPLoggingTransport(next http.RoundTripper) http.RoundTripper {
+	return &HTTPLoggingTransport{
+		next:   next,
+		logger: log.WithField("component", "http"),
+	}
+}
+
+func (transport *HTTPLoggingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	requestBody, err := peekBody(&request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	transport.logger.WithFields(log.Fields{
+		"headers": redactHeaders(request.Header),
+		"body":    redactBody(requestBody),
+	}).Infof("--> %s %s", request.Method, redactURL(request))
+
+	start := time.Now()
+	response, err := transport.next.RoundTrip(request)
+	duration := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		transport.logger.Infof("<-- %s %s failed after %s: %s", request.Method, redactURL(request), duration, err)
+		return response, err
+	}
+
+	responseBody, err := peekBody(&response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	transport.logger.WithFields(log.Fields{
+		"headers": redactHeaders(response.Header),
+		"body":    redactBody(responseBody),
+	}).Infof("<-- %s %s %s (%s)", request.Method, redactURL(request), response.Status, duration)
+
+	return response, nil
+}
+
+// peekBody reads a body, and replaces it with an equivalent one
+func peekBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	content, err := io.ReadAll(*body)
+	_ = (*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(content))
+
+	return content, nil
+}
+
+func redactURL(request *http.Request) string {
+	redactedURL := redactQuery(request.URL)
+	redactedURL.User = nil
+
+	return redactedURL.String()
+}
+
+// redactQuery returns a copy of the given URL whose sensitive query
+// parameters are redacted. The query is left as it is when it has none.
+func redactQuery(u *url.URL) *url.URL {
+	redactedURL := *u
+
+	query := redactedURL.Query()
+	found := false
+	for name := range query {
+		if isSensitive(name) {
+			query.Set(name, redacted)
+			found = true
+		}
+	}
+	if found {
+		redactedURL.RawQuery = query.Encode()
+	}
+
+	return &redactedURL
+}
+
+func redactHeaders(headers http.Header) string {
+	lines := make([]string, 0, len(headers))
+	for name, values := range headers {
+		value := strings.Join(values, ", ")
+		if isSensitive(name) {
+			value = redacted
+		}
+		lines = append(lines, name+": "+value)
+	}
+
+	return strings.Join(lines, "; ")
+}
+
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	body = redactJSON(body)
+	if len(body) > httpLogBodyLimit {
+		return string(body[:httpLogBodyLimit]) + "... (truncated)"
+	}
+
+	return string(body)
+}
+
+// redactJSON redacts the secure fields of JSON documents. Other documents
+// are returned as-is.
+func redactJSON(body []byte) []byte {
+	var content any
+	if err := json.Unmarshal(body, &content); err != nil {
+		return body
+	}
+
+	redactedBody, err := json.Marshal(redactValue(content))
+	if err != nil {
+		return body
+	}
+
+	return redactedBody
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, nested := range v {
+			if isSensitive(key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(nested)
+		}
+	case []any:
+		for i, nested := range v {
+			v[i] = redactValue(nested)
+		}
+	}
+
+	return value
+}
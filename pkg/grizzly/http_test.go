@@ -0,0 +1,222 @@
+package grizzly
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPLoggingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	logger := log.New()
+	logger.SetOutput(out)
+
+	transport := &HTTPLoggingTransport{
+		next:   http.DefaultTransport,
+		logger: log.NewEntry(logger),
+	}
+	client := &http.Client{Transport: transport}
+
+	request, err := http.NewRequest(http.MethodPost, server.URL+"/api/datasources?token=abc", strings.NewReader(`{"name":"prom","secureJsonData":{"basicAuthPassword":"hunter2"}}`))
+	require.NoError(t, err)
+	request.Header.Set("Authorization", "Bearer s3cr3t")
+
+	response, err := client.Do(request)
+	require.NoError(t, err)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	// the bodies are left untouched
+	require.Contains(t, string(body), "hunter2")
+
+	logs := out.String()
+	require.Contains(t, logs, "POST")
+	require.Contains(t, logs, "200 OK")
+	require.Contains(t, logs, `\"name\":\"prom\"`)
+	require.NotContains(t, logs, "hunter2")
+	require.NotContains(t, logs, "s3cr3t")
+	require.NotContains(t, logs, "abc")
+}
+
+func TestHTTPFixtures(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"call":%d,"password":"hunter2"}`, calls)
+	}))
+
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	get := func(client *http.Client) (int, string) {
+		response, err := client.Get(server.URL + "/api/folders?limit=10")
+		require.NoError(t, err)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		return response.StatusCode, string(body)
+	}
+
+	recorder := &http.Client{Transport: NewHTTPFixtures(path).Recorder(http.DefaultTransport)}
+	_, first := get(recorder)
+	_, second := get(recorder)
+	require.Contains(t, first, `"call":1`)
+	require.Contains(t, second, `"call":2`)
+	server.Close()
+
+	fixtures, err := LoadHTTPFixtures(path)
+	require.NoError(t, err)
+	replayer := &http.Client{Transport: fixtures.Replayer(nil)}
+
+	status, body := get(replayer)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `"call":1`)
+	require.NotContains(t, body, "hunter2")
+
+	_, body = get(replayer)
+	require.Contains(t, body, `"call":2`)
+
+	// the last response is repeated
+	_, body = get(replayer)
+	require.Contains(t, body, `"call":2`)
+
+	_, err = replayer.Post(server.URL+"/api/folders", "application/json", strings.NewReader(`{}`))
+	require.ErrorIs(t, err, ErrNoRecordedInteraction)
+}
+
+func TestHTTPFixturesRedactTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id":1,"name":"deploy","key":"glsa_s3cr3t","labels":{"keyword":"ci"}}`)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	recorder := &http.Client{Transport: NewHTTPFixtures(path).Recorder(http.DefaultTransport)}
+	response, err := recorder.Post(server.URL+"/api/serviceaccounts/1/tokens", "application/json", strings.NewReader(`{"name":"deploy","secureSettings":{"url":"hunter2"}}`))
+	require.NoError(t, err)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	// the token is still returned to the handler creating it
+	require.Contains(t, string(body), "glsa_s3cr3t")
+
+	recorded, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(recorded), "glsa_s3cr3t")
+	require.NotContains(t, string(recorded), "hunter2")
+	require.Contains(t, string(recorded), "keyword", "only fields named key are redacted")
+}
+
+func TestHTTPFixturesRedactQueries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"results":[]}`)
+	}))
+
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	recorder := &http.Client{Transport: NewHTTPFixtures(path).Recorder(http.DefaultTransport)}
+	_, err := recorder.Get(server.URL + "/api/search?query=prod&api_key=s3cr3t")
+	require.NoError(t, err)
+	server.Close()
+
+	recorded, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(recorded), "s3cr3t")
+	require.Contains(t, string(recorded), "query=prod")
+
+	// requests are matched on their redacted query
+	fixtures, err := LoadHTTPFixtures(path)
+	require.NoError(t, err)
+	replayer := &http.Client{Transport: fixtures.Replayer(nil)}
+	response, err := replayer.Get(server.URL + "/api/search?query=prod&api_key=s3cr3t")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	_, err = replayer.Get(server.URL + "/api/search?query=dev&api_key=s3cr3t")
+	require.ErrorIs(t, err, ErrNoRecordedInteraction)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caPath, bundle, 0600))
+
+	get := func(t *testing.T, options TLSOptions) error {
+		t.Helper()
+		config, err := NewTLSConfig("test server", options)
+		require.NoError(t, err)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	config, err := NewTLSConfig("test server", TLSOptions{})
+	require.NoError(t, err)
+	require.Nil(t, config, "defaults should apply without options")
+
+	require.Error(t, get(t, TLSOptions{}), "the private CA should not be trusted by default")
+	require.NoError(t, get(t, TLSOptions{CAPath: caPath}))
+
+	out := &bytes.Buffer{}
+	log.SetOutput(out)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	require.NoError(t, get(t, TLSOptions{InsecureSkipVerify: true}))
+	require.Contains(t, out.String(), "TLS certificate verification is DISABLED for test server")
+
+	_, err = NewTLSConfig("test server", TLSOptions{CAPath: filepath.Join(t.TempDir(), "missing.pem")})
+	require.ErrorContains(t, err, "could not read CA bundle")
+}
+
+func TestHTTPHeadersAreNotLogged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Gateway-Key") != "g4t3w4y" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	logger := log.New()
+	logger.SetOutput(out)
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	fixtures := NewHTTPFixtures(path)
+
+	// the headers of contexts are set beneath the decorators of transports
+	transport := &HTTPLoggingTransport{
+		next:   fixtures.Recorder(WithHTTPHeaders(http.DefaultTransport, map[string]string{"X-Gateway-Key": "g4t3w4y"})),
+		logger: log.NewEntry(logger),
+	}
+	response, err := (&http.Client{Transport: transport}).Get(server.URL + "/api/folders")
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	recorded, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "/api/folders")
+	require.NotContains(t, out.String(), "g4t3w4y")
+	require.NotContains(t, string(recorded), "g4t3w4y")
+}
@@ -0,0 +1,202 @@
+package grizzly
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoRecordedInteraction is returned when replaying a request that wasn't
+// recorded.
+var ErrNoRecordedInteraction = errors.New("no recorded interaction")
+
+// HTTPInteraction is a request sent to a remote endpoint, and the response
+// it got.
+type HTTPInteraction struct {
+	Request  HTTPFixtureRequest  `yaml:"request"`
+	Response HTTPFixtureResponse `yaml:"response"`
+}
+
+type HTTPFixtureRequest struct {
+	Method string `yaml:"method"`
+	// URL is the path and query of the request: fixtures don't depend on
+	// the host they were recorded against. Sensitive query parameters are
+	// redacted.
+	URL  string `yaml:"url"`
+	Body string `yaml:"body,omitempty"`
+}
+
+type HTTPFixtureResponse struct {
+	Status      int    `yaml:"status"`
+	ContentType string `yaml:"contentType,omitempty"`
+	Body        string `yaml:"body,omitempty"`
+}
+
+// HTTPFixtures records the interactions with remote endpoints to a file, or
+// replays them from it. Secure fields are redacted from the recorded bodies.
+type HTTPFixtures struct {
+	path string
+
+	lock         sync.Mutex
+	interactions []HTTPInteraction
+	// replayed counts the number of times each request was replayed, so
+	// that identical requests get the responses in the recorded order
+	replayed map[string]int
+}
+
+// NewHTTPFixtures creates an empty set of fixtures, to be recorded in the
+// given file.
+func NewHTTPFixtures(path string) *HTTPFixtures {
+	return &HTTPFixtures{
+		path:     path,
+		replayed: map[string]int{},
+	}
+}
+
+// LoadHTTPFixtures reads fixtures previously recorded in the given file.
+func LoadHTTPFixtures(path string) (*HTTPFixtures, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := NewHTTPFixtures(path)
+	if err := yaml.Unmarshal(content, &fixtures.interactions); err != nil {
+		return nil, ParseError{File: path, Err: err}
+	}
+
+	return fixtures, nil
+}
+
+// Recorder is an HTTPTransportDecorator recording every interaction.
+func (fixtures *HTTPFixtures) Recorder(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		requestBody, err := peekBody(&request.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := next.RoundTrip(request)
+		if err != nil {
+			return response, err
+		}
+
+		responseBody, err := peekBody(&response.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		interaction := HTTPInteraction{
+			Request: fixtureRequest(request, requestBody),
+			Response: HTTPFixtureResponse{
+				Status:      response.StatusCode,
+				ContentType: response.Header.Get("Content-Type"),
+				Body:        string(redactJSON(responseBody)),
+			},
+		}
+
+		if err := fixtures.record(interaction); err != nil {
+			return nil, fmt.Errorf("could not record HTTP interaction: %w", err)
+		}
+
+		return response, nil
+	})
+}
+
+// Replayer is an HTTPTransportDecorator answering requests with the
+// recorded responses. No request reaches the remote endpoints.
+func (fixtures *HTTPFixtures) Replayer(_ http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		requestBody, err := peekBody(&request.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		interaction, err := fixtures.replay(fixtureRequest(request, requestBody))
+		if err != nil {
+			return nil, err
+		}
+
+		header := http.Header{}
+		if interaction.Response.ContentType != "" {
+			header.Set("Content-Type", interaction.Response.ContentType)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       request,
+		}, nil
+	})
+}
+
+func (fixtures *HTTPFixtures) record(interaction HTTPInteraction) error {
+	fixtures.lock.Lock()
+	defer fixtures.lock.Unlock()
+
+	fixtures.interactions = append(fixtures.interactions, interaction)
+
+	// the whole file is written after each interaction, so that nothing
+	// is lost if grizzly exits early
+	content, err := yaml.Marshal(fixtures.interactions)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(fixtures.path, content, 0644)
+}
+
+func (fixtures *HTTPFixtures) replay(request HTTPFixtureRequest) (HTTPInteraction, error) {
+	fixtures.lock.Lock()
+	defer fixtures.lock.Unlock()
+
+	key := request.Method + " " + request.URL + "\n" + request.Body
+
+	var candidates []HTTPInteraction
+	for _, interaction := range fixtures.interactions {
+		if interaction.Request == request {
+			candidates = append(candidates, interaction)
+		}
+	}
+	if len(candidates) == 0 {
+		return HTTPInteraction{}, fmt.Errorf("%w for %s %s in %s", ErrNoRecordedInteraction, request.Method, request.URL, fixtures.path)
+	}
+
+	// once all the recorded responses were used, the last one is repeated
+	i := fixtures.replayed[key]
+	if i >= len(candidates) {
+		i = len(candidates) - 1
+	}
+	fixtures.replayed[key]++
+
+	return candidates[i], nil
+}
+
+// fixtureRequest describes a request the way it is recorded, and matched
+// when replaying: sensitive query parameters and fields are redacted.
+func fixtureRequest(request *http.Request, body []byte) HTTPFixtureRequest {
+	return HTTPFixtureRequest{
+		Method: request.Method,
+		URL:    redactQuery(request.URL).RequestURI(),
+		Body:   string(bytes.TrimSpace(redactJSON(body))),
+	}
+}
+
+type roundTripperFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
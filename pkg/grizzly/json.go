@@ -1,10 +1,14 @@
 package grizzly
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/hashicorp/go-multierror"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -21,30 +25,179 @@ func NewJSONParser(registry Registry) *JSONParser {
 }
 
 func (parser *JSONParser) Accept(file string) bool {
-	return filepath.Ext(file) == ".json"
+	switch filepath.Ext(file) {
+	case ".json", ".jsonc", ".json5":
+		return true
+	}
+	return false
 }
 
 // Parse evaluates a JSON file and parses it into resources
 func (parser *JSONParser) Parse(file string, options ParserOptions) (Resources, error) {
 	parser.logger.WithField("file", file).Debug("Parsing file")
 
-	f, err := os.Open(file)
+	content, err := os.ReadFile(file)
 	if err != nil {
 		return Resources{}, err
 	}
-	defer f.Close()
+	// comments and trailing commas are tolerated, as in files annotated by
+	// hand. Rewriting such files would lose their comments.
+	content, relaxed := relaxJSON(content)
 
 	var m any
-	err = json.NewDecoder(f).Decode(&m)
-	if err != nil {
-		return Resources{}, err
+	if err := json.Unmarshal(content, &m); err != nil {
+		return Resources{}, jsonError(content, err)
 	}
 
 	source := Source{
 		Format:     formatJSON,
 		Path:       file,
-		Rewritable: true,
+		Rewritable: filepath.Ext(file) == ".json" && !relaxed,
+	}
+
+	if _, isList := m.([]any); !isList {
+		return parseAny(parser.registry, m, options.DefaultResourceKind, options.DefaultFolderUID, source)
+	}
+
+	// items of lists are decoded one by one to locate their errors
+	resources := NewResources()
+	var finalErr error
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if _, err := decoder.Token(); err != nil {
+		return Resources{}, jsonError(content, err)
+	}
+	for i := 0; decoder.More(); i++ {
+		// the decoder stops before the separators preceding the next item
+		offset := decoder.InputOffset()
+		for offset < int64(len(content)) && bytes.IndexByte([]byte(" \t\r\n,"), content[offset]) >= 0 {
+			offset++
+		}
+		line, _ := positionAt(content, offset)
+
+		var item any
+		if err := decoder.Decode(&item); err != nil {
+			return resources, multierror.Append(finalErr, jsonError(content, err))
+		}
+
+		parsedResources, err := parseAny(parser.registry, item, options.DefaultResourceKind, options.DefaultFolderUID, source)
+		if err != nil {
+			finalErr = multierror.Append(finalErr, newResourceError(item, fmt.Sprintf("item %d", i), line, err))
+		}
+		resources.Merge(parsedResources)
+	}
+
+	return resources, finalErr
+}
+
+// jsonError locates decoding errors in the JSON document
+func jsonError(content []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	var offset int64
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err
+	}
+
+	// offsets point right after the offending character
+	if offset > 0 {
+		offset--
 	}
+	line, column := positionAt(content, offset)
 
-	return parseAny(parser.registry, m, options.DefaultResourceKind, options.DefaultFolderUID, source)
+	return ResourceError{Line: line, Column: column, Err: err}
+}
+
+// positionAt returns the line and column of an offset in a document
+func positionAt(content []byte, offset int64) (int, int) {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+
+	return line, column
+}
+
+// relaxJSON blanks out the comments and trailing commas of a JSON document,
+// keeping the positions of everything else. It returns whether there were
+// any.
+func relaxJSON(content []byte) ([]byte, bool) {
+	relaxed := content
+	changed := false
+	blank := func(from, to int) {
+		if !changed {
+			relaxed = bytes.Clone(content)
+			changed = true
+		}
+		for i := from; i < to; i++ {
+			if relaxed[i] != '\n' {
+				relaxed[i] = ' '
+			}
+		}
+	}
+
+	// comments first, so that they don't hide trailing commas
+	for i := 0; i < len(relaxed); i++ {
+		switch {
+		case relaxed[i] == '"':
+			i = skipJSONString(relaxed, i)
+		case bytes.HasPrefix(relaxed[i:], []byte("//")):
+			end := bytes.IndexByte(relaxed[i:], '\n')
+			if end < 0 {
+				end = len(relaxed) - i
+			}
+			blank(i, i+end)
+			i += end
+		case bytes.HasPrefix(relaxed[i:], []byte("/*")):
+			end := bytes.Index(relaxed[i+2:], []byte("*/"))
+			if end < 0 {
+				// left as is, for decoding to report it
+				continue
+			}
+			blank(i, i+2+end+2)
+			i += 2 + end + 1
+		}
+	}
+
+	for i := 0; i < len(relaxed); i++ {
+		switch relaxed[i] {
+		case '"':
+			i = skipJSONString(relaxed, i)
+		case ',':
+			// a trailing comma follows a value and precedes the end of its
+			// object or array
+			previous := bytes.TrimRight(relaxed[:i], " \t\r\n")
+			next := bytes.TrimLeft(relaxed[i+1:], " \t\r\n")
+			if len(previous) == 0 || bytes.IndexByte([]byte("{[,"), previous[len(previous)-1]) >= 0 {
+				continue
+			}
+			if len(next) > 0 && (next[0] == '}' || next[0] == ']') {
+				blank(i, i+1)
+			}
+		}
+	}
+
+	return relaxed, changed
+}
+
+// skipJSONString returns the offset of the quote ending the string starting
+// at an offset
+func skipJSONString(content []byte, start int) int {
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return len(content)
 }
@@ -2,21 +2,38 @@ package grizzly
 
 import (
 	_ "embed" // used to embed grizzly.jsonnet script below
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/google/go-jsonnet"
 	"github.com/google/go-jsonnet/ast"
 	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
 type JsonnetParser struct {
 	registry     Registry
 	jsonnetPaths []string
-	logger       *log.Entry
+	// cache, if set, caches the output of evaluated files
+	cache *JsonnetEvalCache
+	// env are the environment variables readable with the getEnv native
+	// function
+	env []string
+	// aliases map prefixes of import paths to other locations
+	aliases map[string]string
+	// lock, if set, pins the remote imports to their checksum
+	lock   *JsonnetImportsLock
+	logger *log.Entry
 }
 
 func NewJsonnetParser(registry Registry, jsonnetPaths []string) *JsonnetParser {
@@ -44,7 +61,7 @@ func (parser *JsonnetParser) Parse(file string, options ParserOptions) (Resource
 	if err != nil {
 		return Resources{}, err
 	}
-	result, err := evaluateJsonnet(file, currentWorkingDirectory, parser.jsonnetPaths)
+	result, err := parser.evaluate(file, currentWorkingDirectory, options)
 	if err != nil {
 		return Resources{}, err
 	}
@@ -62,10 +79,45 @@ func (parser *JsonnetParser) Parse(file string, options ParserOptions) (Resource
 	return parseAny(parser.registry, data, options.DefaultResourceKind, options.DefaultFolderUID, source)
 }
 
+// evaluate evaluates a jsonnet file, unless its output is cached
+func (parser *JsonnetParser) evaluate(file, wd string, options ParserOptions) (string, error) {
+	// the environment is read once, so that evaluations are reproducible
+	env := make(map[string]string, len(parser.env))
+	for _, name := range parser.env {
+		env[name] = os.Getenv(name)
+	}
+
+	if parser.cache == nil {
+		result, _, err := evaluateJsonnet(file, wd, parser.jsonnetPaths, parser.aliases, parser.lock, options.ExtVars, options.TLAs, env)
+		return result, err
+	}
+
+	key := jsonnetEvalKey(file, wd, parser.jsonnetPaths, parser.aliases, options.ExtVars, options.TLAs, env)
+	if result, found := parser.cache.Get(key); found {
+		parser.logger.WithField("file", file).Debug("Using cached evaluation")
+		return result, nil
+	}
+
+	result, imports, err := evaluateJsonnet(file, wd, parser.jsonnetPaths, parser.aliases, parser.lock, options.ExtVars, options.TLAs, env)
+	if err != nil {
+		return "", err
+	}
+	if err := parser.cache.Put(key, imports, result); err != nil {
+		parser.logger.WithField("file", file).Debugf("Could not cache evaluation: %v", err)
+	}
+
+	return result, nil
+}
+
 // extendedImporter does stuff
 type extendedImporter struct {
 	loaders    []importLoader    // for loading jsonnet from somewhere. First one that returns non-nil is used
 	processors []importProcessor // for post-processing (e.g. yaml -> json)
+	// imported caches the contents returned for each location, as Jsonnet
+	// expects the same instance when a file is imported again
+	imported map[string]jsonnet.Contents
+	// aliases map prefixes of import paths to other locations
+	aliases map[string]string
 }
 
 type importLoader func(importedFrom, importedPath string) (c *jsonnet.Contents, foundAt string, err error)
@@ -77,15 +129,74 @@ type importProcessor func(contents, foundAt string) (c *jsonnet.Contents, err er
 //go:embed grizzly.jsonnet
 var script string
 
-func evaluateJsonnet(jsonnetFile, wd string, jpath []string) (string, error) {
-	s := fmt.Sprintf(script, jsonnetFile)
+// tlaNameRegex matches the names of top-level arguments, which must be valid
+// Jsonnet identifiers
+var tlaNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// wrapperScript returns the grizzly.jsonnet script evaluating a file. When
+// the file evaluates to a function, it is called with the given top-level
+// arguments, passed by name.
+func wrapperScript(jsonnetFile string, tlaNames []string) (string, error) {
+	sorted := append([]string{}, tlaNames...)
+	sort.Strings(sorted)
+
+	args := make([]string, 0, len(sorted))
+	for _, name := range sorted {
+		if !tlaNameRegex.MatchString(name) {
+			return "", fmt.Errorf("invalid top-level argument name %q", name)
+		}
+		args = append(args, name+"="+name)
+	}
+
+	return fmt.Sprintf(script, strings.Join(sorted, ", "), jsonnetFile, strings.Join(args, ", ")), nil
+}
+
+// evaluateJsonnet evaluates a jsonnet file, returning its output and the
+// locations of the files it imported, including itself
+func evaluateJsonnet(jsonnetFile, wd string, jpath []string, aliases map[string]string, lock *JsonnetImportsLock, extVars map[string]ExtVar, tlas map[string]ExtVar, env map[string]string) (string, []string, error) {
+	tlaNames := make([]string, 0, len(tlas))
+	for name := range tlas {
+		tlaNames = append(tlaNames, name)
+	}
+	s, err := wrapperScript(jsonnetFile, tlaNames)
+	if err != nil {
+		return "", nil, err
+	}
+
+	importer := newExtendedImporter(jsonnetFile, wd, jpath, lock)
+	importer.aliases = aliases
 	vm := jsonnet.MakeVM()
-	vm.Importer(newExtendedImporter(jsonnetFile, wd, jpath))
-	vm.NativeFunction(escapeStringRegexNativeFunc())
-	vm.NativeFunction(regexMatchNativeFunc())
-	vm.NativeFunction(regexSubstNativeFunc())
+	vm.Importer(importer)
+	for _, function := range nativeFunctions(env) {
+		vm.NativeFunction(function.function)
+	}
+	for name, extVar := range extVars {
+		if extVar.Code {
+			vm.ExtCode(name, extVar.Value)
+		} else {
+			vm.ExtVar(name, extVar.Value)
+		}
+	}
+	for name, tla := range tlas {
+		if tla.Code {
+			vm.TLACode(name, tla.Value)
+		} else {
+			vm.TLAVar(name, tla.Value)
+		}
+	}
+
+	result, err := vm.EvaluateAnonymousSnippet(jsonnetFile, s)
+	if err != nil {
+		return "", nil, err
+	}
+
+	imports := make([]string, 0, len(importer.imported))
+	for location := range importer.imported {
+		imports = append(imports, location)
+	}
+	sort.Strings(imports)
 
-	return vm.EvaluateAnonymousSnippet(jsonnetFile, s)
+	return result, imports, nil
 }
 
 // newFileLoader returns an importLoader that uses jsonnet.FileImporter to source
@@ -98,10 +209,17 @@ func newFileLoader(fi *jsonnet.FileImporter) importLoader {
 	}
 }
 
-func newExtendedImporter(jsonnetFile, path string, jpath []string) *extendedImporter {
+func newExtendedImporter(jsonnetFile, path string, jpath []string, lock *JsonnetImportsLock) *extendedImporter {
 	absolutePaths := make([]string, len(jpath)*2+1)
 	absolutePaths = append(absolutePaths, path)
 	jsonnetDir := filepath.Dir(jsonnetFile)
+	// the vendor directory of the closest jsonnetfile.json comes before the
+	// library paths, so that these take precedence
+	if root, found := FindJsonnetRoot(jsonnetDir); found {
+		if stat, err := os.Stat(filepath.Join(root, JsonnetVendorDir)); err == nil && stat.IsDir() {
+			absolutePaths = append(absolutePaths, filepath.Join(root, JsonnetVendorDir))
+		}
+	}
 	for _, p := range jpath {
 		if !filepath.IsAbs(p) {
 			p = filepath.Join(jsonnetDir, p)
@@ -114,17 +232,27 @@ func newExtendedImporter(jsonnetFile, path string, jpath []string) *extendedImpo
 		}
 		absolutePaths = append(absolutePaths, p)
 	}
+	cacheDir, err := DefaultJsonnetCacheDir()
+	if err != nil {
+		log.Debugf("not caching remote imports: %v", err)
+	}
+	client := &http.Client{Transport: DecorateHTTPTransport(nil), Timeout: httpImportTimeout}
+
 	return &extendedImporter{
 		loaders: []importLoader{
+			newHTTPLoader(client, cacheDir, lock),
 			newFileLoader(&jsonnet.FileImporter{
 				JPaths: absolutePaths,
 			})},
-		processors: []importProcessor{},
+		processors: []importProcessor{yamlProcessor},
+		imported:   map[string]jsonnet.Contents{},
 	}
 }
 
 // Import implements the functionality offered by the extendedImporter
 func (i *extendedImporter) Import(importedFrom, importedPath string) (contents jsonnet.Contents, foundAt string, err error) {
+	importedPath = resolveImportAlias(i.aliases, importedPath)
+
 	// load using loader
 	for _, loader := range i.loaders {
 		c, f, err := loader(importedFrom, importedPath)
@@ -138,6 +266,10 @@ func (i *extendedImporter) Import(importedFrom, importedPath string) (contents j
 		}
 	}
 
+	if cached, ok := i.imported[foundAt]; ok {
+		return cached, foundAt, nil
+	}
+
 	// check if needs postprocessing
 	for _, processor := range i.processors {
 		c, err := processor(contents.String(), foundAt)
@@ -150,9 +282,64 @@ func (i *extendedImporter) Import(importedFrom, importedPath string) (contents j
 		}
 	}
 
+	if i.imported != nil {
+		i.imported[foundAt] = contents
+	}
 	return contents, foundAt, nil
 }
 
+// resolveImportAlias replaces the alias an import path starts with by its
+// target. The longest matching alias applies.
+func resolveImportAlias(aliases map[string]string, importedPath string) string {
+	longest := ""
+	for alias := range aliases {
+		if (importedPath == alias || strings.HasPrefix(importedPath, alias+"/")) && len(alias) > len(longest) {
+			longest = alias
+		}
+	}
+	if longest == "" {
+		return importedPath
+	}
+
+	return strings.TrimSuffix(aliases[longest], "/") + strings.TrimPrefix(importedPath, longest)
+}
+
+// yamlProcessor converts imported YAML files to JSON, so that they can be
+// imported as data. A file holding several documents is converted to an
+// array. As JSON is valid YAML, `std.parseYaml(importstr ...)` still works.
+func yamlProcessor(contents, foundAt string) (*jsonnet.Contents, error) {
+	extension := filepath.Ext(foundAt)
+	if extension != ".yaml" && extension != ".yml" {
+		return nil, nil
+	}
+
+	var documents []any
+	decoder := yaml.NewDecoder(strings.NewReader(contents))
+	for {
+		var document any
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", foundAt, err)
+		}
+		documents = append(documents, document)
+	}
+
+	var data any = documents
+	if len(documents) == 1 {
+		data = documents[0]
+	}
+	converted, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("converting %s to JSON: %w", foundAt, err)
+	}
+
+	c := jsonnet.MakeContents(string(converted))
+	return &c, nil
+}
+
 // escapeStringRegexNativeFunc escapes all regular expression metacharacters
 // and returns a regular expression that matches the literal text.
 func escapeStringRegexNativeFunc() *jsonnet.NativeFunction {
@@ -192,3 +379,108 @@ func regexSubstNativeFunc() *jsonnet.NativeFunction {
 		},
 	}
 }
+
+// hashNativeFunc returns the hex-encoded digest of the given string, for
+// example to derive stable UIDs from dashboard titles.
+func hashNativeFunc(name string, newHash func() hash.Hash) *jsonnet.NativeFunction {
+	return &jsonnet.NativeFunction{
+		Name:   name,
+		Params: ast.Identifiers{"str"},
+		Func: func(s []interface{}) (interface{}, error) {
+			str, ok := s[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: expected a string, got %T", name, s[0])
+			}
+			digest := newHash()
+			digest.Write([]byte(str))
+			return hex.EncodeToString(digest.Sum(nil)), nil
+		},
+	}
+}
+
+// parseYamlNativeFunc parses a YAML string into an array of the documents
+// it holds, like its Tanka counterpart.
+func parseYamlNativeFunc() *jsonnet.NativeFunction {
+	return &jsonnet.NativeFunction{
+		Name:   "parseYaml",
+		Params: ast.Identifiers{"yaml"},
+		Func: func(data []interface{}) (interface{}, error) {
+			input, ok := data[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("parseYaml: expected a string, got %T", data[0])
+			}
+
+			documents := []interface{}{}
+			decoder := yaml.NewDecoder(strings.NewReader(input))
+			for {
+				var document interface{}
+				err := decoder.Decode(&document)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					return nil, fmt.Errorf("parseYaml: %w", err)
+				}
+				documents = append(documents, document)
+			}
+
+			// YAML values, e.g. integers, are turned into the JSON values
+			// Jsonnet understands
+			converted, err := json.Marshal(documents)
+			if err != nil {
+				return nil, fmt.Errorf("parseYaml: %w", err)
+			}
+			var result interface{}
+			if err := json.Unmarshal(converted, &result); err != nil {
+				return nil, fmt.Errorf("parseYaml: %w", err)
+			}
+			return result, nil
+		},
+	}
+}
+
+// manifestYamlDocNativeFunc manifests a value as a YAML document, indented
+// with two spaces and with object keys sorted.
+func manifestYamlDocNativeFunc() *jsonnet.NativeFunction {
+	return &jsonnet.NativeFunction{
+		Name:   "manifestYamlDoc",
+		Params: ast.Identifiers{"value"},
+		Func: func(data []interface{}) (interface{}, error) {
+			var buffer strings.Builder
+			encoder := yaml.NewEncoder(&buffer)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(data[0]); err != nil {
+				return nil, fmt.Errorf("manifestYamlDoc: %w", err)
+			}
+			if err := encoder.Close(); err != nil {
+				return nil, fmt.Errorf("manifestYamlDoc: %w", err)
+			}
+
+			return buffer.String(), nil
+		},
+	}
+}
+
+// getEnvNativeFunc returns the value of an environment variable, or the
+// given default when it is empty. Only the variables of env can be read, so
+// that Jsonnet code doesn't depend on the whole environment.
+func getEnvNativeFunc(env map[string]string) *jsonnet.NativeFunction {
+	return &jsonnet.NativeFunction{
+		Name:   "getEnv",
+		Params: ast.Identifiers{"name", "default"},
+		Func: func(s []interface{}) (interface{}, error) {
+			name, ok := s[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("getEnv: expected a string, got %T", s[0])
+			}
+			value, allowed := env[name]
+			if !allowed {
+				return nil, fmt.Errorf("getEnv: environment variable %s isn't allowed: add it to the jsonnet-env of the context", name)
+			}
+			if value == "" {
+				return s[1], nil
+			}
+			return value, nil
+		},
+	}
+}
@@ -0,0 +1,153 @@
+package grizzly
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultJsonnetEvalCacheDir returns the directory caching the output of
+// evaluated Jsonnet files.
+func DefaultJsonnetEvalCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not locate the cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, "grizzly", "eval"), nil
+}
+
+// JsonnetEvalCache stores the output of evaluated Jsonnet files, so that
+// files whose imports are unchanged aren't evaluated again. Entries are
+// addressed by the evaluation inputs (file, library paths, import aliases,
+// external variables, top-level arguments and readable environment), and
+// record the checksum of every file imported along the way: an entry is only
+// used while they all match.
+type JsonnetEvalCache struct {
+	dir string
+}
+
+func NewJsonnetEvalCache(dir string) *JsonnetEvalCache {
+	return &JsonnetEvalCache{dir: dir}
+}
+
+type jsonnetEvalEntry struct {
+	// Imports are the checksums of the imported files, by location
+	Imports map[string]string `json:"imports"`
+	Output  string            `json:"output"`
+}
+
+// jsonnetEvalKey returns the key of the cache entry of an evaluation
+func jsonnetEvalKey(jsonnetFile, wd string, jpath []string, aliases map[string]string, extVars map[string]ExtVar, tlas map[string]ExtVar, env map[string]string) string {
+	hash := sha256.New()
+	write := func(values ...string) {
+		for _, value := range values {
+			fmt.Fprintf(hash, "%d:%s", len(value), value)
+		}
+	}
+	writeVars := func(vars map[string]ExtVar) {
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		write(fmt.Sprint(len(names)))
+		for _, name := range names {
+			write(name, fmt.Sprint(vars[name].Code), vars[name].Value)
+		}
+	}
+
+	if absolute, err := filepath.Abs(jsonnetFile); err == nil {
+		jsonnetFile = absolute
+	}
+	write(script, jsonnetFile, wd, fmt.Sprint(len(jpath)))
+	write(jpath...)
+	aliasNames := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		aliasNames = append(aliasNames, alias)
+	}
+	sort.Strings(aliasNames)
+	write(fmt.Sprint(len(aliasNames)))
+	for _, alias := range aliasNames {
+		write(alias, aliases[alias])
+	}
+	writeVars(extVars)
+	writeVars(tlas)
+	envVars := make(map[string]ExtVar, len(env))
+	for name, value := range env {
+		envVars[name] = ExtVar{Value: value}
+	}
+	writeVars(envVars)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (cache *JsonnetEvalCache) path(key string) string {
+	return filepath.Join(cache.dir, key[:2], key+".json")
+}
+
+// Get returns the cached output of an evaluation, if none of its imports
+// changed since
+func (cache *JsonnetEvalCache) Get(key string) (string, bool) {
+	content, err := os.ReadFile(cache.path(key))
+	if err != nil {
+		return "", false
+	}
+
+	entry := jsonnetEvalEntry{}
+	if err := json.Unmarshal(content, &entry); err != nil || len(entry.Imports) == 0 {
+		return "", false
+	}
+	for location, checksum := range entry.Imports {
+		current, ok := importChecksum(location)
+		if !ok || current != checksum {
+			return "", false
+		}
+	}
+
+	return entry.Output, true
+}
+
+// Put records the output of an evaluation, along with the checksums of the
+// imported files. Evaluations importing remote files which aren't pinned
+// with a checksum aren't cached, as their content may change.
+func (cache *JsonnetEvalCache) Put(key string, imports []string, output string) error {
+	entry := jsonnetEvalEntry{
+		Imports: make(map[string]string, len(imports)),
+		Output:  output,
+	}
+	for _, location := range imports {
+		checksum, ok := importChecksum(location)
+		if !ok {
+			return nil
+		}
+		entry.Imports[location] = checksum
+	}
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(cache.path(key), content, 0600)
+}
+
+// importChecksum returns the checksum identifying the content of an
+// imported file. Remote imports are identified by their pinned checksum.
+func importChecksum(location string) (string, bool) {
+	if strings.HasPrefix(location, "https://") {
+		_, pinned, found := strings.Cut(location, "#"+checksumFragment)
+		return pinned, found && pinned != ""
+	}
+
+	content, err := os.ReadFile(location)
+	if err != nil {
+		return "", false
+	}
+	return checksumOf(content), true
+}
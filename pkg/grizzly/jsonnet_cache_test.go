@@ -0,0 +1,66 @@
+package grizzly_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJsonnetEvalCache(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	cacheDir := t.TempDir()
+	dir := t.TempDir()
+	main := filepath.Join(dir, "dashboard.jsonnet")
+	lib := filepath.Join(dir, "title.libsonnet")
+	require.NoError(t, os.WriteFile(main, []byte(`{
+  apiVersion: 'grizzly.grafana.com/v1alpha1',
+  kind: 'Dashboard',
+  metadata: { name: 'cached', folder: 'general' },
+  spec: { title: import 'title.libsonnet', refresh: std.extVar('refresh') },
+}`), 0644))
+	require.NoError(t, os.WriteFile(lib, []byte(`'Version 1'`), 0644))
+
+	title := func(t *testing.T, refresh string) any {
+		t.Helper()
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserJsonnetCache(cacheDir))
+		resources, err := parser.Parse(main, grizzly.ParserOptions{
+			ExtVars: map[string]grizzly.ExtVar{"refresh": {Value: refresh}},
+		})
+		require.NoError(t, err)
+		resource, found := resources.Find(grizzly.NewResourceRef("Dashboard", "cached"))
+		require.True(t, found)
+		return resource.GetSpecValue("title")
+	}
+	// tamper replaces the title in the cached outputs, to tell when they
+	// are used
+	tamper := func(t *testing.T) {
+		t.Helper()
+		entries, err := filepath.Glob(filepath.Join(cacheDir, "*", "*.json"))
+		require.NoError(t, err)
+		require.NotEmpty(t, entries)
+		for _, path := range entries {
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			entry := map[string]any{}
+			require.NoError(t, json.Unmarshal(content, &entry))
+			entry["output"] = strings.ReplaceAll(entry["output"].(string), "Version", "Cached")
+			content, err = json.Marshal(entry)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, content, 0600))
+		}
+	}
+
+	require.Equal(t, "Version 1", title(t, "1m"))
+	tamper(t)
+	require.Equal(t, "Cached 1", title(t, "1m"), "unchanged files should not be evaluated again")
+	require.Equal(t, "Version 1", title(t, "5m"), "external variables should be part of the key")
+
+	require.NoError(t, os.WriteFile(lib, []byte(`'Version 2'`), 0644))
+	require.Equal(t, "Version 2", title(t, "1m"), "changes to imported files should be picked up")
+}
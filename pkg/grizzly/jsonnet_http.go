@@ -0,0 +1,195 @@
+package grizzly
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-jsonnet"
+	log "github.com/sirupsen/logrus"
+)
+
+// httpImportTimeout bounds the time spent fetching a single remote import
+const httpImportTimeout = 30 * time.Second
+
+// checksumFragment pins the content of a remote import, as in
+// `import 'https://example.com/lib.libsonnet#sha256=<hex digest>'`
+const checksumFragment = "sha256="
+
+// httpImports caches the remote imports fetched during this run, by URL.
+// Imports that were not found are cached as nil.
+var httpImports = struct {
+	sync.Mutex
+	contents map[string]*string
+}{contents: map[string]*string{}}
+
+// DefaultJsonnetCacheDir returns the directory caching the remote Jsonnet
+// imports pinned with a checksum.
+func DefaultJsonnetCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not locate the cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, "grizzly", "jsonnet"), nil
+}
+
+// newHTTPLoader returns an importLoader fetching `https://` import paths, and
+// the paths imported relatively from remote files. Remote imports are cached
+// for the duration of the run. Imports pinned with a `#sha256=` fragment are
+// checked against their checksum, and cached in cacheDir, if not empty,
+// across runs. Other imports are checked against the lock, if any, and
+// recorded in it.
+func newHTTPLoader(client *http.Client, cacheDir string, lock *JsonnetImportsLock) importLoader {
+	return func(importedFrom, importedPath string) (*jsonnet.Contents, string, error) {
+		location, relative, err := resolveHTTPImport(importedFrom, importedPath)
+		if err != nil || location == nil {
+			return nil, "", err
+		}
+
+		foundAt := location.String()
+		checksum := ""
+		if strings.HasPrefix(location.Fragment, checksumFragment) {
+			checksum = strings.ToLower(strings.TrimPrefix(location.Fragment, checksumFragment))
+		}
+		location.Fragment = ""
+
+		locked := false
+		if checksum == "" && lock != nil {
+			if checksum, err = lock.checksum(location.String()); err != nil {
+				return nil, "", err
+			}
+			locked = checksum != ""
+		}
+
+		content, found, err := fetchHTTPImport(client, location.String(), checksum, cacheDir)
+		if err != nil {
+			if locked {
+				err = fmt.Errorf("%w, as locked in %s", err, lock.file)
+			}
+			return nil, "", fmt.Errorf("importing %s: %w", foundAt, err)
+		}
+		if found && checksum == "" && lock != nil {
+			if err := lock.record(location.String(), checksumOf([]byte(content))); err != nil {
+				return nil, "", fmt.Errorf("locking %s: %w", foundAt, err)
+			}
+		}
+		if !found {
+			if relative {
+				// let the other loaders look it up in the library paths
+				return nil, "", nil
+			}
+			return nil, "", fmt.Errorf("importing %s: not found", foundAt)
+		}
+
+		contents := jsonnet.MakeContents(content)
+		return &contents, foundAt, nil
+	}
+}
+
+// resolveHTTPImport returns the URL of an import, if remote. Relative is true
+// for paths imported relatively from a remote file.
+func resolveHTTPImport(importedFrom, importedPath string) (*url.URL, bool, error) {
+	if strings.HasPrefix(importedPath, "https://") {
+		location, err := url.Parse(importedPath)
+		return location, false, err
+	}
+	if !strings.HasPrefix(importedFrom, "https://") || strings.Contains(importedPath, "://") || filepath.IsAbs(importedPath) {
+		return nil, false, nil
+	}
+
+	base, err := url.Parse(importedFrom)
+	if err != nil {
+		return nil, false, err
+	}
+	location, err := base.Parse(importedPath)
+	return location, true, err
+}
+
+func fetchHTTPImport(client *http.Client, location, checksum, cacheDir string) (string, bool, error) {
+	// the cache directory is addressed by checksum: whatever the URL, a
+	// cached file matching the checksum is the expected content
+	cacheFile := ""
+	if checksum != "" && cacheDir != "" {
+		cacheFile = filepath.Join(cacheDir, checksum)
+		if data, err := os.ReadFile(cacheFile); err == nil && checksumOf(data) == checksum {
+			return string(data), true, nil
+		}
+	}
+
+	httpImports.Lock()
+	cachedContent, cached := httpImports.contents[location]
+	httpImports.Unlock()
+	if cached && cachedContent == nil {
+		return "", false, nil
+	}
+
+	content := ""
+	if cached {
+		content = *cachedContent
+	}
+
+	if !cached {
+		log.WithField("url", location).Debug("Fetching remote import")
+		response, err := client.Get(location)
+		if err != nil {
+			return "", false, err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode == http.StatusNotFound {
+			httpImports.Lock()
+			httpImports.contents[location] = nil
+			httpImports.Unlock()
+			return "", false, nil
+		}
+		if response.StatusCode != http.StatusOK {
+			return "", false, fmt.Errorf("unexpected status %s", response.Status)
+		}
+		data, err := io.ReadAll(response.Body)
+		if err != nil {
+			return "", false, err
+		}
+		content = string(data)
+	}
+
+	if checksum != "" {
+		if actual := checksumOf([]byte(content)); actual != checksum {
+			return "", false, fmt.Errorf("checksum mismatch: expected sha256 %s, got %s", checksum, actual)
+		}
+		if cacheFile != "" {
+			if err := writeImportCache(cacheFile, content); err != nil {
+				log.Warnf("could not cache remote import %s: %v", location, err)
+			}
+		}
+	}
+
+	httpImports.Lock()
+	httpImports.contents[location] = &content
+	httpImports.Unlock()
+
+	return content, true, nil
+}
+
+func writeImportCache(file, content string) error {
+	if _, err := os.Stat(file); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(content), 0644)
+}
+
+func checksumOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,104 @@
+package grizzly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPImports(t *testing.T) {
+	files := map[string]string{
+		"/lib/main.libsonnet":   `local util = import 'util.libsonnet'; local lib = import 'local.libsonnet'; { title: util.title, lib: lib.name }`,
+		"/lib/util.libsonnet":   `{ title: 'from util' }`,
+		"/lib/pinned.libsonnet": `{ pinned: true }`,
+		"/lib/locked.libsonnet": `{ locked: true }`,
+	}
+	var requests atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	libDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(libDir, "local.libsonnet"), []byte(`{ name: 'from jpath' }`), 0644))
+	cacheDir := t.TempDir()
+
+	evaluateLocked := func(t *testing.T, snippet string, lock *JsonnetImportsLock) (string, error) {
+		vm := jsonnet.MakeVM()
+		vm.Importer(&extendedImporter{
+			loaders: []importLoader{
+				newHTTPLoader(server.Client(), cacheDir, lock),
+				newFileLoader(&jsonnet.FileImporter{JPaths: []string{libDir}}),
+			},
+		})
+		return vm.EvaluateAnonymousSnippet("main.jsonnet", snippet)
+	}
+	evaluate := func(t *testing.T, snippet string) (string, error) {
+		return evaluateLocked(t, snippet, nil)
+	}
+
+	t.Run("relative imports are resolved against the URL, then the library paths", func(t *testing.T) {
+		result, err := evaluate(t, `import '`+server.URL+`/lib/main.libsonnet'`)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"title": "from util", "lib": "from jpath"}`, result)
+
+		fetched := requests.Load()
+		_, err = evaluate(t, `import '`+server.URL+`/lib/main.libsonnet'`)
+		require.NoError(t, err)
+		require.Equal(t, fetched, requests.Load(), "remote imports should be cached")
+	})
+
+	t.Run("missing imports fail", func(t *testing.T) {
+		_, err := evaluate(t, `import '`+server.URL+`/lib/missing.libsonnet'`)
+		require.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("pinned imports are checked and cached on disk", func(t *testing.T) {
+		checksum := checksumOf([]byte(files["/lib/pinned.libsonnet"]))
+
+		result, err := evaluate(t, `import '`+server.URL+`/lib/pinned.libsonnet#sha256=`+checksum+`'`)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"pinned": true}`, result)
+		require.FileExists(t, filepath.Join(cacheDir, checksum))
+
+		_, err = evaluate(t, `import '`+server.URL+`/lib/util.libsonnet#sha256=`+checksumOf([]byte("{}"))+`'`)
+		require.ErrorContains(t, err, "checksum mismatch")
+	})
+
+	t.Run("unpinned imports are locked", func(t *testing.T) {
+		lockFile := filepath.Join(t.TempDir(), JsonnetImportsLockFile)
+		snippet := `import '` + server.URL + `/lib/locked.libsonnet'`
+		checksum := checksumOf([]byte(files["/lib/locked.libsonnet"]))
+
+		result, err := evaluateLocked(t, snippet, NewJsonnetImportsLock(lockFile, false))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"locked": true}`, result)
+		content, err := os.ReadFile(lockFile)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"version": 1, "imports": {"`+server.URL+`/lib/locked.libsonnet": "`+checksum+`"}}`, string(content))
+
+		tampered := `{"version": 1, "imports": {"` + server.URL + `/lib/locked.libsonnet": "` + checksumOf([]byte("{}")) + `"}}`
+		require.NoError(t, os.WriteFile(lockFile, []byte(tampered), 0644))
+		_, err = evaluateLocked(t, snippet, NewJsonnetImportsLock(lockFile, false))
+		require.ErrorContains(t, err, "checksum mismatch")
+		require.ErrorContains(t, err, "as locked in "+lockFile)
+
+		_, err = evaluateLocked(t, snippet, NewJsonnetImportsLock(lockFile, true))
+		require.NoError(t, err)
+		content, err = os.ReadFile(lockFile)
+		require.NoError(t, err)
+		require.Contains(t, string(content), checksum, "updating the lock should record the current checksums")
+	})
+}
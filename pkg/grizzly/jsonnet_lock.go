@@ -0,0 +1,101 @@
+package grizzly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// JsonnetImportsLockFile records the checksums of the remote Jsonnet imports
+// of a project
+const JsonnetImportsLockFile = "grizzly-imports.lock.json"
+
+// JsonnetImportsLock pins remote Jsonnet imports without a `#sha256=`
+// fragment to the checksum of their content when first imported: later
+// imports fail if the content changed, so that evaluations are reproducible
+// and tampering is noticed. The lock file is written as imports are added.
+type JsonnetImportsLock struct {
+	file string
+	// update records the checksums of the imports fetched, instead of
+	// checking them
+	update bool
+
+	mu      sync.Mutex
+	loaded  bool
+	imports map[string]string
+}
+
+type jsonnetImportsLockContent struct {
+	Version int               `json:"version"`
+	Imports map[string]string `json:"imports"`
+}
+
+// NewJsonnetImportsLock returns the lock of remote imports recorded in file,
+// read when first needed
+func NewJsonnetImportsLock(file string, update bool) *JsonnetImportsLock {
+	return &JsonnetImportsLock{
+		file:   file,
+		update: update,
+	}
+}
+
+func (lock *JsonnetImportsLock) load() error {
+	if lock.loaded {
+		return nil
+	}
+
+	lock.imports = map[string]string{}
+	content, err := os.ReadFile(lock.file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		parsed := jsonnetImportsLockContent{}
+		if err := json.Unmarshal(content, &parsed); err != nil {
+			return fmt.Errorf("parsing %s: %w", lock.file, err)
+		}
+		for location, checksum := range parsed.Imports {
+			lock.imports[location] = checksum
+		}
+	}
+	lock.loaded = true
+
+	return nil
+}
+
+// checksum returns the checksum an import is locked to, if any
+func (lock *JsonnetImportsLock) checksum(location string) (string, error) {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+
+	if err := lock.load(); err != nil {
+		return "", err
+	}
+	if lock.update {
+		return "", nil
+	}
+	return lock.imports[location], nil
+}
+
+// record locks an import to the checksum of its content, writing the lock
+// file if it changed
+func (lock *JsonnetImportsLock) record(location, checksum string) error {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+
+	if err := lock.load(); err != nil {
+		return err
+	}
+	if lock.imports[location] == checksum {
+		return nil
+	}
+	lock.imports[location] = checksum
+
+	content, err := json.MarshalIndent(jsonnetImportsLockContent{Version: 1, Imports: lock.imports}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(lock.file, append(content, '\n'), 0644)
+}
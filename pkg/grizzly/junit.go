@@ -0,0 +1,111 @@
+package grizzly
+
+import (
+	"encoding/xml"
+	"os"
+	"strings"
+)
+
+// JUnitReport records events as the test cases of a JUnit XML report, one
+// per resource, so that CI systems can display the outcome of each resource.
+type JUnitReport struct {
+	name     string
+	failures map[string]bool
+	cases    []junitTestCase
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Details string `xml:",chardata"`
+}
+
+// NewJUnitReport creates a report named after the command producing it.
+// Events of Error severity are failures, as well as the ones of the given
+// types.
+func NewJUnitReport(name string, failures ...EventType) *JUnitReport {
+	report := &JUnitReport{
+		name:     name,
+		failures: map[string]bool{},
+	}
+	for _, eventType := range failures {
+		report.failures[eventType.ID] = true
+	}
+
+	return report
+}
+
+func (report *JUnitReport) Record(event Event) {
+	kind, _, _ := strings.Cut(event.ResourceRef, ".")
+	testCase := junitTestCase{
+		ClassName: kind,
+		Name:      event.ResourceRef,
+	}
+	if event.Type.Severity == Error || report.failures[event.Type.ID] {
+		testCase.Failure = &junitFailure{
+			Message: event.Type.HumanReadable,
+			Type:    event.Type.ID,
+			Details: event.Details,
+		}
+	}
+
+	report.cases = append(report.cases, testCase)
+}
+
+// XML renders the report
+func (report *JUnitReport) XML() ([]byte, error) {
+	failures := 0
+	for _, testCase := range report.cases {
+		if testCase.Failure != nil {
+			failures++
+		}
+	}
+
+	content, err := xml.MarshalIndent(junitTestSuites{
+		Name:     report.name,
+		Tests:    len(report.cases),
+		Failures: failures,
+		Suites: []junitTestSuite{{
+			Name:     report.name,
+			Tests:    len(report.cases),
+			Failures: failures,
+			Cases:    report.cases,
+		}},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), append(content, '\n')...), nil
+}
+
+// WriteFile writes the report to the given file
+func (report *JUnitReport) WriteFile(path string) error {
+	content, err := report.XML()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0644)
+}
@@ -0,0 +1,60 @@
+package grizzly_test
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJUnitReport(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+
+	err := grizzly.Apply(registry, grizzly.NewResources(grizzlytest.NewFolder(t, "same", "Same"), grizzlytest.NewFolder(t, "changed", "Before")), false, grizzly.NewJUnitReport("apply"))
+	require.NoError(t, err)
+
+	report := grizzly.NewJUnitReport("grr diff", grizzly.ResourceChanged, grizzly.ResourceNotFound)
+	resources := grizzly.NewResources(grizzlytest.NewFolder(t, "same", "Same"), grizzlytest.NewFolder(t, "changed", "After"), grizzlytest.NewFolder(t, "missing", "Missing"))
+	require.NoError(t, grizzly.Diff(registry, resources, false, "yaml", report))
+
+	content, err := report.XML()
+	require.NoError(t, err)
+
+	var suites struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suite    struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				ClassName string `xml:"classname,attr"`
+				Name      string `xml:"name,attr"`
+				Failure   *struct {
+					Type    string `xml:"type,attr"`
+					Details string `xml:",chardata"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	require.NoError(t, xml.Unmarshal(content, &suites))
+
+	require.Equal(t, 3, suites.Tests)
+	require.Equal(t, 2, suites.Failures)
+	require.Equal(t, "grr diff", suites.Suite.Name)
+	require.Len(t, suites.Suite.Cases, 3)
+
+	require.Equal(t, "DashboardFolder", suites.Suite.Cases[0].ClassName)
+	require.Equal(t, "DashboardFolder.same", suites.Suite.Cases[0].Name)
+	require.Nil(t, suites.Suite.Cases[0].Failure)
+
+	require.Equal(t, "DashboardFolder.changed", suites.Suite.Cases[1].Name)
+	require.NotNil(t, suites.Suite.Cases[1].Failure)
+	require.Equal(t, "resource-changed", suites.Suite.Cases[1].Failure.Type)
+	require.Contains(t, suites.Suite.Cases[1].Failure.Details, "+    title: After")
+
+	require.Equal(t, "DashboardFolder.missing", suites.Suite.Cases[2].Name)
+	require.NotNil(t, suites.Suite.Cases[2].Failure)
+	require.Equal(t, "resource-not-found", suites.Suite.Cases[2].Failure.Type)
+}
@@ -0,0 +1,225 @@
+package grizzly
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// lazySpec loads the spec of a resource parsed from a large file, which is
+// only kept with the checksum of its spec until needed
+type lazySpec struct {
+	checksum string
+	load     func() (map[string]any, error)
+}
+
+// IsLazy returns whether the spec of a resource isn't loaded yet
+func (r Resource) IsLazy() bool {
+	_, loaded := r.Body["spec"]
+	return r.lazy != nil && !loaded
+}
+
+// SpecChecksum returns the checksum of the spec of a resource, without
+// loading it when lazy
+func (r Resource) SpecChecksum() (string, error) {
+	if r.IsLazy() {
+		return r.lazy.checksum, nil
+	}
+	return specChecksum(r.Spec())
+}
+
+// Hydrate returns the resource with its spec loaded, leaving lazy resources
+// lazy: the spec is released once the returned resource is no longer used.
+// Specs changed since they were parsed are an error.
+func (r Resource) Hydrate() (Resource, error) {
+	if !r.IsLazy() {
+		return r, nil
+	}
+
+	spec, err := r.lazy.loadSpec()
+	if err != nil {
+		return r, fmt.Errorf("loading the spec of %s: %w", r.Ref(), err)
+	}
+
+	hydrated := r
+	hydrated.Body = maps.Clone(r.Body)
+	hydrated.Body["spec"] = spec
+	hydrated.lazy = nil
+	return hydrated, nil
+}
+
+// hydrateInPlace loads the spec of a lazy resource for good, for the code
+// unaware of lazy resources. The body shared with the copies of the resource
+// is left as is, so that they stay lazy. Workflows go through Hydrate, so
+// that specs which can't be loaded anymore are reported: the resource gets
+// an empty spec here.
+func (r *Resource) hydrateInPlace() {
+	hydrated, err := r.Hydrate()
+	if err != nil {
+		log.Errorf("%s: using an empty spec", err)
+		hydrated.Body = maps.Clone(r.Body)
+		hydrated.Body["spec"] = map[string]any{}
+		hydrated.lazy = nil
+	}
+	*r = hydrated
+}
+
+// dehydrate drops the spec of a resource, which is loaded again when needed
+func (r *Resource) dehydrate(load func() (map[string]any, error)) error {
+	checksum, err := specChecksum(r.Spec())
+	if err != nil {
+		return err
+	}
+
+	r.Body = maps.Clone(r.Body)
+	delete(r.Body, "spec")
+	r.lazy = &lazySpec{checksum: checksum, load: load}
+	return nil
+}
+
+func (spec *lazySpec) loadSpec() (map[string]any, error) {
+	loaded, err := spec.load()
+	if err != nil {
+		return nil, err
+	}
+
+	checksum, err := specChecksum(loaded)
+	if err != nil {
+		return nil, err
+	}
+	if checksum != spec.checksum {
+		return nil, fmt.Errorf("its file changed since it was parsed")
+	}
+	return loaded, nil
+}
+
+// sameLazyResources returns whether two lazy resources are the same,
+// comparing the checksums of their specs
+func sameLazyResources(a, b Resource) bool {
+	if !a.IsLazy() || !b.IsLazy() {
+		return false
+	}
+	return a.lazy.checksum == b.lazy.checksum && reflect.DeepEqual(a.Body, b.Body)
+}
+
+func specChecksum(spec map[string]any) (string, error) {
+	// maps are encoded with sorted keys
+	content, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	return checksumOf(content), nil
+}
+
+// LazyParser keeps the resources of large files without their spec, so that
+// memory stays flat when listing or comparing giant repositories. Specs are
+// loaded again from their file, with the decorated parser, when needed: see
+// lazyFile.
+type LazyParser struct {
+	decorated Parser
+	// threshold is the size in bytes of the files whose resources are lazy
+	threshold int64
+}
+
+func NewLazyParser(decorated Parser, threshold int64) *LazyParser {
+	return &LazyParser{
+		decorated: decorated,
+		threshold: threshold,
+	}
+}
+
+func (parser *LazyParser) Accept(file string) bool {
+	return parser.decorated.Accept(file)
+}
+
+func (parser *LazyParser) Parse(resourcePath string, options ParserOptions) (Resources, error) {
+	resources, parseErr := parser.decorated.Parse(resourcePath, options)
+
+	// files are nil when small
+	files := map[string]*lazyFile{}
+	parsed := NewResources()
+	for _, resource := range resources.AsList() {
+		path := resource.Source.Path
+		if _, ok := files[path]; !ok {
+			files[path] = parser.lazyFile(path, options)
+		}
+		if file := files[path]; file != nil {
+			ref := resource.Ref()
+			load := func() (map[string]any, error) {
+				return file.load(ref)
+			}
+			if err := resource.dehydrate(load); err != nil {
+				log.Debugf("Keeping the spec of %s: %s", resource.Ref(), err)
+			}
+		}
+		parsed.Add(resource)
+	}
+
+	return parsed, parseErr
+}
+
+// lazyFile returns the file the specs of lazy resources are loaded from, or
+// nil when the file isn't large
+func (parser *LazyParser) lazyFile(path string, options ParserOptions) *lazyFile {
+	if path == "" || path == StdinPath {
+		return nil
+	}
+	stat, err := os.Stat(path)
+	if err != nil || stat.IsDir() {
+		return nil
+	}
+	if stat.Size() <= parser.threshold {
+		return nil
+	}
+
+	log.Debugf("Loading the specs of %s lazily: %s is larger than %s", path, formatSize(int(stat.Size())), formatSize(int(parser.threshold)))
+	return &lazyFile{
+		path: path,
+		parse: func() (Resources, error) {
+			return parser.decorated.Parse(path, options)
+		},
+	}
+}
+
+// lazyFile loads the specs of the lazy resources of a file. The file is
+// parsed once for all the resources loaded in a row, e.g. by a diff: the
+// specs parsed are kept until their resource loads them, and the file is
+// only parsed again for a resource loading its spec anew.
+type lazyFile struct {
+	path  string
+	parse func() (Resources, error)
+
+	lock  sync.Mutex
+	specs map[ResourceRef]map[string]any
+	err   error
+}
+
+func (file *lazyFile) load(ref ResourceRef) (map[string]any, error) {
+	file.lock.Lock()
+	defer file.lock.Unlock()
+
+	if _, ok := file.specs[ref]; !ok {
+		log.Debugf("Loading the specs of %s", file.path)
+		resources, err := file.parse()
+		file.specs = make(map[ResourceRef]map[string]any, resources.Len())
+		for _, resource := range resources.AsList() {
+			file.specs[resource.Ref()] = resource.Spec()
+		}
+		file.err = err
+	}
+
+	spec, ok := file.specs[ref]
+	if !ok {
+		if file.err != nil {
+			return nil, file.err
+		}
+		return nil, fmt.Errorf("no longer defined in %s", file.path)
+	}
+	delete(file.specs, ref)
+	return spec, nil
+}
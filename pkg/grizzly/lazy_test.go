@@ -0,0 +1,140 @@
+package grizzly_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazySpecs(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	write := func(dir, name, title string, panels int) {
+		t.Helper()
+		content := "apiVersion: grizzly.grafana.com/v1alpha1\nkind: Dashboard\nmetadata:\n  name: " + name + "\nspec:\n  uid: " + name + "\n  title: " + title + "\n  panels:\n"
+		content += strings.Repeat("    - type: timeseries\n      title: Requests per second\n", panels)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".yaml"), []byte(content), 0644))
+	}
+	parse := func(t *testing.T, path string) grizzly.Resources {
+		t.Helper()
+		resources, err := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserLargeFileSize(1024)).Parse(path, grizzly.ParserOptions{})
+		require.NoError(t, err)
+		return resources
+	}
+	find := func(t *testing.T, resources grizzly.Resources, name string) grizzly.Resource {
+		t.Helper()
+		resource, found := resources.Find(grizzly.NewResourceRef("Dashboard", name))
+		require.True(t, found)
+		return resource
+	}
+
+	dir := t.TempDir()
+	write(dir, "small", "Small", 1)
+	write(dir, "large", "Large", 100)
+
+	t.Run("only the resources of large files are lazy", func(t *testing.T) {
+		resources := parse(t, dir)
+		require.False(t, find(t, resources, "small").IsLazy())
+
+		large := find(t, resources, "large")
+		require.True(t, large.IsLazy())
+		require.NotContains(t, large.Body, "spec")
+		require.Equal(t, "large", large.Name())
+	})
+
+	t.Run("specs are loaded when needed", func(t *testing.T) {
+		large := find(t, parse(t, dir), "large")
+		checksum, err := large.SpecChecksum()
+		require.NoError(t, err)
+
+		hydrated, err := large.Hydrate()
+		require.NoError(t, err)
+		require.Equal(t, "Large", hydrated.GetSpecValue("title"))
+		require.Len(t, hydrated.GetSpecValue("panels"), 100)
+		require.True(t, large.IsLazy(), "hydrating returns a copy")
+
+		hydratedChecksum, err := hydrated.SpecChecksum()
+		require.NoError(t, err)
+		require.Equal(t, checksum, hydratedChecksum)
+
+		require.Equal(t, "Large", large.GetSpecValue("title"))
+		require.False(t, large.IsLazy(), "reading the spec loads it for good")
+	})
+
+	t.Run("files changed since parsed are reported", func(t *testing.T) {
+		changed := t.TempDir()
+		write(changed, "large", "Large", 100)
+		large := find(t, parse(t, changed), "large")
+
+		write(changed, "large", "Larger", 100)
+		_, err := large.Hydrate()
+		require.ErrorContains(t, err, "loading the spec of Dashboard.large: its file changed since it was parsed")
+	})
+
+	t.Run("specs that can't be loaded anymore are empty when read without hydrating", func(t *testing.T) {
+		removed := t.TempDir()
+		write(removed, "large", "Large", 100)
+		large := find(t, parse(t, removed), "large")
+		require.NoError(t, os.Remove(filepath.Join(removed, "large.yaml")))
+
+		_, err := large.Hydrate()
+		require.ErrorContains(t, err, "loading the spec of Dashboard.large")
+
+		read := large
+		require.Nil(t, read.GetSpecValue("title"))
+		require.True(t, large.IsLazy(), "copies stay lazy")
+	})
+
+	t.Run("large files are parsed once to load the specs of their resources", func(t *testing.T) {
+		multiple := t.TempDir()
+		content := ""
+		for _, name := range []string{"first", "second", "third"} {
+			content += "---\napiVersion: grizzly.grafana.com/v1alpha1\nkind: Dashboard\nmetadata:\n  name: " + name + "\nspec:\n  uid: " + name + "\n  panels:\n"
+			content += strings.Repeat("    - type: timeseries\n      title: Requests per second\n", 20)
+		}
+		path := filepath.Join(multiple, "dashboards.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+		parser := &countingParser{Parser: grizzly.DefaultParser(registry, nil, nil)}
+		resources, err := grizzly.NewLazyParser(parser, 1024).Parse(path, grizzly.ParserOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 1, parser.parsed)
+
+		for pass := 1; pass <= 2; pass++ {
+			for _, resource := range resources.AsList() {
+				require.True(t, resource.IsLazy())
+				hydrated, err := resource.Hydrate()
+				require.NoError(t, err)
+				require.Len(t, hydrated.GetSpecValue("panels"), 20)
+			}
+			require.Equal(t, 1+pass, parser.parsed)
+		}
+	})
+
+	t.Run("lazy resources with the same spec are unchanged without loading them", func(t *testing.T) {
+		before, after := t.TempDir(), t.TempDir()
+		write(before, "large", "Large", 100)
+		write(after, "large", "Large", 100)
+		base, resources := parse(t, before), parse(t, after)
+		require.NoError(t, os.Remove(filepath.Join(before, "large.yaml")))
+
+		summary := grizzly.NewDiffSummary()
+		require.NoError(t, grizzly.DiffRevision(registry, base, resources, "HEAD~1", false, "yaml", summary))
+		require.Equal(t, 1, summary.Count("Dashboard", grizzly.ResourceNotChanged))
+	})
+}
+
+// countingParser counts the files it parses
+type countingParser struct {
+	grizzly.Parser
+	parsed int
+}
+
+func (parser *countingParser) Parse(resourcePath string, options grizzly.ParserOptions) (grizzly.Resources, error) {
+	parser.parsed++
+	return parser.Parser.Parse(resourcePath, options)
+}
@@ -0,0 +1,44 @@
+package grizzly
+
+// TaggingHandler is implemented by handlers whose resources can be tagged,
+// such as dashboards, to list the remote resources with a tag
+type TaggingHandler interface {
+	// ListRemoteTagged lists the UIDs of the remote resources with the given
+	// tag
+	ListRemoteTagged(tag string) ([]string, error)
+}
+
+// managedHandler only lists the remote resources tagged as managed by
+// Grizzly. Resources that can't be tagged are all listed.
+type managedHandler struct {
+	Handler
+	tag string
+}
+
+func (h *managedHandler) ListRemote() ([]string, error) {
+	if tagging, ok := unwrapHandler(h.Handler).(TaggingHandler); ok {
+		return tagging.ListRemoteTagged(h.tag)
+	}
+
+	return h.Handler.ListRemote()
+}
+
+// OnlyManaged returns a registry whose handlers only list the remote
+// resources with the given tag, marking them as managed by Grizzly, among
+// the kinds of resources that can be tagged.
+func (r *Registry) OnlyManaged(tag string) Registry {
+	registry := Registry{
+		Providers:    r.Providers,
+		Handlers:     make(map[string]Handler, len(r.Handlers)),
+		HandlerOrder: make([]Handler, 0, len(r.HandlerOrder)),
+		ctx:          r.ctx,
+	}
+
+	for _, handler := range r.HandlerOrder {
+		managed := &managedHandler{Handler: handler, tag: tag}
+		registry.Handlers[handler.Kind()] = managed
+		registry.HandlerOrder = append(registry.HandlerOrder, managed)
+	}
+
+	return registry
+}
@@ -0,0 +1,47 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnlyManaged(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+	tag := grafana.ManagedTag("managed:grizzly")
+
+	dashboard := func(uid string, tags ...any) grizzly.Resource {
+		resource := grizzlytest.NewDashboard(t, uid, uid)
+		resource.SetSpecValue("tags", tags)
+		return resource
+	}
+
+	managed, err := tag(dashboard("managed", "team:infra"))
+	require.NoError(t, err)
+	require.Equal(t, []any{"team:infra", "managed:grizzly"}, managed.GetSpecValue("tags"))
+	retagged, err := tag(managed)
+	require.NoError(t, err)
+	require.Equal(t, []any{"team:infra", "managed:grizzly"}, retagged.GetSpecValue("tags"), "the tag should only be added once")
+
+	err = grizzly.Apply(registry, grizzly.NewResources(managed, dashboard("unmanaged")), false, grizzly.NewJUnitReport("apply"))
+	require.NoError(t, err)
+
+	onlyManaged := registry.OnlyManaged("managed:grizzly")
+	handler, err := onlyManaged.GetHandler("Dashboard")
+	require.NoError(t, err)
+	uids, err := handler.ListRemote()
+	require.NoError(t, err)
+	require.Equal(t, []string{"managed"}, uids)
+
+	dir := t.TempDir()
+	err = grizzly.Pull(onlyManaged, dir, false, "yaml", nil, []string{"Dashboard/*"}, false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPorcelain))
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(dir, "dashboards", "general", "dashboard-managed.yaml"))
+	require.NoFileExists(t, filepath.Join(dir, "dashboards", "general", "dashboard-unmanaged.yaml"))
+}
@@ -0,0 +1,255 @@
+package grizzly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"gopkg.in/yaml.v3"
+)
+
+// ModuleManifestFile is the name of the file declaring a module, at its root
+const ModuleManifestFile = "grizzly-module.yaml"
+
+// defaultModuleMain is the Jsonnet file rendering the resources of a module,
+// unless the manifest says otherwise
+const defaultModuleMain = "main.jsonnet"
+
+// Module is a shareable package of resources, rendered from typed inputs.
+// Its main Jsonnet file is a function receiving the inputs as an object, in
+// its `inputs` parameter:
+//
+//	function(inputs) {
+//	  grafanaDashboards:: { [inputs.service]: ... },
+//	}
+type Module struct {
+	Name        string        `yaml:"name"`
+	Description string        `yaml:"description,omitempty"`
+	Main        string        `yaml:"main,omitempty"`
+	Inputs      []ModuleInput `yaml:"inputs,omitempty"`
+
+	// Dir is where the module is stored
+	Dir string `yaml:"-"`
+}
+
+// ModuleInput is an input of a module
+type ModuleInput struct {
+	Name string `yaml:"name"`
+	// Type is one of string, number, boolean, or any for JSON values
+	Type        string `yaml:"type,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+	Default     any    `yaml:"default,omitempty"`
+}
+
+// LoadModule reads the manifest of the module stored in dir
+func LoadModule(dir string) (Module, error) {
+	var module Module
+
+	path := filepath.Join(dir, ModuleManifestFile)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return module, fmt.Errorf("%s is not a module: %s not found", dir, ModuleManifestFile)
+	}
+	if err != nil {
+		return module, err
+	}
+	if err := yaml.Unmarshal(content, &module); err != nil {
+		return module, ParseError{File: path, Err: err}
+	}
+
+	module.Dir = dir
+	if module.Main == "" {
+		module.Main = defaultModuleMain
+	}
+	for i, input := range module.Inputs {
+		if input.Name == "" {
+			return module, ParseError{File: path, Err: fmt.Errorf("input %d has no name", i)}
+		}
+		switch input.Type {
+		case "":
+			module.Inputs[i].Type = "string"
+		case "string", "number", "boolean", "any":
+		default:
+			return module, ParseError{File: path, Err: fmt.Errorf("input %s: unknown type %s", input.Name, input.Type)}
+		}
+	}
+
+	return module, nil
+}
+
+// ResolveInputs converts the values given on the command line to the types
+// of the inputs, and completes them with defaults
+func (module Module) ResolveInputs(values map[string]string) (map[string]any, error) {
+	inputs := map[string]any{}
+	declared := map[string]bool{}
+
+	for _, input := range module.Inputs {
+		declared[input.Name] = true
+
+		value, ok := values[input.Name]
+		if !ok {
+			if input.Required {
+				return nil, fmt.Errorf("input %s is required", input.Name)
+			}
+			inputs[input.Name] = input.Default
+			continue
+		}
+
+		converted, err := input.convert(value)
+		if err != nil {
+			return nil, fmt.Errorf("input %s: %w", input.Name, err)
+		}
+		inputs[input.Name] = converted
+	}
+
+	var unknown []string
+	for name := range values {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("module %s has no input named %s", module.Name, strings.Join(unknown, ", "))
+	}
+
+	return inputs, nil
+}
+
+func (input ModuleInput) convert(value string) (any, error) {
+	switch input.Type {
+	case "number":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return number, nil
+	case "boolean":
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return boolean, nil
+	case "any":
+		var decoded any
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			return nil, fmt.Errorf("%q is not valid JSON: %w", value, err)
+		}
+		return decoded, nil
+	}
+
+	return value, nil
+}
+
+// Instantiate renders the resources of a module for the given inputs, as
+// returned by ResolveInputs. Modules are self-contained: their libraries are
+// looked up in the vendor and lib directories of the module.
+func (module Module) Instantiate(registry Registry, inputs map[string]any) (Resources, error) {
+	main := filepath.Join(module.Dir, module.Main)
+	if _, err := os.Stat(main); err != nil {
+		return Resources{}, fmt.Errorf("module %s: %w", module.Name, err)
+	}
+
+	encodedInputs, err := json.Marshal(inputs)
+	if err != nil {
+		return Resources{}, err
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.Importer(newExtendedImporter(main, module.Dir, []string{"vendor", "lib"}, nil))
+	// no environment variable is readable: modules only depend on their inputs
+	for _, function := range nativeFunctions(nil) {
+		vm.NativeFunction(function.function)
+	}
+	vm.TLACode("inputs", string(encodedInputs))
+
+	s, err := wrapperScript(main, []string{"inputs"})
+	if err != nil {
+		return Resources{}, err
+	}
+	result, err := vm.EvaluateAnonymousSnippet(main, s)
+	if err != nil {
+		return Resources{}, err
+	}
+	var data any
+	if err := json.Unmarshal([]byte(result), &data); err != nil {
+		return Resources{}, err
+	}
+
+	return parseAny(registry, data, "", "", Source{Format: "jsonnet", Path: main})
+}
+
+// FetchModule returns the directory of a module: either a local directory,
+// or a Git repository followed by the path of the module within it, e.g.
+// `github.com/org/modules/redis`. A tag or branch can be given with an `@`
+// suffix, e.g. `github.com/org/modules/redis@v1.2.0`. Fetched modules are
+// stored in a temporary directory, removed by the returned function.
+func FetchModule(source string) (string, func(), error) {
+	noop := func() {}
+
+	if stat, err := os.Stat(source); err == nil && stat.IsDir() {
+		return source, noop, nil
+	}
+
+	location, ref, _ := strings.Cut(source, "@")
+	parts := strings.Split(strings.Trim(location, "/"), "/")
+	if len(parts) < 3 {
+		return "", noop, fmt.Errorf("module %s not found: expected a directory or a repository such as github.com/org/repository/path", source)
+	}
+
+	dir, err := os.MkdirTemp("", "grizzly-module-")
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "https://"+strings.Join(parts[:3], "/"), dir)
+
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("fetching module %s: %w: %s", source, err, strings.TrimSpace(string(output)))
+	}
+
+	return filepath.Join(append([]string{dir}, parts[3:]...)...), cleanup, nil
+}
+
+// InstantiateModule renders the resources of a module and writes them to
+// outputDir, where `grr pull` would write them
+func InstantiateModule(registry Registry, module Module, inputs map[string]any, outputDir string, outputFormat string, onlySpec bool, eventsRecorder eventsRecorder) error {
+	resources, err := module.Instantiate(registry, inputs)
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range registry.Sort(resources).AsList() {
+		content, filename, _, err := Format(registry, outputDir, &resource, outputFormat, onlySpec)
+		if err == nil {
+			err = WriteFile(filename, content)
+		}
+		if err != nil {
+			eventsRecorder.Record(Event{
+				Type:        ResourceFailure,
+				ResourceRef: resource.Ref().String(),
+				Details:     err.Error(),
+			})
+			return err
+		}
+
+		eventsRecorder.Record(Event{Type: ResourceRendered, ResourceRef: resource.Ref().String(), Details: filename})
+	}
+
+	return nil
+}
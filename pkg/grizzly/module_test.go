@@ -0,0 +1,58 @@
+package grizzly_test
+
+import (
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModule(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+
+	module, err := grizzly.LoadModule("testdata/modules/redis")
+	require.NoError(t, err)
+	require.Equal(t, "redis", module.Name)
+	require.Equal(t, "main.jsonnet", module.Main)
+	require.Equal(t, "string", module.Inputs[0].Type)
+
+	t.Run("inputs are typed and defaulted", func(t *testing.T) {
+		inputs, err := module.ResolveInputs(map[string]string{"service": "cart", "sloTarget": "99.5"})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"service": "cart", "sloTarget": 99.5, "datasource": "prometheus"}, inputs)
+
+		_, err = module.ResolveInputs(map[string]string{})
+		require.ErrorContains(t, err, "input service is required")
+
+		_, err = module.ResolveInputs(map[string]string{"service": "cart", "sloTarget": "high"})
+		require.ErrorContains(t, err, `"high" is not a number`)
+
+		_, err = module.ResolveInputs(map[string]string{"service": "cart", "region": "eu"})
+		require.ErrorContains(t, err, "module redis has no input named region")
+	})
+
+	t.Run("resources are rendered to local sources", func(t *testing.T) {
+		inputs, err := module.ResolveInputs(map[string]string{"service": "cart"})
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		require.NoError(t, grizzly.InstantiateModule(registry, module, inputs, dir, "yaml", false, grizzly.NewJUnitReport("instantiate")))
+
+		content, err := os.ReadFile(filepath.Join(dir, "dashboards", "cart", "dashboard-redis-cart.yaml"))
+		require.NoError(t, err)
+		require.Contains(t, string(content), "title: Redis (cart)")
+		require.Contains(t, string(content), fmt.Sprintf("service-%08x", crc32.ChecksumIEEE([]byte("cart"))), "native functions are available")
+		require.Contains(t, string(content), "title: 'Availability (target: 99.9%)'")
+		require.FileExists(t, filepath.Join(dir, "folders", "folder-cart.yaml"))
+	})
+
+	t.Run("directories without manifest aren't modules", func(t *testing.T) {
+		_, err := grizzly.LoadModule(t.TempDir())
+		require.ErrorContains(t, err, "is not a module")
+	})
+}
@@ -0,0 +1,103 @@
+package grizzly
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var slugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+type templateFunction struct {
+	FunctionDoc
+	function any
+}
+
+// filenameTemplateFunctions are the functions available to filename
+// templates
+var filenameTemplateFunctions = []templateFunction{
+	{
+		FunctionDoc: FunctionDoc{Name: "default", Params: []string{"fallback", "value"}, Description: "returns the fallback when the value is missing or empty"},
+		function: func(fallback string, value any) any {
+			if value == nil || value == "" {
+				return fallback
+			}
+			return value
+		},
+	},
+	{
+		FunctionDoc: FunctionDoc{Name: "lower", Params: []string{"value"}, Description: "returns a string in lower case"},
+		function:    strings.ToLower,
+	},
+	{
+		FunctionDoc: FunctionDoc{Name: "slug", Params: []string{"value"}, Description: "returns a string in lower case, with runs of other characters than letters and digits replaced by dashes"},
+		function: func(value string) string {
+			return strings.Trim(slugRegex.ReplaceAllString(strings.ToLower(value), "-"), "-")
+		},
+	},
+}
+
+// FilenameTemplateFunctionDocs documents the functions available to
+// filename templates
+func FilenameTemplateFunctionDocs() []FunctionDoc {
+	docs := make([]FunctionDoc, 0, len(filenameTemplateFunctions))
+	for _, function := range filenameTemplateFunctions {
+		docs = append(docs, function.FunctionDoc)
+	}
+	return docs
+}
+
+// FilenameTemplate controls where pulled resources are written, relative to
+// the resource path, e.g. `{{ .kind }}/{{ .folder }}/{{ .uid }}.{{ .extension }}`.
+// Templates are given the `kind`, `apiVersion`, `name`, `uid`, `folder`,
+// `title`, `extension`, `metadata` and `spec` of resources, and can use the
+// `lower`, `slug` and `default` functions.
+type FilenameTemplate struct {
+	tmpl *template.Template
+}
+
+// ParseFilenameTemplate parses a template naming the files of resources
+func ParseFilenameTemplate(text string) (*FilenameTemplate, error) {
+	funcs := template.FuncMap{}
+	for _, function := range filenameTemplateFunctions {
+		funcs[function.Name] = function.function
+	}
+	tmpl, err := template.New("filename").Option("missingkey=error").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name template: %w", err)
+	}
+
+	return &FilenameTemplate{tmpl: tmpl}, nil
+}
+
+// Filename returns the file a resource is written to, within resourcePath
+func (t *FilenameTemplate) Filename(resourcePath string, resource Resource, extension string) (string, error) {
+	title, _ := resource.GetSpecValue("title").(string)
+	metadata, _ := resource.Body["metadata"].(map[string]any)
+
+	var out bytes.Buffer
+	err := t.tmpl.Execute(&out, map[string]any{
+		"kind":       resource.Kind(),
+		"apiVersion": resource.APIVersion(),
+		"name":       resource.Name(),
+		"uid":        resource.Name(),
+		"folder":     resource.GetMetadata("folder"),
+		"title":      title,
+		"extension":  extension,
+		"metadata":   metadata,
+		"spec":       resource.Spec(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("naming %s: %w", resource.Ref(), err)
+	}
+
+	name := filepath.Clean(filepath.FromSlash(strings.TrimSpace(out.String())))
+	if name == "." || filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("naming %s: %q is not a file within the resource path", resource.Ref(), out.String())
+	}
+
+	return filepath.Join(resourcePath, name), nil
+}
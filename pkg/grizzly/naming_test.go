@@ -0,0 +1,88 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilenameTemplate(t *testing.T) {
+	resource := grizzlytest.NewResource(t, "Dashboard", "node-exporter", map[string]any{
+		"uid":   "node-exporter",
+		"title": "Node Exporter / Nodes",
+	})
+	resource.SetMetadata("folder", "infra")
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{template: "{{ .kind }}/{{ .folder }}/{{ .uid }}.yaml", expected: "resources/Dashboard/infra/node-exporter.yaml"},
+		{template: "{{ .kind | lower }}s/{{ .title | slug }}.{{ .extension }}", expected: "resources/dashboards/node-exporter-nodes.json"},
+		{template: "{{ index .metadata \"namespace\" | default \"default\" }}/{{ .name }}.json", expected: "resources/default/node-exporter.json"},
+	}
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+			tmpl, err := grizzly.ParseFilenameTemplate(test.template)
+			require.NoError(t, err)
+
+			filename, err := tmpl.Filename("resources", resource, "json")
+			require.NoError(t, err)
+			require.Equal(t, filepath.FromSlash(test.expected), filename)
+		})
+	}
+
+	t.Run("files must be within the resource path", func(t *testing.T) {
+		for _, text := range []string{"../{{ .uid }}.yaml", "/tmp/{{ .uid }}.yaml", "{{ index .metadata \"namespace\" | default \"\" }}"} {
+			tmpl, err := grizzly.ParseFilenameTemplate(text)
+			require.NoError(t, err)
+
+			_, err = tmpl.Filename("resources", resource, "yaml")
+			require.ErrorContains(t, err, "is not a file within the resource path", text)
+		}
+	})
+
+	t.Run("invalid templates are rejected", func(t *testing.T) {
+		_, err := grizzly.ParseFilenameTemplate("{{ .uid ")
+		require.ErrorContains(t, err, "invalid name template")
+
+		tmpl, err := grizzly.ParseFilenameTemplate("{{ .missing }}.yaml")
+		require.NoError(t, err)
+		_, err = tmpl.Filename("resources", resource, "yaml")
+		require.ErrorContains(t, err, "naming Dashboard.node-exporter")
+	})
+}
+
+func TestPullWithFilenameTemplate(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+
+	var resources []grizzly.Resource
+	for _, uid := range []string{"cpu", "memory"} {
+		resource := grizzlytest.NewDashboard(t, uid, "Overview")
+		resources = append(resources, resource)
+	}
+	require.NoError(t, grizzly.Apply(registry, grizzly.NewResources(resources...), false, grizzly.NewJUnitReport("apply")))
+
+	pull := func(text string) (string, error) {
+		tmpl, err := grizzly.ParseFilenameTemplate(text)
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		out := &bytes.Buffer{}
+		err = grizzly.Pull(registry, dir, false, "yaml", tmpl, []string{"Dashboard/*"}, true, grizzly.NewWriterRecorder(out, grizzly.EventToPorcelain))
+		return dir, err
+	}
+
+	dir, err := pull("{{ .kind }}/{{ .folder }}/{{ .uid }}.yaml")
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(dir, "Dashboard", "general", "cpu.yaml"))
+	require.FileExists(t, filepath.Join(dir, "Dashboard", "general", "memory.yaml"))
+
+	_, err = pull("{{ .title | slug }}.yaml")
+	require.ErrorContains(t, err, "is already written to")
+}
@@ -0,0 +1,292 @@
+package grizzly
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Normalizer rewrites the spec of a resource into a canonical form, so that
+// equivalent local and remote resources compare equal. It is given a copy of
+// the spec, which it modifies in place.
+type Normalizer interface {
+	Normalize(spec map[string]any)
+}
+
+// NormalizerFunc adapts a function to the Normalizer interface
+type NormalizerFunc func(spec map[string]any)
+
+func (f NormalizerFunc) Normalize(spec map[string]any) {
+	f(spec)
+}
+
+// NormalizingHandler describes a handler providing normalizers for its kind,
+// e.g. to fill in the defaults set by the remote endpoint
+type NormalizingHandler interface {
+	Normalizers() []Normalizer
+}
+
+var (
+	normalizersLock sync.Mutex
+	normalizers     = map[string][]Normalizer{}
+)
+
+// defaultNormalizers apply to all kinds, before the ones of handlers
+var defaultNormalizers = []Normalizer{StripNulls{}}
+
+// RegisterNormalizer adds normalizers to the pipeline of a kind. They run
+// after the ones provided by its handler, if any.
+func RegisterNormalizer(kind string, normalizer ...Normalizer) {
+	normalizersLock.Lock()
+	defer normalizersLock.Unlock()
+
+	normalizers[kind] = append(normalizers[kind], normalizer...)
+}
+
+// GetNormalizers returns the pipeline of a kind: the default normalizers,
+// then the ones of the handler, then the registered ones
+func GetNormalizers(handler Handler) []Normalizer {
+	pipeline := append([]Normalizer{}, defaultNormalizers...)
+	if normalizingHandler, ok := unwrapHandler(handler).(NormalizingHandler); ok {
+		pipeline = append(pipeline, normalizingHandler.Normalizers()...)
+	}
+
+	normalizersLock.Lock()
+	defer normalizersLock.Unlock()
+
+	return append(pipeline, normalizers[handler.Kind()]...)
+}
+
+// Normalize returns a copy of a resource whose spec went through the
+// normalization pipeline of its kind. The original resource is left untouched.
+func Normalize(handler Handler, resource Resource) Resource {
+	body := make(map[string]any, len(resource.Body))
+	for key, value := range resource.Body {
+		body[key] = value
+	}
+	normalized := Resource{Body: body, Source: resource.Source}
+
+	spec, ok := copyValue(resource.Spec()).(map[string]any)
+	if !ok {
+		return normalized
+	}
+	for _, normalizer := range GetNormalizers(handler) {
+		normalizer.Normalize(spec)
+	}
+	normalized.SetSpec(spec)
+
+	return normalized
+}
+
+func copyValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(value))
+		for key, item := range value {
+			copied[key] = copyValue(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(value))
+		for i, item := range value {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	}
+	return value
+}
+
+// StripNulls removes null fields, at any depth. Most endpoints don't tell a
+// null field from a missing one.
+type StripNulls struct{}
+
+func (StripNulls) Normalize(spec map[string]any) {
+	stripNulls(spec)
+}
+
+func stripNulls(value any) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if item == nil {
+				delete(value, key)
+				continue
+			}
+			stripNulls(item)
+		}
+	case []any:
+		for _, item := range value {
+			stripNulls(item)
+		}
+	}
+}
+
+// Defaults sets the top-level fields of the spec that are missing to their
+// default values, as the remote endpoint would
+type Defaults map[string]any
+
+func (defaults Defaults) Normalize(spec map[string]any) {
+	for key, value := range defaults {
+		if _, ok := spec[key]; !ok {
+			spec[key] = copyValue(value)
+		}
+	}
+}
+
+// SortList sorts the list found at Path, in which `*` stands for each item of
+// a list, e.g. `[]string{"panels", "*", "targets"}`. Objects are sorted by
+// the value of their Key field, other values by themselves. Lists of which
+// items can't be compared are left as is.
+type SortList struct {
+	Path []string
+	Key  string
+}
+
+func (sorter SortList) Normalize(spec map[string]any) {
+	transformPath(spec, sorter.Path, func(value any) any {
+		items, ok := value.([]any)
+		if !ok {
+			return value
+		}
+
+		keys := make([]string, len(items))
+		for i, item := range items {
+			if sorter.Key != "" {
+				object, ok := item.(map[string]any)
+				if !ok || object[sorter.Key] == nil {
+					return value
+				}
+				item = object[sorter.Key]
+			}
+			switch item.(type) {
+			case string, float64, int, bool:
+				keys[i] = fmt.Sprint(item)
+			default:
+				return value
+			}
+		}
+
+		indexes := make([]int, len(items))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return keys[indexes[i]] < keys[indexes[j]]
+		})
+		sorted := make([]any, len(items))
+		for i, index := range indexes {
+			sorted[i] = items[index]
+		}
+		return sorted
+	})
+}
+
+// CanonicalDurations rewrites the durations found at Paths (see SortList) with
+// the largest units possible, e.g. `90s` as `1m30s` and `60m` as `1h`. Values
+// that aren't durations, such as `now-6h`, are left as is.
+type CanonicalDurations struct {
+	Paths [][]string
+}
+
+func (canonical CanonicalDurations) Normalize(spec map[string]any) {
+	for _, path := range canonical.Paths {
+		transformPath(spec, path, func(value any) any {
+			text, ok := value.(string)
+			if !ok {
+				return value
+			}
+			duration, ok := parseDuration(text)
+			if !ok {
+				return value
+			}
+			return formatDuration(duration)
+		})
+	}
+}
+
+// transformPath replaces the values found at a path by the result of
+// transform
+func transformPath(value any, path []string, transform func(any) any) any {
+	if len(path) == 0 {
+		return transform(value)
+	}
+
+	if path[0] == "*" {
+		items, ok := value.([]any)
+		if !ok {
+			return value
+		}
+		for i, item := range items {
+			items[i] = transformPath(item, path[1:], transform)
+		}
+		return items
+	}
+
+	object, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+	if item, found := object[path[0]]; found {
+		object[path[0]] = transformPath(item, path[1:], transform)
+	}
+	return object
+}
+
+var durationRegex = regexp.MustCompile(`^(?:(\d+)w)?(?:(\d+)d)?(?:(\d+)h)?(?:(\d+)m)?(?:(\d+)s)?(?:(\d+)ms)?$`)
+
+var durationUnits = []struct {
+	suffix   string
+	duration time.Duration
+}{
+	{"w", 7 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+}
+
+// parseDuration parses durations as written in Grafana and Prometheus, e.g.
+// `1h30m` or `7d`
+func parseDuration(text string) (time.Duration, bool) {
+	if text == "" {
+		return 0, false
+	}
+	matches := durationRegex.FindStringSubmatch(text)
+	if matches == nil {
+		return 0, false
+	}
+
+	var duration time.Duration
+	for i, unit := range durationUnits {
+		if matches[i+1] == "" {
+			continue
+		}
+		count, err := strconv.ParseInt(matches[i+1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		duration += time.Duration(count) * unit.duration
+	}
+
+	return duration, true
+}
+
+func formatDuration(duration time.Duration) string {
+	if duration == 0 {
+		return "0s"
+	}
+
+	text := ""
+	for _, unit := range durationUnits {
+		if count := duration / unit.duration; count > 0 {
+			text += strconv.FormatInt(int64(count), 10) + unit.suffix
+			duration -= count * unit.duration
+		}
+	}
+
+	return text
+}
@@ -0,0 +1,114 @@
+package grizzly_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizers(t *testing.T) {
+	t.Run("nulls are stripped", func(t *testing.T) {
+		spec := map[string]any{
+			"title":  "cpu",
+			"panels": []any{map[string]any{"id": 1.0, "description": nil}},
+			"links":  nil,
+		}
+		grizzly.StripNulls{}.Normalize(spec)
+
+		require.Equal(t, map[string]any{
+			"title":  "cpu",
+			"panels": []any{map[string]any{"id": 1.0}},
+		}, spec)
+	})
+
+	t.Run("missing fields are defaulted", func(t *testing.T) {
+		spec := map[string]any{"editable": false}
+		grizzly.Defaults{"editable": true, "tags": []any{}}.Normalize(spec)
+
+		require.Equal(t, map[string]any{"editable": false, "tags": []any{}}, spec)
+	})
+
+	t.Run("lists are sorted", func(t *testing.T) {
+		spec := map[string]any{
+			"tags": []any{"web", "cpu"},
+			"panels": []any{
+				map[string]any{"targets": []any{map[string]any{"refId": "B"}, map[string]any{"refId": "A"}}},
+			},
+			"mixed": []any{"b", map[string]any{}},
+		}
+		grizzly.SortList{Path: []string{"tags"}}.Normalize(spec)
+		grizzly.SortList{Path: []string{"panels", "*", "targets"}, Key: "refId"}.Normalize(spec)
+		grizzly.SortList{Path: []string{"mixed"}}.Normalize(spec)
+
+		require.Equal(t, []any{"cpu", "web"}, spec["tags"])
+		require.Equal(t, []any{map[string]any{"refId": "A"}, map[string]any{"refId": "B"}}, spec["panels"].([]any)[0].(map[string]any)["targets"])
+		require.Equal(t, []any{"b", map[string]any{}}, spec["mixed"])
+	})
+
+	t.Run("durations are canonicalized", func(t *testing.T) {
+		spec := map[string]any{
+			"refresh": "90s",
+			"time":    map[string]any{"from": "now-6h"},
+			"rules": []any{
+				map[string]any{"for": "300s"},
+				map[string]any{"for": "0m"},
+				map[string]any{"for": "1d12h"},
+				map[string]any{"for": "14d"},
+			},
+		}
+		grizzly.CanonicalDurations{Paths: [][]string{{"refresh"}, {"time", "from"}, {"rules", "*", "for"}}}.Normalize(spec)
+
+		require.Equal(t, "1m30s", spec["refresh"])
+		require.Equal(t, "now-6h", spec["time"].(map[string]any)["from"])
+		require.Equal(t, []any{
+			map[string]any{"for": "5m"},
+			map[string]any{"for": "0s"},
+			map[string]any{"for": "1d12h"},
+			map[string]any{"for": "2w"},
+		}, spec["rules"])
+	})
+
+	t.Run("resources are normalized as a copy", func(t *testing.T) {
+		registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+		handler, err := registry.GetHandler("Dashboard")
+		require.NoError(t, err)
+
+		resource := grizzlytest.NewResource(t, "Dashboard", "cpu", map[string]any{
+			"title":       "cpu",
+			"tags":        []any{"web", "cpu"},
+			"description": nil,
+		})
+
+		normalized := grizzly.Normalize(handler, resource)
+		require.Equal(t, map[string]any{
+			"title":        "cpu",
+			"tags":         []any{"cpu", "web"},
+			"editable":     true,
+			"graphTooltip": 0,
+			"links":        []any{},
+		}, normalized.Spec())
+		require.Equal(t, []any{"web", "cpu"}, resource.Spec()["tags"])
+		require.Contains(t, resource.Spec(), "description")
+	})
+
+	t.Run("registered normalizers run last", func(t *testing.T) {
+		registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+		handler, err := registry.GetHandler("Datasource")
+		require.NoError(t, err)
+
+		before := len(grizzly.GetNormalizers(handler))
+		grizzly.RegisterNormalizer("Datasource", grizzly.NormalizerFunc(func(spec map[string]any) {
+			delete(spec, "readOnly")
+		}))
+
+		normalizers := grizzly.GetNormalizers(handler)
+		require.Len(t, normalizers, before+1)
+
+		resource := grizzlytest.NewResource(t, "Datasource", "prometheus", map[string]any{"readOnly": false})
+		normalized := grizzly.Normalize(handler, resource)
+		require.Empty(t, normalized.Spec())
+	})
+}
@@ -0,0 +1,146 @@
+package notifier
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// DiffTheme defines the colors used to render differences
+type DiffTheme struct {
+	Header      *color.Color
+	Hunk        *color.Color
+	Removed     *color.Color
+	Added       *color.Color
+	RemovedWord *color.Color
+	AddedWord   *color.Color
+}
+
+// DiffThemes lists the available themes, by name
+var DiffThemes = map[string]DiffTheme{
+	"default": {
+		Header:      color.New(color.Bold),
+		Hunk:        color.New(color.FgCyan),
+		Removed:     color.New(color.FgRed),
+		Added:       color.New(color.FgGreen),
+		RemovedWord: color.New(color.FgRed, color.ReverseVideo),
+		AddedWord:   color.New(color.FgGreen, color.ReverseVideo),
+	},
+	"high-contrast": {
+		Header:      color.New(color.Bold, color.Underline),
+		Hunk:        color.New(color.FgHiCyan, color.Bold),
+		Removed:     color.New(color.FgHiRed),
+		Added:       color.New(color.FgHiGreen),
+		RemovedWord: color.New(color.FgHiWhite, color.BgRed, color.Bold),
+		AddedWord:   color.New(color.FgHiWhite, color.BgGreen, color.Bold),
+	},
+}
+
+const DefaultDiffTheme = "default"
+
+var diffTheme = DiffThemes[DefaultDiffTheme]
+
+// SetDiffTheme selects the theme used to render differences
+func SetDiffTheme(name string) error {
+	theme, ok := DiffThemes[name]
+	if !ok {
+		names := make([]string, 0, len(DiffThemes))
+		for name := range DiffThemes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown diff theme %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	diffTheme = theme
+	return nil
+}
+
+var wordRegexp = regexp.MustCompile(`\w+|\s+|[^\w\s]`)
+
+// ColorizeDiff renders a unified diff with colors. Lines that were modified
+// rather than entirely added or removed have the changed words highlighted.
+func ColorizeDiff(diff string) string {
+	if color.NoColor {
+		return diff
+	}
+
+	var (
+		out            strings.Builder
+		removed, added []string
+		inHunk         bool
+	)
+	flush := func() {
+		for i, line := range removed {
+			if i < len(added) {
+				from, to := colorizeWords(line, added[i])
+				removed[i], added[i] = from, to
+			} else {
+				removed[i] = diffTheme.Removed.Sprint(line)
+			}
+		}
+		for i := len(removed); i < len(added); i++ {
+			added[i] = diffTheme.Added.Sprint(added[i])
+		}
+		for _, line := range append(removed, added...) {
+			out.WriteString(line + "\n")
+		}
+		removed, added = nil, nil
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			inHunk = true
+			out.WriteString(diffTheme.Hunk.Sprint(line) + "\n")
+		case !inHunk:
+			out.WriteString(diffTheme.Header.Sprint(line) + "\n")
+		case strings.HasPrefix(line, "-"):
+			if len(added) > 0 {
+				flush()
+			}
+			removed = append(removed, line)
+		case strings.HasPrefix(line, "+"):
+			added = append(added, line)
+		default:
+			flush()
+			out.WriteString(line + "\n")
+		}
+	}
+	flush()
+
+	return out.String()
+}
+
+// colorizeWords highlights the words that differ between a removed line and
+// the line that replaced it.
+func colorizeWords(from, to string) (string, string) {
+	a := wordRegexp.FindAllString(from[1:], -1)
+	b := wordRegexp.FindAllString(to[1:], -1)
+
+	var fromOut, toOut strings.Builder
+	fromOut.WriteString(diffTheme.Removed.Sprint("-"))
+	toOut.WriteString(diffTheme.Added.Sprint("+"))
+
+	for _, op := range difflib.NewMatcher(a, b).GetOpCodes() {
+		removedWords := strings.Join(a[op.I1:op.I2], "")
+		addedWords := strings.Join(b[op.J1:op.J2], "")
+		if op.Tag == 'e' {
+			fromOut.WriteString(diffTheme.Removed.Sprint(removedWords))
+			toOut.WriteString(diffTheme.Added.Sprint(addedWords))
+			continue
+		}
+		if removedWords != "" {
+			fromOut.WriteString(diffTheme.RemovedWord.Sprint(removedWords))
+		}
+		if addedWords != "" {
+			toOut.WriteString(diffTheme.AddedWord.Sprint(addedWords))
+		}
+	}
+
+	return fromOut.String(), toOut.String()
+}
@@ -0,0 +1,45 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorizeDiff(t *testing.T) {
+	diff := `--- Remote
++++ Local
+@@ -1,3 +1,3 @@
+ spec:
+-  title: Production overview
++  title: Staging overview
+   uid: overview
+`
+
+	t.Run("without colors", func(t *testing.T) {
+		noColor := color.NoColor
+		color.NoColor = true
+		t.Cleanup(func() { color.NoColor = noColor })
+
+		require.Equal(t, diff, ColorizeDiff(diff))
+	})
+
+	t.Run("changed words are highlighted", func(t *testing.T) {
+		noColor := color.NoColor
+		color.NoColor = false
+		t.Cleanup(func() { color.NoColor = noColor })
+
+		colorized := ColorizeDiff(diff)
+		require.Contains(t, colorized, diffTheme.RemovedWord.Sprint("Production"))
+		require.Contains(t, colorized, diffTheme.AddedWord.Sprint("Staging"))
+		require.Contains(t, colorized, diffTheme.Added.Sprint(" overview"))
+		require.Contains(t, colorized, "   uid: overview\n")
+	})
+
+	t.Run("unknown theme", func(t *testing.T) {
+		require.Error(t, SetDiffTheme("unknown"))
+		require.NoError(t, SetDiffTheme("high-contrast"))
+		require.NoError(t, SetDiffTheme(DefaultDiffTheme))
+	})
+}
@@ -13,39 +13,88 @@ var (
 	green  = color.New(color.FgGreen).SprintFunc()
 )
 
+// OutputMode controls how much is announced, and in which shape
+type OutputMode uint8
+
+const (
+	// HumanOutput announces everything, in a human-friendly way
+	HumanOutput OutputMode = iota
+	// QuietOutput only announces errors
+	QuietOutput
+	// PorcelainOutput announces the status of each resource as
+	// `<resource>\t<status>` lines, suitable for scripts. Errors are written
+	// to stderr.
+	PorcelainOutput
+)
+
+var outputMode = HumanOutput
+
+// SetOutputMode changes how announcements are made
+func SetOutputMode(mode OutputMode) {
+	outputMode = mode
+}
+
+// status announces the status of a resource, either as a human-readable
+// message or as a porcelain line
+func status(obj fmt.Stringer, message string, porcelainStatus string) {
+	switch outputMode {
+	case HumanOutput:
+		fmt.Printf("%s %s\n", obj.String(), message)
+	case PorcelainOutput:
+		fmt.Printf("%s\t%s\n", obj.String(), porcelainStatus)
+	}
+}
+
 // NoChanges announces that nothing has changed
 func NoChanges(obj fmt.Stringer) {
-	fmt.Printf("%s %s\n", obj.String(), yellow("no differences"))
+	status(obj, yellow("no differences"), "resource-not-changed")
 }
 
 // HasChanges announces that a resource has changed, and displays the differences
 func HasChanges(obj fmt.Stringer, diff string) {
-	fmt.Printf("%s %s\n", obj.String(), red("changes detected:"))
-	fmt.Println(diff)
+	status(obj, red("changes detected:"), "resource-changed")
+	if outputMode == HumanOutput {
+		fmt.Println(ColorizeDiff(diff))
+	}
 }
 
 // NotFound announces that a resource was not found on the remote endpoint
 func NotFound(obj fmt.Stringer) {
-	fmt.Printf("%s %s\n", obj.String(), yellow("not found"))
+	status(obj, yellow("not found"), "resource-not-found")
 }
 
 // Added announces that a resource has been added to the remote endpoint
 func Added(obj fmt.Stringer) {
-	fmt.Printf("%s %s\n", obj.String(), green("added"))
+	status(obj, green("added"), "resource-added")
 }
 
 // Updated announces that a resource has been updated at the remote endpoint
 func Updated(obj fmt.Stringer) {
-	fmt.Printf("%s %s\n", obj.String(), green("updated"))
+	status(obj, green("updated"), "resource-updated")
+}
+
+// Secret announces a secret value that can't be retrieved later, such as a
+// token. Unlike other announcements, it is made whatever the output mode, as
+// it would be lost otherwise.
+func Secret(obj fmt.Stringer, name string, value string) {
+	switch outputMode {
+	case PorcelainOutput:
+		fmt.Printf("%s\t%s\t%s\n", obj.String(), name, value)
+	default:
+		fmt.Printf("%s %s %s\n", obj.String(), yellow(name+" (shown only once):"), value)
+	}
 }
 
 // NotSupported announces that a behaviour is not supported by a handler
 func NotSupported(obj fmt.Stringer, behaviour string) {
-	fmt.Printf("%s %s\n", obj.String(), red("does not support "+behaviour))
+	Error(obj, "does not support "+behaviour)
 }
 
 // Info announces a message in green
 func Info(obj fmt.Stringer, msg string) {
+	if outputMode != HumanOutput {
+		return
+	}
 	if obj == nil {
 		fmt.Println(green(msg))
 	} else {
@@ -55,6 +104,9 @@ func Info(obj fmt.Stringer, msg string) {
 
 // Info announces a message in green (to stderr)
 func InfoStderr(obj fmt.Stringer, msg string) {
+	if outputMode != HumanOutput {
+		return
+	}
 	if obj == nil {
 		os.Stderr.WriteString(green(msg) + "\n")
 	} else {
@@ -64,6 +116,9 @@ func InfoStderr(obj fmt.Stringer, msg string) {
 
 // Warn announces a message in yellow
 func Warn(obj fmt.Stringer, msg string) {
+	if outputMode != HumanOutput {
+		return
+	}
 	if obj == nil {
 		fmt.Println(yellow(msg))
 	} else {
@@ -73,10 +128,14 @@ func Warn(obj fmt.Stringer, msg string) {
 
 // Error announces a message in yellow
 func Error(obj fmt.Stringer, msg string) {
+	out := os.Stdout
+	if outputMode == PorcelainOutput {
+		out = os.Stderr
+	}
 	if obj == nil {
-		fmt.Println(red(msg))
+		fmt.Fprintln(out, red(msg))
 	} else {
-		fmt.Printf("%s %s\n", obj.String(), red(msg))
+		fmt.Fprintf(out, "%s %s\n", obj.String(), red(msg))
 	}
 }
 
@@ -1,14 +1,21 @@
 package grizzly
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/gobwas/glob"
 	"github.com/hashicorp/go-multierror"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
@@ -16,16 +23,99 @@ import (
 
 type ParseError struct {
 	File string
-	Err  error
+	// Line and Column locate the error in the file, when the format allows it
+	Line   int
+	Column int
+	// Resource identifies the resource of the file that failed to parse, if
+	// the error is specific to one
+	Resource string
+	Err      error
 }
 
 func (err ParseError) Error() string {
-	return fmt.Sprintf("parse error in '%s': %s", err.File, err.Err)
+	location := err.File + formatPosition(err.Line, err.Column)
+	if err.Resource != "" {
+		return fmt.Sprintf("parse error in '%s' (%s): %s", location, err.Resource, err.Err)
+	}
+	return fmt.Sprintf("parse error in '%s': %s", location, err.Err)
+}
+
+func (err ParseError) Unwrap() error {
+	return err.Err
+}
+
+// ResourceError locates an error within a file: in one of its resources,
+// and at a given position when the format allows it
+type ResourceError struct {
+	Resource string
+	Line     int
+	Column   int
+	Err      error
+}
+
+func (err ResourceError) Error() string {
+	location := strings.TrimPrefix(formatPosition(err.Line, err.Column), ":")
+	if err.Resource != "" {
+		location = strings.TrimSuffix(err.Resource+" "+location, " ")
+	}
+	if location == "" {
+		return err.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", location, err.Err)
+}
+
+func (err ResourceError) Unwrap() error {
+	return err.Err
+}
+
+func formatPosition(line int, column int) string {
+	switch {
+	case line > 0 && column > 0:
+		return fmt.Sprintf(":%d:%d", line, column)
+	case line > 0:
+		return fmt.Sprintf(":%d", line)
+	}
+	return ""
+}
+
+// newResourceError identifies the resource described by data, or falls back
+// to its location in the file. Errors already identifying a resource are
+// returned as-is.
+func newResourceError(data any, location string, line int, err error) error {
+	var resourceErr ResourceError
+	if errors.As(err, &resourceErr) {
+		return err
+	}
+
+	resource := location
+	if m, ok := data.(map[string]any); ok {
+		kind, _ := m["kind"].(string)
+		metadata, _ := m["metadata"].(map[string]any)
+		name, _ := metadata["name"].(string)
+		if kind != "" && name != "" {
+			resource = NewResourceRef(kind, name).String()
+		}
+	}
+
+	return ResourceError{Resource: resource, Line: line, Err: err}
 }
 
 type ParserOptions struct {
 	DefaultResourceKind string
 	DefaultFolderUID    string
+	// ExtVars are the external variables given to Jsonnet, read with
+	// std.extVar()
+	ExtVars map[string]ExtVar
+	// TLAs are the top-level arguments given to Jsonnet files evaluating
+	// to a function
+	TLAs map[string]ExtVar
+}
+
+// ExtVar is the value of a Jsonnet external variable or top-level argument:
+// a string, or Jsonnet code evaluated when the value is read
+type ExtVar struct {
+	Value string
+	Code  bool
 }
 
 type FormatParser interface {
@@ -38,8 +128,29 @@ type Parser interface {
 	Parse(resourcePath string, options ParserOptions) (Resources, error)
 }
 
+// StdinPath is the resource path designating resources read from the
+// standard input, as a stream of YAML or JSON documents
+const StdinPath = "-"
+
 type parsersConfig struct {
 	continueOnError bool
+	folderMapPath   string
+	folderMap       *FolderMap
+	remoteFolders   bool
+	ignore          []string
+	stdin           io.Reader
+	transformers    []ResourceTransformer
+	placementRoot   string
+	placementRules  []PlacementRule
+	orgID           int64
+	jsonnetCacheDir string
+	jsonnetEnv      []string
+	jsonnetAliases  map[string]string
+	jsonnetLock     *JsonnetImportsLock
+	helmValues      []string
+	templateValues  string
+	workers         int
+	largeFileSize   int64
 }
 
 type ParserOpt func(config *parsersConfig)
@@ -50,22 +161,192 @@ func ParserContinueOnError(continueOnError bool) ParserOpt {
 	}
 }
 
+// ParserFolderMap sets the file used to record the UIDs of folders created
+// implicitly from `folderName` metadata.
+func ParserFolderMap(path string) ParserOpt {
+	return func(config *parsersConfig) {
+		config.folderMapPath = path
+	}
+}
+
+// ParserRecordFolders makes the parser record the UIDs of the folders it
+// creates from `folderName` metadata in the given map, to be saved by the
+// caller once resources are applied. Otherwise, the folder map file is only
+// read, and new folders get new UIDs every time resources are parsed.
+func ParserRecordFolders(folderMap *FolderMap) ParserOpt {
+	return func(config *parsersConfig) {
+		config.folderMap = folderMap
+	}
+}
+
+// ParserRemoteFolders makes the parser look up the folders created from
+// `folderName` metadata among the remote folders, by title, when they are
+// missing from the folder map, rather than generating new UIDs for them.
+func ParserRemoteFolders(lookup bool) ParserOpt {
+	return func(config *parsersConfig) {
+		config.remoteFolders = lookup
+	}
+}
+
+// ParserIgnore sets glob patterns for the files and directories to skip when
+// parsing a directory. Patterns without a `/` are matched against file names,
+// others against absolute paths.
+func ParserIgnore(patterns []string) ParserOpt {
+	return func(config *parsersConfig) {
+		config.ignore = patterns
+	}
+}
+
+// ParserStdin sets where resources are read from when parsing StdinPath.
+// Defaults to os.Stdin.
+func ParserStdin(stdin io.Reader) ParserOpt {
+	return func(config *parsersConfig) {
+		config.stdin = stdin
+	}
+}
+
+// ParserTransform sets transformers applied to every parsed resource, once
+// its folder is known
+func ParserTransform(transformers ...ResourceTransformer) ParserOpt {
+	return func(config *parsersConfig) {
+		config.transformers = append(config.transformers, transformers...)
+	}
+}
+
+// ParserJsonnetCache sets the directory caching the output of evaluated
+// Jsonnet files. Files are evaluated every time when empty.
+func ParserJsonnetCache(dir string) ParserOpt {
+	return func(config *parsersConfig) {
+		config.jsonnetCacheDir = dir
+	}
+}
+
+// ParserJsonnetEnv sets the environment variables Jsonnet files can read
+// with the getEnv native function. Reading others is an error.
+func ParserJsonnetEnv(names []string) ParserOpt {
+	return func(config *parsersConfig) {
+		config.jsonnetEnv = names
+	}
+}
+
+// ParserJsonnetAliases sets aliases of Jsonnet import paths: imports of an
+// alias, or of a path within it, are resolved from its target instead, e.g.
+// `grafonnet/main.libsonnet` from `vendor/grafonnet@v10/main.libsonnet`.
+func ParserJsonnetAliases(aliases map[string]string) ParserOpt {
+	return func(config *parsersConfig) {
+		config.jsonnetAliases = aliases
+	}
+}
+
+// ParserJsonnetLock pins the remote Jsonnet imports to the checksums recorded
+// in a lock file, recording the new ones. When update is true, the
+// checksums of the imports are recorded instead of checked.
+func ParserJsonnetLock(file string, update bool) ParserOpt {
+	return func(config *parsersConfig) {
+		config.jsonnetLock = NewJsonnetImportsLock(file, update)
+	}
+}
+
+// ParserHelmValues sets the values files overlaid, in order, when rendering
+// Helm charts
+func ParserHelmValues(values []string) ParserOpt {
+	return func(config *parsersConfig) {
+		config.helmValues = values
+	}
+}
+
+// ParserTemplateValues enables the preprocessing of YAML files as Go
+// templates, executed with the values of the given YAML file
+func ParserTemplateValues(valuesFile string) ParserOpt {
+	return func(config *parsersConfig) {
+		config.templateValues = valuesFile
+	}
+}
+
+// ParserWorkers sets the number of files parsed concurrently when parsing a
+// directory, which mostly speeds up the evaluation of Jsonnet files.
+// Defaults to the number of CPUs.
+func ParserWorkers(workers int) ParserOpt {
+	return func(config *parsersConfig) {
+		config.workers = workers
+	}
+}
+
+// ParserLargeFileSize sets the size in bytes above which the resources of a
+// file are kept without their spec, loaded again from the file when needed.
+// Specs are all kept when zero.
+func ParserLargeFileSize(size int64) ParserOpt {
+	return func(config *parsersConfig) {
+		config.largeFileSize = size
+	}
+}
+
 func DefaultParser(registry Registry, targets []string, jsonnetPaths []string, opts ...ParserOpt) Parser {
-	config := &parsersConfig{}
+	config := &parsersConfig{
+		folderMapPath: DefaultFolderMapFile,
+		stdin:         os.Stdin,
+	}
 
 	for _, opt := range opts {
 		opt(config)
 	}
 
-	return NewFilteredParser(
-		registry,
-		NewChainParser([]FormatParser{
-			NewJSONParser(registry),
-			NewYAMLParser(registry),
-			NewJsonnetParser(registry, jsonnetPaths),
-		}, config.continueOnError),
-		targets,
-	)
+	jsonnetParser := NewJsonnetParser(registry, jsonnetPaths)
+	if config.jsonnetCacheDir != "" {
+		jsonnetParser.cache = NewJsonnetEvalCache(config.jsonnetCacheDir)
+	}
+	jsonnetParser.env = config.jsonnetEnv
+	jsonnetParser.aliases = config.jsonnetAliases
+	jsonnetParser.lock = config.jsonnetLock
+	yamlParser := NewYAMLParser(registry)
+	yamlParser.valuesFile = config.templateValues
+	chainParser := NewChainParser([]FormatParser{
+		// before the YAML parser, as charts are identified by a YAML file
+		NewHelmParser(registry, config.helmValues),
+		NewJSONParser(registry),
+		yamlParser,
+		jsonnetParser,
+		NewCueParser(registry),
+		NewHCLParser(registry),
+		NewTOMLParser(registry),
+		NewStarlarkParser(registry),
+	}, config.continueOnError)
+	// the folder map, the pull manifest and the lock of remote imports
+	// aren't resources
+	ignore := append([]string{filepath.Base(config.folderMapPath), PullManifestFile, pullJournalFile, JsonnetImportsLockFile}, config.ignore...)
+	chainParser.ignore = compileIgnorePatterns(ignore)
+	chainParser.stdin = config.stdin
+	chainParser.workers = config.workers
+	chainParser.ctx = registry.Context()
+
+	var parser Parser = NewFilteredParser(registry, chainParser, targets)
+	if len(config.placementRules) > 0 {
+		parser = NewPlacementParser(parser, config.placementRoot, config.placementRules, config.orgID)
+	}
+	parser = NewFolderNameParser(registry, parser, config.folderMapPath, config.folderMap, config.remoteFolders)
+	if len(config.transformers) > 0 {
+		parser = NewTransformingParser(parser, config.transformers)
+	}
+	// specs are loaded again with every other parser, to be the same
+	if config.largeFileSize > 0 {
+		parser = NewLazyParser(parser, config.largeFileSize)
+	}
+
+	return parser
+}
+
+func compileIgnorePatterns(patterns []string) []glob.Glob {
+	globs := make([]glob.Glob, 0, len(patterns))
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			log.Warnf("Ignoring invalid ignore pattern %q: %s", pattern, err)
+			continue
+		}
+		globs = append(globs, g)
+	}
+
+	return globs
 }
 
 type FilteredParser struct {
@@ -91,13 +372,16 @@ func (parser *FilteredParser) Accept(file string) bool {
 func (parser *FilteredParser) Parse(resourcePath string, options ParserOptions) (Resources, error) {
 	parser.logger.WithField("resourcePath", resourcePath).Debug("Parsing resource")
 
+	// resources parsed despite errors are filtered too, as parsers can be
+	// configured to continue on error
 	resources, err := parser.decorated.Parse(resourcePath, options)
-	if err != nil {
-		return resources, err
-	}
 
+	paths := folderPaths(resources)
 	resources = resources.Filter(func(resource Resource) bool {
 		result := parser.registry.ResourceMatchesTarget(resource.Kind(), resource.Name(), parser.targets)
+		if path := nestedPath(parser.registry, resource, paths); !result && path != resource.Name() {
+			result = parser.registry.ResourceMatchesTarget(resource.Kind(), path, parser.targets)
+		}
 		if !result {
 			parser.logger.WithField("resource", resource.Ref().String()).Debug("Omitting resource")
 		}
@@ -105,18 +389,83 @@ func (parser *FilteredParser) Parse(resourcePath string, options ParserOptions)
 		return result
 	})
 
-	return parser.registry.Sort(resources), nil
+	return parser.registry.Sort(resources), err
+}
+
+// ResourceTransformer rewrites a parsed resource, e.g. to fill in defaults
+// configured for the project
+type ResourceTransformer func(resource Resource) (Resource, error)
+
+type TransformingParser struct {
+	decorated    Parser
+	transformers []ResourceTransformer
+}
+
+func NewTransformingParser(decorated Parser, transformers []ResourceTransformer) *TransformingParser {
+	return &TransformingParser{
+		decorated:    decorated,
+		transformers: transformers,
+	}
+}
+
+func (parser *TransformingParser) Accept(file string) bool {
+	return parser.decorated.Accept(file)
+}
+
+func (parser *TransformingParser) Parse(resourcePath string, options ParserOptions) (Resources, error) {
+	// resources parsed despite errors are transformed too, as parsers can be
+	// configured to continue on error
+	resources, parseErr := parser.decorated.Parse(resourcePath, options)
+
+	transformed := NewResources()
+	var errs error
+	for _, resource := range resources.AsList() {
+		var err error
+		for _, transformer := range parser.transformers {
+			if resource, err = transformer(resource); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("%s: %w", resource.Ref(), err))
+			continue
+		}
+		transformed.Add(resource)
+	}
+
+	if parseErr != nil && errs != nil {
+		return transformed, multierror.Append(parseErr, errs)
+	}
+	if parseErr != nil {
+		return transformed, parseErr
+	}
+
+	return transformed, errs
 }
 
 type ChainParser struct {
 	formatParsers   []FormatParser
 	continueOnError bool
+	// ctx collects the warnings raised while parsing
+	ctx    context.Context
+	ignore []glob.Glob
+	stdin  io.Reader
+	// workers is the number of files parsed concurrently, defaulting to the
+	// number of CPUs
+	workers int
+}
+
+// streamParser is implemented by format parsers able to parse resources
+// that aren't read from a file
+type streamParser interface {
+	parseReader(input io.Reader, source Source, options ParserOptions) (Resources, error)
 }
 
 func NewChainParser(formatParsers []FormatParser, continueOnError bool) *ChainParser {
 	return &ChainParser{
 		formatParsers:   formatParsers,
 		continueOnError: continueOnError,
+		ctx:             context.Background(),
 	}
 }
 
@@ -133,6 +482,9 @@ func (parser *ChainParser) Parse(resourcePath string, options ParserOptions) (Re
 	if resourcePath == "" {
 		return NewResources(), nil
 	}
+	if resourcePath == StdinPath {
+		return parser.parseStdin(options)
+	}
 
 	stat, err := os.Stat(resourcePath)
 	if err != nil {
@@ -143,44 +495,164 @@ func (parser *ChainParser) Parse(resourcePath string, options ParserOptions) (Re
 		return parser.parseFile(resourcePath, options)
 	}
 
-	parsedResources := NewResources()
-	var finalErr error
+	var files []string
 	_ = filepath.WalkDir(resourcePath, func(path string, info fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if info.IsDir() {
+		if path != resourcePath && parser.isIgnored(path) {
+			if info.IsDir() {
+				return fs.SkipDir
+			}
 			return nil
 		}
 
-		r, err := parser.parseFile(path, options)
-		if err != nil {
-			finalErr = multierror.Append(finalErr, err)
+		// the templates of charts aren't resources: charts are rendered
+		// as a whole
+		if info.IsDir() && isHelmChart(path) {
+			files = append(files, filepath.Join(path, HelmChartFile))
+			return fs.SkipDir
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+
+	// files are parsed concurrently, but their results are merged in order,
+	// as if they were parsed one after the other
+	parsedResources := NewResources()
+	var finalErr error
+	for _, parsed := range parser.parseFiles(files, options) {
+		if warning, ok := parsed.err.(Warning); ok {
+			// files of other formats can live next to resources
+			RecordWarning(parser.ctx, warning)
+			continue
+		}
+		if parsed.err != nil {
+			finalErr = multierror.Append(finalErr, parsed.err)
 
 			if !parser.continueOnError {
-				return err
-			} else {
-				return nil
+				break
 			}
 		}
-		parsedResources.Merge(r)
-
-		return nil
-	})
+		for _, resource := range parsed.resources.AsList() {
+			if existing, found := parsedResources.Find(resource.Ref()); found {
+				RecordWarning(parser.ctx, NewResourceWarning(resource.Ref(), fmt.Errorf("defined in %s, overridden by %s", existing.Source.Path, resource.Source.Path)))
+			}
+		}
+		parsedResources.Merge(parsed.resources)
+	}
 
 	return parsedResources, finalErr
 }
 
+type parsedFile struct {
+	resources Resources
+	err       error
+}
+
+// parseFiles parses files with a pool of workers, returning their results
+// in the same order. Unless continuing on errors, the files coming after
+// one that failed to parse are skipped.
+func (parser *ChainParser) parseFiles(files []string, options ParserOptions) []parsedFile {
+	results := make([]parsedFile, len(files))
+	workers := parser.workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var firstFailure atomic.Int64
+	firstFailure.Store(int64(len(files)))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if !parser.continueOnError && int64(i) > firstFailure.Load() {
+					continue
+				}
+
+				resources, err := parser.parseFile(files[i], options)
+				results[i] = parsedFile{resources: resources, err: err}
+				if _, ok := err.(Warning); err == nil || ok {
+					continue
+				}
+				for {
+					failure := firstFailure.Load()
+					if int64(i) >= failure || firstFailure.CompareAndSwap(failure, int64(i)) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// parseStdin parses a stream of YAML or JSON documents from the standard
+// input
+func (parser *ChainParser) parseStdin(options ParserOptions) (Resources, error) {
+	const file = "<stdin>"
+
+	for _, formatParser := range parser.formatParsers {
+		streamParser, ok := formatParser.(streamParser)
+		if !ok || parser.stdin == nil {
+			continue
+		}
+
+		// resources read from stdin can't be written back
+		resources, err := streamParser.parseReader(parser.stdin, Source{Format: formatYAML, Path: file}, options)
+		if err != nil {
+			return resources, fileParseErrors(file, err)
+		}
+		return resources, nil
+	}
+
+	return Resources{}, fmt.Errorf("reading resources from the standard input isn't supported")
+}
+
+func (parser *ChainParser) isIgnored(path string) bool {
+	if len(parser.ignore) == 0 {
+		return false
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	absPath = filepath.ToSlash(absPath)
+	name := filepath.Base(path)
+
+	for _, g := range parser.ignore {
+		if g.Match(name) || g.Match(absPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (parser *ChainParser) parseFile(file string, options ParserOptions) (Resources, error) {
 	for _, l := range parser.formatParsers {
 		if !l.Accept(file) {
 			continue
 		}
 
+		// format parsers return the resources they could parse along with
+		// their errors
 		resources, err := l.Parse(file, options)
 		if err != nil {
-			return Resources{}, ParseError{File: file, Err: err}
+			return resources, fileParseErrors(file, err)
 		}
 		return resources, nil
 	}
@@ -188,19 +660,54 @@ func (parser *ChainParser) parseFile(file string, options ParserOptions) (Resour
 	return Resources{}, NewWarning(NewUnrecognisedFormatError(file))
 }
 
+// fileParseErrors turns the errors of a format parser into parse errors,
+// one per failing resource
+func fileParseErrors(file string, err error) error {
+	var errs []error
+	if merr, ok := err.(*multierror.Error); ok {
+		errs = merr.Errors
+	} else {
+		errs = []error{err}
+	}
+
+	var finalErr error
+	for _, e := range errs {
+		parseErr := ParseError{File: file, Err: e}
+
+		var resourceErr ResourceError
+		if errors.As(e, &resourceErr) {
+			parseErr.Resource = resourceErr.Resource
+			parseErr.Line = resourceErr.Line
+			parseErr.Column = resourceErr.Column
+			parseErr.Err = resourceErr.Err
+		}
+
+		finalErr = multierror.Append(finalErr, parseErr)
+	}
+
+	if merr, ok := finalErr.(*multierror.Error); ok && len(merr.Errors) == 1 {
+		return merr.Errors[0]
+	}
+	return finalErr
+}
+
+// parseAny parses resources from decoded data. Lists are parsed item by
+// item: the resources of valid items are returned along with the errors of
+// the others.
 func parseAny(registry Registry, data any, resourceKind, folderUID string, source Source) (Resources, error) {
 	if slice, ok := isSlice(data); ok {
 		resources := NewResources()
-		for _, elem := range slice {
+		var finalErr error
+		for i, elem := range slice {
 			parsedResources, err := parseAny(registry, elem, resourceKind, folderUID, source)
 			if err != nil {
-				return Resources{}, err
+				finalErr = multierror.Append(finalErr, newResourceError(elem, fmt.Sprintf("item %d", i), 0, err))
 			}
 			for _, resource := range parsedResources.AsList() {
 				resources.Add(resource)
 			}
 		}
-		return resources, nil
+		return resources, finalErr
 	}
 	hasEnvelope := DetectEnvelope(data)
 	if hasEnvelope {
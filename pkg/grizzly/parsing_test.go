@@ -1,11 +1,19 @@
 package grizzly_test
 
 import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
 	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/grafana/grizzly/pkg/grafana"
 	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/hashicorp/go-multierror"
 	"github.com/stretchr/testify/require"
 )
 
@@ -241,3 +249,697 @@ func TestParseKindDetection(t *testing.T) {
 		}
 	})
 }
+
+func TestParserIgnore(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	folderMapPath := filepath.Join(t.TempDir(), "folders.yaml")
+
+	parser := grizzly.DefaultParser(registry, []string{"Dashboard"}, nil, grizzly.ParserFolderMap(folderMapPath), grizzly.ParserIgnore([]string{"*nested*"}))
+
+	resources, err := parser.Parse("testdata/folders", grizzly.ParserOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, resources.Filter(func(resource grizzly.Resource) bool {
+		return resource.Kind() == "Dashboard"
+	}).Len())
+}
+
+func TestParserStdin(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+
+	parse := func(stdin string) (grizzly.Resources, error) {
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserStdin(strings.NewReader(stdin)))
+		return parser.Parse(grizzly.StdinPath, grizzly.ParserOptions{})
+	}
+
+	t.Run("YAML and JSON documents are accepted", func(t *testing.T) {
+		resources, err := parse(`apiVersion: grizzly.grafana.com/v1alpha1
+kind: DashboardFolder
+metadata:
+  name: first
+spec:
+  title: First
+---
+{"apiVersion": "grizzly.grafana.com/v1alpha1", "kind": "DashboardFolder", "metadata": {"name": "second"}, "spec": {"title": "Second"}}
+`)
+		require.NoError(t, err)
+		require.Equal(t, 2, resources.Len())
+
+		resource, found := resources.Find(grizzly.NewResourceRef("DashboardFolder", "second"))
+		require.True(t, found)
+		require.Equal(t, "<stdin>", resource.Source.Path)
+		require.False(t, resource.Source.Rewritable)
+	})
+
+	t.Run("errors are located in the standard input", func(t *testing.T) {
+		_, err := parse("kind: [")
+
+		var parseErr grizzly.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.Equal(t, "<stdin>", parseErr.File)
+	})
+}
+
+func TestParserContinueOnError(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+
+	parse := func(continueOnError bool) (grizzly.Resources, error) {
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserContinueOnError(continueOnError))
+		return parser.Parse("testdata/errors", grizzly.ParserOptions{})
+	}
+
+	t.Run("parsing stops at the first error", func(t *testing.T) {
+		_, err := parse(false)
+		require.Error(t, err)
+
+		var merr *multierror.Error
+		require.ErrorAs(t, err, &merr)
+		require.Len(t, merr.Errors, 1)
+	})
+
+	t.Run("all the errors are reported with their file, line and resource", func(t *testing.T) {
+		resources, err := parse(true)
+
+		var merr *multierror.Error
+		require.ErrorAs(t, err, &merr)
+
+		failures := map[string]string{}
+		for _, e := range merr.Errors {
+			var parseErr grizzly.ParseError
+			require.ErrorAs(t, e, &parseErr)
+			failures[parseErr.Resource] = fmt.Sprintf("%s:%d", parseErr.File, parseErr.Line)
+		}
+		require.Equal(t, map[string]string{
+			"DashboardFolder.second": "testdata/errors/documents.yaml:9",
+			"":                       "testdata/errors/invalid.yaml:1",
+			"item 1":                 "testdata/errors/list.json:8",
+		}, failures)
+
+		uids := []string{}
+		for _, resource := range resources.AsList() {
+			uids = append(uids, resource.Name())
+		}
+		require.ElementsMatch(t, []string{"first", "third", "listed", "valid"}, uids)
+	})
+}
+
+func TestParseErrorPositions(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "JSON syntax errors",
+			file:     "dashboard.json",
+			content:  "{\n  \"kind\": \"Dashboard\",\n  \"metadata\": {\"name\": \"sample\"}\n  \"spec\": {}\n}\n",
+			expected: "parse error in '%s:4:3': invalid character '\"' after object key:value pair",
+		},
+		{
+			name:     "truncated JSON",
+			file:     "dashboard.json",
+			content:  "{\n  \"kind\": \"Dashboard\",\n  \"metadata\": {\"name\": \"sample\"},\n  \"spec\": {\"uid\": 1\n}\n",
+			expected: "parse error in '%s:5:2': unexpected end of JSON input",
+		},
+		{
+			name:     "YAML syntax errors",
+			file:     "dashboard.yaml",
+			content:  "kind: Dashboard\nmetadata:\n  name: sample\n spec: {}\n",
+			expected: "parse error in '%s:3': yaml: did not find expected key",
+		},
+		{
+			name:     "invalid resources of YAML lists",
+			file:     "dashboards.yaml",
+			content:  "- kind: Dashboard\n  metadata:\n    name: first\n  spec:\n    uid: first\n- kind: Dashboard\n  metadata:\n    name: second\n",
+			expected: "parse error in '%s:6' (Dashboard.second): found invalid object",
+		},
+		{
+			name:     "YAML syntax errors of later documents",
+			file:     "resources.yaml",
+			content:  "kind: DashboardFolder\nmetadata:\n  name: first\nspec:\n  title: First\n---\nkind: Dashboard\nmetadata:\n  name: sample\n  labels:\n    a: b\n   c: d\n",
+			expected: "parse error in '%s:8': yaml: did not find expected key",
+		},
+		{
+			name:     "YAML syntax errors of later list items",
+			file:     "dashboards.yaml",
+			content:  "- kind: Dashboard\n  metadata:\n    name: first\n  spec:\n    uid: first\n- kind: Dashboard\n  metadata:\n    name: second\n   spec: {}\n",
+			expected: "parse error in '%s:8': yaml: did not find expected key",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), test.file)
+			require.NoError(t, os.WriteFile(file, []byte(test.content), 0644))
+
+			parser := grizzly.DefaultParser(registry, nil, nil)
+			_, err := parser.Parse(file, grizzly.ParserOptions{})
+			require.ErrorContains(t, err, fmt.Sprintf(test.expected, file))
+		})
+	}
+}
+
+func TestYAMLStreams(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	folder := func(name string) string {
+		return fmt.Sprintf("kind: DashboardFolder\nmetadata:\n  name: %[1]s\nspec:\n  title: %[1]s\n", name)
+	}
+	item := func(content string) string {
+		return "- " + strings.ReplaceAll(strings.TrimSuffix(content, "\n"), "\n", "\n  ") + "\n"
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "documents",
+			content:  "# folders\n---\n" + folder("first") + "...\n---\n# second\n" + folder("second") + "---\n---\n" + folder("third"),
+			expected: []string{"first", "second", "third"},
+		},
+		{
+			name:     "list items",
+			content:  "# folders\n" + item(folder("first")) + "\n# second\n" + item(folder("second")) + "---\n" + item(folder("third")),
+			expected: []string{"first", "second", "third"},
+		},
+		{
+			name:     "aliases of previous list items",
+			content:  "- kind: DashboardFolder\n  metadata:\n    name: first\n  spec: &spec\n    title: shared\n- kind: DashboardFolder\n  metadata:\n    name: second\n  spec: *spec\n",
+			expected: []string{"first", "second"},
+		},
+		{
+			name:     "inline documents",
+			content:  "--- {kind: DashboardFolder, metadata: {name: first}, spec: {title: first}}\n--- [{kind: DashboardFolder, metadata: {name: second}, spec: {title: second}}]\n",
+			expected: []string{"first", "second"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "folders.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(test.content), 0644))
+
+			parser := grizzly.DefaultParser(registry, nil, nil)
+			resources, err := parser.Parse(file, grizzly.ParserOptions{})
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, resource := range resources.AsList() {
+				names = append(names, resource.Name())
+			}
+			require.Equal(t, test.expected, names)
+		})
+	}
+}
+
+func TestParserWarnings(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	registry = registry.WithContext(grizzly.WithWarnings(context.Background()))
+
+	parser := grizzly.DefaultParser(registry, nil, nil)
+	resources, err := parser.Parse("testdata/warnings", grizzly.ParserOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, resources.Len())
+
+	warnings := []string{}
+	for _, warning := range grizzly.Warnings(registry.Context()) {
+		warnings = append(warnings, warning.Error())
+	}
+	require.Equal(t, []string{
+		"unrecognized format for testdata/warnings/README.md",
+		"DashboardFolder.shared: defined in testdata/warnings/first.yaml, overridden by testdata/warnings/second.yaml",
+	}, warnings)
+}
+
+func TestJsonnetExtVars(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	parser := grizzly.DefaultParser(registry, nil, nil)
+
+	resources, err := parser.Parse("testdata/parsing/dashboard-with-ext-vars.jsonnet", grizzly.ParserOptions{
+		ExtVars: map[string]grizzly.ExtVar{
+			"env":      {Value: "production"},
+			"settings": {Value: "{refresh: '1m'}", Code: true},
+		},
+	})
+	require.NoError(t, err)
+
+	resource, found := resources.Find(grizzly.NewResourceRef("Dashboard", "nodes-production"))
+	require.True(t, found)
+	require.Equal(t, "Nodes (production)", resource.GetSpecValue("title"))
+	require.Equal(t, "1m", resource.GetSpecValue("refresh"))
+
+	_, err = parser.Parse("testdata/parsing/dashboard-with-ext-vars.jsonnet", grizzly.ParserOptions{})
+	require.ErrorContains(t, err, "Undefined external variable: env")
+}
+
+func TestJsonnetTLAs(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	parser := grizzly.DefaultParser(registry, nil, nil)
+
+	resources, err := parser.Parse("testdata/parsing/dashboard-with-tlas.jsonnet", grizzly.ParserOptions{
+		TLAs: map[string]grizzly.ExtVar{
+			"env": {Value: "production"},
+		},
+	})
+	require.NoError(t, err)
+
+	resource, found := resources.Find(grizzly.NewResourceRef("Dashboard", "nodes-production"))
+	require.True(t, found)
+	require.Equal(t, "Nodes (production)", resource.GetSpecValue("title"))
+	require.Equal(t, "5m", resource.GetSpecValue("refresh"))
+
+	resources, err = parser.Parse("testdata/parsing/dashboard-with-tlas.jsonnet", grizzly.ParserOptions{
+		TLAs: map[string]grizzly.ExtVar{
+			"env":      {Value: "staging"},
+			"settings": {Value: "{refresh: '1m'}", Code: true},
+		},
+	})
+	require.NoError(t, err)
+
+	resource, found = resources.Find(grizzly.NewResourceRef("Dashboard", "nodes-staging"))
+	require.True(t, found)
+	require.Equal(t, "1m", resource.GetSpecValue("refresh"))
+
+	_, err = parser.Parse("testdata/parsing/dashboard-with-tlas.jsonnet", grizzly.ParserOptions{})
+	require.ErrorContains(t, err, "Missing argument: env")
+
+	_, err = parser.Parse("testdata/parsing/dashboard-with-tlas.jsonnet", grizzly.ParserOptions{
+		TLAs: map[string]grizzly.ExtVar{
+			"not-valid": {Value: "production"},
+		},
+	})
+	require.ErrorContains(t, err, `invalid top-level argument name "not-valid"`)
+}
+
+func TestJsonnetYAMLImports(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	parser := grizzly.DefaultParser(registry, nil, nil)
+
+	resources, err := parser.Parse("testdata/parsing/dashboard-importing-yaml.jsonnet", grizzly.ParserOptions{})
+	require.NoError(t, err)
+
+	resource, found := resources.Find(grizzly.NewResourceRef("Dashboard", "nodes"))
+	require.True(t, found)
+	require.Equal(t, "Nodes", resource.GetSpecValue("title"))
+	require.Equal(t, "1m", resource.GetSpecValue("refresh"))
+	require.Equal(t, []any{"infra", "nodes"}, resource.GetSpecValue("tags"))
+	require.Equal(t, []any{map[string]any{"title": "CPU"}, map[string]any{"title": "Memory"}}, resource.GetSpecValue("panels"))
+	require.Equal(t, "Nodes", resource.GetSpecValue("description"), "importstr should still give YAML")
+}
+
+func TestParallelParsing(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	dir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		content := fmt.Sprintf(`{
+  apiVersion: 'grizzly.grafana.com/v1alpha1',
+  kind: 'Dashboard',
+  metadata: { name: 'dashboard-%02d', folder: 'general' },
+  spec: { title: 'Dashboard %d' },
+}`, i, i)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("dashboard-%02d.jsonnet", i)), []byte(content), 0644))
+	}
+	names := func(resources grizzly.Resources) []string {
+		var names []string
+		for _, resource := range resources.AsList() {
+			names = append(names, resource.Name())
+		}
+		return names
+	}
+
+	sequential, err := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserWorkers(1)).Parse(dir, grizzly.ParserOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 20, sequential.Len())
+	parallel, err := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserWorkers(8)).Parse(dir, grizzly.ParserOptions{})
+	require.NoError(t, err)
+	require.Equal(t, names(sequential), names(parallel), "resources should be parsed in the same order")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dashboard-10.jsonnet"), []byte(`{`), 0644))
+	parsed, err := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserWorkers(8)).Parse(dir, grizzly.ParserOptions{})
+	require.ErrorContains(t, err, "dashboard-10.jsonnet")
+	require.Equal(t, names(sequential)[:10], names(parsed), "files after the failing one should be skipped")
+
+	parsed, err = grizzly.DefaultParser(registry, nil, nil, grizzly.ParserWorkers(8), grizzly.ParserContinueOnError(true)).Parse(dir, grizzly.ParserOptions{})
+	require.Error(t, err)
+	require.Equal(t, 19, parsed.Len())
+}
+
+func TestJsonnetYAMLNativeFunctions(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	parser := grizzly.DefaultParser(registry, nil, nil)
+
+	resources, err := parser.Parse("testdata/parsing/dashboard-yaml-natives.jsonnet", grizzly.ParserOptions{})
+	require.NoError(t, err)
+
+	resource, found := resources.Find(grizzly.NewResourceRef("Dashboard", "yaml-natives"))
+	require.True(t, found)
+	require.Equal(t, "NodeDown", resource.GetSpecValue("title"))
+	require.Equal(t, `count: 2
+groups:
+  - name: nodes
+    rules:
+      - alert: NodeDown
+        expr: up == 0
+        for: 5m
+`, resource.GetSpecValue("description"))
+}
+
+func TestJsonnetHashNativeFunctions(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	parser := grizzly.DefaultParser(registry, nil, nil)
+
+	resources, err := parser.Parse("testdata/parsing/dashboard-hash-natives.jsonnet", grizzly.ParserOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, resources.Len())
+
+	resource := resources.First()
+	require.Equal(t, fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte("Node Exporter"))), resource.Name())
+	require.Equal(t, fmt.Sprintf("%x", sha256.Sum256([]byte("Node Exporter"))), resource.GetSpecValue("sha256"))
+	require.Equal(t, fmt.Sprintf("%x", sha1.Sum([]byte("Node Exporter"))), resource.GetSpecValue("sha1"))
+}
+
+func TestJsonnetGetEnv(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	t.Setenv("GRIZZLY_TEST_TITLE", "Production")
+	t.Setenv("GRIZZLY_TEST_REFRESH", "")
+
+	t.Run("allowed variables are read", func(t *testing.T) {
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserJsonnetEnv([]string{"GRIZZLY_TEST_TITLE", "GRIZZLY_TEST_REFRESH"}))
+		resources, err := parser.Parse("testdata/parsing/dashboard-getenv.jsonnet", grizzly.ParserOptions{})
+		require.NoError(t, err)
+
+		resource, found := resources.Find(grizzly.NewResourceRef("Dashboard", "getenv"))
+		require.True(t, found)
+		require.Equal(t, "Production", resource.GetSpecValue("title"))
+		require.Equal(t, "1m", resource.GetSpecValue("refresh"), "empty variables should use the default")
+	})
+
+	t.Run("other variables can't be read", func(t *testing.T) {
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserJsonnetEnv([]string{"GRIZZLY_TEST_REFRESH"}))
+		_, err := parser.Parse("testdata/parsing/dashboard-getenv.jsonnet", grizzly.ParserOptions{})
+		require.ErrorContains(t, err, "environment variable GRIZZLY_TEST_TITLE isn't allowed")
+	})
+}
+
+func TestCueParser(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	parser := grizzly.DefaultParser(registry, nil, nil)
+
+	t.Run("resources are parsed", func(t *testing.T) {
+		resources, err := parser.Parse("testdata/parsing/cue", grizzly.ParserOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 2, resources.Len())
+
+		nodes, found := resources.Find(grizzly.NewResourceRef("Dashboard", "nodes"))
+		require.True(t, found)
+		require.Equal(t, "general", nodes.GetMetadata("folder"))
+		require.Equal(t, "nodes", nodes.GetSpecValue("uid"))
+		require.Equal(t, "1m", nodes.GetSpecValue("refresh"))
+		require.Equal(t, "cue", nodes.Source.Format)
+
+		disks, found := resources.Find(grizzly.NewResourceRef("Dashboard", "disks"))
+		require.True(t, found)
+		require.Equal(t, "infra", disks.GetMetadata("folder"))
+		require.Equal(t, "5m", disks.GetSpecValue("refresh"))
+	})
+
+	t.Run("schema violations are reported", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "invalid.cue")
+		require.NoError(t, os.WriteFile(file, []byte(`#Dashboard: {
+	apiVersion: "grizzly.grafana.com/v1alpha1"
+	kind:       "Dashboard"
+	metadata: name: string
+	spec: refresh: "1m" | "5m"
+}
+
+nodes: #Dashboard & {
+	metadata: name: "nodes"
+	spec: refresh:  "1h"
+}
+`), 0644))
+
+		_, err := parser.Parse(file, grizzly.ParserOptions{})
+		require.ErrorContains(t, err, "nodes.spec.refresh")
+	})
+}
+
+func TestHCLParser(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	parser := grizzly.DefaultParser(registry, nil, nil)
+
+	t.Run("blocks are parsed into resources", func(t *testing.T) {
+		resources, err := parser.Parse("testdata/parsing/hcl/dashboards.hcl", grizzly.ParserOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 3, resources.Len())
+
+		folder, found := resources.Find(grizzly.NewResourceRef("DashboardFolder", "infra"))
+		require.True(t, found)
+		require.Equal(t, "Infrastructure", folder.GetSpecValue("title"))
+
+		nodes, found := resources.Find(grizzly.NewResourceRef("Dashboard", "nodes"))
+		require.True(t, found)
+		require.Equal(t, "infra", nodes.GetMetadata("folder"))
+		require.Equal(t, []any{"infra", "nodes"}, nodes.GetSpecValue("tags"))
+		require.Equal(t, []any{map[string]any{"id": float64(1), "type": "timeseries", "title": "CPU"}}, nodes.GetSpecValue("panels"))
+		require.Equal(t, "hcl", nodes.Source.Format)
+
+		disks, found := resources.Find(grizzly.NewResourceRef("Dashboard", "disks"))
+		require.True(t, found)
+		require.Equal(t, "infra", disks.GetMetadata("folder"))
+		require.Equal(t, "Disks", disks.GetSpecValue("title"))
+		require.Nil(t, disks.GetSpecValue("config_json"))
+	})
+
+	t.Run("unknown blocks are reported", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "unknown.hcl")
+		require.NoError(t, os.WriteFile(file, []byte("widget \"nodes\" {\n  title = \"Nodes\"\n}\n"), 0644))
+
+		_, err := parser.Parse(file, grizzly.ParserOptions{})
+		require.ErrorContains(t, err, "unknown.hcl:1,1-7: unknown resource type widget")
+	})
+}
+
+func TestJsonnetImportAliases(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	dir := t.TempDir()
+	for version, title := range map[string]string{"v1": "'Version 1'", "v2": "'Version 2'"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "vendor", "titles@"+version), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "vendor", "titles@"+version, "title.libsonnet"), []byte(title), 0644))
+	}
+	main := filepath.Join(dir, "dashboard.jsonnet")
+	require.NoError(t, os.WriteFile(main, []byte(`{
+  apiVersion: 'grizzly.grafana.com/v1alpha1',
+  kind: 'Dashboard',
+  metadata: { name: 'aliased', folder: 'general' },
+  spec: { title: import 'titles/title.libsonnet' },
+}`), 0644))
+
+	title := func(t *testing.T, target string) any {
+		t.Helper()
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserJsonnetAliases(map[string]string{
+			"titles": filepath.Join(dir, "vendor", target),
+		}))
+		resources, err := parser.Parse(main, grizzly.ParserOptions{})
+		require.NoError(t, err)
+		resource := resources.First()
+		return resource.GetSpecValue("title")
+	}
+
+	require.Equal(t, "Version 1", title(t, "titles@v1"))
+	require.Equal(t, "Version 2", title(t, "titles@v2"))
+}
+
+func TestTOMLParser(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	parser := grizzly.DefaultParser(registry, nil, nil)
+
+	t.Run("single resource", func(t *testing.T) {
+		resources, err := parser.Parse("testdata/parsing/toml/datasource.toml", grizzly.ParserOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 1, resources.Len())
+
+		datasource := resources.First()
+		require.Equal(t, "Datasource", datasource.Kind())
+		require.Equal(t, "prometheus", datasource.Name())
+		require.Equal(t, "toml", datasource.Source.Format)
+		require.Equal(t, map[string]any{"httpMethod": "GET", "timeInterval": float64(15)}, datasource.GetSpecValue("jsonData"))
+	})
+
+	t.Run("array of resources", func(t *testing.T) {
+		resources, err := parser.Parse("testdata/parsing/toml/resources.toml", grizzly.ParserOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 2, resources.Len())
+
+		apps, found := resources.Find(grizzly.NewResourceRef("DashboardFolder", "apps"))
+		require.True(t, found)
+		require.Equal(t, "Applications", apps.GetSpecValue("title"))
+	})
+
+	t.Run("syntax errors are located", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "invalid.toml")
+		require.NoError(t, os.WriteFile(file, []byte("kind = \"Datasource\"\nname = \n"), 0644))
+
+		_, err := parser.Parse(file, grizzly.ParserOptions{})
+		require.ErrorContains(t, err, "invalid.toml:2:")
+	})
+}
+
+func TestStarlarkParser(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	parser := grizzly.DefaultParser(registry, nil, nil)
+
+	t.Run("globals are parsed into resources", func(t *testing.T) {
+		resources, err := parser.Parse("testdata/parsing/starlark/dashboards.star", grizzly.ParserOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 3, resources.Len())
+
+		folder, found := resources.Find(grizzly.NewResourceRef("DashboardFolder", "infra"))
+		require.True(t, found)
+		require.Equal(t, "Infrastructure", folder.GetSpecValue("title"))
+
+		db, found := resources.Find(grizzly.NewResourceRef("Dashboard", "nodes-db"))
+		require.True(t, found)
+		require.Equal(t, "infra", db.GetMetadata("folder"))
+		require.Equal(t, "Nodes (db)", db.GetSpecValue("title"))
+		require.Equal(t, "starlark", db.Source.Format)
+		require.Equal(t, []any{map[string]any{
+			"id":      float64(1),
+			"type":    "timeseries",
+			"title":   "CPU",
+			"targets": []any{map[string]any{"expr": `node_cpu_seconds_total{host="db"}`}},
+		}}, db.GetSpecValue("panels"))
+	})
+
+	t.Run("unknown kinds are reported", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "unknown.star")
+		require.NoError(t, os.WriteFile(file, []byte("widget = grizzly.resource(\"Widget\", \"nodes\", {})\n"), 0644))
+
+		_, err := parser.Parse(file, grizzly.ParserOptions{})
+		require.ErrorContains(t, err, "unknown.star:1:26")
+		require.ErrorContains(t, err, "Widget")
+	})
+}
+
+func TestJSONCommentsAndTrailingCommas(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	parser := grizzly.DefaultParser(registry, nil, nil)
+
+	resources, err := parser.Parse("testdata/parsing/dashboards-annotated.jsonc", grizzly.ParserOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, resources.Len())
+
+	dashboard := resources.First()
+	require.Equal(t, "nodes", dashboard.Name())
+	require.Equal(t, "Nodes // overview", dashboard.GetSpecValue("title"))
+	require.Equal(t, []any{"infra", "nodes"}, dashboard.GetSpecValue("tags"))
+	require.False(t, dashboard.Source.Rewritable)
+
+	t.Run("errors keep their position", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "invalid.json5")
+		require.NoError(t, os.WriteFile(file, []byte("{\n  /* a\n  comment */ \"kind\": \"Dashboard\",\n  \"spec\": {,}\n}\n"), 0644))
+
+		_, err := parser.Parse(file, grizzly.ParserOptions{})
+		require.ErrorContains(t, err, "invalid.json5:4:12")
+	})
+}
+
+func TestYAMLTemplates(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+
+	t.Run("YAML files are executed with the values", func(t *testing.T) {
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserTemplateValues("testdata/parsing/template-values.yaml"))
+		resources, err := parser.Parse("testdata/parsing/template", grizzly.ParserOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 1, resources.Len())
+
+		dashboard := resources.First()
+		require.Equal(t, "checkout-overview", dashboard.Name())
+		require.Equal(t, "shop", dashboard.GetMetadata("folder"))
+		require.Equal(t, "checkout overview", dashboard.GetSpecValue("title"))
+		require.Equal(t, []any{"production", "payments"}, dashboard.GetSpecValue("tags"))
+		require.False(t, dashboard.Source.Rewritable)
+	})
+
+	t.Run("YAML files aren't templates without values", func(t *testing.T) {
+		parser := grizzly.DefaultParser(registry, nil, nil)
+		_, err := parser.Parse("testdata/parsing/template", grizzly.ParserOptions{})
+		require.Error(t, err)
+	})
+
+	t.Run("missing values are errors", func(t *testing.T) {
+		values := filepath.Join(t.TempDir(), "values.yaml")
+		require.NoError(t, os.WriteFile(values, []byte("service: checkout\n"), 0644))
+
+		parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserTemplateValues(values))
+		_, err := parser.Parse("testdata/parsing/template/dashboard.yaml", grizzly.ParserOptions{})
+		require.ErrorContains(t, err, `map has no entry for key "folder"`)
+	})
+}
+
+func TestHelmParser(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+
+	// a fake helm command, rendering a dashboard ConfigMap and recording
+	// its arguments
+	bin := t.TempDir()
+	args := filepath.Join(bin, "args")
+	script := `#!/bin/sh
+echo "$@" > ` + args + `
+cat <<'MANIFESTS'
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: grizzly-dashboards
+  labels:
+    grafana_dashboard: "1"
+  annotations:
+    grafana_folder: Infrastructure
+data:
+  nodes.json: |
+    {"uid": "nodes", "title": "Nodes"}
+  disks.json: |
+    {"title": "Disks"}
+  README.md: not a dashboard
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  other.json: "{}"
+MANIFESTS
+`
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "helm"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	chart := filepath.Join(dir, "monitoring")
+	require.NoError(t, os.MkdirAll(filepath.Join(chart, "templates"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(chart, "Chart.yaml"), []byte("apiVersion: v2\nname: monitoring\nversion: 1.0.0\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(chart, "templates", "dashboards.yaml"), []byte("{{- range .Values.dashboards }}\n"), 0644))
+
+	parser := grizzly.DefaultParser(registry, nil, nil, grizzly.ParserHelmValues([]string{"production.yaml"}))
+	resources, err := parser.Parse(dir, grizzly.ParserOptions{})
+	require.NoError(t, err)
+
+	dashboards := resources.GroupByKind()["Dashboard"]
+	require.Equal(t, 2, dashboards.Len())
+	disks, found := dashboards.Find(grizzly.NewResourceRef("Dashboard", "disks"))
+	require.True(t, found)
+	require.Equal(t, "Disks", disks.GetSpecValue("title"))
+	require.Equal(t, "helm", disks.Source.Format)
+
+	// dashboards are placed in the folder named by the annotation
+	nodes, found := dashboards.Find(grizzly.NewResourceRef("Dashboard", "nodes"))
+	require.True(t, found)
+	folder, found := resources.Find(grizzly.NewResourceRef("DashboardFolder", nodes.GetMetadata("folder")))
+	require.True(t, found)
+	require.Equal(t, "Infrastructure", folder.GetSpecValue("title"))
+
+	rendered, err := os.ReadFile(args)
+	require.NoError(t, err)
+	require.Equal(t, "template grizzly "+chart+" --values production.yaml\n", string(rendered))
+}
@@ -0,0 +1,189 @@
+package grizzly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// OperationRead is reading remote resources, e.g. to pull or diff them
+	OperationRead = "read"
+	// OperationWrite is creating or updating remote resources
+	OperationWrite = "write"
+)
+
+// Access describes what grants an operation on the resources of a kind
+type Access struct {
+	// Role is the least basic role of the Grafana organization granting it
+	Role string
+	// Actions are the RBAC actions granting it, with fine-grained access
+	// control
+	Actions []string
+	// Scopes are the scopes of Grafana Cloud access policies granting it,
+	// for the resources of Grafana Cloud services
+	Scopes []string
+}
+
+func (access Access) String() string {
+	var grants []string
+	if access.Role != "" {
+		grants = append(grants, "role "+access.Role)
+	}
+	if len(access.Actions) > 0 {
+		grants = append(grants, "RBAC actions "+strings.Join(access.Actions, ", "))
+	}
+	if len(access.Scopes) > 0 {
+		grants = append(grants, "access policy scopes "+strings.Join(access.Scopes, ", "))
+	}
+
+	return strings.Join(grants, ", or ")
+}
+
+// Permissions describes what grants reading and writing the resources of a
+// kind. Writing requires reading as well.
+type Permissions struct {
+	Read  Access
+	Write Access
+}
+
+// PermissionsHandler describes a handler knowing the permissions required to
+// manage its resources
+type PermissionsHandler interface {
+	Permissions() Permissions
+}
+
+// PermissionProblem sums up the requests about a kind of resources denied by
+// their endpoint
+type PermissionProblem struct {
+	Kind      string
+	Operation string
+	// Unauthorized counts the requests denied as the credentials were
+	// rejected (401), Forbidden the ones denied for lack of permissions (403)
+	Unauthorized int
+	Forbidden    int
+	// Required is what grants the operation, when known
+	Required *Access
+}
+
+func (problem PermissionProblem) String() string {
+	var denials []string
+	if problem.Unauthorized > 0 {
+		denials = append(denials, fmt.Sprintf("%d unauthorized", problem.Unauthorized))
+	}
+	if problem.Forbidden > 0 {
+		denials = append(denials, fmt.Sprintf("%d forbidden", problem.Forbidden))
+	}
+
+	description := fmt.Sprintf("%s (%s): %s", problem.Kind, problem.Operation, strings.Join(denials, ", "))
+	if problem.Unauthorized > 0 {
+		description += "; check the credentials of the context"
+	}
+	if problem.Forbidden > 0 && problem.Required != nil {
+		description += "; requires " + problem.Required.String()
+	}
+
+	return description
+}
+
+// permissionCollector collects the permission problems of a call, by kind
+// and operation
+type permissionCollector struct {
+	lock      sync.Mutex
+	collected map[string]*PermissionProblem
+}
+
+type permissionProblemsKey struct{}
+
+// WithPermissionProblems returns a context collecting the permission
+// problems recorded with registries derived from it, to be reported at the
+// end of the call
+func WithPermissionProblems(parent context.Context) context.Context {
+	return context.WithValue(parent, permissionProblemsKey{}, &permissionCollector{collected: map[string]*PermissionProblem{}})
+}
+
+// RecordPermissionProblem collects an operation on a resource denied by its
+// endpoint in the context of the registry, to be reported at the end of the
+// call, along with the permissions the operation requires. Other errors, and
+// contexts without a collector, are ignored.
+func RecordPermissionProblem(registry Registry, kind string, operation string, err error) {
+	if ClassifyError(err) != ErrorClassAuth {
+		return
+	}
+	collector, ok := registry.Context().Value(permissionProblemsKey{}).(*permissionCollector)
+	if !ok {
+		return
+	}
+
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+
+	key := kind + "/" + operation
+	problem, ok := collector.collected[key]
+	if !ok {
+		problem = &PermissionProblem{Kind: kind, Operation: operation}
+		if handler, err := registry.GetHandler(kind); err == nil {
+			if permissioned, ok := unwrapHandler(handler).(PermissionsHandler); ok {
+				required := permissioned.Permissions().Read
+				if operation == OperationWrite {
+					required = permissioned.Permissions().Write
+				}
+				problem.Required = &required
+			}
+		}
+		collector.collected[key] = problem
+	}
+
+	if httpStatus(err) == http.StatusUnauthorized {
+		problem.Unauthorized++
+	} else {
+		problem.Forbidden++
+	}
+}
+
+// PermissionProblems returns the permission problems collected so far in the
+// context, by kind and operation
+func PermissionProblems(ctx context.Context) []PermissionProblem {
+	collector, ok := ctx.Value(permissionProblemsKey{}).(*permissionCollector)
+	if !ok {
+		return nil
+	}
+
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+
+	problems := make([]PermissionProblem, 0, len(collector.collected))
+	for _, problem := range collector.collected {
+		problems = append(problems, *problem)
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].Kind != problems[j].Kind {
+			return problems[i].Kind < problems[j].Kind
+		}
+		return problems[i].Operation < problems[j].Operation
+	})
+
+	return problems
+}
+
+// httpStatus returns the HTTP status code of an error, zero when unknown
+func httpStatus(err error) int {
+	var coder interface{ Code() int }
+	if errors.As(err, &coder) {
+		return coder.Code()
+	}
+	var isCoder interface{ IsCode(code int) bool }
+	if errors.As(err, &isCoder) {
+		for status := range statusClasses {
+			if isCoder.IsCode(status) {
+				return status
+			}
+		}
+	}
+
+	return 0
+}
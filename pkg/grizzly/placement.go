@@ -0,0 +1,208 @@
+package grizzly
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultOrgID is the organization of Grafana used when none is configured
+const DefaultOrgID int64 = 1
+
+// placeholderRegex matches the `<name>` placeholders of placement rules
+var placeholderRegex = regexp.MustCompile(`<([A-Za-z_][A-Za-z0-9_-]*)>`)
+
+// PlacementRule places the resources parsed from the files matching Path
+// into a folder and an organization, so that the layout of a repository
+// drives where resources go. Path is relative to the root of the project,
+// where `*` matches within a directory, `**` across directories, and `<name>`
+// captures a directory name, e.g. `teams/<team>/**`. Captured names can be
+// used in Folder, a folder UID, or FolderName, a folder path as in the
+// `folderName` metadata.
+type PlacementRule struct {
+	Path       string
+	Folder     string
+	FolderName string
+	// Org is the ID of the organization the resources belong to. They are
+	// skipped when targeting another organization.
+	Org int64
+}
+
+type compiledPlacementRule struct {
+	PlacementRule
+	regex *regexp.Regexp
+}
+
+// ParserPlacement sets rules placing resources depending on the files they
+// are parsed from, relative to root. The first matching rule applies.
+// Resources already setting their folder are only subject to the
+// organization of rules. orgID is the organization targeted.
+func ParserPlacement(root string, rules []PlacementRule, orgID int64) ParserOpt {
+	return func(config *parsersConfig) {
+		config.placementRoot = root
+		config.placementRules = rules
+		config.orgID = orgID
+	}
+}
+
+// PlacementParser places parsed resources according to placement rules,
+// before their `folderName` metadata is resolved
+type PlacementParser struct {
+	decorated Parser
+	root      string
+	rules     []compiledPlacementRule
+	orgID     int64
+	// err is the error of invalid rules, reported when parsing
+	err    error
+	logger *log.Entry
+}
+
+func NewPlacementParser(decorated Parser, root string, rules []PlacementRule, orgID int64) *PlacementParser {
+	parser := &PlacementParser{
+		decorated: decorated,
+		root:      root,
+		orgID:     orgID,
+		logger:    log.WithField("parser", "placement"),
+	}
+	if parser.orgID == 0 {
+		parser.orgID = DefaultOrgID
+	}
+	if absolute, err := filepath.Abs(root); err == nil {
+		parser.root = absolute
+	}
+
+	for i, rule := range rules {
+		if rule.Folder != "" && rule.FolderName != "" {
+			parser.err = fmt.Errorf("placement rule %d: folder and folder-name are mutually exclusive", i)
+			break
+		}
+		regex, err := compilePlacementPath(rule.Path)
+		if err != nil {
+			parser.err = fmt.Errorf("placement rule %d: %w", i, err)
+			break
+		}
+		parser.rules = append(parser.rules, compiledPlacementRule{PlacementRule: rule, regex: regex})
+	}
+
+	return parser
+}
+
+func (parser *PlacementParser) Accept(file string) bool {
+	return parser.decorated.Accept(file)
+}
+
+func (parser *PlacementParser) Parse(resourcePath string, options ParserOptions) (Resources, error) {
+	if parser.err != nil {
+		return NewResources(), parser.err
+	}
+
+	resources, parseErr := parser.decorated.Parse(resourcePath, options)
+
+	placed := NewResources()
+	for _, resource := range resources.AsList() {
+		rule, captures, found := parser.match(resource)
+		if !found {
+			placed.Add(resource)
+			continue
+		}
+
+		if rule.Org != 0 && rule.Org != parser.orgID {
+			parser.logger.WithField("resource", resource.Ref().String()).Debugf("Skipping resource of organization %d", rule.Org)
+			continue
+		}
+		if resource.Kind() == "Dashboard" && resource.GetMetadata("folder") == "" && resource.GetMetadata("folderName") == "" {
+			if rule.Folder != "" {
+				resource.SetMetadata("folder", expandPlaceholders(rule.Folder, captures))
+			}
+			if rule.FolderName != "" {
+				resource.SetMetadata("folderName", expandPlaceholders(rule.FolderName, captures))
+			}
+		}
+		placed.Add(resource)
+	}
+
+	return placed, parseErr
+}
+
+func (parser *PlacementParser) match(resource Resource) (compiledPlacementRule, map[string]string, bool) {
+	if resource.Source.Path == "" {
+		return compiledPlacementRule{}, nil, false
+	}
+
+	path, err := filepath.Abs(resource.Source.Path)
+	if err == nil {
+		path, err = filepath.Rel(parser.root, path)
+	}
+	if err != nil || strings.HasPrefix(path, "..") {
+		return compiledPlacementRule{}, nil, false
+	}
+	path = filepath.ToSlash(path)
+
+	for _, rule := range parser.rules {
+		match := rule.regex.FindStringSubmatch(path)
+		if match == nil {
+			continue
+		}
+
+		captures := map[string]string{}
+		for i, name := range rule.regex.SubexpNames() {
+			if name != "" {
+				captures[name] = match[i]
+			}
+		}
+		return rule, captures, true
+	}
+
+	return compiledPlacementRule{}, nil, false
+}
+
+// compilePlacementPath turns a path pattern into a regular expression
+// capturing its `<name>` placeholders
+func compilePlacementPath(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("no path given")
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	rest := strings.Trim(filepath.ToSlash(pattern), "/")
+	for rest != "" {
+		if loc := placeholderRegex.FindStringSubmatchIndex(rest); loc != nil && loc[0] == 0 {
+			name := strings.ReplaceAll(rest[loc[2]:loc[3]], "-", "_")
+			expr.WriteString("(?P<" + name + ">[^/]+)")
+			rest = rest[loc[1]:]
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(rest, "**/"):
+			expr.WriteString("(?:.*/)?")
+			rest = rest[3:]
+		case strings.HasPrefix(rest, "**"):
+			expr.WriteString(".*")
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "*"):
+			expr.WriteString("[^/]*")
+			rest = rest[1:]
+		default:
+			expr.WriteString(regexp.QuoteMeta(rest[:1]))
+			rest = rest[1:]
+		}
+	}
+	expr.WriteString("$")
+
+	return regexp.Compile(expr.String())
+}
+
+func expandPlaceholders(template string, captures map[string]string) string {
+	return placeholderRegex.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := strings.ReplaceAll(placeholder[1:len(placeholder)-1], "-", "_")
+		if value, ok := captures[name]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
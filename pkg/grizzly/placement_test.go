@@ -0,0 +1,65 @@
+package grizzly_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlacement(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	rules := []grizzly.PlacementRule{
+		{Path: "orgs/partners/**", Folder: "partners", Org: 2},
+		{Path: "teams/<team>/**", FolderName: "Teams/<team>"},
+	}
+	parse := func(t *testing.T, orgID int64, rules ...grizzly.PlacementRule) (grizzly.Resources, error) {
+		parser := grizzly.DefaultParser(registry, nil, nil,
+			grizzly.ParserFolderMap(filepath.Join(t.TempDir(), "folders.yaml")),
+			grizzly.ParserPlacement("testdata/placement", rules, orgID),
+		)
+		return parser.Parse("testdata/placement", grizzly.ParserOptions{})
+	}
+	folderTitle := func(t *testing.T, resources grizzly.Resources, uid string) string {
+		folder, found := resources.Find(grizzly.NewResourceRef(grizzly.FolderKind, uid))
+		require.True(t, found, "folder %s should be declared", uid)
+		return folder.Spec()["title"].(string)
+	}
+
+	t.Run("directories are mapped to folders", func(t *testing.T) {
+		resources, err := parse(t, 0, rules...)
+		require.NoError(t, err)
+
+		nodes, found := resources.Find(grizzly.NewResourceRef("Dashboard", "nodes"))
+		require.True(t, found)
+		require.Equal(t, "Teams/infra", nodes.GetMetadata("folderName"))
+		require.Equal(t, "infra", folderTitle(t, resources, nodes.GetMetadata("folder")))
+
+		disks, found := resources.Find(grizzly.NewResourceRef("Dashboard", "disks"))
+		require.True(t, found)
+		require.Equal(t, nodes.GetMetadata("folder"), disks.GetMetadata("folder"))
+
+		frontend, found := resources.Find(grizzly.NewResourceRef("Dashboard", "frontend"))
+		require.True(t, found)
+		require.Equal(t, "shared", frontend.GetMetadata("folder"), "explicit folders should be kept")
+
+		_, found = resources.Find(grizzly.NewResourceRef("Dashboard", "partner"))
+		require.False(t, found, "resources of other organizations should be skipped")
+	})
+
+	t.Run("resources of the targeted organization are kept", func(t *testing.T) {
+		resources, err := parse(t, 2, rules...)
+		require.NoError(t, err)
+
+		partner, found := resources.Find(grizzly.NewResourceRef("Dashboard", "partner"))
+		require.True(t, found)
+		require.Equal(t, "partners", partner.GetMetadata("folder"))
+	})
+
+	t.Run("invalid rules are reported", func(t *testing.T) {
+		_, err := parse(t, 0, grizzly.PlacementRule{Path: "teams/**", Folder: "teams", FolderName: "Teams"})
+		require.ErrorContains(t, err, "folder and folder-name are mutually exclusive")
+	})
+}
@@ -0,0 +1,165 @@
+package grizzly
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// PullManifestFile is the file listing the resources written by the
+	// last complete pull of a directory
+	PullManifestFile = ".grizzly-pull.json"
+	// pullJournalFile records the resources written by a pull in progress,
+	// as they are written
+	pullJournalFile = ".grizzly-pull.journal"
+)
+
+// PulledResource is a resource written by a pull
+type PulledResource struct {
+	Resource string `json:"resource"`
+	// File is relative to the pulled directory
+	File     string `json:"file"`
+	Checksum string `json:"checksum"`
+}
+
+// PullManifest lists the resources written by a pull
+type PullManifest struct {
+	Resources []PulledResource `json:"resources"`
+}
+
+// pullJournal records every resource written by a pull as soon as it is
+// written, so that an interrupted pull resumes where it left off. The
+// journal is replaced by a manifest once the pull completes.
+type pullJournal struct {
+	dir    string
+	file   *os.File
+	pulled map[string]PulledResource
+	// seen are the resources pulled or resumed by the current pull
+	seen map[string]bool
+}
+
+// openPullJournal opens the journal of a directory, along with the
+// resources recorded by an interrupted pull
+func openPullJournal(dir string) (*pullJournal, error) {
+	journal := &pullJournal{
+		dir:    dir,
+		pulled: map[string]PulledResource{},
+		seen:   map[string]bool{},
+	}
+	path := filepath.Join(dir, pullJournalFile)
+
+	existing, err := os.Open(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(existing)
+		for scanner.Scan() {
+			entry := PulledResource{}
+			// the last entry is truncated when the pull was interrupted
+			// while recording it
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				continue
+			}
+			journal.pulled[entry.Resource] = entry
+		}
+		existing.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	journal.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return journal, nil
+}
+
+// Pulled returns the file a resource was written to by the interrupted pull,
+// if it is still there
+func (journal *pullJournal) Pulled(ref string) (string, bool) {
+	entry, ok := journal.pulled[ref]
+	if !ok {
+		return "", false
+	}
+
+	filename := filepath.Join(journal.dir, entry.File)
+	content, err := os.ReadFile(filename)
+	if err != nil || checksumOf(content) != entry.Checksum {
+		return "", false
+	}
+	journal.seen[ref] = true
+	return filename, true
+}
+
+// Record records a resource written to a file
+func (journal *pullJournal) Record(ref string, filename string, content []byte) error {
+	relative, err := filepath.Rel(journal.dir, filename)
+	if err != nil {
+		return err
+	}
+	entry := PulledResource{
+		Resource: ref,
+		File:     filepath.ToSlash(relative),
+		Checksum: checksumOf(content),
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := journal.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	journal.pulled[ref] = entry
+	journal.seen[ref] = true
+
+	return nil
+}
+
+// Resumed returns the number of resources recorded by the interrupted pull
+func (journal *pullJournal) Resumed() int {
+	return len(journal.pulled) - len(journal.seen)
+}
+
+// Complete writes the manifest of the resources of the pull and removes the
+// journal
+func (journal *pullJournal) Complete() error {
+	manifest := PullManifest{Resources: make([]PulledResource, 0, len(journal.seen))}
+	for ref := range journal.seen {
+		manifest.Resources = append(manifest.Resources, journal.pulled[ref])
+	}
+	sort.Slice(manifest.Resources, func(i, j int) bool {
+		return manifest.Resources[i].Resource < manifest.Resources[j].Resource
+	})
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(journal.dir, PullManifestFile), content, 0644); err != nil {
+		return err
+	}
+	if err := journal.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(journal.file.Name())
+}
+
+func (journal *pullJournal) Close() error {
+	err := journal.file.Close()
+	if errors.Is(err, os.ErrClosed) {
+		return nil
+	}
+	return err
+}
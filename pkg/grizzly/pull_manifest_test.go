@@ -0,0 +1,72 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullResumesInterruptedPulls(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+
+	var resources []grizzly.Resource
+	for _, uid := range []string{"cpu", "memory"} {
+		resource := grizzlytest.NewDashboard(t, uid, uid)
+		resources = append(resources, resource)
+	}
+	require.NoError(t, grizzly.Apply(registry, grizzly.NewResources(resources...), false, grizzly.NewJUnitReport("apply")))
+
+	tmpl, err := grizzly.ParseFilenameTemplate("{{ .uid }}.yaml")
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	// an interrupted pull wrote cpu.yaml, and started recording memory.yaml
+	pulled := []byte("pulled before the interruption\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cpu.yaml"), pulled, 0644))
+	checksum := sha256.Sum256(pulled)
+	journal := fmt.Sprintf("{\"resource\":\"Dashboard.cpu\",\"file\":\"cpu.yaml\",\"checksum\":%q}\n{\"resource\":\"Dash", hex.EncodeToString(checksum[:]))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".grizzly-pull.journal"), []byte(journal), 0644))
+
+	out := &bytes.Buffer{}
+	err = grizzly.Pull(registry, dir, false, "yaml", tmpl, []string{"Dashboard/*"}, false, grizzly.NewWriterRecorder(out, grizzly.EventToPorcelain))
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "already pulled")
+
+	content, err := os.ReadFile(filepath.Join(dir, "cpu.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, pulled, content)
+	require.FileExists(t, filepath.Join(dir, "memory.yaml"))
+	require.NoFileExists(t, filepath.Join(dir, ".grizzly-pull.journal"))
+
+	manifestContent, err := os.ReadFile(filepath.Join(dir, grizzly.PullManifestFile))
+	require.NoError(t, err)
+	manifest := grizzly.PullManifest{}
+	require.NoError(t, json.Unmarshal(manifestContent, &manifest))
+	require.Len(t, manifest.Resources, 2)
+	require.Equal(t, "Dashboard.cpu", manifest.Resources[0].Resource)
+	require.Equal(t, "memory.yaml", manifest.Resources[1].File)
+
+	// once complete, pulls start over
+	out.Reset()
+	err = grizzly.Pull(registry, dir, false, "yaml", tmpl, []string{"Dashboard/*"}, false, grizzly.NewWriterRecorder(out, grizzly.EventToPorcelain))
+	require.NoError(t, err)
+	require.NotContains(t, out.String(), "already pulled")
+	content, err = os.ReadFile(filepath.Join(dir, "cpu.yaml"))
+	require.NoError(t, err)
+	require.NotEqual(t, pulled, content)
+
+	// the manifest isn't parsed as a resource
+	parsed, err := grizzly.DefaultParser(registry, nil, nil).Parse(dir, grizzly.ParserOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, parsed.Len())
+}
@@ -1,6 +1,7 @@
 package grizzly
 
 import (
+	"context"
 	"fmt"
 	"net/http/httputil"
 	"strings"
@@ -31,16 +32,33 @@ type Provider interface {
 	Status() ProviderStatus
 }
 
+// ContextProvider describes a provider whose requests can be bound to the
+// context of a call, e.g. to its deadline
+type ContextProvider interface {
+	// WithContext returns a copy of the provider sending its requests with
+	// the given context. Copies share their connections and what they
+	// detected about their endpoint.
+	WithContext(ctx context.Context) Provider
+}
+
 type ProxyProvider interface {
 	// SetupProxy establishes the proxy connection
 	SetupProxy() (*httputil.ReverseProxy, error)
 }
 
-// ProviderSet records providers
+// Registry records providers, and the handlers of the kinds of resources
+// they support. A registry, and the registries derived from it (e.g. with
+// WithRemoteCache or OnlyManaged), can be used from several goroutines once
+// created: they are never modified. The settings of a call (WithApplyStamp,
+// WithTimeouts) and what it collects (WithWarnings, WithPermissionProblems)
+// are carried by the context of the registry, so that concurrent calls each
+// derive their own registry with WithContext.
 type Registry struct {
 	Providers    []Provider
 	Handlers     map[string]Handler
 	HandlerOrder []Handler
+
+	ctx context.Context
 }
 
 // NewRegistry returns an empty registry
@@ -60,6 +78,76 @@ func NewRegistry(providers []Provider) Registry {
 	return registry
 }
 
+// Context returns the context of the registry, set with WithContext
+func (r *Registry) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// WithContext returns a registry whose providers send their requests with
+// the given context, when they support it (see ContextProvider), and whose
+// workflows record their warnings and permission problems in it. The
+// handlers of the registry are decorated as those of this one, e.g. for
+// caching.
+func (r *Registry) WithContext(ctx context.Context) Registry {
+	registry := Registry{
+		Providers:    make([]Provider, 0, len(r.Providers)),
+		Handlers:     make(map[string]Handler, len(r.Handlers)),
+		HandlerOrder: make([]Handler, 0, len(r.HandlerOrder)),
+		ctx:          ctx,
+	}
+
+	// only the handlers of the providers bound to the context are replaced
+	bound := map[string]Handler{}
+	for _, provider := range r.Providers {
+		if contextProvider, ok := provider.(ContextProvider); ok {
+			provider = contextProvider.WithContext(ctx)
+			for _, handler := range provider.GetHandlers() {
+				bound[handler.Kind()] = handler
+			}
+		}
+		registry.Providers = append(registry.Providers, provider)
+	}
+
+	for _, handler := range r.HandlerOrder {
+		handler = rebindHandler(ctx, handler, bound[handler.Kind()])
+		registry.Handlers[handler.Kind()] = handler
+		registry.HandlerOrder = append(registry.HandlerOrder, handler)
+	}
+
+	return registry
+}
+
+// rebindHandler decorates a handler bound to a context as the registry
+// decorated the given handler, e.g. for caching. Without a bound handler,
+// the given one is kept.
+func rebindHandler(ctx context.Context, handler Handler, bound Handler) Handler {
+	if bound == nil {
+		return handler
+	}
+
+	switch decorator := handler.(type) {
+	case *cachingHandler:
+		rebound := *decorator
+		rebound.Handler = rebindHandler(ctx, decorator.Handler, bound)
+		rebound.ctx = ctx
+		return &rebound
+	case *lockingHandler:
+		rebound := *decorator
+		rebound.Handler = rebindHandler(ctx, decorator.Handler, bound)
+		rebound.versioned, _ = unwrapHandler(rebound.Handler).(VersionedHandler)
+		return &rebound
+	case *managedHandler:
+		rebound := *decorator
+		rebound.Handler = rebindHandler(ctx, decorator.Handler, bound)
+		return &rebound
+	default:
+		return bound
+	}
+}
+
 // GetHandler returns a single provider based upon a JSON path
 func (r *Registry) GetHandler(kind string) (Handler, error) {
 	handler, exists := r.Handlers[kind]
@@ -0,0 +1,104 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryConcurrentUse(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+	cached := registry.WithRemoteCache(grizzly.NewRemoteCache(t.TempDir()), false)
+
+	// each call carries its own settings, and collects its own warnings
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			uid := fmt.Sprintf("concurrent-%d", i)
+			ctx := grizzly.WithWarnings(grizzly.WithApplyStamp(context.Background(), grizzly.ApplyStamp{Commit: uid}))
+			ctx, cancel := grizzly.WithTimeouts(ctx, 0, time.Minute, 0)
+			defer cancel()
+			call := cached.WithContext(ctx)
+
+			dashboard, err := grizzly.NewResource("grizzly.grafana.com/v1alpha1", "Dashboard", uid, map[string]any{
+				"uid":   uid,
+				"title": uid,
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+			dashboard.SetMetadata("folder", "general")
+
+			if err := grizzly.Apply(call, grizzly.NewResources(dashboard), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText)); err != nil {
+				errs <- err
+				return
+			}
+			grizzly.RecordWarning(call.Context(), grizzly.NewWarning(fmt.Errorf("applied %s", uid)))
+			if warnings := grizzly.Warnings(call.Context()); len(warnings) != 1 || warnings[0].Error() != "applied "+uid {
+				errs <- fmt.Errorf("unexpected warnings of %s: %v", uid, warnings)
+				return
+			}
+			handler, err := call.GetHandler("Dashboard")
+			if err == nil {
+				_, err = handler.GetByUID(uid)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	handler, err := registry.GetHandler("Dashboard")
+	require.NoError(t, err)
+	uids, err := handler.ListRemote()
+	require.NoError(t, err)
+	for i := 0; i < 8; i++ {
+		uid := fmt.Sprintf("concurrent-%d", i)
+		require.Contains(t, uids, uid)
+		require.Equal(t, "Applied by grizzly from commit "+uid, server.DashboardVersionMessage(uid))
+	}
+}
+
+func TestRegistryWithContext(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+	cached := registry.WithRemoteCache(grizzly.NewRemoteCache(t.TempDir()), true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bound := cached.WithContext(ctx)
+	require.Equal(t, ctx, bound.Context())
+	require.Equal(t, context.Background(), registry.Context())
+
+	t.Run("handlers stay decorated", func(t *testing.T) {
+		handler, err := bound.GetHandler("Dashboard")
+		require.NoError(t, err)
+		_, err = handler.GetByUID("nodes")
+		require.ErrorIs(t, err, grizzly.ErrNotFound)
+		require.NotContains(t, server.Requests(), "GET /api/dashboards/uid/nodes")
+	})
+
+	t.Run("requests are sent with the context", func(t *testing.T) {
+		cancel()
+		cancelled := registry.WithContext(ctx)
+		handler, err := cancelled.GetHandler("Dashboard")
+		require.NoError(t, err)
+		_, err = handler.ListRemote()
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
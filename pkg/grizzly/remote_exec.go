@@ -0,0 +1,406 @@
+package grizzly
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/grafana/grizzly/pkg/grizzly/notifier"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// ExecPrefix is where the API of the remote execution server is mounted
+const ExecPrefix = "/api/v1"
+
+// maxExecRequestSize bounds the size of the resources submitted at once
+const maxExecRequestSize = 64 << 20
+
+// ExecEvent is an event of a remote execution, as sent to clients
+type ExecEvent struct {
+	Type       string `json:"type"`
+	Resource   string `json:"resource"`
+	Details    string `json:"details,omitempty"`
+	ErrorClass string `json:"errorClass,omitempty"`
+}
+
+// ExecResponse is the response of the remote execution API. Error is the
+// reason why an operation failed, events telling which resources failed.
+type ExecResponse struct {
+	Resources []APIResource `json:"resources,omitempty"`
+	Events    []ExecEvent   `json:"events,omitempty"`
+	Warnings  []string      `json:"warnings,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// ExecServer runs the parse, diff and apply workflows on behalf of
+// authenticated clients, so that only the server holds the credentials of
+// the remote endpoints. Clients submit rendered resources, as a stream of
+// YAML or JSON documents:
+//
+//	POST /api/v1/parse    validates resources
+//	POST /api/v1/diff     compares resources with their remote counterparts
+//	POST /api/v1/apply    applies resources
+type ExecServer struct {
+	registry Registry
+	tokens   [][]byte
+}
+
+// NewExecServer returns a server accepting the requests authenticated with
+// one of the given bearer tokens
+func NewExecServer(registry Registry, tokens []string) (*ExecServer, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("the remote execution server requires at least one token")
+	}
+
+	server := &ExecServer{registry: registry}
+	for _, token := range tokens {
+		server.tokens = append(server.tokens, []byte(token))
+	}
+	return server, nil
+}
+
+// ReadExecTokens reads the tokens accepted by a remote execution server from
+// a file, one per line. Empty lines and lines starting with `#` are ignored.
+func ReadExecTokens(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	return tokens, scanner.Err()
+}
+
+// Handler returns the HTTP handler of the remote execution API
+func (s *ExecServer) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Use(s.authenticated)
+	r.Use(s.scoped)
+	r.Post(ExecPrefix+"/parse", s.parseHandler)
+	r.Post(ExecPrefix+"/diff", s.diffHandler)
+	r.Post(ExecPrefix+"/apply", s.applyHandler)
+	return r
+}
+
+func (s *ExecServer) authenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || !s.validToken(token) {
+			log.Warnf("Rejecting unauthenticated request from %s", r.RemoteAddr)
+			sendJSON(w, http.StatusUnauthorized, ExecResponse{Error: "invalid or missing token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// scoped gives each request its own context, collecting the warnings it
+// raises, so that requests are served concurrently
+func (s *ExecServer) scoped(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithWarnings(r.Context())))
+	})
+}
+
+// registryFor returns the registry serving a request, bound to its context
+func (s *ExecServer) registryFor(r *http.Request) Registry {
+	return s.registry.WithContext(r.Context())
+}
+
+// respond sends a response, along with the warnings raised by the request
+func (s *ExecServer) respond(w http.ResponseWriter, r *http.Request, code int, response ExecResponse) {
+	for _, warning := range Warnings(r.Context()) {
+		response.Warnings = append(response.Warnings, warning.Error())
+	}
+
+	sendJSON(w, code, response)
+}
+
+// validToken compares tokens in constant time, not to leak them
+func (s *ExecServer) validToken(token string) bool {
+	valid := false
+	for _, accepted := range s.tokens {
+		if subtle.ConstantTimeCompare(accepted, []byte(token)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// parse parses the resources submitted with a request, writing an error
+// when they can't be parsed
+func (s *ExecServer) parse(w http.ResponseWriter, r *http.Request, registry Registry) (Resources, bool) {
+	body := http.MaxBytesReader(w, r.Body, maxExecRequestSize)
+	parser := DefaultParser(registry, nil, nil, ParserStdin(body))
+	resources, err := parser.Parse(StdinPath, ParserOptions{})
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		s.respond(w, r, http.StatusRequestEntityTooLarge, ExecResponse{Error: fmt.Sprintf("resources larger than %s", formatSize(maxExecRequestSize))})
+		return resources, false
+	}
+	if err != nil {
+		s.respond(w, r, http.StatusBadRequest, ExecResponse{Error: err.Error()})
+		return resources, false
+	}
+	return resources, true
+}
+
+func (s *ExecServer) parseHandler(w http.ResponseWriter, r *http.Request) {
+	registry := s.registryFor(r)
+	resources, ok := s.parse(w, r, registry)
+	if !ok {
+		return
+	}
+
+	response := ExecResponse{Resources: []APIResource{}}
+	invalid := 0
+	for _, resource := range resources.AsList() {
+		described := APIResource{APIVersion: resource.APIVersion(), Kind: resource.Kind(), Name: resource.Name(), Valid: true}
+		if err := validate(registry, resource); err != nil {
+			described.Valid, described.Error = false, err.Error()
+			invalid++
+		}
+		response.Resources = append(response.Resources, described)
+	}
+	if invalid > 0 {
+		response.Error = fmt.Sprintf("%s invalid", Pluraliser(invalid, "resource"))
+	}
+
+	s.respond(w, r, http.StatusOK, response)
+}
+
+func validate(registry Registry, resource Resource) error {
+	handler, err := registry.GetHandler(resource.Kind())
+	if err != nil {
+		return err
+	}
+	return handler.Validate(resource)
+}
+
+func (s *ExecServer) diffHandler(w http.ResponseWriter, r *http.Request) {
+	registry := s.registryFor(r)
+	resources, ok := s.parse(w, r, registry)
+	if !ok {
+		return
+	}
+	onlySpec, _ := strconv.ParseBool(r.URL.Query().Get("only-spec"))
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatYAML
+	}
+
+	s.run(w, r, "diff", resources, func(recorder eventsRecorder) error {
+		return Diff(registry, resources, onlySpec, format, recorder)
+	})
+}
+
+func (s *ExecServer) applyHandler(w http.ResponseWriter, r *http.Request) {
+	registry := s.registryFor(r)
+	resources, ok := s.parse(w, r, registry)
+	if !ok {
+		return
+	}
+	continueOnError, _ := strconv.ParseBool(r.URL.Query().Get("continue-on-error"))
+
+	s.run(w, r, "apply", resources, func(recorder eventsRecorder) error {
+		return Apply(registry, resources, continueOnError, recorder)
+	})
+}
+
+// run runs a workflow, sending its events
+func (s *ExecServer) run(w http.ResponseWriter, r *http.Request, workflow string, resources Resources, run func(recorder eventsRecorder) error) {
+	log.Infof("Running %s of %s for %s", workflow, Pluraliser(resources.Len(), "resource"), r.RemoteAddr)
+	recorder := &execRecorder{events: []ExecEvent{}}
+	response := ExecResponse{}
+	if err := run(recorder); err != nil {
+		response.Error = err.Error()
+	}
+	response.Events = recorder.events
+
+	s.respond(w, r, http.StatusOK, response)
+}
+
+// execRecorder records the events of a workflow, to send them to clients
+type execRecorder struct {
+	events []ExecEvent
+}
+
+func (recorder *execRecorder) Record(event Event) {
+	recorder.events = append(recorder.events, ExecEvent{
+		Type:       event.Type.ID,
+		Resource:   event.ResourceRef,
+		Details:    event.Details,
+		ErrorClass: string(event.ErrorClass),
+	})
+}
+
+// execEventTypes are the types of the events sent by remote execution
+// servers
+var execEventTypes = []EventType{
+	ResourceAdded, ResourceNotChanged, ResourceNotFound, ResourceUpdated, ResourceFailure, ResourceSkipped, ResourceChanged,
+}
+
+func (event ExecEvent) event() Event {
+	eventType := EventType{ID: event.Type, Severity: Info, HumanReadable: event.Type}
+	for _, known := range execEventTypes {
+		if known.ID == event.Type {
+			eventType = known
+		}
+	}
+
+	return Event{
+		Type:        eventType,
+		ResourceRef: event.Resource,
+		Details:     event.Details,
+		ErrorClass:  ErrorClass(event.ErrorClass),
+	}
+}
+
+// ExecClient submits rendered resources to a remote execution server, which
+// holds the credentials of the remote endpoints
+type ExecClient struct {
+	url    string
+	token  string
+	client *http.Client
+	// ctx collects the warnings sent by the server
+	ctx context.Context
+}
+
+func NewExecClient(url string, token string) *ExecClient {
+	return &ExecClient{
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+		client: &http.Client{},
+		ctx:    context.Background(),
+	}
+}
+
+// WithContext returns a copy of the client sending its requests with the
+// given context, and recording the warnings sent by the server in it
+func (c *ExecClient) WithContext(ctx context.Context) *ExecClient {
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
+// Diff compares resources with their remote counterparts on the server,
+// notifying the differences as if compared locally
+func (c *ExecClient) Diff(resources Resources, onlySpec bool, outputFormat string, eventsRecorder eventsRecorder) error {
+	query := fmt.Sprintf("?only-spec=%t&format=%s", onlySpec, outputFormat)
+	response, err := c.send("diff"+query, resources)
+	if err != nil {
+		return err
+	}
+
+	for _, execEvent := range response.Events {
+		event := execEvent.event()
+		if resource, found := resources.Find(refFromString(event.ResourceRef)); found {
+			switch event.Type {
+			case ResourceChanged:
+				notifier.HasChanges(resource, event.Details)
+			case ResourceNotChanged:
+				notifier.NoChanges(resource)
+			case ResourceNotFound:
+				notifier.NotFound(resource)
+			}
+		}
+		eventsRecorder.Record(event)
+	}
+	return execError(response)
+}
+
+// Apply applies resources on the server
+func (c *ExecClient) Apply(resources Resources, continueOnError bool, eventsRecorder eventsRecorder) error {
+	response, err := c.send(fmt.Sprintf("apply?continue-on-error=%t", continueOnError), resources)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range response.Events {
+		eventsRecorder.Record(event.event())
+	}
+	return execError(response)
+}
+
+// send submits resources to an endpoint of the server, as a stream of YAML
+// documents
+func (c *ExecClient) send(endpoint string, resources Resources) (ExecResponse, error) {
+	body := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(body)
+	for _, resource := range resources.AsList() {
+		hydrated, err := resource.Hydrate()
+		if err != nil {
+			return ExecResponse{}, err
+		}
+		if err := encoder.Encode(hydrated.Body); err != nil {
+			return ExecResponse{}, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return ExecResponse{}, err
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodPost, c.url+ExecPrefix+"/"+endpoint, body)
+	if err != nil {
+		return ExecResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return ExecResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var response ExecResponse
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ExecResponse{}, err
+	}
+	if err := json.Unmarshal(content, &response); err != nil {
+		return ExecResponse{}, fmt.Errorf("unexpected response of the remote execution server (%s): %s", resp.Status, content)
+	}
+	for _, warning := range response.Warnings {
+		RecordWarning(c.ctx, NewWarning(errors.New(warning)))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return response, fmt.Errorf("remote execution server: %s: %s", resp.Status, response.Error)
+	}
+	return response, nil
+}
+
+func execError(response ExecResponse) error {
+	if response.Error == "" {
+		return nil
+	}
+	return fmt.Errorf("remote execution server: %s", response.Error)
+}
+
+// refFromString parses the references of resources, as formatted by
+// ResourceRef.String
+func refFromString(ref string) ResourceRef {
+	kind, name, _ := strings.Cut(ref, ".")
+	return NewResourceRef(kind, name)
+}
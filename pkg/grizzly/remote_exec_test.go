@@ -0,0 +1,120 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteExecution(t *testing.T) {
+	grafanaServer := grizzlytest.NewServer(t)
+	registry := grafanaServer.GrafanaRegistry()
+	execServer, err := grizzly.NewExecServer(registry, []string{"ci-token"})
+	require.NoError(t, err)
+	server := httptest.NewServer(execServer.Handler())
+	t.Cleanup(server.Close)
+
+	// clients have no credentials of their own
+	clientRegistry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	folder := func(t *testing.T, title string) grizzly.Resources {
+		t.Helper()
+		return grizzly.NewResources(grizzlytest.NewFolder(t, "platform", title))
+	}
+	client := grizzly.NewExecClient(server.URL, "ci-token")
+
+	t.Run("resources are applied by the server", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		require.NoError(t, client.Apply(folder(t, "Platform"), false, grizzly.NewWriterRecorder(output, grizzly.EventToPlainText)))
+		require.Equal(t, "DashboardFolder.platform added\n", output.String())
+
+		stored, found := grafanaServer.Folder("platform")
+		require.True(t, found)
+		require.Equal(t, "Platform", stored["title"])
+	})
+
+	t.Run("resources are compared by the server", func(t *testing.T) {
+		summary := grizzly.NewDiffSummary()
+		require.NoError(t, client.Diff(folder(t, "Platform team"), false, "yaml", summary))
+		require.Equal(t, 1, summary.Count("DashboardFolder", grizzly.ResourceChanged))
+	})
+
+	t.Run("lazy resources are submitted with their spec", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "apiVersion: grizzly.grafana.com/v1alpha1\nkind: DashboardFolder\nmetadata:\n  name: platform\nspec:\n  uid: platform\n  title: Platform\n" + strings.Repeat("# padding\n", 200)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "folder.yaml"), []byte(content), 0644))
+		resources, err := grizzly.DefaultParser(clientRegistry, nil, nil, grizzly.ParserLargeFileSize(1024)).Parse(dir, grizzly.ParserOptions{})
+		require.NoError(t, err)
+		require.True(t, resources.First().IsLazy())
+
+		summary := grizzly.NewDiffSummary()
+		require.NoError(t, client.Diff(resources, false, "yaml", summary))
+		require.Equal(t, 1, summary.Count("DashboardFolder", grizzly.ResourceNotChanged))
+	})
+
+	t.Run("failures are reported", func(t *testing.T) {
+		invalid := grizzlytest.NewResource(t, "Dashboard", "broken", map[string]any{"uid": "other"})
+
+		output := &bytes.Buffer{}
+		err = client.Apply(grizzly.NewResources(invalid), false, grizzly.NewWriterRecorder(output, grizzly.EventToPlainText))
+		require.ErrorContains(t, err, "remote execution server:")
+		require.Contains(t, output.String(), "Dashboard.broken failed")
+	})
+
+	t.Run("requests are served concurrently", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make(chan error, 4)
+		for i := 0; i < 4; i++ {
+			uid := fmt.Sprintf("team-%d", i)
+			resources := grizzly.NewResources(grizzlytest.NewFolder(t, uid, uid))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- client.Apply(resources, false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText))
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		for i := 0; i < 4; i++ {
+			_, found := grafanaServer.Folder(fmt.Sprintf("team-%d", i))
+			require.True(t, found)
+		}
+	})
+
+	t.Run("requests are authenticated", func(t *testing.T) {
+		err := grizzly.NewExecClient(server.URL, "stolen").Apply(folder(t, "Mine"), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText))
+		require.ErrorContains(t, err, "401 Unauthorized: invalid or missing token")
+
+		resp, err := http.Post(server.URL+grizzly.ExecPrefix+"/apply", "application/yaml", strings.NewReader("{}"))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		_, err = grizzly.NewExecServer(registry, nil)
+		require.ErrorContains(t, err, "requires at least one token")
+	})
+
+	t.Run("invalid submissions are rejected", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+grizzly.ExecPrefix+"/parse", strings.NewReader("kind: [unclosed"))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer ci-token")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
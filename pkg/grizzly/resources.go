@@ -41,6 +41,10 @@ type Resource struct {
 	Body map[string]interface{}
 
 	Source Source
+
+	// lazy loads the spec of resources parsed from large files, kept
+	// without it until needed
+	lazy *lazySpec
 }
 
 func ResourceFromMap(data map[string]interface{}) (*Resource, error) {
@@ -126,6 +130,14 @@ func (r *Resource) SetMetadata(key, value string) {
 	r.Body["metadata"] = metadata
 }
 
+// GetAnnotation returns the value of an annotation, set in the
+// `annotations` map of the metadata
+func (r *Resource) GetAnnotation(key string) string {
+	annotations, _ := r.metadata()["annotations"].(map[string]any)
+	value, _ := annotations[key].(string)
+	return value
+}
+
 func (r *Resource) HasSpecString(key string) bool {
 	_, ok := r.Spec()[key]
 	return ok
@@ -161,6 +173,9 @@ func (r *Resource) DeleteSpecKey(key string) {
 }
 
 func (r *Resource) Spec() map[string]interface{} {
+	if r.IsLazy() {
+		r.hydrateInPlace()
+	}
 	return r.Body["spec"].(map[string]interface{})
 }
 
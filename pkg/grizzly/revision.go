@@ -0,0 +1,199 @@
+package grizzly
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grizzly/pkg/grizzly/notifier"
+	log "github.com/sirupsen/logrus"
+)
+
+// RevisionSources writes the files of the git repository holding a path, as
+// they were at a revision, to a temporary directory, without checking the
+// revision out. It returns where the path is in that directory, and a
+// function removing it.
+func RevisionSources(path string, ref string) (string, func(), error) {
+	noop := func() {}
+
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return "", noop, err
+	}
+	dir := absolute
+	if info, err := os.Stat(absolute); err != nil || !info.IsDir() {
+		dir = filepath.Dir(absolute)
+	}
+
+	git := func(args ...string) *exec.Cmd {
+		return exec.Command("git", append([]string{"-C", dir}, args...)...)
+	}
+	output, err := git("rev-parse", "--show-toplevel").CombinedOutput()
+	if err != nil {
+		return "", noop, fmt.Errorf("%s is not in a git repository: %w: %s", path, err, strings.TrimSpace(string(output)))
+	}
+	root := strings.TrimSpace(string(output))
+	output, err = git("rev-parse", "--verify", "--quiet", ref+"^{commit}").Output()
+	if err != nil {
+		return "", noop, fmt.Errorf("unknown git revision %s", ref)
+	}
+	commit := strings.TrimSpace(string(output))
+
+	// the repository root is resolved, unlike the path given
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", noop, err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Dir(absolute))
+	if err != nil {
+		return "", noop, err
+	}
+	relative, err := filepath.Rel(root, filepath.Join(resolved, filepath.Base(absolute)))
+	if err != nil {
+		return "", noop, err
+	}
+
+	sources, err := os.MkdirTemp("", "grizzly-revision-")
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { os.RemoveAll(sources) }
+
+	log.Debugf("Extracting %s at %s to %s", root, commit, sources)
+	archive := exec.Command("git", "-C", root, "archive", "--format=tar", commit)
+	stderr := &strings.Builder{}
+	archive.Stderr = stderr
+	stdout, err := archive.StdoutPipe()
+	if err != nil {
+		cleanup()
+		return "", noop, err
+	}
+	if err := archive.Start(); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+	extractErr := extractTar(stdout, sources)
+	if err := archive.Wait(); err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("reading the sources at %s: %w: %s", ref, err, strings.TrimSpace(stderr.String()))
+	}
+	if extractErr != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("reading the sources at %s: %w", ref, extractErr)
+	}
+
+	return filepath.Join(sources, relative), cleanup, nil
+}
+
+// extractTar writes the files of a tar archive to a directory
+func extractTar(reader io.Reader, dir string) error {
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !filepath.IsLocal(header.Name) {
+			return fmt.Errorf("invalid file name %s", header.Name)
+		}
+		target := filepath.Join(dir, header.Name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = writeTarFile(archive, target, header.FileInfo().Mode())
+		case tar.TypeSymlink:
+			if err = os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+				err = os.Symlink(header.Linkname, target)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeTarFile(reader io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, reader); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// DiffRevision compares the resources rendered from the sources at a git
+// revision with the ones of the working tree, reporting the resources added,
+// changed and removed since the revision
+func DiffRevision(registry Registry, base Resources, resources Resources, ref string, onlySpec bool, outputFormat string, eventsRecorder eventsRecorder) error {
+	log.Infof("Diff-ing %d resources with %s", resources.Len(), ref)
+
+	for _, resource := range resources.AsList() {
+		resourceRef := resource.Ref().String()
+		handler, err := registry.GetHandler(resource.Kind())
+		if err != nil {
+			return err
+		}
+
+		previous, found := base.Find(resource.Ref())
+		if !found {
+			notifier.Info(resource, "added since "+ref)
+			eventsRecorder.Record(Event{Type: ResourceNotFound, ResourceRef: resourceRef, Details: "added since " + ref})
+			continue
+		}
+
+		// lazy resources with the same spec are unchanged, without loading
+		// their spec
+		if sameLazyResources(previous, resource) {
+			notifier.NoChanges(resource)
+			eventsRecorder.Record(Event{Type: ResourceNotChanged, ResourceRef: resourceRef})
+			continue
+		}
+		if previous, err = previous.Hydrate(); err != nil {
+			return err
+		}
+		if resource, err = resource.Hydrate(); err != nil {
+			return err
+		}
+
+		previous = Normalize(handler, *handler.Unprepare(previous))
+		current := Normalize(handler, *handler.Unprepare(resource))
+		difference, err := unifiedDiff(registry, previous, current, ref, "Working tree", outputFormat, onlySpec)
+		if err != nil {
+			return err
+		}
+		if difference == "" {
+			notifier.NoChanges(resource)
+			eventsRecorder.Record(Event{Type: ResourceNotChanged, ResourceRef: resourceRef})
+			continue
+		}
+
+		notifier.HasChanges(resource, difference)
+		eventsRecorder.Record(Event{Type: ResourceChanged, ResourceRef: resourceRef, Details: difference})
+	}
+
+	for _, previous := range base.AsList() {
+		if _, found := resources.Find(previous.Ref()); found {
+			continue
+		}
+		notifier.Info(previous, "removed since "+ref)
+		eventsRecorder.Record(Event{Type: ResourceRemoved, ResourceRef: previous.Ref().String(), Details: "removed since " + ref})
+	}
+
+	return nil
+}
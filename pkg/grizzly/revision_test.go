@@ -0,0 +1,88 @@
+package grizzly_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffRevision(t *testing.T) {
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	repository := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-C", repository, "-c", "user.name=grizzly", "-c", "user.email=grizzly@example.com"}, args...)
+		output, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	write := func(name string, title string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Join(repository, "resources"), 0755))
+		content := "apiVersion: grizzly.grafana.com/v1alpha1\nkind: Dashboard\nmetadata:\n  name: " + name + "\nspec:\n  uid: " + name + "\n  title: " + title + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(repository, "resources", name+".yaml"), []byte(content), 0644))
+	}
+	parse := func(path string) grizzly.Resources {
+		t.Helper()
+		resources, err := grizzly.DefaultParser(registry, nil, nil).Parse(path, grizzly.ParserOptions{})
+		require.NoError(t, err)
+		return resources
+	}
+
+	git("init", "--quiet", "--initial-branch", "main")
+	write("nodes", "Nodes")
+	write("pods", "Pods")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "Release")
+	git("tag", "v1")
+
+	write("nodes", "Node exporter")
+	write("disks", "Disks")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "Add disks")
+	require.NoError(t, os.Remove(filepath.Join(repository, "resources", "pods.yaml")))
+	write("network", "Network") // not committed yet
+
+	t.Run("sources are read at a revision without checking it out", func(t *testing.T) {
+		path, cleanup, err := grizzly.RevisionSources(filepath.Join(repository, "resources"), "v1")
+		require.NoError(t, err)
+		defer cleanup()
+
+		require.Equal(t, []string{"Dashboard.nodes", "Dashboard.pods"}, refs(parse(path)))
+		_, err = os.Stat(filepath.Join(repository, "resources", "disks.yaml"))
+		require.NoError(t, err, "the working tree is left as is")
+
+		cleanup()
+		_, err = os.Stat(path)
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("changes since the revision are reported", func(t *testing.T) {
+		path, cleanup, err := grizzly.RevisionSources(filepath.Join(repository, "resources"), "HEAD~1")
+		require.NoError(t, err)
+		defer cleanup()
+
+		summary := grizzly.NewDiffSummary()
+		require.NoError(t, grizzly.DiffRevision(registry, parse(path), parse(filepath.Join(repository, "resources")), "HEAD~1", false, "yaml", summary))
+		require.Equal(t, 1, summary.Count("Dashboard", grizzly.ResourceChanged))
+		require.Equal(t, 2, summary.Count("Dashboard", grizzly.ResourceNotFound), "disks and network are new")
+		require.Equal(t, 1, summary.Count("Dashboard", grizzly.ResourceRemoved))
+	})
+
+	t.Run("unknown revisions are reported", func(t *testing.T) {
+		_, _, err := grizzly.RevisionSources(repository, "v2")
+		require.ErrorContains(t, err, "unknown git revision v2")
+	})
+}
+
+func refs(resources grizzly.Resources) []string {
+	refs := []string{}
+	for _, resource := range resources.AsList() {
+		refs = append(refs, resource.Ref().String())
+	}
+	return refs
+}
@@ -0,0 +1,308 @@
+package grizzly
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RemoteLocation is the location of matches found in remote resources
+const RemoteLocation = "remote"
+
+// SearchQuery describes what to look for in resources
+type SearchQuery struct {
+	// Text is looked for in values, everything matches when empty
+	Text       string
+	IgnoreCase bool
+	// Path is a JSONPath expression (e.g. `$.spec.panels[*].targets[*].expr`
+	// or `$..datasource.uid`) restricting the values looked into
+	Path string
+}
+
+// SearchMatch is a value of a resource matching a search query
+type SearchMatch struct {
+	Resource string `yaml:"resource" json:"resource"`
+	// Location is the file the resource comes from, or `remote`
+	Location string `yaml:"location" json:"location"`
+	// Path is the JSONPath of the value in the resource
+	Path  string `yaml:"path" json:"path"`
+	Value string `yaml:"value" json:"value"`
+}
+
+// Search looks for the values of resources matching a query. Values are
+// strings, numbers and booleans, nested in the objects and lists selected
+// by the path of the query.
+func Search(resources Resources, location func(Resource) string, query SearchQuery) ([]SearchMatch, error) {
+	steps, err := parseJSONPath(query.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	text := query.Text
+	if query.IgnoreCase {
+		text = strings.ToLower(text)
+	}
+
+	var matches []SearchMatch
+	for _, resource := range resources.AsList() {
+		// round-trip through JSON, to look into resources as they are pushed
+		content, err := json.Marshal(resource.Body)
+		if err != nil {
+			return nil, err
+		}
+		var body any
+		if err := json.Unmarshal(content, &body); err != nil {
+			return nil, err
+		}
+
+		for _, node := range selectJSONPath(jsonNode{path: "$", value: body}, steps) {
+			for _, leaf := range jsonLeaves(node) {
+				value := leaf.value.(string)
+				candidate := value
+				if query.IgnoreCase {
+					candidate = strings.ToLower(candidate)
+				}
+				if !strings.Contains(candidate, text) {
+					continue
+				}
+				matches = append(matches, SearchMatch{
+					Resource: resource.Ref().String(),
+					Location: location(resource),
+					Path:     leaf.path,
+					Value:    value,
+				})
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// SearchRemote looks for the values of the remote resources of the targeted
+// kinds matching a query
+func SearchRemote(registry Registry, targets []string, query SearchQuery) ([]SearchMatch, error) {
+	var resources []Resource
+	for _, handler := range registry.HandlerOrder {
+		if !registry.HandlerMatchesTarget(handler, targets) {
+			continue
+		}
+		uids, err := handler.ListRemote()
+		if err != nil {
+			return nil, fmt.Errorf("listing remote %s resources: %w", handler.Kind(), err)
+		}
+		for _, uid := range uids {
+			if !registry.ResourceMatchesTarget(handler.Kind(), uid, targets) {
+				continue
+			}
+			resource, err := handler.GetByUID(uid)
+			if err != nil {
+				return nil, fmt.Errorf("retrieving %s: %w", NewResourceRef(handler.Kind(), uid), err)
+			}
+			resources = append(resources, *handler.Unprepare(*resource))
+		}
+	}
+
+	return Search(NewResources(resources...), func(Resource) string { return RemoteLocation }, query)
+}
+
+// FormatSearchMatches renders matches as a table (`default`), `json` or `yaml`
+func FormatSearchMatches(matches []SearchMatch, format string) ([]byte, error) {
+	if matches == nil {
+		matches = []SearchMatch{}
+	}
+
+	switch format {
+	case formatJSON:
+		return json.MarshalIndent(matches, "", "  ")
+	case formatYAML:
+		return yaml.Marshal(matches)
+	case formatDefault:
+		var out bytes.Buffer
+		w := tabwriter.NewWriter(&out, 0, 0, 4, ' ', 0)
+		fmt.Fprintf(w, "RESOURCE\tLOCATION\tPATH\tVALUE\n")
+		for _, match := range matches {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", match.Resource, match.Location, match.Path, abbreviate(match.Value, 80))
+		}
+		err := w.Flush()
+		return out.Bytes(), err
+	}
+
+	return nil, fmt.Errorf("unknown format %s", format)
+}
+
+func abbreviate(value string, length int) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if len(value) <= length {
+		return value
+	}
+	return value[:length-3] + "..."
+}
+
+type jsonNode struct {
+	path  string
+	value any
+}
+
+// jsonPathStep is a step of a JSONPath expression: a key (`.key`,
+// `['key']`), an index (`[1]`) or a wildcard (`.*`, `[*]`), optionally
+// applied to all descendants (`..key`)
+type jsonPathStep struct {
+	key       string
+	index     int
+	isIndex   bool
+	wildcard  bool
+	recursive bool
+}
+
+// parseJSONPath parses the subset of JSONPath supported by searches
+func parseJSONPath(expression string) ([]jsonPathStep, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(expression), "$")
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid path %q: %s", expression, reason)
+	}
+
+	var steps []jsonPathStep
+	for rest != "" {
+		var step jsonPathStep
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			step.recursive = true
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+		case strings.HasPrefix(rest, "["):
+		default:
+			return nil, invalid(fmt.Sprintf("unexpected %q", rest))
+		}
+
+		if strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, invalid("unterminated [")
+			}
+			selector := rest[1:end]
+			rest = rest[end+1:]
+
+			switch {
+			case selector == "*":
+				step.wildcard = true
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				step.key = selector[1 : len(selector)-1]
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, invalid(fmt.Sprintf("unsupported selector [%s]", selector))
+				}
+				step.index, step.isIndex = index, true
+			}
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			step.key = rest[:end]
+			rest = rest[end:]
+
+			if step.key == "" {
+				return nil, invalid("empty key")
+			}
+			if step.key == "*" {
+				step.key, step.wildcard = "", true
+			}
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+func selectJSONPath(root jsonNode, steps []jsonPathStep) []jsonNode {
+	nodes := []jsonNode{root}
+	for _, step := range steps {
+		var selected []jsonNode
+		for _, node := range nodes {
+			candidates := []jsonNode{node}
+			if step.recursive {
+				candidates = jsonDescendants(node)
+			}
+			for _, candidate := range candidates {
+				selected = append(selected, jsonChildren(candidate, step)...)
+			}
+		}
+		nodes = selected
+	}
+
+	return nodes
+}
+
+func jsonChildren(node jsonNode, step jsonPathStep) []jsonNode {
+	var children []jsonNode
+
+	switch value := node.value.(type) {
+	case map[string]any:
+		if step.isIndex {
+			return nil
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			if step.wildcard || key == step.key {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			children = append(children, jsonNode{path: jsonPathKey(node.path, key), value: value[key]})
+		}
+	case []any:
+		for i, item := range value {
+			if step.wildcard || (step.isIndex && step.index == i) {
+				children = append(children, jsonNode{path: fmt.Sprintf("%s[%d]", node.path, i), value: item})
+			}
+		}
+	}
+
+	return children
+}
+
+// jsonDescendants returns a node and all the nodes nested in it
+func jsonDescendants(node jsonNode) []jsonNode {
+	descendants := []jsonNode{node}
+	for _, child := range jsonChildren(node, jsonPathStep{wildcard: true}) {
+		descendants = append(descendants, jsonDescendants(child)...)
+	}
+	return descendants
+}
+
+// jsonLeaves returns the values nested in a node, formatted as strings
+func jsonLeaves(node jsonNode) []jsonNode {
+	switch value := node.value.(type) {
+	case map[string]any, []any:
+		var leaves []jsonNode
+		for _, child := range jsonChildren(node, jsonPathStep{wildcard: true}) {
+			leaves = append(leaves, jsonLeaves(child)...)
+		}
+		return leaves
+	case string:
+		return []jsonNode{node}
+	case nil:
+		return nil
+	default:
+		return []jsonNode{{path: node.path, value: fmt.Sprint(value)}}
+	}
+}
+
+func jsonPathKey(path string, key string) string {
+	for _, r := range key {
+		if !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return fmt.Sprintf("%s['%s']", path, key)
+		}
+	}
+	return path + "." + key
+}
@@ -0,0 +1,106 @@
+package grizzly_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	dashboard := func(uid string, expr string) grizzly.Resource {
+		return grizzlytest.NewResource(t, "Dashboard", uid, map[string]any{
+			"uid":   uid,
+			"title": "CPU of " + uid,
+			"panels": []any{
+				map[string]any{
+					"id":         1,
+					"datasource": map[string]any{"uid": "prometheus"},
+					"targets":    []any{map[string]any{"expr": expr}},
+				},
+			},
+		})
+	}
+	resources := grizzly.NewResources(
+		dashboard("nodes", "rate(node_cpu_usage[5m])"),
+		dashboard("pods", "sum(container_memory_usage)"),
+	)
+	location := func(resource grizzly.Resource) string {
+		return "dashboards/" + resource.Name() + ".json"
+	}
+
+	tests := []struct {
+		name     string
+		query    grizzly.SearchQuery
+		expected []grizzly.SearchMatch
+	}{
+		{
+			name:  "full-text",
+			query: grizzly.SearchQuery{Text: "cpu_usage"},
+			expected: []grizzly.SearchMatch{
+				{Resource: "Dashboard.nodes", Location: "dashboards/nodes.json", Path: "$.spec.panels[0].targets[0].expr", Value: "rate(node_cpu_usage[5m])"},
+			},
+		},
+		{
+			name:  "ignoring case",
+			query: grizzly.SearchQuery{Text: "CPU OF", IgnoreCase: true},
+			expected: []grizzly.SearchMatch{
+				{Resource: "Dashboard.nodes", Location: "dashboards/nodes.json", Path: "$.spec.title", Value: "CPU of nodes"},
+				{Resource: "Dashboard.pods", Location: "dashboards/pods.json", Path: "$.spec.title", Value: "CPU of pods"},
+			},
+		},
+		{
+			name:  "path",
+			query: grizzly.SearchQuery{Text: "usage", Path: "$.spec.panels[*].targets[0]"},
+			expected: []grizzly.SearchMatch{
+				{Resource: "Dashboard.nodes", Location: "dashboards/nodes.json", Path: "$.spec.panels[0].targets[0].expr", Value: "rate(node_cpu_usage[5m])"},
+				{Resource: "Dashboard.pods", Location: "dashboards/pods.json", Path: "$.spec.panels[0].targets[0].expr", Value: "sum(container_memory_usage)"},
+			},
+		},
+		{
+			name:  "recursive path without text",
+			query: grizzly.SearchQuery{Path: "$..datasource['uid']"},
+			expected: []grizzly.SearchMatch{
+				{Resource: "Dashboard.nodes", Location: "dashboards/nodes.json", Path: "$.spec.panels[0].datasource.uid", Value: "prometheus"},
+				{Resource: "Dashboard.pods", Location: "dashboards/pods.json", Path: "$.spec.panels[0].datasource.uid", Value: "prometheus"},
+			},
+		},
+		{
+			name:  "numbers",
+			query: grizzly.SearchQuery{Text: "1", Path: "$.spec.panels.*.id"},
+			expected: []grizzly.SearchMatch{
+				{Resource: "Dashboard.nodes", Location: "dashboards/nodes.json", Path: "$.spec.panels[0].id", Value: "1"},
+				{Resource: "Dashboard.pods", Location: "dashboards/pods.json", Path: "$.spec.panels[0].id", Value: "1"},
+			},
+		},
+		{
+			name:  "no matches",
+			query: grizzly.SearchQuery{Text: "cpu_usage", Path: "$.spec.title"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matches, err := grizzly.Search(resources, location, test.query)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, matches)
+		})
+	}
+
+	t.Run("invalid paths", func(t *testing.T) {
+		_, err := grizzly.Search(resources, location, grizzly.SearchQuery{Path: "$.spec.panels[?(@.id)]"})
+		require.ErrorContains(t, err, "unsupported selector")
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		server := grizzlytest.NewServer(t)
+		registry := server.GrafanaRegistry()
+		require.NoError(t, grizzly.Apply(registry, resources, false, grizzly.NewJUnitReport("apply")))
+
+		matches, err := grizzly.SearchRemote(registry, []string{"Dashboard"}, grizzly.SearchQuery{Text: "memory"})
+		require.NoError(t, err)
+		require.Equal(t, []grizzly.SearchMatch{
+			{Resource: "Dashboard.pods", Location: grizzly.RemoteLocation, Path: "$.spec.panels[0].targets[0].expr", Value: "sum(container_memory_usage)"},
+		}, matches)
+	})
+}
@@ -36,6 +36,7 @@ type Server struct {
 	ResourcePath   string
 	WatchPaths     []string
 	watchScript    string
+	watcherOpts    []WatcherOpt
 	OnlySpec       bool
 	OutputFormat   string
 	watch          bool
@@ -94,6 +95,11 @@ func (s *Server) WatchScript(script string) {
 	s.watchScript = script
 }
 
+// SetWatcherOpts configures how changes are detected when watching
+func (s *Server) SetWatcherOpts(opts ...WatcherOpt) {
+	s.watcherOpts = opts
+}
+
 func (s *Server) SetFormatting(onlySpec bool, outputFormat string) {
 	s.OnlySpec = onlySpec
 	s.OutputFormat = outputFormat
@@ -185,6 +191,7 @@ func (s *Server) Start() error {
 		r.Post(pattern, s.blockHandler(response))
 	}
 	r.Get("/", s.RootHandler)
+	r.Mount(APIPrefix, s.APIHandler())
 	r.Get("/grizzly/{kind}/{name}", s.IframeHandler)
 	r.Get("/api/live/ws", livereload.LiveReloadHandlerFunc(upgrader))
 
@@ -213,7 +220,7 @@ func (s *Server) Start() error {
 	}
 	if s.watch {
 		livereload.Initialize()
-		watcher, err := NewWatcher(s.updateWatchedResource)
+		watcher, err := NewWatcher(s.updateWatchedResource, s.watcherOpts...)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,171 @@
+package grizzly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/hashicorp/go-multierror"
+	log "github.com/sirupsen/logrus"
+)
+
+// APIPrefix is where the read-only JSON API of the server is mounted
+const APIPrefix = "/grizzly/api"
+
+// APIResource describes a parsed resource, as listed by the JSON API
+type APIResource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Source     struct {
+		Format string `json:"format,omitempty"`
+		Path   string `json:"path,omitempty"`
+	} `json:"source"`
+	// Valid tells whether the resource passes the validation of its handler,
+	// Error giving the reason why not
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+	// Body is only given when a single resource is requested
+	Body map[string]any `json:"body,omitempty"`
+}
+
+// APIResourceList is the response listing parsed resources
+type APIResourceList struct {
+	Resources   []APIResource `json:"resources"`
+	ParseErrors []string      `json:"parseErrors"`
+}
+
+// APIDiff describes the differences between a resource and its remote
+// counterpart. Status is one of `changed`, `unchanged` or `new`.
+type APIDiff struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Diff   string `json:"diff,omitempty"`
+}
+
+// APIHandler serves a read-only JSON API exposing the resources parsed by
+// the server, so that other tools can query them:
+//
+//	GET /resources                      lists resources and parse errors
+//	GET /resources/{kind}/{name}        describes a resource, with its body
+//	GET /resources/{kind}/{name}/diff   compares a resource with its remote counterpart
+func (s *Server) APIHandler() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/resources", s.apiListHandler)
+	r.Get("/resources/{kind}/{name}", s.apiResourceHandler)
+	r.Get("/resources/{kind}/{name}/diff", s.apiDiffHandler)
+
+	return r
+}
+
+func (s *Server) apiListHandler(w http.ResponseWriter, _ *http.Request) {
+	list := APIResourceList{
+		Resources:   []APIResource{},
+		ParseErrors: []string{},
+	}
+
+	for _, resource := range s.Resources.AsList() {
+		list.Resources = append(list.Resources, s.describeResource(resource))
+	}
+
+	if s.parserErr != nil {
+		parseErrors := []error{s.parserErr}
+		if merr, ok := s.parserErr.(*multierror.Error); ok {
+			parseErrors = merr.Errors
+		}
+		for _, err := range parseErrors {
+			list.ParseErrors = append(list.ParseErrors, err.Error())
+		}
+	}
+
+	sendJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) apiResourceHandler(w http.ResponseWriter, r *http.Request) {
+	resource, ok := s.findAPIResource(w, r)
+	if !ok {
+		return
+	}
+
+	described := s.describeResource(resource)
+	described.Body = resource.Body
+	sendJSON(w, http.StatusOK, described)
+}
+
+func (s *Server) apiDiffHandler(w http.ResponseWriter, r *http.Request) {
+	resource, ok := s.findAPIResource(w, r)
+	if !ok {
+		return
+	}
+
+	handler, err := s.Registry.GetHandler(resource.Kind())
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := APIDiff{Kind: resource.Kind(), Name: resource.Name()}
+	difference, err := resourceDiff(s.Registry, handler, *handler.Unprepare(resource), s.OnlySpec, s.OutputFormat)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		result.Status = "new"
+	case err != nil:
+		sendJSONError(w, http.StatusBadGateway, err)
+		return
+	case difference == "":
+		result.Status = "unchanged"
+	default:
+		result.Status = "changed"
+		result.Diff = difference
+	}
+
+	sendJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) findAPIResource(w http.ResponseWriter, r *http.Request) (Resource, bool) {
+	ref := NewResourceRef(chi.URLParam(r, "kind"), chi.URLParam(r, "name"))
+
+	resource, found := s.Resources.Find(ref)
+	if !found {
+		sendJSONError(w, http.StatusNotFound, fmt.Errorf("%s not found", ref))
+	}
+
+	return resource, found
+}
+
+func (s *Server) describeResource(resource Resource) APIResource {
+	described := APIResource{
+		APIVersion: resource.APIVersion(),
+		Kind:       resource.Kind(),
+		Name:       resource.Name(),
+		Valid:      true,
+	}
+	described.Source.Format = resource.Source.Format
+	described.Source.Path = resource.Source.Path
+
+	handler, err := s.Registry.GetHandler(resource.Kind())
+	if err == nil {
+		err = handler.Validate(resource)
+	}
+	if err != nil {
+		described.Valid = false
+		described.Error = err.Error()
+	}
+
+	return described
+}
+
+func sendJSON(w http.ResponseWriter, code int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Errorf("error writing response: %v", err)
+	}
+}
+
+func sendJSONError(w http.ResponseWriter, code int, err error) {
+	sendJSON(w, code, map[string]string{"error": err.Error()})
+}
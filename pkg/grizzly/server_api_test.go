@@ -0,0 +1,91 @@
+package grizzly_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerAPI(t *testing.T) {
+	grafanaServer := grizzlytest.NewServer(t)
+	registry := grafanaServer.GrafanaRegistry()
+
+	require.NoError(t, grizzly.Apply(registry, grizzly.NewResources(grizzlytest.NewDashboard(t, "same", "Same"), grizzlytest.NewDashboard(t, "changed", "Before")), false, grizzly.NewJUnitReport("apply")))
+
+	dir := t.TempDir()
+	for _, resource := range []grizzly.Resource{grizzlytest.NewDashboard(t, "same", "Same"), grizzlytest.NewDashboard(t, "changed", "After"), grizzlytest.NewDashboard(t, "new", "New")} {
+		content, _, _, err := grizzly.Format(registry, "", &resource, "yaml", false)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, resource.Name()+".yaml"), content, 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("kind: ["), 0644))
+
+	server, err := grizzly.NewGrizzlyServer(registry, dir, 0)
+	require.NoError(t, err)
+	server.SetParser(grizzly.DefaultParser(registry, nil, nil, grizzly.ParserContinueOnError(true)), grizzly.ParserOptions{})
+	_, err = server.ParseResources(dir)
+	require.Error(t, err)
+
+	api := httptest.NewServer(server.APIHandler())
+	defer api.Close()
+
+	get := func(t *testing.T, path string, expectedCode int, result any) {
+		response, err := http.Get(api.URL + path)
+		require.NoError(t, err)
+		defer response.Body.Close()
+
+		require.Equal(t, expectedCode, response.StatusCode)
+		require.Equal(t, "application/json", response.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(response.Body).Decode(result))
+	}
+
+	t.Run("resources are listed", func(t *testing.T) {
+		var list grizzly.APIResourceList
+		get(t, "/resources", http.StatusOK, &list)
+
+		names := []string{}
+		for _, resource := range list.Resources {
+			require.Equal(t, "Dashboard", resource.Kind)
+			require.True(t, resource.Valid)
+			require.Empty(t, resource.Body)
+			names = append(names, resource.Name)
+		}
+		require.ElementsMatch(t, []string{"same", "changed", "new"}, names)
+		require.Len(t, list.ParseErrors, 1)
+		require.Contains(t, list.ParseErrors[0], "broken.yaml")
+	})
+
+	t.Run("resources are described", func(t *testing.T) {
+		var resource grizzly.APIResource
+		get(t, "/resources/Dashboard/changed", http.StatusOK, &resource)
+
+		require.Equal(t, filepath.Join(dir, "changed.yaml"), resource.Source.Path)
+		require.Equal(t, "After", resource.Body["spec"].(map[string]any)["title"])
+
+		var apiErr map[string]string
+		get(t, "/resources/Dashboard/unknown", http.StatusNotFound, &apiErr)
+		require.Equal(t, "Dashboard.unknown not found", apiErr["error"])
+	})
+
+	t.Run("resources are compared with remote ones", func(t *testing.T) {
+		var diff grizzly.APIDiff
+		get(t, "/resources/Dashboard/same/diff", http.StatusOK, &diff)
+		require.Equal(t, grizzly.APIDiff{Kind: "Dashboard", Name: "same", Status: "unchanged"}, diff)
+
+		diff = grizzly.APIDiff{}
+		get(t, "/resources/Dashboard/new/diff", http.StatusOK, &diff)
+		require.Equal(t, "new", diff.Status)
+
+		diff = grizzly.APIDiff{}
+		get(t, "/resources/Dashboard/changed/diff", http.StatusOK, &diff)
+		require.Equal(t, "changed", diff.Status)
+		require.Contains(t, diff.Diff, "-    title: Before\n+    title: After\n")
+	})
+}
@@ -0,0 +1,111 @@
+package grizzly
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-jsonnet/formatter"
+	"github.com/hashicorp/go-multierror"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatSource returns the canonical formatting of a Jsonnet or YAML source
+// file: Jsonnet files are formatted as jsonnetfmt does, YAML files are
+// indented with two spaces, keeping their comments. Handled is false for
+// other files.
+func FormatSource(file string, content []byte) (formatted []byte, handled bool, err error) {
+	switch filepath.Ext(file) {
+	case ".jsonnet", ".libsonnet":
+		output, err := formatter.Format(file, string(content), formatter.DefaultOptions())
+		return []byte(output), true, err
+	case ".yaml", ".yml":
+		output, err := formatYAMLSource(content)
+		return output, true, err
+	default:
+		return nil, false, nil
+	}
+}
+
+func formatYAMLSource(content []byte) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	output := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(output)
+	encoder.SetIndent(2)
+	for documents := 0; ; documents++ {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) && documents == 0 {
+			// files without documents, e.g. holding only comments, are
+			// left as is
+			return content, nil
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := encoder.Encode(&document); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return output.Bytes(), nil
+}
+
+// FormatSources formats the Jsonnet and YAML files of a path, which may be a
+// single file, returning the files whose formatting changed. Files are
+// rewritten unless check is true. Vendored files and hidden directories are
+// skipped.
+func FormatSources(path string, check bool) ([]string, error) {
+	var changed []string
+	var finalErr error
+	err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if file != path && (entry.Name() == JsonnetVendorDir || strings.HasPrefix(entry.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		formatted, handled, err := FormatSource(file, content)
+		if err != nil {
+			finalErr = multierror.Append(finalErr, fmt.Errorf("%s: %w", file, err))
+			return nil
+		}
+		if !handled || bytes.Equal(content, formatted) {
+			return nil
+		}
+
+		changed = append(changed, file)
+		if check {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(file, formatted, info.Mode().Perm())
+	})
+	if err != nil {
+		return changed, err
+	}
+
+	return changed, finalErr
+}
@@ -0,0 +1,79 @@
+package grizzly_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatSources(t *testing.T) {
+	write := func(t *testing.T, dir string, files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			file := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+			require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		}
+	}
+	read := func(t *testing.T, file string) string {
+		t.Helper()
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	unformatted := map[string]string{
+		"dashboard.jsonnet":        "{\n    \"title\":   \"Nodes\",\n  panels: [ ]\n}\n",
+		"lib/util.libsonnet":       "local x=1;{x:x}\n",
+		"folders.yaml":             "# folders\nkind:   DashboardFolder\nmetadata:\n    name: teams\n---\nkind: DashboardFolder\nmetadata: {name: legacy}\n",
+		"formatted.yaml":           "kind: Dashboard\nmetadata:\n  name: nodes\n",
+		"comments.yml":             "# nothing yet\n",
+		"dashboard.json":           "{ \"title\":   \"untouched\" }\n",
+		"vendor/lib/lib.libsonnet": "{a:1}\n",
+	}
+
+	t.Run("files are rewritten", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, unformatted)
+
+		changed, err := grizzly.FormatSources(dir, false)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			filepath.Join(dir, "dashboard.jsonnet"),
+			filepath.Join(dir, "folders.yaml"),
+			filepath.Join(dir, "lib/util.libsonnet"),
+		}, changed)
+
+		require.Equal(t, "{\n  title: 'Nodes',\n  panels: [],\n}\n", read(t, filepath.Join(dir, "dashboard.jsonnet")))
+		require.Equal(t, "local x = 1; { x: x }\n", read(t, filepath.Join(dir, "lib/util.libsonnet")))
+		require.Equal(t, "# folders\nkind: DashboardFolder\nmetadata:\n  name: teams\n---\nkind: DashboardFolder\nmetadata: {name: legacy}\n", read(t, filepath.Join(dir, "folders.yaml")))
+		require.Equal(t, unformatted["dashboard.json"], read(t, filepath.Join(dir, "dashboard.json")))
+		require.Equal(t, unformatted["vendor/lib/lib.libsonnet"], read(t, filepath.Join(dir, "vendor/lib/lib.libsonnet")))
+
+		changed, err = grizzly.FormatSources(dir, true)
+		require.NoError(t, err)
+		require.Empty(t, changed, "formatting should be stable")
+	})
+
+	t.Run("files are only checked", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, unformatted)
+
+		changed, err := grizzly.FormatSources(filepath.Join(dir, "folders.yaml"), true)
+		require.NoError(t, err)
+		require.Equal(t, []string{filepath.Join(dir, "folders.yaml")}, changed)
+		require.Equal(t, unformatted["folders.yaml"], read(t, filepath.Join(dir, "folders.yaml")))
+	})
+
+	t.Run("invalid files are reported", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, map[string]string{"invalid.jsonnet": "{ title: }\n", "valid.yaml": "a:    1\n"})
+
+		changed, err := grizzly.FormatSources(dir, false)
+		require.ErrorContains(t, err, filepath.Join(dir, "invalid.jsonnet"))
+		require.Equal(t, []string{filepath.Join(dir, "valid.yaml")}, changed)
+	})
+}
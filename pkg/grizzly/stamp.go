@@ -0,0 +1,83 @@
+package grizzly
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ApplyStamp traces applied resources to the commit and the pipeline that
+// produced them
+type ApplyStamp struct {
+	Commit      string
+	PipelineURL string
+	AppliedAt   time.Time
+}
+
+type applyStampKey struct{}
+
+// WithApplyStamp returns a context whose stamp handlers supporting it record
+// along with the resources they apply, e.g. in the version history of
+// dashboards
+func WithApplyStamp(parent context.Context, stamp ApplyStamp) context.Context {
+	return context.WithValue(parent, applyStampKey{}, stamp)
+}
+
+// ApplyStampFromContext returns the stamp set with WithApplyStamp, if any
+func ApplyStampFromContext(ctx context.Context) (ApplyStamp, bool) {
+	stamp, ok := ctx.Value(applyStampKey{}).(ApplyStamp)
+	return stamp, ok
+}
+
+// DetectApplyStamp builds a stamp from the environment of CI systems (GitHub
+// Actions, GitLab CI, Buildkite, Jenkins, CircleCI), falling back to the
+// commit checked out in the current directory
+func DetectApplyStamp(now time.Time) ApplyStamp {
+	stamp := ApplyStamp{
+		Commit:    firstEnv("GITHUB_SHA", "CI_COMMIT_SHA", "BUILDKITE_COMMIT", "GIT_COMMIT", "CIRCLE_SHA1"),
+		AppliedAt: now.UTC(),
+	}
+
+	if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+		stamp.PipelineURL = fmt.Sprintf("%s/%s/actions/runs/%s", os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), runID)
+	} else {
+		stamp.PipelineURL = firstEnv("CI_PIPELINE_URL", "BUILDKITE_BUILD_URL", "BUILD_URL", "CIRCLE_BUILD_URL")
+	}
+
+	if stamp.Commit == "" {
+		if output, err := exec.Command("git", "rev-parse", "HEAD").Output(); err == nil {
+			stamp.Commit = strings.TrimSpace(string(output))
+		}
+	}
+
+	return stamp
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Message describes the stamp in a single line, e.g.
+// `Applied by grizzly from commit 3f2a9c1 (https://ci/pipelines/42) at 2024-05-01T12:00:00Z`
+func (stamp ApplyStamp) Message() string {
+	message := "Applied by grizzly"
+	if stamp.Commit != "" {
+		message += " from commit " + stamp.Commit
+	}
+	if stamp.PipelineURL != "" {
+		message += " (" + stamp.PipelineURL + ")"
+	}
+	if !stamp.AppliedAt.IsZero() {
+		message += " at " + stamp.AppliedAt.UTC().Format(time.RFC3339)
+	}
+
+	return message
+}
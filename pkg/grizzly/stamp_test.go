@@ -0,0 +1,61 @@
+package grizzly_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectApplyStamp(t *testing.T) {
+	appliedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("GitHub Actions", func(t *testing.T) {
+		t.Setenv("GITHUB_SHA", "3f2a9c1")
+		t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+		t.Setenv("GITHUB_REPOSITORY", "org/dashboards")
+		t.Setenv("GITHUB_RUN_ID", "42")
+
+		stamp := grizzly.DetectApplyStamp(appliedAt)
+		require.Equal(t, grizzly.ApplyStamp{
+			Commit:      "3f2a9c1",
+			PipelineURL: "https://github.com/org/dashboards/actions/runs/42",
+			AppliedAt:   appliedAt,
+		}, stamp)
+		require.Equal(t, "Applied by grizzly from commit 3f2a9c1 (https://github.com/org/dashboards/actions/runs/42) at 2024-05-01T12:00:00Z", stamp.Message())
+	})
+
+	t.Run("GitLab CI", func(t *testing.T) {
+		t.Setenv("GITHUB_SHA", "")
+		t.Setenv("GITHUB_RUN_ID", "")
+		t.Setenv("CI_COMMIT_SHA", "8b1e0d2")
+		t.Setenv("CI_PIPELINE_URL", "https://gitlab.com/org/dashboards/-/pipelines/7")
+
+		stamp := grizzly.DetectApplyStamp(appliedAt)
+		require.Equal(t, "8b1e0d2", stamp.Commit)
+		require.Equal(t, "https://gitlab.com/org/dashboards/-/pipelines/7", stamp.PipelineURL)
+	})
+}
+
+func TestApplyStamp(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+
+	apply := func(title string) {
+		dashboard := grizzlytest.NewResource(t, "Dashboard", "nodes", map[string]any{
+			"uid":   "nodes",
+			"title": title,
+		})
+		require.NoError(t, grizzly.Apply(registry, grizzly.NewResources(dashboard), false, grizzly.NewJUnitReport("apply")))
+	}
+
+	apply("Nodes")
+	require.Equal(t, "", server.DashboardVersionMessage("nodes"))
+
+	registry = registry.WithContext(grizzly.WithApplyStamp(context.Background(), grizzly.ApplyStamp{Commit: "3f2a9c1"}))
+	apply("Nodes (updated)")
+	require.Equal(t, "Applied by grizzly from commit 3f2a9c1", server.DashboardVersionMessage("nodes"))
+}
@@ -0,0 +1,203 @@
+package grizzly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+const formatStarlark = "starlark"
+
+// StarlarkParser parses resources generated by Starlark programs, a lighter
+// alternative to Jsonnet. Programs build resources with
+// `grizzly.resource(kind, uid, spec)`, and the global values they define are
+// parsed like the output of Jsonnet files. Globals whose names start with an
+// underscore are private to the program.
+type StarlarkParser struct {
+	registry Registry
+	logger   *log.Entry
+}
+
+func NewStarlarkParser(registry Registry) *StarlarkParser {
+	return &StarlarkParser{
+		registry: registry,
+		logger:   log.WithField("parser", "starlark"),
+	}
+}
+
+func (parser *StarlarkParser) Accept(file string) bool {
+	return filepath.Ext(file) == ".star"
+}
+
+// Parse executes a Starlark file and parses its globals into resources
+func (parser *StarlarkParser) Parse(file string, options ParserOptions) (Resources, error) {
+	parser.logger.WithField("file", file).Debug("Parsing file")
+
+	loader := &starlarkLoader{
+		predeclared: starlark.StringDict{
+			"grizzly": &starlarkstruct.Module{
+				Name: "grizzly",
+				Members: starlark.StringDict{
+					"resource": starlark.NewBuiltin("resource", parser.resource),
+				},
+			},
+			"json": starlarkjson.Module,
+		},
+		modules: map[string]*starlarkModule{},
+	}
+	thread := &starlark.Thread{
+		Name: file,
+		Load: loader.load,
+		Print: func(_ *starlark.Thread, msg string) {
+			parser.logger.WithField("file", file).Info(msg)
+		},
+	}
+
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, file, nil, loader.predeclared)
+	if err != nil {
+		return Resources{}, starlarkError(err)
+	}
+
+	data := map[string]any{}
+	for _, name := range globals.Keys() {
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+		switch globals[name].(type) {
+		case *starlark.Function, *starlark.Builtin, *starlarkstruct.Module:
+			continue
+		}
+
+		value, err := starlarkToAny(thread, globals[name])
+		if err != nil {
+			return Resources{}, fmt.Errorf("starlark: global %s: %w", name, err)
+		}
+		data[name] = value
+	}
+
+	source := Source{
+		Format:     formatStarlark,
+		Path:       file,
+		Rewritable: false,
+	}
+
+	return parseAny(parser.registry, data, options.DefaultResourceKind, options.DefaultFolderUID, source)
+}
+
+// resource implements `grizzly.resource(kind, uid, spec, folder="", metadata={})`,
+// returning the envelope of a resource
+func (parser *StarlarkParser) resource(_ *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var kind, uid, folder string
+	var spec starlark.Value
+	metadata := &starlark.Dict{}
+	if err := starlark.UnpackArgs(builtin.Name(), args, kwargs, "kind", &kind, "uid", &uid, "spec", &spec, "folder?", &folder, "metadata?", &metadata); err != nil {
+		return nil, err
+	}
+
+	handler, err := parser.registry.GetHandler(kind)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", builtin.Name(), err)
+	}
+
+	resourceMetadata := starlark.NewDict(metadata.Len() + 2)
+	for _, item := range metadata.Items() {
+		if err := resourceMetadata.SetKey(item[0], item[1]); err != nil {
+			return nil, err
+		}
+	}
+	if err := resourceMetadata.SetKey(starlark.String("name"), starlark.String(uid)); err != nil {
+		return nil, err
+	}
+	if folder != "" {
+		if err := resourceMetadata.SetKey(starlark.String("folder"), starlark.String(folder)); err != nil {
+			return nil, err
+		}
+	}
+
+	envelope := starlark.NewDict(4)
+	for _, field := range []struct {
+		key   string
+		value starlark.Value
+	}{
+		{"apiVersion", starlark.String(handler.APIVersion())},
+		{"kind", starlark.String(handler.Kind())},
+		{"metadata", resourceMetadata},
+		{"spec", spec},
+	} {
+		if err := envelope.SetKey(starlark.String(field.key), field.value); err != nil {
+			return nil, err
+		}
+	}
+
+	return envelope, nil
+}
+
+type starlarkModule struct {
+	globals starlark.StringDict
+	err     error
+}
+
+// starlarkLoader loads the modules of `load()` statements, relative to the
+// file loading them. Modules are executed once per parsed file.
+type starlarkLoader struct {
+	predeclared starlark.StringDict
+	modules     map[string]*starlarkModule
+}
+
+func (loader *starlarkLoader) load(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+	path := module
+	if !filepath.IsAbs(path) && thread.CallStackDepth() > 0 {
+		path = filepath.Join(filepath.Dir(thread.CallFrame(0).Pos.Filename()), module)
+	}
+
+	loaded, ok := loader.modules[path]
+	if ok && loaded == nil {
+		return nil, fmt.Errorf("cycle in load graph: %s", module)
+	}
+	if ok {
+		return loaded.globals, loaded.err
+	}
+
+	loader.modules[path] = nil
+	content, err := os.ReadFile(path)
+	loaded = &starlarkModule{err: err}
+	if err == nil {
+		moduleThread := &starlark.Thread{Name: path, Load: thread.Load, Print: thread.Print}
+		loaded.globals, loaded.err = starlark.ExecFileOptions(&syntax.FileOptions{}, moduleThread, path, content, loader.predeclared)
+	}
+	loader.modules[path] = loaded
+
+	return loaded.globals, loaded.err
+}
+
+// starlarkToAny converts a Starlark value to the types JSON decodes into
+func starlarkToAny(thread *starlark.Thread, value starlark.Value) (any, error) {
+	encoded, err := starlark.Call(thread, starlarkjson.Module.Members["encode"], starlark.Tuple{value}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result any
+	if err := json.Unmarshal([]byte(encoded.(starlark.String)), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// starlarkError reports the backtrace of evaluation errors
+func starlarkError(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return fmt.Errorf("starlark: %s", evalErr.Backtrace())
+	}
+	return fmt.Errorf("starlark: %w", err)
+}
@@ -0,0 +1,191 @@
+package grizzly
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KindStats describes the resources of a kind
+type KindStats struct {
+	Kind string `yaml:"kind" json:"kind"`
+	// Local is the number of local resources
+	Local int `yaml:"local" json:"local"`
+	// Size is the size of the local resources, in bytes of JSON
+	Size int `yaml:"size" json:"size"`
+	// Remote is the number of remote resources, Managed the ones of them
+	// defined locally, and Unmanaged the other ones
+	Remote    int `yaml:"remote,omitempty" json:"remote,omitempty"`
+	Managed   int `yaml:"managed,omitempty" json:"managed,omitempty"`
+	Unmanaged int `yaml:"unmanaged,omitempty" json:"unmanaged,omitempty"`
+}
+
+// FolderStats describes the local resources living in a folder
+type FolderStats struct {
+	Folder    string `yaml:"folder" json:"folder"`
+	Resources int    `yaml:"resources" json:"resources"`
+	Size      int    `yaml:"size" json:"size"`
+}
+
+// ResourceSize is the size of a local resource, in bytes of JSON
+type ResourceSize struct {
+	Resource string `yaml:"resource" json:"resource"`
+	Size     int    `yaml:"size" json:"size"`
+}
+
+// Stats is an inventory of local (and remote) resources
+type Stats struct {
+	Kinds   []KindStats    `yaml:"kinds" json:"kinds"`
+	Folders []FolderStats  `yaml:"folders" json:"folders"`
+	Largest []ResourceSize `yaml:"largest" json:"largest"`
+	// Remote tells whether remote resources were inventoried
+	Remote bool `yaml:"remote" json:"remote"`
+}
+
+// ComputeStats inventories resources per kind and folder, and lists the top
+// largest ones. With remote, the remote resources of the targeted kinds are
+// listed too, to tell the ones that are managed locally from the other ones.
+func ComputeStats(registry Registry, resources Resources, remote bool, targets []string, top int) (Stats, error) {
+	stats := Stats{Remote: remote}
+	kinds := map[string]*KindStats{}
+	folders := map[string]*FolderStats{}
+	kindStats := func(kind string) *KindStats {
+		if _, found := kinds[kind]; !found {
+			kinds[kind] = &KindStats{Kind: kind}
+		}
+		return kinds[kind]
+	}
+
+	for _, resource := range resources.AsList() {
+		content, err := json.Marshal(resource.Spec())
+		if err != nil {
+			return stats, fmt.Errorf("measuring %s: %w", resource.Ref(), err)
+		}
+		size := len(content)
+
+		kind := kindStats(resource.Kind())
+		kind.Local++
+		kind.Size += size
+
+		if folderUID := resource.GetMetadata("folder"); folderUID != "" {
+			if _, found := folders[folderUID]; !found {
+				folders[folderUID] = &FolderStats{Folder: folderUID}
+			}
+			folders[folderUID].Resources++
+			folders[folderUID].Size += size
+		}
+
+		stats.Largest = append(stats.Largest, ResourceSize{Resource: resource.Ref().String(), Size: size})
+	}
+
+	if remote {
+		for _, handler := range registry.HandlerOrder {
+			if !registry.HandlerMatchesTarget(handler, targets) {
+				continue
+			}
+			uids, err := handler.ListRemote()
+			if err != nil {
+				return stats, fmt.Errorf("listing remote %s resources: %w", handler.Kind(), err)
+			}
+
+			kind := kindStats(handler.Kind())
+			for _, uid := range uids {
+				if !registry.ResourceMatchesTarget(handler.Kind(), uid, targets) {
+					continue
+				}
+				kind.Remote++
+				if _, found := resources.Find(NewResourceRef(handler.Kind(), uid)); found {
+					kind.Managed++
+				} else {
+					kind.Unmanaged++
+				}
+			}
+		}
+	}
+
+	for _, kind := range kinds {
+		stats.Kinds = append(stats.Kinds, *kind)
+	}
+	sort.Slice(stats.Kinds, func(i, j int) bool {
+		return stats.Kinds[i].Kind < stats.Kinds[j].Kind
+	})
+
+	for _, folder := range folders {
+		stats.Folders = append(stats.Folders, *folder)
+	}
+	sort.Slice(stats.Folders, func(i, j int) bool {
+		return stats.Folders[i].Folder < stats.Folders[j].Folder
+	})
+
+	sort.SliceStable(stats.Largest, func(i, j int) bool {
+		return stats.Largest[i].Size > stats.Largest[j].Size
+	})
+	if len(stats.Largest) > top {
+		stats.Largest = stats.Largest[:top]
+	}
+
+	return stats, nil
+}
+
+// Format renders stats as tables (`default`), `json` or `yaml`
+func (stats Stats) Format(format string) ([]byte, error) {
+	switch format {
+	case formatJSON:
+		return json.MarshalIndent(stats, "", "  ")
+	case formatYAML:
+		return yaml.Marshal(stats)
+	case formatDefault:
+		return stats.tables()
+	}
+
+	return nil, fmt.Errorf("unknown format %s", format)
+}
+
+func (stats Stats) tables() ([]byte, error) {
+	var out bytes.Buffer
+	w := tabwriter.NewWriter(&out, 0, 0, 4, ' ', 0)
+
+	if stats.Remote {
+		fmt.Fprintf(w, "KIND\tLOCAL\tSIZE\tREMOTE\tMANAGED\tUNMANAGED\n")
+		for _, kind := range stats.Kinds {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%d\t%d\n", kind.Kind, kind.Local, formatSize(kind.Size), kind.Remote, kind.Managed, kind.Unmanaged)
+		}
+	} else {
+		fmt.Fprintf(w, "KIND\tLOCAL\tSIZE\n")
+		for _, kind := range stats.Kinds {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", kind.Kind, kind.Local, formatSize(kind.Size))
+		}
+	}
+
+	if len(stats.Folders) > 0 {
+		fmt.Fprintf(w, "\nFOLDER\tRESOURCES\tSIZE\n")
+		for _, folder := range stats.Folders {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", folder.Folder, folder.Resources, formatSize(folder.Size))
+		}
+	}
+
+	if len(stats.Largest) > 0 {
+		fmt.Fprintf(w, "\nLARGEST\tSIZE\n")
+		for _, resource := range stats.Largest {
+			fmt.Fprintf(w, "%s\t%s\n", resource.Resource, formatSize(resource.Size))
+		}
+	}
+
+	err := w.Flush()
+	return out.Bytes(), err
+}
+
+func formatSize(size int) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(size)/(1<<10))
+	}
+
+	return fmt.Sprintf("%d B", size)
+}
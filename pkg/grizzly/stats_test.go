@@ -0,0 +1,43 @@
+package grizzly_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeStats(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+
+	resource := func(kind string, uid string, folder string, spec map[string]any) grizzly.Resource {
+		spec["uid"] = uid
+		resource := grizzlytest.NewResource(t, kind, uid, spec)
+		if folder != "" {
+			resource.SetMetadata("folder", folder)
+		}
+		return resource
+	}
+	infra := resource("DashboardFolder", "infra", "", map[string]any{"title": "Infra"})
+	small := resource("Dashboard", "small", "infra", map[string]any{"title": "Small"})
+	large := resource("Dashboard", "large", "infra", map[string]any{"title": "Large", "panels": []any{map[string]any{"id": 1}}})
+	unmanaged := resource("Dashboard", "unmanaged", "general", map[string]any{"title": "Unmanaged"})
+
+	require.NoError(t, grizzly.Apply(registry, grizzly.NewResources(infra, small, unmanaged), false, grizzly.NewJUnitReport("apply")))
+
+	stats, err := grizzly.ComputeStats(registry, grizzly.NewResources(infra, small, large), true, []string{"Dashboard", "DashboardFolder"}, 1)
+	require.NoError(t, err)
+
+	require.Equal(t, []grizzly.KindStats{
+		{Kind: "Dashboard", Local: 2, Size: 82, Remote: 2, Managed: 1, Unmanaged: 1},
+		{Kind: "DashboardFolder", Local: 1, Size: 31, Remote: 1, Managed: 1},
+	}, stats.Kinds)
+	require.Equal(t, []grizzly.FolderStats{{Folder: "infra", Resources: 2, Size: 82}}, stats.Folders)
+	require.Equal(t, []grizzly.ResourceSize{{Resource: "Dashboard.large", Size: 51}}, stats.Largest)
+
+	output, err := stats.Format("default")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "Dashboard          2        82 B    2         1          1")
+}
@@ -0,0 +1,76 @@
+package grizzly
+
+import "fmt"
+
+// ApplyStrategyAnnotation is the annotation setting how a resource is
+// applied, e.g.
+//
+//	metadata:
+//	  name: overview
+//	  annotations:
+//	    grizzly.grafana.com/apply-strategy: create-only
+const ApplyStrategyAnnotation = "grizzly.grafana.com/apply-strategy"
+
+// ApplyStrategy tells how resources are applied
+type ApplyStrategy string
+
+const (
+	// ApplyCreateOrUpdate creates missing resources, and updates existing
+	// ones. This is the default.
+	ApplyCreateOrUpdate ApplyStrategy = "create-or-update"
+	// ApplyCreateOnly creates missing resources, but leaves existing ones as
+	// they are, e.g. for seeded dashboards users may edit
+	ApplyCreateOnly ApplyStrategy = "create-only"
+	// ApplyUpdateOnly updates existing resources, but never creates them
+	ApplyUpdateOnly ApplyStrategy = "update-only"
+	// ApplyRecreate deletes existing resources that changed before creating
+	// them again, for kinds whose APIs don't support updates
+	ApplyRecreate ApplyStrategy = "recreate"
+)
+
+var applyStrategies = []ApplyStrategy{ApplyCreateOrUpdate, ApplyCreateOnly, ApplyUpdateOnly, ApplyRecreate}
+
+// ApplyStrategyHandler describes a handler setting the default apply
+// strategy of its kind
+type ApplyStrategyHandler interface {
+	ApplyStrategy() ApplyStrategy
+}
+
+// DeleteHandler describes a handler able to delete remote resources
+type DeleteHandler interface {
+	Delete(resource Resource) error
+}
+
+// GetApplyStrategy returns the apply strategy of a resource: the one set by
+// its annotation, else the default one of its kind
+func GetApplyStrategy(handler Handler, resource Resource) (ApplyStrategy, error) {
+	strategy := ApplyCreateOrUpdate
+	if strategyHandler, ok := unwrapHandler(handler).(ApplyStrategyHandler); ok {
+		strategy = strategyHandler.ApplyStrategy()
+	}
+	if annotation := resource.GetAnnotation(ApplyStrategyAnnotation); annotation != "" {
+		strategy = ApplyStrategy(annotation)
+	}
+
+	for _, known := range applyStrategies {
+		if strategy == known {
+			return strategy, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown apply strategy %q: expected one of %s, %s, %s or %s", strategy, ApplyCreateOrUpdate, ApplyCreateOnly, ApplyUpdateOnly, ApplyRecreate)
+}
+
+// recreate replaces a remote resource by deleting it, then creating it again
+func recreate(handler Handler, existing Resource, resource Resource) error {
+	deleter, ok := unwrapHandler(handler).(DeleteHandler)
+	if !ok {
+		return fmt.Errorf("%s doesn't support deleting resources, required by the %s apply strategy", handler.Kind(), ApplyRecreate)
+	}
+
+	if err := deleter.Delete(existing); err != nil {
+		return fmt.Errorf("deleting %s to recreate it: %w", resource.Ref(), err)
+	}
+
+	return handler.Add(*handler.Prepare(nil, resource))
+}
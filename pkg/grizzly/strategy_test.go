@@ -0,0 +1,75 @@
+package grizzly_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyStrategies(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+
+	dashboard := func(name string, title string, strategy grizzly.ApplyStrategy) grizzly.Resource {
+		resource := grizzlytest.NewDashboard(t, name, title)
+		if strategy != "" {
+			resource.Body["metadata"].(map[string]any)["annotations"] = map[string]any{
+				grizzly.ApplyStrategyAnnotation: string(strategy),
+			}
+		}
+		return resource
+	}
+	apply := func(resources ...grizzly.Resource) error {
+		return grizzly.Apply(registry, grizzly.NewResources(resources...), false, grizzly.NewJUnitReport("apply"))
+	}
+	title := func(uid string) string {
+		remote, _, found := server.Dashboard(uid)
+		if !found {
+			return ""
+		}
+		return remote["title"].(string)
+	}
+
+	err := apply(dashboard("seeded", "Seeded", ""), dashboard("replaced", "Replaced", ""))
+	require.NoError(t, err)
+	server.EditDashboard("replaced", "jane", map[string]any{"title": "Replaced (edited)"})
+	version := func(uid string) int64 {
+		handler, err := registry.GetHandler("Dashboard")
+		require.NoError(t, err)
+		remote, err := handler.(grizzly.VersionedHandler).RemoteVersion(uid)
+		require.NoError(t, err)
+		return remote.Version
+	}
+	require.Equal(t, int64(2), version("replaced"))
+
+	t.Run("create-only resources are left as is", func(t *testing.T) {
+		err := apply(dashboard("seeded", "Seeded (v2)", grizzly.ApplyCreateOnly), dashboard("created", "Created", grizzly.ApplyCreateOnly))
+		require.NoError(t, err)
+
+		require.Equal(t, "Seeded", title("seeded"))
+		require.Equal(t, "Created", title("created"))
+	})
+
+	t.Run("update-only resources are not created", func(t *testing.T) {
+		err := apply(dashboard("seeded", "Seeded (v3)", grizzly.ApplyUpdateOnly), dashboard("missing", "Missing", grizzly.ApplyUpdateOnly))
+		require.NoError(t, err)
+
+		require.Equal(t, "Seeded (v3)", title("seeded"))
+		require.Equal(t, "", title("missing"))
+	})
+
+	t.Run("recreated resources are deleted first", func(t *testing.T) {
+		err := apply(dashboard("replaced", "Replaced (v2)", grizzly.ApplyRecreate))
+		require.NoError(t, err)
+
+		require.Equal(t, "Replaced (v2)", title("replaced"))
+		require.Equal(t, int64(1), version("replaced"))
+	})
+
+	t.Run("unknown strategies are rejected", func(t *testing.T) {
+		err := apply(dashboard("seeded", "Seeded", "sometimes"))
+		require.ErrorContains(t, err, `unknown apply strategy "sometimes"`)
+	})
+}
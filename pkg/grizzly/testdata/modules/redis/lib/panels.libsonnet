@@ -0,0 +1,7 @@
+{
+  availability(datasource, target):: {
+    type: 'stat',
+    title: 'Availability (target: %.1f%%)' % target,
+    datasource: { uid: datasource },
+  },
+}
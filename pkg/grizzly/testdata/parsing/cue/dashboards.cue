@@ -0,0 +1,31 @@
+package dashboards
+
+#Dashboard: {
+	apiVersion: "grizzly.grafana.com/v1alpha1"
+	kind:       "Dashboard"
+	metadata: {
+		name:   string & =~"^[a-z0-9-]+$"
+		folder: string | *"general"
+	}
+	spec: {
+		uid:     metadata.name
+		title:   string
+		refresh: *"1m" | "5m" | "15m"
+	}
+}
+
+nodes: #Dashboard & {
+	metadata: name: "nodes"
+	spec: title:    "Nodes"
+}
+
+disks: #Dashboard & {
+	metadata: {
+		name:   "disks"
+		folder: "infra"
+	}
+	spec: {
+		title:   "Disks"
+		refresh: "5m"
+	}
+}
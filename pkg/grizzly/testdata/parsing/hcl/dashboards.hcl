@@ -0,0 +1,21 @@
+folder "infra" {
+  title = "Infrastructure"
+}
+
+dashboard "nodes" {
+  metadata {
+    folder = "infra"
+  }
+
+  title = "Nodes"
+  tags  = ["infra", lower("NODES")]
+  panels = [
+    { id = 1, type = "timeseries", title = "CPU" },
+  ]
+}
+
+grafana_dashboard "disks" {
+  metadata = { folder = "infra" }
+
+  config_json = file("disks.json")
+}
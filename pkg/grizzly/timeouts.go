@@ -0,0 +1,134 @@
+package grizzly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrResourceTimeout signals a resource which took longer to process than
+	// allowed by the resource timeout
+	ErrResourceTimeout = errors.New("resource timeout exceeded")
+
+	// ErrRunTimeout signals that the deadline of the whole run was exceeded
+	ErrRunTimeout = errors.New("run timeout exceeded")
+)
+
+func init() {
+	// requests are bound to the timeouts of the context they are sent with
+	AddHTTPTransportDecorator(newTimeoutTransport)
+}
+
+// timeouts bound the time spent reaching remote endpoints, within the
+// context they are set on
+type timeouts struct {
+	request  time.Duration
+	resource time.Duration
+}
+
+type timeoutsKey struct{}
+
+// WithTimeouts returns a context bounding the duration of each HTTP request
+// sent by providers, of the processing of each resource, and of the whole
+// run, starting now. Zero durations don't bound anything. The requests of a
+// registry derived with this context (see Registry.WithContext) are bound to
+// these timeouts. Cancelling the context releases the resources associated
+// with the run deadline.
+func WithTimeouts(parent context.Context, request time.Duration, resource time.Duration, run time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := parent, context.CancelFunc(func() {})
+	if run > 0 {
+		ctx, cancel = context.WithTimeoutCause(parent, run, ErrRunTimeout)
+	}
+
+	return context.WithValue(ctx, timeoutsKey{}, timeouts{request: request, resource: resource}), cancel
+}
+
+func timeoutsFromContext(ctx context.Context) timeouts {
+	t, _ := ctx.Value(timeoutsKey{}).(timeouts)
+	return t
+}
+
+// runExceeded tells whether the context of a run is done, e.g. once its
+// deadline is exceeded
+func runExceeded(registry Registry) bool {
+	return registry.Context().Err() != nil
+}
+
+// withinTimeouts processes a resource within the resource timeout and the
+// run deadline of the context of the registry. The resource is processed
+// with a registry derived from the context of the resource, so that its
+// requests are cancelled once they are exceeded, which makes the handlers
+// return.
+func withinTimeouts(registry Registry, process func(registry Registry) error) error {
+	return withinResourceTimeout(registry, 0, process)
+}
+
+// withinResourceTimeout is withinTimeouts, with a resource timeout
+// overriding the one of the run when positive
+func withinResourceTimeout(registry Registry, timeout time.Duration, process func(registry Registry) error) error {
+	run := registry.Context()
+	if run.Err() != nil {
+		return context.Cause(run)
+	}
+	if timeout <= 0 {
+		timeout = timeoutsFromContext(run).resource
+	}
+	ctx, cancel := run, context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(run, timeout, fmt.Errorf("%w (%s)", ErrResourceTimeout, timeout))
+	}
+	defer cancel()
+
+	err := process(registry.WithContext(ctx))
+	if err != nil && ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+
+	return err
+}
+
+func newTimeoutTransport(next http.RoundTripper) http.RoundTripper {
+	return &timeoutTransport{next: next}
+}
+
+// timeoutTransport bounds requests with the request timeout of their
+// context, and tells why they were cancelled
+type timeoutTransport struct {
+	next http.RoundTripper
+}
+
+func (transport *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, release := req.Context(), context.CancelFunc(func() {})
+	if request := timeoutsFromContext(ctx).request; request > 0 {
+		ctx, release = context.WithTimeoutCause(ctx, request, fmt.Errorf("request timeout exceeded (%s)", request))
+	}
+
+	resp, err := transport.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		if cause := context.Cause(ctx); cause != nil && cause != context.Canceled {
+			err = fmt.Errorf("%w: %w", cause, err)
+		}
+		release()
+		return nil, err
+	}
+
+	// the context must live as long as the body is being read
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (body *releasingBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.release()
+	return err
+}
@@ -0,0 +1,69 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// hangs until the request is cancelled, or the test completes
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	resources := grizzly.NewResources(grizzlytest.NewFolder(t, "first", "first"), grizzlytest.NewFolder(t, "second", "second"), grizzlytest.NewFolder(t, "third", "third"))
+
+	apply := func(request time.Duration, resource time.Duration, run time.Duration, opts ...grizzly.ApplyOpt) (string, error) {
+		ctx, cancel := grizzly.WithTimeouts(context.Background(), request, resource, run)
+		t.Cleanup(cancel)
+
+		registry := grizzly.NewRegistry([]grizzly.Provider{grafana.NewProvider(&config.GrafanaConfig{URL: server.URL})})
+		registry = registry.WithContext(ctx)
+
+		out := &bytes.Buffer{}
+		err := grizzly.Apply(registry, resources, true, grizzly.NewWriterRecorder(out, grizzly.EventToPorcelain), opts...)
+		return out.String(), err
+	}
+
+	t.Run("hung requests fail their resource", func(t *testing.T) {
+		out, err := apply(0, 50*time.Millisecond, 0)
+		require.ErrorIs(t, err, grizzly.ErrResourceTimeout)
+		require.Contains(t, out, "DashboardFolder.first\tresource-failure\tresource timeout exceeded (50ms)")
+		require.Contains(t, out, "DashboardFolder.third\tresource-failure\tresource timeout exceeded (50ms)")
+	})
+
+	t.Run("timeouts of kinds override the resource timeout", func(t *testing.T) {
+		out, err := apply(0, time.Minute, 0, grizzly.ApplyKindTimeouts(map[string]time.Duration{"DashboardFolder": 50 * time.Millisecond}))
+		require.ErrorIs(t, err, grizzly.ErrResourceTimeout)
+		require.Contains(t, out, "DashboardFolder.first\tresource-failure\tresource timeout exceeded (50ms)")
+	})
+
+	t.Run("resources are skipped once the run deadline is exceeded", func(t *testing.T) {
+		out, err := apply(0, 0, 50*time.Millisecond)
+		require.ErrorIs(t, err, grizzly.ErrRunTimeout)
+		require.Contains(t, out, "DashboardFolder.first\tresource-failure\trun timeout exceeded")
+		require.Contains(t, out, "DashboardFolder.second\tresource-skipped\trun timeout exceeded")
+		require.Contains(t, out, "DashboardFolder.third\tresource-skipped\trun timeout exceeded")
+	})
+
+	t.Run("requests time out", func(t *testing.T) {
+		_, err := apply(50*time.Millisecond, 0, 0)
+		require.ErrorContains(t, err, "request timeout exceeded (50ms)")
+	})
+}
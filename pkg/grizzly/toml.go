@@ -0,0 +1,79 @@
+package grizzly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pelletier/go-toml/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+const formatTOML = "toml"
+
+// TOMLParser parses resources written in TOML, which suits flat resources
+// such as datasources, folders or contact points. A file holds either a
+// single resource, or several as a `[[resources]]` array of tables.
+type TOMLParser struct {
+	registry Registry
+	logger   *log.Entry
+}
+
+func NewTOMLParser(registry Registry) *TOMLParser {
+	return &TOMLParser{
+		registry: registry,
+		logger:   log.WithField("parser", "toml"),
+	}
+}
+
+func (parser *TOMLParser) Accept(file string) bool {
+	return filepath.Ext(file) == ".toml"
+}
+
+// Parse decodes a TOML file and parses it into resources
+func (parser *TOMLParser) Parse(file string, options ParserOptions) (Resources, error) {
+	parser.logger.WithField("file", file).Debug("Parsing file")
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return Resources{}, err
+	}
+
+	document := map[string]any{}
+	if err := toml.Unmarshal(content, &document); err != nil {
+		return Resources{}, tomlError(file, err)
+	}
+	// dates and integers are normalized to the types the other formats
+	// decode into
+	normalized, err := json.Marshal(document)
+	if err != nil {
+		return Resources{}, err
+	}
+	var data any
+	if err := json.Unmarshal(normalized, &data); err != nil {
+		return Resources{}, err
+	}
+	if list, ok := data.(map[string]any)["resources"].([]any); ok && len(data.(map[string]any)) == 1 {
+		data = list
+	}
+
+	source := Source{
+		Format:     formatTOML,
+		Path:       file,
+		Rewritable: false,
+	}
+
+	return parseAny(parser.registry, data, options.DefaultResourceKind, options.DefaultFolderUID, source)
+}
+
+// tomlError locates decoding errors in the file
+func tomlError(file string, err error) error {
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		row, column := decodeErr.Position()
+		return fmt.Errorf("%s:%d:%d: %w", file, row, column, err)
+	}
+	return fmt.Errorf("%s: %w", file, err)
+}
@@ -1,9 +1,14 @@
 package grizzly
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // Pluraliser returns a string describing the count of items, with a plural 's'
@@ -16,7 +21,58 @@ func Pluraliser(count int, name string) string {
 	return fmt.Sprintf("%d %ss", count, name)
 }
 
+// sizeSuffixes are the units of the sizes parsed by ParseSize, longest first
+var sizeSuffixes = []struct {
+	suffix string
+	bytes  int
+}{
+	{"MB", 1024 * 1024},
+	{"KB", 1024},
+	{"B", 1},
+}
+
+// ParseSize parses a size in bytes, or with a unit: `500KB`, `1MB`
+func ParseSize(value string) (int, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := 1
+	for _, unit := range sizeSuffixes {
+		if strings.HasSuffix(normalized, unit.suffix) {
+			normalized = strings.TrimSpace(strings.TrimSuffix(normalized, unit.suffix))
+			multiplier = unit.bytes
+			break
+		}
+	}
+
+	size, err := strconv.ParseFloat(normalized, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("%q is not a size, expected e.g. 500KB or 1MB", value)
+	}
+	return int(size * float64(multiplier)), nil
+}
+
 func SendError(w http.ResponseWriter, msg string, err error, code int) {
 	http.Error(w, msg, code)
 	log.Printf("%d - %s: %s", code, msg, err.Error())
 }
+
+// writeFileAtomic writes a file through a temporary file renamed once
+// written, so that concurrent readers and writers never see partial content
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	err = errors.Join(err, file.Chmod(perm), file.Close())
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
@@ -0,0 +1,368 @@
+package grizzly
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// JsonnetFile declares the Jsonnet dependencies of a project, as read by
+	// jsonnet-bundler (jb)
+	JsonnetFile = "jsonnetfile.json"
+	// JsonnetLockFile records the exact versions of the vendored dependencies
+	JsonnetLockFile = "jsonnetfile.lock.json"
+	// JsonnetVendorDir is where dependencies are vendored
+	JsonnetVendorDir = "vendor"
+)
+
+// JsonnetManifest is the content of a jsonnetfile.json, or of its lock file
+type JsonnetManifest struct {
+	Version       int                 `json:"version"`
+	Dependencies  []JsonnetDependency `json:"dependencies"`
+	LegacyImports bool                `json:"legacyImports"`
+}
+
+// UnmarshalJSON defaults legacyImports to true, like jsonnet-bundler does
+func (manifest *JsonnetManifest) UnmarshalJSON(data []byte) error {
+	type plain JsonnetManifest
+	parsed := plain{LegacyImports: true}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+
+	*manifest = JsonnetManifest(parsed)
+	return nil
+}
+
+// JsonnetDependency is a Git repository, or a local directory, vendored for
+// Jsonnet imports. Version is a branch, tag or commit of Git repositories.
+type JsonnetDependency struct {
+	Source struct {
+		Git *struct {
+			Remote string `json:"remote"`
+			Subdir string `json:"subdir"`
+		} `json:"git,omitempty"`
+		Local *struct {
+			Directory string `json:"directory"`
+		} `json:"local,omitempty"`
+	} `json:"source"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
+// Path returns where a Git dependency is vendored within the vendor
+// directory, e.g. `github.com/grafana/grafonnet/gen/grafonnet-latest`
+func (dependency JsonnetDependency) Path() string {
+	if dependency.Source.Local != nil {
+		return filepath.Base(dependency.Source.Local.Directory)
+	}
+	if dependency.Source.Git == nil {
+		return ""
+	}
+
+	remote := strings.TrimSuffix(dependency.Source.Git.Remote, ".git")
+	if strings.HasPrefix(remote, "git@") {
+		// git@github.com:org/repository
+		remote = strings.Replace(strings.TrimPrefix(remote, "git@"), ":", "/", 1)
+	} else if parsed, err := url.Parse(remote); err == nil {
+		remote = parsed.Host + parsed.Path
+	}
+
+	return strings.Trim(path.Join(remote, dependency.Source.Git.Subdir), "/")
+}
+
+// LegacyName is the name of the link to the dependency at the root of the
+// vendor directory, allowing imports such as `grafonnet-latest/main.libsonnet`
+func (dependency JsonnetDependency) LegacyName() string {
+	if dependency.Name != "" {
+		return dependency.Name
+	}
+	return path.Base(dependency.Path())
+}
+
+// FindJsonnetRoot returns the closest directory, from dir up, holding a
+// jsonnetfile.json
+func FindJsonnetRoot(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, JsonnetFile)); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// ReadJsonnetManifest reads a jsonnetfile.json or jsonnetfile.lock.json
+func ReadJsonnetManifest(file string) (JsonnetManifest, error) {
+	manifest := JsonnetManifest{LegacyImports: true}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return manifest, fmt.Errorf("parsing %s: %w", file, err)
+	}
+
+	return manifest, nil
+}
+
+// Vendor installs the dependencies declared in the jsonnetfile.json of dir,
+// and theirs, into its vendor directory, without requiring jsonnet-bundler.
+// Git dependencies are installed at the versions recorded in the lock file,
+// unless update is true, and the lock file is updated with the versions
+// installed. Installing a locked version whose content doesn't match the
+// checksum of the lock file fails.
+func Vendor(dir string, update bool) ([]JsonnetDependency, error) {
+	manifest, err := ReadJsonnetManifest(filepath.Join(dir, JsonnetFile))
+	if err != nil {
+		return nil, err
+	}
+
+	locked := map[string]JsonnetDependency{}
+	if !update {
+		lock, err := ReadJsonnetManifest(filepath.Join(dir, JsonnetLockFile))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for _, dependency := range lock.Dependencies {
+			locked[dependency.Path()] = dependency
+		}
+	}
+
+	vendorDir := filepath.Join(dir, JsonnetVendorDir)
+	if err := os.MkdirAll(vendorDir, 0755); err != nil {
+		return nil, err
+	}
+
+	type pending struct {
+		dependency JsonnetDependency
+		// dir is the directory of the jsonnetfile.json declaring the
+		// dependency, which local directories are relative to
+		dir string
+	}
+	queue := make([]pending, 0, len(manifest.Dependencies))
+	for _, dependency := range manifest.Dependencies {
+		queue = append(queue, pending{dependency: dependency, dir: dir})
+	}
+
+	var installed []JsonnetDependency
+	seen := map[string]bool{}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		dependency := next.dependency
+		key := dependency.Path()
+		if key == "" {
+			return installed, fmt.Errorf("dependency %d of %s has neither a git nor a local source", len(installed), JsonnetFile)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		var installedDir string
+		switch {
+		case dependency.Source.Local != nil:
+			installedDir = filepath.Join(next.dir, dependency.Source.Local.Directory)
+			if err := linkVendored(vendorDir, dependency.LegacyName(), installedDir); err != nil {
+				return installed, err
+			}
+		default:
+			if lock, ok := locked[key]; ok {
+				dependency.Version = lock.Version
+			}
+			installedDir = filepath.Join(vendorDir, filepath.FromSlash(key))
+			version, err := vendorGit(dependency, installedDir)
+			if err != nil {
+				return installed, err
+			}
+			dependency.Version = version
+			dependency.Sum = hashDir(installedDir)
+			if lock, ok := locked[key]; ok && lock.Sum != "" && lock.Sum != dependency.Sum {
+				return installed, fmt.Errorf("%s@%s doesn't match the checksum of %s: expected %s, got %s", key, version, JsonnetLockFile, lock.Sum, dependency.Sum)
+			}
+			if manifest.LegacyImports {
+				if err := linkVendored(vendorDir, dependency.LegacyName(), installedDir); err != nil {
+					return installed, err
+				}
+			}
+		}
+		installed = append(installed, dependency)
+
+		nested, err := ReadJsonnetManifest(filepath.Join(installedDir, JsonnetFile))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return installed, err
+		}
+		for _, dependency := range nested.Dependencies {
+			queue = append(queue, pending{dependency: dependency, dir: installedDir})
+		}
+	}
+
+	lock := JsonnetManifest{Version: 1, Dependencies: installed, LegacyImports: manifest.LegacyImports}
+	content, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return installed, err
+	}
+	return installed, os.WriteFile(filepath.Join(dir, JsonnetLockFile), append(content, '\n'), 0644)
+}
+
+// vendorGit fetches a Git dependency and copies it to target, returning the
+// commit it was fetched at
+func vendorGit(dependency JsonnetDependency, target string) (string, error) {
+	clone, err := os.MkdirTemp("", "grizzly-vendor-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(clone)
+
+	ref := dependency.Version
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	git := func(args ...string) (string, error) {
+		output, err := exec.Command("git", append([]string{"-C", clone}, args...)...).CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("vendoring %s@%s: %w: %s", dependency.Path(), ref, err, strings.TrimSpace(string(output)))
+		}
+		return strings.TrimSpace(string(output)), nil
+	}
+
+	log.Debugf("Fetching %s@%s", dependency.Source.Git.Remote, ref)
+	if _, err := git("init", "--quiet"); err != nil {
+		return "", err
+	}
+	if _, err := git("fetch", "--quiet", "--depth", "1", dependency.Source.Git.Remote, ref); err != nil {
+		return "", err
+	}
+	if _, err := git("checkout", "--quiet", "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+	commit, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	source := filepath.Join(clone, filepath.FromSlash(dependency.Source.Git.Subdir))
+	if _, err := os.Stat(source); err != nil {
+		return "", fmt.Errorf("vendoring %s@%s: %w", dependency.Path(), ref, err)
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return "", err
+	}
+
+	return commit, copyDir(source, target)
+}
+
+// linkVendored links the root of the vendor directory to a dependency
+func linkVendored(vendorDir, name, target string) error {
+	link := filepath.Join(vendorDir, name)
+	relative, err := filepath.Rel(vendorDir, target)
+	if err != nil {
+		return err
+	}
+	if relative == name {
+		// the dependency is vendored at the root already
+		return nil
+	}
+
+	if err := os.RemoveAll(link); err != nil {
+		return err
+	}
+	return os.Symlink(relative, link)
+}
+
+func copyDir(source, target string) error {
+	return filepath.Walk(source, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(source, file)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		destination := filepath.Join(target, relative)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(destination, 0755)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, destination)
+		default:
+			in, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			out, err := os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, in); err != nil {
+				out.Close()
+				return err
+			}
+			return out.Close()
+		}
+	})
+}
+
+// hashDir computes the checksum of a vendored dependency recorded in the
+// lock file, over the paths and contents of its files
+func hashDir(dir string) string {
+	hasher := sha256.New()
+	_ = filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relative, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		if _, err := hasher.Write([]byte(filepath.ToSlash(relative))); err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		content, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+		_, err = io.Copy(hasher, content)
+		return err
+	})
+
+	return base64.StdEncoding.EncodeToString(hasher.Sum(nil))
+}
@@ -0,0 +1,93 @@
+package grizzly_test
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVendor(t *testing.T) {
+	repository := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-C", repository, "-c", "user.name=grizzly", "-c", "user.email=grizzly@example.com"}, args...)
+		output, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	commit := func(title string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Join(repository, "lib"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(repository, "lib", "dashboard.libsonnet"), []byte(`{ title: '`+title+`' }`), 0644))
+		git("add", "-A")
+		git("commit", "--quiet", "-m", title)
+	}
+	git("init", "--quiet", "--initial-branch", "main")
+	commit("Version 1")
+
+	project := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(project, "shared"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(project, "shared", "tags.libsonnet"), []byte(`['vendored']`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(project, grizzly.JsonnetFile), []byte(`{
+  "version": 1,
+  "dependencies": [
+    {"source": {"git": {"remote": "file://`+filepath.ToSlash(repository)+`", "subdir": "lib"}}, "version": "main", "name": "dashboards"},
+    {"source": {"local": {"directory": "shared"}}}
+  ]
+}`), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(project, "resources"), 0755))
+	main := filepath.Join(project, "resources", "dashboard.jsonnet")
+	require.NoError(t, os.WriteFile(main, []byte(`{
+  apiVersion: 'grizzly.grafana.com/v1alpha1',
+  kind: 'Dashboard',
+  metadata: { name: 'vendored', folder: 'general' },
+  spec: (import 'dashboards/dashboard.libsonnet') + { tags: import 'shared/tags.libsonnet' },
+}`), 0644))
+
+	registry := grizzly.NewRegistry([]grizzly.Provider{&grafana.Provider{}})
+	title := func() string {
+		t.Helper()
+		resources, err := grizzly.DefaultParser(registry, nil, nil).Parse(main, grizzly.ParserOptions{})
+		require.NoError(t, err)
+		resource, found := resources.Find(grizzly.NewResourceRef("Dashboard", "vendored"))
+		require.True(t, found)
+		require.Equal(t, []any{"vendored"}, resource.GetSpecValue("tags"))
+		return resource.GetSpecValue("title").(string)
+	}
+
+	dependencies, err := grizzly.Vendor(project, false)
+	require.NoError(t, err)
+	require.Len(t, dependencies, 2)
+	require.Len(t, dependencies[0].Version, 40, "the commit of git dependencies should be locked")
+	require.FileExists(t, filepath.Join(project, grizzly.JsonnetLockFile))
+	require.Equal(t, "Version 1", title())
+
+	commit("Version 2")
+
+	_, err = grizzly.Vendor(project, false)
+	require.NoError(t, err)
+	require.Equal(t, "Version 1", title(), "the locked version should be installed")
+
+	_, err = grizzly.Vendor(project, true)
+	require.NoError(t, err)
+	require.Equal(t, "Version 2", title())
+
+	lock, err := grizzly.ReadJsonnetManifest(filepath.Join(project, grizzly.JsonnetLockFile))
+	require.NoError(t, err)
+	require.NotEqual(t, dependencies[0].Version, lock.Dependencies[0].Version)
+	require.NotEmpty(t, lock.Dependencies[0].Sum)
+
+	// a tampered dependency doesn't match its checksum anymore
+	lock.Dependencies[0].Sum = "tampered"
+	content, err := json.Marshal(lock)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(project, grizzly.JsonnetLockFile), content, 0644))
+
+	_, err = grizzly.Vendor(project, false)
+	require.ErrorContains(t, err, "doesn't match the checksum of "+grizzly.JsonnetLockFile)
+}
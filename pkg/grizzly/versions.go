@@ -0,0 +1,255 @@
+package grizzly
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AnyVersion is given to UpdateVersion to update a remote resource whatever
+// its version
+const AnyVersion int64 = -1
+
+// ErrConflict is returned when a remote resource was modified since it was
+// last applied or pulled
+var ErrConflict = errors.New("remote resource was modified since it was last applied or pulled")
+
+// ResourceVersion describes the version of a remote resource
+type ResourceVersion struct {
+	Version   int64
+	UpdatedBy string
+	UpdatedAt time.Time
+}
+
+// VersionedHandler is implemented by handlers whose remote resources are
+// versioned, so that they are only updated if they weren't modified since
+// they were last applied or pulled
+type VersionedHandler interface {
+	// RemoteVersion returns the current version of a remote resource, and
+	// who last modified it
+	RemoteVersion(uid string) (ResourceVersion, error)
+
+	// Version returns the version of a resource retrieved from the remote
+	// endpoint
+	Version(resource Resource) (int64, error)
+
+	// AddVersion adds a remote resource, and returns its version
+	AddVersion(resource Resource) (int64, error)
+
+	// UpdateVersion updates a remote resource, provided that it is still at
+	// the given version, unless AnyVersion is given, and returns its new
+	// version. ErrConflict is returned otherwise.
+	UpdateVersion(existing, resource Resource, version int64) (int64, error)
+}
+
+// ConflictError describes a remote resource modified since the version it
+// was last applied or pulled at
+type ConflictError struct {
+	Resource ResourceRef
+	Expected int64
+	Remote   ResourceVersion
+}
+
+func (err ConflictError) Error() string {
+	modified := fmt.Sprintf("version %d", err.Remote.Version)
+	if err.Remote.UpdatedBy != "" {
+		modified += " by " + err.Remote.UpdatedBy
+	}
+	if !err.Remote.UpdatedAt.IsZero() {
+		modified += " at " + err.Remote.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+
+	return fmt.Sprintf("%s: %s was modified remotely (%s, expected version %d): pull it, or use --force to overwrite it", ErrConflict, err.Resource, modified, err.Expected)
+}
+
+func (err ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// VersionLock records, per context, the versions of the remote resources last
+// applied or pulled. The file is meant to be committed along with resources.
+type VersionLock struct {
+	lock     sync.Mutex
+	path     string
+	context  string
+	versions map[string]map[string]map[string]int64
+	dirty    bool
+}
+
+// LoadVersionLock reads the versions recorded for a context. A missing file
+// results in an empty lock.
+func LoadVersionLock(path string, context string) (*VersionLock, error) {
+	versionLock := &VersionLock{
+		path:     path,
+		context:  context,
+		versions: map[string]map[string]map[string]int64{},
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return versionLock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(content, &versionLock.versions); err != nil {
+		return nil, ParseError{File: path, Err: err}
+	}
+	if versionLock.versions == nil {
+		versionLock.versions = map[string]map[string]map[string]int64{}
+	}
+
+	return versionLock, nil
+}
+
+// Get returns the recorded version of a resource
+func (l *VersionLock) Get(kind string, uid string) (int64, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	version, ok := l.versions[l.context][kind][uid]
+	return version, ok
+}
+
+// Set records the version of a resource
+func (l *VersionLock) Set(kind string, uid string, version int64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if current, ok := l.versions[l.context][kind][uid]; ok && current == version {
+		return
+	}
+	if l.versions[l.context] == nil {
+		l.versions[l.context] = map[string]map[string]int64{}
+	}
+	if l.versions[l.context][kind] == nil {
+		l.versions[l.context][kind] = map[string]int64{}
+	}
+	l.versions[l.context][kind][uid] = version
+	l.dirty = true
+}
+
+// Save writes the lock back to disk, if it was modified
+func (l *VersionLock) Save() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if !l.dirty {
+		return nil
+	}
+
+	content, err := yaml.Marshal(l.versions)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(l.path, content, 0644); err != nil {
+		return err
+	}
+	l.dirty = false
+
+	return nil
+}
+
+// lockingHandler refuses to update versioned resources modified remotely
+// since they were last applied or pulled, unless forced, and records the
+// versions of the resources it applies or retrieves
+type lockingHandler struct {
+	Handler
+	versioned VersionedHandler
+	lock      *VersionLock
+	force     bool
+}
+
+func (h *lockingHandler) GetByUID(uid string) (*Resource, error) {
+	resource, err := h.Handler.GetByUID(uid)
+	if err != nil {
+		return resource, err
+	}
+
+	version, err := h.versioned.Version(*resource)
+	if err != nil {
+		return resource, fmt.Errorf("could not record the version of %s: %w", resource.Ref(), err)
+	}
+	h.lock.Set(h.Kind(), uid, version)
+
+	return resource, nil
+}
+
+func (h *lockingHandler) Add(resource Resource) error {
+	// the version is the one returned by the remote endpoint, rather than
+	// retrieved again
+	version, err := h.versioned.AddVersion(resource)
+	if err != nil {
+		return err
+	}
+	h.lock.Set(h.Kind(), resource.Name(), version)
+
+	return nil
+}
+
+func (h *lockingHandler) Update(existing, resource Resource) error {
+	expected, ok := h.lock.Get(h.Kind(), resource.Name())
+	if h.force || !ok {
+		expected = AnyVersion
+	}
+
+	// the remote endpoint checks the version: the current one is only
+	// retrieved to report who modified the resource
+	version, err := h.versioned.UpdateVersion(existing, resource, expected)
+	if errors.Is(err, ErrConflict) {
+		if remote, remoteErr := h.versioned.RemoteVersion(resource.Name()); remoteErr == nil {
+			return ConflictError{Resource: resource.Ref(), Expected: expected, Remote: remote}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	h.lock.Set(h.Kind(), resource.Name(), version)
+
+	return nil
+}
+
+// WithVersionLock returns a registry whose versioned handlers refuse to
+// update resources modified remotely since the version recorded in the lock,
+// unless forced. The versions of the resources applied or retrieved by these
+// handlers are recorded in the lock.
+func (r *Registry) WithVersionLock(lock *VersionLock, force bool) Registry {
+	registry := Registry{
+		Providers:    r.Providers,
+		Handlers:     make(map[string]Handler, len(r.Handlers)),
+		HandlerOrder: make([]Handler, 0, len(r.HandlerOrder)),
+		ctx:          r.ctx,
+	}
+
+	for _, handler := range r.HandlerOrder {
+		if versioned, ok := unwrapHandler(handler).(VersionedHandler); ok {
+			handler = &lockingHandler{Handler: handler, versioned: versioned, lock: lock, force: force}
+		}
+		registry.Handlers[handler.Kind()] = handler
+		registry.HandlerOrder = append(registry.HandlerOrder, handler)
+	}
+
+	return registry
+}
+
+// unwrapHandler returns the handler decorated by the registry, e.g. for
+// caching
+func unwrapHandler(handler Handler) Handler {
+	for {
+		switch decorator := handler.(type) {
+		case *cachingHandler:
+			handler = decorator.Handler
+		case *lockingHandler:
+			handler = decorator.Handler
+		case *managedHandler:
+			handler = decorator.Handler
+		default:
+			return handler
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package grizzly_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionLock(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+	lockPath := filepath.Join(t.TempDir(), ".grizzly-versions.yaml")
+
+	apply := func(title string, force bool) error {
+		lock, err := grizzly.LoadVersionLock(lockPath, "production")
+		require.NoError(t, err)
+		locked := registry.WithVersionLock(lock, force)
+
+		dashboard := grizzlytest.NewResource(t, "Dashboard", "nodes", map[string]any{
+			"uid":   "nodes",
+			"title": title,
+		})
+		applyErr := grizzly.Apply(locked, grizzly.NewResources(dashboard), false, grizzly.NewJUnitReport("apply"))
+		require.NoError(t, lock.Save())
+
+		return applyErr
+	}
+	recorded := func(context string) (int64, bool) {
+		lock, err := grizzly.LoadVersionLock(lockPath, context)
+		require.NoError(t, err)
+		return lock.Get("Dashboard", "nodes")
+	}
+
+	require.NoError(t, apply("Nodes", false))
+	version, ok := recorded("production")
+	require.True(t, ok)
+	require.Equal(t, int64(1), version)
+
+	_, ok = recorded("staging")
+	require.False(t, ok)
+
+	require.NoError(t, apply("Nodes (v2)", false))
+	version, _ = recorded("production")
+	require.Equal(t, int64(2), version)
+
+	t.Run("remote changes are not overwritten", func(t *testing.T) {
+		server.EditDashboard("nodes", "jane", map[string]any{"title": "Nodes (edited)"})
+
+		err := apply("Nodes (v3)", false)
+		require.ErrorIs(t, err, grizzly.ErrConflict)
+		require.ErrorContains(t, err, "Dashboard.nodes was modified remotely (version 3 by jane at ")
+		require.ErrorContains(t, err, "expected version 2")
+
+		dashboard, _, _ := server.Dashboard("nodes")
+		require.Equal(t, "Nodes (edited)", dashboard["title"])
+	})
+
+	t.Run("remote changes are overwritten when forced", func(t *testing.T) {
+		require.NoError(t, apply("Nodes (v3)", true))
+
+		dashboard, _, _ := server.Dashboard("nodes")
+		require.Equal(t, "Nodes (v3)", dashboard["title"])
+		version, _ := recorded("production")
+		require.Equal(t, int64(4), version)
+	})
+
+	t.Run("Grafana refuses stale versions", func(t *testing.T) {
+		handler, err := registry.GetHandler("Dashboard")
+		require.NoError(t, err)
+		versioned := handler.(grizzly.VersionedHandler)
+
+		dashboard := grizzlytest.NewResource(t, "Dashboard", "nodes", map[string]any{
+			"uid":   "nodes",
+			"title": "Nodes (stale)",
+		})
+		dashboard = *handler.Prepare(nil, dashboard)
+		_, err = versioned.UpdateVersion(dashboard, dashboard, 2)
+		require.ErrorIs(t, err, grizzly.ErrConflict)
+		version, err := versioned.UpdateVersion(dashboard, dashboard, 4)
+		require.NoError(t, err)
+		require.Equal(t, int64(5), version)
+	})
+}
@@ -0,0 +1,159 @@
+package grizzly
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrVisualRegression is returned when rendered images differ from their
+// baseline
+var ErrVisualRegression = errors.New("rendered images differ from their baseline")
+
+// pixelTolerance is the difference of color channels (out of 0xffff) below
+// which pixels are considered identical, so that anti-aliasing changes
+// aren't reported
+const pixelTolerance = 0x0800
+
+// Baseline describes the committed images rendered images are compared to.
+type Baseline struct {
+	Dir string
+	// Threshold is the ratio of pixels (between 0 and 1) that may differ
+	// before an image is considered changed
+	Threshold float64
+	// Update writes the rendered images as baseline instead of comparing them
+	Update bool
+}
+
+// ImageDiff is the outcome of the comparison of two images
+type ImageDiff struct {
+	// Ratio is the ratio of pixels that differ, 1 when sizes differ
+	Ratio float64
+	// Image highlights the pixels that differ in red
+	Image image.Image
+}
+
+// CompareImages compares two PNG images pixel by pixel
+func CompareImages(expected, actual []byte) (ImageDiff, error) {
+	expectedImage, err := png.Decode(bytes.NewReader(expected))
+	if err != nil {
+		return ImageDiff{}, fmt.Errorf("decoding baseline: %w", err)
+	}
+	actualImage, err := png.Decode(bytes.NewReader(actual))
+	if err != nil {
+		return ImageDiff{}, fmt.Errorf("decoding rendered image: %w", err)
+	}
+
+	bounds := actualImage.Bounds()
+	if expectedImage.Bounds().Size() != bounds.Size() {
+		return ImageDiff{Ratio: 1, Image: actualImage}, nil
+	}
+
+	// changed pixels are drawn in red over a faded copy of the image
+	diff := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(diff, diff.Bounds(), actualImage, bounds.Min, draw.Src)
+	draw.Draw(diff, diff.Bounds(), image.NewUniform(color.RGBA{R: 255, G: 255, B: 255, A: 192}), image.Point{}, draw.Over)
+
+	changed := 0
+	offset := expectedImage.Bounds().Min.Sub(bounds.Min)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if samePixel(expectedImage.At(x+offset.X, y+offset.Y), actualImage.At(x, y)) {
+				continue
+			}
+			changed++
+			diff.Set(x-bounds.Min.X, y-bounds.Min.Y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	total := bounds.Dx() * bounds.Dy()
+	if total == 0 {
+		return ImageDiff{Image: diff}, nil
+	}
+
+	return ImageDiff{Ratio: float64(changed) / float64(total), Image: diff}, nil
+}
+
+func samePixel(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+
+	return channelDistance(ar, br) <= pixelTolerance &&
+		channelDistance(ag, bg) <= pixelTolerance &&
+		channelDistance(ab, bb) <= pixelTolerance &&
+		channelDistance(aa, ba) <= pixelTolerance
+}
+
+func channelDistance(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// Check compares an image rendered to path (under outDir) with its
+// baseline. Images exceeding the threshold get a `.diff.png` companion,
+// highlighting the pixels that changed.
+func (b Baseline) Check(outDir string, path string, rendered []byte) (EventType, string, error) {
+	relative, err := filepath.Rel(outDir, path)
+	if err != nil {
+		return ResourceFailure, "", err
+	}
+	baselinePath := filepath.Join(b.Dir, relative)
+
+	expected, err := os.ReadFile(baselinePath)
+	missing := errors.Is(err, os.ErrNotExist)
+	if err != nil && !missing {
+		return ResourceFailure, "", err
+	}
+
+	if b.Update {
+		if !missing && bytes.Equal(expected, rendered) {
+			return ResourceNotChanged, "", nil
+		}
+		if err := os.MkdirAll(filepath.Dir(baselinePath), 0755); err != nil {
+			return ResourceFailure, "", err
+		}
+		if err := os.WriteFile(baselinePath, rendered, 0644); err != nil {
+			return ResourceFailure, "", err
+		}
+		if missing {
+			return ResourceAdded, fmt.Sprintf("added baseline %s", baselinePath), nil
+		}
+		return ResourceUpdated, fmt.Sprintf("updated baseline %s", baselinePath), nil
+	}
+
+	if missing {
+		return ResourceFailure, fmt.Sprintf("missing baseline %s", baselinePath), nil
+	}
+
+	diff, err := CompareImages(expected, rendered)
+	if err != nil {
+		return ResourceFailure, "", err
+	}
+	diffPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".diff.png"
+	if diff.Ratio <= b.Threshold {
+		// diffs of previous runs are outdated
+		if err := os.Remove(diffPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return ResourceFailure, "", err
+		}
+		return ResourceNotChanged, "", nil
+	}
+
+	var content bytes.Buffer
+	if err := png.Encode(&content, diff.Image); err != nil {
+		return ResourceFailure, "", err
+	}
+	if err := os.WriteFile(diffPath, content.Bytes(), 0644); err != nil {
+		return ResourceFailure, "", err
+	}
+
+	return ResourceFailure, fmt.Sprintf("%.2f%% of pixels differ from baseline %s, see %s", diff.Ratio*100, baselinePath, diffPath), nil
+}
@@ -0,0 +1,106 @@
+package grizzly_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareImages(t *testing.T) {
+	encode := func(width, height int, changed int, c color.Color) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		for i := 0; i < width*height; i++ {
+			pixel := color.Color(color.White)
+			if i < changed {
+				pixel = c
+			}
+			img.Set(i%width, i/width, pixel)
+		}
+		var content bytes.Buffer
+		require.NoError(t, png.Encode(&content, img))
+		return content.Bytes()
+	}
+	white := encode(10, 10, 0, color.White)
+
+	tests := []struct {
+		name     string
+		actual   []byte
+		expected float64
+	}{
+		{name: "identical", actual: white, expected: 0},
+		{name: "changed pixels", actual: encode(10, 10, 25, color.Black), expected: 0.25},
+		{name: "slight color changes are tolerated", actual: encode(10, 10, 25, color.RGBA{R: 254, G: 253, B: 255, A: 255}), expected: 0},
+		{name: "different sizes", actual: encode(10, 20, 0, color.White), expected: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff, err := grizzly.CompareImages(white, test.actual)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, diff.Ratio)
+		})
+	}
+}
+
+func TestPreviewBaseline(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	registry := server.GrafanaRegistry()
+
+	dashboard := func(title string) grizzly.Resources {
+		resource := grizzlytest.NewResource(t, "Dashboard", "overview", map[string]any{
+			"uid":   "overview",
+			"title": "Overview",
+			"panels": []any{
+				map[string]any{"id": float64(1), "type": "timeseries", "title": title},
+			},
+		})
+		return grizzly.NewResources(resource)
+	}
+
+	outDir := t.TempDir()
+	baseline := grizzly.Baseline{Dir: t.TempDir()}
+	opts := grizzly.PreviewOptions{Format: "png", Width: 20, Height: 10}
+	preview := func(resources grizzly.Resources, baseline grizzly.Baseline) (string, error) {
+		out := &bytes.Buffer{}
+		err := grizzly.Preview(registry, resources, opts, outDir, &baseline, false, grizzly.NewWriterRecorder(out, grizzly.EventToPorcelain))
+
+		fields := strings.Split(strings.TrimSpace(out.String()), "\t")
+		require.GreaterOrEqual(t, len(fields), 2)
+		return fields[1], err
+	}
+
+	update := baseline
+	update.Update = true
+	event, err := preview(dashboard("Requests"), update)
+	require.NoError(t, err)
+	require.Equal(t, grizzly.ResourceAdded.ID, event)
+	require.FileExists(t, filepath.Join(baseline.Dir, "Dashboard", "overview", "panel-1.png"))
+
+	event, err = preview(dashboard("Requests"), baseline)
+	require.NoError(t, err)
+	require.Equal(t, grizzly.ResourceNotChanged.ID, event)
+
+	event, err = preview(dashboard("Errors"), baseline)
+	require.ErrorIs(t, err, grizzly.ErrVisualRegression)
+	require.Equal(t, grizzly.ResourceFailure.ID, event)
+	require.FileExists(t, filepath.Join(outDir, "Dashboard", "overview", "panel-1.diff.png"))
+
+	tolerant := baseline
+	tolerant.Threshold = 1
+	event, err = preview(dashboard("Errors"), tolerant)
+	require.NoError(t, err)
+	require.Equal(t, grizzly.ResourceNotChanged.ID, event)
+
+	require.NoError(t, os.RemoveAll(baseline.Dir))
+	event, err = preview(dashboard("Requests"), baseline)
+	require.ErrorIs(t, err, grizzly.ErrVisualRegression)
+	require.Equal(t, grizzly.ResourceFailure.ID, event)
+}
@@ -0,0 +1,95 @@
+package grizzly
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Warning is a problem that doesn't prevent a command from completing, but
+// that deserves attention. Warnings are collected while a command runs, to be
+// reported at the end of it.
+type Warning struct {
+	// ResourceRef identifies the resource the warning is about, if any
+	ResourceRef string
+	Err         error
+}
+
+func NewWarning(err error) Warning {
+	return Warning{Err: err}
+}
+
+// NewResourceWarning creates a warning about a specific resource
+func NewResourceWarning(ref ResourceRef, err error) Warning {
+	return Warning{ResourceRef: ref.String(), Err: err}
+}
+
+func (w Warning) Error() string {
+	if w.ResourceRef != "" {
+		return w.ResourceRef + ": " + w.Err.Error()
+	}
+	return w.Err.Error()
+}
+
+func (w Warning) Unwrap() error {
+	return w.Err
+}
+
+func (w Warning) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Resource string `json:"resource,omitempty"`
+		Message  string `json:"message"`
+	}{
+		Resource: w.ResourceRef,
+		Message:  w.Err.Error(),
+	})
+}
+
+func IsWarning(err any) bool {
+	_, ok := err.(Warning)
+	return ok
+}
+
+// warningCollector collects the warnings of a call
+type warningCollector struct {
+	lock      sync.Mutex
+	collected []Warning
+}
+
+type warningsKey struct{}
+
+// WithWarnings returns a context collecting the warnings recorded with it,
+// to be reported at the end of the call
+func WithWarnings(parent context.Context) context.Context {
+	return context.WithValue(parent, warningsKey{}, &warningCollector{})
+}
+
+// RecordWarning collects a warning in the context, to be reported at the end
+// of the call. Without a collector in the context, the warning is logged.
+func RecordWarning(ctx context.Context, warning Warning) {
+	collector, ok := ctx.Value(warningsKey{}).(*warningCollector)
+	if !ok {
+		log.Warn(warning.Error())
+		return
+	}
+
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+
+	collector.collected = append(collector.collected, warning)
+}
+
+// Warnings returns the warnings collected so far in the context
+func Warnings(ctx context.Context) []Warning {
+	collector, ok := ctx.Value(warningsKey{}).(*warningCollector)
+	if !ok {
+		return nil
+	}
+
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+
+	return append([]Warning{}, collector.collected...)
+}
@@ -1,39 +1,148 @@
 package grizzly
 
 import (
+	"fmt"
 	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/fsnotify.v1"
 )
 
+// WatchHooks are commands run by `watch` when changes are detected
+type WatchHooks struct {
+	// Exec lists shell commands run, in order, before resources are applied.
+	// The changed file is available in the GRR_CHANGED_PATH environment
+	// variable.
+	Exec []string
+	// SkipApply only runs the commands, resources aren't applied
+	SkipApply bool
+	// Applied is called once resources are applied successfully, e.g. to
+	// save the folder map the parser records new folders in
+	Applied func() error
+}
+
+// Run runs the commands, stopping at the first failing one
+func (hooks WatchHooks) Run(changedPath string) error {
+	for _, command := range hooks.Exec {
+		log.Infof("[watcher] Running %q", command)
+
+		cmd := shellCommand(command)
+		cmd.Env = append(os.Environ(), "GRR_CHANGED_PATH="+changedPath)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%q failed: %w", command, err)
+		}
+	}
+
+	return nil
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
 type watch struct {
 	path   string
 	parent string
 	isDir  bool
 }
 
+// DefaultPollInterval is the interval at which watched paths are polled,
+// unless set with WatcherPollInterval
+const DefaultPollInterval = time.Second
+
+// WatcherOpt configures a Watcher
+type WatcherOpt func(watcher *Watcher)
+
+// WatcherPolling detects changes by polling the watched paths instead of
+// relying on file system events (inotify, FSEvents), which aren't delivered
+// for network mounts or in some containers. Watchers fall back to polling
+// by themselves when file system events can't be set up.
+func WatcherPolling(polling bool) WatcherOpt {
+	return func(watcher *Watcher) {
+		watcher.polling = polling
+	}
+}
+
+// WatcherPollInterval sets the interval at which watched paths are polled
+func WatcherPollInterval(interval time.Duration) WatcherOpt {
+	return func(watcher *Watcher) {
+		watcher.pollInterval = interval
+	}
+}
+
+// fileState is what polling compares to detect changes
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
 type Watcher struct {
 	watcher     *fsnotify.Watcher
 	watcherFunc func(string) error
 	watches     []watch
+
+	polling      bool
+	pollInterval time.Duration
 }
 
-func NewWatcher(watcherFunc func(path string) error) (*Watcher, error) {
-	w, err := fsnotify.NewWatcher()
-	if err != nil {
-		return nil, err
-	}
+func NewWatcher(watcherFunc func(path string) error, opts ...WatcherOpt) (*Watcher, error) {
 	watcher := Watcher{
-		watcher:     w,
-		watcherFunc: watcherFunc,
+		watcherFunc:  watcherFunc,
+		pollInterval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(&watcher)
+	}
+	if watcher.pollInterval <= 0 {
+		return nil, fmt.Errorf("invalid poll interval %s: must be positive", watcher.pollInterval)
 	}
+
+	if !watcher.polling {
+		w, err := fsnotify.NewWatcher()
+		if err != nil {
+			watcher.fallBackToPolling(err)
+		} else {
+			watcher.watcher = w
+		}
+	}
+
 	return &watcher, nil
 }
 
+// fallBackToPolling switches to polling when file system events can't be
+// used, e.g. when running out of inotify watches
+func (w *Watcher) fallBackToPolling(err error) {
+	log.Warnf("[watcher] Could not watch file system events (%s), polling every %s instead", err, w.pollInterval)
+
+	if w.watcher != nil {
+		w.watcher.Close()
+		w.watcher = nil
+	}
+	w.polling = true
+}
+
+// addNative watches a directory for file system events, unless polling
+func (w *Watcher) addNative(dir string) {
+	if w.polling {
+		return
+	}
+	if err := w.watcher.Add(dir); err != nil {
+		w.fallBackToPolling(err)
+	}
+}
+
 func (w *Watcher) Add(path string) error {
 	log.WithField("path", path).Info("[watcher] Adding path to watch list")
 
@@ -46,10 +155,7 @@ func (w *Watcher) Add(path string) error {
 		// `vim` renames and replaces, doesn't create a WRITE event. So we need to watch the whole dir and filter for our file
 		parent := filepath.Dir(path) + "/"
 		w.watches = append(w.watches, watch{path: path, parent: parent, isDir: false})
-		err := w.watcher.Add(parent)
-		if err != nil {
-			return err
-		}
+		w.addNative(parent)
 	} else {
 		err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
@@ -60,7 +166,7 @@ func (w *Watcher) Add(path string) error {
 					path += "/"
 				}
 				w.watches = append(w.watches, watch{path: path, parent: path, isDir: true})
-				return w.watcher.Add(path)
+				w.addNative(path)
 			}
 			return nil
 		})
@@ -70,7 +176,13 @@ func (w *Watcher) Add(path string) error {
 	}
 	return nil
 }
+
 func (w *Watcher) Watch() error {
+	if w.polling {
+		go w.poll()
+		return nil
+	}
+
 	go func() {
 		log.Info("[watcher] Watching for changes")
 		for {
@@ -80,13 +192,7 @@ func (w *Watcher) Watch() error {
 					return
 				}
 				if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create {
-					if !w.isFiltered(event.Name) {
-						log.Info("[watcher] Changes detected. Parsing")
-						err := w.watcherFunc(event.Name)
-						if err != nil {
-							log.Warn("[watcher] error: ", err)
-						}
-					}
+					w.changed(event.Name)
 				}
 			case err, ok := <-w.watcher.Errors:
 				if !ok {
@@ -99,6 +205,75 @@ func (w *Watcher) Watch() error {
 	return nil
 }
 
+func (w *Watcher) changed(path string) {
+	if w.isFiltered(path) {
+		return
+	}
+
+	log.Info("[watcher] Changes detected. Parsing")
+	if err := w.watcherFunc(path); err != nil {
+		log.Warn("[watcher] error: ", err)
+	}
+}
+
+// poll compares the state of the watched files at each interval. Files
+// created or modified are reported as changed, like with file system events.
+func (w *Watcher) poll() {
+	log.Infof("[watcher] Watching for changes, polling every %s", w.pollInterval)
+
+	previous := w.snapshot()
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		current := w.snapshot()
+
+		var changed []string
+		for path, state := range current {
+			if before, found := previous[path]; !found || before != state {
+				changed = append(changed, path)
+			}
+		}
+		sort.Strings(changed)
+		for _, path := range changed {
+			w.changed(path)
+		}
+
+		previous = current
+	}
+}
+
+func (w *Watcher) snapshot() map[string]fileState {
+	states := map[string]fileState{}
+
+	listed := map[string]bool{}
+	for _, watch := range w.watches {
+		if listed[watch.parent] {
+			continue
+		}
+		listed[watch.parent] = true
+
+		entries, err := os.ReadDir(watch.parent)
+		if err != nil {
+			log.Warn("[watcher] error: ", err)
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				// removed in the meantime
+				continue
+			}
+			states[filepath.Join(watch.parent, entry.Name())] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+
+	return states
+}
+
 func (w *Watcher) Wait() error {
 	done := make(chan bool)
 	<-done
@@ -0,0 +1,78 @@
+package grizzly_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are tested with a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output")
+
+	t.Run("commands run in order", func(t *testing.T) {
+		hooks := grizzly.WatchHooks{Exec: []string{
+			"echo first > " + output,
+			"echo $GRR_CHANGED_PATH >> " + output,
+		}}
+		require.NoError(t, hooks.Run("dashboards/overview.json"))
+
+		content, err := os.ReadFile(output)
+		require.NoError(t, err)
+		require.Equal(t, "first\ndashboards/overview.json\n", string(content))
+	})
+
+	t.Run("failing commands stop hooks", func(t *testing.T) {
+		hooks := grizzly.WatchHooks{Exec: []string{
+			"exit 3",
+			"echo unexpected > " + output,
+		}}
+		err := hooks.Run("dashboards/overview.json")
+		require.ErrorContains(t, err, `"exit 3" failed`)
+
+		content, err := os.ReadFile(output)
+		require.NoError(t, err)
+		require.NotContains(t, string(content), "unexpected")
+	})
+}
+
+func TestWatcherPolling(t *testing.T) {
+	dir := t.TempDir()
+	watched := filepath.Join(dir, "dashboard.json")
+	ignored := filepath.Join(dir, "other.json")
+	require.NoError(t, os.WriteFile(watched, []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(ignored, []byte("{}"), 0644))
+
+	changes := make(chan string, 10)
+	watcher, err := grizzly.NewWatcher(func(path string) error {
+		changes <- path
+		return nil
+	}, grizzly.WatcherPolling(true), grizzly.WatcherPollInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	require.NoError(t, watcher.Add(watched))
+	require.NoError(t, watcher.Watch())
+
+	// let the first poll record the initial state
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, os.WriteFile(ignored, []byte(`{"title": "other"}`), 0644))
+	require.NoError(t, os.WriteFile(watched, []byte(`{"title": "changed"}`), 0644))
+
+	select {
+	case path := <-changes:
+		require.Equal(t, watched, path)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change detected")
+	}
+
+	_, err = grizzly.NewWatcher(func(string) error { return nil }, grizzly.WatcherPollInterval(0))
+	require.ErrorContains(t, err, "invalid poll interval")
+}
@@ -2,18 +2,21 @@ package grizzly
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/grafana/grizzly/pkg/grizzly/notifier"
 	"github.com/grafana/grizzly/pkg/term"
 	"github.com/hashicorp/go-multierror"
-	"github.com/pmezard/go-difflib/difflib"
 	log "github.com/sirupsen/logrus"
 	terminal "golang.org/x/term"
 	"gopkg.in/yaml.v3"
@@ -47,6 +50,7 @@ func Get(registry Registry, uid string, onlySpec bool, outputFormat string) erro
 
 	resource, err := handler.GetByUID(resourceID)
 	if err != nil {
+		RecordPermissionProblem(registry, handler.Kind(), OperationRead, err)
 		return err
 	}
 
@@ -104,6 +108,7 @@ func ListRemote(registry Registry, targets []string, format string) error {
 		log.Debugf("Listing remote values for handler %s", name)
 		IDs, err := handler.ListRemote()
 		if err != nil {
+			RecordPermissionProblem(registry, handler.Kind(), OperationRead, err)
 			return err
 		}
 		for _, id := range IDs {
@@ -177,7 +182,8 @@ func listWide(listedResources []listedResource) ([]byte, error) {
 // Pull pulls remote resources and stores them in the local file system.
 // The given resourcePath must be a directory, where all resources will be stored.
 // If opts.JSONSpec is true, which is only applicable for dashboards, saves the spec as a JSON file.
-func Pull(registry Registry, resourcePath string, onlySpec bool, outputFormat string, targets []string, continueOnError bool, eventsRecorder eventsRecorder) error {
+// The files are named by filenameTemplate if given, or by the handlers of the resources otherwise.
+func Pull(registry Registry, resourcePath string, onlySpec bool, outputFormat string, filenameTemplate *FilenameTemplate, targets []string, continueOnError bool, eventsRecorder eventsRecorder) error {
 	resourcePathIsFile, err := isFile(resourcePath)
 	if err != nil {
 		return err
@@ -187,9 +193,21 @@ func Pull(registry Registry, resourcePath string, onlySpec bool, outputFormat st
 		return fmt.Errorf("pull <resource-path> must be a directory")
 	}
 
+	journal, err := openPullJournal(resourcePath)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
 	var finalErr error
+	// written records the resource written to each file, so that resources
+	// named alike by a template don't overwrite each other
+	written := map[string]string{}
 
 	log.Infof("Pulling resources to %s", resourcePath)
+	if resumed := journal.Resumed(); resumed > 0 {
+		notifier.Info(nil, fmt.Sprintf("Resuming interrupted pull: %d resources already pulled", resumed))
+	}
 	for name, handler := range registry.Handlers {
 		if !registry.HandlerMatchesTarget(handler, targets) {
 			notifier.Info(notifier.SimpleString(handler.Kind()), "skipped")
@@ -199,6 +217,7 @@ func Pull(registry Registry, resourcePath string, onlySpec bool, outputFormat st
 		log.Debugf("Listing remote values for handler %s", name)
 		UIDs, err := handler.ListRemote()
 		if err != nil {
+			RecordPermissionProblem(registry, handler.Kind(), OperationRead, err)
 			finalErr = multierror.Append(finalErr, err)
 			eventsRecorder.Record(Event{
 				Type:        ResourceFailure,
@@ -222,6 +241,12 @@ func Pull(registry Registry, resourcePath string, onlySpec bool, outputFormat st
 			if !registry.ResourceMatchesTarget(handler.Kind(), UID, targets) {
 				continue
 			}
+			ref := NewResourceRef(handler.Kind(), UID).String()
+			if filename, ok := journal.Pulled(ref); ok {
+				written[filename] = ref
+				eventsRecorder.Record(Event{Type: ResourcePulled, ResourceRef: ref, Details: "already pulled"})
+				continue
+			}
 
 			resource, err := handler.GetByUID(UID)
 			if errors.Is(err, ErrNotFound) {
@@ -234,6 +259,7 @@ func Pull(registry Registry, resourcePath string, onlySpec bool, outputFormat st
 				return nil
 			}
 			if err != nil {
+				RecordPermissionProblem(registry, handler.Kind(), OperationRead, err)
 				finalErr = multierror.Append(finalErr, err)
 				eventsRecorder.Record(Event{
 					Type:        ResourceFailure,
@@ -250,7 +276,13 @@ func Pull(registry Registry, resourcePath string, onlySpec bool, outputFormat st
 
 			resource = handler.Unprepare(*resource)
 
-			content, filename, _, err := Format(registry, resourcePath, resource, outputFormat, onlySpec)
+			content, filename, extension, err := Format(registry, resourcePath, resource, outputFormat, onlySpec)
+			if err == nil && filenameTemplate != nil {
+				filename, err = filenameTemplate.Filename(resourcePath, *resource, extension)
+			}
+			if err == nil && written[filename] != "" {
+				err = fmt.Errorf("%s is already written to %s", written[filename], filename)
+			}
 			if err != nil {
 				finalErr = multierror.Append(finalErr, err)
 				eventsRecorder.Record(Event{
@@ -267,6 +299,9 @@ func Pull(registry Registry, resourcePath string, onlySpec bool, outputFormat st
 			}
 
 			err = WriteFile(filename, content)
+			if err == nil {
+				err = journal.Record(resource.Ref().String(), filename, content)
+			}
 			if err != nil {
 				finalErr = multierror.Append(finalErr, err)
 				eventsRecorder.Record(Event{
@@ -282,11 +317,17 @@ func Pull(registry Registry, resourcePath string, onlySpec bool, outputFormat st
 				return finalErr
 			}
 
+			written[filename] = resource.Ref().String()
 			eventsRecorder.Record(Event{Type: ResourcePulled, ResourceRef: resource.Ref().String()})
 		}
 	}
 
-	return finalErr
+	// the journal is kept until every resource is pulled, for the next pull
+	// to resume from it
+	if finalErr != nil {
+		return finalErr
+	}
+	return journal.Complete()
 }
 
 // Show displays resources
@@ -323,88 +364,284 @@ func Show(registry Registry, resources Resources, outputFormat string) error {
 }
 
 // Diff compares resources to those at the endpoints
-func Diff(registry Registry, resources Resources, onlySpec bool, outputFormat string) error {
+func Diff(registry Registry, resources Resources, onlySpec bool, outputFormat string, eventsRecorder eventsRecorder) error {
 	log.Infof("Diff-ing %d resources", resources.Len())
 
-	for _, resource := range resources.AsList() {
-		handler, err := registry.GetHandler(resource.Kind())
-		if err != nil {
-			return err
+	list := resources.AsList()
+	for i, resource := range list {
+		if runExceeded(registry) {
+			return skipResources(registry, list[i:], eventsRecorder)
 		}
 
-		resource = *handler.Unprepare(resource)
-
-		local, _, _, err := Format(registry, "", &resource, outputFormat, onlySpec)
+		err := withinTimeouts(registry, func(registry Registry) error {
+			// the specs of lazy resources are only loaded one at a time
+			hydrated, err := resource.Hydrate()
+			if err != nil {
+				return err
+			}
+			return diffResource(registry, hydrated, onlySpec, outputFormat, eventsRecorder)
+		})
 		if err != nil {
-			return err
+			RecordPermissionProblem(registry, resource.Kind(), OperationRead, err)
+			classified := NewClassifiedError(resource, err)
+			eventsRecorder.Record(Event{
+				Type:        ResourceFailure,
+				ResourceRef: resource.Ref().String(),
+				Details:     err.Error(),
+				ErrorClass:  classified.Class,
+			})
+			return classified
 		}
+	}
 
-		uid := resource.Name()
+	return nil
+}
 
-		log.Debugf("Getting the remote value for `%s`", resource.Ref())
-		remote, err := handler.GetRemote(resource)
-		if errors.Is(err, ErrNotFound) {
-			notifier.NotFound(resource)
-			continue
-		}
+func diffResource(registry Registry, resource Resource, onlySpec bool, outputFormat string, eventsRecorder eventsRecorder) error {
+	resourceRef := resource.Ref().String()
 
-		if err != nil {
-			return fmt.Errorf("Error retrieving resource from %s %s: %v", resource.Kind(), uid, err)
-		}
+	handler, err := registry.GetHandler(resource.Kind())
+	if err != nil {
+		return err
+	}
 
-		remote = handler.Unprepare(*remote)
+	resource = *handler.Unprepare(resource)
 
-		remoteRepresentation, _, _, err := Format(registry, "", remote, outputFormat, onlySpec)
-		if err != nil {
-			return err
-		}
+	difference, err := resourceDiff(registry, handler, resource, onlySpec, outputFormat)
+	if errors.Is(err, ErrNotFound) {
+		notifier.NotFound(resource)
+		eventsRecorder.Record(Event{Type: ResourceNotFound, ResourceRef: resourceRef})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 
-		if string(local) == string(remoteRepresentation) {
-			notifier.NoChanges(resource)
-		} else {
-			diff := difflib.UnifiedDiff{
-				A:        difflib.SplitLines(string(remoteRepresentation)),
-				B:        difflib.SplitLines(string(local)),
-				FromFile: "Remote",
-				ToFile:   "Local",
-				Context:  3,
-			}
-			difference, _ := difflib.GetUnifiedDiffString(diff)
-			notifier.HasChanges(resource, difference)
-		}
+	if difference == "" {
+		notifier.NoChanges(resource)
+		eventsRecorder.Record(Event{Type: ResourceNotChanged, ResourceRef: resourceRef})
+		return nil
 	}
 
+	notifier.HasChanges(resource, difference)
+	eventsRecorder.Record(Event{Type: ResourceChanged, ResourceRef: resourceRef, Details: difference})
+
 	return nil
 }
 
+// resourceDiff returns the differences between an unprepared resource and
+// its remote counterpart, empty if there are none. ErrNotFound is returned
+// when the resource doesn't exist remotely.
+func resourceDiff(registry Registry, handler Handler, resource Resource, onlySpec bool, outputFormat string) (string, error) {
+	log.Debugf("Getting the remote value for `%s`", resource.Ref())
+	remote, err := handler.GetRemote(resource)
+	if errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("Error retrieving resource from %s %s: %w", resource.Kind(), resource.Name(), err)
+	}
+
+	remote = handler.Unprepare(*remote)
+	// read-only fields are only compared when recorded locally, e.g. by pull
+	for _, field := range readOnlyFields(handler) {
+		if _, ok := resource.Spec()[field]; !ok {
+			*remote = withoutSpecKeys(*remote, field)
+		}
+	}
+
+	return GetDiffEngine(handler).Diff(registry, Normalize(handler, resource), Normalize(handler, *remote), outputFormat, onlySpec)
+}
+
 type eventsRecorder interface {
 	Record(event Event)
 }
 
+// ApplyOpt configures how resources are applied
+type ApplyOpt func(config *applyConfig)
+
+type applyConfig struct {
+	checksums      *AppliedChecksums
+	progress       *ApplyProgress
+	circuitBreaker int
+	kindTimeouts   map[string]time.Duration
+}
+
+// ApplySkipUnchanged skips the resources applied with the same content
+// before, according to the given checksums, without reaching remote
+// endpoints. The checksums of the resources applied are recorded.
+func ApplySkipUnchanged(checksums *AppliedChecksums) ApplyOpt {
+	return func(config *applyConfig) {
+		config.checksums = checksums
+	}
+}
+
+// ApplyRecordProgress records the resources applied in a progress, and
+// skips the ones it already lists as completed
+func ApplyRecordProgress(progress *ApplyProgress) ApplyOpt {
+	return func(config *applyConfig) {
+		config.progress = progress
+	}
+}
+
+// ApplyCircuitBreaker skips the remaining resources of a kind once the given
+// number of them failed in a row, as their endpoint is likely down, and
+// carries on with the other kinds. Such failures don't stop the apply, even
+// without continueOnError. Zero disables circuit breaking.
+func ApplyCircuitBreaker(failures int) ApplyOpt {
+	return func(config *applyConfig) {
+		config.circuitBreaker = failures
+	}
+}
+
+// ApplyKindTimeouts bounds the processing of each resource of the given
+// kinds, overriding the resource timeout
+func ApplyKindTimeouts(timeouts map[string]time.Duration) ApplyOpt {
+	return func(config *applyConfig) {
+		config.kindTimeouts = timeouts
+	}
+}
+
 // Apply pushes resources to endpoints
-func Apply(registry Registry, resources Resources, continueOnError bool, eventsRecorder eventsRecorder) error {
+func Apply(registry Registry, resources Resources, continueOnError bool, eventsRecorder eventsRecorder, opts ...ApplyOpt) error {
+	config := &applyConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
 	var finalErr error
+	fail := func(resource Resource, err error) {
+		RecordPermissionProblem(registry, resource.Kind(), OperationWrite, err)
+		classified := NewClassifiedError(resource, err)
+		finalErr = multierror.Append(finalErr, classified)
+
+		eventsRecorder.Record(Event{
+			Type:        ResourceFailure,
+			ResourceRef: resource.Ref().String(),
+			Details:     err.Error(),
+			ErrorClass:  classified.Class,
+		})
+	}
+	applied := func(resource Resource) {
+		if config.checksums != nil {
+			config.checksums.Record(resource)
+		}
+		if config.progress != nil {
+			if err := config.progress.Record(resource); err != nil {
+				log.Warnf("could not record the progress of the apply: %s", err)
+			}
+		}
+	}
 
-	for _, resource := range resources.AsList() {
-		err := applyResource(registry, resource, eventsRecorder)
-		if err != nil {
-			finalErr = multierror.Append(finalErr, err)
+	if err := checkConflicts(registry, resources.AsList()); err != nil {
+		return err
+	}
 
+	list := make([]Resource, 0, resources.Len())
+	for _, resource := range resources.AsList() {
+		if config.checksums != nil && config.checksums.Unchanged(resource) {
 			eventsRecorder.Record(Event{
-				Type:        ResourceFailure,
+				Type:        ResourceNotChanged,
 				ResourceRef: resource.Ref().String(),
-				Details:     err.Error(),
+				Details:     "same content as last applied",
 			})
+			continue
+		}
+		if config.progress != nil && config.progress.Completed(resource) {
+			eventsRecorder.Record(Event{
+				Type:        ResourceNotChanged,
+				ResourceRef: resource.Ref().String(),
+				Details:     "applied by the resumed apply",
+			})
+			continue
+		}
+		list = append(list, resource)
+	}
 
-			if !continueOnError {
+	breakers := newCircuitBreakers(config.circuitBreaker)
+	stop := func(err error) bool {
+		return !continueOnError && !breakers.governs(err)
+	}
+	for i := 0; i < len(list); {
+		if runExceeded(registry) {
+			return multierror.Append(finalErr, skipResources(registry, list[i:], eventsRecorder), breakers.err())
+		}
+
+		if handler, batch := bulkBatch(registry, list[i:]); len(batch) > 0 {
+			i += len(batch)
+			if breakers.open(handler.Kind()) {
+				for _, resource := range batch {
+					breakers.skip(resource, eventsRecorder)
+				}
+				continue
+			}
+
+			var errs []error
+			_ = withinResourceTimeout(registry, config.kindTimeouts[handler.Kind()], func(registry Registry) error {
+				// the handler bound to the context of the batch
+				bound, _ := bulkBatch(registry, batch)
+				errs = applyBatch(bound, batch, eventsRecorder)
+				return nil
+			})
+			stopped := false
+			for j, err := range errs {
+				breakers.record(handler.Kind(), err)
+				if err != nil {
+					fail(batch[j], err)
+					stopped = stopped || stop(err)
+				} else {
+					applied(batch[j])
+				}
+			}
+			if stopped {
+				return finalErr
+			}
+			continue
+		}
+
+		resource := list[i]
+		i++
+		if breakers.open(resource.Kind()) {
+			breakers.skip(resource, eventsRecorder)
+			continue
+		}
+
+		err := withinResourceTimeout(registry, config.kindTimeouts[resource.Kind()], func(registry Registry) error {
+			return applyResource(registry, resource, eventsRecorder)
+		})
+		breakers.record(resource.Kind(), err)
+		if err != nil {
+			fail(resource, err)
+
+			if stop(err) {
 				return finalErr
 			}
+		} else {
+			applied(resource)
 		}
 	}
 
+	if err := breakers.err(); err != nil {
+		finalErr = multierror.Append(finalErr, err)
+	}
 	return finalErr
 }
 
+// skipResources reports the resources left aside once the context of the
+// run is done, e.g. once its deadline is exceeded
+func skipResources(registry Registry, resources []Resource, eventsRecorder eventsRecorder) error {
+	cause := context.Cause(registry.Context())
+	for _, resource := range resources {
+		eventsRecorder.Record(Event{
+			Type:        ResourceSkipped,
+			ResourceRef: resource.Ref().String(),
+			Details:     cause.Error(),
+		})
+	}
+
+	return fmt.Errorf("%w: %s skipped", cause, Pluraliser(len(resources), "resource"))
+}
+
 func applyResource(registry Registry, resource Resource, trailRecorder eventsRecorder) error {
 	resourceRef := resource.Ref().String()
 
@@ -413,13 +650,33 @@ func applyResource(registry Registry, resource Resource, trailRecorder eventsRec
 		return err
 	}
 
+	strategy, err := GetApplyStrategy(handler, resource)
+	if err != nil {
+		return err
+	}
+
 	log.Debugf("Getting the remote value for `%s`", resource.Ref())
 	existingResource, err := handler.GetRemote(resource)
 	if errors.Is(err, ErrNotFound) {
+		existingResource, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	change, event, err := planApply(handler, resource, existingResource, strategy)
+	if err != nil {
+		return err
+	}
+	if change == nil {
+		trailRecorder.Record(*event)
+		return nil
+	}
+
+	if change.Existing == nil {
 		log.Debugf("`%s` was not found, adding it...", resource.Ref())
 
-		resource = *handler.Prepare(nil, resource)
-		if err := handler.Add(resource); err != nil {
+		if err := handler.Add(change.Resource); err != nil {
 			return err
 		}
 
@@ -429,33 +686,23 @@ func applyResource(registry Registry, resource Resource, trailRecorder eventsRec
 		})
 		return nil
 	}
-	if err != nil {
-		return err
-	}
 
 	log.Debugf("`%s` was found, updating it...", resource.Ref())
 
-	resourceRepresentation, err := resource.YAML()
-	if err != nil {
-		return err
-	}
-
-	resource = *handler.Prepare(existingResource, resource)
-	existingResource = handler.Unprepare(*existingResource)
-	existingResourceRepresentation, err := existingResource.YAML()
-	if err != nil {
-		return err
-	}
+	if strategy == ApplyRecreate {
+		if err := recreate(handler, *change.Existing, change.Resource); err != nil {
+			return err
+		}
 
-	if resourceRepresentation == existingResourceRepresentation {
 		trailRecorder.Record(Event{
-			Type:        ResourceNotChanged,
+			Type:        ResourceUpdated,
 			ResourceRef: resourceRef,
+			Details:     "recreated",
 		})
 		return nil
 	}
 
-	if err = handler.Update(*existingResource, resource); err != nil {
+	if err = handler.Update(*change.Existing, change.Resource); err != nil {
 		return err
 	}
 
@@ -467,6 +714,56 @@ func applyResource(registry Registry, resource Resource, trailRecorder eventsRec
 	return nil
 }
 
+// planApply decides how to apply a resource, given its remote counterpart,
+// nil when not found. It returns either the change to write, or the event
+// to record when there is nothing to write.
+func planApply(handler Handler, resource Resource, existing *Resource, strategy ApplyStrategy) (*BulkChange, *Event, error) {
+	resourceRef := resource.Ref().String()
+
+	if existing == nil && strategy == ApplyUpdateOnly {
+		return nil, &Event{
+			Type:        ResourceNotFound,
+			ResourceRef: resourceRef,
+			Details:     fmt.Sprintf("not created, as its apply strategy is %s", strategy),
+		}, nil
+	}
+	if existing == nil {
+		return &BulkChange{Resource: *handler.Prepare(nil, resource)}, nil, nil
+	}
+
+	if strategy == ApplyCreateOnly {
+		return nil, &Event{
+			Type:        ResourceNotChanged,
+			ResourceRef: resourceRef,
+			Details:     fmt.Sprintf("left as is, as its apply strategy is %s", strategy),
+		}, nil
+	}
+
+	fields := readOnlyFields(handler)
+	compared := withoutSpecKeys(resource, fields...)
+	resourceRepresentation, err := compared.YAML()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resource = *handler.Prepare(existing, resource)
+	existing = handler.Unprepare(*existing)
+	comparedExisting := withoutSpecKeys(*existing, fields...)
+	existingResourceRepresentation, err := comparedExisting.YAML()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resourceRepresentation == existingResourceRepresentation {
+		return nil, &Event{
+			Type:        ResourceNotChanged,
+			ResourceRef: resourceRef,
+		}, nil
+	}
+
+	return &BulkChange{Existing: existing, Resource: resource}, nil, nil
+}
+
 // Snapshot pushes resources to endpoints as snapshots, if supported
 func Snapshot(registry Registry, resources Resources, expiresSeconds int) error {
 	for _, resource := range resources.AsList() {
@@ -487,10 +784,113 @@ func Snapshot(registry Registry, resources Resources, expiresSeconds int) error
 	return nil
 }
 
+// PreviewPath returns the location of a preview image of a resource
+func PreviewPath(outDir string, resource Resource, image PreviewImage, format string) string {
+	return filepath.Join(outDir, resource.Kind(), url.PathEscape(resource.Name()), url.PathEscape(image.Name)+"."+format)
+}
+
+// Preview renders resources as images, written to outDir. Resources whose
+// handler can't render them are skipped, as most kinds have nothing to show.
+// With a baseline, images are compared to it, and ErrVisualRegression is
+// returned when some of them changed.
+func Preview(registry Registry, resources Resources, opts PreviewOptions, outDir string, baseline *Baseline, continueOnError bool, eventsRecorder eventsRecorder) error {
+	var finalErr error
+	regression := false
+
+	for _, resource := range resources.AsList() {
+		handler, err := registry.GetHandler(resource.Kind())
+		if err != nil {
+			return err
+		}
+		previewHandler, ok := handler.(PreviewHandler)
+		if !ok {
+			log.Debugf("Skipping %s: previews are not supported", resource.Ref())
+			continue
+		}
+
+		event, err := previewResource(previewHandler, resource, opts, outDir, baseline)
+		if err != nil {
+			finalErr = multierror.Append(finalErr, err)
+			eventsRecorder.Record(Event{
+				Type:        ResourceFailure,
+				ResourceRef: resource.Ref().String(),
+				Details:     err.Error(),
+			})
+
+			if !continueOnError {
+				return finalErr
+			}
+			continue
+		}
+
+		// regressions don't stop rendering, so that all of them are reported
+		regression = regression || event.Type == ResourceFailure
+		eventsRecorder.Record(event)
+	}
+
+	if regression {
+		finalErr = multierror.Append(finalErr, ErrVisualRegression)
+	}
+
+	return finalErr
+}
+
+func previewResource(handler PreviewHandler, resource Resource, opts PreviewOptions, outDir string, baseline *Baseline) (Event, error) {
+	event := Event{ResourceRef: resource.Ref().String()}
+
+	images, err := handler.Preview(resource, opts)
+	if err != nil {
+		return event, err
+	}
+
+	outcomes := map[EventType][]string{}
+	for _, image := range images {
+		path := PreviewPath(outDir, resource, image, opts.Format)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return event, err
+		}
+		if err := os.WriteFile(path, image.Content, 0644); err != nil {
+			return event, err
+		}
+
+		if baseline == nil {
+			continue
+		}
+		outcome, details, err := baseline.Check(outDir, path, image.Content)
+		if err != nil {
+			return event, fmt.Errorf("comparing %s with its baseline: %w", image.Name, err)
+		}
+		outcomes[outcome] = append(outcomes[outcome], details)
+	}
+
+	// the most severe outcome among the images is reported
+	for _, outcome := range []EventType{ResourceFailure, ResourceUpdated, ResourceAdded, ResourceNotChanged} {
+		if details, found := outcomes[outcome]; found {
+			event.Type = outcome
+			event.Details = strings.Join(details, "; ")
+			return event, nil
+		}
+	}
+
+	event.Type = ResourceRendered
+	event.Details = Pluraliser(len(images), "image")
+
+	return event, nil
+}
+
 // Watch watches a directory for changes then pushes Jsonnet resource to endpoints
-// when changes are noticed.
-func Watch(registry Registry, watchDir string, resourcePath string, parser Parser, parserOpts ParserOptions, trailRecorder eventsRecorder) error {
+// when changes are noticed. Hooks are run first, and a failing hook prevents
+// resources from being applied.
+func Watch(registry Registry, watchDir string, resourcePath string, parser Parser, parserOpts ParserOptions, hooks WatchHooks, trailRecorder eventsRecorder, watcherOpts ...WatcherOpt) error {
 	updateWatchedResource := func(path string) error {
+		if err := hooks.Run(path); err != nil {
+			log.Error("Error running hooks: ", err)
+			return nil
+		}
+		if hooks.SkipApply {
+			return nil
+		}
+
 		log.Infof("Changes detected in %q. Applying %q", path, resourcePath)
 		resources, err := parser.Parse(resourcePath, parserOpts)
 		if err != nil {
@@ -499,10 +899,16 @@ func Watch(registry Registry, watchDir string, resourcePath string, parser Parse
 		err = Apply(registry, resources, false, trailRecorder) // TODO?
 		if err != nil {
 			log.Error("Error applying resources: ", err)
+			return nil
+		}
+		if hooks.Applied != nil {
+			if err := hooks.Applied(); err != nil {
+				log.Error("Error after applying resources: ", err)
+			}
 		}
 		return nil
 	}
-	watcher, err := NewWatcher(updateWatchedResource)
+	watcher, err := NewWatcher(updateWatchedResource, watcherOpts...)
 	if err != nil {
 		return err
 	}
@@ -1,11 +1,18 @@
 package grizzly
 
 import (
-	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"text/template"
 
+	"github.com/hashicorp/go-multierror"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
@@ -13,6 +20,13 @@ import (
 type YAMLParser struct {
 	registry Registry
 	logger   *log.Entry
+	// valuesFile enables the preprocessing of YAML files as Go templates,
+	// executed with the values of this file
+	valuesFile string
+
+	valuesOnce sync.Once
+	values     map[string]any
+	valuesErr  error
 }
 
 func NewYAMLParser(registry Registry) *YAMLParser {
@@ -32,37 +46,140 @@ func (parser *YAMLParser) Accept(file string) bool {
 func (parser *YAMLParser) Parse(file string, options ParserOptions) (Resources, error) {
 	parser.logger.WithField("file", file).Debug("Parsing file")
 
+	if parser.valuesFile != "" {
+		content, err := parser.executeTemplate(file)
+		if err != nil {
+			return Resources{}, err
+		}
+		// the rendered resources can't be written back to their template
+		return parser.parseReader(bytes.NewReader(content), Source{
+			Format:     formatYAML,
+			Path:       file,
+			Rewritable: false,
+		}, options)
+	}
+
 	f, err := os.Open(file)
 	if err != nil {
 		return Resources{}, err
 	}
 	defer f.Close()
 
-	reader := bufio.NewReader(f)
-	decoder := yaml.NewDecoder(reader)
+	return parser.parseReader(f, Source{
+		Format:     formatYAML,
+		Path:       file,
+		Rewritable: true,
+	}, options)
+}
+
+// parseReader parses a stream of YAML documents into resources. As YAML is
+// a superset of JSON, JSON documents are accepted too. Documents, and the
+// items of documents made of a list, are decoded one at a time, so that
+// large files aren't held in memory twice.
+func (parser *YAMLParser) parseReader(input io.Reader, source Source, options ParserOptions) (Resources, error) {
 	resources := NewResources()
-	for i := 0; ; i++ {
-		var m any
-		err = decoder.Decode(&m)
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return Resources{}, err
-		}
+	var finalErr error
+	document, item := -1, 0
+	err := splitYAML(input, func(chunk yamlChunk) error {
+		decoder := yaml.NewDecoder(bytes.NewReader(chunk.content))
+		for {
+			var node yaml.Node
+			err := decoder.Decode(&node)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return yamlError(err, chunk.line-1)
+			}
+			if chunk.document {
+				document, item = document+1, 0
+			}
 
-		source := Source{
-			Format:     formatYAML,
-			Path:       file,
-			Rewritable: true,
+			// items of lists are parsed one by one to locate their errors
+			nodes := []*yaml.Node{&node}
+			location := func() string { return fmt.Sprintf("document %d", document) }
+			if len(node.Content) == 1 && node.Content[0].Kind == yaml.SequenceNode {
+				nodes = node.Content[0].Content
+				location = func() string { return fmt.Sprintf("item %d", item) }
+			}
+
+			for _, node := range nodes {
+				var m any
+				if err := node.Decode(&m); err != nil {
+					finalErr = multierror.Append(finalErr, yamlError(err, chunk.line-1))
+				} else if m != nil {
+					// empty documents are skipped
+					parsedResources, err := parseAny(parser.registry, m, options.DefaultResourceKind, options.DefaultFolderUID, source)
+					if err != nil {
+						finalErr = multierror.Append(finalErr, newResourceError(m, location(), nodeLine(node)+chunk.line-1, err))
+					}
+					resources.Merge(parsedResources)
+				}
+				item++
+			}
 		}
-		parsedResources, err := parseAny(parser.registry, m, options.DefaultResourceKind, options.DefaultFolderUID, source)
+	})
+	if err != nil {
+		// the rest of the file can't be decoded
+		return resources, multierror.Append(finalErr, err)
+	}
+
+	return resources, finalErr
+}
+
+// executeTemplate renders a YAML file as a Go template, with the values of
+// the values file as data. Missing values are errors rather than empty
+// strings.
+func (parser *YAMLParser) executeTemplate(file string) ([]byte, error) {
+	parser.valuesOnce.Do(func() {
+		content, err := os.ReadFile(parser.valuesFile)
 		if err != nil {
-			return Resources{}, err
+			parser.valuesErr = err
+			return
+		}
+		parser.values = map[string]any{}
+		if err := yaml.Unmarshal(content, &parser.values); err != nil {
+			parser.valuesErr = fmt.Errorf("values file %s: %w", parser.valuesFile, err)
 		}
+	})
+	if parser.valuesErr != nil {
+		return nil, parser.valuesErr
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(filepath.Base(file)).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	rendered := &bytes.Buffer{}
+	if err := tmpl.Execute(rendered, parser.values); err != nil {
+		return nil, err
+	}
+
+	return rendered.Bytes(), nil
+}
 
-		resources.Merge(parsedResources)
+var yamlLineErrorRegexp = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// yamlError extracts the line of YAML syntax errors, offset by the lines
+// preceding the decoded chunk
+func yamlError(err error, offset int) error {
+	matches := yamlLineErrorRegexp.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return err
 	}
+	line, _ := strconv.Atoi(matches[1])
+
+	return ResourceError{Line: line + offset, Err: errors.New("yaml: " + matches[2])}
+}
 
-	return resources, nil
+// nodeLine returns the line at which the content of a node starts
+func nodeLine(node *yaml.Node) int {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return node.Content[0].Line
+	}
+	return node.Line
 }
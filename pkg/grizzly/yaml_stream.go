@@ -0,0 +1,125 @@
+package grizzly
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+)
+
+// yamlChunk is a part of a YAML stream decoded on its own: a document, or
+// some items of a document made of a list
+type yamlChunk struct {
+	content []byte
+	// line is the line of the stream at which the chunk starts
+	line int
+	// document tells whether the chunk starts a document, rather than
+	// continuing the list of the previous chunk
+	document bool
+}
+
+// yamlAnchorRegexp matches lines which may define an anchor
+var yamlAnchorRegexp = regexp.MustCompile(`(?:^|[\s\[{,])&\S`)
+
+// splitYAML reads a YAML stream line by line, and hands its documents to
+// emit one at a time, so that only the document being decoded is held in
+// memory. Documents made of a block list are split further, one item at a
+// time, as exports are often a single list of many resources.
+//
+// Aliases may refer to anchors of previous items: once a line possibly
+// defining an anchor is met, the rest of the document is kept in a single
+// chunk.
+func splitYAML(input io.Reader, emit func(yamlChunk) error) error {
+	reader := bufio.NewReader(input)
+
+	chunk := yamlChunk{line: 1, document: true}
+	var (
+		// hasContent tells whether the chunk holds more than comments
+		hasContent bool
+		hasMarker  bool
+		// rootKnown tells whether the root node of the document was met,
+		// and split whether it is a block list whose items are emitted one
+		// by one
+		rootKnown bool
+		split     bool
+	)
+	flush := func(next int, document bool) error {
+		if len(chunk.content) > 0 {
+			if err := emit(chunk); err != nil {
+				return err
+			}
+		}
+		chunk = yamlChunk{line: next, document: document}
+		hasContent, hasMarker = false, false
+		return nil
+	}
+
+	for line := 1; ; line++ {
+		text, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(text) == 0 {
+			return flush(line, true)
+		}
+
+		switch {
+		case isYAMLMarker(text, "---"):
+			if hasContent || hasMarker {
+				if err := flush(line, true); err != nil {
+					return err
+				}
+			}
+			hasMarker = true
+			// the root of the document may follow the marker
+			inline := bytes.TrimSpace(text[3:])
+			rootKnown = len(inline) > 0 && inline[0] != '#'
+			hasContent, split = rootKnown, false
+		case isYAMLMarker(text, "..."):
+			chunk.content = append(chunk.content, text...)
+			if err := flush(line+1, true); err != nil {
+				return err
+			}
+			rootKnown, split = false, false
+			continue
+		case isYAMLComment(text):
+		case split && hasContent && isYAMLListItem(text):
+			if err := flush(line, false); err != nil {
+				return err
+			}
+			hasContent = true
+		default:
+			if !rootKnown {
+				rootKnown = true
+				split = isYAMLListItem(text)
+			}
+			hasContent = true
+		}
+
+		chunk.content = append(chunk.content, text...)
+		if split && yamlAnchorRegexp.Match(text) {
+			split = false
+		}
+	}
+}
+
+// isYAMLMarker tells whether a line is a document marker: --- or ...
+func isYAMLMarker(text []byte, marker string) bool {
+	return bytes.HasPrefix(text, []byte(marker)) && (len(text) == len(marker) || isYAMLSpace(text[len(marker)]))
+}
+
+// isYAMLListItem tells whether a line starts an item of a list at the root
+// of a document
+func isYAMLListItem(text []byte) bool {
+	return text[0] == '-' && (len(text) == 1 || isYAMLSpace(text[1]))
+}
+
+// isYAMLComment tells whether a line is blank or only holds a comment
+func isYAMLComment(text []byte) bool {
+	trimmed := bytes.TrimSpace(text)
+	return len(trimmed) == 0 || trimmed[0] == '#'
+}
+
+func isYAMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,188 @@
+package grizzlytest
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// grafanaAlertmanager is the name of the Alertmanager built into Grafana, in
+// the paths of the Alertmanager API
+const grafanaAlertmanager = "grafana"
+
+func (s *Server) registerAlertmanager(mux *http.ServeMux) {
+	s.handle(mux, "GET /api/alertmanager/{recipient}/config/api/v1/alerts", s.getAlertmanagerConfig)
+	s.handle(mux, "POST /api/alertmanager/{recipient}/config/api/v1/alerts", s.postAlertmanagerConfig)
+}
+
+// AlertmanagerConfig returns the configuration of an Alertmanager stored in
+// the fake Grafana: `grafana` for the Alertmanager of Grafana, whose route
+// is the notification policy, or the UID of an Alertmanager datasource
+func (s *Server) AlertmanagerConfig(recipient string) (map[string]any, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	config, found := s.alertmanagerConfigs[recipient]
+	return copyObject(config), found
+}
+
+// alertmanagerExists tells whether a recipient of the Alertmanager API is
+// the Alertmanager of Grafana or an Alertmanager datasource
+func (s *Server) alertmanagerExists(w http.ResponseWriter, recipient string) bool {
+	if recipient == grafanaAlertmanager {
+		return true
+	}
+	datasource, found := s.datasources[recipient]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "datasource not found")
+		return false
+	}
+	if datasource["type"] != "alertmanager" {
+		writeMessage(w, http.StatusBadRequest, "can only be used with alertmanager datasource")
+		return false
+	}
+	return true
+}
+
+func (s *Server) getAlertmanagerConfig(w http.ResponseWriter, r *http.Request) {
+	recipient := r.PathValue("recipient")
+	if !s.alertmanagerExists(w, recipient) {
+		return
+	}
+
+	config, found := s.alertmanagerConfigs[recipient]
+	if !found {
+		config = defaultAlertmanagerConfig(recipient)
+	}
+	config = copyObject(config)
+	if recipient == grafanaAlertmanager {
+		// the route of the Alertmanager of Grafana is the notification policy
+		config["alertmanager_config"].(map[string]any)["route"] = copyObject(s.policy)
+		config["template_file_provenances"] = map[string]any{}
+	}
+	writeJSON(w, http.StatusOK, config)
+}
+
+func (s *Server) postAlertmanagerConfig(w http.ResponseWriter, r *http.Request) {
+	recipient := r.PathValue("recipient")
+	if !s.alertmanagerExists(w, recipient) {
+		return
+	}
+
+	config := map[string]any{}
+	if err := readJSON(r, &config); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	alertmanagerConfig, _ := config["alertmanager_config"].(map[string]any)
+	route, _ := alertmanagerConfig["route"].(map[string]any)
+	if route == nil {
+		writeMessage(w, http.StatusBadRequest, "failed to save and apply Alertmanager configuration: no route provided in config")
+		return
+	}
+
+	if recipient == grafanaAlertmanager {
+		s.policy = copyObject(route)
+		s.storeReceiverSecureSettings(alertmanagerConfig)
+	}
+	s.alertmanagerConfigs[recipient] = config
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"message": "configuration created"})
+}
+
+// storeReceiverSecureSettings identifies the receivers of the Alertmanager
+// of Grafana, and keeps their secure settings apart, as Grafana does.
+// Receivers posted with the UID of an existing one keep its secure settings
+// unless they set them again, while the other ones only have the secure
+// settings they are posted with.
+func (s *Server) storeReceiverSecureSettings(alertmanagerConfig map[string]any) {
+	secureSettings := map[string]map[string]any{}
+	receivers, _ := alertmanagerConfig["receivers"].([]any)
+	for _, receiver := range receivers {
+		receiver, _ := receiver.(map[string]any)
+		configs, _ := receiver["grafana_managed_receiver_configs"].([]any)
+		for _, receiverConfig := range configs {
+			receiverConfig, ok := receiverConfig.(map[string]any)
+			if !ok {
+				continue
+			}
+			uid := stringValue(receiverConfig, "uid")
+			settings := map[string]any{}
+			if _, found := s.receiverSecureSettings[uid]; found {
+				settings = s.receiverSecureSettings[uid]
+			} else {
+				uid = fmt.Sprintf("receiver-%d", s.newID())
+			}
+			posted, _ := receiverConfig["secureSettings"].(map[string]any)
+			for key, value := range posted {
+				settings[key] = value
+			}
+			delete(receiverConfig, "secureSettings")
+
+			secureFields := map[string]any{}
+			for key := range settings {
+				secureFields[key] = true
+			}
+			receiverConfig["uid"] = uid
+			receiverConfig["secureFields"] = secureFields
+			secureSettings[uid] = settings
+		}
+	}
+	s.receiverSecureSettings = secureSettings
+}
+
+// ReceiverSecureSettings returns the secure settings of the integrations of
+// a receiver of the Alertmanager of Grafana
+func (s *Server) ReceiverSecureSettings(receiver string) []map[string]any {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var settings []map[string]any
+	alertmanagerConfig, _ := s.alertmanagerConfigs[grafanaAlertmanager]["alertmanager_config"].(map[string]any)
+	receivers, _ := alertmanagerConfig["receivers"].([]any)
+	for _, candidate := range receivers {
+		candidate, _ := candidate.(map[string]any)
+		if candidate["name"] != receiver {
+			continue
+		}
+		configs, _ := candidate["grafana_managed_receiver_configs"].([]any)
+		for _, receiverConfig := range configs {
+			receiverConfig, _ := receiverConfig.(map[string]any)
+			settings = append(settings, copyObject(s.receiverSecureSettings[stringValue(receiverConfig, "uid")]))
+		}
+	}
+	return settings
+}
+
+// defaultAlertmanagerConfig returns the configuration of an Alertmanager
+// that hasn't been configured yet
+func defaultAlertmanagerConfig(recipient string) map[string]any {
+	if recipient == grafanaAlertmanager {
+		return map[string]any{
+			"template_files": map[string]any{},
+			"alertmanager_config": map[string]any{
+				"receivers": []any{
+					map[string]any{
+						"name": "grafana-default-email",
+						"grafana_managed_receiver_configs": []any{
+							map[string]any{
+								"uid":          "default-email",
+								"name":         "grafana-default-email",
+								"type":         "email",
+								"settings":     map[string]any{"addresses": "<example@email.com>"},
+								"secureFields": map[string]any{},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	return map[string]any{
+		"template_files": map[string]any{},
+		"alertmanager_config": map[string]any{
+			"route":     map[string]any{"receiver": "empty-receiver"},
+			"receivers": []any{map[string]any{"name": "empty-receiver"}},
+		},
+	}
+}
@@ -0,0 +1,32 @@
+package grizzlytest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// UpdateGoldenEnv is the environment variable which, when set, makes
+// AssertGolden write the golden files instead of comparing against them
+const UpdateGoldenEnv = "GRIZZLY_UPDATE_GOLDEN"
+
+// AssertGolden fails the test if rendered resources don't match the golden
+// files stored in goldenDir, like `grr test` does.
+func AssertGolden(t testing.TB, registry grizzly.Registry, resources grizzly.Resources, goldenDir string) {
+	t.Helper()
+
+	results, err := grizzly.CheckGolden(registry, goldenDir, resources, os.Getenv(UpdateGoldenEnv) != "")
+	if err != nil {
+		t.Fatalf("comparing with golden files: %s", err)
+	}
+
+	for _, result := range results {
+		switch result.Event {
+		case grizzly.ResourceFailure:
+			t.Errorf("%s: %s (set %s=1 to accept the changes)\n%s", result.ResourceRef, result.Details, UpdateGoldenEnv, result.Diff)
+		case grizzly.ResourceAdded, grizzly.ResourceUpdated:
+			t.Logf("%s: golden file %s written", result.ResourceRef, result.Path)
+		}
+	}
+}
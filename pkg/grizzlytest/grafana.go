@@ -0,0 +1,1753 @@
+package grizzlytest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// generalFolderUID is the UID of the folder of dashboards stored at the root
+const generalFolderUID = "general"
+
+func (s *Server) registerGrafana(mux *http.ServeMux) {
+	s.handle(mux, "GET /api/health", s.getHealth)
+	s.handle(mux, "GET /api/frontend/settings", s.getFrontendSettings)
+	s.handle(mux, "GET /api/search", s.search)
+	s.handle(mux, "GET /api/search/sorting", s.listSortOptions)
+
+	s.handle(mux, "GET /api/folders", s.listFolders)
+	s.handle(mux, "POST /api/folders", s.createFolder)
+	s.handle(mux, "GET /api/folders/{uid}", s.getFolder)
+	s.handle(mux, "PUT /api/folders/{uid}", s.updateFolder)
+	s.handle(mux, "POST /api/folders/{uid}/move", s.moveFolder)
+	s.handle(mux, "GET /api/folders/id/{id}", s.getFolderByID)
+
+	s.handle(mux, "GET /api/dashboards/home", s.getHomeDashboard)
+	s.handle(mux, "GET /api/dashboards/uid/{uid}", s.getDashboard)
+	s.handle(mux, "DELETE /api/dashboards/uid/{uid}", s.deleteDashboard)
+	s.handle(mux, "POST /api/dashboards/db", s.saveDashboard)
+	s.handle(mux, "POST /api/snapshots", s.createSnapshot)
+
+	s.handle(mux, "GET /render/d-solo/{uid}/{slug}", s.renderPanel)
+
+	s.handle(mux, "GET /api/datasources", s.listDatasources)
+	s.handle(mux, "POST /api/datasources", s.addDatasource)
+	s.handle(mux, "GET /api/datasources/uid/{uid}", s.getDatasourceByUID)
+	s.handle(mux, "GET /api/datasources/name/{name}", s.getDatasourceByName)
+	s.handle(mux, "PUT /api/datasources/{id}", s.updateDatasource)
+
+	s.handle(mux, "GET /api/org/users", s.listOrgUsers)
+
+	s.handle(mux, "GET /api/teams/search", s.searchTeams)
+	s.handle(mux, "POST /api/teams", s.createTeam)
+	s.handle(mux, "GET /api/teams/{id}", s.getTeam)
+	s.handle(mux, "PUT /api/teams/{id}", s.updateTeam)
+	s.handle(mux, "DELETE /api/teams/{id}", s.deleteTeam)
+	s.handle(mux, "GET /api/teams/{id}/members", s.listTeamMembers)
+	s.handle(mux, "POST /api/teams/{id}/members", s.addTeamMember)
+	s.handle(mux, "DELETE /api/teams/{id}/members/{userId}", s.removeTeamMember)
+
+	s.handle(mux, "GET /api/serviceaccounts/search", s.searchServiceAccounts)
+	s.handle(mux, "POST /api/serviceaccounts", s.createServiceAccount)
+	s.handle(mux, "PATCH /api/serviceaccounts/{id}", s.updateServiceAccount)
+	s.handle(mux, "DELETE /api/serviceaccounts/{id}", s.deleteServiceAccount)
+	s.handle(mux, "GET /api/serviceaccounts/{id}/tokens", s.listTokens)
+	s.handle(mux, "POST /api/serviceaccounts/{id}/tokens", s.createToken)
+	s.handle(mux, "DELETE /api/serviceaccounts/{id}/tokens/{tokenId}", s.deleteToken)
+
+	s.handle(mux, "GET /api/annotations", s.listAnnotations)
+	s.handle(mux, "POST /api/annotations", s.createAnnotation)
+	s.handle(mux, "GET /api/annotations/{id}", s.getAnnotation)
+	s.handle(mux, "PUT /api/annotations/{id}", s.updateAnnotation)
+	s.handle(mux, "DELETE /api/annotations/{id}", s.deleteAnnotation)
+
+	s.handle(mux, "GET /api/reports", s.listReports)
+	s.handle(mux, "POST /api/reports", s.createReport)
+	s.handle(mux, "GET /api/reports/{id}", s.getReport)
+	s.handle(mux, "PUT /api/reports/{id}", s.updateReport)
+	s.handle(mux, "DELETE /api/reports/{id}", s.deleteReport)
+	s.registerSLO(mux)
+	s.registerML(mux)
+
+	s.handle(mux, "GET /api/library-elements", s.listLibraryElements)
+	s.handle(mux, "POST /api/library-elements", s.createLibraryElement)
+	s.handle(mux, "GET /api/library-elements/{uid}", s.getLibraryElement)
+	s.handle(mux, "PATCH /api/library-elements/{uid}", s.updateLibraryElement)
+	s.handle(mux, "DELETE /api/library-elements/{uid}", s.deleteLibraryElement)
+	s.handle(mux, "GET /api/library-elements/{uid}/connections/", s.listLibraryElementConnections)
+
+	s.handle(mux, "GET /api/v1/provisioning/alert-rules", s.listAlertRules)
+	s.handle(mux, "POST /api/v1/provisioning/alert-rules", s.createAlertRule)
+	s.handle(mux, "GET /api/v1/provisioning/alert-rules/{uid}", s.getAlertRule)
+	s.handle(mux, "PUT /api/v1/provisioning/alert-rules/{uid}", s.updateAlertRule)
+	s.handle(mux, "GET /api/v1/provisioning/folder/{folder}/rule-groups/{group}", s.getAlertRuleGroup)
+	s.handle(mux, "PUT /api/v1/provisioning/folder/{folder}/rule-groups/{group}", s.updateAlertRuleGroup)
+
+	s.handle(mux, "GET /api/v1/provisioning/contact-points", s.listContactPoints)
+	s.handle(mux, "POST /api/v1/provisioning/contact-points", s.createContactPoint)
+	s.handle(mux, "PUT /api/v1/provisioning/contact-points/{uid}", s.updateContactPoint)
+
+	s.handle(mux, "GET /api/v1/provisioning/mute-timings", s.listMuteTimings)
+
+	s.handle(mux, "GET /api/alertmanager/grafana/api/v2/alerts", s.listAlerts)
+
+	s.handle(mux, "GET /api/v1/provisioning/policies", s.getPolicy)
+	s.handle(mux, "PUT /api/v1/provisioning/policies", s.updatePolicy)
+	s.registerAlertmanager(mux)
+}
+
+// Folder returns a folder stored in the fake Grafana
+func (s *Server) Folder(uid string) (map[string]any, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	folder, found := s.folders[uid]
+	return copyObject(folder), found
+}
+
+// Dashboard returns a dashboard stored in the fake Grafana, and the UID of
+// its folder
+func (s *Server) Dashboard(uid string) (map[string]any, string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	dashboard, found := s.dashboards[uid]
+	if !found {
+		return nil, "", false
+	}
+	return copyObject(dashboard["dashboard"].(map[string]any)), stringValue(dashboard, "folderUid"), true
+}
+
+// EditDashboard modifies a dashboard stored in the fake Grafana, as a user
+// would in the UI
+func (s *Server) EditDashboard(uid string, user string, changes map[string]any) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stored := s.dashboards[uid]
+	dashboard := stored["dashboard"].(map[string]any)
+	for key, value := range changes {
+		dashboard[key] = value
+	}
+	dashboard["version"] = int64Value(dashboard, "version") + 1
+	stored["updatedBy"] = user
+	stored["updated"] = time.Now().UTC().Format(time.RFC3339)
+}
+
+// SetDashboardUpdated changes the time a dashboard stored in the fake Grafana
+// was last saved
+func (s *Server) SetDashboardUpdated(uid string, updated time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.dashboards[uid]["updated"] = updated.UTC().Format(time.RFC3339)
+}
+
+// ViewDashboard records a view of a dashboard, enabling the usage insights
+// of Grafana Enterprise in the fake Grafana
+func (s *Server) ViewDashboard(uid string, at time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.dashboardViews == nil {
+		s.dashboardViews = map[string]time.Time{}
+	}
+	s.dashboardViews[uid] = at
+}
+
+// DashboardVersionMessage returns the message of the latest version of a
+// dashboard stored in the fake Grafana
+func (s *Server) DashboardVersionMessage(uid string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return stringValue(s.dashboards[uid], "message")
+}
+
+// Datasource returns a datasource stored in the fake Grafana
+func (s *Server) Datasource(uid string) (map[string]any, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	datasource, found := s.datasources[uid]
+	return copyObject(datasource), found
+}
+
+// AddUser registers a user of the organization in the fake Grafana
+func (s *Server) AddUser(login string, email string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	id := s.newID()
+	s.users[id] = map[string]any{"userId": id, "login": login, "email": email, "role": "Viewer"}
+}
+
+// Team returns a team stored in the fake Grafana, by name, and the logins of
+// its members
+func (s *Server) Team(name string) (map[string]any, []string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	team := s.findTeam(name)
+	if team == nil {
+		return nil, nil, false
+	}
+	members := []string{}
+	for _, userID := range s.teamMembers[int64Value(team, "id")] {
+		members = append(members, stringValue(s.users[userID], "login"))
+	}
+	sort.Strings(members)
+	return copyObject(team), members, true
+}
+
+// ServiceAccount returns a service account stored in the fake Grafana, by
+// name, and the names of its tokens
+func (s *Server) ServiceAccount(name string) (map[string]any, []string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	account := s.findServiceAccount(name)
+	if account == nil {
+		return nil, nil, false
+	}
+	tokens := []string{}
+	for _, token := range s.tokens[int64Value(account, "id")] {
+		tokens = append(tokens, stringValue(token, "name"))
+	}
+	return copyObject(account), tokens, true
+}
+
+// Annotations returns the annotations stored in the fake Grafana, oldest
+// first
+func (s *Server) Annotations() []map[string]any {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	annotations := []map[string]any{}
+	for _, annotation := range s.annotations {
+		annotations = append(annotations, copyObject(annotation))
+	}
+	sort.Slice(annotations, func(i, j int) bool {
+		return int64Value(annotations[i], "id") < int64Value(annotations[j], "id")
+	})
+	return annotations
+}
+
+// Report returns a report stored in the fake Grafana, by name
+func (s *Server) Report(name string) (map[string]any, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, report := range s.reports {
+		if stringValue(report, "name") == name {
+			return copyObject(report), true
+		}
+	}
+	return nil, false
+}
+
+// AlertRule returns an alert rule stored in the fake Grafana
+func (s *Server) AlertRule(uid string) (map[string]any, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rule, found := s.alertRules[uid]
+	return copyObject(rule), found
+}
+
+// AddMuteTiming registers a mute timing in the fake Grafana
+func (s *Server) AddMuteTiming(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.muteTimings[name] = map[string]any{"name": name, "time_intervals": []any{}}
+}
+
+// SetFiringAlerts sets the number of alerts an alert rule fires in the fake
+// Grafana
+func (s *Server) SetFiringAlerts(ruleUID string, count int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.firingAlerts[ruleUID] = count
+}
+
+func (s *Server) getHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"database": "ok", "version": s.grafanaVersion})
+}
+
+func (s *Server) getFrontendSettings(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"buildInfo":              map[string]any{"version": s.grafanaVersion},
+		"featureToggles":         s.featureToggles,
+		"unifiedAlertingEnabled": s.unifiedAlerting,
+	})
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	hits := []map[string]any{}
+	if searchType := query.Get("type"); searchType == "" || searchType == "dash-folder" {
+		for uid, folder := range s.folders {
+			hit := map[string]any{
+				"uid":   uid,
+				"title": folder["title"],
+				"type":  "dash-folder",
+			}
+			if parentUID, _ := folder["parentUid"].(string); parentUID != "" {
+				hit["folderUid"] = parentUID
+			}
+			hits = append(hits, hit)
+		}
+	}
+	if searchType := query.Get("type"); searchType == "" || searchType == "dash-db" {
+		for uid, dashboard := range s.dashboards {
+			tags, _ := dashboard["dashboard"].(map[string]any)["tags"].([]any)
+			if !hasTags(tags, query["tag"]) {
+				continue
+			}
+			hit := map[string]any{
+				"uid":       uid,
+				"title":     dashboard["dashboard"].(map[string]any)["title"],
+				"type":      "dash-db",
+				"folderUid": dashboard["folderUid"],
+				"tags":      tags,
+			}
+			if query.Get("sort") == "viewed-recently-desc" && s.dashboardViews != nil {
+				hit["sortMeta"] = int64(0)
+				if viewed, found := s.dashboardViews[uid]; found {
+					hit["sortMeta"] = viewed.UnixMilli()
+				}
+			}
+			hits = append(hits, hit)
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i]["uid"].(string) < hits[j]["uid"].(string)
+	})
+
+	writeJSON(w, http.StatusOK, paginate(hits, query.Get("limit"), query.Get("page")))
+}
+
+func (s *Server) listSortOptions(w http.ResponseWriter, _ *http.Request) {
+	options := []map[string]any{
+		{"name": "alpha-asc", "displayName": "Alphabetically (A–Z)"},
+		{"name": "alpha-desc", "displayName": "Alphabetically (Z–A)"},
+	}
+	if s.dashboardViews != nil {
+		options = append(options, map[string]any{"name": "viewed-recently-desc", "displayName": "Recently viewed", "meta": "last viewed"})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"sortOptions": options})
+}
+
+// hasTags tells whether tags include every wanted tag, as Grafana filters
+// searches
+func hasTags(tags []any, wanted []string) bool {
+	for _, tag := range wanted {
+		if !slices.Contains(tags, any(tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+func paginate(hits []map[string]any, limitParam string, pageParam string) []map[string]any {
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit <= 0 {
+		limit = 1000
+	}
+	page, err := strconv.Atoi(pageParam)
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	start := (page - 1) * limit
+	if start >= len(hits) {
+		return []map[string]any{}
+	}
+	end := min(start+limit, len(hits))
+
+	return hits[start:end]
+}
+
+func (s *Server) listFolders(w http.ResponseWriter, _ *http.Request) {
+	folders := []map[string]any{}
+	for _, folder := range s.folders {
+		folders = append(folders, map[string]any{
+			"id":        folder["id"],
+			"uid":       folder["uid"],
+			"title":     folder["title"],
+			"parentUid": folder["parentUid"],
+		})
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i]["uid"].(string) < folders[j]["uid"].(string)
+	})
+
+	writeJSON(w, http.StatusOK, folders)
+}
+
+func (s *Server) createFolder(w http.ResponseWriter, r *http.Request) {
+	command := map[string]any{}
+	if err := readJSON(r, &command); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	uid := stringValue(command, "uid")
+	if uid == "" {
+		uid = s.newUID()
+	}
+	if _, exists := s.folders[uid]; exists {
+		writeMessage(w, http.StatusConflict, "a folder with the same uid already exists")
+		return
+	}
+	parentUID := stringValue(command, "parentUid")
+	if _, exists := s.folders[parentUID]; parentUID != "" && !exists {
+		writeMessage(w, http.StatusNotFound, "parent folder not found")
+		return
+	}
+
+	folder := map[string]any{
+		"id":        s.newID(),
+		"uid":       uid,
+		"title":     stringValue(command, "title"),
+		"url":       "/dashboards/f/" + uid,
+		"version":   1,
+		"parentUid": parentUID,
+	}
+	s.folders[uid] = folder
+
+	writeJSON(w, http.StatusOK, folder)
+}
+
+func (s *Server) getFolder(w http.ResponseWriter, r *http.Request) {
+	folder, found := s.folders[r.PathValue("uid")]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "folder not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, folder)
+}
+
+func (s *Server) getFolderByID(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	for _, folder := range s.folders {
+		if int64Value(folder, "id") == id {
+			writeJSON(w, http.StatusOK, folder)
+			return
+		}
+	}
+
+	writeMessage(w, http.StatusNotFound, "folder not found")
+}
+
+func (s *Server) updateFolder(w http.ResponseWriter, r *http.Request) {
+	folder, found := s.folders[r.PathValue("uid")]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "folder not found")
+		return
+	}
+
+	command := map[string]any{}
+	if err := readJSON(r, &command); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if title := stringValue(command, "title"); title != "" {
+		folder["title"] = title
+	}
+	folder["version"] = int64Value(folder, "version") + 1
+
+	writeJSON(w, http.StatusOK, folder)
+}
+
+func (s *Server) moveFolder(w http.ResponseWriter, r *http.Request) {
+	folder, found := s.folders[r.PathValue("uid")]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "folder not found")
+		return
+	}
+
+	command := map[string]any{}
+	if err := readJSON(r, &command); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	parentUID := stringValue(command, "parentUid")
+	if _, exists := s.folders[parentUID]; parentUID != "" && !exists {
+		writeMessage(w, http.StatusNotFound, "parent folder not found")
+		return
+	}
+	folder["parentUid"] = parentUID
+
+	writeJSON(w, http.StatusOK, folder)
+}
+
+func (s *Server) getHomeDashboard(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"dashboard": map[string]any{"title": "Home"},
+		"meta":      map[string]any{"isHome": true},
+	})
+}
+
+func (s *Server) getDashboard(w http.ResponseWriter, r *http.Request) {
+	dashboard, found := s.dashboards[r.PathValue("uid")]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "Dashboard not found")
+		return
+	}
+
+	folderUID := stringValue(dashboard, "folderUid")
+	meta := map[string]any{
+		"folderUid": folderUID,
+		"version":   dashboard["dashboard"].(map[string]any)["version"],
+		"updatedBy": dashboard["updatedBy"],
+		"updated":   dashboard["updated"],
+	}
+	if folder, found := s.folders[folderUID]; found {
+		meta["folderId"] = folder["id"]
+		meta["folderTitle"] = folder["title"]
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"dashboard": dashboard["dashboard"],
+		"meta":      meta,
+	})
+}
+
+func (s *Server) saveDashboard(w http.ResponseWriter, r *http.Request) {
+	command := map[string]any{}
+	if err := readJSON(r, &command); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	dashboard, _ := command["dashboard"].(map[string]any)
+	if dashboard == nil {
+		writeMessage(w, http.StatusBadRequest, "missing dashboard")
+		return
+	}
+
+	// Grafana accepts the folder either by UID or by (legacy) ID
+	folderUID := stringValue(command, "folderUid")
+	if folderID := int64Value(command, "folderId"); folderUID == "" && folderID != 0 {
+		for uid, folder := range s.folders {
+			if int64Value(folder, "id") == folderID {
+				folderUID = uid
+			}
+		}
+	}
+	if folderUID == "" {
+		folderUID = generalFolderUID
+	}
+	if _, exists := s.folders[folderUID]; folderUID != generalFolderUID && !exists {
+		writeMessage(w, http.StatusBadRequest, "folder not found")
+		return
+	}
+
+	uid := stringValue(dashboard, "uid")
+	if uid == "" {
+		uid = s.newUID()
+	}
+	existing, exists := s.dashboards[uid]
+	overwrite, _ := command["overwrite"].(bool)
+	if exists && !overwrite && int64Value(dashboard, "version") != int64Value(existing["dashboard"].(map[string]any), "version") {
+		writeJSON(w, http.StatusPreconditionFailed, map[string]any{
+			"status":  "version-mismatch",
+			"message": "The dashboard has been changed by someone else",
+		})
+		return
+	}
+
+	id, version := s.newID(), int64(1)
+	if exists {
+		stored := existing["dashboard"].(map[string]any)
+		id, version = int64Value(stored, "id"), int64Value(stored, "version")+1
+	}
+	dashboard["uid"] = uid
+	dashboard["id"] = id
+	dashboard["version"] = version
+
+	s.dashboards[uid] = map[string]any{
+		"dashboard": dashboard,
+		"folderUid": folderUID,
+		"message":   stringValue(command, "message"),
+		"updatedBy": "admin",
+		"updated":   time.Now().UTC().Format(time.RFC3339),
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":        id,
+		"uid":       uid,
+		"status":    "success",
+		"version":   version,
+		"url":       "/d/" + uid,
+		"folderUid": folderUID,
+	})
+}
+
+func (s *Server) deleteDashboard(w http.ResponseWriter, r *http.Request) {
+	uid := r.PathValue("uid")
+	dashboard, found := s.dashboards[uid]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "Dashboard not found")
+		return
+	}
+	delete(s.dashboards, uid)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"title":   dashboard["dashboard"].(map[string]any)["title"],
+		"message": "Dashboard deleted",
+	})
+}
+
+// renderPanel renders a panel as a PNG image filled with a color derived
+// from the panel definition, so that images only change with panels.
+func (s *Server) renderPanel(w http.ResponseWriter, r *http.Request) {
+	dashboard, found := s.dashboards[r.PathValue("uid")]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "Dashboard not found")
+		return
+	}
+
+	query := r.URL.Query()
+	panel := findPanel(dashboard["dashboard"].(map[string]any), query.Get("panelId"))
+	if panel == nil {
+		writeMessage(w, http.StatusNotFound, "Panel not found")
+		return
+	}
+	width, _ := strconv.Atoi(query.Get("width"))
+	height, _ := strconv.Atoi(query.Get("height"))
+	if width <= 0 || height <= 0 {
+		width, height = 100, 50
+	}
+
+	definition, _ := json.Marshal(panel)
+	sum := sha256.Sum256(definition)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: color.RGBA{R: sum[0], G: sum[1], B: sum[2], A: 255}}, image.Point{}, draw.Src)
+
+	w.Header().Set("Content-Type", "image/png")
+	_ = png.Encode(w, img)
+}
+
+// findPanel returns the panel of the given ID, including the ones of
+// collapsed rows
+func findPanel(parent map[string]any, id string) map[string]any {
+	panels, _ := parent["panels"].([]any)
+	for _, item := range panels {
+		panel, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if strconv.FormatInt(int64Value(panel, "id"), 10) == id {
+			return panel
+		}
+		if found := findPanel(panel, id); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) createSnapshot(w http.ResponseWriter, _ *http.Request) {
+	key := s.newUID()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":        s.newID(),
+		"key":       key,
+		"deleteKey": key,
+		"url":       s.URL + "/dashboard/snapshot/" + key,
+		"deleteUrl": s.URL + "/api/snapshots-delete/" + key,
+	})
+}
+
+func (s *Server) listDatasources(w http.ResponseWriter, _ *http.Request) {
+	datasources := []map[string]any{}
+	for _, datasource := range s.datasources {
+		datasources = append(datasources, datasource)
+	}
+	sort.Slice(datasources, func(i, j int) bool {
+		return int64Value(datasources[i], "id") < int64Value(datasources[j], "id")
+	})
+
+	writeJSON(w, http.StatusOK, datasources)
+}
+
+func (s *Server) addDatasource(w http.ResponseWriter, r *http.Request) {
+	datasource := map[string]any{}
+	if err := readJSON(r, &datasource); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	uid := stringValue(datasource, "uid")
+	if uid == "" {
+		uid = s.newUID()
+	}
+	for _, existing := range s.datasources {
+		if existing["uid"] == uid || existing["name"] == datasource["name"] {
+			writeMessage(w, http.StatusConflict, "data source with the same name or uid already exists")
+			return
+		}
+	}
+
+	delete(datasource, "secureJsonData")
+	datasource["id"] = s.newID()
+	datasource["uid"] = uid
+	datasource["version"] = 1
+	s.datasources[uid] = datasource
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":         datasource["id"],
+		"name":       datasource["name"],
+		"message":    "Datasource added",
+		"datasource": datasource,
+	})
+}
+
+func (s *Server) getDatasourceByUID(w http.ResponseWriter, r *http.Request) {
+	datasource, found := s.datasources[r.PathValue("uid")]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "Data source not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, datasource)
+}
+
+func (s *Server) getDatasourceByName(w http.ResponseWriter, r *http.Request) {
+	for _, datasource := range s.datasources {
+		if datasource["name"] == r.PathValue("name") {
+			writeJSON(w, http.StatusOK, datasource)
+			return
+		}
+	}
+
+	writeMessage(w, http.StatusNotFound, "Data source not found")
+}
+
+func (s *Server) updateDatasource(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+	var existing map[string]any
+	for _, datasource := range s.datasources {
+		if int64Value(datasource, "id") == id {
+			existing = datasource
+		}
+	}
+	if existing == nil {
+		writeMessage(w, http.StatusNotFound, "Data source not found")
+		return
+	}
+
+	datasource := map[string]any{}
+	if err := readJSON(r, &datasource); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	delete(datasource, "secureJsonData")
+	datasource["id"] = id
+	datasource["uid"] = existing["uid"]
+	datasource["version"] = int64Value(existing, "version") + 1
+	s.datasources[stringValue(existing, "uid")] = datasource
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":         id,
+		"name":       datasource["name"],
+		"message":    "Datasource updated",
+		"datasource": datasource,
+	})
+}
+
+func (s *Server) listLibraryElements(w http.ResponseWriter, _ *http.Request) {
+	elements := []map[string]any{}
+	for _, element := range s.libraryElements {
+		elements = append(elements, element)
+	}
+	sort.Slice(elements, func(i, j int) bool {
+		return elements[i]["uid"].(string) < elements[j]["uid"].(string)
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"result": map[string]any{
+			"elements":   elements,
+			"page":       1,
+			"perPage":    len(elements),
+			"totalCount": len(elements),
+		},
+	})
+}
+
+func (s *Server) createLibraryElement(w http.ResponseWriter, r *http.Request) {
+	element := map[string]any{}
+	if err := readJSON(r, &element); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	uid := stringValue(element, "uid")
+	if uid == "" {
+		uid = s.newUID()
+	}
+	if _, exists := s.libraryElements[uid]; exists {
+		writeMessage(w, http.StatusBadRequest, "library element with that uid already exists")
+		return
+	}
+
+	element["id"] = s.newID()
+	element["uid"] = uid
+	element["version"] = 1
+	s.libraryElements[uid] = element
+
+	writeJSON(w, http.StatusOK, map[string]any{"result": element})
+}
+
+func (s *Server) getLibraryElement(w http.ResponseWriter, r *http.Request) {
+	element, found := s.libraryElements[r.PathValue("uid")]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "library element could not be found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"result": element})
+}
+
+func (s *Server) updateLibraryElement(w http.ResponseWriter, r *http.Request) {
+	existing, found := s.libraryElements[r.PathValue("uid")]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "library element could not be found")
+		return
+	}
+
+	element := map[string]any{}
+	if err := readJSON(r, &element); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	element["id"] = existing["id"]
+	element["uid"] = existing["uid"]
+	element["version"] = int64Value(existing, "version") + 1
+	s.libraryElements[stringValue(existing, "uid")] = element
+
+	writeJSON(w, http.StatusOK, map[string]any{"result": element})
+}
+
+func (s *Server) deleteLibraryElement(w http.ResponseWriter, r *http.Request) {
+	uid := r.PathValue("uid")
+	if _, found := s.libraryElements[uid]; !found {
+		writeMessage(w, http.StatusNotFound, "library element could not be found")
+		return
+	}
+	if len(s.libraryElementConnections(uid)) > 0 {
+		writeMessage(w, http.StatusForbidden, "the library element has connections")
+		return
+	}
+	delete(s.libraryElements, uid)
+
+	writeJSON(w, http.StatusOK, map[string]any{"message": "Library element deleted"})
+}
+
+// listLibraryElementConnections lists the dashboards using an element, as
+// Grafana connects them when dashboards are saved
+func (s *Server) listLibraryElementConnections(w http.ResponseWriter, r *http.Request) {
+	uid := r.PathValue("uid")
+	element, found := s.libraryElements[uid]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "library element could not be found")
+		return
+	}
+
+	connections := []map[string]any{}
+	for _, dashboardUID := range s.libraryElementConnections(uid) {
+		dashboard := s.dashboards[dashboardUID]["dashboard"].(map[string]any)
+		connections = append(connections, map[string]any{
+			"elementId":     element["id"],
+			"kind":          1,
+			"connectionId":  dashboard["id"],
+			"connectionUid": dashboardUID,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"result": connections})
+}
+
+// libraryElementConnections returns the UIDs of the dashboards having a
+// panel of an element, sorted
+func (s *Server) libraryElementConnections(uid string) []string {
+	var uses func(parent map[string]any) bool
+	uses = func(parent map[string]any) bool {
+		panels, _ := parent["panels"].([]any)
+		for _, item := range panels {
+			panel, _ := item.(map[string]any)
+			libraryPanel, _ := panel["libraryPanel"].(map[string]any)
+			if stringValue(libraryPanel, "uid") == uid || uses(panel) {
+				return true
+			}
+		}
+		return false
+	}
+
+	dashboards := []string{}
+	for dashboardUID, dashboard := range s.dashboards {
+		if uses(dashboard["dashboard"].(map[string]any)) {
+			dashboards = append(dashboards, dashboardUID)
+		}
+	}
+	sort.Strings(dashboards)
+	return dashboards
+}
+
+func (s *Server) listAlertRules(w http.ResponseWriter, _ *http.Request) {
+	rules := []map[string]any{}
+	for _, rule := range s.alertRules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i]["uid"].(string) < rules[j]["uid"].(string)
+	})
+
+	writeJSON(w, http.StatusOK, rules)
+}
+
+func (s *Server) createAlertRule(w http.ResponseWriter, r *http.Request) {
+	rule := map[string]any{}
+	if err := readJSON(r, &rule); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	uid := stringValue(rule, "uid")
+	if uid == "" {
+		uid = s.newUID()
+	}
+	if _, exists := s.alertRules[uid]; exists {
+		writeMessage(w, http.StatusConflict, "an alert rule with the same uid already exists")
+		return
+	}
+
+	rule["id"] = s.newID()
+	rule["uid"] = uid
+	s.alertRules[uid] = rule
+
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+func (s *Server) getAlertRule(w http.ResponseWriter, r *http.Request) {
+	rule, found := s.alertRules[r.PathValue("uid")]
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rule)
+}
+
+func (s *Server) updateAlertRule(w http.ResponseWriter, r *http.Request) {
+	existing, found := s.alertRules[r.PathValue("uid")]
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	rule := map[string]any{}
+	if err := readJSON(r, &rule); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	rule["id"] = existing["id"]
+	rule["uid"] = existing["uid"]
+	s.alertRules[stringValue(existing, "uid")] = rule
+
+	writeJSON(w, http.StatusOK, rule)
+}
+
+func (s *Server) getAlertRuleGroup(w http.ResponseWriter, r *http.Request) {
+	folderUID, title := r.PathValue("folder"), r.PathValue("group")
+
+	rules := []map[string]any{}
+	for _, rule := range s.alertRules {
+		if rule["folderUID"] == folderUID && rule["ruleGroup"] == title {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i]["uid"].(string) < rules[j]["uid"].(string)
+	})
+
+	group := map[string]any{
+		"title":     title,
+		"folderUid": folderUID,
+		"interval":  int64(60),
+		"rules":     rules,
+	}
+	if existing, found := s.alertRuleGroups[folderUID+"/"+title]; found {
+		group["interval"] = existing["interval"]
+	}
+
+	writeJSON(w, http.StatusOK, group)
+}
+
+func (s *Server) updateAlertRuleGroup(w http.ResponseWriter, r *http.Request) {
+	folderUID, title := r.PathValue("folder"), r.PathValue("group")
+
+	group := map[string]any{}
+	if err := readJSON(r, &group); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	rules, _ := group["rules"].([]any)
+	for _, item := range rules {
+		rule, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		uid := stringValue(rule, "uid")
+		if uid == "" {
+			uid = s.newUID()
+		}
+		rule["uid"] = uid
+		rule["folderUID"] = folderUID
+		rule["ruleGroup"] = title
+		if existing, found := s.alertRules[uid]; found {
+			rule["id"] = existing["id"]
+		} else {
+			rule["id"] = s.newID()
+		}
+		s.alertRules[uid] = rule
+	}
+
+	s.alertRuleGroups[folderUID+"/"+title] = map[string]any{"interval": group["interval"]}
+
+	writeJSON(w, http.StatusOK, group)
+}
+
+func (s *Server) listMuteTimings(w http.ResponseWriter, _ *http.Request) {
+	muteTimings := []map[string]any{}
+	for _, muteTiming := range s.muteTimings {
+		muteTimings = append(muteTimings, muteTiming)
+	}
+	sort.Slice(muteTimings, func(i, j int) bool {
+		return muteTimings[i]["name"].(string) < muteTimings[j]["name"].(string)
+	})
+
+	writeJSON(w, http.StatusOK, muteTimings)
+}
+
+func (s *Server) listContactPoints(w http.ResponseWriter, _ *http.Request) {
+	contactPoints := []map[string]any{}
+	for _, contactPoint := range s.contactPoints {
+		contactPoints = append(contactPoints, contactPoint)
+	}
+	sort.Slice(contactPoints, func(i, j int) bool {
+		return contactPoints[i]["uid"].(string) < contactPoints[j]["uid"].(string)
+	})
+
+	writeJSON(w, http.StatusOK, contactPoints)
+}
+
+func (s *Server) createContactPoint(w http.ResponseWriter, r *http.Request) {
+	contactPoint := map[string]any{}
+	if err := readJSON(r, &contactPoint); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	uid := stringValue(contactPoint, "uid")
+	if uid == "" {
+		uid = s.newUID()
+	}
+	if _, exists := s.contactPoints[uid]; exists {
+		writeMessage(w, http.StatusBadRequest, "a contact point with the same uid already exists")
+		return
+	}
+
+	contactPoint["uid"] = uid
+	s.contactPoints[uid] = contactPoint
+
+	writeJSON(w, http.StatusAccepted, contactPoint)
+}
+
+func (s *Server) updateContactPoint(w http.ResponseWriter, r *http.Request) {
+	uid := r.PathValue("uid")
+	if _, found := s.contactPoints[uid]; !found {
+		writeMessage(w, http.StatusNotFound, "contact point not found")
+		return
+	}
+
+	contactPoint := map[string]any{}
+	if err := readJSON(r, &contactPoint); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	contactPoint["uid"] = uid
+	s.contactPoints[uid] = contactPoint
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"message": "contactpoint updated"})
+}
+
+func (s *Server) getPolicy(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.policy)
+}
+
+func (s *Server) updatePolicy(w http.ResponseWriter, r *http.Request) {
+	policy := map[string]any{}
+	if err := readJSON(r, &policy); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	s.policy = policy
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"message": "policies updated"})
+}
+
+func (s *Server) listOrgUsers(w http.ResponseWriter, _ *http.Request) {
+	users := []map[string]any{}
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return int64Value(users[i], "userId") < int64Value(users[j], "userId")
+	})
+
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) findTeam(name string) map[string]any {
+	for _, team := range s.teams {
+		if stringValue(team, "name") == name {
+			return team
+		}
+	}
+	return nil
+}
+
+// teamFromPath returns the team whose ID is in the path of a request, writing
+// an error when not found
+func (s *Server) teamFromPath(w http.ResponseWriter, r *http.Request) map[string]any {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	team, found := s.teams[id]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "Team not found")
+		return nil
+	}
+	return team
+}
+
+func (s *Server) searchTeams(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	teams := []map[string]any{}
+	for id, team := range s.teams {
+		if name := query.Get("name"); name != "" && stringValue(team, "name") != name {
+			continue
+		}
+		if contains := query.Get("query"); contains != "" && !strings.Contains(stringValue(team, "name"), contains) {
+			continue
+		}
+		team["memberCount"] = len(s.teamMembers[id])
+		teams = append(teams, team)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		return int64Value(teams[i], "id") < int64Value(teams[j], "id")
+	})
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	perPage, _ := strconv.Atoi(query.Get("perpage"))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"teams":      paginate(teams, query.Get("perpage"), query.Get("page")),
+		"totalCount": len(teams),
+		"page":       page,
+		"perPage":    perPage,
+	})
+}
+
+func (s *Server) createTeam(w http.ResponseWriter, r *http.Request) {
+	var command struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	if err := readJSON(r, &command); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if command.Name == "" {
+		writeMessage(w, http.StatusBadRequest, "team name is required")
+		return
+	}
+	if s.findTeam(command.Name) != nil {
+		writeMessage(w, http.StatusConflict, "Team name taken")
+		return
+	}
+
+	id := s.newID()
+	s.teams[id] = map[string]any{
+		"id":        id,
+		"uid":       s.newUID(),
+		"orgId":     1,
+		"name":      command.Name,
+		"email":     command.Email,
+		"avatarUrl": "/avatar/" + strconv.FormatInt(id, 10),
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"teamId": id, "message": "Team created"})
+}
+
+func (s *Server) getTeam(w http.ResponseWriter, r *http.Request) {
+	team := s.teamFromPath(w, r)
+	if team == nil {
+		return
+	}
+	team["memberCount"] = len(s.teamMembers[int64Value(team, "id")])
+
+	writeJSON(w, http.StatusOK, team)
+}
+
+func (s *Server) updateTeam(w http.ResponseWriter, r *http.Request) {
+	team := s.teamFromPath(w, r)
+	if team == nil {
+		return
+	}
+
+	// Grafana reads the fields of the command regardless of their case
+	var command struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	if err := readJSON(r, &command); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if other := s.findTeam(command.Name); other != nil && int64Value(other, "id") != int64Value(team, "id") {
+		writeMessage(w, http.StatusConflict, "Team name taken")
+		return
+	}
+	team["name"] = command.Name
+	team["email"] = command.Email
+
+	writeJSON(w, http.StatusOK, map[string]any{"message": "Team updated"})
+}
+
+func (s *Server) deleteTeam(w http.ResponseWriter, r *http.Request) {
+	team := s.teamFromPath(w, r)
+	if team == nil {
+		return
+	}
+	id := int64Value(team, "id")
+	delete(s.teams, id)
+	delete(s.teamMembers, id)
+
+	writeJSON(w, http.StatusOK, map[string]any{"message": "Team deleted"})
+}
+
+func (s *Server) listTeamMembers(w http.ResponseWriter, r *http.Request) {
+	team := s.teamFromPath(w, r)
+	if team == nil {
+		return
+	}
+
+	members := []map[string]any{}
+	for _, userID := range s.teamMembers[int64Value(team, "id")] {
+		user := s.users[userID]
+		members = append(members, map[string]any{
+			"orgId":  1,
+			"teamId": team["id"],
+			"userId": userID,
+			"login":  user["login"],
+			"email":  user["email"],
+		})
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
+
+func (s *Server) addTeamMember(w http.ResponseWriter, r *http.Request) {
+	team := s.teamFromPath(w, r)
+	if team == nil {
+		return
+	}
+
+	var command struct {
+		UserID int64 `json:"userId"`
+	}
+	if err := readJSON(r, &command); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if _, found := s.users[command.UserID]; !found {
+		writeMessage(w, http.StatusNotFound, "User not found")
+		return
+	}
+	id := int64Value(team, "id")
+	if slices.Contains(s.teamMembers[id], command.UserID) {
+		writeMessage(w, http.StatusBadRequest, "User is already added to this team")
+		return
+	}
+	s.teamMembers[id] = append(s.teamMembers[id], command.UserID)
+
+	writeJSON(w, http.StatusOK, map[string]any{"message": "Member added to Team"})
+}
+
+func (s *Server) removeTeamMember(w http.ResponseWriter, r *http.Request) {
+	team := s.teamFromPath(w, r)
+	if team == nil {
+		return
+	}
+
+	userID, _ := strconv.ParseInt(r.PathValue("userId"), 10, 64)
+	id := int64Value(team, "id")
+	index := slices.Index(s.teamMembers[id], userID)
+	if index < 0 {
+		writeMessage(w, http.StatusNotFound, "Team member not found")
+		return
+	}
+	s.teamMembers[id] = slices.Delete(s.teamMembers[id], index, index+1)
+
+	writeJSON(w, http.StatusOK, map[string]any{"message": "Team Member removed"})
+}
+
+func (s *Server) findServiceAccount(name string) map[string]any {
+	for _, account := range s.serviceAccounts {
+		if stringValue(account, "name") == name {
+			return account
+		}
+	}
+	return nil
+}
+
+// serviceAccountFromPath returns the service account whose ID is in the path
+// of a request, writing an error when not found
+func (s *Server) serviceAccountFromPath(w http.ResponseWriter, r *http.Request) map[string]any {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	account, found := s.serviceAccounts[id]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "service account not found")
+		return nil
+	}
+	return account
+}
+
+func (s *Server) searchServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	accounts := []map[string]any{}
+	for id, account := range s.serviceAccounts {
+		if !strings.Contains(stringValue(account, "name"), query.Get("query")) {
+			continue
+		}
+		account["tokens"] = len(s.tokens[id])
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return int64Value(accounts[i], "id") < int64Value(accounts[j], "id")
+	})
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	perPage, _ := strconv.Atoi(query.Get("perpage"))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"serviceAccounts": paginate(accounts, query.Get("perpage"), query.Get("page")),
+		"totalCount":      len(accounts),
+		"page":            page,
+		"perPage":         perPage,
+	})
+}
+
+func (s *Server) createServiceAccount(w http.ResponseWriter, r *http.Request) {
+	var command struct {
+		Name       string `json:"name"`
+		Role       string `json:"role"`
+		IsDisabled bool   `json:"isDisabled"`
+	}
+	if err := readJSON(r, &command); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if command.Name == "" {
+		writeMessage(w, http.StatusBadRequest, "service account name is required")
+		return
+	}
+	if s.findServiceAccount(command.Name) != nil {
+		writeMessage(w, http.StatusBadRequest, "service account already exists")
+		return
+	}
+	if command.Role == "" {
+		command.Role = "Viewer"
+	}
+
+	id := s.newID()
+	account := map[string]any{
+		"id":         id,
+		"orgId":      1,
+		"name":       command.Name,
+		"login":      "sa-" + command.Name,
+		"role":       command.Role,
+		"isDisabled": command.IsDisabled,
+	}
+	s.serviceAccounts[id] = account
+
+	writeJSON(w, http.StatusCreated, account)
+}
+
+func (s *Server) updateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	account := s.serviceAccountFromPath(w, r)
+	if account == nil {
+		return
+	}
+
+	// as Grafana, fields left out are unchanged
+	var command struct {
+		Name       string `json:"name"`
+		Role       string `json:"role"`
+		IsDisabled *bool  `json:"isDisabled"`
+	}
+	if err := readJSON(r, &command); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if command.Name != "" {
+		account["name"] = command.Name
+	}
+	if command.Role != "" {
+		account["role"] = command.Role
+	}
+	if command.IsDisabled != nil {
+		account["isDisabled"] = *command.IsDisabled
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":             account["id"],
+		"name":           account["name"],
+		"message":        "Service account updated",
+		"serviceaccount": account,
+	})
+}
+
+func (s *Server) deleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	account := s.serviceAccountFromPath(w, r)
+	if account == nil {
+		return
+	}
+	id := int64Value(account, "id")
+	delete(s.serviceAccounts, id)
+	delete(s.tokens, id)
+
+	writeJSON(w, http.StatusOK, map[string]any{"message": "Service account deleted"})
+}
+
+func (s *Server) listTokens(w http.ResponseWriter, r *http.Request) {
+	account := s.serviceAccountFromPath(w, r)
+	if account == nil {
+		return
+	}
+
+	tokens := []map[string]any{}
+	for _, token := range s.tokens[int64Value(account, "id")] {
+		tokens = append(tokens, map[string]any{"id": token["id"], "name": token["name"]})
+	}
+
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+func (s *Server) createToken(w http.ResponseWriter, r *http.Request) {
+	account := s.serviceAccountFromPath(w, r)
+	if account == nil {
+		return
+	}
+
+	var command struct {
+		Name          string `json:"name"`
+		SecondsToLive int64  `json:"secondsToLive"`
+	}
+	if err := readJSON(r, &command); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	accountID := int64Value(account, "id")
+	for _, token := range s.tokens[accountID] {
+		if stringValue(token, "name") == command.Name {
+			writeMessage(w, http.StatusConflict, "service account token with given name already exists in the organization")
+			return
+		}
+	}
+
+	id := s.newID()
+	token := map[string]any{"id": id, "name": command.Name, "key": fmt.Sprintf("glsa_grizzlytest_%d", id)}
+	s.tokens[accountID] = append(s.tokens[accountID], token)
+
+	writeJSON(w, http.StatusOK, token)
+}
+
+func (s *Server) deleteToken(w http.ResponseWriter, r *http.Request) {
+	account := s.serviceAccountFromPath(w, r)
+	if account == nil {
+		return
+	}
+
+	tokenID, _ := strconv.ParseInt(r.PathValue("tokenId"), 10, 64)
+	accountID := int64Value(account, "id")
+	index := slices.IndexFunc(s.tokens[accountID], func(token map[string]any) bool {
+		return int64Value(token, "id") == tokenID
+	})
+	if index < 0 {
+		writeMessage(w, http.StatusNotFound, "service account token not found")
+		return
+	}
+	s.tokens[accountID] = slices.Delete(s.tokens[accountID], index, index+1)
+
+	writeJSON(w, http.StatusOK, map[string]any{"message": "Service account token deleted"})
+}
+
+// annotationFromPath returns the annotation whose ID is in the path of a
+// request, writing an error when not found
+func (s *Server) annotationFromPath(w http.ResponseWriter, r *http.Request) map[string]any {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	annotation, found := s.annotations[id]
+	if !found {
+		writeMessage(w, http.StatusNotFound, "Annotation not found")
+		return nil
+	}
+	return annotation
+}
+
+// listAnnotations filters annotations as Grafana does: by all the tags given,
+// and by time range, keeping the annotations overlapping it
+func (s *Server) listAnnotations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, _ := strconv.ParseInt(query.Get("from"), 10, 64)
+	to, _ := strconv.ParseInt(query.Get("to"), 10, 64)
+
+	annotations := []map[string]any{}
+	for _, annotation := range s.annotations {
+		tags, _ := annotation["tags"].([]any)
+		hasTag := func(tag string) bool {
+			return slices.ContainsFunc(tags, func(value any) bool { return value == tag })
+		}
+		if !slices.ContainsFunc(query["tags"], func(tag string) bool { return !hasTag(tag) }) &&
+			(from == 0 || int64Value(annotation, "timeEnd") >= from) &&
+			(to == 0 || int64Value(annotation, "time") <= to) {
+			annotations = append(annotations, annotation)
+		}
+	}
+	sort.Slice(annotations, func(i, j int) bool {
+		return int64Value(annotations[i], "time") > int64Value(annotations[j], "time")
+	})
+
+	writeJSON(w, http.StatusOK, annotations)
+}
+
+func (s *Server) createAnnotation(w http.ResponseWriter, r *http.Request) {
+	annotation := map[string]any{}
+	if err := readJSON(r, &annotation); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if stringValue(annotation, "text") == "" {
+		writeMessage(w, http.StatusBadRequest, "Failed to save annotation: text field should not be empty")
+		return
+	}
+	if int64Value(annotation, "timeEnd") == 0 {
+		annotation["timeEnd"] = annotation["time"]
+	}
+	if annotation["tags"] == nil {
+		annotation["tags"] = []any{}
+	}
+
+	id := s.newID()
+	annotation["id"] = id
+	s.annotations[id] = annotation
+
+	writeJSON(w, http.StatusOK, map[string]any{"id": id, "message": "Annotation added"})
+}
+
+func (s *Server) getAnnotation(w http.ResponseWriter, r *http.Request) {
+	annotation := s.annotationFromPath(w, r)
+	if annotation == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, annotation)
+}
+
+func (s *Server) updateAnnotation(w http.ResponseWriter, r *http.Request) {
+	annotation := s.annotationFromPath(w, r)
+	if annotation == nil {
+		return
+	}
+
+	var command struct {
+		Text    string `json:"text"`
+		Tags    []any  `json:"tags"`
+		Time    int64  `json:"time"`
+		TimeEnd int64  `json:"timeEnd"`
+	}
+	if err := readJSON(r, &command); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if command.TimeEnd == 0 {
+		command.TimeEnd = command.Time
+	}
+	annotation["text"] = command.Text
+	annotation["tags"] = command.Tags
+	annotation["time"] = command.Time
+	annotation["timeEnd"] = command.TimeEnd
+
+	writeJSON(w, http.StatusOK, map[string]any{"message": "Annotation updated"})
+}
+
+func (s *Server) deleteAnnotation(w http.ResponseWriter, r *http.Request) {
+	annotation := s.annotationFromPath(w, r)
+	if annotation == nil {
+		return
+	}
+	delete(s.annotations, int64Value(annotation, "id"))
+
+	writeJSON(w, http.StatusOK, map[string]any{"message": "Annotation deleted"})
+}
+
+// reportFromPath returns the report whose ID is in the path of a request,
+// writing an error when not found or when reporting is disabled
+func (s *Server) reportFromPath(w http.ResponseWriter, r *http.Request) map[string]any {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	report, found := s.reports[id]
+	if !s.reporting || !found {
+		writeMessage(w, http.StatusNotFound, "report not found")
+		return nil
+	}
+	return report
+}
+
+// validReport checks a report as Grafana does, writing an error when invalid
+func validReport(w http.ResponseWriter, report map[string]any) bool {
+	dashboards, _ := report["dashboards"].([]any)
+	if stringValue(report, "name") == "" || len(dashboards) == 0 {
+		writeMessage(w, http.StatusBadRequest, "report needs a name and a dashboard")
+		return false
+	}
+	return true
+}
+
+func (s *Server) listReports(w http.ResponseWriter, _ *http.Request) {
+	if !s.reporting {
+		writeMessage(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	reports := []map[string]any{}
+	for _, report := range s.reports {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return int64Value(reports[i], "id") < int64Value(reports[j], "id")
+	})
+
+	writeJSON(w, http.StatusOK, reports)
+}
+
+func (s *Server) createReport(w http.ResponseWriter, r *http.Request) {
+	if !s.reporting {
+		writeMessage(w, http.StatusNotFound, "Not found")
+		return
+	}
+	report := map[string]any{}
+	if err := readJSON(r, &report); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if !validReport(w, report) {
+		return
+	}
+
+	id := s.newID()
+	report["id"] = id
+	report["uid"] = s.newUID()
+	report["orgId"] = 1
+	report["userId"] = 1
+	s.reports[id] = report
+
+	writeJSON(w, http.StatusOK, map[string]any{"id": id, "message": "Report created"})
+}
+
+func (s *Server) getReport(w http.ResponseWriter, r *http.Request) {
+	report := s.reportFromPath(w, r)
+	if report == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) updateReport(w http.ResponseWriter, r *http.Request) {
+	report := s.reportFromPath(w, r)
+	if report == nil {
+		return
+	}
+	update := map[string]any{}
+	if err := readJSON(r, &update); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if !validReport(w, update) {
+		return
+	}
+
+	for _, key := range []string{"id", "uid", "orgId", "userId"} {
+		update[key] = report[key]
+	}
+	s.reports[int64Value(report, "id")] = update
+
+	writeJSON(w, http.StatusOK, map[string]any{"message": "Report updated"})
+}
+
+func (s *Server) deleteReport(w http.ResponseWriter, r *http.Request) {
+	report := s.reportFromPath(w, r)
+	if report == nil {
+		return
+	}
+	delete(s.reports, int64Value(report, "id"))
+
+	writeJSON(w, http.StatusOK, map[string]any{"message": "Report deleted"})
+}
+
+// listAlerts lists the firing alerts, filtered by rule with
+// `__alert_rule_uid__="<uid>"`, the only filter supported
+func (s *Server) listAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts := []map[string]any{}
+	for ruleUID, count := range s.firingAlerts {
+		filter := fmt.Sprintf("__alert_rule_uid__=%q", ruleUID)
+		if len(r.URL.Query()["filter"]) > 0 && !slices.Contains(r.URL.Query()["filter"], filter) {
+			continue
+		}
+		for i := 0; i < count; i++ {
+			alerts = append(alerts, map[string]any{
+				"labels": map[string]any{"__alert_rule_uid__": ruleUID},
+				"status": map[string]any{"state": "active"},
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, alerts)
+}
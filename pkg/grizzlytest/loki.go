@@ -0,0 +1,125 @@
+package grizzlytest
+
+import (
+	"io"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+func (s *Server) registerLoki(mux *http.ServeMux) {
+	s.handle(mux, "GET "+LokiPrefix+"/loki/api/v1/rules", s.listLokiRuleGroups)
+	s.handle(mux, "GET "+LokiPrefix+"/loki/api/v1/rules/{namespace}/{name}", s.getLokiRuleGroup)
+	s.handle(mux, "POST "+LokiPrefix+"/loki/api/v1/rules/{namespace}", s.loadLokiRuleGroup)
+	s.handle(mux, "DELETE "+LokiPrefix+"/loki/api/v1/rules/{namespace}/{name}", s.deleteLokiRuleGroup)
+}
+
+// LokiRuleGroup returns a rule group stored in the fake Loki
+func (s *Server) LokiRuleGroup(namespace string, name string) (map[string]any, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, group := range s.lokiRuleGroups[namespace] {
+		if group["name"] == name {
+			return copyObject(group), true
+		}
+	}
+
+	return nil, false
+}
+
+// writeYAML writes a YAML response, as the ruler of Loki does
+func writeYAML(w http.ResponseWriter, status int, value any) {
+	content, err := yaml.Marshal(value)
+	if err != nil {
+		writeMessage(w, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(status)
+	_, _ = w.Write(content)
+}
+
+func (s *Server) listLokiRuleGroups(w http.ResponseWriter, r *http.Request) {
+	if mimirTenant(r) == "" {
+		writeMessage(w, http.StatusUnauthorized, "no org id")
+		return
+	}
+
+	if len(s.lokiRuleGroups) == 0 {
+		writeMessage(w, http.StatusNotFound, "no rule groups found")
+		return
+	}
+	writeYAML(w, http.StatusOK, s.lokiRuleGroups)
+}
+
+func (s *Server) getLokiRuleGroup(w http.ResponseWriter, r *http.Request) {
+	if mimirTenant(r) == "" {
+		writeMessage(w, http.StatusUnauthorized, "no org id")
+		return
+	}
+
+	for _, group := range s.lokiRuleGroups[r.PathValue("namespace")] {
+		if group["name"] == r.PathValue("name") {
+			writeYAML(w, http.StatusOK, group)
+			return
+		}
+	}
+	writeMessage(w, http.StatusNotFound, "group does not exist")
+}
+
+func (s *Server) loadLokiRuleGroup(w http.ResponseWriter, r *http.Request) {
+	if mimirTenant(r) == "" {
+		writeMessage(w, http.StatusUnauthorized, "no org id")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	group := map[string]any{}
+	if err := yaml.Unmarshal(body, &group); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if stringValue(group, "name") == "" {
+		writeMessage(w, http.StatusBadRequest, "invalid rules config: rule group name must not be empty")
+		return
+	}
+
+	namespace := r.PathValue("namespace")
+	groups := s.lokiRuleGroups[namespace]
+	for i, existing := range groups {
+		if existing["name"] == group["name"] {
+			groups[i] = group
+			writeJSON(w, http.StatusAccepted, map[string]any{})
+			return
+		}
+	}
+	s.lokiRuleGroups[namespace] = append(groups, group)
+
+	writeJSON(w, http.StatusAccepted, map[string]any{})
+}
+
+func (s *Server) deleteLokiRuleGroup(w http.ResponseWriter, r *http.Request) {
+	if mimirTenant(r) == "" {
+		writeMessage(w, http.StatusUnauthorized, "no org id")
+		return
+	}
+
+	namespace := r.PathValue("namespace")
+	groups := s.lokiRuleGroups[namespace]
+	for i, group := range groups {
+		if group["name"] == r.PathValue("name") {
+			s.lokiRuleGroups[namespace] = append(groups[:i], groups[i+1:]...)
+			if len(s.lokiRuleGroups[namespace]) == 0 {
+				delete(s.lokiRuleGroups, namespace)
+			}
+			writeJSON(w, http.StatusAccepted, map[string]any{})
+			return
+		}
+	}
+	writeMessage(w, http.StatusNotFound, "group does not exist")
+}
@@ -0,0 +1,115 @@
+package grizzlytest
+
+import (
+	"io"
+	"net/http"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+func (s *Server) registerMimir(mux *http.ServeMux) {
+	s.handle(mux, "GET "+MimirPrefix+"/prometheus/api/v1/rules", s.listRuleGroups)
+	s.handle(mux, "POST "+MimirPrefix+"/prometheus/config/v1/rules/{namespace}", s.loadRuleGroup)
+}
+
+// RuleGroup returns a rule group stored in the fake Mimir, for the tenant
+// of the context
+func (s *Server) RuleGroup(namespace string, name string) (map[string]any, bool) {
+	return s.TenantRuleGroup(TenantID, namespace, name)
+}
+
+// TenantRuleGroup returns a rule group stored in the fake Mimir for a
+// tenant
+func (s *Server) TenantRuleGroup(tenant string, namespace string, name string) (map[string]any, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, group := range s.ruleGroups[tenant][namespace] {
+		if group["name"] == name {
+			return copyObject(group), true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *Server) listRuleGroups(w http.ResponseWriter, r *http.Request) {
+	tenant := mimirTenant(r)
+	if tenant == "" {
+		writeMessage(w, http.StatusUnauthorized, "no org id")
+		return
+	}
+
+	namespaces := make([]string, 0, len(s.ruleGroups[tenant]))
+	for namespace := range s.ruleGroups[tenant] {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	groups := []map[string]any{}
+	for _, namespace := range namespaces {
+		for _, group := range s.ruleGroups[tenant][namespace] {
+			groups = append(groups, map[string]any{
+				"name":  group["name"],
+				"file":  namespace,
+				"rules": group["rules"],
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": "success",
+		"data":   map[string]any{"groups": groups},
+	})
+}
+
+func (s *Server) loadRuleGroup(w http.ResponseWriter, r *http.Request) {
+	tenant := mimirTenant(r)
+	if tenant == "" {
+		writeMessage(w, http.StatusUnauthorized, "no org id")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	group := map[string]any{}
+	if err := yaml.Unmarshal(body, &group); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if stringValue(group, "name") == "" {
+		writeMessage(w, http.StatusBadRequest, "invalid rules config: rule group name must not be empty")
+		return
+	}
+
+	if s.ruleGroups[tenant] == nil {
+		s.ruleGroups[tenant] = map[string][]map[string]any{}
+	}
+	namespace := r.PathValue("namespace")
+	groups := s.ruleGroups[tenant][namespace]
+	for i, existing := range groups {
+		if existing["name"] == group["name"] {
+			groups[i] = group
+			writeJSON(w, http.StatusAccepted, map[string]any{})
+			return
+		}
+	}
+	s.ruleGroups[tenant][namespace] = append(groups, group)
+
+	writeJSON(w, http.StatusAccepted, map[string]any{})
+}
+
+// mimirTenant returns the tenant requests identify, empty when they don't,
+// which Mimir rejects
+func mimirTenant(r *http.Request) string {
+	if tenant := r.Header.Get("X-Scope-OrgID"); tenant != "" {
+		return tenant
+	}
+
+	user, _, _ := r.BasicAuth()
+	return user
+}
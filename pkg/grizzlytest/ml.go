@@ -0,0 +1,148 @@
+package grizzlytest
+
+import (
+	"net/http"
+	"sort"
+)
+
+// mlPrefix is the path of the API of the Machine Learning plugin of Grafana
+const mlPrefix = "/api/plugins/grafana-ml-app/resources/manage/api/v1"
+
+// mlEndpoints are the endpoints of the forecast jobs and outlier detectors
+// served by the fake
+var mlEndpoints = []string{"jobs", "outliers"}
+
+func (s *Server) registerML(mux *http.ServeMux) {
+	for _, endpoint := range mlEndpoints {
+		s.mlJobs[endpoint] = map[string]map[string]any{}
+
+		s.handle(mux, "GET "+mlPrefix+"/"+endpoint, s.listMLJobs(endpoint))
+		s.handle(mux, "POST "+mlPrefix+"/"+endpoint, s.createMLJob(endpoint))
+		s.handle(mux, "POST "+mlPrefix+"/"+endpoint+"/{id}", s.updateMLJob(endpoint))
+		s.handle(mux, "DELETE "+mlPrefix+"/"+endpoint+"/{id}", s.deleteMLJob(endpoint))
+	}
+}
+
+// SetML installs or uninstalls the Machine Learning plugin of Grafana Cloud
+// in the fake, which answers 404 to the requests to its API when
+// uninstalled.
+func (s *Server) SetML(enabled bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.ml = enabled
+}
+
+// MLJob returns a job stored in the fake Grafana Machine Learning, by the
+// endpoint serving it, `jobs` or `outliers`, and its metric
+func (s *Server) MLJob(endpoint string, metric string) (map[string]any, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, job := range s.mlJobs[endpoint] {
+		if job["metric"] == metric {
+			return copyObject(job), true
+		}
+	}
+
+	return nil, false
+}
+
+// writeMLData writes a response of the ML API, which wraps data with a
+// status
+func writeMLData(w http.ResponseWriter, status int, data any) {
+	writeJSON(w, status, map[string]any{"status": "success", "data": data})
+}
+
+// validMLJob checks a job as the ML API does, writing an error when invalid
+func (s *Server) validMLJob(w http.ResponseWriter, endpoint string, id string, job map[string]any) bool {
+	metric := stringValue(job, "metric")
+	if metric == "" || stringValue(job, "name") == "" || stringValue(job, "datasourceUid") == "" {
+		writeMessage(w, http.StatusBadRequest, "metric, name and datasourceUid are required")
+		return false
+	}
+	for otherID, other := range s.mlJobs[endpoint] {
+		if otherID != id && other["metric"] == metric {
+			writeMessage(w, http.StatusConflict, "metric %s is already used", metric)
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) listMLJobs(endpoint string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if !s.ml {
+			writeMessage(w, http.StatusNotFound, "Plugin not found")
+			return
+		}
+
+		jobs := []map[string]any{}
+		for _, job := range s.mlJobs[endpoint] {
+			jobs = append(jobs, job)
+		}
+		sort.Slice(jobs, func(i, j int) bool {
+			return stringValue(jobs[i], "metric") < stringValue(jobs[j], "metric")
+		})
+
+		writeMLData(w, http.StatusOK, jobs)
+	}
+}
+
+func (s *Server) createMLJob(endpoint string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.ml {
+			writeMessage(w, http.StatusNotFound, "Plugin not found")
+			return
+		}
+		job := map[string]any{}
+		if err := readJSON(r, &job); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		if !s.validMLJob(w, endpoint, "", job) {
+			return
+		}
+
+		job["id"] = s.newUID()
+		s.mlJobs[endpoint][stringValue(job, "id")] = job
+
+		writeMLData(w, http.StatusOK, job)
+	}
+}
+
+func (s *Server) updateMLJob(endpoint string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if _, found := s.mlJobs[endpoint][id]; !s.ml || !found {
+			writeMessage(w, http.StatusNotFound, "job not found")
+			return
+		}
+		job := map[string]any{}
+		if err := readJSON(r, &job); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		if !s.validMLJob(w, endpoint, id, job) {
+			return
+		}
+
+		job["id"] = id
+		s.mlJobs[endpoint][id] = job
+
+		writeMLData(w, http.StatusOK, job)
+	}
+}
+
+func (s *Server) deleteMLJob(endpoint string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if _, found := s.mlJobs[endpoint][id]; !s.ml || !found {
+			writeMessage(w, http.StatusNotFound, "job not found")
+			return
+		}
+		delete(s.mlJobs[endpoint], id)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,195 @@
+package grizzlytest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// onCallPageSize is the number of objects per page of the listings of the
+// fake OnCall, small so that clients have to follow pages
+const onCallPageSize = 2
+
+// onCallEndpoints are the endpoints of the OnCall objects served by the fake
+var onCallEndpoints = []string{"escalation_chains", "schedules", "integrations"}
+
+func (s *Server) registerOnCall(mux *http.ServeMux) {
+	prefix := OnCallPrefix + "/api/v1"
+
+	for _, endpoint := range onCallEndpoints {
+		s.onCall[endpoint] = map[string]map[string]any{}
+
+		s.handle(mux, "GET "+prefix+"/"+endpoint+"/", s.onCallAuthenticated(s.listOnCallObjects(endpoint)))
+		s.handle(mux, "POST "+prefix+"/"+endpoint+"/", s.onCallAuthenticated(s.createOnCallObject(endpoint)))
+		s.handle(mux, "PUT "+prefix+"/"+endpoint+"/{id}/", s.onCallAuthenticated(s.updateOnCallObject(endpoint)))
+		s.handle(mux, "DELETE "+prefix+"/"+endpoint+"/{id}/", s.onCallAuthenticated(s.deleteOnCallObject(endpoint)))
+	}
+}
+
+// OnCallObject returns an object stored in the fake OnCall, by the endpoint
+// serving it, e.g. `schedules`, and its name
+func (s *Server) OnCallObject(endpoint string, name string) (map[string]any, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, object := range s.onCall[endpoint] {
+		if object["name"] == name {
+			return copyObject(object), true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *Server) onCallAuthenticated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != TenantID {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"detail": "Invalid token."})
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *Server) listOnCallObjects(endpoint string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objects := []map[string]any{}
+		for _, object := range s.onCall[endpoint] {
+			objects = append(objects, object)
+		}
+		sort.Slice(objects, func(i, j int) bool {
+			return stringValue(objects[i], "name") < stringValue(objects[j], "name")
+		})
+
+		page := 1
+		if value := r.URL.Query().Get("page"); value != "" {
+			page, _ = strconv.Atoi(value)
+		}
+		start := min(max(page-1, 0)*onCallPageSize, len(objects))
+		end := min(start+onCallPageSize, len(objects))
+
+		var next any
+		if end < len(objects) {
+			next = fmt.Sprintf("http://%s%s?page=%d", r.Host, r.URL.Path, page+1)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"count":   len(objects),
+			"next":    next,
+			"results": objects[start:end],
+		})
+	}
+}
+
+func (s *Server) createOnCallObject(endpoint string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		object := map[string]any{}
+		if err := readJSON(r, &object); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+
+		id := fmt.Sprintf("O%d", s.newID())
+		if errs := s.checkOnCallObject(endpoint, id, object); len(errs) > 0 {
+			writeJSON(w, http.StatusBadRequest, errs)
+			return
+		}
+		object["id"] = id
+		s.completeOnCallObject(endpoint, object)
+		s.onCall[endpoint][id] = object
+
+		writeJSON(w, http.StatusCreated, object)
+	}
+}
+
+func (s *Server) updateOnCallObject(endpoint string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		existing, found := s.onCall[endpoint][id]
+		if !found {
+			writeJSON(w, http.StatusNotFound, map[string]any{"detail": "Not found."})
+			return
+		}
+
+		object := map[string]any{}
+		if err := readJSON(r, &object); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		if errs := s.checkOnCallObject(endpoint, id, object); len(errs) > 0 {
+			writeJSON(w, http.StatusBadRequest, errs)
+			return
+		}
+		object["id"] = id
+		if route, ok := object["default_route"].(map[string]any); ok {
+			existingRoute, _ := existing["default_route"].(map[string]any)
+			route["id"] = existingRoute["id"]
+		}
+		s.completeOnCallObject(endpoint, object)
+		s.onCall[endpoint][id] = object
+
+		writeJSON(w, http.StatusOK, object)
+	}
+}
+
+func (s *Server) deleteOnCallObject(endpoint string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if _, found := s.onCall[endpoint][id]; !found {
+			writeJSON(w, http.StatusNotFound, map[string]any{"detail": "Not found."})
+			return
+		}
+		delete(s.onCall[endpoint], id)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// checkOnCallObject returns the errors of an object, by field, as OnCall
+// reports them
+func (s *Server) checkOnCallObject(endpoint string, id string, object map[string]any) map[string][]string {
+	errs := map[string][]string{}
+
+	name := stringValue(object, "name")
+	if name == "" {
+		errs["name"] = []string{"This field is required."}
+	}
+	for otherID, other := range s.onCall[endpoint] {
+		if otherID != id && other["name"] == name {
+			errs["name"] = []string{"An object with this name already exists."}
+		}
+	}
+
+	if endpoint == "integrations" {
+		if stringValue(object, "type") == "" {
+			errs["type"] = []string{"This field is required."}
+		}
+		route, _ := object["default_route"].(map[string]any)
+		if chain := stringValue(route, "escalation_chain_id"); chain != "" {
+			if _, found := s.onCall["escalation_chains"][chain]; !found {
+				errs["escalation_chain_id"] = []string{fmt.Sprintf("Invalid pk %q - object does not exist.", chain)}
+			}
+		}
+	}
+
+	return errs
+}
+
+// completeOnCallObject sets the fields of an object maintained by OnCall
+func (s *Server) completeOnCallObject(endpoint string, object map[string]any) {
+	switch endpoint {
+	case "schedules":
+		object["on_call_now"] = []any{}
+	case "integrations":
+		object["link"] = fmt.Sprintf("%s%s/integrations/v1/%s/", s.URL, OnCallPrefix, object["id"])
+		object["inbound_email"] = fmt.Sprintf("%s@inbound.grizzlytest", object["id"])
+		route, _ := object["default_route"].(map[string]any)
+		if route == nil {
+			route = map[string]any{}
+			object["default_route"] = route
+		}
+		if stringValue(route, "id") == "" {
+			route["id"] = fmt.Sprintf("R%d", s.newID())
+		}
+	}
+}
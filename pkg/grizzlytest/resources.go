@@ -0,0 +1,52 @@
+package grizzlytest
+
+import (
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// apiVersion is the API version of the resources built by the helpers
+const apiVersion = "grizzly.grafana.com/v1alpha1"
+
+// GrafanaRegistry returns a registry whose Grafana provider targets the fake
+// server.
+func (s *Server) GrafanaRegistry() grizzly.Registry {
+	return grizzly.NewRegistry([]grizzly.Provider{grafana.NewProvider(&s.Context().Grafana)})
+}
+
+// NewResource returns a resource of the given kind, failing the test if it
+// can't be built.
+func NewResource(t testing.TB, kind string, name string, spec map[string]any) grizzly.Resource {
+	t.Helper()
+
+	resource, err := grizzly.NewResource(apiVersion, kind, name, spec)
+	if err != nil {
+		t.Fatalf("creating %s %s: %s", kind, name, err)
+	}
+	return resource
+}
+
+// NewDashboard returns a dashboard of the General folder, with the given UID
+// and title.
+func NewDashboard(t testing.TB, uid string, title string) grizzly.Resource {
+	t.Helper()
+
+	resource := NewResource(t, "Dashboard", uid, map[string]any{
+		"uid":   uid,
+		"title": title,
+	})
+	resource.SetMetadata("folder", "general")
+	return resource
+}
+
+// NewFolder returns a dashboard folder with the given UID and title.
+func NewFolder(t testing.TB, uid string, title string) grizzly.Resource {
+	t.Helper()
+
+	return NewResource(t, "DashboardFolder", uid, map[string]any{
+		"uid":   uid,
+		"title": title,
+	})
+}
@@ -0,0 +1,294 @@
+// Package grizzlytest provides an in-memory fake of the Grafana, Mimir,
+// Loki, Synthetic Monitoring and OnCall endpoints used by grizzly, to test
+// pipelines and providers without running the real services, and helpers to
+// build the resources of tests.
+package grizzlytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/config"
+)
+
+const (
+	// MimirPrefix is the path under which the Mimir endpoints are served
+	MimirPrefix = "/mimir"
+	// LokiPrefix is the path under which the Loki endpoints are served
+	LokiPrefix = "/loki"
+	// SyntheticMonitoringPrefix is the path under which the Synthetic
+	// Monitoring endpoints are served
+	SyntheticMonitoringPrefix = "/synthetic-monitoring"
+	// OnCallPrefix is the path under which the OnCall endpoints are served
+	OnCallPrefix = "/oncall"
+
+	// TenantID is the Mimir and Loki tenant and the Synthetic Monitoring
+	// tenant of the fake, and the token of its OnCall API
+	TenantID = "grizzlytest"
+)
+
+// Server is a fake Grafana, Mimir, Loki, Synthetic Monitoring and OnCall
+// server, keeping the resources it receives in memory.
+type Server struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	requests []string
+	nextID   int64
+
+	grafanaVersion  string
+	featureToggles  map[string]bool
+	unifiedAlerting bool
+	// reporting tells whether the fake is an Enterprise instance serving the
+	// reporting API
+	reporting bool
+	// slo tells whether the SLO plugin of Grafana Cloud is installed
+	slo bool
+	// ml tells whether the Machine Learning plugin of Grafana Cloud is
+	// installed
+	ml bool
+
+	folders    map[string]map[string]any
+	dashboards map[string]map[string]any
+	// dashboardViews are the last views of dashboards, nil unless usage
+	// insights are enabled
+	dashboardViews  map[string]time.Time
+	datasources     map[string]map[string]any
+	libraryElements map[string]map[string]any
+	alertRules      map[string]map[string]any
+	alertRuleGroups map[string]map[string]any
+	contactPoints   map[string]map[string]any
+	muteTimings     map[string]map[string]any
+	policy          map[string]any
+	// alertmanagerConfigs are the configurations posted to the Alertmanager
+	// of Grafana and to Alertmanager datasources
+	alertmanagerConfigs map[string]map[string]any
+	// receiverSecureSettings are the secure settings of the receivers of
+	// the Alertmanager of Grafana, by UID, which it never returns
+	receiverSecureSettings map[string]map[string]any
+	users                  map[int64]map[string]any
+	teams                  map[int64]map[string]any
+	teamMembers            map[int64][]int64
+	serviceAccounts        map[int64]map[string]any
+	tokens                 map[int64][]map[string]any
+	annotations            map[int64]map[string]any
+	reports                map[int64]map[string]any
+	slos                   map[string]map[string]any
+	firingAlerts           map[string]int
+	ruleGroups             map[string]map[string][]map[string]any
+	// lokiRuleGroups are the rule groups of the ruler of Loki, by namespace
+	lokiRuleGroups map[string][]map[string]any
+	checks         map[int64]map[string]any
+	probes         map[int64]map[string]any
+	// onCall are the OnCall objects, by endpoint and ID
+	onCall map[string]map[string]map[string]any
+	// mlJobs are the forecast jobs and outlier detectors of Grafana Machine
+	// Learning, by endpoint and ID
+	mlJobs map[string]map[string]map[string]any
+}
+
+// NewServer starts a fake server, stopped when the test completes.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		grafanaVersion:         "11.2.0",
+		featureToggles:         map[string]bool{},
+		unifiedAlerting:        true,
+		reporting:              true,
+		slo:                    true,
+		ml:                     true,
+		folders:                map[string]map[string]any{},
+		dashboards:             map[string]map[string]any{},
+		datasources:            map[string]map[string]any{},
+		libraryElements:        map[string]map[string]any{},
+		alertRules:             map[string]map[string]any{},
+		alertRuleGroups:        map[string]map[string]any{},
+		contactPoints:          map[string]map[string]any{},
+		muteTimings:            map[string]map[string]any{},
+		policy:                 map[string]any{"receiver": "grafana-default-email"},
+		alertmanagerConfigs:    map[string]map[string]any{},
+		receiverSecureSettings: map[string]map[string]any{},
+		users:                  map[int64]map[string]any{},
+		teams:                  map[int64]map[string]any{},
+		teamMembers:            map[int64][]int64{},
+		serviceAccounts:        map[int64]map[string]any{},
+		tokens:                 map[int64][]map[string]any{},
+		annotations:            map[int64]map[string]any{},
+		reports:                map[int64]map[string]any{},
+		slos:                   map[string]map[string]any{},
+		firingAlerts:           map[string]int{},
+		ruleGroups:             map[string]map[string][]map[string]any{},
+		lokiRuleGroups:         map[string][]map[string]any{},
+		checks:                 map[int64]map[string]any{},
+		probes:                 map[int64]map[string]any{},
+		onCall:                 map[string]map[string]map[string]any{},
+		mlJobs:                 map[string]map[string]map[string]any{},
+	}
+
+	mux := http.NewServeMux()
+	s.registerGrafana(mux)
+	s.registerMimir(mux)
+	s.registerLoki(mux)
+	s.registerSyntheticMonitoring(mux)
+	s.registerOnCall(mux)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		s.lock.Unlock()
+
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Context returns a grizzly context targeting the fake server.
+func (s *Server) Context() *config.Context {
+	return &config.Context{
+		Name: "grizzlytest",
+		Grafana: config.GrafanaConfig{
+			URL: s.URL,
+		},
+		Mimir: config.MimirConfig{
+			Address:  s.URL + MimirPrefix,
+			TenantID: TenantID,
+		},
+		Loki: config.LokiConfig{
+			Address:  s.URL + LokiPrefix,
+			TenantID: TenantID,
+		},
+		SyntheticMonitoring: config.SyntheticMonitoringConfig{
+			URL:         s.URL + SyntheticMonitoringPrefix,
+			AccessToken: "grizzlytest",
+		},
+		OnCall: config.OnCallConfig{
+			URL:   s.URL + OnCallPrefix,
+			Token: TenantID,
+		},
+	}
+}
+
+// Requests returns the method and path of every request received so far.
+func (s *Server) Requests() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string{}, s.requests...)
+}
+
+// SetGrafanaVersion changes the version of Grafana reported by the fake,
+// along with the feature toggles it has enabled.
+func (s *Server) SetGrafanaVersion(version string, toggles ...string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.grafanaVersion = version
+	s.featureToggles = map[string]bool{}
+	for _, toggle := range toggles {
+		s.featureToggles[toggle] = true
+	}
+}
+
+// SetUnifiedAlerting enables or disables unified alerting in the settings
+// reported by the fake.
+func (s *Server) SetUnifiedAlerting(enabled bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.unifiedAlerting = enabled
+}
+
+// SetReporting enables or disables the reporting API of Grafana Enterprise in
+// the fake, which answers 404 like open source Grafana when disabled.
+func (s *Server) SetReporting(enabled bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.reporting = enabled
+}
+
+// handle serves a route while holding the lock of the server
+func (s *Server) handle(mux *http.ServeMux, pattern string, handler http.HandlerFunc) {
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		handler(w, r)
+	})
+}
+
+func (s *Server) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *Server) newUID() string {
+	return fmt.Sprintf("grizzlytest-%d", s.newID())
+}
+
+func readJSON(r *http.Request, target any) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(body, target)
+}
+
+func writeJSON(w http.ResponseWriter, status int, content any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(content)
+}
+
+func writeMessage(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, map[string]any{"message": fmt.Sprintf(format, args...)})
+}
+
+func writeBadRequest(w http.ResponseWriter, err error) {
+	writeMessage(w, http.StatusBadRequest, "bad request: %s", err)
+}
+
+// copyObject returns a deep copy of a JSON object, so that stored objects
+// can't be modified by callers
+func copyObject(object map[string]any) map[string]any {
+	if object == nil {
+		return nil
+	}
+
+	content, _ := json.Marshal(object)
+	copied := map[string]any{}
+	_ = json.Unmarshal(content, &copied)
+
+	return copied
+}
+
+func stringValue(object map[string]any, key string) string {
+	value, _ := object[key].(string)
+	return value
+}
+
+func int64Value(object map[string]any, key string) int64 {
+	switch value := object[key].(type) {
+	case float64:
+		return int64(value)
+	case int64:
+		return value
+	case int:
+		return int64(value)
+	}
+
+	return 0
+}
@@ -0,0 +1,126 @@
+package grizzlytest_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grafana"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/grafana/grizzly/pkg/mimir"
+	"github.com/grafana/grizzly/pkg/syntheticmonitoring"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	server.AddProbe("Paris")
+
+	context := server.Context()
+	registry := grizzly.NewRegistry([]grizzly.Provider{
+		grafana.NewProvider(&context.Grafana),
+		mimir.NewProvider(&context.Mimir),
+		syntheticmonitoring.NewProvider(&context.SyntheticMonitoring),
+	})
+
+	resource := func(kind string, name string, metadata map[string]string, spec map[string]any) grizzly.Resource {
+		handler, err := registry.GetHandler(kind)
+		require.NoError(t, err)
+
+		resource, err := grizzly.NewResource(handler.APIVersion(), kind, name, spec)
+		require.NoError(t, err)
+		for key, value := range metadata {
+			resource.SetMetadata(key, value)
+		}
+		return resource
+	}
+
+	// handlers modify the resources they apply, so fresh ones are parsed for
+	// each apply, like the CLI does
+	resources := func() grizzly.Resources {
+		return grizzly.NewResources(
+			resource("Datasource", "prometheus", nil, map[string]any{
+				"uid":    "prometheus",
+				"name":   "Prometheus",
+				"type":   "prometheus",
+				"access": "proxy",
+				"url":    "http://prometheus:9090",
+			}),
+			resource("DashboardFolder", "sample", nil, map[string]any{
+				"uid":   "sample",
+				"title": "Sample",
+			}),
+			resource("Dashboard", "sample-dashboard", map[string]string{"folder": "sample"}, map[string]any{
+				"uid":   "sample-dashboard",
+				"title": "Sample dashboard",
+			}),
+			resource("PrometheusRuleGroup", "sample_rules", map[string]string{"namespace": "sample"}, map[string]any{
+				"rules": []any{
+					map[string]any{"record": "job:up:sum", "expr": "sum by (job) (up)"},
+				},
+			}),
+			resource("SyntheticMonitoringCheck", "grafana-com", map[string]string{"type": "http"}, map[string]any{
+				"job":       "grafana-com",
+				"target":    "https://grafana.com",
+				"enabled":   true,
+				"frequency": 60000,
+				"timeout":   3000,
+				"probes":    []any{"Paris"},
+				"settings":  map[string]any{"http": map[string]any{"method": "GET"}},
+			}),
+		)
+	}
+
+	apply := func() string {
+		out := &bytes.Buffer{}
+		err := grizzly.Apply(registry, registry.Sort(resources()), false, grizzly.NewWriterRecorder(out, grizzly.EventToPorcelain))
+		require.NoError(t, err, out.String())
+		return out.String()
+	}
+
+	t.Run("resources are added", func(t *testing.T) {
+		require.Equal(t, ""+
+			"Datasource.prometheus\tresource-added\n"+
+			"DashboardFolder.sample\tresource-added\n"+
+			"Dashboard.sample-dashboard\tresource-added\n"+
+			"PrometheusRuleGroup.sample_rules\tresource-added\n"+
+			"SyntheticMonitoringCheck.grafana-com\tresource-added\n",
+			apply())
+
+		dashboard, folderUID, found := server.Dashboard("sample-dashboard")
+		require.True(t, found)
+		require.Equal(t, "Sample dashboard", dashboard["title"])
+		require.Equal(t, "sample", folderUID)
+
+		_, found = server.Folder("sample")
+		require.True(t, found)
+
+		datasource, found := server.Datasource("prometheus")
+		require.True(t, found)
+		require.Equal(t, "http://prometheus:9090", datasource["url"])
+
+		_, found = server.RuleGroup("sample", "sample_rules")
+		require.True(t, found)
+
+		_, found = server.Check("grafana-com")
+		require.True(t, found)
+	})
+
+	t.Run("applying again changes nothing", func(t *testing.T) {
+		// the remote check gets the default values of all its fields, so
+		// it always differs from the minimal local one
+		require.Equal(t, ""+
+			"Datasource.prometheus\tresource-not-changed\n"+
+			"DashboardFolder.sample\tresource-not-changed\n"+
+			"Dashboard.sample-dashboard\tresource-not-changed\n"+
+			"PrometheusRuleGroup.sample_rules\tresource-not-changed\n"+
+			"SyntheticMonitoringCheck.grafana-com\tresource-updated\n",
+			apply())
+	})
+
+	t.Run("requests are recorded", func(t *testing.T) {
+		require.Contains(t, server.Requests(), "POST /api/dashboards/db")
+		require.Contains(t, server.Requests(), "POST /mimir/prometheus/config/v1/rules/sample")
+		require.Contains(t, server.Requests(), "POST /synthetic-monitoring/api/v1/check/add")
+	})
+}
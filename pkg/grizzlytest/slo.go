@@ -0,0 +1,241 @@
+package grizzlytest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+)
+
+// sloPrefix is the path of the API of the SLO plugin of Grafana
+const sloPrefix = "/api/plugins/grafana-slo-app/resources/v1/slo"
+
+// sloBurnRates are the burn rates alerted on by the alert rules generated for
+// SLOs, by severity
+var sloBurnRates = []struct {
+	alerting string
+	severity string
+	rate     float64
+	duration string
+}{
+	{alerting: "fastBurn", severity: "critical", rate: 14.4, duration: "2m"},
+	{alerting: "slowBurn", severity: "warning", rate: 6, duration: "5m"},
+}
+
+func (s *Server) registerSLO(mux *http.ServeMux) {
+	s.handle(mux, "GET "+sloPrefix, s.listSLOs)
+	s.handle(mux, "POST "+sloPrefix, s.createSLO)
+	s.handle(mux, "GET "+sloPrefix+"/{uuid}", s.getSLO)
+	s.handle(mux, "PUT "+sloPrefix+"/{uuid}", s.updateSLO)
+	s.handle(mux, "DELETE "+sloPrefix+"/{uuid}", s.deleteSLO)
+}
+
+// SetSLO installs or uninstalls the SLO plugin of Grafana Cloud in the fake,
+// which answers 404 to the requests to its API when uninstalled.
+func (s *Server) SetSLO(enabled bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.slo = enabled
+}
+
+// SLO returns an SLO stored in the fake Grafana, by UUID
+func (s *Server) SLO(uuid string) (map[string]any, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	slo, found := s.slos[uuid]
+	return copyObject(slo), found
+}
+
+// sloFromPath returns the SLO whose UUID is in the path of a request,
+// writing an error when not found or when the plugin isn't installed
+func (s *Server) sloFromPath(w http.ResponseWriter, r *http.Request) map[string]any {
+	slo, found := s.slos[r.PathValue("uuid")]
+	if !s.slo || !found {
+		writeMessage(w, http.StatusNotFound, "SLO not found")
+		return nil
+	}
+	return slo
+}
+
+// validSLO checks an SLO as the SLO plugin does, writing an error when
+// invalid
+func validSLO(w http.ResponseWriter, slo map[string]any) bool {
+	objectives, _ := slo["objectives"].([]any)
+	if stringValue(slo, "name") == "" || len(objectives) == 0 {
+		writeMessage(w, http.StatusBadRequest, "SLO needs a name and an objective")
+		return false
+	}
+	return true
+}
+
+func (s *Server) listSLOs(w http.ResponseWriter, _ *http.Request) {
+	if !s.slo {
+		writeMessage(w, http.StatusNotFound, "Plugin not found")
+		return
+	}
+
+	slos := []map[string]any{}
+	for _, slo := range s.slos {
+		slos = append(slos, slo)
+	}
+	sort.Slice(slos, func(i, j int) bool {
+		return stringValue(slos[i], "uuid") < stringValue(slos[j], "uuid")
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{"slos": slos})
+}
+
+func (s *Server) createSLO(w http.ResponseWriter, r *http.Request) {
+	if !s.slo {
+		writeMessage(w, http.StatusNotFound, "Plugin not found")
+		return
+	}
+	slo := map[string]any{}
+	if err := readJSON(r, &slo); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if !validSLO(w, slo) {
+		return
+	}
+
+	uuid := stringValue(slo, "uuid")
+	if uuid == "" {
+		uuid = s.newUID()
+	}
+	if _, exists := s.slos[uuid]; exists {
+		writeMessage(w, http.StatusConflict, "an SLO with the same uuid already exists")
+		return
+	}
+	slo["uuid"] = uuid
+	slo["readOnly"] = map[string]any{"status": map[string]any{"type": "running"}, "provenance": "api"}
+	s.slos[uuid] = slo
+	s.generateSLOAlertRules(slo)
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"uuid": uuid, "message": "SLO created"})
+}
+
+func (s *Server) getSLO(w http.ResponseWriter, r *http.Request) {
+	slo := s.sloFromPath(w, r)
+	if slo == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, slo)
+}
+
+func (s *Server) updateSLO(w http.ResponseWriter, r *http.Request) {
+	slo := s.sloFromPath(w, r)
+	if slo == nil {
+		return
+	}
+	update := map[string]any{}
+	if err := readJSON(r, &update); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if !validSLO(w, update) {
+		return
+	}
+
+	update["uuid"] = slo["uuid"]
+	update["readOnly"] = slo["readOnly"]
+	s.slos[stringValue(slo, "uuid")] = update
+	s.generateSLOAlertRules(update)
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"uuid": slo["uuid"], "message": "SLO updated"})
+}
+
+func (s *Server) deleteSLO(w http.ResponseWriter, r *http.Request) {
+	slo := s.sloFromPath(w, r)
+	if slo == nil {
+		return
+	}
+	uuid := stringValue(slo, "uuid")
+	delete(s.slos, uuid)
+	s.deleteSLOAlertRules(uuid)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// generateSLOAlertRules replaces the alert rules of an SLO by the ones the
+// SLO plugin generates for its alerting options: a rule per burn rate,
+// alerting when the budget of the first objective burns too fast
+func (s *Server) generateSLOAlertRules(slo map[string]any) {
+	uuid := stringValue(slo, "uuid")
+	s.deleteSLOAlertRules(uuid)
+
+	alerting, _ := slo["alerting"].(map[string]any)
+	if alerting == nil {
+		return
+	}
+	objective := 0.0
+	if objectives, _ := slo["objectives"].([]any); len(objectives) > 0 {
+		first, _ := objectives[0].(map[string]any)
+		objective, _ = first["value"].(float64)
+	}
+	folder, _ := slo["folder"].(map[string]any)
+
+	for _, burnRate := range sloBurnRates {
+		options, ok := alerting[burnRate.alerting].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		labels := map[string]any{
+			"grafana_slo_uuid":     uuid,
+			"grafana_slo_severity": burnRate.severity,
+		}
+		annotations := map[string]any{}
+		for _, source := range []map[string]any{alerting, options} {
+			addSLOKeyValues(labels, source["labels"])
+			addSLOKeyValues(annotations, source["annotations"])
+		}
+
+		ruleUID := fmt.Sprintf("slo-%s-%s", uuid, burnRate.severity)
+		s.alertRules[ruleUID] = map[string]any{
+			"id":           s.newID(),
+			"uid":          ruleUID,
+			"orgID":        1,
+			"folderUID":    stringValue(folder, "uid"),
+			"ruleGroup":    stringValue(slo, "name"),
+			"title":        fmt.Sprintf("%s %s burn rate", stringValue(slo, "name"), burnRate.severity),
+			"condition":    "A",
+			"for":          burnRate.duration,
+			"noDataState":  "NoData",
+			"execErrState": "Error",
+			"labels":       labels,
+			"annotations":  annotations,
+			"data": []any{map[string]any{
+				"refId":         "A",
+				"datasourceUid": "grafanacloud-prom",
+				"model": map[string]any{
+					"expr": fmt.Sprintf("grafana_slo_burn_rate{grafana_slo_uuid=%q} > %g", uuid, math.Round(burnRate.rate*(1-objective)*1e6)/1e6),
+				},
+			}},
+		}
+	}
+}
+
+func (s *Server) deleteSLOAlertRules(uuid string) {
+	for ruleUID, rule := range s.alertRules {
+		labels, _ := rule["labels"].(map[string]any)
+		if labels["grafana_slo_uuid"] == uuid {
+			delete(s.alertRules, ruleUID)
+		}
+	}
+}
+
+// addSLOKeyValues adds the labels or annotations of SLOs, given as a list of
+// keys and values, to those of an alert rule
+func addSLOKeyValues(target map[string]any, values any) {
+	list, _ := values.([]any)
+	for _, item := range list {
+		pair, _ := item.(map[string]any)
+		if key := stringValue(pair, "key"); key != "" {
+			target[key] = pair["value"]
+		}
+	}
+}
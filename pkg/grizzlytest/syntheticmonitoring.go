@@ -0,0 +1,117 @@
+package grizzlytest
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+func (s *Server) registerSyntheticMonitoring(mux *http.ServeMux) {
+	prefix := SyntheticMonitoringPrefix + "/api/v1"
+
+	s.handle(mux, "POST "+prefix+"/register/install", s.install)
+	s.handle(mux, "GET "+prefix+"/probe/list", s.listProbes)
+	s.handle(mux, "GET "+prefix+"/check/list", s.listChecks)
+	s.handle(mux, "POST "+prefix+"/check/add", s.addCheck)
+	s.handle(mux, "POST "+prefix+"/check/update", s.updateCheck)
+}
+
+// AddProbe registers a public and online probe in the fake Synthetic
+// Monitoring, and returns its ID
+func (s *Server) AddProbe(name string) int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	id := s.newID()
+	s.probes[id] = map[string]any{
+		"id":     id,
+		"name":   name,
+		"public": true,
+		"online": true,
+	}
+
+	return id
+}
+
+// Check returns a check stored in the fake Synthetic Monitoring
+func (s *Server) Check(job string) (map[string]any, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, check := range s.checks {
+		if check["job"] == job {
+			return copyObject(check), true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *Server) install(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeMessage(w, http.StatusUnauthorized, "missing publisher token")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"accessToken": "grizzlytest",
+		"tenantInfo":  map[string]any{"id": 1},
+	})
+}
+
+func (s *Server) listProbes(w http.ResponseWriter, _ *http.Request) {
+	probes := []map[string]any{}
+	for _, probe := range s.probes {
+		probes = append(probes, probe)
+	}
+	sort.Slice(probes, func(i, j int) bool {
+		return int64Value(probes[i], "id") < int64Value(probes[j], "id")
+	})
+
+	writeJSON(w, http.StatusOK, probes)
+}
+
+func (s *Server) listChecks(w http.ResponseWriter, _ *http.Request) {
+	checks := []map[string]any{}
+	for _, check := range s.checks {
+		checks = append(checks, check)
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		return int64Value(checks[i], "id") < int64Value(checks[j], "id")
+	})
+
+	writeJSON(w, http.StatusOK, checks)
+}
+
+func (s *Server) addCheck(w http.ResponseWriter, r *http.Request) {
+	check := map[string]any{}
+	if err := readJSON(r, &check); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	id := s.newID()
+	check["id"] = id
+	check["tenantId"] = 1
+	s.checks[id] = check
+
+	writeJSON(w, http.StatusOK, check)
+}
+
+func (s *Server) updateCheck(w http.ResponseWriter, r *http.Request) {
+	check := map[string]any{}
+	if err := readJSON(r, &check); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	id := int64Value(check, "id")
+	if _, found := s.checks[id]; !found {
+		writeMessage(w, http.StatusNotFound, "check not found")
+		return
+	}
+	check["tenantId"] = 1
+	s.checks[id] = check
+
+	writeJSON(w, http.StatusOK, check)
+}
@@ -0,0 +1,150 @@
+package loki
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"gopkg.in/yaml.v3"
+)
+
+const rulesEndpoint = "/loki/api/v1/rules"
+
+// Client is a client of the ruler of Loki, handling rule groups as YAML
+// objects
+type Client struct {
+	address    string
+	tenantID   string
+	apiKey     string
+	httpClient *http.Client
+	ctx        context.Context
+}
+
+// NewClient returns a client of the ruler of Loki
+func NewClient(config *config.LokiConfig) (*Client, error) {
+	timeout := 10 * time.Second
+	if timeoutStr := os.Getenv("GRIZZLY_HTTP_TIMEOUT"); timeoutStr != "" {
+		timeoutSeconds, err := strconv.Atoi(timeoutStr)
+		if err != nil {
+			return nil, err
+		}
+		timeout = time.Duration(timeoutSeconds) * time.Second
+	}
+
+	tlsConfig, err := grizzly.NewTLSConfig("Loki at "+config.Address, grizzly.TLSOptions{
+		CAPath:             config.TLS.CAPath,
+		InsecureSkipVerify: config.TLS.InsecureSkipVerify,
+	})
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &Client{
+		address:  strings.TrimSuffix(config.Address, "/"),
+		tenantID: config.TenantID,
+		apiKey:   config.APIKey,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: grizzly.DecorateHTTPTransport(grizzly.WithHTTPHeaders(transport, config.Headers)),
+		},
+		ctx: context.Background(),
+	}, nil
+}
+
+// WithContext returns a copy of the client sending its requests with the
+// given context
+func (c *Client) WithContext(ctx context.Context) *Client {
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
+// ListRules returns the rule groups of the ruler, by namespace
+func (c *Client) ListRules() (map[string][]map[string]any, error) {
+	groups := map[string][]map[string]any{}
+	err := c.do(http.MethodGet, rulesEndpoint, nil, &groups)
+	// the ruler answers with a 404 when it has no rule groups at all
+	var statusErr grizzly.HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return map[string][]map[string]any{}, nil
+	}
+	return groups, err
+}
+
+// GetRuleGroup returns a rule group of a namespace
+func (c *Client) GetRuleGroup(namespace string, name string) (map[string]any, error) {
+	group := map[string]any{}
+	err := c.do(http.MethodGet, fmt.Sprintf("%s/%s/%s", rulesEndpoint, url.PathEscape(namespace), url.PathEscape(name)), nil, &group)
+	var statusErr grizzly.HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return nil, grizzly.ErrNotFound
+	}
+	return group, err
+}
+
+// WriteRuleGroup creates or replaces a rule group of a namespace
+func (c *Client) WriteRuleGroup(namespace string, group map[string]any) error {
+	return c.do(http.MethodPost, fmt.Sprintf("%s/%s", rulesEndpoint, url.PathEscape(namespace)), group, nil)
+}
+
+// DeleteRuleGroup deletes a rule group of a namespace
+func (c *Client) DeleteRuleGroup(namespace string, name string) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("%s/%s/%s", rulesEndpoint, url.PathEscape(namespace), url.PathEscape(name)), nil, nil)
+}
+
+func (c *Client) do(method string, path string, body any, target any) error {
+	var reader io.Reader
+	if body != nil {
+		content, err := yaml.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, method, c.address+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+	if c.apiKey != "" {
+		req.SetBasicAuth(c.tenantID, c.apiKey)
+	} else {
+		req.Header.Set("X-Scope-OrgID", c.tenantID)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to Loki failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("cannot read response body: %w", err)
+	}
+	if res.StatusCode >= 300 {
+		// the ruler explains rejected rule groups in the body of its responses
+		if detail := strings.TrimSpace(string(content)); detail != "" && res.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("%s %s: %w: %s", method, path, grizzly.HTTPStatusError{StatusCode: res.StatusCode}, detail)
+		}
+		return fmt.Errorf("%s %s: %w", method, path, grizzly.HTTPStatusError{StatusCode: res.StatusCode})
+	}
+
+	if target == nil || len(content) == 0 {
+		return nil
+	}
+	return yaml.Unmarshal(content, target)
+}
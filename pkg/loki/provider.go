@@ -0,0 +1,105 @@
+package loki
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// Provider is a grizzly.Provider implementation for the ruler of Loki.
+type Provider struct {
+	config *config.LokiConfig
+	ctx    context.Context
+}
+
+// NewProvider instantiates a new Provider.
+func NewProvider(config *config.LokiConfig) *Provider {
+	return &Provider{
+		config: config,
+	}
+}
+
+// WithContext returns a copy of the provider sending its requests with the
+// given context
+func (p *Provider) WithContext(ctx context.Context) grizzly.Provider {
+	return &Provider{
+		config: p.config,
+		ctx:    ctx,
+	}
+}
+
+func (p *Provider) Validate() error {
+	if p.config.Address == "" {
+		return fmt.Errorf("loki address is not set")
+	}
+	if p.config.TenantID == "" {
+		return fmt.Errorf("loki tenant id is not set")
+	}
+	return nil
+}
+
+func (p *Provider) Status() grizzly.ProviderStatus {
+	status := grizzly.ProviderStatus{}
+
+	if err := p.Validate(); err != nil {
+		status.ActiveReason = err.Error()
+		return status
+	}
+
+	status.Active = true
+
+	client, err := p.Client()
+	if err != nil {
+		status.OnlineReason = err.Error()
+		return status
+	}
+	if _, err := client.ListRules(); err != nil {
+		status.OnlineReason = err.Error()
+		return status
+	}
+
+	status.Online = true
+
+	return status
+}
+
+func (p *Provider) Name() string {
+	return "Loki"
+}
+
+// Group returns the group name of the Loki provider
+func (p *Provider) Group() string {
+	return "grizzly.grafana.com"
+}
+
+// Version returns the version of this provider
+func (p *Provider) Version() string {
+	return "v1alpha1"
+}
+
+// APIVersion returns the group and version of this provider
+func (p *Provider) APIVersion() string {
+	return filepath.Join(p.Group(), p.Version())
+}
+
+// GetHandlers identifies the handlers for the Loki provider
+func (p *Provider) GetHandlers() []grizzly.Handler {
+	return []grizzly.Handler{
+		NewRuleHandler(p),
+	}
+}
+
+// Client returns a client of the ruler of Loki
+func (p *Provider) Client() (*Client, error) {
+	client, err := NewClient(p.config)
+	if err != nil {
+		return nil, err
+	}
+	if p.ctx != nil {
+		client = client.WithContext(p.ctx)
+	}
+	return client, nil
+}
@@ -0,0 +1,159 @@
+package loki
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// RuleGroupKind is the kind of the rule groups of the ruler of Loki
+const RuleGroupKind = "LokiRuleGroup"
+
+const lokiRuleGroupPattern = "loki/rules-%s.%s"
+
+// RuleHandler is a Grizzly Handler for the rule groups of Loki, which are
+// alerting and recording rules on LogQL queries
+type RuleHandler struct {
+	grizzly.BaseHandler
+}
+
+var _ grizzly.Handler = &RuleHandler{}
+var _ grizzly.DeleteHandler = &RuleHandler{}
+
+// NewRuleHandler returns a new Grizzly Handler for Loki rule groups
+func NewRuleHandler(provider grizzly.Provider) *RuleHandler {
+	return &RuleHandler{
+		BaseHandler: grizzly.NewBaseHandler(provider, RuleGroupKind, false),
+	}
+}
+
+// Permissions returns the permissions required to manage rule groups on
+// Grafana Cloud. Self-hosted rulers don't have permissions of their own.
+func (h *RuleHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Scopes: []string{"rules:read"}},
+		Write: grizzly.Access{Scopes: []string{"rules:read", "rules:write"}},
+	}
+}
+
+// ResourceFilePath returns the location on disk where a resource should be updated
+func (h *RuleHandler) ResourceFilePath(resource grizzly.Resource, filetype string) string {
+	return fmt.Sprintf(lokiRuleGroupPattern, resource.Name(), filetype)
+}
+
+// Validate checks that the uid of a rule group matches its name, and that
+// it has rules
+func (h *RuleHandler) Validate(resource grizzly.Resource) error {
+	uid, exist := resource.GetSpecString("uid")
+	if exist && uid != resource.Name() {
+		return fmt.Errorf("uid '%s' and name '%s', don't match", uid, resource.Name())
+	}
+	if _, ok := resource.Spec()["rules"].([]any); !ok {
+		return fmt.Errorf("%s %s requires a list of rules", h.Kind(), resource.Name())
+	}
+	return nil
+}
+
+// GetUID returns the UID for a resource, `namespace.name`
+func (h *RuleHandler) GetUID(resource grizzly.Resource) (string, error) {
+	if !resource.HasMetadata("namespace") {
+		return "", fmt.Errorf("%s %s requires a namespace metadata entry", h.Kind(), resource.Name())
+	}
+	return fmt.Sprintf("%s.%s", resource.GetMetadata("namespace"), resource.Name()), nil
+}
+
+func (h *RuleHandler) GetSpecUID(resource grizzly.Resource) (string, error) {
+	return "", fmt.Errorf("GetSpecUID not implemented for loki rules")
+}
+
+// GetByUID retrieves a rule group by UID
+func (h *RuleHandler) GetByUID(uid string) (*grizzly.Resource, error) {
+	namespace, name, found := strings.Cut(uid, ".")
+	if !found {
+		return nil, fmt.Errorf("invalid UID '%s': expected <namespace>.<name>", uid)
+	}
+	return h.getRemote(namespace, name)
+}
+
+// GetRemote retrieves the remote rule group of a resource
+func (h *RuleHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	return h.getRemote(resource.GetMetadata("namespace"), resource.Name())
+}
+
+// ListRemote retrieves as list of UIDs of all remote rule groups
+func (h *RuleHandler) ListRemote() ([]string, error) {
+	client, err := h.Provider.(*Provider).Client()
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := client.ListRules()
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []string
+	for namespace, groups := range namespaces {
+		for _, group := range groups {
+			if name, _ := group["name"].(string); name != "" {
+				uids = append(uids, fmt.Sprintf("%s.%s", namespace, name))
+			}
+		}
+	}
+	return uids, nil
+}
+
+// Add pushes a rule group to the ruler
+func (h *RuleHandler) Add(resource grizzly.Resource) error {
+	return h.writeRuleGroup(resource)
+}
+
+// Update pushes a rule group to the ruler, replacing the existing one
+func (h *RuleHandler) Update(existing, resource grizzly.Resource) error {
+	return h.writeRuleGroup(resource)
+}
+
+// Delete deletes a rule group from the ruler
+func (h *RuleHandler) Delete(resource grizzly.Resource) error {
+	client, err := h.Provider.(*Provider).Client()
+	if err != nil {
+		return err
+	}
+	return client.DeleteRuleGroup(resource.GetMetadata("namespace"), resource.Name())
+}
+
+// getRemote retrieves a rule group of a namespace as a resource. The spec
+// is the rule group without its name, e.g. its rules and interval.
+func (h *RuleHandler) getRemote(namespace string, name string) (*grizzly.Resource, error) {
+	client, err := h.Provider.(*Provider).Client()
+	if err != nil {
+		return nil, err
+	}
+	group, err := client.GetRuleGroup(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := maps.Clone(group)
+	delete(spec, "name")
+	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), name, spec)
+	if err != nil {
+		return nil, err
+	}
+	resource.SetMetadata("namespace", namespace)
+	return &resource, nil
+}
+
+// writeRuleGroup writes the rule group of a resource to its namespace
+func (h *RuleHandler) writeRuleGroup(resource grizzly.Resource) error {
+	client, err := h.Provider.(*Provider).Client()
+	if err != nil {
+		return err
+	}
+
+	group := maps.Clone(resource.Spec())
+	delete(group, "uid")
+	group["name"] = resource.Name()
+	return client.WriteRuleGroup(resource.GetMetadata("namespace"), group)
+}
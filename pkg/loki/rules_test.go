@@ -0,0 +1,125 @@
+package loki_test
+
+import (
+	"bytes"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/grafana/grizzly/pkg/loki"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleGroups(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	provider := loki.NewProvider(&server.Context().Loki)
+	registry := grizzly.NewRegistry([]grizzly.Provider{provider})
+	handler := loki.NewRuleHandler(provider)
+
+	group := func(t *testing.T, expr string) grizzly.Resource {
+		t.Helper()
+		resource := grizzlytest.NewResource(t, loki.RuleGroupKind, "errors", map[string]any{
+			"interval": "1m",
+			"rules": []any{
+				map[string]any{"alert": "HighErrorRate", "expr": expr, "for": "5m"},
+			},
+		})
+		resource.SetMetadata("namespace", "api")
+		return resource
+	}
+	apply := func(t *testing.T, resource grizzly.Resource) {
+		t.Helper()
+		require.NoError(t, grizzly.Apply(registry, grizzly.NewResources(resource), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText)))
+	}
+	rateExpr := `sum(rate({app="api"} |= "error" [5m])) > 10`
+
+	t.Run("an empty ruler has no rule groups", func(t *testing.T) {
+		status := provider.Status()
+		require.True(t, status.Online, status.OnlineReason)
+
+		uids, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.Empty(t, uids)
+	})
+
+	t.Run("rule groups are pushed to their namespace", func(t *testing.T) {
+		apply(t, group(t, rateExpr))
+
+		stored, found := server.LokiRuleGroup("api", "errors")
+		require.True(t, found)
+		require.Equal(t, "1m", stored["interval"])
+		require.Equal(t, rateExpr, stored["rules"].([]any)[0].(map[string]any)["expr"])
+
+		uids, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.Equal(t, []string{"api.errors"}, uids)
+	})
+
+	t.Run("rule groups are retrieved by namespace and name", func(t *testing.T) {
+		remote, err := handler.GetByUID("api.errors")
+		require.NoError(t, err)
+		require.Equal(t, "api", remote.GetMetadata("namespace"))
+		require.Equal(t, "errors", remote.Name())
+		require.Nil(t, remote.GetSpecValue("name"))
+
+		_, err = handler.GetByUID("api.unknown")
+		require.ErrorIs(t, err, grizzly.ErrNotFound)
+		_, err = handler.GetByUID("errors")
+		require.ErrorContains(t, err, "expected <namespace>.<name>")
+	})
+
+	t.Run("changed rule groups are replaced", func(t *testing.T) {
+		summary := grizzly.NewDiffSummary()
+		require.NoError(t, grizzly.Diff(registry, grizzly.NewResources(group(t, rateExpr)), false, "yaml", summary))
+		require.Equal(t, 1, summary.Count(loki.RuleGroupKind, grizzly.ResourceNotChanged))
+
+		apply(t, group(t, `sum(rate({app="api"} |= "error" [5m])) > 20`))
+		stored, _ := server.LokiRuleGroup("api", "errors")
+		require.Contains(t, stored["rules"].([]any)[0].(map[string]any)["expr"], "> 20")
+	})
+
+	t.Run("rule groups are deleted", func(t *testing.T) {
+		require.NoError(t, handler.Delete(group(t, rateExpr)))
+		_, found := server.LokiRuleGroup("api", "errors")
+		require.False(t, found)
+	})
+
+	t.Run("rule groups require a namespace and rules", func(t *testing.T) {
+		resource := grizzlytest.NewResource(t, loki.RuleGroupKind, "errors", map[string]any{})
+		require.ErrorContains(t, handler.Validate(resource), "requires a list of rules")
+		_, err := handler.GetUID(resource)
+		require.ErrorContains(t, err, "requires a namespace metadata entry")
+	})
+
+	t.Run("the provider requires an address and a tenant", func(t *testing.T) {
+		require.ErrorContains(t, loki.NewProvider(&config.LokiConfig{}).Validate(), "loki address is not set")
+		require.ErrorContains(t, loki.NewProvider(&config.LokiConfig{Address: server.URL}).Validate(), "loki tenant id is not set")
+	})
+}
+
+func TestClientCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("api:\n  - name: errors\n    rules: []\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+
+	client, err := loki.NewClient(&config.LokiConfig{Address: server.URL, TenantID: "grizzly"})
+	require.NoError(t, err)
+	_, err = client.ListRules()
+	require.ErrorContains(t, err, "certificate")
+
+	client, err = loki.NewClient(&config.LokiConfig{Address: server.URL, TenantID: "grizzly", TLS: config.LokiTLSConfig{CAPath: caPath}})
+	require.NoError(t, err)
+	groups, err := client.ListRules()
+	require.NoError(t, err)
+	require.Len(t, groups["api"], 1)
+}
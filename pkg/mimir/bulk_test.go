@@ -0,0 +1,98 @@
+package mimir
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/mimir/models"
+	"github.com/stretchr/testify/require"
+)
+
+// countingClient stores the rule groups of tenants in memory, counting
+// requests
+type countingClient struct {
+	groups map[string]map[string][]models.PrometheusRuleGroup
+	lists  map[string]int
+	writes int
+}
+
+func (c *countingClient) ListRules(tenant string) (map[string][]models.PrometheusRuleGroup, error) {
+	c.lists[tenant]++
+	return c.groups[tenant], nil
+}
+
+func (c *countingClient) CreateRules(tenant string, grouping models.PrometheusRuleGrouping) error {
+	c.writes++
+	if c.groups[tenant] == nil {
+		c.groups[tenant] = map[string][]models.PrometheusRuleGroup{}
+	}
+	groups := c.groups[tenant][grouping.Namespace]
+	for _, group := range grouping.Groups {
+		groups = slices.DeleteFunc(groups, func(existing models.PrometheusRuleGroup) bool {
+			return existing.Name == group.Name
+		})
+		groups = append(groups, group)
+	}
+	c.groups[tenant][grouping.Namespace] = groups
+	return nil
+}
+
+func TestBulkApply(t *testing.T) {
+	rule := map[string]any{"record": "job:up:sum", "expr": "sum by(job) (up)"}
+	client := &countingClient{
+		groups: map[string]map[string][]models.PrometheusRuleGroup{
+			"":       {"infra": {{Name: "unchanged", Rules: []any{rule}}}},
+			"team-a": {"web": {{Name: "drifted", Rules: []any{}}}},
+			"team-b": {"web": {{Name: "drifted", Rules: []any{rule}}}},
+		},
+		lists: map[string]int{},
+	}
+	provider := &Provider{config: &config.MimirConfig{TenantID: "ops", Tenants: []string{"team-a"}}, clientTool: client}
+	registry := grizzly.NewRegistry([]grizzly.Provider{provider})
+
+	group := func(namespace string, name string, tenants ...any) grizzly.Resource {
+		resource, err := grizzly.NewResource("grizzly.grafana.com/v1alpha1", "PrometheusRuleGroup", name, map[string]any{
+			"rules": []any{rule},
+		})
+		require.NoError(t, err)
+		resource.SetMetadata("namespace", namespace)
+		switch len(tenants) {
+		case 0:
+		case 1:
+			resource.Body["metadata"].(map[string]any)["tenant"] = tenants[0]
+		default:
+			resource.Body["metadata"].(map[string]any)["tenants"] = tenants
+		}
+		return resource
+	}
+	resources := grizzly.NewResources(
+		group("infra", "unchanged", "ops"),
+		group("infra", "added"),
+		group("web", "frontend", "team-a", "team-b", 12345),
+		group("web", "drifted", "team-a", "team-b"),
+	)
+
+	out := &bytes.Buffer{}
+	err := grizzly.Apply(registry, resources, false, grizzly.NewWriterRecorder(out, grizzly.EventToPorcelain))
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"": 1, "team-a": 1, "team-b": 1}, client.lists, "rule groups should be listed once per tenant, until missing from one")
+	require.Equal(t, 6, client.writes, "only the groups that changed should be written, to each of their tenants")
+	require.Equal(t, "PrometheusRuleGroup.unchanged\tresource-not-changed\nPrometheusRuleGroup.added\tresource-added\nPrometheusRuleGroup.frontend\tresource-added\nPrometheusRuleGroup.drifted\tresource-updated\n", out.String())
+	require.Len(t, client.groups["12345"]["web"], 1)
+	require.Equal(t, []any{rule}, client.groups["team-a"]["web"][0].Rules)
+
+	handler := NewRuleHandler(provider, client)
+	uids, err := handler.ListRemote()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"infra.unchanged", "infra.added", "team-a/web.drifted", "team-a/web.frontend"}, uids, "only the tenants of the context should be listed")
+
+	remote, err := handler.GetByUID("team-a/web.frontend")
+	require.NoError(t, err)
+	require.Equal(t, "team-a", remote.GetMetadata("tenant"))
+	require.Equal(t, "prometheus/team-a/rules-frontend.yaml", handler.ResourceFilePath(*remote, "yaml"))
+
+	require.ErrorContains(t, handler.Validate(group("web", "frontend", "team/a")), "'team/a' is not a valid tenant ID")
+}
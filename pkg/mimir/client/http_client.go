@@ -2,17 +2,20 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grizzly"
 	"github.com/grafana/grizzly/pkg/mimir/models"
 	"gopkg.in/yaml.v3"
 )
@@ -35,15 +38,32 @@ type DataGroups struct {
 
 type Client struct {
 	config *config.MimirConfig
+	ctx    context.Context
+
+	// the HTTP client is created once, so that insecure TLS settings are
+	// only reported once, and shared by the copies of the client
+	httpClient *sharedHTTPClient
+}
+
+type sharedHTTPClient struct {
+	once   sync.Once
+	client *http.Client
+	err    error
 }
 
 func NewHTTPClient(config *config.MimirConfig) Mimir {
-	return &Client{config: config}
+	return &Client{config: config, ctx: context.Background(), httpClient: &sharedHTTPClient{}}
 }
 
-func (c *Client) ListRules() (map[string][]models.PrometheusRuleGroup, error) {
-	url := fmt.Sprintf(listRulesEndpoint, c.config.Address)
-	res, err := c.doRequest(http.MethodGet, url, nil)
+// WithContext returns a copy of the client sending its requests with the
+// given context
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{config: c.config, ctx: ctx, httpClient: c.httpClient}
+}
+
+func (c *Client) ListRules(tenant string) (map[string][]models.PrometheusRuleGroup, error) {
+	url := fmt.Sprintf(listRulesEndpoint, strings.TrimSuffix(c.config.Address, "/"))
+	res, err := c.doRequest(tenant, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -64,50 +84,58 @@ func (c *Client) ListRules() (map[string][]models.PrometheusRuleGroup, error) {
 	return groups, nil
 }
 
-func (c *Client) CreateRules(resource models.PrometheusRuleGrouping) error {
-	url := fmt.Sprintf(loadRulesEndpoint, c.config.Address, resource.Namespace)
+func (c *Client) CreateRules(tenant string, resource models.PrometheusRuleGrouping) error {
+	url := fmt.Sprintf(loadRulesEndpoint, strings.TrimSuffix(c.config.Address, "/"), resource.Namespace)
 	for _, group := range resource.Groups {
 		out, err := yaml.Marshal(group)
 		if err != nil {
 			return fmt.Errorf("cannot marshall groups: %s", err)
 		}
 
-		if _, err = c.doRequest(http.MethodPost, url, out); err != nil {
-			return fmt.Errorf("error found creating rule group: %s", group.Name)
+		if _, err = c.doRequest(tenant, http.MethodPost, url, out); err != nil {
+			return fmt.Errorf("error found creating rule group %s: %w", group.Name, err)
 		}
 	}
 
 	return nil
 }
 
-func (c *Client) doRequest(method string, url string, body []byte) ([]byte, error) {
-	if c.config.TenantID == "" {
+// doRequest sends a request on behalf of a tenant, or of the tenant of the
+// context when empty
+func (c *Client) doRequest(tenant string, method string, url string, body []byte) ([]byte, error) {
+	if tenant == "" {
+		tenant = c.config.TenantID
+	}
+	if tenant == "" {
 		return nil, errors.New("missing tenant-id")
 	}
-	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(c.ctx, method, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/yaml")
 	if c.config.APIKey != "" {
-		req.SetBasicAuth(c.config.TenantID, c.config.APIKey)
+		req.SetBasicAuth(tenant, c.config.APIKey)
 	} else {
-		req.Header.Set("X-Scope-OrgID", c.config.TenantID)
+		req.Header.Set("X-Scope-OrgID", tenant)
 	}
 
-	client, err := c.createHTTPClient()
+	c.httpClient.once.Do(func() {
+		c.httpClient.client, c.httpClient.err = c.createHTTPClient()
+	})
+	client, err := c.httpClient.client, c.httpClient.err
 	if err != nil {
 		return nil, err
 	}
 
 	res, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request to load rules failed: %s", err)
+		return nil, fmt.Errorf("request to load rules failed: %w", err)
 	}
 
 	if res.StatusCode >= 300 {
-		return nil, fmt.Errorf("error loading rules: %d", res.StatusCode)
+		return nil, fmt.Errorf("error loading rules: %w", grizzly.HTTPStatusError{StatusCode: res.StatusCode})
 	}
 
 	b, err := io.ReadAll(res.Body)
@@ -129,29 +157,18 @@ func (c *Client) createHTTPClient() (*http.Client, error) {
 		timeout = time.Duration(timeoutSeconds) * time.Second
 	}
 
-	tlsConfig := &tls.Config{}
-	httpClient := http.Client{
-		Timeout:   timeout,
-		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	tlsConfig, err := grizzly.NewTLSConfig("Mimir at "+c.config.Address, grizzly.TLSOptions{
+		CAPath:             c.config.TLS.CAPath,
+		InsecureSkipVerify: c.config.TLS.InsecureSkipVerify,
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	if c.config.TLS.CAPath != "" {
-		certPool, err := x509.SystemCertPool()
-		if err != nil {
-			return nil, err
-		}
-
-		caCertPEM, err := os.ReadFile(c.config.TLS.CAPath)
-		if err != nil {
-			return nil, err
-		}
-
-		ok := certPool.AppendCertsFromPEM(caCertPEM)
-		if !ok {
-			return nil, fmt.Errorf("could not append ca-bundle at path %s to existing certificates", c.config.TLS.CAPath)
-		}
-
-		tlsConfig.RootCAs = certPool
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	httpClient := http.Client{
+		Timeout: timeout,
 	}
 
 	if c.config.TLS.ClientCertPath != "" || c.config.TLS.ClientKeyPath != "" {
@@ -162,8 +179,8 @@ func (c *Client) createHTTPClient() (*http.Client, error) {
 		tlsConfig.Certificates = []tls.Certificate{clientTLSCert}
 	}
 
-	httpClient.Transport = &http.Transport{
+	httpClient.Transport = grizzly.DecorateHTTPTransport(grizzly.WithHTTPHeaders(&http.Transport{
 		TLSClientConfig: tlsConfig,
-	}
+	}, c.config.Headers))
 	return &httpClient, nil
 }
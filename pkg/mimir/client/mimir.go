@@ -4,7 +4,9 @@ import (
 	"github.com/grafana/grizzly/pkg/mimir/models"
 )
 
+// Mimir is a client of the ruler of Mimir. Tenants are selected per
+// request, an empty tenant being the tenant of the context.
 type Mimir interface {
-	ListRules() (map[string][]models.PrometheusRuleGroup, error)
-	CreateRules(resource models.PrometheusRuleGrouping) error
+	ListRules(tenant string) (map[string][]models.PrometheusRuleGroup, error)
+	CreateRules(tenant string, resource models.PrometheusRuleGrouping) error
 }
@@ -1,8 +1,10 @@
 package mimir
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
+	"slices"
 
 	"github.com/grafana/grizzly/pkg/config"
 	"github.com/grafana/grizzly/pkg/grizzly"
@@ -24,6 +26,19 @@ func NewProvider(config *config.MimirConfig) *Provider {
 	}
 }
 
+// WithContext returns a copy of the provider sending its requests with the
+// given context
+func (p *Provider) WithContext(ctx context.Context) grizzly.Provider {
+	clientTool := p.clientTool
+	if httpClient, ok := clientTool.(*client.Client); ok {
+		clientTool = httpClient.WithContext(ctx)
+	}
+	return &Provider{
+		config:     p.config,
+		clientTool: clientTool,
+	}
+}
+
 func (p *Provider) Validate() error {
 	if p.config.Address == "" {
 		return fmt.Errorf("mimir address is not set")
@@ -44,7 +59,7 @@ func (p *Provider) Status() grizzly.ProviderStatus {
 
 	status.Active = true
 
-	if _, err := p.clientTool.ListRules(); err != nil {
+	if _, err := p.clientTool.ListRules(""); err != nil {
 		status.OnlineReason = err.Error()
 		return status
 	}
@@ -54,6 +69,30 @@ func (p *Provider) Status() grizzly.ProviderStatus {
 	return status
 }
 
+// tenant returns the tenant a rule group is pushed to, empty for the tenant
+// of the context
+func (p *Provider) tenant(tenant string) string {
+	if p.config != nil && tenant == p.config.TenantID {
+		return ""
+	}
+	return tenant
+}
+
+// tenants returns the tenants whose rule groups are listed: the tenant of
+// the context, as an empty tenant, and the additional ones
+func (p *Provider) tenants() []string {
+	tenants := []string{""}
+	if p.config == nil {
+		return tenants
+	}
+	for _, tenant := range p.config.Tenants {
+		if tenant = p.tenant(tenant); tenant != "" && !slices.Contains(tenants, tenant) {
+			tenants = append(tenants, tenant)
+		}
+	}
+	return tenants
+}
+
 func (p *Provider) Name() string {
 	return "Mimir"
 }
@@ -2,12 +2,15 @@ package mimir
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/grafana/grizzly/pkg/mimir/client"
 	"github.com/grafana/grizzly/pkg/mimir/models"
 
 	"github.com/grafana/grizzly/pkg/grizzly"
+	"gopkg.in/yaml.v3"
 )
 
 // RuleHandler is a Grizzly Handler for Prometheus Rules
@@ -16,6 +19,8 @@ type RuleHandler struct {
 	clientTool client.Mimir
 }
 
+var _ grizzly.BulkApplyHandler = &RuleHandler{}
+
 // NewRuleHandler returns a new Grizzly Handler for Prometheus Rules
 func NewRuleHandler(provider *Provider, clientTool client.Mimir) *RuleHandler {
 	return &RuleHandler{
@@ -24,12 +29,25 @@ func NewRuleHandler(provider *Provider, clientTool client.Mimir) *RuleHandler {
 	}
 }
 
+// Permissions returns the permissions required to manage rule groups on
+// Grafana Cloud. Self-hosted rulers don't have permissions of their own.
+func (h *RuleHandler) Permissions() grizzly.Permissions {
+	return grizzly.Permissions{
+		Read:  grizzly.Access{Scopes: []string{"rules:read"}},
+		Write: grizzly.Access{Scopes: []string{"rules:read", "rules:write"}},
+	}
+}
+
 const (
-	prometheusRuleGroupPattern = "prometheus/rules-%s.%s"
+	prometheusRuleGroupPattern       = "prometheus/rules-%s.%s"
+	prometheusTenantRuleGroupPattern = "prometheus/%s/rules-%s.%s"
 )
 
 // ResourceFilePath returns the location on disk where a resource should be updated
 func (h *RuleHandler) ResourceFilePath(resource grizzly.Resource, filetype string) string {
+	if tenant := h.tenant(resource); tenant != "" {
+		return fmt.Sprintf(prometheusTenantRuleGroupPattern, tenant, resource.Name(), filetype)
+	}
 	return fmt.Sprintf(prometheusRuleGroupPattern, resource.Name(), filetype)
 }
 
@@ -39,15 +57,25 @@ func (h *RuleHandler) Validate(resource grizzly.Resource) error {
 	if exist && uid != resource.Name() {
 		return fmt.Errorf("uid '%s' and name '%s', don't match", uid, resource.Name())
 	}
+	if resource.HasMetadata("tenant") && resource.HasMetadata("tenants") {
+		return fmt.Errorf("tenant and tenants can't both be set")
+	}
+	for _, tenant := range resourceTenants(resource) {
+		if tenant == "" || strings.Contains(tenant, "/") {
+			return fmt.Errorf("'%s' is not a valid tenant ID", tenant)
+		}
+	}
 	return nil
 }
 
-// GetUID returns the UID for a resource
+// GetUID returns the UID for a resource. Rule groups of other tenants than
+// the one of the context are prefixed with their tenant, e.g.
+// `team-a/namespace.name`, or their first tenant when they have several.
 func (h *RuleHandler) GetUID(resource grizzly.Resource) (string, error) {
 	if !resource.HasMetadata("namespace") {
 		return "", fmt.Errorf("%s %s requires a namespace metadata entry", h.Kind(), resource.Name())
 	}
-	return fmt.Sprintf("%s.%s", resource.GetMetadata("namespace"), resource.Name()), nil
+	return ruleGroupUID(h.tenant(resource), resource.GetMetadata("namespace"), resource.Name()), nil
 }
 
 func (h *RuleHandler) GetSpecUID(resource grizzly.Resource) (string, error) {
@@ -59,10 +87,17 @@ func (h *RuleHandler) GetByUID(uid string) (*grizzly.Resource, error) {
 	return h.getRemoteRuleGroup(uid)
 }
 
-// GetRemote retrieves a datasource as a Resource
+// GetRemote retrieves the remote rule group of a resource, across its
+// tenants
 func (h *RuleHandler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
-	uid := fmt.Sprintf("%s.%s", resource.GetMetadata("namespace"), resource.Name())
-	return h.getRemoteRuleGroup(uid)
+	remote, err := h.remoteRuleGroup(resource, h.clientTool.ListRules)
+	if err != nil {
+		return nil, err
+	}
+	if remote == nil {
+		return nil, grizzly.ErrNotFound
+	}
+	return remote, nil
 }
 
 // ListRemote retrieves as list of UIDs of all remote resources
@@ -82,11 +117,15 @@ func (h *RuleHandler) Update(existing, resource grizzly.Resource) error {
 
 // getRemoteRuleGroup retrieves a datasource object from Grafana
 func (h *RuleHandler) getRemoteRuleGroup(uid string) (*grizzly.Resource, error) {
+	tenant, uid := splitTenant(uid)
 	parts := strings.SplitN(uid, ".", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid UID '%s': expected <namespace>.<name>", uid)
+	}
 	namespace := parts[0]
 	name := parts[1]
 
-	groupings, err := h.clientTool.ListRules()
+	groupings, err := h.clientTool.ListRules(tenant)
 	if err != nil {
 		return nil, err
 	}
@@ -95,15 +134,7 @@ func (h *RuleHandler) getRemoteRuleGroup(uid string) (*grizzly.Resource, error)
 		if key == namespace {
 			for _, group := range grouping {
 				if group.Name == name {
-					spec := map[string]interface{}{
-						"rules": group.Rules,
-					}
-					resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), group.Name, spec)
-					if err != nil {
-						return nil, err
-					}
-					resource.SetMetadata("namespace", namespace)
-					return &resource, nil
+					return h.ruleGroupResource(tenant, namespace, group)
 				}
 			}
 		}
@@ -111,23 +142,139 @@ func (h *RuleHandler) getRemoteRuleGroup(uid string) (*grizzly.Resource, error)
 	return nil, grizzly.ErrNotFound
 }
 
-// getRemoteRuleGroupList retrieves a datasource object from Grafana
-func (h *RuleHandler) getRemoteRuleGroupList() ([]string, error) {
-	groupings, err := h.clientTool.ListRules()
+// ruleGroupResource turns a remote rule group of a tenant into a resource
+func (h *RuleHandler) ruleGroupResource(tenant string, namespace string, group models.PrometheusRuleGroup) (*grizzly.Resource, error) {
+	spec := map[string]interface{}{
+		"rules": group.Rules,
+	}
+	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), group.Name, spec)
+	if err != nil {
+		return nil, err
+	}
+	resource.SetMetadata("namespace", namespace)
+	if tenant != "" {
+		resource.SetMetadata("tenant", tenant)
+	}
+	return &resource, nil
+}
+
+// BulkGetRemote retrieves the remote rule groups of resources, listing the
+// rules of the ruler once per tenant
+func (h *RuleHandler) BulkGetRemote(resources []grizzly.Resource) ([]*grizzly.Resource, error) {
+	groupings := map[string]map[string][]models.PrometheusRuleGroup{}
+	listRules := func(tenant string) (map[string][]models.PrometheusRuleGroup, error) {
+		if grouping, listed := groupings[tenant]; listed {
+			return grouping, nil
+		}
+		grouping, err := h.clientTool.ListRules(tenant)
+		if err != nil {
+			return nil, err
+		}
+		groupings[tenant] = grouping
+		return grouping, nil
+	}
+
+	remotes := make([]*grizzly.Resource, len(resources))
+	for i, resource := range resources {
+		remote, err := h.remoteRuleGroup(resource, listRules)
+		if err != nil {
+			return nil, err
+		}
+		remotes[i] = remote
+	}
+
+	return remotes, nil
+}
+
+// remoteRuleGroup retrieves the rule group of a resource from each of its
+// tenants, listing rules with listRules. It returns nil when the group is
+// missing from any tenant, so that it is written to all of them, or else
+// the first group that differs from the resource, if any, so that drift in
+// any tenant shows. The remote group carries the tenants of the resource.
+func (h *RuleHandler) remoteRuleGroup(resource grizzly.Resource, listRules func(string) (map[string][]models.PrometheusRuleGroup, error)) (*grizzly.Resource, error) {
+	expected, err := yaml.Marshal(resource.Spec())
 	if err != nil {
 		return nil, err
 	}
 
+	namespace := resource.GetMetadata("namespace")
+	var remote, drifted *grizzly.Resource
+	for _, tenant := range h.tenants(resource) {
+		groupings, err := listRules(tenant)
+		if err != nil {
+			return nil, err
+		}
+
+		var found *grizzly.Resource
+		for _, group := range groupings[namespace] {
+			if group.Name != resource.Name() {
+				continue
+			}
+			if found, err = h.ruleGroupResource(tenant, namespace, group); err != nil {
+				return nil, err
+			}
+			break
+		}
+		if found == nil {
+			return nil, nil
+		}
+
+		actual, err := yaml.Marshal(found.Spec())
+		if err != nil {
+			return nil, err
+		}
+		if remote == nil {
+			remote = found
+		}
+		if drifted == nil && string(actual) != string(expected) {
+			drifted = found
+		}
+	}
+	if drifted != nil {
+		remote = drifted
+	}
+
+	metadata := remote.Body["metadata"].(map[string]any)
+	delete(metadata, "tenant")
+	for _, key := range []string{"tenant", "tenants"} {
+		if value, ok := resource.Body["metadata"].(map[string]any)[key]; ok {
+			metadata[key] = value
+		}
+	}
+	return remote, nil
+}
+
+// BulkApply writes the rule groups that changed. The ruler API takes one
+// rule group per request.
+func (h *RuleHandler) BulkApply(changes []grizzly.BulkChange) []error {
+	errs := make([]error, len(changes))
+	for i, change := range changes {
+		errs[i] = h.writeRuleGroup(change.Resource)
+	}
+
+	return errs
+}
+
+// getRemoteRuleGroupList lists the UIDs of the rule groups of the tenant of
+// the context and of the additional tenants
+func (h *RuleHandler) getRemoteRuleGroupList() ([]string, error) {
 	var IDs []string
-	for namespace, grouping := range groupings {
-		for _, group := range grouping {
-			uid := fmt.Sprintf("%s.%s", namespace, group.Name)
-			IDs = append(IDs, uid)
+	for _, tenant := range h.Provider.(*Provider).tenants() {
+		groupings, err := h.clientTool.ListRules(tenant)
+		if err != nil {
+			return nil, err
+		}
+
+		for namespace, grouping := range groupings {
+			for _, group := range grouping {
+				IDs = append(IDs, ruleGroupUID(tenant, namespace, group.Name))
+			}
 		}
 	}
 	return IDs, nil
 }
 
+// writeRuleGroup writes a rule group to each of its tenants
 func (h *RuleHandler) writeRuleGroup(resource grizzly.Resource) error {
 	newGroup := models.PrometheusRuleGroup{
 		Name:  resource.Name(),
@@ -143,5 +290,83 @@ func (h *RuleHandler) writeRuleGroup(resource grizzly.Resource) error {
 		Groups:    []models.PrometheusRuleGroup{newGroup},
 	}
 
-	return h.clientTool.CreateRules(grouping)
+	for _, tenant := range h.tenants(resource) {
+		if err := h.clientTool.CreateRules(tenant, grouping); err != nil {
+			if tenant != "" {
+				return fmt.Errorf("tenant %s: %w", tenant, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// tenants returns the tenants of a rule group, from its `tenant` or
+// `tenants` metadata, the tenant of the context being empty
+func (h *RuleHandler) tenants(resource grizzly.Resource) []string {
+	var tenants []string
+	for _, tenant := range resourceTenants(resource) {
+		if tenant = h.Provider.(*Provider).tenant(tenant); !slices.Contains(tenants, tenant) {
+			tenants = append(tenants, tenant)
+		}
+	}
+	if len(tenants) == 0 {
+		return []string{""}
+	}
+	return tenants
+}
+
+// tenant returns the first tenant of a rule group
+func (h *RuleHandler) tenant(resource grizzly.Resource) string {
+	return h.tenants(resource)[0]
+}
+
+// resourceTenants returns the `tenant` or `tenants` metadata of a rule
+// group
+func resourceTenants(resource grizzly.Resource) []string {
+	metadata, _ := resource.Body["metadata"].(map[string]any)
+	if tenants, ok := metadata["tenants"].([]any); ok {
+		ids := make([]string, 0, len(tenants))
+		for _, tenant := range tenants {
+			ids = append(ids, tenantID(tenant))
+		}
+		return ids
+	}
+	if tenant, ok := metadata["tenant"]; ok {
+		return []string{tenantID(tenant)}
+	}
+	return nil
+}
+
+// tenantID formats a tenant ID. Tenant IDs are often numbers, e.g. the IDs
+// of Grafana Cloud instances, which are written unquoted.
+func tenantID(tenant any) string {
+	switch tenant := tenant.(type) {
+	case nil:
+		return ""
+	case string:
+		return tenant
+	case float64:
+		return strconv.FormatFloat(tenant, 'f', -1, 64)
+	default:
+		return fmt.Sprint(tenant)
+	}
+}
+
+// ruleGroupUID returns the UID of a rule group, prefixed with its tenant
+// unless it belongs to the tenant of the context
+func ruleGroupUID(tenant string, namespace string, name string) string {
+	if tenant == "" {
+		return fmt.Sprintf("%s.%s", namespace, name)
+	}
+	return fmt.Sprintf("%s/%s.%s", tenant, namespace, name)
+}
+
+// splitTenant splits the tenant prefixing a UID from the rest of it. Tenant
+// IDs can't contain slashes.
+func splitTenant(uid string) (string, string) {
+	if tenant, rest, found := strings.Cut(uid, "/"); found {
+		return tenant, rest
+	}
+	return "", uid
 }
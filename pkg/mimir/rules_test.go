@@ -107,7 +107,7 @@ type FakeClient struct {
 	expectedError error
 }
 
-func (f *FakeClient) ListRules() (map[string][]models.PrometheusRuleGroup, error) {
+func (f *FakeClient) ListRules(_ string) (map[string][]models.PrometheusRuleGroup, error) {
 	if f.expectedError != nil {
 		return nil, f.expectedError
 	}
@@ -129,7 +129,7 @@ func (f *FakeClient) ListRules() (map[string][]models.PrometheusRuleGroup, error
 	return nil, nil
 }
 
-func (f *FakeClient) CreateRules(_ models.PrometheusRuleGrouping) error {
+func (f *FakeClient) CreateRules(_ string, _ models.PrometheusRuleGrouping) error {
 	if f.expectedError != nil {
 		return f.expectedError
 	}
@@ -0,0 +1,141 @@
+package oncall
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// Client is a client of the OnCall API, handling its objects as JSON objects
+type Client struct {
+	url        string
+	token      string
+	httpClient *http.Client
+	ctx        context.Context
+}
+
+// listResponse is a page of objects listed by the OnCall API
+type listResponse struct {
+	Next    *string          `json:"next"`
+	Results []map[string]any `json:"results"`
+}
+
+// NewClient returns a client of the OnCall API
+func NewClient(config *config.OnCallConfig) (*Client, error) {
+	timeout := 10 * time.Second
+	if timeoutStr := os.Getenv("GRIZZLY_HTTP_TIMEOUT"); timeoutStr != "" {
+		timeoutSeconds, err := strconv.Atoi(timeoutStr)
+		if err != nil {
+			return nil, err
+		}
+		timeout = time.Duration(timeoutSeconds) * time.Second
+	}
+
+	return &Client{
+		url:   strings.TrimSuffix(config.URL, "/"),
+		token: config.Token,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: grizzly.DecorateHTTPTransport(nil),
+		},
+		ctx: context.Background(),
+	}, nil
+}
+
+// WithContext returns a copy of the client sending its requests with the
+// given context
+func (c *Client) WithContext(ctx context.Context) *Client {
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
+// List returns the objects of an endpoint, e.g. `schedules`, following the
+// pages of the listing
+func (c *Client) List(endpoint string) ([]map[string]any, error) {
+	objects := []map[string]any{}
+	url := fmt.Sprintf("%s/api/v1/%s/", c.url, endpoint)
+	for url != "" {
+		var page listResponse
+		if err := c.do(http.MethodGet, url, nil, &page); err != nil {
+			return nil, err
+		}
+		objects = append(objects, page.Results...)
+
+		url = ""
+		if page.Next != nil {
+			url = *page.Next
+		}
+	}
+	return objects, nil
+}
+
+// Create creates an object, and returns it as created by OnCall
+func (c *Client) Create(endpoint string, object map[string]any) (map[string]any, error) {
+	created := map[string]any{}
+	err := c.do(http.MethodPost, fmt.Sprintf("%s/api/v1/%s/", c.url, endpoint), object, &created)
+	return created, err
+}
+
+// Update replaces an object, and returns it as updated by OnCall
+func (c *Client) Update(endpoint string, id string, object map[string]any) (map[string]any, error) {
+	updated := map[string]any{}
+	err := c.do(http.MethodPut, fmt.Sprintf("%s/api/v1/%s/%s/", c.url, endpoint, id), object, &updated)
+	return updated, err
+}
+
+// Delete deletes an object
+func (c *Client) Delete(endpoint string, id string) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("%s/api/v1/%s/%s/", c.url, endpoint, id), nil, nil)
+}
+
+func (c *Client) do(method string, url string, body any, target any) error {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to OnCall failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("cannot read response body: %w", err)
+	}
+	if res.StatusCode >= 300 {
+		// OnCall details invalid objects in the body of its responses
+		if detail := strings.TrimSpace(string(content)); detail != "" {
+			return fmt.Errorf("%s %s: %w: %s", method, strings.TrimPrefix(url, c.url), grizzly.HTTPStatusError{StatusCode: res.StatusCode}, detail)
+		}
+		return fmt.Errorf("%s %s: %w", method, strings.TrimPrefix(url, c.url), grizzly.HTTPStatusError{StatusCode: res.StatusCode})
+	}
+
+	if target == nil || len(content) == 0 {
+		return nil
+	}
+	return json.Unmarshal(content, target)
+}
@@ -0,0 +1,312 @@
+package oncall
+
+import (
+	"fmt"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+const (
+	EscalationChainKind = "OnCallEscalationChain"
+	ScheduleKind        = "OnCallSchedule"
+	IntegrationKind     = "OnCallIntegration"
+)
+
+const (
+	escalationChainsEndpoint = "escalation_chains"
+	schedulesEndpoint        = "schedules"
+	integrationsEndpoint     = "integrations"
+
+	escalationChainPattern = "oncall/escalation-chain-%s.%s"
+	schedulePattern        = "oncall/schedule-%s.%s"
+	integrationPattern     = "oncall/integration-%s.%s"
+)
+
+// Handler is a Grizzly Handler for the OnCall objects of a kind, identified
+// by their name
+type Handler struct {
+	grizzly.BaseHandler
+
+	endpoint string
+	pattern  string
+	// readOnly are the fields of the objects maintained by OnCall
+	readOnly []string
+	// toSpec and toObject convert objects of the API to specs and back, e.g.
+	// to reference other objects by name rather than by ID
+	toSpec   func(client *Client, object map[string]any) error
+	toObject func(client *Client, spec map[string]any) error
+}
+
+var _ grizzly.Handler = &Handler{}
+var _ grizzly.DeleteHandler = &Handler{}
+
+// NewEscalationChainHandler returns a new Grizzly Handler for OnCall
+// escalation chains
+func NewEscalationChainHandler(provider grizzly.Provider) *Handler {
+	return &Handler{
+		BaseHandler: grizzly.NewBaseHandler(provider, EscalationChainKind, false),
+		endpoint:    escalationChainsEndpoint,
+		pattern:     escalationChainPattern,
+	}
+}
+
+// NewScheduleHandler returns a new Grizzly Handler for OnCall schedules
+func NewScheduleHandler(provider grizzly.Provider) *Handler {
+	return &Handler{
+		BaseHandler: grizzly.NewBaseHandler(provider, ScheduleKind, false),
+		endpoint:    schedulesEndpoint,
+		pattern:     schedulePattern,
+		readOnly:    []string{"on_call_now"},
+	}
+}
+
+// NewIntegrationHandler returns a new Grizzly Handler for OnCall
+// integrations, whose default route references its escalation chain by name
+func NewIntegrationHandler(provider grizzly.Provider) *Handler {
+	return &Handler{
+		BaseHandler: grizzly.NewBaseHandler(provider, IntegrationKind, false),
+		endpoint:    integrationsEndpoint,
+		pattern:     integrationPattern,
+		readOnly:    []string{"link", "inbound_email"},
+		toSpec:      integrationSpec,
+		toObject:    integrationObject,
+	}
+}
+
+// ResourceFilePath returns the location on disk where a resource should be updated
+func (h *Handler) ResourceFilePath(resource grizzly.Resource, filetype string) string {
+	return fmt.Sprintf(h.pattern, resource.Name(), filetype)
+}
+
+// Unprepare removes unnecessary elements from a remote resource ready for presentation/comparison
+func (h *Handler) Unprepare(resource grizzly.Resource) *grizzly.Resource {
+	resource.DeleteSpecKey("id")
+	for _, field := range h.readOnly {
+		resource.DeleteSpecKey(field)
+	}
+	return &resource
+}
+
+// Prepare gets a resource ready for dispatch to the remote endpoint
+func (h *Handler) Prepare(existing *grizzly.Resource, resource grizzly.Resource) *grizzly.Resource {
+	if !resource.HasSpecString("name") {
+		resource.SetSpecString("name", resource.Name())
+	}
+	return &resource
+}
+
+// Validate checks that the name of the object matches the name of the resource
+func (h *Handler) Validate(resource grizzly.Resource) error {
+	name, exist := resource.GetSpecString("name")
+	if exist && name != resource.Name() {
+		return fmt.Errorf("name '%s' and resource name '%s', don't match", name, resource.Name())
+	}
+	return nil
+}
+
+func (h *Handler) GetSpecUID(resource grizzly.Resource) (string, error) {
+	name, ok := resource.GetSpecString("name")
+	if !ok {
+		return "", fmt.Errorf("name not specified")
+	}
+	return name, nil
+}
+
+// GetByUID retrieves JSON for a resource from an endpoint, by name
+func (h *Handler) GetByUID(name string) (*grizzly.Resource, error) {
+	return h.getRemote(name)
+}
+
+// GetRemote retrieves an object as a Resource
+func (h *Handler) GetRemote(resource grizzly.Resource) (*grizzly.Resource, error) {
+	return h.getRemote(resource.Name())
+}
+
+// ListRemote retrieves as list of names of all remote objects
+func (h *Handler) ListRemote() ([]string, error) {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+	objects, err := client.List(h.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(objects))
+	for _, object := range objects {
+		if name, _ := object["name"].(string); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Add creates an object
+func (h *Handler) Add(resource grizzly.Resource) error {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+	object, err := h.object(client, resource)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Create(h.endpoint, object)
+	return err
+}
+
+// Update replaces an object
+func (h *Handler) Update(existing, resource grizzly.Resource) error {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+	remote, err := h.find(client, resource.Name())
+	if err != nil {
+		return err
+	}
+	object, err := h.object(client, resource)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Update(h.endpoint, objectID(remote), object)
+	return err
+}
+
+// Delete deletes an object
+func (h *Handler) Delete(resource grizzly.Resource) error {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return err
+	}
+	remote, err := h.find(client, resource.Name())
+	if err != nil {
+		return err
+	}
+
+	return client.Delete(h.endpoint, objectID(remote))
+}
+
+// find returns an object by name
+func (h *Handler) find(client *Client, name string) (map[string]any, error) {
+	objects, err := client.List(h.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range objects {
+		if object["name"] == name {
+			return object, nil
+		}
+	}
+
+	return nil, grizzly.ErrNotFound
+}
+
+// getRemote retrieves an object as a resource
+func (h *Handler) getRemote(name string) (*grizzly.Resource, error) {
+	client, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+	object, err := h.find(client, name)
+	if err != nil {
+		return nil, err
+	}
+	if h.toSpec != nil {
+		if err := h.toSpec(client, object); err != nil {
+			return nil, err
+		}
+	}
+
+	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), name, object)
+	if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// object returns the object of the API described by a resource
+func (h *Handler) object(client *Client, resource grizzly.Resource) (map[string]any, error) {
+	object := make(map[string]any, len(resource.Spec()))
+	for key, value := range resource.Spec() {
+		object[key] = value
+	}
+	object["name"] = resource.Name()
+	delete(object, "id")
+	for _, field := range h.readOnly {
+		delete(object, field)
+	}
+
+	if h.toObject != nil {
+		if err := h.toObject(client, object); err != nil {
+			return nil, err
+		}
+	}
+	return object, nil
+}
+
+func objectID(object map[string]any) string {
+	id, _ := object["id"].(string)
+	return id
+}
+
+// integrationSpec references the escalation chain of the default route of an
+// integration by name
+func integrationSpec(client *Client, object map[string]any) error {
+	route, ok := object["default_route"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	delete(route, "id")
+
+	id, _ := route["escalation_chain_id"].(string)
+	delete(route, "escalation_chain_id")
+	if id == "" {
+		return nil
+	}
+	chains, err := client.List(escalationChainsEndpoint)
+	if err != nil {
+		return err
+	}
+	for _, chain := range chains {
+		if objectID(chain) == id {
+			route["escalation_chain"] = chain["name"]
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown escalation chain %s in the default route of %s", id, object["name"])
+}
+
+// integrationObject references the escalation chain of the default route of
+// an integration by ID
+func integrationObject(client *Client, object map[string]any) error {
+	route, ok := object["default_route"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	copied := make(map[string]any, len(route))
+	for key, value := range route {
+		copied[key] = value
+	}
+	object["default_route"] = copied
+
+	name, _ := copied["escalation_chain"].(string)
+	delete(copied, "escalation_chain")
+	if name == "" {
+		return nil
+	}
+	chains, err := client.List(escalationChainsEndpoint)
+	if err != nil {
+		return err
+	}
+	for _, chain := range chains {
+		if chain["name"] == name {
+			copied["escalation_chain_id"] = objectID(chain)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown escalation chain %s in the default route of %s", name, object["name"])
+}
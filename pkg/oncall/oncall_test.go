@@ -0,0 +1,113 @@
+package oncall_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
+	"github.com/grafana/grizzly/pkg/grizzlytest"
+	"github.com/grafana/grizzly/pkg/oncall"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnCall(t *testing.T) {
+	server := grizzlytest.NewServer(t)
+	provider := oncall.NewProvider(&server.Context().OnCall)
+	registry := grizzly.NewRegistry([]grizzly.Provider{provider})
+
+	resource := func(t *testing.T, kind string, name string, spec map[string]any) grizzly.Resource {
+		t.Helper()
+		spec["name"] = name
+		return grizzlytest.NewResource(t, kind, name, spec)
+	}
+	integration := func(t *testing.T, chain string) grizzly.Resource {
+		t.Helper()
+		return resource(t, oncall.IntegrationKind, "alertmanager", map[string]any{
+			"type":          "alertmanager",
+			"default_route": map[string]any{"escalation_chain": chain},
+		})
+	}
+	apply := func(t *testing.T, resources ...grizzly.Resource) error {
+		t.Helper()
+		return grizzly.Apply(registry, grizzly.NewResources(resources...), false, grizzly.NewWriterRecorder(&bytes.Buffer{}, grizzly.EventToPlainText))
+	}
+	diff := func(t *testing.T, resource grizzly.Resource) string {
+		t.Helper()
+		output := &bytes.Buffer{}
+		require.NoError(t, grizzly.Diff(registry, grizzly.NewResources(resource), false, "", grizzly.NewWriterRecorder(output, grizzly.EventToPlainText)))
+		return output.String()
+	}
+
+	chains := []grizzly.Resource{
+		resource(t, oncall.EscalationChainKind, "platform", map[string]any{}),
+		resource(t, oncall.EscalationChainKind, "databases", map[string]any{}),
+		resource(t, oncall.EscalationChainKind, "frontend", map[string]any{}),
+	}
+	schedule := resource(t, oncall.ScheduleKind, "platform-primary", map[string]any{
+		"type":      "web",
+		"time_zone": "Europe/Paris",
+	})
+	require.NoError(t, apply(t, append(chains, schedule, integration(t, "databases"))...))
+
+	t.Run("the provider is online", func(t *testing.T) {
+		status := provider.Status()
+		require.True(t, status.Online, status.OnlineReason)
+	})
+
+	t.Run("objects are listed across pages", func(t *testing.T) {
+		handler, err := registry.GetHandler(oncall.EscalationChainKind)
+		require.NoError(t, err)
+		names, err := handler.ListRemote()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"platform", "databases", "frontend"}, names)
+	})
+
+	t.Run("integrations reference escalation chains by name", func(t *testing.T) {
+		object, found := server.OnCallObject("integrations", "alertmanager")
+		require.True(t, found)
+		chain, found := server.OnCallObject("escalation_chains", "databases")
+		require.True(t, found)
+		require.Equal(t, chain["id"], object["default_route"].(map[string]any)["escalation_chain_id"])
+
+		handler, err := registry.GetHandler(oncall.IntegrationKind)
+		require.NoError(t, err)
+		remote, err := handler.GetByUID("alertmanager")
+		require.NoError(t, err)
+		remote = handler.Unprepare(*remote)
+		require.Equal(t, map[string]any{"escalation_chain": "databases"}, remote.GetSpecValue("default_route"))
+		require.Nil(t, remote.GetSpecValue("link"))
+	})
+
+	t.Run("fields maintained by OnCall aren't compared", func(t *testing.T) {
+		require.Contains(t, diff(t, schedule), "OnCallSchedule.platform-primary unchanged")
+		require.Contains(t, diff(t, integration(t, "databases")), "OnCallIntegration.alertmanager unchanged")
+		require.Contains(t, diff(t, integration(t, "platform")), "+        escalation_chain: platform")
+	})
+
+	t.Run("integrations are moved to other escalation chains", func(t *testing.T) {
+		require.NoError(t, apply(t, integration(t, "platform")))
+		object, _ := server.OnCallObject("integrations", "alertmanager")
+		chain, _ := server.OnCallObject("escalation_chains", "platform")
+		require.Equal(t, chain["id"], object["default_route"].(map[string]any)["escalation_chain_id"])
+
+		err := apply(t, integration(t, "unknown"))
+		require.ErrorContains(t, err, "unknown escalation chain unknown in the default route of alertmanager")
+	})
+
+	t.Run("objects are deleted", func(t *testing.T) {
+		handler, err := registry.GetHandler(oncall.ScheduleKind)
+		require.NoError(t, err)
+		require.NoError(t, handler.(grizzly.DeleteHandler).Delete(schedule))
+		_, err = handler.GetByUID("platform-primary")
+		require.ErrorIs(t, err, grizzly.ErrNotFound)
+	})
+
+	t.Run("tokens are required", func(t *testing.T) {
+		context := server.Context()
+		context.OnCall.Token = "invalid"
+		status := oncall.NewProvider(&context.OnCall).Status()
+		require.True(t, status.Active)
+		require.False(t, status.Online)
+		require.Contains(t, status.OnlineReason, "401 Unauthorized")
+	})
+}
@@ -0,0 +1,112 @@
+package oncall
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/grafana/grizzly/pkg/config"
+	"github.com/grafana/grizzly/pkg/grizzly"
+)
+
+// Provider is a grizzly.Provider implementation for Grafana OnCall.
+type Provider struct {
+	config *config.OnCallConfig
+	ctx    context.Context
+}
+
+type ClientProvider interface {
+	Client() (*Client, error)
+}
+
+// NewProvider instantiates a new Provider.
+func NewProvider(config *config.OnCallConfig) *Provider {
+	return &Provider{
+		config: config,
+	}
+}
+
+// WithContext returns a copy of the provider sending its requests with the
+// given context
+func (p *Provider) WithContext(ctx context.Context) grizzly.Provider {
+	return &Provider{
+		config: p.config,
+		ctx:    ctx,
+	}
+}
+
+func (p *Provider) Validate() error {
+	if p.config.URL == "" {
+		return fmt.Errorf("oncall url is not set")
+	}
+	if p.config.Token == "" {
+		return fmt.Errorf("oncall token is not set")
+	}
+	return nil
+}
+
+func (p *Provider) Status() grizzly.ProviderStatus {
+	status := grizzly.ProviderStatus{}
+
+	if err := p.Validate(); err != nil {
+		status.ActiveReason = err.Error()
+		return status
+	}
+
+	status.Active = true
+
+	client, err := p.Client()
+	if err != nil {
+		status.OnlineReason = err.Error()
+		return status
+	}
+	if _, err := client.List(escalationChainsEndpoint); err != nil {
+		status.OnlineReason = err.Error()
+		return status
+	}
+
+	status.Online = true
+
+	return status
+}
+
+func (p *Provider) Name() string {
+	return "OnCall"
+}
+
+// Group returns the group name of the OnCall provider
+func (p *Provider) Group() string {
+	return "grizzly.grafana.com"
+}
+
+// Version returns the version of this provider
+func (p *Provider) Version() string {
+	return "v1alpha1"
+}
+
+// APIVersion returns the group and version of this provider
+func (p *Provider) APIVersion() string {
+	return filepath.Join(p.Group(), p.Version())
+}
+
+// GetHandlers identifies the handlers for the OnCall provider. Escalation
+// chains come first, as integrations reference them.
+func (p *Provider) GetHandlers() []grizzly.Handler {
+	return []grizzly.Handler{
+		NewEscalationChainHandler(p),
+		NewScheduleHandler(p),
+		NewIntegrationHandler(p),
+	}
+}
+
+// Client returns a client of the OnCall API
+func (p *Provider) Client() (*Client, error) {
+	client, err := NewClient(p.config)
+	if err != nil {
+		return nil, err
+	}
+	if p.ctx != nil {
+		client = client.WithContext(p.ctx)
+	}
+	return client, nil
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"strconv"
 	"time"
+
+	"github.com/grafana/grizzly/pkg/grizzly"
 )
 
 func NewHTTPClient() (*http.Client, error) {
@@ -16,5 +18,8 @@ func NewHTTPClient() (*http.Client, error) {
 		}
 		timeout = time.Duration(timeoutSeconds) * time.Second
 	}
-	return &http.Client{Timeout: timeout}, nil
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: grizzly.DecorateHTTPTransport(nil),
+	}, nil
 }
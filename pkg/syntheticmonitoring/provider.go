@@ -14,6 +14,7 @@ import (
 // Provider is a grizzly.Provider implementation for Grafana.
 type Provider struct {
 	config *config.SyntheticMonitoringConfig
+	ctx    context.Context
 }
 
 type ClientProvider interface {
@@ -27,6 +28,23 @@ func NewProvider(config *config.SyntheticMonitoringConfig) *Provider {
 	}
 }
 
+// WithContext returns a copy of the provider sending its requests with the
+// given context
+func (p *Provider) WithContext(ctx context.Context) grizzly.Provider {
+	return &Provider{
+		config: p.config,
+		ctx:    ctx,
+	}
+}
+
+// contextOf returns the context a handler's provider is bound to
+func contextOf(provider grizzly.Provider) context.Context {
+	if p, ok := provider.(*Provider); ok && p.ctx != nil {
+		return p.ctx
+	}
+	return context.Background()
+}
+
 func (p *Provider) Validate() error {
 	if p.config.URL == "" {
 		p.config.URL = "https://synthetic-monitoring-api.grafana.net"
@@ -113,7 +131,7 @@ func (p *Provider) Client() (*smapi.Client, error) {
 
 	smClient := smapi.NewClient(p.config.URL, "", client)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(contextOf(p), 5*time.Second)
 	defer cancel()
 
 	_, err = smClient.Install(ctx, p.config.StackID, p.config.MetricsID, p.config.LogsID, p.config.Token)
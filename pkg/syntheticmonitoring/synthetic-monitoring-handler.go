@@ -8,6 +8,7 @@ import (
 
 	"github.com/grafana/grizzly/pkg/grizzly"
 	"github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
 )
 
 /*
@@ -35,6 +36,8 @@ type SyntheticMonitoringHandler struct {
 	grizzly.BaseHandler
 }
 
+var _ grizzly.BulkApplyHandler = &SyntheticMonitoringHandler{}
+
 // NewSyntheticMonitoringHandler returns a Grizzly Handler for Grafana Synthetic Monitoring
 func NewSyntheticMonitoringHandler(provider grizzly.Provider) *SyntheticMonitoringHandler {
 	return &SyntheticMonitoringHandler{
@@ -62,8 +65,10 @@ func (h *SyntheticMonitoringHandler) Unprepare(resource grizzly.Resource) *grizz
 
 // Prepare gets a resource ready for dispatch to the remote endpoint
 func (h *SyntheticMonitoringHandler) Prepare(existing *grizzly.Resource, resource grizzly.Resource) *grizzly.Resource {
-	resource.SetSpecValue("tenantId", existing.GetSpecValue("tenantId"))
-	resource.SetSpecValue("id", existing.GetSpecValue("id"))
+	if existing != nil {
+		resource.SetSpecValue("tenantId", existing.GetSpecValue("tenantId"))
+		resource.SetSpecValue("id", existing.GetSpecValue("id"))
+	}
 	_, exists := resource.GetSpecString("job")
 	if !exists {
 		resource.SetSpecString("job", resource.GetMetadata("name"))
@@ -127,7 +132,7 @@ func (h *SyntheticMonitoringHandler) getProbeList() (Probes, error) {
 	if err != nil {
 		return Probes{}, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(contextOf(h.Provider), 5*time.Second)
 	defer cancel()
 
 	probeList, err := smClient.ListProbes(ctx)
@@ -155,7 +160,7 @@ func (h *SyntheticMonitoringHandler) getRemoteCheckList() ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(contextOf(h.Provider), 5*time.Second)
 	defer cancel()
 
 	checks, err := smClient.ListChecks(ctx)
@@ -177,7 +182,7 @@ func (h *SyntheticMonitoringHandler) getRemoteCheck(uid string) (*grizzly.Resour
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(contextOf(h.Provider), 5*time.Second)
 	defer cancel()
 
 	checkList, err := smClient.ListChecks(ctx)
@@ -192,36 +197,105 @@ func (h *SyntheticMonitoringHandler) getRemoteCheck(uid string) (*grizzly.Resour
 
 	for _, check := range checkList {
 		if h.getUID(check) == uid {
-			var probeNames []string
-			for _, probeID := range check.Probes {
-				probeNames = append(probeNames, probes.ByID[probeID].Name)
-			}
-			data, err := json.Marshal(check)
-			if err != nil {
-				return nil, err
-			}
-			var specmap map[string]interface{}
-			err = json.Unmarshal(data, &specmap)
-			if err != nil {
-				return nil, err
-			}
-			specmap["probes"] = probeNames
-			resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), check.Job, specmap)
-			if err != nil {
-				return nil, err
-			}
-			resource.SetMetadata("type", h.getType(check))
-			return &resource, nil
+			return h.checkResource(check, probes)
 		}
 	}
 	return nil, grizzly.ErrNotFound
 }
 
-func (h *SyntheticMonitoringHandler) convertProbeNameToID(resource *grizzly.Resource) error {
+// checkResource turns a remote check into a resource, referencing probes by
+// name
+func (h *SyntheticMonitoringHandler) checkResource(check synthetic_monitoring.Check, probes Probes) (*grizzly.Resource, error) {
+	var probeNames []string
+	for _, probeID := range check.Probes {
+		probeNames = append(probeNames, probes.ByID[probeID].Name)
+	}
+	data, err := json.Marshal(check)
+	if err != nil {
+		return nil, err
+	}
+	var specmap map[string]interface{}
+	err = json.Unmarshal(data, &specmap)
+	if err != nil {
+		return nil, err
+	}
+	specmap["probes"] = probeNames
+	resource, err := grizzly.NewResource(h.APIVersion(), h.Kind(), check.Job, specmap)
+	if err != nil {
+		return nil, err
+	}
+	resource.SetMetadata("type", h.getType(check))
+	return &resource, nil
+}
+
+// BulkGetRemote retrieves the remote checks of resources, listing checks and
+// probes once
+func (h *SyntheticMonitoringHandler) BulkGetRemote(resources []grizzly.Resource) ([]*grizzly.Resource, error) {
+	smClient, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(contextOf(h.Provider), 5*time.Second)
+	defer cancel()
+
+	checkList, err := smClient.ListChecks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checks list: %v", err)
+	}
+
 	probes, err := h.getProbeList()
 	if err != nil {
-		return err
+		return nil, err
 	}
+
+	checks := make(map[string]synthetic_monitoring.Check, len(checkList))
+	for _, check := range checkList {
+		checks[h.getUID(check)] = check
+	}
+
+	remotes := make([]*grizzly.Resource, len(resources))
+	for i, resource := range resources {
+		check, found := checks[fmt.Sprintf("%s.%s", resource.GetMetadata("type"), resource.Name())]
+		if !found {
+			continue
+		}
+		if remotes[i], err = h.checkResource(check, probes); err != nil {
+			return nil, err
+		}
+	}
+
+	return remotes, nil
+}
+
+// BulkApply adds or updates checks, listing probes once. The API takes one
+// check per request.
+func (h *SyntheticMonitoringHandler) BulkApply(changes []grizzly.BulkChange) []error {
+	errs := make([]error, len(changes))
+	fail := func(err error) []error {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	smClient, err := h.Provider.(ClientProvider).Client()
+	if err != nil {
+		return fail(err)
+	}
+	probes, err := h.getProbeList()
+	if err != nil {
+		return fail(err)
+	}
+
+	for i, change := range changes {
+		errs[i] = h.writeCheck(smClient, probes, change.Resource, change.Existing == nil)
+	}
+
+	return errs
+}
+
+func (h *SyntheticMonitoringHandler) convertProbeNameToID(resource *grizzly.Resource, probes Probes) {
 	var probeIDs []int64
 
 	for _, probename := range (*resource).GetSpecValue("probes").([]interface{}) {
@@ -230,7 +304,6 @@ func (h *SyntheticMonitoringHandler) convertProbeNameToID(resource *grizzly.Reso
 		probeIDs = append(probeIDs, id)
 	}
 	(*resource).SetSpecValue("probes", probeIDs)
-	return nil
 }
 
 func (h *SyntheticMonitoringHandler) addCheck(resource grizzly.Resource) error {
@@ -239,23 +312,12 @@ func (h *SyntheticMonitoringHandler) addCheck(resource grizzly.Resource) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	err = h.convertProbeNameToID(&resource)
+	probes, err := h.getProbeList()
 	if err != nil {
 		return err
 	}
 
-	theCheck, err := h.SpecToCheck(&resource)
-	if err != nil {
-		return fmt.Errorf("input file is invalid: %v", err)
-	}
-	_, err = smClient.AddCheck(ctx, theCheck)
-	if err != nil {
-		return err
-	}
-	return nil
+	return h.writeCheck(smClient, probes, resource, true)
 }
 
 func (h *SyntheticMonitoringHandler) updateCheck(resource grizzly.Resource) error {
@@ -263,24 +325,32 @@ func (h *SyntheticMonitoringHandler) updateCheck(resource grizzly.Resource) erro
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
 
-	err = h.convertProbeNameToID(&resource)
+	probes, err := h.getProbeList()
 	if err != nil {
 		return err
 	}
 
+	return h.writeCheck(smClient, probes, resource, false)
+}
+
+// writeCheck adds or updates a check, referencing probes by ID
+func (h *SyntheticMonitoringHandler) writeCheck(smClient *smapi.Client, probes Probes, resource grizzly.Resource, add bool) error {
+	ctx, cancel := context.WithTimeout(contextOf(h.Provider), 5*time.Second)
+	defer cancel()
+
+	h.convertProbeNameToID(&resource, probes)
+
 	theCheck, err := h.SpecToCheck(&resource)
 	if err != nil {
 		return fmt.Errorf("input file is invalid: %v", err)
 	}
-	_, err = smClient.UpdateCheck(ctx, theCheck)
-	if err != nil {
-		return err
+	if add {
+		_, err = smClient.AddCheck(ctx, theCheck)
+	} else {
+		_, err = smClient.UpdateCheck(ctx, theCheck)
 	}
-
-	return nil
+	return err
 }
 
 func (h *SyntheticMonitoringHandler) SpecToCheck(r *grizzly.Resource) (synthetic_monitoring.Check, error) {